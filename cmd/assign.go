@@ -2,30 +2,67 @@ package cmd
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
 	"os"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/renan-alm/gh-cost-center/internal/actions"
 	"github.com/renan-alm/gh-cost-center/internal/cache"
+	"github.com/renan-alm/gh-cost-center/internal/config"
 	"github.com/renan-alm/gh-cost-center/internal/customprop"
 	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/ledger"
+	"github.com/renan-alm/gh-cost-center/internal/lock"
+	"github.com/renan-alm/gh-cost-center/internal/plan"
 	"github.com/renan-alm/gh-cost-center/internal/pru"
+	"github.com/renan-alm/gh-cost-center/internal/render"
 	"github.com/renan-alm/gh-cost-center/internal/repository"
+	"github.com/renan-alm/gh-cost-center/internal/runstate"
 	"github.com/renan-alm/gh-cost-center/internal/teams"
 )
 
 var (
 	// assign flags
-	assignMode           string
-	assignYes            bool
-	assignUsers          string
-	assignIncremental    bool
-	assignCreateCC       bool
-	assignCreateBudgets  bool
-	assignCheckCurrentCC bool
+	assignMode             string
+	assignYes              bool
+	assignUsers            string
+	assignIncremental      bool
+	assignCreateCC         bool
+	assignCreateBudgets    bool
+	assignCheckCurrentCC   bool
+	assignReconcileBudgets bool
+	assignExcludeUsers     string
+	assignLimit            int
+	assignResume           string
+	assignPlanOut          string
+	assignPlanFile         string
+	assignPlanMaxAge       time.Duration
+	assignParallel         int
+	assignFailFast         bool
+	assignFailOnUnmapped   bool
+	assignForce            bool
+	assignSaveIDs          bool
+	assignStrictFetch      bool
+)
+
+var (
+	// assignOutputFormat is --output: text, or json for one machine-readable
+	// document on stdout.
+	assignOutputFormat string
+
+	// assignOut collects the --output json document of the current run; nil
+	// with text output.
+	assignOut *assignOutput
 )
 
 var assignCmd = &cobra.Command{
@@ -38,6 +75,8 @@ The mode is determined by cost_center.mode in config.yaml:
   teams:           Assigns users based on GitHub team membership.
   repos:           Assigns repos based on custom property values (explicit mappings).
   custom-prop:     Assigns repos using custom property filters (AND logic).
+  assigning-team:  Assigns Copilot users to a cost center named after the team
+                   that granted their seat.
 
 The --mode flag controls execution:
   plan  - Preview changes without applying (default)
@@ -54,26 +93,117 @@ Examples:
   gh cost-center assign --mode apply --yes --create-cost-centers
 
   # Process only new users since last run (users mode)
-  gh cost-center assign --mode apply --yes --incremental`,
+  gh cost-center assign --mode apply --yes --incremental
+
+  # Save a plan for review, then apply exactly that plan (users mode)
+  gh cost-center assign --mode plan --out plan.json
+  gh cost-center assign --mode apply --plan plan.json
+
+  # One JSON document on stdout for automation; logs stay on stderr
+  # (users, teams, and assigning-team modes)
+  gh cost-center assign --mode apply --yes --output json > result.json`,
 	RunE: runAssign,
 }
 
 func init() {
 	assignCmd.Flags().StringVar(&assignMode, "mode", "plan", "execution mode: plan (preview) or apply (push changes)")
 	assignCmd.Flags().BoolVarP(&assignYes, "yes", "y", false, "skip confirmation prompt in apply mode")
-	assignCmd.Flags().StringVar(&assignUsers, "users", "", "comma-separated list of specific users to process")
+	assignCmd.Flags().StringVar(&assignExcludeUsers, "exclude-users", "", "comma-separated list (or @file) of users to keep out of any changes; merged with cost_center.excluded_users")
+	assignCmd.Flags().IntVar(&assignLimit, "limit", 0, "canary run: process only the first N users by login (users mode; the incremental timestamp is not saved)")
+	assignCmd.Flags().StringVar(&assignResume, "resume", "", "resume an apply run (run ID or \"latest\"), retrying only users not yet assigned (users mode)")
+	assignCmd.Flags().StringVar(&assignPlanOut, "out", "", "write the planned changes to this JSON file (plan mode, users mode)")
+	assignCmd.Flags().StringVar(&assignPlanFile, "plan", "", "apply exactly the changes in this plan file written by --out (apply mode, users mode)")
+	assignCmd.Flags().DurationVar(&assignPlanMaxAge, "plan-max-age", plan.DefaultMaxAge, "refuse --plan files older than this (0 disables the check)")
+	assignCmd.Flags().IntVar(&assignParallel, "parallel", teams.DefaultParallel, "number of teams whose members are fetched at once (teams mode)")
+	assignCmd.Flags().BoolVar(&assignFailFast, "fail-fast", false, "abort on the first team whose members cannot be fetched (teams mode and PRU exception teams)")
+	assignCmd.Flags().BoolVar(&assignStrictFetch, "strict-fetch", false, "abort apply mode when the Copilot seats fetched do not match the total_seats reported by the API (users and assigning-team modes)")
+	assignCmd.Flags().BoolVar(&assignFailOnUnmapped, "fail-on-unmapped", false, "exit non-zero if teams with Copilot seat holders or Copilot users are left unmapped (teams mode)")
+	assignCmd.Flags().StringVarP(&assignOutputFormat, "output", "o", "text", "output format: text, or json for a single JSON document on stdout (users, teams, and assigning-team modes)")
+	assignCmd.Flags().BoolVar(&assignForce, "force", false, "continue even if a mapping references a custom property an organization does not define (repos mode)")
+	assignCmd.Flags().StringVar(&assignUsers, "users", "", "comma-separated list of specific users to process, or @file with one login per line")
 	assignCmd.Flags().BoolVar(&assignIncremental, "incremental", false, "only process users added since last run (users mode)")
 	assignCmd.Flags().BoolVar(&assignCreateCC, "create-cost-centers", false, "create cost centers if they don't exist")
+	assignCmd.Flags().BoolVar(&assignSaveIDs, "save-ids", false, "write the IDs of created cost centers back to the config file, keeping a .bak copy (users mode)")
 	assignCmd.Flags().BoolVar(&assignCreateBudgets, "create-budgets", false, "create budgets for new cost centers")
 	assignCmd.Flags().BoolVar(&assignCheckCurrentCC, "check-current", false, "check current cost center membership before assigning")
+	assignCmd.Flags().BoolVar(&assignReconcileBudgets, "reconcile-budgets", false, "update existing budgets whose amount differs from config (with --create-budgets)")
 
 	rootCmd.AddCommand(assignCmd)
 }
 
 // runAssign dispatches to the appropriate assignment mode based on config.
-func runAssign(cmd *cobra.Command, _ []string) error {
+// Inside GitHub Actions a failed run is also reported as an ::error::
+// annotation.
+func runAssign(cmd *cobra.Command, _ []string) (err error) {
+	defer func() {
+		if err != nil {
+			actions.Error(os.Stderr, "assign failed: "+err.Error())
+		}
+	}()
 	if assignMode != "plan" && assignMode != "apply" {
-		return fmt.Errorf("invalid --mode %q: must be 'plan' or 'apply'", assignMode)
+		return usageErrorf("invalid --mode %q: must be 'plan' or 'apply'", assignMode)
+	}
+	if assignReconcileBudgets && !assignCreateBudgets {
+		slog.Warn("--reconcile-budgets has no effect without --create-budgets")
+	}
+	if assignLimit < 0 {
+		return usageErrorf("invalid --limit %d: must not be negative", assignLimit)
+	}
+	if assignParallel < 1 {
+		return usageErrorf("invalid --parallel %d: must be at least 1", assignParallel)
+	}
+	if assignOutputFormat != "text" && assignOutputFormat != "json" {
+		return usageErrorf("invalid --output %q: must be 'text' or 'json'", assignOutputFormat)
+	}
+	if assignOutputFormat == "json" {
+		switch cfgManager.CostCenterMode {
+		case "repos", "custom-prop":
+			return usageErrorf("--output json is not supported in %s mode", cfgManager.CostCenterMode)
+		}
+		if assignMode == "apply" && !assignYes {
+			return usageErrorf("--output json with --mode apply requires --yes")
+		}
+		stdout, restore, serr := suppressStdout()
+		if serr != nil {
+			return serr
+		}
+		assignOut = newAssignOutput(cfgManager.CostCenterMode, assignMode, time.Now())
+		defer func() {
+			restore()
+			out := assignOut
+			assignOut = nil
+			if werr := out.finish(stdout, err, time.Now()); werr != nil && err == nil {
+				err = werr
+			}
+		}()
+	}
+	if assignExcludeUsers != "" {
+		logins, err := parseLoginList(assignExcludeUsers)
+		if err != nil {
+			return fmt.Errorf("parsing --exclude-users: %w", err)
+		}
+		cfgManager.MergeExcludedUsers(logins)
+	}
+	if assignMode == "apply" {
+		release, err := acquireRunLock(slog.Default())
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	if assignPlanOut != "" || assignPlanFile != "" {
+		if cfgManager.CostCenterMode != "users" {
+			return usageErrorf("--out and --plan are only supported in users mode")
+		}
+		if assignPlanOut != "" && assignMode != "plan" {
+			return usageErrorf("--out requires --mode plan")
+		}
+		if assignPlanFile != "" {
+			if assignMode != "apply" {
+				return usageErrorf("--plan requires --mode apply")
+			}
+			return runPlanApply()
+		}
 	}
 
 	switch cfgManager.CostCenterMode {
@@ -83,6 +213,8 @@ func runAssign(cmd *cobra.Command, _ []string) error {
 		return runRepoAssign(cmd)
 	case "custom-prop":
 		return runCustomPropAssign(cmd)
+	case "assigning-team":
+		return runAssigningTeamAssign(cmd)
 	default:
 		// "users" (PRU) is the default
 		return runPRUAssign(cmd)
@@ -90,10 +222,14 @@ func runAssign(cmd *cobra.Command, _ []string) error {
 }
 
 // attachCache creates a file-based cost center cache and attaches it to the
-// GitHub client.  Errors during cache creation are logged but do not abort
-// the run — the client will simply skip caching.
+// GitHub client unless --no-cache was passed.  Errors during cache creation
+// are logged but do not abort the run — the client will simply skip caching.
 func attachCache(client *github.Client, logger *slog.Logger) {
-	cc, err := cache.New("", logger)
+	if noCache {
+		logger.Debug("Cache disabled by --no-cache")
+		return
+	}
+	cc, err := openCache(logger)
 	if err != nil {
 		logger.Warn("Could not initialise cost center cache, continuing without cache", "error", err)
 		return
@@ -102,6 +238,45 @@ func attachCache(client *github.Client, logger *slog.Logger) {
 	logger.Debug("Cost center cache attached", "path", cc.FilePath())
 }
 
+// openCache opens the enterprise's cache with the configured TTL, first
+// moving a legacy un-namespaced cache into place.
+func openCache(logger *slog.Logger) (*cache.Cache, error) {
+	if err := cfgManager.MigrateLegacyCache(); err != nil {
+		logger.Warn("Could not migrate legacy cache", "error", err)
+	}
+	cc, err := cache.New(cfgManager.CacheDir, logger)
+	if err != nil {
+		return nil, err
+	}
+	cc.SetTTL(cfgManager.CacheTTL)
+	return cc, nil
+}
+
+// acquireRunLock takes the enterprise's apply-run lock so that overlapping
+// runs cannot both mutate cost centers and the incremental timestamp.  With
+// --force-unlock an existing lock is removed first.  The returned function
+// releases the lock.
+func acquireRunLock(logger *slog.Logger) (func(), error) {
+	if forceUnlock {
+		removed, err := lock.ForceUnlock(cfgManager.LockFile)
+		if err != nil {
+			return nil, err
+		}
+		if removed {
+			logger.Warn("Removed existing run lock (--force-unlock)", "path", cfgManager.LockFile)
+		}
+	}
+	l, err := lock.Acquire(cfgManager.LockFile, cfgManager.LockStaleAfter, logger)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		if err := l.Release(); err != nil {
+			logger.Warn("Could not release run lock", "path", cfgManager.LockFile, "error", err)
+		}
+	}, nil
+}
+
 // runPRUAssign implements the default PRU-based assignment flow.
 func runPRUAssign(cmd *cobra.Command) error {
 	logger := slog.Default()
@@ -112,11 +287,27 @@ func runPRUAssign(cmd *cobra.Command) error {
 		cfgManager.EnableAutoCreation()
 	}
 
+	// Load the run being resumed before doing any work.
+	var resumed *runstate.State
+	if assignResume != "" {
+		if assignMode != "apply" {
+			return usageErrorf("--resume requires --mode apply")
+		}
+		var err error
+		if resumed, err = runstate.Load(cfgManager.ExportDir, assignResume); err != nil {
+			return fmt.Errorf("loading run to resume: %w", err)
+		}
+		logger.Info("Resuming run", "run_id", resumed.RunID, "recorded", len(resumed.Results))
+	}
+
 	// Initialize PRU manager.
 	mgr := pru.NewManager(cfgManager, logger)
 
 	// Show configuration.
 	mgr.PrintConfigSummary(cfgManager, autoCreate)
+	if assignCreateBudgets && cfgManager.BudgetsEnabled {
+		printTierBudgetPlan(os.Stdout, cfgManager, mgr.Tiers())
+	}
 
 	// Create GitHub API client.
 	client, err := github.NewClient(cfgManager, logger)
@@ -124,6 +315,9 @@ func runPRUAssign(cmd *cobra.Command) error {
 		return fmt.Errorf("creating GitHub client: %w", err)
 	}
 	attachCache(client, logger)
+	attachAuditLog(client, logger)
+	client.SetReconcileBudgets(assignReconcileBudgets)
+	assignOut.useClient(client)
 
 	// Fetch Copilot users.
 	logger.Info("Fetching Copilot license holders...")
@@ -132,91 +326,178 @@ func runPRUAssign(cmd *cobra.Command) error {
 		return fmt.Errorf("fetching copilot users: %w", err)
 	}
 	logger.Info("Found Copilot license holders", "count", len(users))
+	if err := checkSeatFetch(client); err != nil {
+		return err
+	}
+
+	// Validate --users against all seat holders before incremental filtering.
+	var selected []string
+	if assignUsers != "" {
+		if selected, err = selectedLogins(users, assignUsers); err != nil {
+			return err
+		}
+	}
+
+	// Load the members of teams listed as tier members or PRU exceptions.
+	if err := loadTierTeams(client, mgr, assignFailFast, logger); err != nil {
+		return err
+	}
+
+	// Cross-check the exception list against actual seat holders.
+	unmatchedExceptions := mgr.UnmatchedExceptions(users)
+	if len(unmatchedExceptions) > 0 {
+		logger.Warn("PRU exception users hold no Copilot seat (typo?)",
+			"count", len(unmatchedExceptions),
+			"users", strings.Join(unmatchedExceptions, ", "),
+		)
+	}
+
+	seatHolders := make(map[string]bool, len(users))
+	for _, u := range users {
+		seatHolders[strings.ToLower(u.Login)] = true
+	}
 
-	// Incremental processing: filter to new users since last run.
+	// Drop excluded users before anything is planned.
+	assignOut.skipExcluded(users)
+	users, excluded := excludeUsers(users, logger)
+
+	// Incremental processing: load the processed-users ledger.  Users are
+	// checked against it once their cost centers are resolved, below.
 	originalCount := len(users)
+	var processed *ledger.Ledger
+	var departed []ledger.Departure
 	if assignIncremental {
-		ts, err := cfgManager.LoadLastRunTimestamp()
-		if err != nil {
-			return fmt.Errorf("loading last run timestamp: %w", err)
-		}
-		if ts != nil {
-			users = github.FilterUsersByTimestamp(users, *ts)
-			logger.Info("Incremental mode",
-				"new_users", len(users),
-				"total_users", originalCount,
-				"since", ts.Format("2006-01-02T15:04:05Z"),
-			)
-			if len(users) == 0 {
-				logger.Info("No new users found since last run — nothing to process")
-				if assignMode == "apply" {
-					if err := cfgManager.SaveLastRunTimestamp(nil); err != nil {
-						return fmt.Errorf("saving run timestamp: %w", err)
-					}
-				}
-				return nil
+		var err error
+		if processed, err = ledger.Load(cfgManager.ProcessedUsersFile); err != nil {
+			return err
+		}
+		// Users recorded by an earlier run who hold no seat anymore.  A run
+		// limited by --users or --limit does not look beyond its users.
+		if assignUsers == "" && assignLimit == 0 {
+			departed = departedUsers(processed, seatHolders)
+		}
+		if processed.Len() == 0 {
+			ts, err := cfgManager.LoadLastRunTimestamp(config.TimestampModePRU)
+			if err != nil {
+				return fmt.Errorf("loading last run timestamp: %w", err)
 			}
-		} else {
-			logger.Info("Incremental mode: no previous timestamp found, processing all users")
+			args := []any{"total_users", originalCount}
+			if ts != nil {
+				args = append(args, "last_run", ts.Format("2006-01-02T15:04:05Z"))
+			}
+			logger.Info("Incremental mode: no processed-users ledger yet, processing all users once to build it", args...)
 		}
 	}
 
+	// Filter to specific users if --users flag was provided; combined with
+	// --incremental this is the intersection.
+	if selected != nil {
+		users = filterUsersByLogin(users, selected)
+		logger.Info("Filtered to specified users", "count", len(users))
+	}
+
+	// A plan file needs real cost center IDs, so --out resolves them as apply
+	// would, without creating anything.
+	planOut := assignMode == "plan" && assignPlanOut != ""
+
 	// Auto-create cost centers if requested.
-	if autoCreate {
+	if autoCreate && !planOut {
+		// Configured IDs that already exist are kept as-is; only missing
+		// tiers are created (or resolved) by name.
+		tiers := mgr.Tiers()
+		existing := map[string]bool{}
+		if assignCreateCC {
+			wanted := make(map[string]string, len(tiers))
+			for _, tier := range tiers {
+				wanted[tier.CostCenterID] = tier.CostCenterName
+			}
+			existing = reconcileCostCenterNames(client, wanted, assignMode == "apply", logger)
+		}
 		if assignMode == "plan" {
 			logger.Info("mode=plan: Would create cost centers if they don't exist")
-			logger.Info("mode=plan: Would create", "no_pru", cfgManager.NoPRUsCostCenterName)
-			logger.Info("mode=plan: Would create", "pru_allowed", cfgManager.PRUsAllowedCostCenterName)
+			for _, tier := range tiers {
+				if existing[tier.CostCenterID] {
+					logger.Info("mode=plan: Would use existing", "tier", tier.Name, "cc", tier.CostCenterID)
+				} else {
+					logger.Info("mode=plan: Would create", "tier", tier.Name, "name", tier.CostCenterName)
+				}
+			}
 		} else {
 			logger.Info("Creating cost centers if they don't exist...")
-			noPRUID, pruAllowedID, err := client.EnsureCostCentersExist(
-				cfgManager.NoPRUsCostCenterName,
-				cfgManager.PRUsAllowedCostCenterName,
-			)
-			if err != nil {
-				return fmt.Errorf("creating cost centers: %w", err)
+			created := map[int]string{}
+			for i, tier := range tiers {
+				if existing[tier.CostCenterID] {
+					continue
+				}
+				logger.Info("Ensuring cost center exists", "name", tier.CostCenterName)
+				id, err := client.CreateCostCenter(tier.CostCenterName)
+				if err != nil {
+					return fmt.Errorf("creating cost centers: ensuring cost center %q: %w", tier.CostCenterName, err)
+				}
+				mgr.SetTierCostCenterID(i, id)
+				created[i] = id
+			}
+			if assignSaveIDs || cfgManager.PersistCreatedIDs {
+				if err := saveCreatedIDs(cfgManager, created, logger); err != nil {
+					return err
+				}
 			}
-			// Update IDs in config and manager.
-			cfgManager.NoPRUsCostCenterID = noPRUID
-			cfgManager.PRUsAllowedCostCenterID = pruAllowedID
-			mgr.SetCostCenterIDs(noPRUID, pruAllowedID)
-
-			logger.Info("Updated cost center IDs",
-				"no_pru", noPRUID,
-				"pru_allowed", pruAllowedID,
-			)
 		}
-	} else if assignMode != "plan" {
-		// Without auto-create, resolve names to UUIDs.
-		logger.Info("Resolving cost center names to IDs...")
-		noPRUID, pruAllowedID, err := client.ResolveCostCenters(
-			cfgManager.NoPRUsCostCenterName,
-			cfgManager.PRUsAllowedCostCenterName,
-		)
-		if err != nil {
-			return fmt.Errorf("resolving cost centers: %w", err)
+	} else if assignMode != "plan" || planOut {
+		if err := resolveTierCostCenters(client, mgr, logger); err != nil {
+			return err
 		}
-		cfgManager.NoPRUsCostCenterID = noPRUID
-		cfgManager.PRUsAllowedCostCenterID = pruAllowedID
-		mgr.SetCostCenterIDs(noPRUID, pruAllowedID)
+	}
 
-		logger.Info("Resolved cost center IDs",
-			"no_pru", noPRUID,
-			"pru_allowed", pruAllowedID,
+	// Create per-tier Copilot budgets.
+	if assignMode == "apply" && assignCreateBudgets && cfgManager.BudgetsEnabled {
+		if err := createTierBudgets(client, mgr, logger); err != nil {
+			return err
+		}
+	}
+
+	// Resolve user override cost center names to IDs.  Plan runs look them
+	// up too but never create them.
+	if err := resolveUserOverrides(client, mgr, autoCreate && !planOut, assignMode == "plan", logger); err != nil {
+		return err
+	}
+
+	// Incremental processing: skip the users the ledger records as assigned
+	// to the cost center they should be in now.
+	if processed != nil && processed.Len() > 0 {
+		users = unprocessedUsers(users, processed, mgr)
+		logger.Info("Incremental mode",
+			"to_process", len(users),
+			"total_users", originalCount,
+			"ledger_users", processed.Len(),
 		)
+		if len(users) == 0 && len(departed) == 0 {
+			logger.Info("No new or changed users since the last run — nothing to process")
+			if assignMode == "apply" {
+				if err := cfgManager.SaveLastRunTimestamp(config.TimestampModePRU, nil); err != nil {
+					return fmt.Errorf("saving run timestamp: %w", err)
+				}
+			}
+			return nil
+		}
 	}
 
-	// Filter to specific users if --users flag was provided.
-	if assignUsers != "" {
-		users = filterUsersByLogin(users, assignUsers)
-		logger.Info("Filtered to specified users", "count", len(users))
+	// Canary run: only the first --limit users by login.
+	canary := assignLimit > 0 && assignLimit < len(users)
+	if canary {
+		fmt.Printf("\n*** CANARY RUN: processing %d of %d users ***\n", assignLimit, len(users))
+		users = limitUsers(users, assignLimit)
 	}
 
 	// Build assignment groups.
 	groups := mgr.AssignmentGroups(users)
-
-	pruCount := len(groups[mgr.PRUAllowedCCID()])
-	noPRUCount := len(groups[mgr.NoPRUCCID()])
+	if assignOut != nil {
+		for _, u := range users {
+			if reason := mgr.Explain(u).SkipReason; reason != "" {
+				assignOut.skip(u.Login, reason)
+			}
+		}
+	}
 
 	// Log individual assignments in plan mode.
 	if assignMode == "plan" {
@@ -225,26 +506,109 @@ func runPRUAssign(cmd *cobra.Command) error {
 			cc := mgr.AssignCostCenter(u)
 			logger.Debug("Would assign", "user", u.Login, "cc", cc)
 		}
+		if assignCheckCurrentCC {
+			printMembershipCounts(client, groups, groupNames(mgr), logger)
+		}
 	}
 
 	// Print assignment summary.
-	fmt.Printf("\n=== Assignment Summary ===\n")
-	fmt.Printf("PRUs Allowed (%s): %d users\n", mgr.PRUAllowedCCID(), pruCount)
-	fmt.Printf("No PRUs (%s): %d users\n", mgr.NoPRUCCID(), noPRUCount)
-	fmt.Printf("Total: %d users\n", len(users))
+	p := render.Stdout()
+	p.Println("\n=== Assignment Summary ===")
+	var rows [][]string
+	addRow := func(label string, n int) {
+		rows = append(rows, []string{label + ":", fmt.Sprintf("%d users", n)})
+	}
+	if len(cfgManager.PRUTiers) == 0 {
+		addRow(fmt.Sprintf("PRUs Allowed (%s)", mgr.PRUAllowedCCID()), len(groups[mgr.PRUAllowedCCID()]))
+		addRow(fmt.Sprintf("No PRUs (%s)", mgr.NoPRUCCID()), len(groups[mgr.NoPRUCCID()]))
+	} else {
+		for _, tier := range mgr.Tiers() {
+			addRow(fmt.Sprintf("%s (%s)", tier.Name, tier.CostCenterID), len(groups[tier.CostCenterID]))
+		}
+	}
+	for _, cc := range mgr.OverrideCostCenters() {
+		addRow(fmt.Sprintf("User override (%s)", cc), len(groups[cc]))
+	}
+	addRow("Total", len(users))
+	if excluded > 0 {
+		addRow("Excluded (excluded_users / --exclude-users)", excluded)
+	}
+	p.Table("", rows)
+	if len(departed) > 0 {
+		p.Println(p.Warn(fmt.Sprintf("Seats removed since the last run (%d):", len(departed))))
+		for _, d := range departed {
+			p.Printf("  - %s (cost center %s)\n", d.Login, d.CostCenter)
+		}
+		if !cfgManager.RemoveDepartedUsers {
+			p.Println("  They stay in their cost center; set cost_center.users.remove_departed_users to remove them.")
+		}
+	}
+	if assignMode == "plan" && len(mgr.ExceptionTeams()) > 0 {
+		explicit, fromTeams := mgr.ExceptionSources(users)
+		p.Printf("PRU exceptions: %d from exception_users, %d from exception_teams\n", explicit, fromTeams)
+	}
+	if assignMode == "plan" && len(unmatchedExceptions) > 0 {
+		p.Println(p.Warn(fmt.Sprintf("Exception users without a Copilot seat (%d):", len(unmatchedExceptions))))
+		for _, u := range unmatchedExceptions {
+			p.Println("  " + p.Warn("- "+u))
+		}
+	}
 
 	// Execute assignments.
 	var assignmentResults map[string]map[string]bool
+	var movedUsers map[string]bool
+	var assignErr error
+	var stats pru.RunStats
 
 	if assignMode == "plan" {
 		logger.Info("Would sync full assignment state (plan mode)")
 		for ccID, usernames := range groups {
 			logger.Info("Would add users to cost center", "cc", ccID, "count", len(usernames))
 		}
+		if planOut {
+			if err := writePlanFile(client, mgr, groups, logger); err != nil {
+				return err
+			}
+		}
+		assignOut.planned(groups, nil, groupNames(mgr))
 	} else {
+		// With --check-current only the delta against current membership is
+		// sent; otherwise the full desired state is pushed.
+		toSync := maps.Clone(groups)
+		var diff *membershipDiff
+		if assignCheckCurrentCC || cfgManager.EnforceExclusiveMembership {
+			var err error
+			if diff, err = computeMembershipDiff(client, groups, groupNames(mgr)); err != nil {
+				logger.Warn("Could not fetch current cost center membership, syncing full state without moves", "error", err)
+			}
+		}
+		if diff != nil && assignCheckCurrentCC {
+			printMembershipDelta(render.Stdout(), diff, groupNames(mgr))
+			toSync = diff.adds()
+		}
+
+		// Resume: skip users the previous attempt already assigned.
+		if resumed != nil {
+			toSync, stats.ResumedSkipped = skipSucceeded(toSync, resumed)
+			stats.Resumed = true
+			logger.Info("Skipping users assigned by the resumed run", "run_id", resumed.RunID, "count", stats.ResumedSkipped)
+		}
+
 		// Apply mode — safety confirmation unless --yes.
 		if !assignYes {
-			proceed, err := confirmApply(groups, assignCheckCurrentCC)
+			var removes map[string][]string
+			if diff != nil && cfgManager.EnforceExclusiveMembership {
+				removes = diff.removes()
+			}
+			if cfgManager.RemoveDepartedUsers {
+				if removes == nil {
+					removes = map[string][]string{}
+				}
+				for _, d := range departed {
+					removes[d.CostCenter] = append(removes[d.CostCenter], d.Login)
+				}
+			}
+			proceed, err := confirmApply(applyPrompter, toSync, removes, assignCheckCurrentCC)
 			if err != nil {
 				return fmt.Errorf("confirmation failed: %w", err)
 			}
@@ -255,83 +619,765 @@ func runPRUAssign(cmd *cobra.Command) error {
 		}
 
 		// Remove empty groups.
-		toSync := make(map[string][]string)
-		for cc, names := range groups {
-			if len(names) > 0 {
-				toSync[cc] = names
+		for cc, names := range toSync {
+			if len(names) == 0 {
+				delete(toSync, cc)
+			}
+		}
+
+		// Record every batch so that a failed run can be resumed.
+		recorder, err := startRunState(resumed)
+		if err != nil {
+			return err
+		}
+		stats.RunID = recorder.RunID()
+		client.SetAssignmentRecorder(func(ccID string, results map[string]bool, batchErr error) {
+			if err := recorder.Record(ccID, results, batchErr); err != nil {
+				logger.Warn("Could not record run state", "run_id", recorder.RunID(), "error", err)
+			}
+			if processed != nil {
+				if err := processed.Record(ccID, results); err != nil {
+					logger.Warn("Could not update the processed-users ledger", "file", cfgManager.ProcessedUsersFile, "error", err)
+				}
+			}
+		})
+		logger.Info("Recording apply results", "run_id", recorder.RunID())
+
+		// From here on an interrupt stops the run between batches, so that
+		// the summary and the run state cover everything that was sent.
+		ctx, stopInterrupt := notifyInterrupt(logger)
+		defer stopInterrupt()
+		client.SetContext(ctx)
+
+		// Take users who changed tier out of their old cost center first, so
+		// they are never billed to two cost centers.
+		var moved map[string]bool
+		if diff != nil && cfgManager.EnforceExclusiveMembership {
+			var err error
+			if moved, err = removeMovedUsers(client, diff, logger); err != nil {
+				return err
+			}
+		}
+		movedUsers = moved
+
+		if len(toSync) == 0 {
+			logger.Warn("No users to sync")
+		} else {
+			logger.Info("Applying full assignment state to GitHub Enterprise...")
+			// ignore_current_cost_center is the inverse of --check-current
+			ignoreCurrentCC := !assignCheckCurrentCC
+			results, err := client.BulkUpdateCostCenterAssignments(toSync, ignoreCurrentCC)
+			if err != nil && !errors.Is(err, github.ErrInterrupted) {
+				return fmt.Errorf("applying assignments: %w", err)
+			}
+			assignmentResults = results
+			assignOut.applied(results, nil, groupNames(mgr))
+
+			// Process and log results.  Failures and interruptions still get
+			// a summary below, but the run timestamp is not advanced.
+			assignErr = logAssignmentResults(results, logger)
+			if err != nil {
+				assignErr = interruptedError(recorder.RunID())
+			}
+		}
+
+		// Take users who lost their seat out of their cost center.
+		if cfgManager.RemoveDepartedUsers && len(departed) > 0 {
+			removed, err := removeDepartedUsers(client, departed, processed, logger)
+			stats.DepartedRemoved = removed
+			if err != nil {
+				assignErr = errors.Join(assignErr, err)
+			}
+		}
+
+		// Save timestamp for incremental processing.  A canary run leaves it
+		// alone so the next full run still covers everyone.
+		if canary && assignIncremental {
+			logger.Info("Canary run: not saving the incremental timestamp")
+		}
+		if assignIncremental && assignErr == nil && !canary {
+			if err := cfgManager.SaveLastRunTimestamp(config.TimestampModePRU, nil); err != nil {
+				return fmt.Errorf("saving run timestamp: %w", err)
+			}
+			logger.Info("Saved current timestamp for next incremental run")
+		}
+	}
+
+	// Show success summary.
+	var origPtr *int
+	if assignIncremental {
+		origPtr = &originalCount
+	}
+	stats.Results = assignmentResults
+	stats.Moved = movedUsers
+	stats.Applied = assignMode == "apply"
+	stats.Departed = len(departed)
+	mgr.ShowSuccessSummary(cfgManager, users, origPtr, stats)
+
+	if assignErr != nil {
+		return assignErr
+	}
+	logger.Info("Assign command completed successfully")
+	return nil
+}
+
+// membershipFetchConcurrency bounds the number of simultaneous cost center
+// detail requests made when hydrating current membership.
+const membershipFetchConcurrency = 4
+
+// checkSeatFetch fails an apply run with --strict-fetch when the seats
+// client fetched do not add up to total_seats, so that a partial user list
+// never reaches the API.
+func checkSeatFetch(client *github.Client) error {
+	f := client.SeatFetch()
+	if !f.Mismatch || !assignStrictFetch || assignMode != "apply" {
+		return nil
+	}
+	return fmt.Errorf("--strict-fetch: fetched %d Copilot seats but the API reports total_seats %d; refusing to apply a possibly partial user list", f.Fetched, f.TotalSeats)
+}
+
+// reconcileCostCenterNames renames existing cost centers whose name no longer
+// matches the configured one.  wanted maps configured cost center ID → name;
+// IDs that are not UUIDs (e.g. the config placeholders) are skipped.
+//
+// It returns the set of configured IDs that exist.  Callers keep using those
+// IDs even when the rename fails — in particular on a 409 because another
+// cost center already owns the name — so users are never silently moved to
+// that other cost center.  Failures are logged as warnings so that the rest
+// of the run can continue.
+func reconcileCostCenterNames(client github.CostCenterAPI, wanted map[string]string, apply bool, logger *slog.Logger) map[string]bool {
+	existing := make(map[string]bool, len(wanted))
+	var active map[string]string
+	for id, name := range wanted {
+		if !github.IsValidCostCenterUUID(id) || name == "" {
+			continue
+		}
+		cc, err := client.GetCostCenter(id)
+		if err != nil {
+			if github.IsCostCenterNotFound(err) {
+				logger.Debug("Configured cost center ID not found, skipping rename check", "id", id)
+			} else {
+				logger.Warn("Could not check cost center name", "id", id, "error", err)
+			}
+			continue
+		}
+		existing[id] = true
+		if cc.Name == name {
+			continue
+		}
+		if !apply {
+			if active == nil {
+				if active, err = client.GetAllActiveCostCenters(); err != nil {
+					logger.Warn("Could not list cost centers to check rename conflicts", "error", err)
+					active = map[string]string{}
+				}
+			}
+			if owner, taken := active[name]; taken && owner != id {
+				logger.Warn("mode=plan: Cannot rename cost center: target name is already taken; keeping configured ID",
+					"id", id, "from", cc.Name, "to", name, "owner", owner)
+				continue
+			}
+			logger.Info("mode=plan: Would rename cost center", "id", id, "from", cc.Name, "to", name)
+			continue
+		}
+		if err := client.UpdateCostCenter(id, name); err != nil {
+			if github.IsCostCenterConflict(err) {
+				logger.Warn("Cannot rename cost center: target name is already taken; keeping configured ID",
+					"id", id, "from", cc.Name, "to", name, "error", err)
+			} else {
+				logger.Warn("Failed to rename cost center; keeping configured ID",
+					"id", id, "from", cc.Name, "to", name, "error", err)
+			}
+			continue
+		}
+		logger.Info("Renamed cost center to match config", "id", id, "from", cc.Name, "to", name)
+	}
+	return existing
+}
+
+// resolveTierCostCenters turns tier cost center names into IDs without
+// creating anything.  The legacy two-tier settings are resolved by name;
+// configured tiers keep a UUID cost_center_id and look up the rest by name.
+func resolveTierCostCenters(client github.CostCenterAPI, mgr *pru.Manager, logger *slog.Logger) error {
+	logger.Info("Resolving cost center names to IDs...")
+	if len(cfgManager.PRUTiers) == 0 {
+		noPRUID, pruAllowedID, err := client.ResolveCostCenters(
+			cfgManager.NoPRUsCostCenterName,
+			cfgManager.PRUsAllowedCostCenterName,
+		)
+		if err != nil {
+			return fmt.Errorf("resolving cost centers: %w", err)
+		}
+		cfgManager.NoPRUsCostCenterID = noPRUID
+		cfgManager.PRUsAllowedCostCenterID = pruAllowedID
+		mgr.SetCostCenterIDs(noPRUID, pruAllowedID)
+
+		logger.Info("Resolved cost center IDs",
+			"no_pru", noPRUID,
+			"pru_allowed", pruAllowedID,
+		)
+		return nil
+	}
+
+	var active map[string]string
+	var missing []string
+	for i, tier := range mgr.Tiers() {
+		if github.IsValidCostCenterUUID(tier.CostCenterID) {
+			continue
+		}
+		if active == nil {
+			var err error
+			if active, err = client.GetAllActiveCostCenters(); err != nil {
+				return fmt.Errorf("resolving cost centers: %w", err)
+			}
+		}
+		id, ok := active[tier.CostCenterName]
+		if !ok {
+			missing = append(missing, tier.CostCenterName)
+			continue
+		}
+		mgr.SetTierCostCenterID(i, id)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("resolving cost centers: tier cost centers not found: %s (use --create-cost-centers to create them)",
+			strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// createdIDSettings are the settings that hold the cost center ID of each
+// legacy tier, by tier index (see pru.Manager.Tiers).
+var createdIDSettings = []string{
+	"cost_center.users.prus_allowed_cost_center_id",
+	"cost_center.users.no_prus_cost_center_id",
+}
+
+// saveCreatedIDs writes the IDs of the cost centers created for the legacy
+// tiers, by tier index, back to the config file (--save-ids).  IDs already
+// in the config are left alone; custom tiers have no ID setting to write.
+func saveCreatedIDs(cfg *config.Manager, created map[int]string, logger *slog.Logger) error {
+	if len(cfg.PRUTiers) > 0 {
+		logger.Warn("--save-ids only writes no_prus_cost_center_id and prus_allowed_cost_center_id; ignored with cost_center.users.tiers")
+		return nil
+	}
+	configured := []string{cfg.PRUsAllowedCostCenterID, cfg.NoPRUsCostCenterID}
+	values := map[string]string{}
+	for i, id := range created {
+		if i < len(createdIDSettings) && id != configured[i] {
+			values[createdIDSettings[i]] = id
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	files, err := cfg.SaveSettings(values)
+	if err != nil {
+		return fmt.Errorf("saving created cost center IDs: %w", err)
+	}
+	for _, f := range files {
+		fmt.Printf("Saved created cost center IDs to %s (backup: %s.bak)\n", f, f)
+	}
+	return nil
+}
+
+// loadTierTeams fetches the members of every "org/team-slug" tier member
+// entry and of every PRU exception team.  An exception team whose members
+// cannot be fetched is skipped with a warning unless failFast is set.
+func loadTierTeams(client github.CostCenterAPI, mgr *pru.Manager, failFast bool, logger *slog.Logger) error {
+	for _, team := range mgr.ExceptionTeams() {
+		var members []github.TeamMember
+		var err error
+		if org, slug, ok := strings.Cut(team, "/"); ok {
+			members, err = client.GetOrgTeamMembers(org, slug)
+		} else {
+			members, err = client.GetEnterpriseTeamMembers(team)
+		}
+		if err != nil {
+			if failFast {
+				return fmt.Errorf("fetching members of PRU exception team %s: %w", team, err)
+			}
+			logger.Warn("Could not fetch PRU exception team members; skipping the team", "team", team, "error", err)
+			continue
+		}
+		logins := make([]string, len(members))
+		for i, m := range members {
+			logins[i] = m.Login
+		}
+		mgr.SetTeamMembers(team, logins)
+		logger.Debug("Loaded PRU exception team members", "team", team, "count", len(logins))
+	}
+	for _, team := range mgr.TeamSlugs() {
+		org, slug, _ := strings.Cut(team, "/")
+		members, err := client.GetOrgTeamMembers(org, slug)
+		if err != nil {
+			return fmt.Errorf("fetching members of tier team %s: %w", team, err)
+		}
+		logins := make([]string, len(members))
+		for i, m := range members {
+			logins[i] = m.Login
+		}
+		mgr.SetTeamMembers(team, logins)
+		logger.Debug("Loaded tier team members", "team", team, "count", len(logins))
+	}
+	return nil
+}
+
+// createTierBudgets creates the tierBudgets of every tier.  Tiers whose cost
+// center is not resolved are skipped.
+func createTierBudgets(client github.CostCenterAPI, mgr *pru.Manager, logger *slog.Logger) error {
+	for i, tier := range mgr.Tiers() {
+		if !github.IsValidCostCenterUUID(tier.CostCenterID) {
+			continue
+		}
+		for _, b := range tierBudgets(cfgManager, i, tier) {
+			alerting := github.AlertingFromConfig(cfgManager.BudgetProducts[b.product])
+			if _, err := client.CreateProductBudget(tier.CostCenterID, tier.CostCenterName, b.product, b.amount, alerting); err != nil {
+				return fmt.Errorf("creating %s budget for tier %q: %w", b.product, tier.Name, err)
+			}
+			logger.Info("Ensured tier budget", "tier", tier.Name, "product", b.product, "amount", b.amount)
+		}
+	}
+	return nil
+}
+
+// tierBudget is one budget --create-budgets gives a users-mode cost center.
+type tierBudget struct {
+	product string
+	amount  int
+}
+
+// tierBudgets returns the budgets of tier i, sorted by product: a Copilot
+// budget of its budget_amount, and the products of its
+// budgets.cost_center_overrides entry (by cost center name, or "prus_allowed"
+// and "no_prus" for the two legacy tiers), which win over budget_amount.
+func tierBudgets(cfg *config.Manager, i int, tier pru.Tier) []tierBudget {
+	keys := []string{tier.CostCenterName}
+	if len(cfg.PRUTiers) == 0 && i == 0 {
+		keys = append(keys, config.BudgetKeyPRUsAllowed)
+	} else if len(cfg.PRUTiers) == 0 {
+		keys = append(keys, config.BudgetKeyNoPRUs)
+	}
+	override := cfg.BudgetOverrideFor(keys...)
+	var budgets []tierBudget
+	if _, ok := override["copilot"]; !ok && tier.BudgetAmount > 0 {
+		budgets = append(budgets, tierBudget{"copilot", tier.BudgetAmount})
+	}
+	for product, amount := range override {
+		budgets = append(budgets, tierBudget{product, amount})
+	}
+	slices.SortFunc(budgets, func(a, b tierBudget) int { return strings.Compare(a.product, b.product) })
+	return budgets
+}
+
+// printTierBudgetPlan shows the budgets --create-budgets gives each tier's
+// cost center.
+func printTierBudgetPlan(w io.Writer, cfg *config.Manager, tiers []pru.Tier) {
+	_, _ = fmt.Fprintln(w, "\n===== Budgets =====")
+	for i, tier := range tiers {
+		budgets := tierBudgets(cfg, i, tier)
+		if len(budgets) == 0 {
+			_, _ = fmt.Fprintf(w, "  %s: no budgets\n", tier.CostCenterName)
+			continue
+		}
+		amounts := make([]string, len(budgets))
+		for j, b := range budgets {
+			amounts[j] = fmt.Sprintf("%s $%d", b.product, b.amount)
+		}
+		_, _ = fmt.Fprintf(w, "  %s: %s\n", tier.CostCenterName, strings.Join(amounts, ", "))
+	}
+}
+
+// resolveUserOverrides turns user_overrides cost center names into IDs.
+// UUIDs are used as-is; names are looked up among the active cost centers,
+// or created when autoCreate is set.  A plan run creates nothing: missing
+// names it would create are left unresolved.
+func resolveUserOverrides(client github.CostCenterAPI, mgr *pru.Manager, autoCreate, plan bool, logger *slog.Logger) error {
+	var names []string
+	for _, cc := range mgr.OverrideCostCenters() {
+		if !github.IsValidCostCenterUUID(cc) {
+			names = append(names, cc)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	active, err := client.GetAllActiveCostCenters()
+	if err != nil {
+		return fmt.Errorf("resolving user override cost centers: %w", err)
+	}
+	resolved := make(map[string]string, len(names))
+	var missing []string
+	for _, name := range names {
+		if id, ok := active[name]; ok {
+			resolved[name] = id
+			continue
+		}
+		if !autoCreate {
+			missing = append(missing, name)
+			continue
+		}
+		if plan {
+			logger.Info("mode=plan: Would create user override cost center", "name", name)
+			continue
+		}
+		id, err := client.CreateCostCenter(name)
+		if err != nil {
+			return fmt.Errorf("creating user override cost center %q: %w", name, err)
+		}
+		resolved[name] = id
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("user override cost centers not found: %s (use --create-cost-centers to create them)",
+			strings.Join(missing, ", "))
+	}
+
+	mgr.SetOverrideIDs(resolved)
+	logger.Info("Resolved user override cost centers", "count", len(resolved))
+	return nil
+}
+
+// membershipDiff summarises how the desired groups compare with the current
+// membership of their target cost centers.
+type membershipDiff struct {
+	correct int // already in the desired cost center
+	toAdd   int // not in any target cost center yet
+	skipped int // in another target cost center; left alone by --check-current
+
+	perCC map[string]*ccDelta // group key -> delta
+}
+
+// ccDelta is the change needed for one target cost center.
+type ccDelta struct {
+	id        string   // resolved cost center UUID; "" if it does not exist yet
+	add       []string // desired members not currently in it
+	remove    []string // processed users in it who belong to another cost center
+	unchanged int
+}
+
+// adds returns the users to add per group key, i.e. what apply has to send.
+func (d *membershipDiff) adds() map[string][]string {
+	out := make(map[string][]string, len(d.perCC))
+	for key, cc := range d.perCC {
+		if len(cc.add) > 0 {
+			out[key] = cc.add
+		}
+	}
+	return out
+}
+
+// removes returns the users to take out of each target cost center because
+// they belong to another one.  Keys are cost center UUIDs.
+func (d *membershipDiff) removes() map[string][]string {
+	out := make(map[string][]string)
+	for _, cc := range d.perCC {
+		if len(cc.remove) > 0 && cc.id != "" {
+			out[cc.id] = cc.remove
+		}
+	}
+	return out
+}
+
+// empty reports whether the diff needs no changes at all.
+func (d *membershipDiff) empty() bool {
+	for _, cc := range d.perCC {
+		if len(cc.add) > 0 || len(cc.remove) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// groupNames maps each assignment group key (tier and override cost centers)
+// to its cost center name.
+func groupNames(mgr *pru.Manager) map[string]string {
+	names := make(map[string]string)
+	for _, tier := range mgr.Tiers() {
+		names[tier.CostCenterID] = tier.CostCenterName
+	}
+	for _, cc := range mgr.OverrideCostCenters() {
+		names[cc] = cc
+	}
+	return names
+}
+
+// printMembershipCounts compares the desired groups against the current
+// membership of each target cost center and prints only the delta: per cost
+// center adds, removes, and the unchanged count.  names maps each group key to
+// its cost center name so unresolved keys (plan mode) can be looked up
+// read-only.
+func printMembershipCounts(client github.CostCenterAPI, groups map[string][]string, names map[string]string, logger *slog.Logger) {
+	diff, err := computeMembershipDiff(client, groups, names)
+	if err != nil {
+		logger.Warn("mode=plan: could not fetch current cost center membership", "error", err)
+		return
+	}
+	if diff == nil {
+		logger.Info("mode=plan: cost centers do not exist yet, skipping current membership check")
+		return
+	}
+	fmt.Printf("Current state: %d users already correctly assigned, %d to add, %d in another cost center (skipped)\n",
+		diff.correct, diff.toAdd, diff.skipped)
+	printMembershipDelta(render.Stdout(), diff, names)
+}
+
+// printMembershipDelta prints the per-cost-center changes of diff with p, or
+// an explicit "nothing to do" line when the state already matches: a table of
+// the counts, then the users added (green) and removed (red) per cost center.
+func printMembershipDelta(p *render.Printer, diff *membershipDiff, names map[string]string) {
+	if diff.empty() {
+		p.Println("Nothing to do: current cost center membership already matches the plan.")
+		return
+	}
+	keys := make([]string, 0, len(diff.perCC))
+	for key := range diff.perCC {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var rows [][]string
+	var changed []string
+	for _, key := range keys {
+		cc := diff.perCC[key]
+		if len(cc.add) == 0 && len(cc.remove) == 0 {
+			continue
+		}
+		changed = append(changed, key)
+		rows = append(rows, []string{
+			groupLabel(key, names) + ":",
+			p.Add(fmt.Sprintf("+%d", len(cc.add))),
+			p.Remove(fmt.Sprintf("-%d", len(cc.remove))),
+			fmt.Sprintf("%d unchanged", cc.unchanged),
+		})
+	}
+
+	p.Println("Changes against current membership:")
+	p.Table("  ", rows)
+	for _, key := range changed {
+		cc := diff.perCC[key]
+		p.Printf("\n  %s:\n", groupLabel(key, names))
+		for _, u := range cc.add {
+			p.Println("    " + p.Add("+ "+u))
+		}
+		for _, u := range cc.remove {
+			p.Println("    " + p.Remove("- "+u))
+		}
+	}
+}
+
+// groupLabel returns "name (id)" for a cost center key, or the bare key when
+// names has no distinct name for it.
+func groupLabel(key string, names map[string]string) string {
+	if name := names[key]; name != "" && name != key {
+		return fmt.Sprintf("%s (%s)", name, key)
+	}
+	return key
+}
+
+// computeMembershipDiff resolves each group key to a cost center UUID (by
+// name via GetAllActiveCostCenters when the key is not a UUID), fetches the
+// current members, and diffs only the users in groups.  Members outside the
+// processed users are ignored because apply never removes anyone else.  It
+// returns nil when none of the target cost centers exist.
+func computeMembershipDiff(client github.CostCenterAPI, groups map[string][]string, names map[string]string) (*membershipDiff, error) {
+	resolved := make(map[string]string, len(groups)) // group key -> UUID
+	var active map[string]string
+	for key := range groups {
+		if github.IsValidCostCenterUUID(key) {
+			resolved[key] = key
+			continue
+		}
+		if active == nil {
+			var err error
+			if active, err = client.GetAllActiveCostCenters(); err != nil {
+				return nil, fmt.Errorf("fetching active cost centers: %w", err)
 			}
 		}
+		if id, ok := active[names[key]]; ok {
+			resolved[key] = id
+		}
+	}
+	if len(resolved) == 0 {
+		return nil, nil
+	}
 
-		if len(toSync) == 0 {
-			logger.Warn("No users to sync")
-		} else {
-			logger.Info("Applying full assignment state to GitHub Enterprise...")
-			// ignore_current_cost_center is the inverse of --check-current
-			ignoreCurrentCC := !assignCheckCurrentCC
-			results, err := client.BulkUpdateCostCenterAssignments(toSync, ignoreCurrentCC)
-			if err != nil {
-				return fmt.Errorf("applying assignments: %w", err)
-			}
-			assignmentResults = results
+	ids := make([]string, 0, len(resolved))
+	for _, id := range resolved {
+		ids = append(ids, id)
+	}
+	current, err := client.GetCostCentersUsers(ids, membershipFetchConcurrency)
+	if err != nil {
+		return nil, err
+	}
 
-			// Process and log results.
-			if err := logAssignmentResults(results, logger); err != nil {
-				return err
+	memberOf := make(map[string]string) // lower-case login -> UUID
+	for id, users := range current {
+		for _, u := range users {
+			memberOf[strings.ToLower(u)] = id
+		}
+	}
+
+	diff := &membershipDiff{perCC: make(map[string]*ccDelta, len(groups))}
+	keyOf := make(map[string]string, len(resolved)) // UUID -> group key
+	for key := range groups {
+		diff.perCC[key] = &ccDelta{id: resolved[key]}
+		if id := resolved[key]; id != "" {
+			keyOf[id] = key
+		}
+	}
+	for key, users := range groups {
+		want := resolved[key]
+		for _, u := range users {
+			have, ok := memberOf[strings.ToLower(u)]
+			switch {
+			case !ok:
+				diff.toAdd++
+			case have == want:
+				diff.correct++
+				diff.perCC[key].unchanged++
+				continue
+			default:
+				diff.skipped++
+				if from, tracked := keyOf[have]; tracked {
+					diff.perCC[from].remove = append(diff.perCC[from].remove, u)
+				}
 			}
+			diff.perCC[key].add = append(diff.perCC[key].add, u)
 		}
+	}
+	for _, cc := range diff.perCC {
+		sort.Strings(cc.add)
+		sort.Strings(cc.remove)
+	}
+	return diff, nil
+}
 
-		// Save timestamp for incremental processing.
-		if assignIncremental {
-			if err := cfgManager.SaveLastRunTimestamp(nil); err != nil {
-				return fmt.Errorf("saving run timestamp: %w", err)
+// removeMovedUsers removes every processed user from the target cost center
+// they are leaving (see ccDelta.remove) and returns the lower-cased logins
+// that were removed.
+func removeMovedUsers(client github.CostCenterAPI, diff *membershipDiff, logger *slog.Logger) (map[string]bool, error) {
+	keys := make([]string, 0, len(diff.perCC))
+	for key := range diff.perCC {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	moved := make(map[string]bool)
+	for _, key := range keys {
+		cc := diff.perCC[key]
+		if len(cc.remove) == 0 || cc.id == "" {
+			continue
+		}
+		logger.Info("Removing users who moved to another cost center", "cc", cc.id, "count", len(cc.remove))
+		results, err := client.RemoveUsersFromCostCenter(cc.id, cc.remove)
+		if err != nil {
+			return nil, fmt.Errorf("removing moved users from cost center %s: %w", cc.id, err)
+		}
+		assignOut.applied(nil, map[string]map[string]bool{cc.id: results}, nil)
+		for login, ok := range results {
+			if ok {
+				moved[strings.ToLower(login)] = true
 			}
-			logger.Info("Saved current timestamp for next incremental run")
 		}
 	}
+	return moved, nil
+}
 
-	// Show success summary.
-	var origPtr *int
-	if assignIncremental {
-		origPtr = &originalCount
+// departedUsers returns the users processed records who are not in
+// seatHolders (lower-cased logins), leaving out excluded users, which are
+// never removed.
+func departedUsers(processed *ledger.Ledger, seatHolders map[string]bool) []ledger.Departure {
+	var out []ledger.Departure
+	for _, d := range processed.Departed(seatHolders) {
+		if !cfgManager.IsExcludedUser(d.Login) {
+			out = append(out, d)
+		}
 	}
-	pru.ShowSuccessSummary(cfgManager, users, origPtr, assignmentResults, assignMode == "apply")
+	return out
+}
 
-	logger.Info("Assign command completed successfully")
-	return nil
+// removeDepartedUsers removes the departed users from the cost center the
+// ledger last recorded for them and forgets those removed, so they are not
+// reported again.  It returns how many were removed.
+func removeDepartedUsers(client github.CostCenterAPI, departed []ledger.Departure, processed *ledger.Ledger, logger *slog.Logger) (int, error) {
+	byCC := map[string][]string{}
+	for _, d := range departed {
+		byCC[d.CostCenter] = append(byCC[d.CostCenter], d.Login)
+	}
+	removed := 0
+	var errs []error
+	for _, cc := range slices.Sorted(maps.Keys(byCC)) {
+		logger.Info("Removing users who lost their Copilot seat", "cc", cc, "count", len(byCC[cc]))
+		results, err := client.RemoveUsersFromCostCenter(cc, byCC[cc])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("removing departed users from cost center %s: %w", cc, err))
+			continue
+		}
+		assignOut.applied(nil, map[string]map[string]bool{cc: results}, nil)
+		var gone []string
+		for login, ok := range results {
+			if ok {
+				gone = append(gone, login)
+			}
+		}
+		removed += len(gone)
+		if err := processed.Forget(gone); err != nil {
+			logger.Warn("Could not update the processed-users ledger", "file", cfgManager.ProcessedUsersFile, "error", err)
+		}
+	}
+	return removed, errors.Join(errs...)
 }
 
-// confirmApply shows a confirmation prompt and returns true if the user types "yes".
-// It returns an error if reading from stdin fails.
-func confirmApply(groups map[string][]string, checkCurrent bool) (bool, error) {
+// confirmApply shows the planned changes — per cost center, how many users
+// will be added and removed — and asks for confirmation via p.  Anything but
+// y/yes aborts; a non-interactive p fails with errNotInteractive.
+func confirmApply(p prompter, adds, removes map[string][]string, checkCurrent bool) (bool, error) {
 	fmt.Println("\nYou are about to APPLY cost center assignments to GitHub Enterprise.")
-	fmt.Println("This will push assignments for ALL processed users (no diff).")
 
 	if checkCurrent {
+		fmt.Println("Only users missing from their cost center will be pushed.")
 		fmt.Println("Current cost center membership will be checked — users in other cost centers will be SKIPPED.")
 	} else {
+		fmt.Println("This will push assignments for ALL processed users (no diff).")
 		fmt.Println("Fast mode: Users will be assigned WITHOUT checking current cost center membership.")
 	}
 
-	fmt.Println("Summary:")
-	for ccID, usernames := range groups {
-		fmt.Printf("  - %s: %d users\n", ccID, len(usernames))
+	ccs := make([]string, 0, len(adds)+len(removes))
+	for cc := range adds {
+		ccs = append(ccs, cc)
 	}
-
-	fmt.Print("\nProceed? (yes/no): ")
-	scanner := bufio.NewScanner(os.Stdin)
-	if scanner.Scan() {
-		return strings.TrimSpace(strings.ToLower(scanner.Text())) == "yes", nil
+	for cc := range removes {
+		if _, ok := adds[cc]; !ok {
+			ccs = append(ccs, cc)
+		}
 	}
-	if err := scanner.Err(); err != nil {
-		return false, fmt.Errorf("reading user input: %w", err)
+	sort.Strings(ccs)
+
+	fmt.Println("Planned changes:")
+	for _, cc := range ccs {
+		fmt.Printf("  - %s: +%d / -%d users\n", cc, len(adds[cc]), len(removes[cc]))
 	}
-	return false, nil
+
+	return askYesNo(p, "Proceed with assignment?")
+}
+
+// PartialFailureError reports an apply run in which some users could not be
+// assigned.  It exits with exitCodePartial.
+type PartialFailureError struct {
+	Failed int // users whose assignment failed
+	Total  int // users attempted
+}
+
+func (e *PartialFailureError) Error() string {
+	return fmt.Sprintf("assignment incomplete: %d/%d users failed", e.Failed, e.Total)
 }
 
 // logAssignmentResults logs per-cost-center and overall success/failure counts.
-// It returns an error when one or more user assignments failed so the caller
-// can propagate a non-zero exit code.
+// It returns a *PartialFailureError when one or more user assignments failed
+// so that the run exits with exitCodePartial.
 func logAssignmentResults(results map[string]map[string]bool, logger *slog.Logger) error {
+	writeAssignStepSummary(results, logger)
+
 	totalAttempted := 0
 	totalSuccessful := 0
 	totalFailed := 0
@@ -375,7 +1421,7 @@ func logAssignmentResults(results map[string]map[string]bool, logger *slog.Logge
 			"total", totalAttempted,
 			"failed", totalFailed,
 		)
-		return fmt.Errorf("assignment incomplete: %d/%d users failed", totalFailed, totalAttempted)
+		return &PartialFailureError{Failed: totalFailed, Total: totalAttempted}
 	}
 
 	logger.Info("All users successfully assigned",
@@ -384,6 +1430,187 @@ func logAssignmentResults(results map[string]map[string]bool, logger *slog.Logge
 	return nil
 }
 
+// writeAssignStepSummary appends per-cost-center assignment counts and the
+// failed users to the GitHub Actions step summary, and emits an ::error::
+// annotation per cost center with failures.  Outside Actions it does nothing.
+func writeAssignStepSummary(results map[string]map[string]bool, logger *slog.Logger) {
+	if !actions.Running() {
+		return
+	}
+	mode := cfgManager.CostCenterMode
+	if mode == "" {
+		mode = "users"
+	}
+
+	ccIDs := slices.Sorted(maps.Keys(results))
+	var rows [][]string
+	var failures []string
+	totalFailed := 0
+	for _, ccID := range ccIDs {
+		var failed []string
+		for login, ok := range results[ccID] {
+			if !ok {
+				failed = append(failed, login)
+			}
+		}
+		sort.Strings(failed)
+		totalFailed += len(failed)
+		rows = append(rows, []string{ccID, strconv.Itoa(len(results[ccID]) - len(failed)), strconv.Itoa(len(failed))})
+		if len(failed) > 0 {
+			failures = append(failures, fmt.Sprintf("- `%s`: %s", ccID, strings.Join(failed, ", ")))
+			actions.Error(os.Stderr, fmt.Sprintf("Cost center %s: %d users failed to assign: %s", ccID, len(failed), strings.Join(failed, ", ")))
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Cost center assignment (%s mode)\n\n", mode)
+	b.WriteString(actions.Table([]string{"Cost center", "Assigned", "Failed"}, rows))
+	if totalFailed > 0 {
+		fmt.Fprintf(&b, "\n### Failures (%d)\n\n%s\n", totalFailed, strings.Join(failures, "\n"))
+	} else {
+		b.WriteString("\nAll users were assigned successfully.\n")
+	}
+	if err := actions.AppendSummary(b.String()); err != nil {
+		logger.Warn("Could not write the step summary", "error", err)
+	}
+}
+
+// writePlanFile writes the changes apply would make to --out.  Adds are the
+// delta against current membership with --check-current and the full groups
+// otherwise; removes are the users enforce_exclusive_membership moves out.
+func writePlanFile(client github.CostCenterAPI, mgr *pru.Manager, groups map[string][]string, logger *slog.Logger) error {
+	names := groupNames(mgr)
+	adds := groups
+	var removes map[string][]string
+	if assignCheckCurrentCC || cfgManager.EnforceExclusiveMembership {
+		diff, err := computeMembershipDiff(client, groups, names)
+		if err != nil {
+			return fmt.Errorf("computing plan: %w", err)
+		}
+		if assignCheckCurrentCC {
+			adds = diff.adds()
+		}
+		if cfgManager.EnforceExclusiveMembership {
+			removes = diff.removes()
+		}
+	}
+
+	ids := slices.Sorted(maps.Keys(adds))
+	for id := range removes {
+		if _, ok := adds[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	p := plan.New(cfgManager.CostCenterMode, cfgManager.Enterprise, cfgManager.ConfigHash())
+	p.CheckCurrent = assignCheckCurrentCC
+	totalAdd, totalRemove := 0, 0
+	for _, id := range ids {
+		if len(adds[id]) == 0 && len(removes[id]) == 0 {
+			continue
+		}
+		change := plan.CostCenterChange{
+			ID:     id,
+			Name:   names[id],
+			Add:    sortedLogins(adds[id]),
+			Remove: sortedLogins(removes[id]),
+		}
+		totalAdd += len(change.Add)
+		totalRemove += len(change.Remove)
+		p.CostCenters = append(p.CostCenters, change)
+	}
+
+	if err := plan.Write(assignPlanOut, p); err != nil {
+		return err
+	}
+	fmt.Printf("\nPlan written to %s: %d cost centers, +%d / -%d users\n",
+		assignPlanOut, len(p.CostCenters), totalAdd, totalRemove)
+	logger.Info("Wrote plan file", "path", assignPlanOut, "config_hash", p.ConfigHash)
+	return nil
+}
+
+// sortedLogins returns a sorted copy of logins, never nil so that plan files
+// always show a list.
+func sortedLogins(logins []string) []string {
+	out := append([]string{}, logins...)
+	sort.Strings(out)
+	return out
+}
+
+// runPlanApply applies a plan file written by --out: exactly its removes and
+// adds, and nothing else.  The plan is refused when it was made for another
+// mode, enterprise, or configuration, or is older than --plan-max-age.
+func runPlanApply() error {
+	logger := slog.Default()
+
+	p, err := plan.Read(assignPlanFile)
+	if err != nil {
+		return err
+	}
+	if err := p.Check(cfgManager.CostCenterMode, cfgManager.Enterprise, cfgManager.ConfigHash(), assignPlanMaxAge, time.Now()); err != nil {
+		return fmt.Errorf("refusing plan %s: %w", assignPlanFile, err)
+	}
+	logger.Info("Applying plan file",
+		"path", assignPlanFile,
+		"created_at", p.CreatedAt.Format(time.RFC3339),
+		"cost_centers", len(p.CostCenters),
+	)
+
+	adds, removes := p.Adds(), p.Removes()
+	if !assignYes {
+		proceed, err := confirmApply(applyPrompter, adds, removes, p.CheckCurrent)
+		if err != nil {
+			return fmt.Errorf("confirmation failed: %w", err)
+		}
+		if !proceed {
+			logger.Warn("Aborted by user before applying assignments")
+			return nil
+		}
+	}
+
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	attachCache(client, logger)
+	attachAuditLog(client, logger)
+	assignOut.useClient(client)
+
+	names := make(map[string]string, len(p.CostCenters))
+	for _, cc := range p.CostCenters {
+		names[cc.ID] = cc.Name
+	}
+
+	// Removes first, as in a normal apply, so nobody is in two cost centers.
+	for _, cc := range p.CostCenters {
+		if len(cc.Remove) == 0 {
+			continue
+		}
+		logger.Info("Removing users who moved to another cost center", "cc", cc.ID, "count", len(cc.Remove))
+		removed, err := client.RemoveUsersFromCostCenter(cc.ID, cc.Remove)
+		if err != nil {
+			return fmt.Errorf("removing moved users from cost center %s: %w", cc.ID, err)
+		}
+		assignOut.applied(nil, map[string]map[string]bool{cc.ID: removed}, names)
+	}
+
+	if len(adds) == 0 {
+		logger.Info("Plan has no users to add")
+		return nil
+	}
+	results, err := client.BulkUpdateCostCenterAssignments(adds, !p.CheckCurrent)
+	if err != nil {
+		return fmt.Errorf("applying assignments: %w", err)
+	}
+	assignOut.applied(results, nil, names)
+	if err := logAssignmentResults(results, logger); err != nil {
+		return err
+	}
+	logger.Info("Plan applied successfully")
+	return nil
+}
+
 // runTeamsAssign implements the teams-based assignment flow.
 func runTeamsAssign(_ *cobra.Command) error {
 	logger := slog.Default()
@@ -394,6 +1621,9 @@ func runTeamsAssign(_ *cobra.Command) error {
 		return fmt.Errorf("creating GitHub client: %w", err)
 	}
 	attachCache(client, logger)
+	attachAuditLog(client, logger)
+	client.SetReconcileBudgets(assignReconcileBudgets)
+	assignOut.useClient(client)
 
 	// Enable auto-creation if flag was passed.
 	if assignCreateCC {
@@ -402,6 +1632,7 @@ func runTeamsAssign(_ *cobra.Command) error {
 
 	// Initialize teams manager.
 	mgr := teams.NewManager(cfgManager, client, logger)
+	mgr.SetMemberFetchOptions(assignParallel, assignFailFast)
 
 	// Wire budget creation if requested.
 	if assignCreateBudgets && cfgManager.BudgetsEnabled {
@@ -410,28 +1641,145 @@ func runTeamsAssign(_ *cobra.Command) error {
 
 	// Show configuration.
 	mgr.PrintConfigSummary(assignCheckCurrentCC, assignCreateBudgets)
+	if assignCreateBudgets && cfgManager.BudgetsEnabled {
+		printBudgetPlan(os.Stdout, cfgManager.BudgetProducts, cfgManager.BudgetCostCenterOverrides)
+	}
+
+	confirmSync(mgr)
 
 	// Sync assignments (plan or apply).
 	ignoreCurrentCC := !assignCheckCurrentCC
 	results, err := mgr.SyncTeamAssignments(assignMode, ignoreCurrentCC)
+	if errors.Is(err, teams.ErrAborted) {
+		logger.Warn("Aborted by user before applying assignments")
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("syncing team assignments: %w", err)
 	}
+	assignOut.teamsSync(mgr.LastSync())
+	if assignMode == "plan" {
+		mgr.PrintTeamFilter()
+		mgr.PrintNonCopilotSkipped()
+		mgr.PrintConflicts()
+	}
+	var unmapped *teams.Unmapped
+	if assignMode == "plan" || assignFailOnUnmapped {
+		if unmapped, err = mgr.FindUnmapped(); err != nil {
+			return fmt.Errorf("finding unmapped teams and users: %w", err)
+		}
+		unmapped.Print()
+	}
+	if results != nil {
+		if err := logAssignmentResults(results, logger); err != nil {
+			return err
+		}
+	}
+	if failed := mgr.FailedTeams(); len(failed) > 0 {
+		return fmt.Errorf("could not fetch members of %d teams (skipped): %s", len(failed), strings.Join(failed, ", "))
+	}
+	if err := unmappedError(unmapped, assignFailOnUnmapped); err != nil {
+		return err
+	}
 
-	if assignMode == "apply" {
-		if !assignYes && results == nil {
-			// In apply mode without --yes, SyncTeamAssignments would have
-			// already applied.  Log completion.
-			logger.Info("Teams assignment completed")
+	logger.Info("Teams assign command completed successfully")
+	return nil
+}
+
+// confirmSync makes an apply run of mgr without --yes list the planned adds
+// per cost center and ask for confirmation before changing anything.
+func confirmSync(mgr *teams.Manager) {
+	if assignMode != "apply" || assignYes {
+		return
+	}
+	mgr.SetConfirm(func(adds map[string][]string) (bool, error) {
+		return confirmApply(applyPrompter, adds, nil, assignCheckCurrentCC)
+	})
+}
+
+// unmappedError returns an error for --fail-on-unmapped when anything was
+// left unmapped.
+func unmappedError(unmapped *teams.Unmapped, failOnUnmapped bool) error {
+	if !failOnUnmapped || unmapped == nil || unmapped.Count() == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d teams with Copilot seat holders and %d Copilot users are unmapped (--fail-on-unmapped)",
+		len(unmapped.Teams), len(unmapped.Users))
+}
+
+// runAssigningTeamAssign implements the assigning-team flow: Copilot users are
+// grouped by the team that granted their seat.
+func runAssigningTeamAssign(_ *cobra.Command) error {
+	logger := slog.Default()
+
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	attachCache(client, logger)
+	attachAuditLog(client, logger)
+	client.SetReconcileBudgets(assignReconcileBudgets)
+	assignOut.useClient(client)
+
+	if assignCreateCC {
+		cfgManager.AssigningTeamAutoCreate = true
+	}
+
+	mgr := teams.NewAssigningTeamManager(cfgManager, client, logger)
+	if assignCreateBudgets && cfgManager.BudgetsEnabled {
+		mgr.SetBudgetConfig(true, cfgManager.BudgetProducts)
+	}
+	mgr.PrintAssigningTeamConfigSummary(assignCheckCurrentCC, assignCreateBudgets)
+	if assignCreateBudgets && cfgManager.BudgetsEnabled {
+		printBudgetPlan(os.Stdout, cfgManager.BudgetProducts, cfgManager.BudgetCostCenterOverrides)
+	}
+
+	logger.Info("Fetching Copilot license holders...")
+	users, err := client.GetCopilotUsers()
+	if err != nil {
+		return fmt.Errorf("fetching copilot users: %w", err)
+	}
+	logger.Info("Found Copilot license holders", "count", len(users))
+	if err := checkSeatFetch(client); err != nil {
+		return err
+	}
+	assignOut.skipExcluded(users)
+	users, _ = excludeUsers(users, logger)
+
+	if assignUsers != "" {
+		logins, err := selectedLogins(users, assignUsers)
+		if err != nil {
+			return err
 		}
-		if results != nil {
-			if err := logAssignmentResults(results, logger); err != nil {
-				return err
+		users = filterUsersByLogin(users, logins)
+		logger.Info("Filtered to specified users", "count", len(users))
+	}
+
+	confirmSync(mgr)
+
+	results, err := mgr.SyncAssigningTeamAssignments(users, assignMode, !assignCheckCurrentCC)
+	if errors.Is(err, teams.ErrAborted) {
+		logger.Warn("Aborted by user before applying assignments")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("syncing assigning-team assignments: %w", err)
+	}
+	if assignOut != nil && !cfgManager.IncludeNonUserAccounts {
+		for _, u := range users {
+			if !github.IsUserAccount(u.Type) {
+				assignOut.skip(u.Login, pru.SkippedNonUser)
 			}
 		}
 	}
+	assignOut.teamsSync(mgr.LastSync())
+	if results != nil {
+		if err := logAssignmentResults(results, logger); err != nil {
+			return err
+		}
+	}
 
-	logger.Info("Teams assign command completed successfully")
+	logger.Info("Assigning-team assign command completed successfully")
 	return nil
 }
 
@@ -439,16 +1787,18 @@ func runTeamsAssign(_ *cobra.Command) error {
 func runRepoAssign(_ *cobra.Command) error {
 	logger := slog.Default()
 
-	if len(cfgManager.Organizations) == 0 {
-		return fmt.Errorf("repos mode requires at least one organization in github.organizations config")
+	if len(cfgManager.ReposOrganizations) == 0 {
+		return fmt.Errorf("repos mode requires at least one organization in cost_center.repos.organizations or github.organizations config")
 	}
-	org := cfgManager.Organizations[0]
+	orgs := cfgManager.ReposOrganizations
 
 	client, err := github.NewClient(cfgManager, logger)
 	if err != nil {
 		return fmt.Errorf("creating GitHub client: %w", err)
 	}
 	attachCache(client, logger)
+	attachAuditLog(client, logger)
+	client.SetReconcileBudgets(assignReconcileBudgets)
 
 	mgr, err := repository.NewManager(cfgManager, client, logger)
 	if err != nil {
@@ -462,7 +1812,25 @@ func runRepoAssign(_ *cobra.Command) error {
 		return fmt.Errorf("invalid repository configuration: %d issues found", len(issues))
 	}
 
-	mgr.PrintConfigSummary(org)
+	problems, err := mgr.CheckPropertySchema(orgs)
+	if err != nil {
+		return fmt.Errorf("checking custom property schema: %w", err)
+	}
+	for _, problem := range problems {
+		if assignForce {
+			logger.Warn("Custom property issue (continuing because of --force)", "detail", problem)
+		} else {
+			logger.Error("Custom property issue", "detail", problem)
+		}
+	}
+	if len(problems) > 0 && !assignForce {
+		return fmt.Errorf("%d custom property issues found (use --force to continue anyway)", len(problems))
+	}
+
+	mgr.PrintConfigSummary(orgs...)
+	if assignCreateBudgets && cfgManager.BudgetsEnabled {
+		printBudgetPlan(os.Stdout, cfgManager.BudgetProducts, cfgManager.BudgetCostCenterOverrides)
+	}
 
 	// Confirmation in apply mode.
 	if assignMode == "apply" && !assignYes {
@@ -482,13 +1850,13 @@ func runRepoAssign(_ *cobra.Command) error {
 	}
 
 	createBudgets := assignCreateBudgets && cfgManager.BudgetsEnabled
-	summary, err := mgr.Run(org, assignMode, createBudgets)
-	if err != nil {
-		return fmt.Errorf("repository assignment failed: %w", err)
-	}
+	summary, err := mgr.Run(orgs, assignMode, createBudgets)
 	if summary != nil {
 		summary.Print()
 	}
+	if err != nil {
+		return fmt.Errorf("repository assignment failed: %w", err)
+	}
 
 	logger.Info("Repos assign command completed successfully")
 	return nil
@@ -508,6 +1876,8 @@ func runCustomPropAssign(_ *cobra.Command) error {
 		return fmt.Errorf("creating GitHub client: %w", err)
 	}
 	attachCache(client, logger)
+	attachAuditLog(client, logger)
+	client.SetReconcileBudgets(assignReconcileBudgets)
 
 	cpMgr, err := customprop.NewManager(cfgManager, client, logger)
 	if err != nil {
@@ -531,6 +1901,9 @@ func runCustomPropAssign(_ *cobra.Command) error {
 	}
 
 	cpMgr.PrintConfigSummary(org)
+	if assignCreateBudgets && cfgManager.BudgetsEnabled {
+		printBudgetPlan(os.Stdout, cfgManager.BudgetProducts, cfgManager.BudgetCostCenterOverrides)
+	}
 
 	// Confirmation in apply mode.
 	if assignMode == "apply" && !assignYes {
@@ -562,15 +1935,139 @@ func runCustomPropAssign(_ *cobra.Command) error {
 	return nil
 }
 
-// filterUsersByLogin filters a user slice to only those whose login appears in
-// the comma-separated list.
-func filterUsersByLogin(users []github.CopilotUser, commaSep string) []github.CopilotUser {
-	wanted := make(map[string]bool)
-	for _, u := range strings.Split(commaSep, ",") {
-		u = strings.TrimSpace(u)
-		if u != "" {
-			wanted[strings.ToLower(u)] = true
+// excludeUsers drops the configured excluded users from users and returns
+// the rest together with the number dropped.
+func excludeUsers(users []github.CopilotUser, logger *slog.Logger) ([]github.CopilotUser, int) {
+	if len(cfgManager.ExcludedUsers) == 0 {
+		return users, 0
+	}
+	kept := make([]github.CopilotUser, 0, len(users))
+	for _, u := range users {
+		if !cfgManager.IsExcludedUser(u.Login) {
+			kept = append(kept, u)
+		}
+	}
+	excluded := len(users) - len(kept)
+	logger.Info("Excluded users from processing", "count", excluded)
+	return kept, excluded
+}
+
+// startRunState returns the recorder for this apply run: the resumed run's,
+// or a new run in the export directory.
+func startRunState(resumed *runstate.State) (*runstate.Recorder, error) {
+	if resumed != nil {
+		return runstate.Resume(resumed), nil
+	}
+	recorder, err := runstate.New(cfgManager.ExportDir)
+	if err != nil {
+		return nil, fmt.Errorf("starting run state: %w", err)
+	}
+	return recorder, nil
+}
+
+// unprocessedUsers returns the users that processed does not record as
+// assigned to the cost center mgr assigns them to now: new users, users whose
+// seat date could not be parsed, and users that moved or failed to assign.
+func unprocessedUsers(users []github.CopilotUser, processed *ledger.Ledger, mgr *pru.Manager) []github.CopilotUser {
+	var out []github.CopilotUser
+	for _, u := range users {
+		if processed.NeedsProcessing(u.Login, mgr.AssignCostCenter(u)) {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+// skipSucceeded drops the users that state records as already assigned to
+// their cost center and returns the remainder with the number dropped.
+func skipSucceeded(groups map[string][]string, state *runstate.State) (map[string][]string, int) {
+	out := make(map[string][]string, len(groups))
+	skipped := 0
+	for cc, users := range groups {
+		var rest []string
+		for _, u := range users {
+			if state.Succeeded(cc, u) {
+				skipped++
+				continue
+			}
+			rest = append(rest, u)
+		}
+		out[cc] = rest
+	}
+	return out, skipped
+}
+
+// limitUsers returns the first n users sorted by login (case-insensitively),
+// so repeated canary runs pick the same users.
+func limitUsers(users []github.CopilotUser, n int) []github.CopilotUser {
+	sorted := slices.Clone(users)
+	slices.SortFunc(sorted, func(a, b github.CopilotUser) int {
+		return strings.Compare(strings.ToLower(a.Login), strings.ToLower(b.Login))
+	})
+	return sorted[:min(n, len(sorted))]
+}
+
+// parseLoginList parses a --users style value: a comma-separated list of
+// logins, or "@path" to read one login per line (blank lines and lines
+// starting with "#" are skipped).  Logins are trimmed, lower-cased, and
+// deduplicated in order.
+func parseLoginList(spec string) ([]string, error) {
+	var raw []string
+	if path, ok := strings.CutPrefix(strings.TrimSpace(spec), "@"); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading login list: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); !strings.HasPrefix(line, "#") {
+				raw = append(raw, line)
+			}
 		}
+	} else {
+		raw = strings.Split(spec, ",")
+	}
+
+	seen := make(map[string]bool, len(raw))
+	var logins []string
+	for _, l := range raw {
+		l = strings.ToLower(strings.TrimSpace(l))
+		if l != "" && !seen[l] {
+			seen[l] = true
+			logins = append(logins, l)
+		}
+	}
+	return logins, nil
+}
+
+// selectedLogins parses the --users value and checks that every login holds
+// a Copilot seat, so typos fail before anything is planned.
+func selectedLogins(users []github.CopilotUser, spec string) ([]string, error) {
+	logins, err := parseLoginList(spec)
+	if err != nil {
+		return nil, fmt.Errorf("parsing --users: %w", err)
+	}
+	holders := make(map[string]bool, len(users))
+	for _, u := range users {
+		holders[strings.ToLower(u.Login)] = true
+	}
+	var unknown []string
+	for _, l := range logins {
+		if !holders[l] {
+			unknown = append(unknown, l)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, usageErrorf("--users: not Copilot seat holders: %s", strings.Join(unknown, ", "))
+	}
+	return logins, nil
+}
+
+// filterUsersByLogin filters a user slice to only those whose login appears
+// in logins (lower-cased, see parseLoginList).
+func filterUsersByLogin(users []github.CopilotUser, logins []string) []github.CopilotUser {
+	wanted := make(map[string]bool, len(logins))
+	for _, l := range logins {
+		wanted[l] = true
 	}
 
 	var filtered []github.CopilotUser
@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/teams"
+)
+
+// assignOutputSchemaVersion is the schema_version of the assign --output json
+// document.  Bump it on changes that break existing parsers.
+const assignOutputSchemaVersion = 1
+
+// skippedExcluded is the skip reason of users left out by excluded_users or
+// --exclude-users, worded like the pru.Skipped* reasons.
+const skippedExcluded = "skipped (excluded)"
+
+// assignOutput is the assign --output json document.  The flows fill it in
+// through its methods, which are no-ops on a nil *assignOutput so that they
+// can be called unconditionally.
+type assignOutput struct {
+	SchemaVersion   int                      `json:"schema_version"`
+	Mode            string                   `json:"mode"`
+	Action          string                   `json:"action"` // plan or apply
+	CostCenters     []assignOutputCostCenter `json:"cost_centers"`
+	Skipped         []assignOutputSkip       `json:"skipped"`
+	API             github.Metrics           `json:"api"`
+	Seats           *github.SeatFetch        `json:"seats,omitempty"` // absent when seats were not listed
+	DurationSeconds float64                  `json:"duration_seconds"`
+	Error           string                   `json:"error,omitempty"`
+
+	start   time.Time
+	client  *github.Client
+	byID    map[string]*assignOutputCostCenter
+	skipped map[string]bool // lower-cased logins already in Skipped
+}
+
+// assignOutputCostCenter lists the users added to and removed from one cost
+// center.  In plan mode the users carry no success field.
+type assignOutputCostCenter struct {
+	ID      string             `json:"id"`
+	Name    string             `json:"name,omitempty"`
+	Adds    []assignOutputUser `json:"adds"`
+	Removes []assignOutputUser `json:"removes"`
+}
+
+// assignOutputUser is one added or removed user.
+type assignOutputUser struct {
+	Login   string `json:"login"`
+	Success *bool  `json:"success,omitempty"`
+}
+
+// assignOutputSkip is a user left out of the assignment.
+type assignOutputSkip struct {
+	Login  string `json:"login"`
+	Reason string `json:"reason"`
+}
+
+// newAssignOutput starts the document of an assign run.
+func newAssignOutput(mode, action string, start time.Time) *assignOutput {
+	if mode == "" {
+		mode = "users"
+	}
+	return &assignOutput{
+		SchemaVersion: assignOutputSchemaVersion,
+		Mode:          mode,
+		Action:        action,
+		CostCenters:   []assignOutputCostCenter{},
+		Skipped:       []assignOutputSkip{},
+		start:         start,
+		byID:          make(map[string]*assignOutputCostCenter),
+		skipped:       make(map[string]bool),
+	}
+}
+
+// useClient makes the document report the API metrics of client.
+func (o *assignOutput) useClient(client *github.Client) {
+	if o != nil {
+		o.client = client
+	}
+}
+
+// costCenter returns the entry of cost center id, created on first use.
+func (o *assignOutput) costCenter(id string, names map[string]string) *assignOutputCostCenter {
+	cc, ok := o.byID[id]
+	if !ok {
+		cc = &assignOutputCostCenter{ID: id, Adds: []assignOutputUser{}, Removes: []assignOutputUser{}}
+		o.byID[id] = cc
+	}
+	if cc.Name == "" && names[id] != id {
+		cc.Name = names[id]
+	}
+	return cc
+}
+
+// planned records the adds and removes a plan run would make.
+func (o *assignOutput) planned(adds, removes map[string][]string, names map[string]string) {
+	if o == nil {
+		return
+	}
+	for id, logins := range adds {
+		cc := o.costCenter(id, names)
+		for _, login := range logins {
+			cc.Adds = append(cc.Adds, assignOutputUser{Login: login})
+		}
+	}
+	for id, logins := range removes {
+		cc := o.costCenter(id, names)
+		for _, login := range logins {
+			cc.Removes = append(cc.Removes, assignOutputUser{Login: login})
+		}
+	}
+}
+
+// applied records the per-user results of an apply run.
+func (o *assignOutput) applied(adds, removes map[string]map[string]bool, names map[string]string) {
+	if o == nil {
+		return
+	}
+	for id, results := range adds {
+		cc := o.costCenter(id, names)
+		for login, ok := range results {
+			cc.Adds = append(cc.Adds, assignOutputUser{Login: login, Success: &ok})
+		}
+	}
+	for id, results := range removes {
+		cc := o.costCenter(id, names)
+		for login, ok := range results {
+			cc.Removes = append(cc.Removes, assignOutputUser{Login: login, Success: &ok})
+		}
+	}
+}
+
+// teamsSync records the last sync of a teams or assigning-team manager.
+func (o *assignOutput) teamsSync(s *teams.SyncResult) {
+	if o == nil || s == nil {
+		return
+	}
+	if o.Action == "apply" {
+		o.applied(s.Assigned, s.Removed, s.Names)
+		return
+	}
+	o.planned(s.Adds, s.Removes, s.Names)
+}
+
+// skip records a user left out of the assignment.  Only the first reason
+// given for a login is kept.
+func (o *assignOutput) skip(login, reason string) {
+	if o == nil || o.skipped[strings.ToLower(login)] {
+		return
+	}
+	o.skipped[strings.ToLower(login)] = true
+	o.Skipped = append(o.Skipped, assignOutputSkip{Login: login, Reason: reason})
+}
+
+// skipExcluded records the users of seats that excluded_users or
+// --exclude-users leave out.
+func (o *assignOutput) skipExcluded(users []github.CopilotUser) {
+	if o == nil {
+		return
+	}
+	for _, u := range users {
+		if cfgManager.IsExcludedUser(u.Login) {
+			o.skip(u.Login, skippedExcluded)
+		}
+	}
+}
+
+// finish completes the document after the run returned err and writes it to
+// w as indented JSON.
+func (o *assignOutput) finish(w io.Writer, err error, now time.Time) error {
+	ids := make([]string, 0, len(o.byID))
+	for id := range o.byID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	byLogin := func(a, b assignOutputUser) int {
+		return strings.Compare(strings.ToLower(a.Login), strings.ToLower(b.Login))
+	}
+	for _, id := range ids {
+		cc := o.byID[id]
+		slices.SortFunc(cc.Adds, byLogin)
+		slices.SortFunc(cc.Removes, byLogin)
+		o.CostCenters = append(o.CostCenters, *cc)
+	}
+	sort.SliceStable(o.Skipped, func(i, j int) bool {
+		return strings.ToLower(o.Skipped[i].Login) < strings.ToLower(o.Skipped[j].Login)
+	})
+	if o.client != nil {
+		o.API = o.client.Metrics()
+		if f := o.client.SeatFetch(); f != (github.SeatFetch{}) {
+			o.Seats = &f
+		}
+	}
+	o.DurationSeconds = now.Sub(o.start).Seconds()
+	if err != nil {
+		o.Error = err.Error()
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(o); err != nil {
+		return fmt.Errorf("encoding assign output: %w", err)
+	}
+	return nil
+}
+
+// suppressStdout points os.Stdout at the null device so that the human
+// summaries printed during a run do not mix with the JSON document, and
+// returns the real stdout together with a function that restores it.
+func suppressStdout() (*os.File, func(), error) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening %s: %w", os.DevNull, err)
+	}
+	stdout := os.Stdout
+	os.Stdout = devNull
+	return stdout, func() {
+		os.Stdout = stdout
+		_ = devNull.Close()
+	}, nil
+}
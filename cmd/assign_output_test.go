@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// runAssignJSON runs assign with --output json and returns the error and the
+// decoded stdout document.
+func runAssignJSON(t *testing.T) (error, assignOutput) {
+	t.Helper()
+	prev := assignOutputFormat
+	t.Cleanup(func() { assignOutputFormat = prev })
+	assignOutputFormat = "json"
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+	runErr := runAssign(assignCmd, nil)
+	os.Stdout = orig
+	_ = w.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out assignOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("stdout is not one JSON document: %v\n%s", err, data)
+	}
+	return runErr, out
+}
+
+func findOutputCC(t *testing.T, out assignOutput, id string) assignOutputCostCenter {
+	t.Helper()
+	for _, cc := range out.CostCenters {
+		if cc.ID == id {
+			return cc
+		}
+	}
+	t.Fatalf("cost center %s missing from %+v", id, out.CostCenters)
+	return assignOutputCostCenter{}
+}
+
+func TestRunAssign_OutputJSONApply(t *testing.T) {
+	srv, _ := pruTestServer(t, testPRUCCID)
+	setupPRUAssign(t, srv, "apply")
+
+	runErr, out := runAssignJSON(t)
+	if runErr == nil {
+		t.Fatal("runAssign succeeded, want an incomplete-assignment error")
+	}
+	if out.SchemaVersion != 1 || out.Mode != "users" || out.Action != "apply" {
+		t.Errorf("header = %d/%s/%s, want 1/users/apply", out.SchemaVersion, out.Mode, out.Action)
+	}
+	if !strings.Contains(out.Error, "1/2 users failed") {
+		t.Errorf("error = %q, want the incomplete-assignment error", out.Error)
+	}
+	if out.API.Requests == 0 {
+		t.Error("api.requests = 0, want the requests of the run")
+	}
+	if out.DurationSeconds <= 0 {
+		t.Errorf("duration_seconds = %v", out.DurationSeconds)
+	}
+	if out.Seats == nil || out.Seats.Fetched != 2 || out.Seats.TotalSeats != 2 || out.Seats.Mismatch {
+		t.Errorf("seats = %+v, want 2 of 2 fetched", out.Seats)
+	}
+
+	pruCC := findOutputCC(t, out, testPRUCCID)
+	if len(pruCC.Adds) != 1 || pruCC.Adds[0].Login != "alice" || pruCC.Adds[0].Success == nil || *pruCC.Adds[0].Success {
+		t.Errorf("PRU cost center adds = %+v, want alice failed", pruCC.Adds)
+	}
+	if pruCC.Name != "PRUs Allowed" {
+		t.Errorf("name = %q, want PRUs Allowed", pruCC.Name)
+	}
+	noPRU := findOutputCC(t, out, testCCID)
+	if len(noPRU.Adds) != 1 || noPRU.Adds[0].Login != "bob" || noPRU.Adds[0].Success == nil || !*noPRU.Adds[0].Success {
+		t.Errorf("no-PRU cost center adds = %+v, want bob succeeded", noPRU.Adds)
+	}
+}
+
+func TestRunAssign_OutputJSONPlanSkipped(t *testing.T) {
+	srv, _ := pruTestServer(t, "")
+	setupPRUAssign(t, srv, "plan")
+	cfgManager.MergeExcludedUsers([]string{"bob"})
+
+	runErr, out := runAssignJSON(t)
+	if runErr != nil {
+		t.Fatalf("runAssign: %v", runErr)
+	}
+	if out.Action != "plan" {
+		t.Errorf("action = %q, want plan", out.Action)
+	}
+	if len(out.Skipped) != 1 || out.Skipped[0].Login != "bob" || out.Skipped[0].Reason != skippedExcluded {
+		t.Errorf("skipped = %+v, want bob excluded", out.Skipped)
+	}
+	for _, cc := range out.CostCenters {
+		for _, u := range cc.Adds {
+			if u.Success != nil {
+				t.Errorf("plan add %+v carries a success field", u)
+			}
+			if u.Login == "bob" {
+				t.Error("excluded bob is planned")
+			}
+		}
+	}
+}
+
+func TestRunAssign_OutputJSONValidation(t *testing.T) {
+	srv, _ := pruTestServer(t, "")
+	setupPRUAssign(t, srv, "apply")
+	prev := assignOutputFormat
+	t.Cleanup(func() { assignOutputFormat = prev })
+
+	assignOutputFormat = "yaml"
+	if err := runAssign(assignCmd, nil); err == nil || !strings.Contains(err.Error(), "invalid --output") {
+		t.Errorf("err = %v, want an invalid --output error", err)
+	}
+
+	assignOutputFormat = "json"
+	assignYes = false
+	if err := runAssign(assignCmd, nil); err == nil || !strings.Contains(err.Error(), "requires --yes") {
+		t.Errorf("err = %v, want a --yes error", err)
+	}
+
+	cfgManager.CostCenterMode = "repos"
+	if err := runAssign(assignCmd, nil); err == nil || !strings.Contains(err.Error(), "not supported in repos mode") {
+		t.Errorf("err = %v, want a repos mode error", err)
+	}
+}
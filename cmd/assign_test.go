@@ -0,0 +1,1227 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/githubtest"
+	"github.com/renan-alm/gh-cost-center/internal/ledger"
+	"github.com/renan-alm/gh-cost-center/internal/lock"
+	"github.com/renan-alm/gh-cost-center/internal/plan"
+	"github.com/renan-alm/gh-cost-center/internal/pru"
+	"github.com/renan-alm/gh-cost-center/internal/render"
+	"github.com/renan-alm/gh-cost-center/internal/runstate"
+	"github.com/renan-alm/gh-cost-center/internal/teams"
+)
+
+const testPRUCCID = "a1b2c3d4-b5c6-7890-abcd-ef1234567890"
+
+// pruTestServer serves two Copilot seats (alice, bob), the no-PRU and
+// PRU-allowed cost centers (both empty), and records the users POSTed to each
+// cost center.  POSTs to failCC are rejected.
+func pruTestServer(t *testing.T, failCC string) (*httptest.Server, map[string][]string) {
+	t.Helper()
+	var mu sync.Mutex
+	added := map[string][]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/copilot/billing/seats"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"total_seats": 2, "seats": []map[string]any{
+				{"assignee": map[string]string{"login": "alice", "type": "User"}, "created_at": "2024-01-01T00:00:00Z"},
+				{"assignee": map[string]string{"login": "bob", "type": "User"}, "created_at": "2025-06-01T00:00:00Z"},
+			}})
+		case strings.HasSuffix(r.URL.Path, "/cost-centers"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"costCenters": []map[string]string{
+				{"id": testCCID, "name": "No PRUs", "state": "active"},
+				{"id": testPRUCCID, "name": "PRUs Allowed", "state": "active"},
+			}})
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/resource"):
+			id := filepath.Base(filepath.Dir(r.URL.Path))
+			if id == failCC {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				_, _ = w.Write([]byte(`{"message":"rejected"}`))
+				return
+			}
+			var body struct {
+				Users []string `json:"users"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			added[id] = append(added[id], body.Users...)
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			id := filepath.Base(r.URL.Path)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": id, "name": id, "state": "active", "resources": []any{}})
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, added
+}
+
+// setupPRUAssign points cfgManager at srv with alice as the only PRU
+// exception and resets the assign flags.  It returns the export directory.
+func setupPRUAssign(t *testing.T, srv *httptest.Server, mode string) string {
+	t.Helper()
+	t.Chdir(t.TempDir()) // keep the cost center cache out of the tree
+	exportDir := t.TempDir()
+
+	cfgPath := filepath.Join(exportDir, "config.yaml")
+	yaml := `github:
+  enterprise: "test-ent"
+export_dir: "` + exportDir + `"
+cost_center:
+  users:
+    no_prus_cost_center_name: "No PRUs"
+    prus_allowed_cost_center_name: "PRUs Allowed"
+    exception_users: ["alice"]
+`
+	if err := os.WriteFile(cfgPath, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	m, err := config.Load(cfgPath, logger)
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+	m.APIBaseURL = srv.URL // Load only accepts HTTPS
+	m.Token = "test-token"
+
+	prevCfg := cfgManager
+	t.Cleanup(func() { cfgManager = prevCfg })
+	cfgManager = m
+
+	assignMode, assignYes, assignIncremental = mode, true, true
+	assignUsers, assignCreateCC, assignCreateBudgets, assignCheckCurrentCC = "", false, false, false
+	assignStrictFetch = false
+	t.Cleanup(func() { assignMode, assignYes, assignIncremental = "plan", false, false })
+	return exportDir
+}
+
+func TestRunPRUAssign_PlanMakesNoChanges(t *testing.T) {
+	srv, added := pruTestServer(t, "")
+	setupPRUAssign(t, srv, "plan")
+
+	if err := runAssign(assignCmd, nil); err != nil {
+		t.Fatalf("runAssign: %v", err)
+	}
+	if len(added) != 0 {
+		t.Errorf("plan mode added users: %v", added)
+	}
+	if _, err := os.Stat(filepath.Join(cfgManager.StateDir, ".last_run_timestamp")); !os.IsNotExist(err) {
+		t.Errorf("plan mode must not save the run timestamp (stat err = %v)", err)
+	}
+}
+
+func TestRunAssign_ApplyRespectsRunLock(t *testing.T) {
+	srv, added := pruTestServer(t, "")
+	setupPRUAssign(t, srv, "apply")
+
+	held, err := lock.Acquire(cfgManager.LockFile, time.Hour, nil)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	defer func() { _ = held.Release() }()
+
+	var heldErr *lock.HeldError
+	if err := runAssign(assignCmd, nil); !errors.As(err, &heldErr) {
+		t.Fatalf("runAssign err = %v, want the run refused while the lock is held", err)
+	}
+	if len(added) != 0 {
+		t.Errorf("a refused run must not assign users: %v", added)
+	}
+
+	// Plan runs do not take the lock.
+	assignMode = "plan"
+	if err := runAssign(assignCmd, nil); err != nil {
+		t.Fatalf("plan runAssign: %v", err)
+	}
+
+	// --force-unlock removes the other run's lock; ours is released on exit.
+	assignMode, forceUnlock = "apply", true
+	t.Cleanup(func() { forceUnlock = false })
+	if err := runAssign(assignCmd, nil); err != nil {
+		t.Fatalf("runAssign --force-unlock: %v", err)
+	}
+	if len(added) == 0 {
+		t.Error("expected the forced run to assign users")
+	}
+	if _, err := os.Stat(cfgManager.LockFile); !os.IsNotExist(err) {
+		t.Errorf("lock file should be released after the run (stat err = %v)", err)
+	}
+}
+
+func TestRunPRUAssign_ApplyAssignsAndSavesTimestamp(t *testing.T) {
+	srv, added := pruTestServer(t, "")
+	setupPRUAssign(t, srv, "apply")
+
+	if err := runAssign(assignCmd, nil); err != nil {
+		t.Fatalf("runAssign: %v", err)
+	}
+	if strings.Join(added[testPRUCCID], ",") != "alice" || strings.Join(added[testCCID], ",") != "bob" {
+		t.Errorf("added = %v, want alice -> PRU-allowed, bob -> no-PRU", added)
+	}
+	if _, err := os.Stat(filepath.Join(cfgManager.StateDir, ".last_run_timestamp")); err != nil {
+		t.Errorf("expected the run timestamp to be saved: %v", err)
+	}
+}
+
+func TestRunPRUAssign_FailedAssignmentExitsNonZero(t *testing.T) {
+	srv, added := pruTestServer(t, testPRUCCID)
+	setupPRUAssign(t, srv, "apply")
+
+	err := runAssign(assignCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "1/2 users failed") {
+		t.Fatalf("runAssign error = %v, want an incomplete-assignment error", err)
+	}
+	if strings.Join(added[testCCID], ",") != "bob" {
+		t.Errorf("added = %v, want bob still assigned", added)
+	}
+	if _, err := os.Stat(filepath.Join(cfgManager.StateDir, ".last_run_timestamp")); !os.IsNotExist(err) {
+		t.Errorf("a failed run must not save the run timestamp (stat err = %v)", err)
+	}
+}
+
+func TestRunPRUAssign_WritesStepSummary(t *testing.T) {
+	srv, _ := pruTestServer(t, testPRUCCID)
+	setupPRUAssign(t, srv, "apply")
+	summary := filepath.Join(t.TempDir(), "summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", summary)
+
+	if err := runAssign(assignCmd, nil); err == nil {
+		t.Fatal("runAssign succeeded, want an incomplete-assignment error")
+	}
+	data, err := os.ReadFile(summary)
+	if err != nil {
+		t.Fatalf("reading step summary: %v", err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"## Cost center assignment (users mode)",
+		"| Cost center | Assigned | Failed |",
+		"| " + testCCID + " | 1 | 0 |",
+		"| " + testPRUCCID + " | 0 | 1 |",
+		"### Failures (1)",
+		"- `" + testPRUCCID + "`: alice",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("step summary missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestComputeMembershipDiff_ResolvesNamesAndScopesToProcessedUsers(t *testing.T) {
+	const otherCCID = "a1b2c3d4-b5c6-7890-abcd-ef1234567890"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/cost-centers"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"costCenters": []map[string]string{
+				{"id": testCCID, "name": "No PRUs", "state": "active"},
+				{"id": otherCCID, "name": "PRUs Allowed", "state": "active"},
+			}})
+		case strings.HasSuffix(r.URL.Path, "/"+testCCID):
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": testCCID, "resources": []map[string]string{
+				{"type": "User", "name": "alice"},
+				{"type": "User", "name": "outsider"},
+			}})
+		case strings.HasSuffix(r.URL.Path, "/"+otherCCID):
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": otherCCID, "resources": []map[string]string{
+				{"type": "User", "name": "Bob"},
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	groups := map[string][]string{
+		"placeholder-no-pru": {"alice", "bob", "carol"},
+		"placeholder-pru":    nil,
+	}
+	names := map[string]string{"placeholder-no-pru": "No PRUs", "placeholder-pru": "PRUs Allowed"}
+	diff, err := computeMembershipDiff(newTestGitHubClient(t, srv.URL), groups, names)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff == nil {
+		t.Fatal("expected the cost center name to be resolved")
+	}
+	// "outsider" is not processed, so it must not be counted at all.
+	if diff.correct != 1 || diff.toAdd != 1 || diff.skipped != 1 {
+		t.Errorf("diff = %+v, want correct=1 toAdd=1 skipped=1", *diff)
+	}
+}
+
+func TestComputeMembershipDiff_PerCostCenterDelta(t *testing.T) {
+	const otherCCID = "a1b2c3d4-b5c6-7890-abcd-ef1234567890"
+	members := map[string][]string{
+		testCCID:  {"alice", "bob"},
+		otherCCID: {"carol"},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		var res []map[string]string
+		for _, m := range members[id] {
+			res = append(res, map[string]string{"type": "User", "name": m})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": id, "resources": res})
+	}))
+	defer srv.Close()
+	client := newTestGitHubClient(t, srv.URL)
+
+	// alice moves to the other cost center, bob stays, dave is new.
+	groups := map[string][]string{
+		testCCID:  {"bob", "dave"},
+		otherCCID: {"alice", "carol"},
+	}
+	diff, err := computeMembershipDiff(client, groups, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	no, other := diff.perCC[testCCID], diff.perCC[otherCCID]
+	if strings.Join(no.add, ",") != "dave" || strings.Join(no.remove, ",") != "alice" || no.unchanged != 1 {
+		t.Errorf("no-PRU delta = %+v", *no)
+	}
+	if strings.Join(other.add, ",") != "alice" || len(other.remove) != 0 || other.unchanged != 1 {
+		t.Errorf("other delta = %+v", *other)
+	}
+	if adds := diff.adds(); len(adds) != 2 || diff.empty() {
+		t.Errorf("adds = %v, empty = %v", adds, diff.empty())
+	}
+
+	// Already matching: nothing to send.
+	diff, err = computeMembershipDiff(client, members, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !diff.empty() || len(diff.adds()) != 0 {
+		t.Errorf("expected an empty diff, got %+v", diff.perCC)
+	}
+}
+
+func TestPrintMembershipDelta(t *testing.T) {
+	diff := &membershipDiff{perCC: map[string]*ccDelta{
+		"no-pru":    {add: []string{"dave"}, remove: []string{"alice"}, unchanged: 1},
+		"pru":       {add: []string{"alice", "erin"}, unchanged: 12},
+		"untouched": {unchanged: 3},
+	}}
+	names := map[string]string{"no-pru": "No PRUs", "pru": "PRUs Allowed"}
+
+	var plain bytes.Buffer
+	printMembershipDelta(render.New(&plain, false), diff, names)
+	want := "Changes against current membership:\n" +
+		"  No PRUs (no-pru):    +1  -1  1 unchanged\n" +
+		"  PRUs Allowed (pru):  +2  -0  12 unchanged\n" +
+		"\n  No PRUs (no-pru):\n" +
+		"    + dave\n" +
+		"    - alice\n" +
+		"\n  PRUs Allowed (pru):\n" +
+		"    + alice\n" +
+		"    + erin\n"
+	if plain.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", plain.String(), want)
+	}
+
+	var colored bytes.Buffer
+	printMembershipDelta(render.New(&colored, true), diff, names)
+	for _, s := range []string{"\x1b[32m+ dave\x1b[0m", "\x1b[31m- alice\x1b[0m", "\x1b[32m+2\x1b[0m"} {
+		if !strings.Contains(colored.String(), s) {
+			t.Errorf("colored output missing %q:\n%q", s, colored.String())
+		}
+	}
+
+	var empty bytes.Buffer
+	printMembershipDelta(render.New(&empty, true), &membershipDiff{perCC: map[string]*ccDelta{"pru": {unchanged: 2}}}, names)
+	if !strings.HasPrefix(empty.String(), "Nothing to do") {
+		t.Errorf("empty diff output = %q", empty.String())
+	}
+}
+
+func TestRemoveMovedUsers_ExceptionFlipsBothWays(t *testing.T) {
+	const pruCCID = "a1b2c3d4-b5c6-7890-abcd-ef1234567890"
+	members := map[string][]string{
+		testCCID: {"bob", "carol"}, // bob was just added to the exception list
+		pruCCID:  {"alice"},        // alice was just removed from it
+	}
+	removed := map[string][]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/resource"), "/")
+		id := parts[len(parts)-1]
+		if r.Method == http.MethodDelete {
+			var body struct {
+				Users []string `json:"users"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			removed[id] = append(removed[id], body.Users...)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		var res []map[string]string
+		for _, m := range members[id] {
+			res = append(res, map[string]string{"type": "User", "name": m})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": id, "resources": res})
+	}))
+	defer srv.Close()
+	client := newTestGitHubClient(t, srv.URL)
+
+	groups := map[string][]string{
+		testCCID: {"alice", "carol"},
+		pruCCID:  {"bob"},
+	}
+	diff, err := computeMembershipDiff(client, groups, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	moved, err := removeMovedUsers(client, diff, slog.Default())
+	if err != nil {
+		t.Fatalf("removeMovedUsers: %v", err)
+	}
+	if strings.Join(removed[pruCCID], ",") != "alice" || strings.Join(removed[testCCID], ",") != "bob" {
+		t.Errorf("removed = %v, want alice from the PRU cost center and bob from the no-PRU one", removed)
+	}
+	if len(moved) != 2 || !moved["alice"] || !moved["bob"] || moved["carol"] {
+		t.Errorf("moved = %v, want alice and bob", moved)
+	}
+	if adds := diff.adds(); strings.Join(adds[testCCID], ",") != "alice" || strings.Join(adds[pruCCID], ",") != "bob" {
+		t.Errorf("adds = %v", adds)
+	}
+}
+
+func TestReconcileCostCenterNames_ConflictKeepsConfiguredID(t *testing.T) {
+	var patched bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPatch {
+			patched = true
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"message":"Name already taken"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": testCCID, "name": "Old Name", "state": "active"})
+	}))
+	defer srv.Close()
+
+	existing := reconcileCostCenterNames(newTestGitHubClient(t, srv.URL),
+		map[string]string{testCCID: "Taken Name", "placeholder": "Other"}, true, slog.Default())
+	if !patched {
+		t.Fatal("expected a rename attempt")
+	}
+	if !existing[testCCID] || existing["placeholder"] {
+		t.Errorf("existing = %v, want only the configured UUID", existing)
+	}
+}
+
+// scriptedPrompter answers prompts from a fixed list of lines.
+type scriptedPrompter struct {
+	interactive bool
+	lines       []string
+}
+
+func (p *scriptedPrompter) Interactive() bool { return p.interactive }
+
+func (p *scriptedPrompter) ReadLine() (string, error) {
+	if len(p.lines) == 0 {
+		return "", nil
+	}
+	line := p.lines[0]
+	p.lines = p.lines[1:]
+	return line, nil
+}
+
+func TestConfirmApply(t *testing.T) {
+	adds := map[string][]string{testCCID: {"bob"}}
+	removes := map[string][]string{testPRUCCID: {"bob"}}
+
+	tests := []struct {
+		name    string
+		p       *scriptedPrompter
+		want    bool
+		wantErr error
+	}{
+		{"non-TTY", &scriptedPrompter{}, false, errNotInteractive},
+		{"decline", &scriptedPrompter{interactive: true, lines: []string{"n"}}, false, nil},
+		{"empty answer", &scriptedPrompter{interactive: true}, false, nil},
+		{"accept y", &scriptedPrompter{interactive: true, lines: []string{"y"}}, true, nil},
+		{"accept YES", &scriptedPrompter{interactive: true, lines: []string{" YES "}}, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := confirmApply(tt.p, adds, removes, true)
+			if err != tt.wantErr || got != tt.want {
+				t.Errorf("confirmApply = %v, %v; want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunPRUAssign_NonInteractiveWithoutYes(t *testing.T) {
+	srv, added := pruTestServer(t, "")
+	setupPRUAssign(t, srv, "apply")
+	assignYes = false
+	prev := applyPrompter
+	applyPrompter = &scriptedPrompter{}
+	t.Cleanup(func() { applyPrompter = prev })
+
+	err := runAssign(assignCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Fatalf("runAssign error = %v, want a hint to pass --yes", err)
+	}
+	if len(added) != 0 {
+		t.Errorf("no users may be added without confirmation, got %v", added)
+	}
+}
+
+// teamsTestServer serves one enterprise team, eng, whose member alice holds a
+// Copilot seat granted by it.  It fails the test on any request that is not
+// a GET.
+func teamsTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected %s %s before confirmation", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/copilot/billing/seats"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"total_seats": 1, "seats": []map[string]any{{
+				"assignee":       map[string]string{"login": "alice", "type": "User"},
+				"assigning_team": map[string]string{"slug": "eng", "name": "Eng"},
+			}}})
+		case strings.HasSuffix(r.URL.Path, "/teams/eng/memberships"):
+			_ = json.NewEncoder(w).Encode([]map[string]string{{"login": "alice", "type": "User"}})
+		case strings.HasSuffix(r.URL.Path, "/teams"):
+			_ = json.NewEncoder(w).Encode([]map[string]any{{"id": 1, "slug": "eng", "name": "Eng"}})
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]any{"costCenters": []any{}})
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRunAssign_TeamModesConfirmApply(t *testing.T) {
+	for _, mode := range []string{"teams", "assigning-team"} {
+		for _, tc := range []struct {
+			name    string
+			p       *scriptedPrompter
+			wantErr bool
+		}{
+			{"non-TTY", &scriptedPrompter{}, true},
+			{"decline", &scriptedPrompter{interactive: true, lines: []string{"n"}}, false},
+		} {
+			t.Run(mode+"/"+tc.name, func(t *testing.T) {
+				srv := teamsTestServer(t)
+				setupPRUAssign(t, srv, "apply")
+				cfgManager.CostCenterMode = mode
+				cfgManager.TeamsScope, cfgManager.TeamsStrategy = "enterprise", "auto"
+				cfgManager.AssigningTeamDefaultCostCenter = "Unassigned"
+				assignYes, assignIncremental = false, false
+				prev := applyPrompter
+				applyPrompter = tc.p
+				t.Cleanup(func() { applyPrompter = prev })
+
+				var err error
+				out := captureStdout(t, func() { err = runAssign(assignCmd, nil) })
+				if tc.wantErr {
+					if !errors.Is(err, errNotInteractive) {
+						t.Fatalf("runAssign error = %v, want errNotInteractive", err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("runAssign: %v", err)
+				}
+				if !strings.Contains(out, "Planned changes:") || !strings.Contains(out, ": +1 / -0 users") {
+					t.Errorf("output has no per-cost-center summary before the prompt:\n%s", out)
+				}
+			})
+		}
+	}
+}
+
+func TestParseLoginList(t *testing.T) {
+	got, err := parseLoginList(" Alice, bob ,,alice")
+	if err != nil || strings.Join(got, ",") != "alice,bob" {
+		t.Errorf("comma list = %v, %v; want [alice bob]", got, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "logins.txt")
+	if err := os.WriteFile(path, []byte("# batch 1\nCarol\n\n  dave  \n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err = parseLoginList("@" + path)
+	if err != nil || strings.Join(got, ",") != "carol,dave" {
+		t.Errorf("@file = %v, %v; want [carol dave]", got, err)
+	}
+	if _, err := parseLoginList("@" + filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("expected an error for a missing @file")
+	}
+}
+
+func TestSelectedLogins_UnknownLogins(t *testing.T) {
+	users := []github.CopilotUser{{Login: "Alice"}, {Login: "bob"}}
+	if got, err := selectedLogins(users, "ALICE"); err != nil || len(got) != 1 {
+		t.Errorf("selectedLogins(ALICE) = %v, %v", got, err)
+	}
+	_, err := selectedLogins(users, "alice,alcie,zed")
+	if err == nil || !strings.Contains(err.Error(), "alcie, zed") {
+		t.Errorf("expected unknown logins to be listed, got %v", err)
+	}
+}
+
+func TestRunPRUAssign_UsersWithIncrementalIntersects(t *testing.T) {
+	srv, added := pruTestServer(t, "")
+	setupPRUAssign(t, srv, "apply")
+	processed, err := ledger.Load(cfgManager.ProcessedUsersFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := processed.Record(testPRUCCID, map[string]bool{"alice": true}); err != nil {
+		t.Fatal(err)
+	}
+	assignUsers = "alice,BOB"
+	t.Cleanup(func() { assignUsers = "" })
+
+	if err := runAssign(assignCmd, nil); err != nil {
+		t.Fatalf("runAssign: %v", err)
+	}
+	// alice is in the ledger with her cost center, so only bob is processed.
+	if len(added[testPRUCCID]) != 0 || strings.Join(added[testCCID], ",") != "bob" {
+		t.Errorf("added = %v, want only bob", added)
+	}
+}
+
+func TestRunPRUAssign_IncrementalLedger(t *testing.T) {
+	t.Run("timestamp no longer skips users", func(t *testing.T) {
+		srv, added := pruTestServer(t, "")
+		setupPRUAssign(t, srv, "apply")
+		since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		if err := cfgManager.SaveLastRunTimestamp(config.TimestampModePRU, &since); err != nil {
+			t.Fatal(err)
+		}
+		if err := runAssign(assignCmd, nil); err != nil {
+			t.Fatalf("runAssign: %v", err)
+		}
+		// Without a ledger every user is processed once, even alice whose
+		// seat predates the last run.
+		if len(added[testPRUCCID]) != 1 || len(added[testCCID]) != 1 {
+			t.Errorf("added = %v, want alice and bob", added)
+		}
+	})
+
+	t.Run("partial failure resumes", func(t *testing.T) {
+		srv, _ := pruTestServer(t, testPRUCCID)
+		setupPRUAssign(t, srv, "apply")
+		if err := runAssign(assignCmd, nil); err == nil {
+			t.Fatal("first run: want the failed batch reported")
+		}
+		processed, err := ledger.Load(cfgManager.ProcessedUsersFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if processed.NeedsProcessing("bob", testCCID) || !processed.NeedsProcessing("alice", testPRUCCID) {
+			t.Fatalf("ledger after the first run: want bob recorded and alice not")
+		}
+
+		// The next run sends only alice, whose batch failed.
+		srv2, added := pruTestServer(t, "")
+		cfgManager.APIBaseURL = srv2.URL
+		if err := runAssign(assignCmd, nil); err != nil {
+			t.Fatalf("second run: %v", err)
+		}
+		if strings.Join(added[testPRUCCID], ",") != "alice" || len(added[testCCID]) != 0 {
+			t.Errorf("second run added = %v, want only alice", added)
+		}
+
+		// A run with nothing new or changed does nothing.
+		srv3, added := pruTestServer(t, "")
+		cfgManager.APIBaseURL = srv3.URL
+		if err := runAssign(assignCmd, nil); err != nil {
+			t.Fatalf("third run: %v", err)
+		}
+		if len(added) != 0 {
+			t.Errorf("third run added = %v, want nothing", added)
+		}
+	})
+}
+
+func TestUnprocessedUsers(t *testing.T) {
+	cfg := &config.Manager{
+		CostCenterMode:          "users",
+		NoPRUsCostCenterID:      testCCID,
+		PRUsAllowedCostCenterID: testPRUCCID,
+		PRUsExceptionUsers:      []string{"alice"},
+	}
+	mgr := pru.NewManager(cfg, quietLogger())
+	processed, err := ledger.Load(filepath.Join(t.TempDir(), "processed_users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// alice moved into the PRU tier since she was last assigned; bob is
+	// unchanged; carol's seat date is malformed and dave is new.
+	if err := processed.Record(testCCID, map[string]bool{"alice": true, "bob": true, "carol": false}); err != nil {
+		t.Fatal(err)
+	}
+	users := []github.CopilotUser{
+		{Login: "alice", CreatedAt: "2024-01-01T00:00:00Z"},
+		{Login: "Bob", CreatedAt: "2024-01-01T00:00:00Z"},
+		{Login: "carol", CreatedAt: "not-a-date"},
+		{Login: "dave"},
+	}
+	var got []string
+	for _, u := range unprocessedUsers(users, processed, mgr) {
+		got = append(got, u.Login)
+	}
+	if strings.Join(got, ",") != "alice,carol,dave" {
+		t.Errorf("unprocessedUsers = %v, want [alice carol dave]", got)
+	}
+}
+
+func TestRunPRUAssign_ExcludedUsersFromConfigAndFlag(t *testing.T) {
+	srv, added := pruTestServer(t, "")
+	setupPRUAssign(t, srv, "apply")
+	cfgManager.MergeExcludedUsers([]string{"ALICE"}) // as if from cost_center.excluded_users
+	assignExcludeUsers = "Bob"
+	t.Cleanup(func() { assignExcludeUsers = "" })
+
+	if err := runAssign(assignCmd, nil); err != nil {
+		t.Fatalf("runAssign: %v", err)
+	}
+	if len(added) != 0 {
+		t.Errorf("excluded users must not be assigned, got %v", added)
+	}
+	if strings.Join(cfgManager.ExcludedUsers, ",") != "alice,bob" {
+		t.Errorf("ExcludedUsers = %v, want config and flag merged", cfgManager.ExcludedUsers)
+	}
+}
+
+func TestLimitUsers_SortedByLogin(t *testing.T) {
+	users := []github.CopilotUser{{Login: "carol"}, {Login: "Alice"}, {Login: "bob"}}
+	got := limitUsers(users, 2)
+	if len(got) != 2 || got[0].Login != "Alice" || got[1].Login != "bob" {
+		t.Errorf("limitUsers = %v, want [Alice bob]", got)
+	}
+	if users[0].Login != "carol" {
+		t.Error("limitUsers must not reorder its input")
+	}
+}
+
+func TestRunPRUAssign_LimitSkipsTimestamp(t *testing.T) {
+	srv, added := pruTestServer(t, "")
+	setupPRUAssign(t, srv, "apply")
+	assignLimit = 1
+	t.Cleanup(func() { assignLimit = 0 })
+
+	if err := runAssign(assignCmd, nil); err != nil {
+		t.Fatalf("runAssign: %v", err)
+	}
+	if strings.Join(added[testPRUCCID], ",") != "alice" || len(added[testCCID]) != 0 {
+		t.Errorf("added = %v, want only alice (first by login)", added)
+	}
+	if _, err := os.Stat(filepath.Join(cfgManager.StateDir, ".last_run_timestamp")); !os.IsNotExist(err) {
+		t.Errorf("a canary run must not save the run timestamp (stat err = %v)", err)
+	}
+}
+
+func TestRunPRUAssign_ResumeRetriesOnlyFailures(t *testing.T) {
+	// First attempt: the PRU-allowed batch fails, the no-PRU batch succeeds.
+	failing, _ := pruTestServer(t, testPRUCCID)
+	exportDir := setupPRUAssign(t, failing, "apply")
+	if err := runAssign(assignCmd, nil); err == nil {
+		t.Fatal("expected the first attempt to fail")
+	}
+
+	healthy, added := pruTestServer(t, "")
+	cfgManager.APIBaseURL = healthy.URL
+	assignResume = runstate.Latest
+	t.Cleanup(func() { assignResume = "" })
+	if err := runAssign(assignCmd, nil); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if strings.Join(added[testPRUCCID], ",") != "alice" || len(added[testCCID]) != 0 {
+		t.Errorf("resume added = %v, want only alice re-sent", added)
+	}
+	state, err := runstate.Load(exportDir, runstate.Latest)
+	if err != nil {
+		t.Fatalf("loading run state: %v", err)
+	}
+	if state.SucceededCount() != 2 {
+		t.Errorf("after resume SucceededCount = %d, want 2", state.SucceededCount())
+	}
+}
+
+func TestRunPRUAssign_ResumeAfterCrash(t *testing.T) {
+	srv, added := pruTestServer(t, "")
+	exportDir := setupPRUAssign(t, srv, "apply")
+
+	// Simulate a crash right after the first batch (alice) was recorded.
+	rec, err := runstate.New(exportDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Record(testPRUCCID, map[string]bool{"alice": true}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	assignResume = rec.RunID()
+	t.Cleanup(func() { assignResume = "" })
+	if err := runAssign(assignCmd, nil); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if len(added[testPRUCCID]) != 0 || strings.Join(added[testCCID], ",") != "bob" {
+		t.Errorf("resume added = %v, want only the remainder (bob)", added)
+	}
+}
+
+func TestRunPRUAssign_PlanOutThenApplyPlan(t *testing.T) {
+	srv, added := pruTestServer(t, "")
+	exportDir := setupPRUAssign(t, srv, "plan")
+	planPath := filepath.Join(exportDir, "plan.json")
+	assignPlanOut, assignPlanMaxAge = planPath, plan.DefaultMaxAge
+	t.Cleanup(func() { assignPlanOut, assignPlanFile, assignPlanMaxAge = "", "", plan.DefaultMaxAge })
+
+	if err := runAssign(assignCmd, nil); err != nil {
+		t.Fatalf("plan --out: %v", err)
+	}
+	if len(added) != 0 {
+		t.Fatalf("plan --out added users: %v", added)
+	}
+	p, err := plan.Read(planPath)
+	if err != nil {
+		t.Fatalf("reading plan: %v", err)
+	}
+	if p.Enterprise != "test-ent" || p.ConfigHash != cfgManager.ConfigHash() {
+		t.Errorf("plan header = %+v", p)
+	}
+	if adds := p.Adds(); strings.Join(adds[testPRUCCID], ",") != "alice" || strings.Join(adds[testCCID], ",") != "bob" {
+		t.Errorf("plan adds = %v, want alice to PRUs Allowed and bob to No PRUs", adds)
+	}
+
+	assignMode, assignPlanOut, assignPlanFile = "apply", "", planPath
+	if err := runAssign(assignCmd, nil); err != nil {
+		t.Fatalf("apply --plan: %v", err)
+	}
+	if strings.Join(added[testPRUCCID], ",") != "alice" || strings.Join(added[testCCID], ",") != "bob" {
+		t.Errorf("apply --plan added = %v", added)
+	}
+}
+
+func TestRunPRUAssign_ApplyPlanRefusesStaleOrChanged(t *testing.T) {
+	srv, added := pruTestServer(t, "")
+	exportDir := setupPRUAssign(t, srv, "plan")
+	planPath := filepath.Join(exportDir, "plan.json")
+	assignPlanOut, assignPlanMaxAge = planPath, plan.DefaultMaxAge
+	t.Cleanup(func() { assignPlanOut, assignPlanFile, assignPlanMaxAge = "", "", plan.DefaultMaxAge })
+	if err := runAssign(assignCmd, nil); err != nil {
+		t.Fatalf("plan --out: %v", err)
+	}
+
+	assignMode, assignPlanOut, assignPlanFile = "apply", "", planPath
+	assignPlanMaxAge = time.Nanosecond
+	if err := runAssign(assignCmd, nil); err == nil || !strings.Contains(err.Error(), "older than") {
+		t.Errorf("stale plan: err = %v, want refusal", err)
+	}
+
+	assignPlanMaxAge = plan.DefaultMaxAge
+	cfgManager.APIBaseURL = srv.URL + "/other"
+	if err := runAssign(assignCmd, nil); err == nil || !strings.Contains(err.Error(), "configuration changed") {
+		t.Errorf("changed config: err = %v, want refusal", err)
+	}
+	if len(added) != 0 {
+		t.Errorf("a refused plan added users: %v", added)
+	}
+}
+
+func TestUnmappedError(t *testing.T) {
+	none := &teams.Unmapped{}
+	some := &teams.Unmapped{Teams: []teams.UnmappedTeam{{Team: "org1/sales", CopilotHolders: 2}}, Users: []string{"dave"}}
+
+	if err := unmappedError(some, false); err != nil {
+		t.Errorf("without --fail-on-unmapped: %v", err)
+	}
+	if err := unmappedError(none, true); err != nil {
+		t.Errorf("nothing unmapped: %v", err)
+	}
+	if err := unmappedError(some, true); err == nil || !strings.Contains(err.Error(), "1 teams with Copilot seat holders and 1 Copilot users") {
+		t.Errorf("err = %v, want the unmapped counts", err)
+	}
+}
+
+func TestLoadTierTeams_ExceptionTeams(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/enterprises/test-ent/teams/ml-research/memberships":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`[{"user":{"login":"Bob"}},{"user":{"login":"alice"}}]`))
+		default:
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	client := newTestGitHubClient(t, srv.URL)
+	cfg := &config.Manager{
+		NoPRUsCostCenterID:      testCCID,
+		PRUsAllowedCostCenterID: testPRUCCID,
+		PRUsExceptionUsers:      []string{"alice"},
+		PRUsExceptionTeams:      []string{"ml-research", "my-org/gone"},
+	}
+	users := []github.CopilotUser{{Login: "alice"}, {Login: "bob"}, {Login: "carol"}}
+
+	mgr := pru.NewManager(cfg, quietLogger())
+	if err := loadTierTeams(client, mgr, false, quietLogger()); err != nil {
+		t.Fatalf("loadTierTeams: %v, want the missing team skipped", err)
+	}
+	if got := mgr.AssignCostCenter(github.CopilotUser{Login: "bob"}); got != testPRUCCID {
+		t.Errorf("bob -> %q, want the PRU-allowed cost center through ml-research", got)
+	}
+	if explicit, fromTeams := mgr.ExceptionSources(users); explicit != 1 || fromTeams != 1 {
+		t.Errorf("ExceptionSources = %d explicit, %d from teams; want 1 and 1", explicit, fromTeams)
+	}
+
+	err := loadTierTeams(client, pru.NewManager(cfg, quietLogger()), true, quietLogger())
+	if err == nil || !strings.Contains(err.Error(), "my-org/gone") {
+		t.Errorf("with failFast: err = %v, want the missing team named", err)
+	}
+}
+
+func TestRunPRUAssign_PlanResolvesUserOverrides(t *testing.T) {
+	const contractorsID = "33333333-3333-4333-8333-333333333333"
+	pru, added := pruTestServer(t, "")
+	var mu sync.Mutex
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/cost-centers") {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{"costCenters": []map[string]string{
+				{"id": testCCID, "name": "No PRUs", "state": "active"},
+				{"id": testPRUCCID, "name": "PRUs Allowed", "state": "active"},
+				{"id": contractorsID, "name": "Contractors", "state": "active"},
+			}})
+			return
+		}
+		pru.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	setupPRUAssign(t, srv, "plan")
+	assignCheckCurrentCC = true
+	t.Cleanup(func() { assignCheckCurrentCC = false })
+	cfgManager.PRUsUserOverrides = map[string]string{"bob": "Contractors"}
+
+	var err error
+	out := captureStdout(t, func() { err = runAssign(assignCmd, nil) })
+	if err != nil {
+		t.Fatalf("runAssign: %v", err)
+	}
+	if !strings.Contains(out, "User override ("+contractorsID+")") {
+		t.Errorf("summary does not show the resolved override ID:\n%s", out)
+	}
+	for _, p := range paths {
+		if strings.Contains(p, "Contractors") {
+			t.Errorf("requested %s: the override name was used as a cost center ID", p)
+		}
+	}
+	if len(added) != 0 {
+		t.Errorf("plan added users: %v", added)
+	}
+}
+
+func TestRunPRUAssign_ReconcileTierBudgets(t *testing.T) {
+	pru, _ := pruTestServer(t, "")
+	_, sku := github.GetBudgetTypeAndSKU("copilot_premium_request")
+	var mu sync.Mutex
+	var patches []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Path, "/settings/billing/budgets") {
+			pru.Config.Handler.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(map[string]any{"budgets": []map[string]any{{
+				"id": "budget-1", "budget_scope": "cost_center", "budget_entity_name": testPRUCCID,
+				"budget_product_sku": sku, "budget_amount": 100, "prevent_further_usage": true,
+			}}})
+		case http.MethodPatch:
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			patches = append(patches, body)
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			t.Errorf("unexpected budget request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+	setupPRUAssign(t, srv, "apply")
+	cfgManager.BudgetsEnabled = true
+	cfgManager.BudgetCostCenterOverrides = map[string]map[string]int{config.BudgetKeyPRUsAllowed: {"copilot_premium_request": 500}}
+	assignCreateBudgets, assignReconcileBudgets = true, true
+	t.Cleanup(func() { assignCreateBudgets, assignReconcileBudgets = false, false })
+
+	if err := runAssign(assignCmd, nil); err != nil {
+		t.Fatalf("runAssign: %v", err)
+	}
+	if len(patches) != 1 || patches[0]["budget_amount"] != float64(500) {
+		t.Errorf("PATCH bodies = %v, want one updating the amount to 500", patches)
+	}
+}
+
+func TestTierBudgets_CostCenterOverrides(t *testing.T) {
+	cfg := &config.Manager{BudgetCostCenterOverrides: map[string]map[string]int{
+		config.BudgetKeyPRUsAllowed: {"copilot_premium_request": 500},
+		config.BudgetKeyNoPRUs:      {"copilot_premium_request": 0},
+		"No PRUs":                   {"copilot_premium_request": 10}, // the name wins over no_prus
+	}}
+	tiers := []pru.Tier{
+		{Name: "PRU Overages Allowed", CostCenterName: "PRUs allowed", BudgetAmount: 50},
+		{Name: "No PRU Overages", CostCenterName: "No PRUs"},
+	}
+	if got := tierBudgets(cfg, 0, tiers[0]); !slices.Equal(got, []tierBudget{{"copilot", 50}, {"copilot_premium_request", 500}}) {
+		t.Errorf("PRU-allowed budgets = %v", got)
+	}
+	if got := tierBudgets(cfg, 1, tiers[1]); !slices.Equal(got, []tierBudget{{"copilot_premium_request", 10}}) {
+		t.Errorf("no-PRU budgets = %v", got)
+	}
+
+	var buf bytes.Buffer
+	printTierBudgetPlan(&buf, &config.Manager{}, tiers[1:])
+	if want := "  No PRUs: no budgets\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("output missing %q:\n%s", want, buf.String())
+	}
+}
+
+func TestSaveCreatedIDs(t *testing.T) {
+	const created = "7c3f5a2e-9d8b-4e6f-a1c2-3b4d5e6f7a8b"
+	t.Setenv("GITHUB_ENTERPRISE", "test-ent")
+	cfgPath := filepath.Join(t.TempDir(), "config.yaml")
+	yaml := `# keep me
+cost_center:
+  users:
+    no_prus_cost_center_id: "` + testCCID + `"
+    prus_allowed_cost_center_name: "PRUs Allowed" # named tier
+    exception_users: ["alice"]
+`
+	if err := os.WriteFile(cfgPath, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := config.Load(cfgPath, quietLogger())
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+
+	// Tier 1 (no-PRU) was found by its configured ID; only tier 0 is new.
+	if err := saveCreatedIDs(m, map[int]string{0: created, 1: testCCID}, quietLogger()); err != nil {
+		t.Fatalf("saveCreatedIDs: %v", err)
+	}
+	data, err := os.ReadFile(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{"# keep me", "# named tier", `prus_allowed_cost_center_id: "` + created + `"`, `no_prus_cost_center_id: "` + testCCID + `"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("config missing %q:\n%s", want, got)
+		}
+	}
+	if _, err := os.Stat(cfgPath + ".bak"); err != nil {
+		t.Errorf("backup: %v", err)
+	}
+}
+
+func TestRunPRUAssign_DepartedSeats(t *testing.T) {
+	base, added := pruTestServer(t, "")
+	var mu sync.Mutex
+	shrunk := false
+	removed := map[string][]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case shrunk && strings.HasSuffix(r.URL.Path, "/copilot/billing/seats"):
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{"total_seats": 1, "seats": []map[string]any{
+				{"assignee": map[string]string{"login": "bob", "type": "User"}, "created_at": "2025-06-01T00:00:00Z"},
+			}})
+		case r.Method == http.MethodDelete && strings.HasSuffix(r.URL.Path, "/resource"):
+			var body struct {
+				Users []string `json:"users"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			id := filepath.Base(filepath.Dir(r.URL.Path))
+			removed[id] = append(removed[id], body.Users...)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			base.Config.Handler.ServeHTTP(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	setupPRUAssign(t, srv, "apply")
+
+	// First run: alice and bob hold seats and go into the ledger.
+	if err := runAssign(assignCmd, nil); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	if len(added[testPRUCCID]) != 1 || len(added[testCCID]) != 1 {
+		t.Fatalf("first run added = %v, want alice and bob", added)
+	}
+
+	// alice loses her seat.  Without remove_departed_users she is only
+	// reported.
+	mu.Lock()
+	shrunk = true
+	mu.Unlock()
+	var err error
+	out := captureStdout(t, func() { err = runAssign(assignCmd, nil) })
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	for _, want := range []string{"Seats removed since the last run (1):", "  - alice (cost center " + testPRUCCID + ")", "Users without a Copilot seat anymore: 1"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("second run output missing %q:\n%s", want, out)
+		}
+	}
+	if len(removed) != 0 {
+		t.Errorf("second run removed = %v, want nothing without remove_departed_users", removed)
+	}
+
+	// With remove_departed_users she is removed and forgotten.
+	cfgManager.RemoveDepartedUsers = true
+	out = captureStdout(t, func() { err = runAssign(assignCmd, nil) })
+	if err != nil {
+		t.Fatalf("third run: %v", err)
+	}
+	if strings.Join(removed[testPRUCCID], ",") != "alice" {
+		t.Errorf("third run removed = %v, want alice from %s", removed, testPRUCCID)
+	}
+	if !strings.Contains(out, "Removed from their cost center: 1 users") {
+		t.Errorf("third run output missing the removal count:\n%s", out)
+	}
+	processed, err := ledger.Load(cfgManager.ProcessedUsersFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed.Len() != 1 || !processed.NeedsProcessing("alice", testPRUCCID) {
+		t.Errorf("ledger after removal: want only bob, got %d users", processed.Len())
+	}
+}
+
+// captureStdout returns what fn writes to os.Stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- data
+	}()
+	orig := os.Stdout
+	os.Stdout = w
+	fn()
+	os.Stdout = orig
+	_ = w.Close()
+	return string(<-done)
+}
+
+func TestRunPRUAssign_StrictFetch(t *testing.T) {
+	base, added := pruTestServer(t, "")
+	// The API reports 5 seats but lists only alice and bob.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/copilot/billing/seats") {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{"total_seats": 5, "seats": []map[string]any{
+				{"assignee": map[string]string{"login": "alice", "type": "User"}},
+				{"assignee": map[string]string{"login": "bob", "type": "User"}},
+			}})
+			return
+		}
+		base.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	setupPRUAssign(t, srv, "apply")
+	assignIncremental = false
+
+	assignStrictFetch = true
+	err := runAssign(assignCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "total_seats 5") {
+		t.Fatalf("err = %v, want a --strict-fetch mismatch error", err)
+	}
+	if len(added) != 0 {
+		t.Errorf("added = %v, want nothing applied", added)
+	}
+
+	// Plan mode and runs without --strict-fetch only warn.
+	assignMode = "plan"
+	if err := runAssign(assignCmd, nil); err != nil {
+		t.Errorf("plan with --strict-fetch: %v", err)
+	}
+	assignMode, assignStrictFetch = "apply", false
+	if err := runAssign(assignCmd, nil); err != nil {
+		t.Errorf("apply without --strict-fetch: %v", err)
+	}
+	if len(added[testPRUCCID])+len(added[testCCID]) != 2 {
+		t.Errorf("added = %v, want alice and bob", added)
+	}
+}
+
+func TestRemoveDepartedUsers_FakeAPI(t *testing.T) {
+	prevCfg := cfgManager
+	t.Cleanup(func() { cfgManager = prevCfg })
+	cfgManager = &config.Manager{ProcessedUsersFile: filepath.Join(t.TempDir(), "processed_users.json")}
+
+	fake := githubtest.New()
+	first := fake.AddCostCenter("No PRUs", "alice", "bob")
+	second := fake.AddCostCenter("PRUs Allowed", "carol")
+	processed, err := ledger.Load(cfgManager.ProcessedUsersFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := processed.Record(first, map[string]bool{"alice": true, "bob": true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := processed.Record(second, map[string]bool{"carol": true}); err != nil {
+		t.Fatal(err)
+	}
+
+	departed := processed.Departed(map[string]bool{"bob": true})
+	removed, err := removeDepartedUsers(fake, departed, processed, quietLogger())
+	if err != nil || removed != 2 {
+		t.Fatalf("removeDepartedUsers = %d, %v; want alice and carol removed", removed, err)
+	}
+	if got := fake.Users(first); strings.Join(got, ",") != "bob" {
+		t.Errorf("No PRUs = %v, want bob only", got)
+	}
+	if got := processed.Departed(map[string]bool{"bob": true}); len(got) != 0 {
+		t.Errorf("Departed after removal = %v, want the removed users forgotten", got)
+	}
+}
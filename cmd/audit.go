@@ -0,0 +1,385 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/actions"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/pru"
+	"github.com/renan-alm/gh-cost-center/internal/render"
+	"github.com/renan-alm/gh-cost-center/internal/teams"
+)
+
+var (
+	auditOutput      string
+	auditFailOnDrift bool
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Compare desired and actual cost center membership",
+	Long: `Compute the assignment the active mode would make (as assign --mode plan
+does), fetch the current membership of every active cost center, and report
+four buckets:
+
+  correct       users already in their desired cost center
+  missing       users who should be in a cost center but are in none
+  misplaced     users in a different cost center than desired
+  unrecognized  members of a managed cost center this configuration does not
+                assign at all (excluded users are left out)
+
+Managed cost centers are the desired ones plus the active cost centers whose
+name matches the configuration (as for budgets cleanup).  Missing, misplaced,
+and unrecognized users are drift; --fail-on-drift exits with status 5 when
+there is any, for scheduled CI checks.  Supported in users, teams, and
+assigning-team modes.  Nothing is changed.
+
+Examples:
+  gh cost-center audit
+  gh cost-center audit --output json --fail-on-drift > audit.json`,
+	RunE: runAudit,
+}
+
+func init() {
+	auditCmd.Flags().StringVarP(&auditOutput, "output", "o", "table", "output format: table or json")
+	auditCmd.Flags().BoolVar(&auditFailOnDrift, "fail-on-drift", false, "exit with status 5 when any drift is found")
+	rootCmd.AddCommand(auditCmd)
+}
+
+// auditEntry is one user in an audit bucket.  The desired fields are empty
+// for unrecognized users, the actual ones for missing users.
+type auditEntry struct {
+	Login     string `json:"login"`
+	Desired   string `json:"desired_cost_center,omitempty"`
+	DesiredID string `json:"desired_cost_center_id,omitempty"`
+	Actual    string `json:"actual_cost_center,omitempty"`
+	ActualID  string `json:"actual_cost_center_id,omitempty"`
+}
+
+// auditReport is the result of an audit.
+type auditReport struct {
+	Mode         string       `json:"mode"`
+	Correct      []auditEntry `json:"correct"`
+	Missing      []auditEntry `json:"missing"`
+	Misplaced    []auditEntry `json:"misplaced"`
+	Unrecognized []auditEntry `json:"unrecognized"`
+}
+
+// drift returns the number of users not in their desired state.
+func (r *auditReport) drift() int {
+	return len(r.Missing) + len(r.Misplaced) + len(r.Unrecognized)
+}
+
+// desiredCC is the cost center a user should be in.  id is "" when the cost
+// center does not exist yet.
+type desiredCC struct {
+	name string
+	id   string
+}
+
+func runAudit(_ *cobra.Command, _ []string) error {
+	if auditOutput != "table" && auditOutput != "json" {
+		return usageErrorf("invalid --output %q: must be 'table' or 'json'", auditOutput)
+	}
+	mode := cfgManager.CostCenterMode
+	if mode == "repos" || mode == "custom-prop" {
+		return fmt.Errorf("audit compares user membership and is not supported in %s mode, which assigns repositories", mode)
+	}
+
+	logger := slog.Default()
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	attachCache(client, logger)
+
+	logger.Info("Fetching current cost center membership...")
+	active, members, err := client.GetAllActiveCostCentersWithMembers(membershipFetchConcurrency)
+	if err != nil {
+		return fmt.Errorf("fetching cost center membership: %w", err)
+	}
+
+	var users []github.CopilotUser
+	if cfgManager.CostCenterMode != "teams" {
+		if users, err = client.GetCopilotUsers(); err != nil {
+			return fmt.Errorf("fetching copilot users: %w", err)
+		}
+	}
+	groups, names, err := desiredGroups(client, users, logger)
+	if err != nil {
+		return err
+	}
+	desired := resolveDesired(groups, names, active)
+	managed := managedCostCenters(active, managedCostCenterPatterns(cfgManager))
+
+	report := buildAuditReport(desired, active, members, managed, cfgManager.IsExcludedUser)
+	if mode == "" {
+		mode = "users"
+	}
+	report.Mode = mode
+
+	if auditOutput == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("encoding audit report: %w", err)
+		}
+	} else {
+		printAuditReport(os.Stdout, report)
+	}
+	writeAuditStepSummary(report, logger)
+	return driftError(report, auditFailOnDrift)
+}
+
+// desiredGroups computes the desired assignment of the active mode as group
+// key -> logins, and the cost center name of each group key.  users are the
+// Copilot seat holders (unused in teams mode); excluded users are dropped.
+func desiredGroups(client github.CostCenterAPI, users []github.CopilotUser, logger *slog.Logger) (map[string][]string, map[string]string, error) {
+	byName := func(assignments map[string][]teams.UserAssignment) (map[string][]string, map[string]string) {
+		groups := make(map[string][]string, len(assignments))
+		names := make(map[string]string, len(assignments))
+		for cc, list := range assignments {
+			for _, a := range list {
+				groups[cc] = append(groups[cc], a.Username)
+			}
+			names[cc] = cc
+		}
+		return groups, names
+	}
+
+	switch cfgManager.CostCenterMode {
+	case "teams":
+		mgr := teams.NewManager(cfgManager, client, logger)
+		assignments, err := mgr.BuildTeamAssignments()
+		if err != nil {
+			return nil, nil, fmt.Errorf("building team assignments: %w", err)
+		}
+		groups, names := byName(assignments)
+		return groups, names, nil
+
+	case "assigning-team":
+		users, _ = excludeUsers(users, logger)
+		groups, names := byName(teams.BuildAssigningTeamAssignments(users, cfgManager.AssigningTeamDefaultCostCenter))
+		return groups, names, nil
+
+	default:
+		mgr := pru.NewManager(cfgManager, logger)
+		if err := loadTierTeams(client, mgr, false, logger); err != nil {
+			return nil, nil, err
+		}
+		users, _ = excludeUsers(users, logger)
+		return mgr.AssignmentGroups(users), groupNames(mgr), nil
+	}
+}
+
+// resolveDesired maps every login in groups to its desired cost center.
+// Group keys that are UUIDs are used as IDs; other keys are looked up by
+// name among the active cost centers.
+func resolveDesired(groups map[string][]string, names map[string]string, active map[string]string) map[string]desiredCC {
+	idToName := reverseMap(active)
+	desired := make(map[string]desiredCC)
+	for key, logins := range groups {
+		cc := desiredCC{name: names[key]}
+		if github.IsValidCostCenterUUID(key) {
+			cc.id = key
+			if name, ok := idToName[key]; ok {
+				cc.name = name
+			}
+		} else {
+			cc.id = active[cc.name]
+		}
+		if cc.name == "" {
+			cc.name = key
+		}
+		for _, login := range logins {
+			desired[login] = cc
+		}
+	}
+	return desired
+}
+
+// buildAuditReport sorts the users into the audit buckets.  desired maps
+// login -> desired cost center, active name -> ID, members cost center ID ->
+// logins, and managed the managed cost centers (name -> ID).  Members of a
+// managed or desired cost center that are not in desired are unrecognized
+// unless ignore reports true for them.
+func buildAuditReport(desired map[string]desiredCC, active map[string]string, members map[string][]string,
+	managed map[string]string, ignore func(string) bool) *auditReport {
+	idToName := reverseMap(active)
+	memberOf := membershipIndex(members)
+
+	report := &auditReport{
+		Correct:      []auditEntry{},
+		Missing:      []auditEntry{},
+		Misplaced:    []auditEntry{},
+		Unrecognized: []auditEntry{},
+	}
+	known := make(map[string]bool, len(desired))
+	scope := make(map[string]bool, len(managed))
+	for _, id := range managed {
+		scope[id] = true
+	}
+	for login, cc := range desired {
+		known[strings.ToLower(login)] = true
+		if cc.id != "" {
+			scope[cc.id] = true
+		}
+		e := auditEntry{Login: login, Desired: cc.name, DesiredID: cc.id}
+		have, ok := memberOf[strings.ToLower(login)]
+		switch {
+		case !ok:
+			report.Missing = append(report.Missing, e)
+		case have == cc.id:
+			e.Actual, e.ActualID = idToName[have], have
+			report.Correct = append(report.Correct, e)
+		default:
+			e.Actual, e.ActualID = idToName[have], have
+			report.Misplaced = append(report.Misplaced, e)
+		}
+	}
+
+	for id := range scope {
+		for _, login := range members[id] {
+			if known[strings.ToLower(login)] || (ignore != nil && ignore(login)) {
+				continue
+			}
+			report.Unrecognized = append(report.Unrecognized, auditEntry{Login: login, Actual: idToName[id], ActualID: id})
+		}
+	}
+
+	for _, bucket := range [][]auditEntry{report.Correct, report.Missing, report.Misplaced, report.Unrecognized} {
+		sort.Slice(bucket, func(i, j int) bool { return strings.ToLower(bucket[i].Login) < strings.ToLower(bucket[j].Login) })
+	}
+	return report
+}
+
+// membershipIndex maps each lower-cased login in members (cost center ID ->
+// logins) to its cost center.  A user in several cost centers gets the
+// lowest ID, so the result is deterministic.
+func membershipIndex(members map[string][]string) map[string]string {
+	ids := make([]string, 0, len(members))
+	for id := range members {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	memberOf := make(map[string]string)
+	for _, id := range ids {
+		for _, login := range members[id] {
+			if _, seen := memberOf[strings.ToLower(login)]; !seen {
+				memberOf[strings.ToLower(login)] = id
+			}
+		}
+	}
+	return memberOf
+}
+
+// reverseMap inverts a name -> ID map.
+func reverseMap(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[v] = k
+	}
+	return out
+}
+
+// printAuditReport writes the bucket counts and the drifted users.
+func printAuditReport(w io.Writer, r *auditReport) {
+	p := render.Auto(w)
+	p.Printf("\n=== Cost Center Audit (%s mode) ===\n", r.Mode)
+	count := func(label string, n int) []string {
+		if n > 0 {
+			label = p.Warn(label)
+		}
+		return []string{label + ":", strconv.Itoa(n)}
+	}
+	p.Table("", [][]string{
+		{"Correctly assigned:", strconv.Itoa(len(r.Correct))},
+		count("Missing", len(r.Missing)),
+		count("Misplaced", len(r.Misplaced)),
+		count("Unrecognized", len(r.Unrecognized)),
+	})
+
+	if len(r.Missing) > 0 {
+		p.Println("\n" + p.Warn("Missing (in no cost center):"))
+		for _, e := range r.Missing {
+			p.Printf("  - %s -> %s\n", e.Login, p.Add(e.Desired))
+		}
+	}
+	if len(r.Misplaced) > 0 {
+		p.Println("\n" + p.Warn("Misplaced (in another cost center):"))
+		for _, e := range r.Misplaced {
+			p.Printf("  - %s: %s -> %s\n", e.Login, p.Remove(e.Actual), p.Add(e.Desired))
+		}
+	}
+	if len(r.Unrecognized) > 0 {
+		p.Println("\n" + p.Warn("Unrecognized (in a managed cost center, not assigned by this configuration):"))
+		for _, e := range r.Unrecognized {
+			p.Printf("  - %s in %s\n", e.Login, e.Actual)
+		}
+	}
+	if r.drift() == 0 {
+		p.Println("\n" + p.Add("No drift: cost center membership matches the configuration."))
+	}
+}
+
+// writeAuditStepSummary appends the bucket counts and drifted users to the
+// GitHub Actions step summary and emits a ::warning:: annotation (an ::error::
+// one with --fail-on-drift) when there is drift.  Outside Actions it does
+// nothing.
+func writeAuditStepSummary(r *auditReport, logger *slog.Logger) {
+	if !actions.Running() {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Cost center audit (%s mode)\n\n", r.Mode)
+	b.WriteString(actions.Table([]string{"Bucket", "Users"}, [][]string{
+		{"Correct", strconv.Itoa(len(r.Correct))},
+		{"Missing", strconv.Itoa(len(r.Missing))},
+		{"Misplaced", strconv.Itoa(len(r.Misplaced))},
+		{"Unrecognized", strconv.Itoa(len(r.Unrecognized))},
+	}))
+	if r.drift() == 0 {
+		b.WriteString("\nNo drift: cost center membership matches the configuration.\n")
+	} else {
+		var rows [][]string
+		for _, e := range r.Missing {
+			rows = append(rows, []string{e.Login, "missing", "", e.Desired})
+		}
+		for _, e := range r.Misplaced {
+			rows = append(rows, []string{e.Login, "misplaced", e.Actual, e.Desired})
+		}
+		for _, e := range r.Unrecognized {
+			rows = append(rows, []string{e.Login, "unrecognized", e.Actual, ""})
+		}
+		fmt.Fprintf(&b, "\n### Drift (%d)\n\n", r.drift())
+		b.WriteString(actions.Table([]string{"User", "Bucket", "Actual", "Desired"}, rows))
+
+		msg := fmt.Sprintf("Cost center drift: %d missing, %d misplaced, %d unrecognized", len(r.Missing), len(r.Misplaced), len(r.Unrecognized))
+		if auditFailOnDrift {
+			actions.Error(os.Stderr, msg)
+		} else {
+			actions.Warning(os.Stderr, msg)
+		}
+	}
+	if err := actions.AppendSummary(b.String()); err != nil {
+		logger.Warn("Could not write the step summary", "error", err)
+	}
+}
+
+// driftError returns an exitCodeDrift error for --fail-on-drift when the
+// report has any drift.
+func driftError(r *auditReport, failOnDrift bool) error {
+	if !failOnDrift || r.drift() == 0 {
+		return nil
+	}
+	return &exitError{code: exitCodeDrift, err: fmt.Errorf("%d users drifted from the desired cost center assignment", r.drift())}
+}
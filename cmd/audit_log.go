@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/auditlog"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/render"
+)
+
+var (
+	auditLogSince     string
+	auditLogOperation string
+	auditLogOutput    string
+)
+
+var auditLogCmd = &cobra.Command{
+	Use:   "audit-log",
+	Short: "Show the trail of changes made through the API",
+	Long: `Show the entries of <export_dir>/audit.log, the append-only trail of every
+change this tool made through the API: users added to or removed from cost
+centers, cost centers created, renamed, or deleted, and budgets created,
+updated, or deleted.  Each entry records when, by whom (the token owner when
+it can be resolved), on which enterprise, the target, and whether it
+succeeded.  Plan runs change nothing and write no entries.
+
+--since keeps entries newer than a duration (7d, 12h) or a date
+(2026-01-02, or an RFC 3339 time).
+
+Examples:
+  gh cost-center audit-log --since 7d
+  gh cost-center audit-log --operation remove_users --output json`,
+	Args: cobra.NoArgs,
+	RunE: runAuditLog,
+}
+
+func init() {
+	auditLogCmd.Flags().StringVar(&auditLogSince, "since", "", "only entries newer than a duration (7d, 12h) or a date (2026-01-02)")
+	auditLogCmd.Flags().StringVar(&auditLogOperation, "operation", "", "only entries of this operation, e.g. add_users or create_budget")
+	auditLogCmd.Flags().StringVarP(&auditLogOutput, "output", "o", "text", "output format: text or json")
+
+	rootCmd.AddCommand(auditLogCmd)
+}
+
+func runAuditLog(_ *cobra.Command, _ []string) error {
+	if auditLogOutput != "text" && auditLogOutput != "json" {
+		return usageErrorf("invalid --output %q: must be text or json", auditLogOutput)
+	}
+	since, err := parseSince(auditLogSince, time.Now())
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(cfgManager.ExportDir, auditlog.FileName)
+	entries, skipped, err := auditlog.Read(path, since)
+	if err != nil {
+		return err
+	}
+	if skipped > 0 {
+		slog.Default().Warn("Skipped unreadable audit log lines", "path", path, "count", skipped)
+	}
+	if auditLogOperation != "" {
+		kept := entries[:0]
+		for _, e := range entries {
+			if e.Operation == auditLogOperation {
+				kept = append(kept, e)
+			}
+		}
+		entries = kept
+	}
+	return writeAuditLog(os.Stdout, auditLogOutput, entries)
+}
+
+// parseSince turns --since into the earliest time to show: a number of days
+// ("7d"), a Go duration ("12h"), a date, or an RFC 3339 time.  An empty
+// value shows everything.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, usageErrorf("invalid --since %q: use a duration such as 7d or 12h, or a date such as 2026-01-02", s)
+}
+
+// writeAuditLog writes entries to w as a table or as a JSON array.
+func writeAuditLog(w io.Writer, format string, entries []auditlog.Entry) error {
+	if format == "json" {
+		if entries == nil {
+			entries = []auditlog.Entry{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(entries); err != nil {
+			return fmt.Errorf("encoding audit log: %w", err)
+		}
+		return nil
+	}
+
+	p := render.Auto(w)
+	if len(entries) == 0 {
+		p.Println("No audit log entries.")
+		return nil
+	}
+	rows := [][]string{{"TIME", "ACTOR", "OPERATION", "TARGET", "RESULT", "DETAILS"}}
+	for _, e := range entries {
+		actor := e.Actor
+		if actor == "" {
+			actor = "-"
+		}
+		result := p.Add(e.Result)
+		if e.Result != auditlog.ResultSuccess {
+			result = p.Remove(e.Result)
+		}
+		var details []string
+		if len(e.Items) > 0 {
+			details = append(details, strings.Join(e.Items, ","))
+		}
+		if e.Detail != "" {
+			details = append(details, e.Detail)
+		}
+		if e.Error != "" {
+			details = append(details, e.Error)
+		}
+		rows = append(rows, []string{e.Time.Local().Format(time.DateTime), actor, e.Operation, e.Target, result, strings.Join(details, " ")})
+	}
+	p.Table("", rows)
+	p.Printf("%d entries\n", len(entries))
+	return nil
+}
+
+// attachAuditLog appends every mutation client sends to the audit trail in
+// the export directory.  The file is written and the token owner looked up
+// only on the first mutation, so plan runs leave no trace.
+func attachAuditLog(client *github.Client, logger *slog.Logger) {
+	trail := auditlog.New(filepath.Join(cfgManager.ExportDir, auditlog.FileName), cfgManager.Enterprise, func() string {
+		login, err := client.AuthenticatedLogin()
+		if err != nil {
+			logger.Debug("Could not resolve the token owner for the audit log", "error", err)
+		}
+		return login
+	})
+	client.SetMutationRecorder(func(m github.Mutation) {
+		if err := trail.Append(m.Operation, m.Target, m.Items, m.Detail, m.Err); err != nil {
+			logger.Warn("Could not write the audit log", "error", err)
+		}
+	})
+}
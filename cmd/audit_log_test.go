@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/auditlog"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"", time.Time{}},
+		{"7d", now.AddDate(0, 0, -7)},
+		{"12h", now.Add(-12 * time.Hour)},
+		{"2026-03-01", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"2026-03-01T08:00:00Z", time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseSince(tt.in, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseSince(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"week", "-3d", "-1h"} {
+		if _, err := parseSince(bad, now); exitCode(err) != exitCodeConfig {
+			t.Errorf("parseSince(%q) err = %v, want a usage error", bad, err)
+		}
+	}
+}
+
+func TestWriteAuditLog_Text(t *testing.T) {
+	at := time.Date(2026, 3, 1, 8, 0, 0, 0, time.Local)
+	entries := []auditlog.Entry{
+		{Time: at, Actor: "octocat", Operation: "add_users", Target: testCCID, Items: []string{"alice", "bob"}, Result: auditlog.ResultSuccess},
+		{Time: at, Operation: "create_budget", Target: testCCID, Detail: "amount=5", Result: auditlog.ResultFailure, Error: "API error 422"},
+	}
+	var buf bytes.Buffer
+	if err := writeAuditLog(&buf, "text", entries); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"2026-03-01 08:00:00  octocat  add_users      " + testCCID + "  success  alice,bob\n",
+		"2026-03-01 08:00:00  -        create_budget  " + testCCID + "  failure  amount=5 API error 422\n",
+		"2 entries\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	if err := writeAuditLog(&buf, "json", nil); err != nil || strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("json with no entries = %q, %v", buf.String(), err)
+	}
+}
+
+func TestRunAssign_AuditLog(t *testing.T) {
+	srv, _ := pruTestServer(t, testPRUCCID)
+	exportDir := setupPRUAssign(t, srv, "plan")
+	path := filepath.Join(exportDir, auditlog.FileName)
+
+	if err := runAssign(assignCmd, nil); err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("plan run wrote %s (stat err %v)", path, err)
+	}
+
+	assignMode = "apply"
+	if err := runAssign(assignCmd, nil); exitCode(err) != exitCodePartial {
+		t.Fatalf("apply: %v, want a partial failure", err)
+	}
+	entries, _, err := auditlog.Read(path, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := map[string]string{}
+	for _, e := range entries {
+		if e.Operation != "add_users" || e.Enterprise != "test-ent" || len(e.Items) != 1 {
+			t.Errorf("unexpected entry %+v", e)
+			continue
+		}
+		results[e.Items[0]] = e.Result
+	}
+	if results["alice"] != auditlog.ResultFailure || results["bob"] != auditlog.ResultSuccess {
+		t.Errorf("results = %v, want alice failed and bob added", results)
+	}
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testOtherCCID = "a1b2c3d4-e5f6-7890-abcd-ef1234567890"
+
+func auditLogins(entries []auditEntry) string {
+	logins := make([]string, len(entries))
+	for i, e := range entries {
+		logins[i] = e.Login
+	}
+	return strings.Join(logins, ",")
+}
+
+func TestBuildAuditReport_Buckets(t *testing.T) {
+	active := map[string]string{"No PRUs": testCCID, "PRUs Allowed": testPRUCCID, "Unmanaged": testOtherCCID}
+	members := map[string][]string{
+		testCCID:      {"alice", "Carol", "stranger", "ceo"},
+		testPRUCCID:   {"bob"},
+		testOtherCCID: {"dave", "outsider"},
+	}
+	desired := map[string]desiredCC{
+		"alice": {name: "No PRUs", id: testCCID},         // correct
+		"bob":   {name: "No PRUs", id: testCCID},         // misplaced (managed)
+		"carol": {name: "No PRUs", id: testCCID},         // correct, case-insensitive
+		"dave":  {name: "PRUs Allowed", id: testPRUCCID}, // misplaced (unmanaged)
+		"erin":  {name: "PRUs Allowed", id: testPRUCCID}, // missing
+		"frank": {name: "New Tier"},                      // missing, CC not created yet
+	}
+	managed := map[string]string{"No PRUs": testCCID, "PRUs Allowed": testPRUCCID}
+	ignore := func(login string) bool { return login == "ceo" }
+
+	r := buildAuditReport(desired, active, members, managed, ignore)
+
+	if got := auditLogins(r.Correct); got != "alice,carol" {
+		t.Errorf("correct = %s, want alice,carol", got)
+	}
+	if got := auditLogins(r.Missing); got != "erin,frank" {
+		t.Errorf("missing = %s, want erin,frank", got)
+	}
+	if got := auditLogins(r.Misplaced); got != "bob,dave" {
+		t.Errorf("misplaced = %s, want bob,dave", got)
+	}
+	if r.Misplaced[1].Actual != "Unmanaged" || r.Misplaced[1].Desired != "PRUs Allowed" {
+		t.Errorf("dave = %+v, want Unmanaged -> PRUs Allowed", r.Misplaced[1])
+	}
+	// outsider is only in an unmanaged cost center and ceo is ignored.
+	if got := auditLogins(r.Unrecognized); got != "stranger" {
+		t.Errorf("unrecognized = %s, want stranger", got)
+	}
+	if r.drift() != 5 {
+		t.Errorf("drift = %d, want 5", r.drift())
+	}
+}
+
+func TestBuildAuditReport_NoDrift(t *testing.T) {
+	active := map[string]string{"No PRUs": testCCID}
+	members := map[string][]string{testCCID: {"alice"}}
+	desired := map[string]desiredCC{"alice": {name: "No PRUs", id: testCCID}}
+
+	r := buildAuditReport(desired, active, members, nil, nil)
+	if r.drift() != 0 || len(r.Correct) != 1 {
+		t.Fatalf("report = %+v, want alice correct and no drift", r)
+	}
+	if err := driftError(r, true); err != nil {
+		t.Errorf("driftError without drift = %v", err)
+	}
+
+	var buf bytes.Buffer
+	printAuditReport(&buf, r)
+	if !strings.Contains(buf.String(), "No drift") {
+		t.Errorf("output missing no-drift line:\n%s", buf.String())
+	}
+}
+
+func TestResolveDesired(t *testing.T) {
+	active := map[string]string{"No PRUs": testCCID, "Override": testOtherCCID}
+	groups := map[string][]string{
+		testCCID:   {"alice"},
+		"Override": {"bob"},
+		"Missing":  {"carol"},
+	}
+	names := map[string]string{testCCID: "00 - No PRUs (configured)", "Override": "Override", "Missing": "Missing"}
+
+	d := resolveDesired(groups, names, active)
+	if d["alice"] != (desiredCC{name: "No PRUs", id: testCCID}) {
+		t.Errorf("alice = %+v, want the live name of the configured ID", d["alice"])
+	}
+	if d["bob"] != (desiredCC{name: "Override", id: testOtherCCID}) {
+		t.Errorf("bob = %+v, want the override resolved by name", d["bob"])
+	}
+	if d["carol"] != (desiredCC{name: "Missing"}) {
+		t.Errorf("carol = %+v, want an unresolved cost center", d["carol"])
+	}
+}
+
+func TestRunAudit_FailOnDrift(t *testing.T) {
+	srv, _ := pruTestServer(t, "")
+	setupPRUAssign(t, srv, "plan")
+	prevOutput, prevFail := auditOutput, auditFailOnDrift
+	t.Cleanup(func() { auditOutput, auditFailOnDrift = prevOutput, prevFail })
+
+	// The test server's cost centers are empty, so alice and bob are missing.
+	auditOutput, auditFailOnDrift = "json", true
+	var ee *exitError
+	if err := runAudit(auditCmd, nil); !errors.As(err, &ee) || ee.code != exitCodeDrift {
+		t.Fatalf("err = %v, want exit code %d", err, exitCodeDrift)
+	}
+
+	auditFailOnDrift = false
+	if err := runAudit(auditCmd, nil); err != nil {
+		t.Errorf("without --fail-on-drift: %v", err)
+	}
+}
+
+func TestRunAudit_WritesStepSummary(t *testing.T) {
+	srv, _ := pruTestServer(t, "")
+	setupPRUAssign(t, srv, "plan")
+	prevOutput, prevFail := auditOutput, auditFailOnDrift
+	t.Cleanup(func() { auditOutput, auditFailOnDrift = prevOutput, prevFail })
+	auditOutput, auditFailOnDrift = "json", false
+	summary := filepath.Join(t.TempDir(), "summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", summary)
+
+	if err := runAudit(auditCmd, nil); err != nil {
+		t.Fatalf("runAudit: %v", err)
+	}
+	data, err := os.ReadFile(summary)
+	if err != nil {
+		t.Fatalf("reading step summary: %v", err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"## Cost center audit (users mode)",
+		"| Correct | 0 |",
+		"| Missing | 2 |",
+		"### Drift (2)",
+		"| alice | missing |  | PRUs Allowed |",
+		"| bob | missing |  | No PRUs |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("step summary missing %q:\n%s", want, got)
+		}
+	}
+}
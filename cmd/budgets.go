@@ -0,0 +1,550 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/budgets"
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+var (
+	budgetsCleanupMode string
+	budgetsCleanupYes  bool
+
+	budgetsListOutput string
+
+	budgetsCreateCostCenter string
+	budgetsCreateProduct    string
+	budgetsCreateAmount     int
+
+	budgetsReconcileMode string
+	budgetsReconcileYes  bool
+)
+
+var budgetsCmd = &cobra.Command{
+	Use:   "budgets",
+	Short: "Manage cost center budgets",
+	Long:  `Inspect and maintain the billing budgets attached to cost centers.`,
+}
+
+var budgetsCleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Delete budgets that point at cost centers which no longer exist",
+	Long: `Find cost-center budgets whose target no longer matches any active cost
+center (by ID or name) and delete them.
+
+The --mode flag controls execution:
+  plan  - List orphaned budgets without deleting them (default)
+  apply - Delete orphaned budgets
+
+Examples:
+  # List orphaned budgets
+  gh cost-center budgets cleanup
+
+  # Delete them without confirmation
+  gh cost-center budgets cleanup --mode apply --yes`,
+	RunE: runBudgetsCleanup,
+}
+
+var budgetsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all budgets of the enterprise",
+	Long: `List every budget with its scope, entity, SKU, and amount.
+
+Examples:
+  gh cost-center budgets list
+  gh cost-center budgets list --output json`,
+	RunE: runBudgetsList,
+}
+
+var budgetsCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a product budget for one cost center",
+	Long: `Create a budget for a cost center, given by ID or exact name.  --product
+is a product or SKU name (see budgets.products); alert settings are taken
+from budgets.products when the product is configured there.
+
+Examples:
+  gh cost-center budgets create --cost-center "Platform" --product copilot --amount 500`,
+	RunE: runBudgetsCreate,
+}
+
+var budgetsReconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Create or update budgets to match budgets.products",
+	Long: `Compare the enabled budgets.products with the budgets of every cost center
+this configuration manages, and create missing budgets and update amounts
+that differ.
+
+Managed cost centers are the active ones the configuration names for its
+mode: tier, override, and mapping targets, default cost centers, and the
+generated cost_center_name_template or "[assigning team] ..." names.  A teams
+name template without fixed text, such as the default "{team_name}", matches
+no cost center: set one like "Team - {team_name}" to manage team cost centers.
+
+The --mode flag controls execution:
+  plan  - List the changes without making them (default)
+  apply - Create and update budgets
+
+Examples:
+  gh cost-center budgets reconcile
+  gh cost-center budgets reconcile --mode apply --yes`,
+	RunE: runBudgetsReconcile,
+}
+
+func init() {
+	budgetsCleanupCmd.Flags().StringVar(&budgetsCleanupMode, "mode", "plan", "execution mode: plan (preview) or apply (delete)")
+	budgetsCleanupCmd.Flags().BoolVarP(&budgetsCleanupYes, "yes", "y", false, "skip confirmation prompt in apply mode")
+
+	budgetsListCmd.Flags().StringVarP(&budgetsListOutput, "output", "o", "table", "output format: table or json")
+
+	budgetsCreateCmd.Flags().StringVar(&budgetsCreateCostCenter, "cost-center", "", "cost center ID or exact name (required)")
+	budgetsCreateCmd.Flags().StringVar(&budgetsCreateProduct, "product", "", "product or SKU, e.g. copilot, actions, copilot_premium_request (required)")
+	budgetsCreateCmd.Flags().IntVar(&budgetsCreateAmount, "amount", 0, "budget amount in USD (required)")
+
+	budgetsReconcileCmd.Flags().StringVar(&budgetsReconcileMode, "mode", "plan", "execution mode: plan (preview) or apply (create and update)")
+	budgetsReconcileCmd.Flags().BoolVarP(&budgetsReconcileYes, "yes", "y", false, "skip confirmation prompt in apply mode")
+
+	budgetsCmd.AddCommand(budgetsCleanupCmd, budgetsListCmd, budgetsCreateCmd, budgetsReconcileCmd)
+	rootCmd.AddCommand(budgetsCmd)
+}
+
+func runBudgetsCleanup(_ *cobra.Command, _ []string) error {
+	if budgetsCleanupMode != "plan" && budgetsCleanupMode != "apply" {
+		return usageErrorf("invalid --mode %q: must be 'plan' or 'apply'", budgetsCleanupMode)
+	}
+
+	logger := slog.Default()
+	if budgetsCleanupMode == "apply" {
+		release, err := acquireRunLock(logger)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	attachAuditLog(client, logger)
+
+	confirm := confirmYes
+	if budgetsCleanupYes {
+		confirm = nil
+	}
+	return cleanupBudgets(client, budgetsCleanupMode == "apply", confirm)
+}
+
+// cleanupBudgets lists budgets pointing at cost centers that no longer exist
+// and, when apply is true, deletes them after confirmation (skipped when
+// confirm is nil).
+func cleanupBudgets(client github.CostCenterAPI, apply bool, confirm func(string) (bool, error)) error {
+	logger := slog.Default()
+
+	budgets, err := client.ListBudgets()
+	if err != nil {
+		var uaErr *github.BudgetsAPIUnavailableError
+		if errors.As(err, &uaErr) {
+			logger.Warn("Budgets API unavailable, nothing to clean up", "error", err)
+			return nil
+		}
+		return fmt.Errorf("listing budgets: %w", err)
+	}
+
+	active, err := client.GetAllActiveCostCenters()
+	if err != nil {
+		return fmt.Errorf("fetching active cost centers: %w", err)
+	}
+
+	orphaned := github.FindOrphanedBudgets(budgets, active)
+	if len(orphaned) == 0 {
+		fmt.Println("No orphaned budgets found.")
+		return nil
+	}
+
+	fmt.Printf("Found %d budget(s) pointing at cost centers that no longer exist:\n", len(orphaned))
+	for _, b := range orphaned {
+		fmt.Printf("  - %s  entity=%s  sku=%s  amount=%d\n", b.ID, b.BudgetEntityName, b.BudgetProductSKU, b.BudgetAmount)
+	}
+
+	if !apply {
+		fmt.Println("\nmode=plan: no budgets deleted. Re-run with --mode apply to delete them.")
+		return nil
+	}
+
+	if confirm != nil {
+		proceed, err := confirm(fmt.Sprintf("Delete %d orphaned budget(s)?", len(orphaned)))
+		if err != nil {
+			return fmt.Errorf("confirmation failed: %w", err)
+		}
+		if !proceed {
+			logger.Warn("Aborted by user")
+			return nil
+		}
+	}
+
+	var failed int
+	for _, b := range orphaned {
+		if err := client.DeleteBudget(b.ID); err != nil {
+			logger.Error("Failed to delete budget", "id", b.ID, "entity", b.BudgetEntityName, "error", err)
+			failed++
+		}
+	}
+	fmt.Printf("Deleted %d of %d orphaned budget(s)\n", len(orphaned)-failed, len(orphaned))
+	if failed > 0 {
+		return fmt.Errorf("%d budget deletion(s) failed", failed)
+	}
+	return nil
+}
+
+func runBudgetsList(_ *cobra.Command, _ []string) error {
+	if budgetsListOutput != "table" && budgetsListOutput != "json" {
+		return usageErrorf("invalid --output %q: must be 'table' or 'json'", budgetsListOutput)
+	}
+	client, err := github.NewClient(cfgManager, slog.Default())
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	return listBudgets(os.Stdout, client, budgetsListOutput)
+}
+
+// listBudgets writes every budget to w, sorted by scope, entity, and SKU.
+func listBudgets(w io.Writer, client github.CostCenterAPI, output string) error {
+	list, err := client.ListBudgets()
+	if err != nil {
+		return budgetsError("listing budgets", err)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.BudgetScope != b.BudgetScope {
+			return a.BudgetScope < b.BudgetScope
+		}
+		if a.BudgetEntityName != b.BudgetEntityName {
+			return a.BudgetEntityName < b.BudgetEntityName
+		}
+		return a.BudgetProductSKU < b.BudgetProductSKU
+	})
+
+	if output == "json" {
+		if list == nil {
+			list = []github.Budget{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(list); err != nil {
+			return fmt.Errorf("encoding budgets: %w", err)
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tSCOPE\tENTITY\tSKU\tAMOUNT")
+	for _, b := range list {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\n", b.ID, b.BudgetScope, b.BudgetEntityName, b.BudgetProductSKU, b.BudgetAmount)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("writing budgets: %w", err)
+	}
+	_, _ = fmt.Fprintf(w, "%d budget(s)\n", len(list))
+	return nil
+}
+
+func runBudgetsCreate(_ *cobra.Command, _ []string) error {
+	if budgetsCreateCostCenter == "" || budgetsCreateProduct == "" {
+		return usageErrorf("--cost-center and --product are required")
+	}
+	if budgetsCreateAmount <= 0 {
+		return usageErrorf("invalid --amount %d: must be positive", budgetsCreateAmount)
+	}
+	client, err := github.NewClient(cfgManager, slog.Default())
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	attachAuditLog(client, slog.Default())
+	return createBudget(client, budgetsCreateCostCenter, budgetsCreateProduct, budgetsCreateAmount)
+}
+
+// createBudget creates a product budget for the cost center ref (ID or
+// name).  A cost center that already has a budget for the product is an
+// error pointing at budgets reconcile.
+func createBudget(client github.CostCenterAPI, ref, product string, amount int) error {
+	id, name, err := resolveCostCenterRef(client, ref)
+	if err != nil {
+		return notFoundExit(ref, err)
+	}
+	exists, err := client.CheckCostCenterHasProductBudget(id, name, product)
+	if err != nil {
+		return budgetsError("checking existing budgets", err)
+	}
+	if exists {
+		return fmt.Errorf("cost center %q already has a %s budget; use 'budgets reconcile' to change its amount", name, product)
+	}
+	alerting := github.AlertingFromConfig(cfgManager.BudgetProducts[product])
+	if _, err := client.CreateProductBudget(id, name, product, amount, alerting); err != nil {
+		return budgetsError("creating budget", err)
+	}
+	fmt.Printf("Created %s budget of %d for cost center %s (%s)\n", product, amount, name, id)
+	return nil
+}
+
+func runBudgetsReconcile(_ *cobra.Command, _ []string) error {
+	if budgetsReconcileMode != "plan" && budgetsReconcileMode != "apply" {
+		return usageErrorf("invalid --mode %q: must be 'plan' or 'apply'", budgetsReconcileMode)
+	}
+
+	logger := slog.Default()
+	if budgetsReconcileMode == "apply" {
+		release, err := acquireRunLock(logger)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	attachAuditLog(client, logger)
+
+	confirm := confirmYes
+	if budgetsReconcileYes {
+		confirm = nil
+	}
+	return reconcileBudgets(client, budgetsReconcileMode == "apply", confirm)
+}
+
+// reconcileBudgets plans the budget changes for the managed cost centers and,
+// when apply is true, makes them after confirmation (skipped when confirm is
+// nil).
+func reconcileBudgets(client github.CostCenterAPI, apply bool, confirm func(string) (bool, error)) error {
+	logger := slog.Default()
+
+	list, err := client.ListBudgets()
+	if err != nil {
+		return budgetsError("listing budgets", err)
+	}
+	active, err := client.GetAllActiveCostCenters()
+	if err != nil {
+		return fmt.Errorf("fetching active cost centers: %w", err)
+	}
+	managed := managedCostCenters(active, managedCostCenterPatterns(cfgManager))
+	changes := budgets.PlanReconcile(managed, list, cfgManager.BudgetProducts)
+
+	fmt.Printf("Managed cost centers: %d\n", len(managed))
+	if len(changes) == 0 {
+		fmt.Println("Budgets match the configuration. Nothing to do.")
+		return nil
+	}
+	fmt.Printf("%d budget change(s):\n", len(changes))
+	for _, c := range changes {
+		if c.IsCreate() {
+			fmt.Printf("  + %s: create %s budget of %d\n", c.CostCenterName, c.Product, c.To)
+		} else {
+			fmt.Printf("  ~ %s: update %s budget %s from %d to %d\n", c.CostCenterName, c.Product, c.BudgetID, c.From, c.To)
+		}
+	}
+
+	if !apply {
+		fmt.Println("\nmode=plan: no budgets changed. Re-run with --mode apply to apply them.")
+		return nil
+	}
+
+	if confirm != nil {
+		proceed, err := confirm(fmt.Sprintf("Apply %d budget change(s)?", len(changes)))
+		if err != nil {
+			return fmt.Errorf("confirmation failed: %w", err)
+		}
+		if !proceed {
+			logger.Warn("Aborted by user")
+			return nil
+		}
+	}
+
+	var failed int
+	for _, c := range changes {
+		if c.IsCreate() {
+			alerting := github.AlertingFromConfig(cfgManager.BudgetProducts[c.Product])
+			_, err = client.CreateProductBudget(c.CostCenterID, c.CostCenterName, c.Product, c.To, alerting)
+		} else {
+			err = client.UpdateBudget(c.BudgetID, c.To, true)
+		}
+		if err != nil {
+			var uaErr *github.BudgetsAPIUnavailableError
+			if errors.As(err, &uaErr) {
+				return err
+			}
+			logger.Error("Failed to reconcile budget", "cost_center", c.CostCenterName, "product", c.Product, "error", err)
+			failed++
+		}
+	}
+	fmt.Printf("Applied %d of %d budget change(s)\n", len(changes)-failed, len(changes))
+	if failed > 0 {
+		return fmt.Errorf("%d budget change(s) failed", failed)
+	}
+	return nil
+}
+
+// budgetsError wraps err from a budgets API call, returning the
+// BudgetsAPIUnavailableError message on its own so the user sees why.
+func budgetsError(action string, err error) error {
+	var uaErr *github.BudgetsAPIUnavailableError
+	if errors.As(err, &uaErr) {
+		return uaErr
+	}
+	return fmt.Errorf("%s: %w", action, err)
+}
+
+// managedCostCenterPatterns returns patterns for the cost centers cfg
+// manages in its mode: the IDs and names it targets, and the names it
+// generates from a template.
+func managedCostCenterPatterns(cfg *config.Manager) []*regexp.Regexp {
+	var refs []string
+	var generated []string // name templates; placeholders match any text
+	switch cfg.CostCenterMode {
+	case "teams":
+		if cfg.TeamsStrategy == "manual" {
+			for _, cc := range cfg.TeamsMappings {
+				refs = append(refs, cc)
+			}
+			break
+		}
+		// A template without fixed text, like the default {team_name},
+		// would match every cost center, so it identifies none.
+		tmpl := cfg.TeamsNameTemplate
+		if tmpl == "" {
+			tmpl = config.DefaultTeamsNameTemplate
+		}
+		if templatePlaceholder.ReplaceAllString(tmpl, "") != "" {
+			generated = append(generated, tmpl)
+		}
+	case "repos":
+		for _, mp := range cfg.ReposMappings {
+			refs = append(refs, mp.CostCenter)
+		}
+		refs = append(refs, cfg.ReposDefaultCostCenter)
+	case "custom-prop":
+		for _, cc := range cfg.CustomPropCostCenters {
+			refs = append(refs, cc.Name)
+		}
+	case "assigning-team":
+		generated = append(generated, "[assigning team] {team}")
+		refs = append(refs, cfg.AssigningTeamDefaultCostCenter)
+	default:
+		if len(cfg.PRUTiers) == 0 {
+			refs = append(refs, cfg.NoPRUsCostCenterID, cfg.NoPRUsCostCenterName,
+				cfg.PRUsAllowedCostCenterID, cfg.PRUsAllowedCostCenterName)
+		}
+		for _, t := range cfg.PRUTiers {
+			refs = append(refs, t.CostCenterID, t.CostCenterName)
+		}
+		for _, cc := range cfg.PRUsUserOverrides {
+			refs = append(refs, cc)
+		}
+	}
+
+	var patterns []*regexp.Regexp
+	for _, r := range refs {
+		if r != "" {
+			patterns = append(patterns, regexp.MustCompile("^"+regexp.QuoteMeta(r)+"$"))
+		}
+	}
+	for _, tmpl := range generated {
+		patterns = append(patterns, templatePattern(tmpl))
+	}
+	return patterns
+}
+
+// managedCostCenters returns the active cost centers (name → ID) whose name
+// or ID matches one of patterns.
+func managedCostCenters(active map[string]string, patterns []*regexp.Regexp) map[string]string {
+	managed := make(map[string]string)
+	for name, id := range active {
+		for _, p := range patterns {
+			if p.MatchString(name) || p.MatchString(id) {
+				managed[name] = id
+				break
+			}
+		}
+	}
+	return managed
+}
+
+// templatePlaceholder matches a {placeholder} in a name template.
+var templatePlaceholder = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// templatePattern returns a pattern matching the names generated from a cost
+// center name template.
+func templatePattern(template string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	last := 0
+	for _, loc := range templatePlaceholder.FindAllStringIndex(template, -1) {
+		b.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		b.WriteString(".+")
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(template[last:]))
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// printBudgetPlan shows which product budgets --create-budgets will create
+// and who each one alerts, then the effective budgets of every cost center in
+// overrides (budgets.cost_center_overrides).
+func printBudgetPlan(w io.Writer, products map[string]config.ProductBudget, overrides map[string]map[string]int) {
+	names := enabledProducts(products)
+
+	_, _ = fmt.Fprintln(w, "\n===== Budgets =====")
+	if len(names) == 0 {
+		_, _ = fmt.Fprintln(w, "No product budgets enabled")
+	}
+	for _, name := range names {
+		pb := products[name]
+		alerts := "no alerts"
+		if pb.AlertsEnabled {
+			alerts = "alerts: " + strings.Join(pb.AlertRecipients, ", ")
+			if len(pb.AlertRecipients) == 0 {
+				alerts = "alerts: (no recipients)"
+			}
+		}
+		_, _ = fmt.Fprintf(w, "  %s: $%d (%s)\n", name, pb.Amount, alerts)
+	}
+	if len(overrides) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintln(w, "Per cost center (budgets.cost_center_overrides):")
+	for _, cc := range slices.Sorted(maps.Keys(overrides)) {
+		effective := config.WithBudgetOverride(products, overrides[cc])
+		amounts := make([]string, 0, len(effective))
+		for _, name := range enabledProducts(effective) {
+			amounts = append(amounts, fmt.Sprintf("%s $%d", name, effective[name].Amount))
+		}
+		_, _ = fmt.Fprintf(w, "  %s: %s\n", cc, strings.Join(amounts, ", "))
+	}
+}
+
+// enabledProducts returns the names of the enabled products, sorted.
+func enabledProducts(products map[string]config.ProductBudget) []string {
+	names := make([]string, 0, len(products))
+	for name, pb := range products {
+		if pb.Enabled {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,334 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+// budgetsTestServer serves one active cost center plus the given budgets and
+// records the IDs of deleted budgets.
+func budgetsTestServer(t *testing.T, budgetsStatus int, budgets []map[string]any, deleted *[]string) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodDelete:
+			mu.Lock()
+			*deleted = append(*deleted, r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		case strings.HasSuffix(r.URL.Path, "/budgets"):
+			if budgetsStatus != http.StatusOK {
+				w.WriteHeader(budgetsStatus)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"budgets": budgets})
+		case strings.HasSuffix(r.URL.Path, "/cost-centers"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"costCenters": []map[string]string{
+				{"id": testCCID, "name": "Live Team", "state": "active"},
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func testBudgets() []map[string]any {
+	return []map[string]any{
+		{"id": "b-live-id", "budget_scope": "cost_center", "budget_entity_name": testCCID},
+		{"id": "b-live-name", "budget_scope": "cost_center", "budget_entity_name": "Live Team"},
+		{"id": "b-orphan", "budget_scope": "cost_center", "budget_entity_name": "Deleted Team"},
+		{"id": "b-org", "budget_scope": "organization", "budget_entity_name": "my-org"},
+	}
+}
+
+func TestCleanupBudgets_PlanDeletesNothing(t *testing.T) {
+	var deleted []string
+	srv := budgetsTestServer(t, http.StatusOK, testBudgets(), &deleted)
+	defer srv.Close()
+
+	if err := cleanupBudgets(newTestGitHubClient(t, srv.URL), false, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deleted) != 0 {
+		t.Errorf("deleted = %v, want none in plan mode", deleted)
+	}
+}
+
+func TestCleanupBudgets_ApplyDeletesOnlyOrphans(t *testing.T) {
+	var deleted []string
+	srv := budgetsTestServer(t, http.StatusOK, testBudgets(), &deleted)
+	defer srv.Close()
+
+	if err := cleanupBudgets(newTestGitHubClient(t, srv.URL), true, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != "b-orphan" {
+		t.Errorf("deleted = %v, want [b-orphan]", deleted)
+	}
+}
+
+func TestCleanupBudgets_Declined(t *testing.T) {
+	var deleted []string
+	srv := budgetsTestServer(t, http.StatusOK, testBudgets(), &deleted)
+	defer srv.Close()
+
+	decline := func(string) (bool, error) { return false, nil }
+	if err := cleanupBudgets(newTestGitHubClient(t, srv.URL), true, decline); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deleted) != 0 {
+		t.Errorf("deleted = %v, want none after declining", deleted)
+	}
+}
+
+func TestCleanupBudgets_APIUnavailable(t *testing.T) {
+	var deleted []string
+	srv := budgetsTestServer(t, http.StatusNotFound, nil, &deleted)
+	defer srv.Close()
+
+	if err := cleanupBudgets(newTestGitHubClient(t, srv.URL), true, nil); err != nil {
+		t.Fatalf("expected clean short-circuit, got %v", err)
+	}
+}
+
+// budgetWritesServer serves one active cost center ("Live Team") plus the
+// given budgets (404 when budgets is nil) and records each POST and PATCH as
+// "METHOD entity-or-id sku amount".
+func budgetWritesServer(t *testing.T, budgets []map[string]any) (*httptest.Server, *[]string) {
+	t.Helper()
+	var (
+		mu     sync.Mutex
+		writes []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost || r.Method == http.MethodPatch:
+			var body struct {
+				Entity string `json:"budget_entity_name"`
+				SKU    string `json:"budget_product_sku"`
+				Amount int    `json:"budget_amount"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			target := body.Entity
+			if r.Method == http.MethodPatch {
+				target = r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+			}
+			mu.Lock()
+			writes = append(writes, fmt.Sprintf("%s %s %s %d", r.Method, target, body.SKU, body.Amount))
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{}`))
+		case strings.HasSuffix(r.URL.Path, "/budgets"):
+			if budgets == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"budgets": budgets})
+		case strings.HasSuffix(r.URL.Path, "/cost-centers"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"costCenters": []map[string]string{
+				{"id": testCCID, "name": "Live Team", "state": "active"},
+				{"id": testPRUCCID, "name": "Someone Else's", "state": "active"},
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &writes
+}
+
+// useBudgetsConfig sets cfgManager to custom-prop mode managing "Live Team"
+// with copilot (100) and actions (125) budgets enabled.
+func useBudgetsConfig(t *testing.T) {
+	t.Helper()
+	prev := cfgManager
+	t.Cleanup(func() { cfgManager = prev })
+	cfgManager = &config.Manager{
+		Enterprise:            "test-ent",
+		CostCenterMode:        "custom-prop",
+		CustomPropCostCenters: []config.CustomPropCostCenter{{Name: "Live Team"}},
+		BudgetProducts: map[string]config.ProductBudget{
+			"copilot": {Amount: 100, Enabled: true},
+			"actions": {Amount: 125, Enabled: true},
+		},
+	}
+}
+
+func TestCreateBudget_ResolvesNameToID(t *testing.T) {
+	useBudgetsConfig(t)
+	srv, writes := budgetWritesServer(t, []map[string]any{})
+	client := newTestGitHubClient(t, srv.URL)
+
+	if err := createBudget(client, "Live Team", "copilot", 500); err != nil {
+		t.Fatalf("createBudget by name: %v", err)
+	}
+	if err := createBudget(client, testCCID, "actions", 50); err != nil {
+		t.Fatalf("createBudget by ID: %v", err)
+	}
+	want := "POST " + testCCID + " copilot 500,POST " + testCCID + " actions 50"
+	if got := strings.Join(*writes, ","); got != want {
+		t.Errorf("writes = %q, want %q", got, want)
+	}
+
+	var ee *exitError
+	if err := createBudget(client, "No Such Team", "copilot", 1); !errors.As(err, &ee) || ee.code != exitCodeNotFound {
+		t.Errorf("unknown cost center err = %v, want exit code %d", err, exitCodeNotFound)
+	}
+}
+
+func TestCreateBudget_ExistingBudget(t *testing.T) {
+	useBudgetsConfig(t)
+	srv, writes := budgetWritesServer(t, []map[string]any{
+		{"id": "b1", "budget_scope": "cost_center", "budget_entity_name": "Live Team", "budget_product_sku": "copilot", "budget_amount": 100},
+	})
+
+	err := createBudget(newTestGitHubClient(t, srv.URL), testCCID, "copilot", 500)
+	if err == nil || !strings.Contains(err.Error(), "budgets reconcile") {
+		t.Errorf("err = %v, want a pointer to budgets reconcile", err)
+	}
+	if len(*writes) != 0 {
+		t.Errorf("writes = %v, want none", *writes)
+	}
+}
+
+func TestBudgetsCommands_APIUnavailable(t *testing.T) {
+	useBudgetsConfig(t)
+	srv, _ := budgetWritesServer(t, nil)
+	client := newTestGitHubClient(t, srv.URL)
+
+	var buf bytes.Buffer
+	checks := map[string]error{
+		"list":      listBudgets(&buf, client, "table"),
+		"create":    createBudget(client, "Live Team", "copilot", 1),
+		"reconcile": reconcileBudgets(client, true, nil),
+	}
+	for name, err := range checks {
+		var uaErr *github.BudgetsAPIUnavailableError
+		if !errors.As(err, &uaErr) {
+			t.Errorf("%s: err = %v, want BudgetsAPIUnavailableError", name, err)
+		}
+	}
+}
+
+func TestListBudgets(t *testing.T) {
+	srv, _ := budgetWritesServer(t, testBudgets())
+	client := newTestGitHubClient(t, srv.URL)
+
+	var table bytes.Buffer
+	if err := listBudgets(&table, client, "table"); err != nil {
+		t.Fatalf("listBudgets: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+	if len(lines) != 6 || !strings.HasPrefix(lines[0], "ID") || !strings.HasPrefix(lines[1], "b-orphan") ||
+		!strings.HasPrefix(lines[4], "b-org") || lines[5] != "4 budget(s)" {
+		t.Errorf("table =\n%s", table.String())
+	}
+
+	var js bytes.Buffer
+	if err := listBudgets(&js, client, "json"); err != nil {
+		t.Fatalf("listBudgets json: %v", err)
+	}
+	var got []github.Budget
+	if err := json.Unmarshal(js.Bytes(), &got); err != nil || len(got) != 4 || got[0].ID != "b-orphan" {
+		t.Errorf("json = %s (err %v)", js.String(), err)
+	}
+}
+
+func TestReconcileBudgets(t *testing.T) {
+	useBudgetsConfig(t)
+	existing := []map[string]any{
+		{"id": "b-copilot", "budget_scope": "cost_center", "budget_entity_name": testCCID, "budget_product_sku": "copilot", "budget_amount": 40},
+	}
+
+	srv, writes := budgetWritesServer(t, existing)
+	if err := reconcileBudgets(newTestGitHubClient(t, srv.URL), false, nil); err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if len(*writes) != 0 {
+		t.Errorf("plan wrote %v", *writes)
+	}
+
+	if err := reconcileBudgets(newTestGitHubClient(t, srv.URL), true, nil); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	// "Someone Else's" is not managed and gets nothing.
+	want := "POST " + testCCID + " actions 125,PATCH b-copilot  100"
+	if got := strings.Join(*writes, ","); got != want {
+		t.Errorf("writes = %q, want %q", got, want)
+	}
+}
+
+func TestManagedCostCenterPatterns(t *testing.T) {
+	active := map[string]string{
+		"[org team] my-org/Platform": "id-1",
+		"[enterprise team] Platform": "id-2",
+		"[assigning team] my-org/ml": "id-3",
+		"Eng - Platform (my-org)":    "id-4",
+		"No assigning team":          "id-5",
+		"Payments":                   "id-6",
+		"Unrelated":                  "id-7",
+		"01 - Power users":           "id-8",
+	}
+	tests := []struct {
+		name string
+		cfg  *config.Manager
+		want string
+	}{
+		// The default {team_name} has no fixed text and would match anything.
+		{"teams default template", &config.Manager{CostCenterMode: "teams", TeamsScope: "organization", TeamsStrategy: "auto"}, ""},
+		{"teams placeholder-only template", &config.Manager{CostCenterMode: "teams", TeamsStrategy: "auto", TeamsNameTemplate: "{org}{team_name}"}, ""},
+		{"teams prefixed template", &config.Manager{CostCenterMode: "teams", TeamsScope: "enterprise", TeamsStrategy: "auto", TeamsNameTemplate: "[enterprise team] {team_name}"}, "[enterprise team] Platform"},
+		{"teams template", &config.Manager{CostCenterMode: "teams", TeamsStrategy: "auto", TeamsNameTemplate: "Eng - {team_name} ({org})"}, "Eng - Platform (my-org)"},
+		{"teams manual", &config.Manager{CostCenterMode: "teams", TeamsStrategy: "manual", TeamsMappings: map[string]string{"my-org/pay": "Payments"}}, "Payments"},
+		{"assigning team", &config.Manager{CostCenterMode: "assigning-team", AssigningTeamDefaultCostCenter: "No assigning team"}, "No assigning team,[assigning team] my-org/ml"},
+		{"repos", &config.Manager{CostCenterMode: "repos", ReposMappings: []config.ExplicitMapping{{CostCenter: "id-6"}}}, "Payments"},
+		{"users tiers", &config.Manager{CostCenterMode: "users", PRUTiers: []config.PRUTier{{CostCenterName: "01 - Power users"}, {CostCenterID: "id-7"}}}, "01 - Power users,Unrelated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			managed := managedCostCenters(active, managedCostCenterPatterns(tt.cfg))
+			names := make([]string, 0, len(managed))
+			for name := range managed {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if got := strings.Join(names, ","); got != tt.want {
+				t.Errorf("managed = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintBudgetPlan_CostCenterOverrides(t *testing.T) {
+	products := map[string]config.ProductBudget{
+		"copilot": {Amount: 100, Enabled: true},
+		"actions": {Amount: 125, Enabled: true},
+	}
+	var buf bytes.Buffer
+	printBudgetPlan(&buf, products, map[string]map[string]int{
+		"Platform": {"actions": 300, "copilot_premium_request": 0},
+	})
+	out := buf.String()
+	for _, want := range []string{
+		"  actions: $125 (no alerts)\n",
+		"Per cost center (budgets.cost_center_overrides):\n",
+		"  Platform: actions $300, copilot $100, copilot_premium_request $0\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
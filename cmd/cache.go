@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -21,8 +24,10 @@ var cacheCmd = &cobra.Command{
 	Short: "Manage the cost center cache",
 	Long: `View, clear, or clean up the cost center cache.
 
-The cache stores cost center lookups to reduce API calls on repeated runs.
-Cache entries expire after 24 hours.
+The cache stores cost center name lookups, the active cost center list, and
+organization custom property schemas under <export_dir>/cache to reduce API
+calls on repeated runs.  Entries expire after cache.ttl (default 24h); pass
+--no-cache to any command to bypass the cache.
 
 Examples:
   # Show cache statistics
@@ -38,13 +43,13 @@ Examples:
 			return cmd.Help()
 		}
 
-		cc, err := cache.New("", slog.Default())
+		cc, err := openCache(slog.Default())
 		if err != nil {
 			return fmt.Errorf("opening cache: %w", err)
 		}
 
 		if cacheStats {
-			runCacheStats(cc)
+			printCacheStats(os.Stdout, cc.GetStats())
 		}
 		if cacheClear {
 			if err := runCacheClear(cc); err != nil {
@@ -60,18 +65,27 @@ Examples:
 	},
 }
 
-func runCacheStats(cc *cache.Cache) {
-	stats := cc.GetStats()
-	fmt.Println()
-	fmt.Println(strings.Repeat("=", 60))
-	fmt.Println("COST CENTER CACHE STATISTICS")
-	fmt.Println(strings.Repeat("=", 60))
-	fmt.Printf("Cache file:      %s\n", stats.FilePath)
-	fmt.Printf("File size:       %d bytes\n", stats.FileSizeBytes)
-	fmt.Printf("Total entries:   %d\n", stats.TotalEntries)
-	fmt.Printf("Valid entries:   %d\n", stats.ValidEntries)
-	fmt.Printf("Expired entries: %d\n", stats.ExpiredEntries)
-	fmt.Println(strings.Repeat("=", 60))
+// printCacheStats writes the totals and, per kind of cached data, the file,
+// entry counts, size, and the ages of the oldest and newest entries.
+func printCacheStats(w io.Writer, stats cache.Stats) {
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, strings.Repeat("=", 60))
+	_, _ = fmt.Fprintln(w, "COST CENTER CACHE STATISTICS")
+	_, _ = fmt.Fprintln(w, strings.Repeat("=", 60))
+	_, _ = fmt.Fprintf(w, "TTL:             %s\n", stats.TTL)
+	_, _ = fmt.Fprintf(w, "Total entries:   %d\n", stats.TotalEntries)
+	_, _ = fmt.Fprintf(w, "Valid entries:   %d\n", stats.ValidEntries)
+	_, _ = fmt.Fprintf(w, "Expired entries: %d\n", stats.ExpiredEntries)
+	for _, ks := range stats.Kinds {
+		_, _ = fmt.Fprintf(w, "\n%s\n", ks.Kind)
+		_, _ = fmt.Fprintf(w, "  File:          %s (%d bytes)\n", ks.FilePath, ks.FileSizeBytes)
+		_, _ = fmt.Fprintf(w, "  Entries:       %d (%d expired)\n", ks.TotalEntries, ks.ExpiredEntries)
+		if ks.TotalEntries > 0 {
+			_, _ = fmt.Fprintf(w, "  Oldest entry:  %s ago\n", ks.OldestAge.Round(time.Second))
+			_, _ = fmt.Fprintf(w, "  Newest entry:  %s ago\n", ks.NewestAge.Round(time.Second))
+		}
+	}
+	_, _ = fmt.Fprintln(w, strings.Repeat("=", 60))
 }
 
 func runCacheClear(cc *cache.Cache) error {
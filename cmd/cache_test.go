@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/cache"
+)
+
+func TestPrintCacheStats(t *testing.T) {
+	stats := cache.Stats{
+		TTL:            24 * time.Hour,
+		TotalEntries:   3,
+		ValidEntries:   2,
+		ExpiredEntries: 1,
+		Kinds: []cache.KindStats{
+			{Kind: cache.KindCostCenterNames, FilePath: "exports/cache/cost_centers.json", FileSizeBytes: 120,
+				TotalEntries: 3, ExpiredEntries: 1, OldestAge: 30 * time.Hour, NewestAge: 90 * time.Second},
+			{Kind: cache.KindPropertySchemas, FilePath: "exports/cache/property_schemas.json"},
+		},
+	}
+
+	var buf bytes.Buffer
+	printCacheStats(&buf, stats)
+	out := buf.String()
+
+	for _, want := range []string{
+		"TTL:             24h0m0s",
+		"Total entries:   3",
+		"Expired entries: 1",
+		"cost_centers\n  File:          exports/cache/cost_centers.json (120 bytes)",
+		"  Entries:       3 (1 expired)",
+		"  Oldest entry:  30h0m0s ago",
+		"  Newest entry:  1m30s ago",
+		"property_schemas\n  File:          exports/cache/property_schemas.json (0 bytes)\n  Entries:       0 (0 expired)\n=",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -1,11 +1,27 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
 	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/render"
+)
+
+var (
+	configValidateOnline bool
+
+	// configJSON is config --json: the summary as one JSON object.
+	configJSON bool
 )
 
 var configCmd = &cobra.Command{
@@ -14,33 +30,163 @@ var configCmd = &cobra.Command{
 	Long: `Display the current configuration and exit.
 
 Shows enterprise, cost center mode, organizations, and mode-specific
-configuration details.
+configuration details, each with where it came from: a config file, an
+environment variable, or the default.  With several --config files, later
+files are deep-merged over earlier ones.
 
 Examples:
   gh cost-center config
-  gh cost-center config --config path/to/config.yaml`,
+  gh cost-center config --config path/to/config.yaml
+  gh cost-center config --config base.yaml --config prod.yaml
+  gh cost-center config --json > prod-config.json`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		summary := cfgManager.Summary()
-
-		// Print in sorted key order for deterministic output.
-		keys := make([]string, 0, len(summary))
-		for k := range summary {
-			keys = append(keys, k)
+		if configJSON {
+			return writeConfigJSON(os.Stdout, cfgManager)
 		}
-		sort.Strings(keys)
+		writeConfigSummary(os.Stdout, cfgManager)
+		return nil
+	},
+}
+
+// writeConfigSummary writes the resolved settings of mgr to w, each with the
+// file, environment variable, or default it came from.
+func writeConfigSummary(w io.Writer, mgr *config.Manager) {
+	summary := mgr.Summary()
 
-		fmt.Println("Current configuration:")
-		fmt.Println(strings.Repeat("-", 50))
-		for _, k := range keys {
-			fmt.Printf("  %-35s %v\n", k+":", summary[k])
+	// Print in sorted key order for deterministic output.
+	keys := make([]string, 0, len(summary))
+	for k := range summary {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	p := render.Auto(w)
+	p.Println("Current configuration:")
+	p.Println(strings.Repeat("-", 50))
+	rows := make([][]string, 0, len(keys))
+	for _, k := range keys {
+		rows = append(rows, []string{k + ":", fmt.Sprint(summary[k]), mgr.SummarySource(k)})
+	}
+	p.Table("  ", rows)
+	p.Println(strings.Repeat("-", 50))
+	if len(mgr.Files) > 1 {
+		p.Printf("  config files: %s (later files override earlier ones)\n", strings.Join(mgr.Files, ", "))
+	} else {
+		note := ""
+		if _, err := os.Stat(mgr.Files[0]); err != nil {
+			note = " (not found, using defaults)"
 		}
-		fmt.Println(strings.Repeat("-", 50))
-		fmt.Printf("  config file: %s\n", cfgFile)
+		p.Printf("  config file: %s%s\n", mgr.Files[0], note)
+	}
+}
+
+// writeConfigJSON writes the resolved settings of mgr to w as one JSON
+// object with sorted keys, so that two environments can be diffed.  The
+// token is masked like in the text summary.
+func writeConfigJSON(w io.Writer, mgr *config.Manager) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(mgr.Summary()); err != nil {
+		return fmt.Errorf("encoding configuration: %w", err)
+	}
+	return nil
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the configuration and list every problem",
+	Long: `Check the configuration and list every problem found, each with the YAML
+path of the offending key, instead of stopping at the first one.
 
+Besides the checks every command runs when loading the configuration,
+validate reports:
+
+  - keys that match no setting, such as a misspelled key
+  - placeholder cost center IDs while auto_create is off
+  - team mappings that conflict with each other or with the mappings file
+  - placeholder cost centers in repos mappings
+
+--online also checks that the cost center IDs in the configuration exist,
+which needs a token.  validate exits with code 2 when it finds a problem.
+
+Examples:
+  gh cost-center config validate
+  gh cost-center config validate --online --config prod.yaml`,
+	Args: cobra.NoArgs,
+	// validate loads the configuration itself so that every problem is
+	// reported instead of aborting on the first.
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		resolveConfigFiles(cmd, setupLogger())
 		return nil
 	},
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return validateConfig(os.Stdout, cfgFiles, configValidateOnline)
+	},
 }
 
 func init() {
+	configCmd.Flags().BoolVar(&configJSON, "json", false, "print the configuration as one JSON object with sorted keys")
+	configValidateCmd.Flags().BoolVar(&configValidateOnline, "online", false, "also check that the configured cost center IDs exist")
+	configCmd.AddCommand(configValidateCmd)
 	rootCmd.AddCommand(configCmd)
 }
+
+// validateConfig validates the config files in paths, writes the problems
+// found to w, and returns a configuration error when there are any.
+func validateConfig(w io.Writer, paths []string, online bool) error {
+	logger := slog.Default()
+	mgr, problems, err := config.Validate(paths, logger)
+	if err != nil {
+		return &exitError{code: exitCodeConfig, err: fmt.Errorf("loading configuration: %w", err)}
+	}
+
+	p := render.Auto(w)
+	if online {
+		if i := slices.IndexFunc(problems, func(pr config.Problem) bool {
+			return strings.HasPrefix(pr.Path, "github.enterprise") || strings.HasPrefix(pr.Path, "github.api_base_url")
+		}); i >= 0 {
+			p.Println(p.Warn("Skipping online checks until " + problems[i].Path + " is fixed."))
+		} else {
+			mgr.Token = tokenFlag
+			client, err := github.NewClient(mgr, logger)
+			if err != nil {
+				return fmt.Errorf("creating GitHub client: %w", err)
+			}
+			found, err := costCenterRefProblems(client, mgr.CostCenterRefs())
+			if err != nil {
+				return err
+			}
+			problems = append(problems, found...)
+		}
+	}
+	slices.SortStableFunc(problems, func(a, b config.Problem) int { return strings.Compare(a.Path, b.Path) })
+
+	name := strings.Join(paths, " + ")
+	if len(problems) == 0 {
+		p.Printf("%s is valid.\n", name)
+		return nil
+	}
+	p.Printf("%s has %d problem(s):\n", name, len(problems))
+	for _, pr := range problems {
+		p.Printf("  %s %s\n", p.Remove(pr.Path+":"), pr.Message)
+	}
+	return &exitError{code: exitCodeConfig, err: fmt.Errorf("configuration has %d problem(s)", len(problems))}
+}
+
+// costCenterRefProblems reports the configured cost center IDs that do not
+// exist in the enterprise.
+func costCenterRefProblems(client github.CostCenterAPI, refs []config.CostCenterRef) ([]config.Problem, error) {
+	var problems []config.Problem
+	for _, ref := range refs {
+		if _, err := client.GetCostCenter(ref.ID); err != nil {
+			if !github.IsCostCenterNotFound(err) {
+				return nil, fmt.Errorf("checking %s: %w", ref.Path, err)
+			}
+			problems = append(problems, config.Problem{
+				Path:    ref.Path,
+				Message: fmt.Sprintf("cost center %s does not exist in the enterprise", ref.ID),
+			})
+		}
+	}
+	return problems, nil
+}
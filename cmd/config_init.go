@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	configInitMode       string
+	configInitPath       string
+	configInitEnterprise string
+	configInitOrgs       []string
+	configInitForce      bool
+)
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a starter configuration file",
+	Long: `Write a commented starter configuration for the chosen mode, then validate
+it as "config validate" does.
+
+The enterprise slug is asked for interactively unless --enterprise is given.
+repos mode also needs the organizations whose repositories are mapped, from
+--org or the prompt.  An existing file is only overwritten with --force.
+
+Examples:
+  gh cost-center config init
+  gh cost-center config init --mode teams --enterprise my-ent
+  gh cost-center config init --mode repos --org my-org --path prod.yaml --force`,
+	Args: cobra.NoArgs,
+	// init writes the configuration, so there is none to load yet.
+	PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+		setupLogger()
+		return nil
+	},
+	RunE: func(_ *cobra.Command, _ []string) error {
+		path := configInitPath
+		if path == "" {
+			path = cfgFiles[0]
+		}
+		return initConfig(os.Stdout, applyPrompter, configInitOptions{
+			mode:       configInitMode,
+			path:       path,
+			enterprise: configInitEnterprise,
+			orgs:       configInitOrgs,
+			force:      configInitForce,
+		})
+	},
+}
+
+func init() {
+	configInitCmd.Flags().StringVar(&configInitMode, "mode", "users", "cost center mode of the starter config: users, teams, or repos (also \"repository\")")
+	configInitCmd.Flags().StringVar(&configInitPath, "path", "", "file to write (default: the first --config path)")
+	configInitCmd.Flags().StringVar(&configInitEnterprise, "enterprise", "", "GitHub Enterprise slug (asked for when not set)")
+	configInitCmd.Flags().StringSliceVar(&configInitOrgs, "org", nil, "organization to include (repeatable; asked for in repos mode when not set)")
+	configInitCmd.Flags().BoolVar(&configInitForce, "force", false, "overwrite an existing file")
+	configCmd.AddCommand(configInitCmd)
+}
+
+// configInitOptions are the settings of config init.
+type configInitOptions struct {
+	mode       string
+	path       string
+	enterprise string
+	orgs       []string
+	force      bool
+}
+
+// slugPattern matches an enterprise or organization slug.
+var slugPattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9_-]*[A-Za-z0-9])?$`)
+
+// initConfig writes the starter config described by opts, asking p for the
+// values opts leaves out, and validates the result.
+func initConfig(w io.Writer, p prompter, opts configInitOptions) error {
+	if opts.mode == "repository" {
+		opts.mode = "repos"
+	}
+	body, ok := starterModeSections[opts.mode]
+	if !ok {
+		return usageErrorf("invalid --mode %q: must be users, teams, or repos", opts.mode)
+	}
+	if _, err := os.Stat(opts.path); err == nil && !opts.force {
+		return &exitError{code: exitCodeExists, err: fmt.Errorf("%s already exists; pass --force to overwrite it", opts.path)}
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("checking %s: %w", opts.path, err)
+	}
+
+	enterprise := opts.enterprise
+	if enterprise == "" {
+		answer, err := ask(w, p, "GitHub Enterprise slug", "--enterprise")
+		if err != nil {
+			return err
+		}
+		enterprise = answer
+	}
+	if !slugPattern.MatchString(enterprise) {
+		return usageErrorf("invalid enterprise slug %q", enterprise)
+	}
+
+	orgs := opts.orgs
+	if len(orgs) == 0 && opts.mode == "repos" {
+		answer, err := ask(w, p, "Organizations whose repositories are mapped (comma-separated)", "--org")
+		if err != nil {
+			return err
+		}
+		for org := range strings.SplitSeq(answer, ",") {
+			if org = strings.TrimSpace(org); org != "" {
+				orgs = append(orgs, org)
+			}
+		}
+		if len(orgs) == 0 {
+			return usageErrorf("repos mode needs at least one organization")
+		}
+	}
+	if i := slices.IndexFunc(orgs, func(org string) bool { return !slugPattern.MatchString(org) }); i >= 0 {
+		return usageErrorf("invalid organization %q", orgs[i])
+	}
+
+	var sb strings.Builder
+	tmpl := template.Must(template.New("config").Parse(starterHeader + body + starterFooter))
+	if err := tmpl.Execute(&sb, map[string]any{"Mode": opts.mode, "Enterprise": enterprise, "Organizations": orgs}); err != nil {
+		return fmt.Errorf("rendering starter config: %w", err)
+	}
+	if dir := filepath.Dir(opts.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("creating config directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(opts.path, []byte(sb.String()), 0o644); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	_, _ = fmt.Fprintf(w, "Wrote a starter %s mode configuration to %s.\n", opts.mode, opts.path)
+	return validateConfig(w, []string{opts.path}, false)
+}
+
+// ask prints question and returns the trimmed answer.  When p is not
+// interactive it fails with a usage error pointing at flag.
+func ask(w io.Writer, p prompter, question, flag string) (string, error) {
+	if !p.Interactive() {
+		return "", usageErrorf("stdin is not a terminal; pass %s", flag)
+	}
+	_, _ = fmt.Fprintf(w, "%s: ", question)
+	line, err := p.ReadLine()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// starterHeader, a section of starterModeSections, and starterFooter make up
+// the starter config template.
+const starterHeader = `# gh-cost-center configuration ({{.Mode}} mode), written by
+# "gh cost-center config init".  config/config.example.yaml in the
+# repository documents every setting.  Check changes with
+# "gh cost-center config validate".
+
+github:
+  # GitHub Enterprise slug.  The GITHUB_ENTERPRISE environment variable
+  # overrides it.
+  enterprise: "{{.Enterprise}}"
+
+  # API base URL: https://api.{subdomain}.ghe.com for data residency, or
+  # https://{hostname}/api/v3 for GitHub Enterprise Server.
+  # api_base_url: "https://api.github.com"
+{{if .Organizations}}
+  organizations:
+{{- range .Organizations}}
+    - "{{.}}"
+{{- end}}
+{{else}}
+  # Organizations, for organization-scoped teams and Copilot seats.
+  # organizations: ["my-org"]
+{{end}}
+`
+
+var starterModeSections = map[string]string{
+	"users": `cost_center:
+  # Everyone goes to the "no PRUs" cost center; exception_users go to the
+  # "PRUs allowed" one.
+  mode: "users"
+  users:
+    # Create the two cost centers by name when they do not exist yet.
+    auto_create: true
+    no_prus_cost_center_name: "00 - No PRU overages"
+    prus_allowed_cost_center_name: "01 - PRU overages allowed"
+
+    # Logins, glob patterns ("svc-*"), or email domains ("@example.com").
+    exception_users: []
+`,
+	"teams": `cost_center:
+  # One cost center per team, holding the team's Copilot users.
+  mode: "teams"
+  teams:
+    # "enterprise" teams, or "organization" teams of github.organizations.
+    scope: "enterprise"
+
+    # "auto": one cost center per team, named after it.  "manual": only the
+    # teams in mappings, to the cost centers named there.
+    strategy: "auto"
+    auto_create: true
+
+    # Remove users who are no longer in the team from its cost center.
+    remove_unmatched_users: false
+
+    # mappings:
+    #   "my-org/frontend": "Frontend"
+`,
+	"repos": `cost_center:
+  # Repositories go to cost centers by the value of a custom property.
+  mode: "repos"
+  repos:
+    mappings:
+      # Repositories whose "cost-center" property is "platform" go to the
+      # "Platform" cost center.  Add one entry per cost center.
+      - cost_center: "Platform"
+        property_name: "cost-center"
+        property_values: ["platform"]
+
+    # Repositories matching no mapping: "skip", "assign_default" (to
+    # default_cost_center), or "error".
+    unmatched_policy: "skip"
+`,
+}
+
+const starterFooter = `
+logging:
+  # "DEBUG", "INFO", "WARNING", or "ERROR".
+  level: "INFO"
+  file: "logs/cost_centers.log"
+
+# Plans, exports, the cache, and run state are written here.
+export_dir: "exports"
+`
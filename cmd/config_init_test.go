@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+)
+
+func TestInitConfig_LoadsForEveryMode(t *testing.T) {
+	t.Setenv("GITHUB_ENTERPRISE", "")
+	t.Setenv("GITHUB_API_BASE_URL", "")
+	for _, mode := range []string{"users", "teams", "repos", "repository"} {
+		t.Run(mode, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config", "config.yaml")
+			var out bytes.Buffer
+			opts := configInitOptions{mode: mode, path: path, enterprise: "acme", orgs: []string{"my-org"}}
+			if err := initConfig(&out, &scriptedPrompter{}, opts); err != nil {
+				t.Fatalf("initConfig: %v\n%s", err, out.String())
+			}
+			if !strings.HasSuffix(out.String(), path+" is valid.\n") {
+				t.Errorf("output does not end with the validation result:\n%s", out.String())
+			}
+
+			config.Strict = true
+			t.Cleanup(func() { config.Strict = false })
+			m, err := config.Load(path, quietLogger())
+			if err != nil {
+				t.Fatalf("config.Load: %v", err)
+			}
+			wantMode := strings.Replace(mode, "repository", "repos", 1)
+			if m.CostCenterMode != wantMode || m.Enterprise != "acme" || len(m.Organizations) != 1 {
+				t.Errorf("loaded mode %q, enterprise %q, orgs %v; want %s, acme, [my-org]",
+					m.CostCenterMode, m.Enterprise, m.Organizations, wantMode)
+			}
+		})
+	}
+}
+
+func TestInitConfig_Prompts(t *testing.T) {
+	t.Setenv("GITHUB_ENTERPRISE", "")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	var out bytes.Buffer
+	p := &scriptedPrompter{interactive: true, lines: []string{" acme ", "org-a, org-b"}}
+	if err := initConfig(&out, p, configInitOptions{mode: "repos", path: path}); err != nil {
+		t.Fatalf("initConfig: %v\n%s", err, out.String())
+	}
+	m, err := config.Load(path, quietLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Enterprise != "acme" || strings.Join(m.ReposOrganizations, ",") != "org-a,org-b" {
+		t.Errorf("enterprise %q, orgs %v; want the answers", m.Enterprise, m.ReposOrganizations)
+	}
+
+	err = initConfig(&out, &scriptedPrompter{}, configInitOptions{mode: "users", path: filepath.Join(t.TempDir(), "c.yaml")})
+	if exitCode(err) != exitCodeConfig || !strings.Contains(err.Error(), "pass --enterprise") {
+		t.Errorf("non-interactive without --enterprise: err = %v", err)
+	}
+}
+
+func TestInitConfig_RefusesOverwrite(t *testing.T) {
+	t.Setenv("GITHUB_ENTERPRISE", "")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("keep: me\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := configInitOptions{mode: "users", path: path, enterprise: "acme"}
+	var out bytes.Buffer
+	if err := initConfig(&out, &scriptedPrompter{}, opts); exitCode(err) != exitCodeExists {
+		t.Fatalf("err = %v, want an already-exists error", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "keep: me\n" {
+		t.Errorf("existing file changed to:\n%s", data)
+	}
+
+	opts.force = true
+	if err := initConfig(&out, &scriptedPrompter{}, opts); err != nil {
+		t.Fatalf("--force: %v", err)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), `enterprise: "acme"`) {
+		t.Errorf("--force did not overwrite the file:\n%s", data)
+	}
+
+	if err := initConfig(&out, &scriptedPrompter{}, configInitOptions{mode: "custom-prop", path: path, force: true}); exitCode(err) != exitCodeConfig {
+		t.Errorf("unsupported mode: err = %v, want a usage error", err)
+	}
+}
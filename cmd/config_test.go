@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+)
+
+func writeValidateConfig(t *testing.T, content string) string {
+	t.Helper()
+	t.Setenv("GITHUB_ENTERPRISE", "")
+	t.Setenv("GITHUB_API_BASE_URL", "")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidateConfig(t *testing.T) {
+	path := writeValidateConfig(t, `
+github:
+  enterprise: "REPLACE_WITH_ENTERPRISE_SLUG"
+cost_center:
+  mode: "users"
+  users:
+    no_prus_cost_center_id: "REPLACE_WITH_NO_PRUS_COST_CENTER_ID"
+    auto_create: false
+budgets:
+  products:
+    copilot_premium_requests:
+      amount: 10
+cache:
+  ttl: "-5m"
+`)
+	var buf bytes.Buffer
+	err := validateConfig(&buf, []string{path}, true)
+	if exitCode(err) != exitCodeConfig {
+		t.Fatalf("err = %v, want a configuration error", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"has 4 problem(s):\n",
+		"  budgets.products.copilot_premium_requests: unknown product or SKU",
+		"  cache.ttl: invalid cache.ttl",
+		"  cost_center.users.no_prus_cost_center_id: cost center ID \"REPLACE_WITH_NO_PRUS_COST_CENTER_ID\" is a placeholder",
+		"  github.enterprise: github enterprise must be configured",
+		"Skipping online checks until github.enterprise is fixed.\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	path = writeValidateConfig(t, "github:\n  enterprise: ent\n")
+	if err := validateConfig(&buf, []string{path}, false); err != nil || buf.String() != path+" is valid.\n" {
+		t.Errorf("valid config: err = %v, output %q", err, buf.String())
+	}
+}
+
+func TestCostCenterRefProblems(t *testing.T) {
+	const missing = "0b9e9a4e-1c1b-4a5f-9d7e-2f1f4c3b5a61"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, missing) {
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"` + testCCID + `","name":"No PRUs","state":"active","resources":[]}`))
+	}))
+	defer srv.Close()
+
+	problems, err := costCenterRefProblems(newTestGitHubClient(t, srv.URL), []config.CostCenterRef{
+		{Path: "cost_center.users.no_prus_cost_center_id", ID: testCCID},
+		{Path: "cost_center.users.prus_allowed_cost_center_id", ID: missing},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || problems[0].Path != "cost_center.users.prus_allowed_cost_center_id" ||
+		!strings.Contains(problems[0].Message, missing+" does not exist") {
+		t.Errorf("problems = %v, want the missing cost center only", problems)
+	}
+}
+
+func TestWriteConfigSummary_Sources(t *testing.T) {
+	base := writeValidateConfig(t, "github:\n  enterprise: base-ent\ncost_center:\n  users:\n    auto_create: true\n")
+	overlay := filepath.Join(filepath.Dir(base), "prod.yaml")
+	if err := os.WriteFile(overlay, []byte("github:\n  enterprise: prod-ent\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mgr, err := config.LoadFiles([]string{base, overlay}, quietLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	writeConfigSummary(&buf, mgr)
+	rows := map[string][]string{}
+	for line := range strings.Lines(buf.String()) {
+		if f := strings.Fields(line); len(f) == 3 {
+			rows[f[0]] = f[1:]
+		}
+	}
+	for key, want := range map[string][]string{
+		"enterprise:":       {"prod-ent", overlay},
+		"auto_create:":      {"true", base},
+		"cost_center_mode:": {"users", config.DefaultSource},
+	} {
+		if got := rows[key]; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+			t.Errorf("%s row = %v, want %v", key, got, want)
+		}
+	}
+	if want := "config files: " + base + ", " + overlay + " (later files override earlier ones)\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("output missing %q:\n%s", want, buf.String())
+	}
+}
+
+func TestValidateConfig_BudgetOverrideProducts(t *testing.T) {
+	path := writeValidateConfig(t, `
+github:
+  enterprise: ent
+budgets:
+  cost_center_overrides:
+    prus_allowed:
+      copilot_premium_request: 500
+      copilot_premium_requests: 500
+`)
+	var buf bytes.Buffer
+	if err := validateConfig(&buf, []string{path}, false); exitCode(err) != exitCodeConfig {
+		t.Fatalf("err = %v, want a configuration error", err)
+	}
+	want := "  budgets.cost_center_overrides.prus_allowed.copilot_premium_requests: unknown product or SKU"
+	if out := buf.String(); !strings.Contains(out, want) || strings.Contains(out, "prus_allowed.copilot_premium_request:") {
+		t.Errorf("output = %q, want only the misspelled product reported", out)
+	}
+}
+
+func TestWriteConfigJSON(t *testing.T) {
+	path := writeValidateConfig(t, `github:
+  enterprise: json-ent
+  organizations: [org-a, org-b]
+cost_center:
+  mode: teams
+  teams:
+    scope: organization
+budgets:
+  enabled: true
+  products:
+    copilot: {amount: 100, enabled: true}
+    actions: {amount: 50, enabled: false}
+`)
+	mgr, err := config.Load(path, quietLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr.Token = "ghp_supersecrettoken1234"
+
+	var buf bytes.Buffer
+	if err := writeConfigJSON(&buf, mgr); err != nil {
+		t.Fatalf("writeConfigJSON: %v", err)
+	}
+	if strings.Contains(buf.String(), "supersecret") {
+		t.Errorf("output contains the token:\n%s", buf.String())
+	}
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshalling: %v\n%s", err, buf.String())
+	}
+	for key, want := range map[string]any{
+		"enterprise":                "json-ent",
+		"token":                     "****1234",
+		"teams_organizations_count": 2.0,
+		"http_timeout":              "30s",
+	} {
+		if got[key] != want {
+			t.Errorf("%s = %v, want %v", key, got[key], want)
+		}
+	}
+	if products, _ := got["budget_products"].([]any); len(products) != 1 || products[0] != "copilot" {
+		t.Errorf("budget_products = %v, want [copilot]", got["budget_products"])
+	}
+
+	// Keys come out sorted, so the same config always gives the same bytes.
+	var keys []string
+	dec := json.NewDecoder(bytes.NewReader(buf.Bytes()))
+	_, _ = dec.Token() // {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, tok.(string))
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !slices.IsSorted(keys) {
+		t.Errorf("keys not sorted: %v", keys)
+	}
+}
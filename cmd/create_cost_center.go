@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+var (
+	createCCFailIfExists bool
+	createCCWithBudgets  []string
+)
+
+var createCostCenterCmd = &cobra.Command{
+	Use:   "create-cost-center <name>",
+	Short: "Create a cost center",
+	Long: `Create a single cost center in the enterprise and print its ID and billing URL.
+
+If a cost center with the name already exists its ID is printed with a notice
+and the command succeeds; pass --fail-if-exists to exit with status 7 instead.
+
+--with-budget <product>=<amount> (repeatable) attaches a budget for the product
+to the cost center right away, using the alerting settings from
+budgets.products in the configuration.  Products that already have a budget
+are left alone.
+
+Examples:
+  # Create a cost center
+  gh cost-center create-cost-center "Platform Engineering"
+
+  # Create it with Copilot and Actions budgets
+  gh cost-center create-cost-center "Platform Engineering" \
+    --with-budget copilot=500 --with-budget actions=200`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCreateCostCenter,
+}
+
+func init() {
+	createCostCenterCmd.Flags().BoolVar(&createCCFailIfExists, "fail-if-exists", false, "exit with status 7 if the cost center already exists")
+	createCostCenterCmd.Flags().StringArrayVar(&createCCWithBudgets, "with-budget", nil, "attach a budget as <product>=<amount> (repeatable)")
+
+	rootCmd.AddCommand(createCostCenterCmd)
+}
+
+// budgetSpec is one --with-budget value.
+type budgetSpec struct {
+	Product string
+	Amount  int
+}
+
+// parseBudgetSpecs parses --with-budget values of the form product=amount.
+func parseBudgetSpecs(values []string) ([]budgetSpec, error) {
+	specs := make([]budgetSpec, 0, len(values))
+	seen := make(map[string]bool)
+	for _, v := range values {
+		product, amountStr, ok := strings.Cut(v, "=")
+		product = strings.ToLower(strings.TrimSpace(product))
+		if !ok || product == "" {
+			return nil, usageErrorf("invalid --with-budget %q: expected <product>=<amount>", v)
+		}
+		amount, err := strconv.Atoi(strings.TrimSpace(amountStr))
+		if err != nil || amount <= 0 {
+			return nil, usageErrorf("invalid --with-budget %q: amount must be a positive integer", v)
+		}
+		if seen[product] {
+			return nil, usageErrorf("invalid --with-budget %q: product %s given more than once", v, product)
+		}
+		seen[product] = true
+		specs = append(specs, budgetSpec{Product: product, Amount: amount})
+	}
+	return specs, nil
+}
+
+func runCreateCostCenter(_ *cobra.Command, args []string) error {
+	name := strings.TrimSpace(args[0])
+	if name == "" {
+		return fmt.Errorf("cost center name must not be empty")
+	}
+	specs, err := parseBudgetSpecs(createCCWithBudgets)
+	if err != nil {
+		return err
+	}
+
+	logger := slog.Default()
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	attachCache(client, logger)
+	attachAuditLog(client, logger)
+
+	return createCostCenter(client, name, specs, createCCFailIfExists)
+}
+
+// createCostCenter creates the cost center name, prints its ID and billing
+// URL, and attaches the given budgets.  An existing cost center is reported
+// as such; with failIfExists it is an exitCodeExists error and no budgets are
+// attached.
+func createCostCenter(client github.CostCenterAPI, name string, specs []budgetSpec, failIfExists bool) error {
+	id, created, err := client.EnsureCostCenter(name)
+	if err != nil {
+		return err
+	}
+
+	if created {
+		fmt.Printf("Created cost center %q\n", name)
+	} else {
+		fmt.Printf("Cost center %q already exists\n", name)
+	}
+	fmt.Printf("ID:  %s\n", id)
+	fmt.Printf("URL: https://github.com/enterprises/%s/billing/cost_centers/%s\n", cfgManager.Enterprise, id)
+
+	if !created && failIfExists {
+		return &exitError{code: exitCodeExists, err: fmt.Errorf("cost center %q already exists (%s)", name, id)}
+	}
+
+	for _, s := range specs {
+		alerting := github.AlertingFromConfig(cfgManager.BudgetProducts[s.Product])
+		if _, err := client.CreateProductBudget(id, name, s.Product, s.Amount, alerting); err != nil {
+			return budgetsError(fmt.Sprintf("attaching %s budget", s.Product), err)
+		}
+		fmt.Printf("Budget: %s = %d\n", s.Product, s.Amount)
+	}
+	return nil
+}
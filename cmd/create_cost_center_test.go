@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// createCCTestServer answers cost center creation with testCCID, or with a
+// 409 Conflict naming testCCID when exists is true, and records budget
+// POSTs as "entity sku amount".
+func createCCTestServer(t *testing.T, exists bool) (*httptest.Server, *[]string) {
+	t.Helper()
+	var (
+		mu      sync.Mutex
+		budgets []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/cost-centers"):
+			if exists {
+				w.WriteHeader(http.StatusConflict)
+				_ = json.NewEncoder(w).Encode(map[string]string{
+					"message":                 "Cost center name already exists",
+					"existing_cost_center_id": testCCID,
+				})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"id": testCCID, "name": "New Team"})
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/budgets"):
+			var body struct {
+				Entity string `json:"budget_entity_name"`
+				SKU    string `json:"budget_product_sku"`
+				Amount int    `json:"budget_amount"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			budgets = append(budgets, fmt.Sprintf("%s %s %d", body.Entity, body.SKU, body.Amount))
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{}`))
+		case strings.HasSuffix(r.URL.Path, "/budgets"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"budgets": []map[string]any{}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &budgets
+}
+
+func TestParseBudgetSpecs(t *testing.T) {
+	specs, err := parseBudgetSpecs([]string{"Copilot=500", " actions = 20 "})
+	if err != nil {
+		t.Fatalf("parseBudgetSpecs: %v", err)
+	}
+	want := []budgetSpec{{Product: "copilot", Amount: 500}, {Product: "actions", Amount: 20}}
+	if fmt.Sprint(specs) != fmt.Sprint(want) {
+		t.Errorf("specs = %v, want %v", specs, want)
+	}
+
+	for _, bad := range []string{"copilot", "=5", "copilot=abc", "copilot=0", "copilot=1,copilot=2"} {
+		if _, err := parseBudgetSpecs(strings.Split(bad, ",")); err == nil {
+			t.Errorf("parseBudgetSpecs(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestCreateCostCenter_AttachesBudgets(t *testing.T) {
+	useBudgetsConfig(t)
+	srv, budgets := createCCTestServer(t, false)
+
+	specs := []budgetSpec{{Product: "copilot", Amount: 500}, {Product: "actions", Amount: 20}}
+	if err := createCostCenter(newTestGitHubClient(t, srv.URL), "New Team", specs, true); err != nil {
+		t.Fatalf("createCostCenter: %v", err)
+	}
+	want := testCCID + " copilot 500," + testCCID + " actions 20"
+	if got := strings.Join(*budgets, ","); got != want {
+		t.Errorf("budget POSTs = %q, want %q", got, want)
+	}
+}
+
+func TestCreateCostCenter_Conflict(t *testing.T) {
+	useBudgetsConfig(t)
+	srv, budgets := createCCTestServer(t, true)
+	client := newTestGitHubClient(t, srv.URL)
+	specs := []budgetSpec{{Product: "copilot", Amount: 500}}
+
+	// Without --fail-if-exists an existing cost center is success, and the
+	// budgets are still attached to it.
+	if err := createCostCenter(client, "New Team", specs, false); err != nil {
+		t.Fatalf("createCostCenter on existing cost center: %v", err)
+	}
+	if got := strings.Join(*budgets, ","); got != testCCID+" copilot 500" {
+		t.Errorf("budget POSTs = %q, want one for the existing cost center", got)
+	}
+
+	*budgets = nil
+	var ee *exitError
+	err := createCostCenter(client, "New Team", specs, true)
+	if !errors.As(err, &ee) || ee.code != exitCodeExists {
+		t.Fatalf("err = %v, want exit code %d", err, exitCodeExists)
+	}
+	if !strings.Contains(err.Error(), testCCID) {
+		t.Errorf("error %q does not name the existing ID", err)
+	}
+	if len(*budgets) != 0 {
+		t.Errorf("budget POSTs = %v, want none with --fail-if-exists", *budgets)
+	}
+}
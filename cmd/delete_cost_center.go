@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+var (
+	deleteCCYes   bool
+	deleteCCForce bool
+)
+
+var deleteCostCenterCmd = &cobra.Command{
+	Use:   "delete-cost-center <id-or-name>",
+	Short: "Delete a cost center",
+	Long: `Delete a single cost center from the enterprise.
+
+The argument may be a cost center UUID or its exact name.  Cost centers that
+still have users assigned are refused unless --force is passed.
+
+Examples:
+  # Delete by name (asks for confirmation)
+  gh cost-center delete-cost-center "Old Team"
+
+  # Delete by ID without confirmation, even if it has members
+  gh cost-center delete-cost-center d1e2f3a4-b5c6-7890-abcd-ef1234567890 --yes --force`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDeleteCostCenter,
+}
+
+func init() {
+	deleteCostCenterCmd.Flags().BoolVarP(&deleteCCYes, "yes", "y", false, "skip confirmation prompt")
+	deleteCostCenterCmd.Flags().BoolVar(&deleteCCForce, "force", false, "delete even if the cost center still has members")
+
+	rootCmd.AddCommand(deleteCostCenterCmd)
+}
+
+func runDeleteCostCenter(_ *cobra.Command, args []string) error {
+	logger := slog.Default()
+
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	attachCache(client, logger)
+	attachAuditLog(client, logger)
+
+	confirm := confirmYes
+	if deleteCCYes {
+		confirm = nil
+	}
+	return deleteCostCenter(client, args[0], deleteCCForce, confirm)
+}
+
+// deleteCostCenter resolves ref to a cost center, guards against deleting one
+// that still has members (unless force), asks for confirmation when confirm
+// is non-nil, and deletes it.
+func deleteCostCenter(client github.CostCenterAPI, ref string, force bool, confirm func(string) (bool, error)) error {
+	id, name, err := resolveCostCenterRef(client, ref)
+	if err != nil {
+		return err
+	}
+
+	members, err := client.GetCostCenterUsers(id)
+	if err != nil {
+		return fmt.Errorf("checking cost center members: %w", err)
+	}
+	if len(members) > 0 && !force {
+		return fmt.Errorf("cost center %q (%s) still has %d users assigned — use --force to delete it anyway",
+			name, id, len(members))
+	}
+
+	fmt.Printf("Cost center: %s\n", name)
+	fmt.Printf("ID:          %s\n", id)
+	fmt.Printf("Members:     %d users\n", len(members))
+
+	if confirm != nil {
+		proceed, err := confirm(fmt.Sprintf("Delete cost center %q?", name))
+		if err != nil {
+			return fmt.Errorf("confirmation failed: %w", err)
+		}
+		if !proceed {
+			slog.Default().Warn("Aborted by user")
+			return nil
+		}
+	}
+
+	if err := client.DeleteCostCenter(id); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted cost center %q (%s)\n", name, id)
+	return nil
+}
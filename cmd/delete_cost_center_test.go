@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+const testCCID = "d1e2f3a4-b5c6-7890-abcd-ef1234567890"
+
+// newTestGitHubClient returns a client pointed at a test server.
+func newTestGitHubClient(t *testing.T, url string) *github.Client {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	cfg := &config.Manager{Enterprise: "test-ent", APIBaseURL: url, Token: "test-token"}
+	c, err := github.NewClient(cfg, logger)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c
+}
+
+// deleteTestServer serves one active cost center with the given members and
+// counts DELETE requests.
+func deleteTestServer(t *testing.T, members []string, deletes *atomic.Int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodDelete:
+			deletes.Add(1)
+			w.WriteHeader(http.StatusNoContent)
+		case strings.HasSuffix(r.URL.Path, "/cost-centers"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"costCenters": []map[string]string{
+				{"id": testCCID, "name": "Old Team", "state": "active"},
+			}})
+		default:
+			var res []map[string]string
+			for _, m := range members {
+				res = append(res, map[string]string{"type": "User", "name": m})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": testCCID, "resources": res})
+		}
+	}))
+}
+
+func TestDeleteCostCenter_ResolvesName(t *testing.T) {
+	var deletes atomic.Int32
+	srv := deleteTestServer(t, nil, &deletes)
+	defer srv.Close()
+
+	if err := deleteCostCenter(newTestGitHubClient(t, srv.URL), "Old Team", false, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deletes.Load() != 1 {
+		t.Errorf("deletes = %d, want 1", deletes.Load())
+	}
+}
+
+func TestDeleteCostCenter_UnknownName(t *testing.T) {
+	var deletes atomic.Int32
+	srv := deleteTestServer(t, nil, &deletes)
+	defer srv.Close()
+
+	err := deleteCostCenter(newTestGitHubClient(t, srv.URL), "Missing", false, nil)
+	if err == nil || !strings.Contains(err.Error(), "Missing") {
+		t.Fatalf("expected not-found error naming the cost center, got %v", err)
+	}
+	if deletes.Load() != 0 {
+		t.Errorf("deletes = %d, want 0", deletes.Load())
+	}
+}
+
+func TestDeleteCostCenter_MemberGuard(t *testing.T) {
+	var deletes atomic.Int32
+	srv := deleteTestServer(t, []string{"alice"}, &deletes)
+	defer srv.Close()
+
+	err := deleteCostCenter(newTestGitHubClient(t, srv.URL), testCCID, false, nil)
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("expected member guard error, got %v", err)
+	}
+	if deletes.Load() != 0 {
+		t.Errorf("deletes = %d, want 0", deletes.Load())
+	}
+}
+
+func TestDeleteCostCenter_Force(t *testing.T) {
+	var deletes atomic.Int32
+	srv := deleteTestServer(t, []string{"alice"}, &deletes)
+	defer srv.Close()
+
+	if err := deleteCostCenter(newTestGitHubClient(t, srv.URL), testCCID, true, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deletes.Load() != 1 {
+		t.Errorf("deletes = %d, want 1", deletes.Load())
+	}
+}
+
+func TestDeleteCostCenter_Declined(t *testing.T) {
+	var deletes atomic.Int32
+	srv := deleteTestServer(t, nil, &deletes)
+	defer srv.Close()
+
+	decline := func(string) (bool, error) { return false, nil }
+	if err := deleteCostCenter(newTestGitHubClient(t, srv.URL), "Old Team", false, decline); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deletes.Load() != 0 {
+		t.Errorf("deletes = %d, want 0", deletes.Load())
+	}
+}
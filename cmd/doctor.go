@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+var doctorTimeout time.Duration
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check configuration, connectivity, and token permissions",
+	Long: `Run preflight checks and print a ✓ or ✗ line for each, with a hint on how
+to fix a failure:
+
+  1. the configuration loads and validates
+  2. the API base URL is reachable
+  3. the token authenticates
+  4. a classic token has the manage_billing:enterprise and read:enterprise
+     scopes (X-OAuth-Scopes)
+  5. the token can list cost centers
+  6. the Copilot seats endpoint responds
+  7. the Budgets API is available (optional)
+  8. the teams or repository endpoints of the configured mode respond
+
+Each check gives up after --timeout.  A failed configuration, connectivity,
+or authentication check skips the checks after it.  doctor exits non-zero
+when any required check fails.
+
+Examples:
+  gh cost-center doctor
+  gh cost-center doctor --config prod.yaml --timeout 5s`,
+	// doctor loads the configuration itself so that a broken one is
+	// reported as a failed check instead of aborting.
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		resolveConfigFiles(cmd, setupLogger())
+		return nil
+	},
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return runDoctor(os.Stdout, doctorTimeout, loadDoctorConfig)
+	},
+}
+
+func init() {
+	doctorCmd.Flags().DurationVar(&doctorTimeout, "timeout", 10*time.Second, "time limit for each check")
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// doctorCheck is one preflight check.  run returns a short detail shown on
+// success, or an error; hint explains how to fix a failure.
+type doctorCheck struct {
+	name     string
+	optional bool // a failure is reported but does not fail doctor
+	fatal    bool // a failure skips the remaining checks
+	run      func() (string, error)
+	hint     func(error) string
+}
+
+// loadDoctorConfig loads the configuration as every other command does.
+func loadDoctorConfig() (*config.Manager, error) {
+	mgr, err := config.LoadFiles(cfgFiles, slog.Default())
+	if err != nil {
+		return nil, err
+	}
+	mgr.Token = tokenFlag
+	return mgr, nil
+}
+
+// runDoctor runs the checks in order, writing one line per check to w, and
+// returns an error when a required check failed.  load loads the
+// configuration checked first.
+func runDoctor(w io.Writer, timeout time.Duration, load func() (*config.Manager, error)) error {
+	if timeout <= 0 {
+		return usageErrorf("invalid --timeout %s: must be positive", timeout)
+	}
+	var cfg *config.Manager
+	var client *github.Client
+	checks := []doctorCheck{{
+		name:  "Configuration loads and validates",
+		fatal: true,
+		run: func() (string, error) {
+			mgr, err := load()
+			if err != nil {
+				return "", err
+			}
+			cfg = mgr
+			mode := cfg.CostCenterMode
+			if mode == "" {
+				mode = "users"
+			}
+			return fmt.Sprintf("%s, enterprise %s, %s mode", strings.Join(cfgFiles, " + "), cfg.Enterprise, mode), nil
+		},
+		hint: func(error) string {
+			return fmt.Sprintf("fix the setting named above in %s (see config/config.example.yaml)", strings.Join(cfgFiles, " or "))
+		},
+	}}
+
+	failed := 0
+	for i := 0; i < len(checks); i++ {
+		c := checks[i]
+		detail, err := c.run()
+		switch {
+		case err == nil:
+			if detail != "" {
+				_, _ = fmt.Fprintf(w, "✓ %s (%s)\n", c.name, detail)
+			} else {
+				_, _ = fmt.Fprintf(w, "✓ %s\n", c.name)
+			}
+		case c.optional:
+			_, _ = fmt.Fprintf(w, "✗ %s (optional): %v\n", c.name, err)
+			_, _ = fmt.Fprintf(w, "    hint: %s\n", c.hint(err))
+		default:
+			failed++
+			_, _ = fmt.Fprintf(w, "✗ %s: %v\n", c.name, err)
+			_, _ = fmt.Fprintf(w, "    hint: %s\n", c.hint(err))
+		}
+		if err != nil && c.fatal {
+			_, _ = fmt.Fprintln(w, "  remaining checks skipped")
+			break
+		}
+		if i == 0 {
+			checks = append(checks, doctorChecks(cfg, &client, timeout)...)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("doctor: %d required check(s) failed", failed)
+	}
+	_, _ = fmt.Fprintln(w, "All required checks passed.")
+	return nil
+}
+
+// doctorChecks returns the checks that follow a successful configuration
+// check.  The token check stores the client it creates in *client for the
+// later checks.
+func doctorChecks(cfg *config.Manager, client **github.Client, timeout time.Duration) []doctorCheck {
+	logger := slog.Default()
+	probe := func(enterprise bool, path string) func() (string, error) {
+		return func() (string, error) {
+			var err error
+			if enterprise {
+				_, err = (*client).ProbeEnterprise(path, timeout)
+			} else {
+				_, err = (*client).Probe(path, timeout)
+			}
+			return "", err
+		}
+	}
+
+	checks := []doctorCheck{
+		{
+			name:  "API base URL is reachable",
+			fatal: true,
+			run: func() (string, error) {
+				// Reachability only: the request is unauthenticated, so any
+				// HTTP response counts.
+				anon, err := github.NewClient(&config.Manager{
+					Enterprise:         cfg.Enterprise,
+					APIBaseURL:         cfg.APIBaseURL,
+					Token:              "-",
+					CACertPath:         cfg.CACertPath,
+					InsecureSkipVerify: cfg.InsecureSkipVerify,
+				}, logger)
+				if err != nil {
+					return "", err
+				}
+				if status, err := anon.Probe("/", timeout); status == 0 {
+					return "", err
+				}
+				return cfg.APIBaseURL, nil
+			},
+			hint: func(error) string {
+				return "check github.api_base_url (GHES: https://<host>/api/v3), your network, and HTTPS_PROXY"
+			},
+		},
+		{
+			name:  "Token authenticates",
+			fatal: true,
+			run: func() (string, error) {
+				c, err := github.NewClient(cfg, logger)
+				if err != nil {
+					return "", err
+				}
+				*client = c
+				// GitHub App installation tokens cannot read /user (403) but
+				// are authenticated; only 401 means a bad token.
+				if status, err := c.Probe("/user", timeout); err != nil && status != http.StatusForbidden {
+					return "", err
+				}
+				return "", nil
+			},
+			hint: func(err error) string {
+				if isStatus(err, http.StatusUnauthorized) {
+					return "the token is invalid or expired: run 'gh auth refresh' or set a new GITHUB_TOKEN"
+				}
+				return "set GITHUB_TOKEN or GH_TOKEN, pass --token, or run 'gh auth login'"
+			},
+		},
+		{
+			name: "Token has the required scopes",
+			run: func() (string, error) {
+				return "", (*client).CheckScopes(timeout)
+			},
+			hint: func(error) string {
+				return "fine-grained and GitHub App tokens are not checked here; they need the equivalent billing and enterprise read permissions"
+			},
+		},
+		{
+			name: "Token can list cost centers",
+			run:  probe(true, "/settings/billing/cost-centers"),
+			hint: func(err error) string {
+				if isStatus(err, http.StatusNotFound) {
+					return fmt.Sprintf("check github.enterprise (%q); the token's user must be an enterprise owner or billing manager", cfg.Enterprise)
+				}
+				return "the token needs the manage_billing:enterprise scope and an enterprise owner or billing manager"
+			},
+		},
+		{
+			name: "Copilot seats endpoint responds",
+			run:  copilotSeatsProbe(cfg, client, timeout),
+			hint: func(error) string {
+				return "the token needs manage_billing:copilot (or read:enterprise) and Copilot must be enabled for the enterprise"
+			},
+		},
+		{
+			name:     "Budgets API is available",
+			optional: true,
+			run:      probe(true, "/settings/billing/budgets?per_page=1"),
+			hint: func(err error) string {
+				if isStatus(err, http.StatusNotFound) {
+					return "the Budgets API is not enabled for this enterprise; budget features will be skipped"
+				}
+				return "budget features need the manage_billing:enterprise scope"
+			},
+		},
+	}
+
+	switch cfg.CostCenterMode {
+	case "teams":
+		if cfg.TeamsScope == "enterprise" {
+			checks = append(checks, doctorCheck{
+				name: "Enterprise teams endpoint responds",
+				run:  probe(true, "/teams?per_page=1"),
+				hint: func(error) string { return "the token needs the read:enterprise scope" },
+			})
+			break
+		}
+		for _, org := range cfg.Organizations {
+			checks = append(checks, doctorCheck{
+				name: fmt.Sprintf("Teams of organization %s are readable", org),
+				run:  probe(false, "/orgs/"+url.PathEscape(org)+"/teams?per_page=1"),
+				hint: func(error) string {
+					return fmt.Sprintf("the token needs the read:org scope and access to %s (SSO authorization if enforced)", org)
+				},
+			})
+		}
+	case "repos", "custom-prop":
+		orgs := cfg.ReposOrganizations
+		if cfg.CostCenterMode == "custom-prop" {
+			orgs = cfg.Organizations[:min(1, len(cfg.Organizations))]
+		}
+		for _, org := range orgs {
+			checks = append(checks, doctorCheck{
+				name: fmt.Sprintf("Custom properties of organization %s are readable", org),
+				run:  probe(false, "/orgs/"+url.PathEscape(org)+"/properties/schema"),
+				hint: func(error) string {
+					return fmt.Sprintf("the token needs read:org and custom properties read access in %s", org)
+				},
+			})
+		}
+	}
+	return checks
+}
+
+// copilotSeatsProbe probes the enterprise seats endpoint, or each
+// organization's in organization Copilot scope.
+func copilotSeatsProbe(cfg *config.Manager, client **github.Client, timeout time.Duration) func() (string, error) {
+	return func() (string, error) {
+		if cfg.CopilotScope != "organization" {
+			_, err := (*client).ProbeEnterprise("/copilot/billing/seats?per_page=1", timeout)
+			return "", err
+		}
+		for _, org := range cfg.Organizations {
+			if _, err := (*client).Probe("/orgs/"+url.PathEscape(org)+"/copilot/billing/seats?per_page=1", timeout); err != nil {
+				return "", fmt.Errorf("organization %s: %w", org, err)
+			}
+		}
+		return fmt.Sprintf("%d organizations", len(cfg.Organizations)), nil
+	}
+}
+
+// isStatus reports whether err is an API error with the given status.
+func isStatus(err error, status int) bool {
+	var apiErr *github.APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
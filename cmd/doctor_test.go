@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+)
+
+// doctorServer answers every doctor probe with 200 except the paths in
+// fail (path suffix -> status); a status of 0 hangs until the client gives
+// up.
+func doctorServer(t *testing.T, fail map[string]int) *httptest.Server {
+	t.Helper()
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for suffix, status := range fail {
+			if strings.HasSuffix(r.URL.Path, suffix) {
+				if status == 0 {
+					select {
+					case <-r.Context().Done():
+					case <-done:
+					}
+					return
+				}
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(`{"message":"nope"}`))
+				return
+			}
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(func() { close(done); srv.Close() })
+	return srv
+}
+
+// doctorConfig returns a loader for a configuration pointing at srv.
+func doctorConfig(srv *httptest.Server, mode string, orgs ...string) func() (*config.Manager, error) {
+	return func() (*config.Manager, error) {
+		return &config.Manager{
+			Enterprise:         "test-ent",
+			APIBaseURL:         srv.URL,
+			Token:              "test-token",
+			CostCenterMode:     mode,
+			CopilotScope:       "enterprise",
+			TeamsScope:         "organization",
+			Organizations:      orgs,
+			ReposOrganizations: orgs,
+		}, nil
+	}
+}
+
+func TestDoctor_AllPass(t *testing.T) {
+	srv := doctorServer(t, nil)
+	var buf bytes.Buffer
+	if err := runDoctor(&buf, time.Second, doctorConfig(srv, "teams", "org1")); err != nil {
+		t.Fatalf("runDoctor: %v\n%s", err, buf.String())
+	}
+	out := buf.String()
+	if strings.Contains(out, "✗") || !strings.Contains(out, "✓ Teams of organization org1 are readable") {
+		t.Errorf("output:\n%s", out)
+	}
+}
+
+func TestDoctor_ConfigFailureSkipsEverything(t *testing.T) {
+	var buf bytes.Buffer
+	err := runDoctor(&buf, time.Second, func() (*config.Manager, error) {
+		return nil, errors.New("invalid cost_center.mode \"nope\"")
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	out := buf.String()
+	if !strings.Contains(out, "✗ Configuration loads and validates") || !strings.Contains(out, "remaining checks skipped") {
+		t.Errorf("output:\n%s", out)
+	}
+	if strings.Count(out, "✗")+strings.Count(out, "✓") != 1 {
+		t.Errorf("ran checks after the configuration failed:\n%s", out)
+	}
+}
+
+func TestDoctor_FailingChecks(t *testing.T) {
+	tests := []struct {
+		name     string
+		fail     map[string]int
+		wantErr  bool
+		wantLine string
+		wantHint string
+		fatal    bool
+	}{
+		{"bad token", map[string]int{"/user": http.StatusUnauthorized}, true,
+			"✗ Token authenticates", "invalid or expired", true},
+		{"app token without /user", map[string]int{"/user": http.StatusForbidden}, false,
+			"✓ Token authenticates", "", false},
+		{"no billing scope", map[string]int{"/cost-centers": http.StatusForbidden}, true,
+			"✗ Token can list cost centers", "manage_billing:enterprise", false},
+		{"seats hang", map[string]int{"/seats": 0}, true,
+			"✗ Copilot seats endpoint responds", "manage_billing:copilot", false},
+		{"budgets off", map[string]int{"/budgets": http.StatusNotFound}, false,
+			"✗ Budgets API is available (optional)", "not enabled", false},
+		{"org teams", map[string]int{"/orgs/org1/teams": http.StatusForbidden}, true,
+			"✗ Teams of organization org1 are readable", "read:org", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := doctorServer(t, tt.fail)
+			var buf bytes.Buffer
+			start := time.Now()
+			err := runDoctor(&buf, 200*time.Millisecond, doctorConfig(srv, "teams", "org1"))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			out := buf.String()
+			if !strings.Contains(out, tt.wantLine) || !strings.Contains(out, tt.wantHint) {
+				t.Errorf("output missing %q / %q:\n%s", tt.wantLine, tt.wantHint, out)
+			}
+			// Checks after a failing non-fatal one still run; an
+			// authentication failure skips them.
+			if ran := strings.Contains(out, "Teams of organization org1"); ran == tt.fatal {
+				t.Errorf("later checks ran = %v, want %v:\n%s", ran, !tt.fatal, out)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("doctor took %s; a hanging check should time out", elapsed)
+			}
+		})
+	}
+}
+
+func TestDoctor_MissingScopes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-OAuth-Scopes", "repo, manage_billing:enterprise")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	var buf bytes.Buffer
+	if err := runDoctor(&buf, time.Second, doctorConfig(srv, "users")); err == nil {
+		t.Error("expected an error for a token without read:enterprise")
+	}
+	out := buf.String()
+	if !strings.Contains(out, "✗ Token has the required scopes: the token lacks required scopes; missing scopes: read:enterprise") ||
+		!strings.Contains(out, "✓ Token can list cost centers") {
+		t.Errorf("output:\n%s", out)
+	}
+}
+
+func TestDoctor_UnreachableSkipsRest(t *testing.T) {
+	srv := doctorServer(t, nil)
+	load := doctorConfig(srv, "users")
+	srv.Close()
+
+	var buf bytes.Buffer
+	if err := runDoctor(&buf, time.Second, load); err == nil {
+		t.Fatal("expected an error for an unreachable API")
+	}
+	out := buf.String()
+	if !strings.Contains(out, "✗ API base URL is reachable") || strings.Contains(out, "Token authenticates") {
+		t.Errorf("output:\n%s", out)
+	}
+}
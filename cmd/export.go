@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/pru"
+)
+
+var (
+	exportFormat       string
+	exportCheckCurrent bool
+	exportAnonymize    bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write a point-in-time snapshot of cost center assignments",
+	Long: `Write a snapshot of every Copilot seat holder to a CSV or JSON file in
+export_dir: the cost center the active mode assigns them to, their seat
+creation and last activity dates, and their PRU exception status.  With
+--check-current the cost center they are in right now is added (one request
+per cost center).
+
+The file starts with metadata (enterprise, mode, timestamp, tool version):
+"# key: value" comment lines in CSV, a "metadata" object in JSON.  CSV
+columns are only ever appended.  --anonymize replaces every login with a
+stable hash so the file can be shared outside the admin team; the same login
+always hashes to the same value within an enterprise.
+
+The path of the written file is printed on stdout (logs go to stderr), so
+scripts can capture it.  Supported in users, teams, and assigning-team modes.
+
+Examples:
+  gh cost-center export
+  gh cost-center export --format json --check-current --anonymize
+  file=$(gh cost-center export) && upload "$file"`,
+	RunE: runExport,
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFormat, "format", "csv", "file format: csv or json")
+	exportCmd.Flags().BoolVar(&exportCheckCurrent, "check-current", false, "include each user's current cost center")
+	exportCmd.Flags().BoolVar(&exportAnonymize, "anonymize", false, "replace logins with stable hashes")
+	rootCmd.AddCommand(exportCmd)
+}
+
+// snapshotMetadata describes an assignment snapshot.
+type snapshotMetadata struct {
+	Enterprise   string    `json:"enterprise"`
+	Mode         string    `json:"mode"`
+	GeneratedAt  time.Time `json:"generated_at"`
+	ToolVersion  string    `json:"tool_version"`
+	CheckCurrent bool      `json:"check_current"`
+	Anonymized   bool      `json:"anonymized"`
+}
+
+// snapshotRow is one Copilot user of a snapshot.  Desired fields are empty
+// for users the mode does not assign (excluded or skipped seats); actual
+// fields are empty without --check-current and for users in no cost center.
+type snapshotRow struct {
+	Login               string `json:"login"`
+	DesiredCostCenter   string `json:"desired_cost_center"`
+	DesiredCostCenterID string `json:"desired_cost_center_id"`
+	ActualCostCenter    string `json:"actual_cost_center"`
+	ActualCostCenterID  string `json:"actual_cost_center_id"`
+	SeatCreatedAt       string `json:"seat_created_at"`
+	LastActivityAt      string `json:"last_activity_at"`
+	PRUException        bool   `json:"pru_exception"`
+}
+
+// snapshot is the content of an export file.
+type snapshot struct {
+	Metadata snapshotMetadata `json:"metadata"`
+	Users    []snapshotRow    `json:"users"`
+}
+
+func runExport(_ *cobra.Command, _ []string) error {
+	if exportFormat != "csv" && exportFormat != "json" {
+		return usageErrorf("invalid --format %q: must be 'csv' or 'json'", exportFormat)
+	}
+	mode := cfgManager.CostCenterMode
+	if mode == "repos" || mode == "custom-prop" {
+		return fmt.Errorf("export snapshots user assignments and is not supported in %s mode, which assigns repositories", mode)
+	}
+	if mode == "" {
+		mode = "users"
+	}
+	ver, err := toolVersion()
+	if err != nil {
+		return err
+	}
+
+	logger := slog.Default()
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	attachCache(client, logger)
+
+	users, err := client.GetCopilotUsers()
+	if err != nil {
+		return fmt.Errorf("fetching copilot users: %w", err)
+	}
+	groups, names, err := desiredGroups(client, users, logger)
+	if err != nil {
+		return err
+	}
+
+	var active map[string]string
+	var memberOf map[string]string
+	if exportCheckCurrent {
+		var members map[string][]string
+		if active, members, err = client.GetAllActiveCostCentersWithMembers(membershipFetchConcurrency); err != nil {
+			return fmt.Errorf("fetching cost center membership: %w", err)
+		}
+		memberOf = membershipIndex(members)
+	} else if active, err = client.GetAllActiveCostCenters(); err != nil {
+		return fmt.Errorf("fetching active cost centers: %w", err)
+	}
+
+	mgr := pru.NewManager(cfgManager, logger)
+	if err := loadTierTeams(client, mgr, false, logger); err != nil {
+		return err
+	}
+
+	snap := &snapshot{
+		Metadata: snapshotMetadata{
+			Enterprise:   cfgManager.Enterprise,
+			Mode:         mode,
+			GeneratedAt:  time.Now().UTC(),
+			ToolVersion:  ver,
+			CheckCurrent: exportCheckCurrent,
+		},
+		Users: buildSnapshotRows(users, resolveDesired(groups, names, active), memberOf, reverseMap(active), mgr.IsExceptionUser),
+	}
+	if exportAnonymize {
+		anonymizeSnapshot(snap)
+	}
+
+	path, err := exportSnapshot(cfgManager.ExportDir, exportFormat, snap)
+	if err != nil {
+		return err
+	}
+	logger.Info("Snapshot exported", "path", path, "users", len(snap.Users))
+	fmt.Println(path)
+	return nil
+}
+
+// buildSnapshotRows builds one row per user, sorted by login.  memberOf
+// (lower-cased login -> cost center ID) is nil without --check-current;
+// idToName names the cost centers.
+func buildSnapshotRows(users []github.CopilotUser, desired map[string]desiredCC, memberOf, idToName map[string]string,
+	isException func(github.CopilotUser) bool) []snapshotRow {
+	rows := make([]snapshotRow, 0, len(users))
+	for _, u := range users {
+		if u.Login == "" {
+			continue
+		}
+		row := snapshotRow{
+			Login:          u.Login,
+			SeatCreatedAt:  u.CreatedAt,
+			LastActivityAt: u.LastActivityAt,
+			PRUException:   isException(u),
+		}
+		if cc, ok := desired[u.Login]; ok {
+			row.DesiredCostCenter, row.DesiredCostCenterID = cc.name, cc.id
+		}
+		if id, ok := memberOf[strings.ToLower(u.Login)]; ok {
+			row.ActualCostCenter, row.ActualCostCenterID = idToName[id], id
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return strings.ToLower(rows[i].Login) < strings.ToLower(rows[j].Login) })
+	return rows
+}
+
+// anonymizeSnapshot replaces every login with anonymizeLogin and marks the
+// snapshot as anonymized.
+func anonymizeSnapshot(s *snapshot) {
+	for i := range s.Users {
+		s.Users[i].Login = anonymizeLogin(s.Metadata.Enterprise, s.Users[i].Login)
+	}
+	s.Metadata.Anonymized = true
+	sort.Slice(s.Users, func(i, j int) bool { return s.Users[i].Login < s.Users[j].Login })
+}
+
+// anonymizeLogin returns a stable, case-insensitive pseudonym for login:
+// "user-" and the first 16 hex digits of SHA-256 over the enterprise and the
+// lower-cased login.
+func anonymizeLogin(enterprise, login string) string {
+	sum := sha256.Sum256([]byte(enterprise + ":" + strings.ToLower(login)))
+	return "user-" + hex.EncodeToString(sum[:8])
+}
+
+// snapshotCSVHeader is the header row of CSV snapshots.  Columns are only
+// ever appended so that scripts reading by position keep working.
+var snapshotCSVHeader = []string{
+	"login", "desired_cost_center", "desired_cost_center_id", "actual_cost_center",
+	"actual_cost_center_id", "seat_created_at", "last_activity_at", "pru_exception",
+}
+
+// writeSnapshotCSV writes s as "# key: value" metadata lines followed by CSV
+// with snapshotCSVHeader.
+func writeSnapshotCSV(w io.Writer, s *snapshot) error {
+	m := s.Metadata
+	for _, kv := range [][2]string{
+		{"enterprise", m.Enterprise},
+		{"mode", m.Mode},
+		{"generated_at", m.GeneratedAt.Format(time.RFC3339)},
+		{"tool_version", m.ToolVersion},
+		{"check_current", strconv.FormatBool(m.CheckCurrent)},
+		{"anonymized", strconv.FormatBool(m.Anonymized)},
+	} {
+		if _, err := fmt.Fprintf(w, "# %s: %s\n", kv[0], kv[1]); err != nil {
+			return fmt.Errorf("writing CSV: %w", err)
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(snapshotCSVHeader); err != nil {
+		return fmt.Errorf("writing CSV: %w", err)
+	}
+	for _, r := range s.Users {
+		record := []string{
+			r.Login, r.DesiredCostCenter, r.DesiredCostCenterID, r.ActualCostCenter,
+			r.ActualCostCenterID, r.SeatCreatedAt, r.LastActivityAt, strconv.FormatBool(r.PRUException),
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("writing CSV: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("writing CSV: %w", err)
+	}
+	return nil
+}
+
+// exportSnapshot writes s to a timestamped file in dir and returns its path.
+func exportSnapshot(dir, format string, s *snapshot) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("creating export directory: %w", err)
+	}
+	path := filepath.Join(dir, "assignment_snapshot_"+s.Metadata.GeneratedAt.Format("20060102-150405")+"."+format)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("creating snapshot file: %w", err)
+	}
+	if format == "json" {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(s)
+		if err != nil {
+			err = fmt.Errorf("encoding snapshot: %w", err)
+		}
+	} else {
+		err = writeSnapshotCSV(f, s)
+	}
+	if err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("writing snapshot file: %w", err)
+	}
+	return path, nil
+}
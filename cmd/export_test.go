@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+func testSnapshot() *snapshot {
+	users := []github.CopilotUser{
+		{Login: "bob", CreatedAt: "2025-06-01T00:00:00Z"},
+		{Login: "Alice", CreatedAt: "2024-01-01T00:00:00Z", LastActivityAt: "2026-01-02T03:04:05Z"},
+	}
+	desired := map[string]desiredCC{"Alice": {name: "PRUs Allowed", id: testPRUCCID}, "bob": {name: "No PRUs", id: testCCID}}
+	memberOf := map[string]string{"alice": testCCID}
+	idToName := map[string]string{testCCID: "No PRUs", testPRUCCID: "PRUs Allowed"}
+	isException := func(u github.CopilotUser) bool { return u.Login == "Alice" }
+	return &snapshot{
+		Metadata: snapshotMetadata{
+			Enterprise:  "test-ent",
+			Mode:        "users",
+			GeneratedAt: time.Date(2026, 2, 3, 4, 5, 6, 0, time.UTC),
+			ToolVersion: "1.2.3",
+		},
+		Users: buildSnapshotRows(users, desired, memberOf, idToName, isException),
+	}
+}
+
+func TestWriteSnapshotCSV_ColumnsAreStable(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeSnapshotCSV(&buf, testSnapshot()); err != nil {
+		t.Fatalf("writeSnapshotCSV: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "# enterprise: test-ent\n# mode: users\n# generated_at: 2026-02-03T04:05:06Z\n# tool_version: 1.2.3\n") {
+		t.Errorf("metadata header:\n%s", buf.String())
+	}
+
+	r := csv.NewReader(&buf)
+	r.Comment = '#'
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV: %v", err)
+	}
+	// Scripts read these columns by position; only append new ones.
+	wantHeader := []string{
+		"login", "desired_cost_center", "desired_cost_center_id", "actual_cost_center",
+		"actual_cost_center_id", "seat_created_at", "last_activity_at", "pru_exception",
+	}
+	if !reflect.DeepEqual(records[0], wantHeader) {
+		t.Errorf("header = %v, want %v", records[0], wantHeader)
+	}
+	wantAlice := []string{"Alice", "PRUs Allowed", testPRUCCID, "No PRUs", testCCID, "2024-01-01T00:00:00Z", "2026-01-02T03:04:05Z", "true"}
+	if !reflect.DeepEqual(records[1], wantAlice) {
+		t.Errorf("first row = %v, want %v", records[1], wantAlice)
+	}
+	if len(records) != 3 || records[2][0] != "bob" || records[2][3] != "" {
+		t.Errorf("rows = %v, want bob second with no current cost center", records[1:])
+	}
+}
+
+func TestAnonymizeSnapshot(t *testing.T) {
+	s := testSnapshot()
+	anonymizeSnapshot(s)
+
+	if !s.Metadata.Anonymized {
+		t.Error("snapshot not marked as anonymized")
+	}
+	want := anonymizeLogin("test-ent", "alice")
+	if want != anonymizeLogin("test-ent", "ALICE") {
+		t.Error("anonymizeLogin is case-sensitive")
+	}
+	if want == anonymizeLogin("other-ent", "alice") {
+		t.Error("anonymizeLogin does not depend on the enterprise")
+	}
+	found := false
+	for _, u := range s.Users {
+		if !strings.HasPrefix(u.Login, "user-") || len(u.Login) != len("user-")+16 {
+			t.Errorf("login %q is not a pseudonym", u.Login)
+		}
+		found = found || u.Login == want
+	}
+	if !found {
+		t.Errorf("no row has alice's pseudonym %s", want)
+	}
+
+	var buf bytes.Buffer
+	if err := writeSnapshotCSV(&buf, s); err != nil {
+		t.Fatalf("writeSnapshotCSV: %v", err)
+	}
+	if out := strings.ToLower(buf.String()); strings.Contains(out, "alice") || strings.Contains(out, "bob") {
+		t.Errorf("anonymized output contains a login:\n%s", buf.String())
+	}
+}
+
+func TestRunExport_WritesJSONToExportDir(t *testing.T) {
+	srv, _ := pruTestServer(t, "")
+	exportDir := setupPRUAssign(t, srv, "plan")
+	prevFormat, prevCheck, prevAnon := exportFormat, exportCheckCurrent, exportAnonymize
+	t.Cleanup(func() { exportFormat, exportCheckCurrent, exportAnonymize = prevFormat, prevCheck, prevAnon })
+	exportFormat, exportCheckCurrent, exportAnonymize = "json", true, false
+
+	if err := runExport(exportCmd, nil); err != nil {
+		t.Fatalf("runExport: %v", err)
+	}
+	paths, _ := filepath.Glob(filepath.Join(exportDir, "assignment_snapshot_*.json"))
+	if len(paths) != 1 {
+		t.Fatalf("snapshot files = %v, want one", paths)
+	}
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("decoding snapshot: %v", err)
+	}
+	if s.Metadata.Enterprise != "test-ent" || !s.Metadata.CheckCurrent || len(s.Users) != 2 {
+		t.Fatalf("snapshot = %+v", s)
+	}
+	// alice is the PRU exception in setupPRUAssign.
+	if u := s.Users[0]; u.Login != "alice" || !u.PRUException || u.DesiredCostCenterID != testPRUCCID {
+		t.Errorf("alice = %+v", u)
+	}
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// forceExit ends the process on a second interrupt.  Tests replace it.
+var forceExit = os.Exit
+
+// notifyInterrupt returns a context that is cancelled by the first SIGINT or
+// SIGTERM, so that an apply run can stop between batches and report what it
+// already did.  A second signal exits immediately with exitCodeInterrupted.
+// stop restores the default signal behavior.
+func notifyInterrupt(logger *slog.Logger) (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-sigs:
+			logger.Warn("Interrupted: letting in-flight batches finish; interrupt again to exit immediately", "signal", sig.String())
+			cancel()
+		case <-done:
+			return
+		}
+		select {
+		case <-sigs:
+			forceExit(exitCodeInterrupted)
+		case <-done:
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(sigs)
+		close(done)
+		cancel()
+	}
+}
+
+// interruptedError is returned by an apply run stopped by notifyInterrupt.
+func interruptedError(runID string) error {
+	return &exitError{
+		code: exitCodeInterrupted,
+		err:  fmt.Errorf("interrupted: the remaining assignments were not applied; run again with --resume %s", runID),
+	}
+}
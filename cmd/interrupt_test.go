@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/runstate"
+)
+
+// interruptSelf sends SIGINT to the test process.  notifyInterrupt must be
+// listening, or the process dies.
+func interruptSelf(t *testing.T) {
+	t.Helper()
+	p, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = p.Signal(os.Interrupt)
+	}
+	if err != nil {
+		t.Skipf("cannot send SIGINT: %v", err)
+	}
+}
+
+func TestNotifyInterrupt(t *testing.T) {
+	exited := make(chan int, 1)
+	prev := forceExit
+	t.Cleanup(func() { forceExit = prev })
+	forceExit = func(code int) { exited <- code }
+
+	ctx, stop := notifyInterrupt(quietLogger())
+	defer stop()
+
+	interruptSelf(t)
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context not cancelled by the first interrupt")
+	}
+	select {
+	case code := <-exited:
+		t.Fatalf("first interrupt exited with %d", code)
+	default:
+	}
+
+	interruptSelf(t)
+	select {
+	case code := <-exited:
+		if code != exitCodeInterrupted {
+			t.Errorf("exit code = %d, want %d", code, exitCodeInterrupted)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("second interrupt did not force an exit")
+	}
+}
+
+func TestRunAssign_Interrupted(t *testing.T) {
+	// The first assignment batch is slow and interrupts the run while in
+	// flight; it must complete and be the only one sent.
+	var mu sync.Mutex
+	var posted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/copilot/billing/seats"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"total_seats": 2, "seats": []map[string]any{
+				{"assignee": map[string]string{"login": "alice", "type": "User"}},
+				{"assignee": map[string]string{"login": "bob", "type": "User"}},
+			}})
+		case strings.HasSuffix(r.URL.Path, "/cost-centers"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"costCenters": []map[string]string{
+				{"id": testCCID, "name": "No PRUs", "state": "active"},
+				{"id": testPRUCCID, "name": "PRUs Allowed", "state": "active"},
+			}})
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/resource"):
+			mu.Lock()
+			posted = append(posted, filepath.Base(filepath.Dir(r.URL.Path)))
+			first := len(posted) == 1
+			mu.Unlock()
+			if first {
+				interruptSelf(t)
+				time.Sleep(200 * time.Millisecond)
+			}
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			id := filepath.Base(r.URL.Path)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": id, "name": id, "state": "active", "resources": []any{}})
+		}
+	}))
+	t.Cleanup(srv.Close)
+	exportDir := setupPRUAssign(t, srv, "apply")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout bytes.Buffer
+	copied := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(&stdout, r)
+		close(copied)
+	}()
+	orig := os.Stdout
+	os.Stdout = w
+	runErr := runAssign(assignCmd, nil)
+	os.Stdout = orig
+	_ = w.Close()
+	<-copied
+
+	if got := exitCode(runErr); got != exitCodeInterrupted {
+		t.Fatalf("exit code = %d (err %v), want %d", got, runErr, exitCodeInterrupted)
+	}
+	if len(posted) != 1 {
+		t.Errorf("batches sent = %v, want only the one in flight", posted)
+	}
+	if !strings.Contains(stdout.String(), "SUCCESS SUMMARY") {
+		t.Errorf("no summary printed:\n%s", stdout.String())
+	}
+
+	state, err := runstate.Load(exportDir, runstate.Latest)
+	if err != nil {
+		t.Fatalf("loading run state: %v", err)
+	}
+	if state.SucceededCount() != 1 || !strings.Contains(runErr.Error(), "--resume "+state.RunID) {
+		t.Errorf("run state = %d successes, err = %v; want the batch in flight recorded and a resume hint",
+			state.SucceededCount(), runErr)
+	}
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/teams"
+)
+
+var (
+	listTeamsOutput      string
+	listTeamsWithMembers bool
+	listTeamsOrgs        []string
+)
+
+var listTeamsCmd = &cobra.Command{
+	Use:   "list-teams",
+	Short: "List the teams teams mode would use",
+	Long: `List the teams visible in the configured teams scope (organization or
+enterprise), after teams.include and teams.exclude, with the cost center each
+would be assigned to — "(unmapped)" for a team without a manual mapping.  The
+cost center column is shown in auto mode and once teams.team_mappings exist.
+
+--with-members adds each team's member count (excluded and non-user accounts
+left out, as assignment would), at the cost of one request per team.  --org
+replaces the configured organizations (organization scope only).
+
+Examples:
+  gh cost-center list-teams
+  gh cost-center list-teams --org my-org --org other-org --with-members
+  gh cost-center list-teams --output json | jq '.[] | select(.cost_center == null)'`,
+	RunE: runListTeams,
+}
+
+func init() {
+	listTeamsCmd.Flags().StringVarP(&listTeamsOutput, "output", "o", "table", "output format: table or json")
+	listTeamsCmd.Flags().BoolVar(&listTeamsWithMembers, "with-members", false, "count each team's members (one request per team)")
+	listTeamsCmd.Flags().StringSliceVar(&listTeamsOrgs, "org", nil, "organization to list teams of, overriding the configured list (repeatable)")
+	rootCmd.AddCommand(listTeamsCmd)
+}
+
+func runListTeams(_ *cobra.Command, _ []string) error {
+	if listTeamsOutput != "table" && listTeamsOutput != "json" {
+		return usageErrorf("invalid --output %q: must be 'table' or 'json'", listTeamsOutput)
+	}
+	if len(listTeamsOrgs) > 0 && cfgManager.TeamsScope == "enterprise" {
+		return usageErrorf("--org cannot be used with teams scope 'enterprise'")
+	}
+
+	logger := slog.Default()
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+
+	mgr := teams.NewManager(cfgManager, client, logger)
+	if len(listTeamsOrgs) > 0 {
+		mgr.SetOrganizations(listTeamsOrgs)
+	}
+	return listTeams(os.Stdout, mgr, listTeamsWithMembers, listTeamsOutput)
+}
+
+// listTeams writes the teams mgr sees as a table or, with output "json", as
+// a JSON array.
+func listTeams(w io.Writer, mgr *teams.Manager, withMembers bool, output string) error {
+	list, err := mgr.ListTeams(withMembers)
+	if err != nil {
+		return fmt.Errorf("listing teams: %w", err)
+	}
+
+	if output == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(list); err != nil {
+			return fmt.Errorf("encoding teams: %w", err)
+		}
+		return nil
+	}
+
+	showCC := mgr.ResolvesCostCenters()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := "TEAM\tNAME"
+	if withMembers {
+		header += "\tMEMBERS"
+	}
+	if showCC {
+		header += "\tCOST CENTER"
+	}
+	_, _ = fmt.Fprintln(tw, header)
+	for _, t := range list {
+		row := t.Key + "\t" + t.Name
+		if withMembers {
+			members := "?"
+			if t.Members != nil {
+				members = strconv.Itoa(*t.Members)
+			}
+			row += "\t" + members
+		}
+		if showCC {
+			cc := t.CostCenter
+			if cc == "" {
+				cc = "(unmapped)"
+			}
+			row += "\t" + cc
+		}
+		_, _ = fmt.Fprintln(tw, row)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("writing teams: %w", err)
+	}
+	_, _ = fmt.Fprintf(w, "%d team(s)\n", len(list))
+	return nil
+}
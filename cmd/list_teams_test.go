@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/teams"
+)
+
+// teamsListServer serves count teams (team-000 ...) in pages of 100 for
+// org1 and for the enterprise, and two members for every team.
+func teamsListServer(t *testing.T, count int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/members"), strings.HasSuffix(r.URL.Path, "/memberships"):
+			_, _ = w.Write([]byte(`[{"login":"alice","type":"User"},{"login":"bob","type":"User"}]`))
+		case strings.HasSuffix(r.URL.Path, "/teams"):
+			page := 1
+			_, _ = fmt.Sscan(r.URL.Query().Get("page"), &page)
+			res := []map[string]string{}
+			for i := (page - 1) * 100; i < count && i < page*100; i++ {
+				slug := fmt.Sprintf("team-%03d", i)
+				res = append(res, map[string]string{"slug": slug, "name": strings.ToUpper(slug)})
+			}
+			_ = json.NewEncoder(w).Encode(res)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newListTeamsManager(t *testing.T, srv *httptest.Server, scope string, mappings map[string]string) *teams.Manager {
+	t.Helper()
+	cfg := &config.Manager{
+		Enterprise:    "test-ent",
+		TeamsScope:    scope,
+		TeamsStrategy: "manual",
+		Organizations: []string{"org1"},
+		TeamsMappings: mappings,
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	return teams.NewManager(cfg, newTestGitHubClient(t, srv.URL), logger)
+}
+
+func TestListTeams_OrganizationScopePaginated(t *testing.T) {
+	srv := teamsListServer(t, 101)
+	mgr := newListTeamsManager(t, srv, "organization", map[string]string{"org1/team-100": "Platform"})
+
+	var buf bytes.Buffer
+	if err := listTeams(&buf, mgr, true, "json"); err != nil {
+		t.Fatalf("listTeams: %v", err)
+	}
+	var got []teams.TeamListing
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("decoding output: %v\n%s", err, buf.String())
+	}
+	if len(got) != 101 {
+		t.Fatalf("got %d teams, want 101 across two pages", len(got))
+	}
+	last := got[100]
+	if last.Key != "org1/team-100" || last.CostCenter != "Platform" {
+		t.Errorf("last team = %+v, want org1/team-100 mapped to Platform", last)
+	}
+	if last.Members == nil || *last.Members != 2 {
+		t.Errorf("last team members = %v, want 2", last.Members)
+	}
+	if got[0].CostCenter != "" {
+		t.Errorf("unmapped team has cost center %q", got[0].CostCenter)
+	}
+}
+
+func TestListTeams_EnterpriseScopeTable(t *testing.T) {
+	srv := teamsListServer(t, 2)
+	mgr := newListTeamsManager(t, srv, "enterprise", map[string]string{"team-001": "Platform"})
+
+	var buf bytes.Buffer
+	if err := listTeams(&buf, mgr, false, "table"); err != nil {
+		t.Fatalf("listTeams: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"COST CENTER", "team-000  TEAM-000  (unmapped)", "team-001  TEAM-001  Platform", "2 team(s)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "MEMBERS") {
+		t.Errorf("members column shown without --with-members:\n%s", out)
+	}
+}
+
+func TestListTeams_NoMappingsHidesCostCenter(t *testing.T) {
+	srv := teamsListServer(t, 1)
+	mgr := newListTeamsManager(t, srv, "organization", nil)
+	mgr.SetOrganizations([]string{"other-org"})
+
+	var buf bytes.Buffer
+	if err := listTeams(&buf, mgr, false, "table"); err != nil {
+		t.Fatalf("listTeams: %v", err)
+	}
+	if out := buf.String(); strings.Contains(out, "COST CENTER") || !strings.Contains(out, "other-org/team-000") {
+		t.Errorf("want only other-org's teams and no cost center column:\n%s", out)
+	}
+}
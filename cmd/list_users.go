@@ -1,8 +1,16 @@
 package cmd
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -10,24 +18,64 @@ import (
 	"github.com/renan-alm/gh-cost-center/internal/pru"
 )
 
+var (
+	listUsersOutput         string
+	listUsersInactiveDays   int
+	listUsersPlan           string
+	listUsersExceptionsOnly bool
+	listUsersSort           string
+)
+
 var listUsersCmd = &cobra.Command{
 	Use:   "list-users",
 	Short: "List all Copilot license holders",
 	Long: `List all GitHub Copilot license holders in the enterprise.
 
-Shows each user with their PRU exception status.
+Shows each user with their PRU exception status and a [cancelling <date>]
+marker for seats pending cancellation.  --output json and --output csv write
+every seat field plus pru_exception and target_cost_center (the configured
+cost center the user would be assigned to; empty for skipped seats) to
+stdout; logs always go to stderr.
+
+--inactive-days, --plan, and --exceptions-only filter the list and combine
+(all must match).  Users with no or an unreadable last activity count as
+never active, so they match any --inactive-days.  --sort orders the list by
+login, last-activity (least recently active first), or created.
 
 Examples:
   gh cost-center list-users
-  gh cost-center list-users -v`,
+  gh cost-center list-users -v
+  gh cost-center list-users --output json | jq '.[] | select(.pru_exception)'
+  gh cost-center list-users --output csv > users.csv
+
+  # Seats unused for 60 days, least recently active first
+  gh cost-center list-users --inactive-days 60 --sort last-activity`,
 	RunE: runListUsers,
 }
 
 func init() {
+	listUsersCmd.Flags().StringVarP(&listUsersOutput, "output", "o", "table", "output format: table, json, or csv")
+	listUsersCmd.Flags().IntVar(&listUsersInactiveDays, "inactive-days", 0, "only users with no Copilot activity in the last N days")
+	listUsersCmd.Flags().StringVar(&listUsersPlan, "plan", "", "only seats on this plan: business or enterprise")
+	listUsersCmd.Flags().BoolVar(&listUsersExceptionsOnly, "exceptions-only", false, "only PRU exception users")
+	listUsersCmd.Flags().StringVar(&listUsersSort, "sort", "", "sort by login, last-activity, or created (default: API order)")
 	rootCmd.AddCommand(listUsersCmd)
 }
 
 func runListUsers(_ *cobra.Command, _ []string) error {
+	if listUsersOutput != "table" && listUsersOutput != "json" && listUsersOutput != "csv" {
+		return usageErrorf("invalid --output %q: must be 'table', 'json', or 'csv'", listUsersOutput)
+	}
+	if listUsersInactiveDays < 0 {
+		return usageErrorf("invalid --inactive-days %d: must not be negative", listUsersInactiveDays)
+	}
+	if listUsersPlan != "" && listUsersPlan != "business" && listUsersPlan != "enterprise" {
+		return usageErrorf("invalid --plan %q: must be 'business' or 'enterprise'", listUsersPlan)
+	}
+	if listUsersSort != "" && listUsersSort != "login" && listUsersSort != "last-activity" && listUsersSort != "created" {
+		return usageErrorf("invalid --sort %q: must be 'login', 'last-activity', or 'created'", listUsersSort)
+	}
+
 	logger := slog.Default()
 
 	// Create GitHub API client.
@@ -45,16 +93,192 @@ func runListUsers(_ *cobra.Command, _ []string) error {
 		return fmt.Errorf("fetching copilot users: %w", err)
 	}
 
+	if err := loadTierTeams(client, mgr, false, logger); err != nil {
+		return err
+	}
+
+	filter := listUsersFilter{
+		inactiveDays:   listUsersInactiveDays,
+		plan:           listUsersPlan,
+		exceptionsOnly: listUsersExceptionsOnly,
+	}
+	rows := filterListedUsers(listUserRows(users, mgr), filter, time.Now())
+	sortListedUsers(rows, listUsersSort)
+	switch listUsersOutput {
+	case "json":
+		return writeUsersJSON(os.Stdout, rows)
+	case "csv":
+		return writeUsersCSV(os.Stdout, rows)
+	}
+
 	// Display users with PRU exception markers.
 	fmt.Println("\n=== Copilot License Holders ===")
-	fmt.Printf("Total users: %d\n", len(users))
+	if filter.active() {
+		fmt.Printf("Users: %d of %d match the filters\n", len(rows), len(users))
+	} else {
+		fmt.Printf("Total users: %d\n", len(users))
+	}
+	for _, r := range rows {
+		fmt.Printf("- %s%s\n", r.Login, userMarkers(r.CopilotUser, r.PRUException))
+	}
+
+	return nil
+}
+
+// listedUser is one list-users row: the seat, whether the user is a PRU
+// exception, and the cost center users mode would assign them to.
+type listedUser struct {
+	github.CopilotUser
+	PRUException     bool   `json:"pru_exception"`
+	TargetCostCenter string `json:"target_cost_center"`
+}
+
+// listUserRows evaluates every user against the PRU manager.  Skipped seats
+// (see pru.Manager.IsSkipped) have no target cost center.
+func listUserRows(users []github.CopilotUser, mgr *pru.Manager) []listedUser {
+	rows := make([]listedUser, 0, len(users))
 	for _, u := range users {
-		marker := ""
-		if mgr.IsException(u.Login) {
-			marker = " [PRUs Exception]"
+		row := listedUser{CopilotUser: u, PRUException: mgr.IsExceptionUser(u)}
+		if !mgr.IsSkipped(u) {
+			row.TargetCostCenter = mgr.AssignCostCenter(u)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// listUsersFilter selects list-users rows; every set criterion must match.
+type listUsersFilter struct {
+	inactiveDays   int    // no activity within this many days; 0 disables
+	plan           string // seat plan; "" matches any
+	exceptionsOnly bool
+}
+
+// active reports whether any criterion is set.
+func (f listUsersFilter) active() bool {
+	return f.inactiveDays > 0 || f.plan != "" || f.exceptionsOnly
+}
+
+// filterListedUsers returns the rows matching f.  A user who has never been
+// active, or whose last activity cannot be parsed, is inactive for any
+// number of days.
+func filterListedUsers(rows []listedUser, f listUsersFilter, now time.Time) []listedUser {
+	if !f.active() {
+		return rows
+	}
+	cutoff := now.AddDate(0, 0, -f.inactiveDays)
+	out := make([]listedUser, 0, len(rows))
+	for _, r := range rows {
+		if f.exceptionsOnly && !r.PRUException {
+			continue
+		}
+		if f.plan != "" && !strings.EqualFold(r.Plan, f.plan) {
+			continue
+		}
+		if f.inactiveDays > 0 {
+			if last, ok := r.LastActivity(); ok && last.After(cutoff) {
+				continue
+			}
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// sortListedUsers orders rows by key: "login", "last-activity" (never active
+// first, then oldest activity), or "created" (oldest seat first).  Ties and
+// the other keys fall back to login; "" keeps the API order.
+func sortListedUsers(rows []listedUser, key string) {
+	if key == "" {
+		return
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		switch key {
+		case "last-activity":
+			ta, okA := a.LastActivity()
+			tb, okB := b.LastActivity()
+			if okA != okB {
+				return !okA
+			}
+			if !ta.Equal(tb) {
+				return ta.Before(tb)
+			}
+		case "created":
+			ta, okA := a.Created()
+			tb, okB := b.Created()
+			if okA != okB {
+				return okA
+			}
+			if !ta.Equal(tb) {
+				return ta.Before(tb)
+			}
 		}
-		fmt.Printf("- %s%s\n", u.Login, marker)
+		return strings.ToLower(a.Login) < strings.ToLower(b.Login)
+	})
+}
+
+// writeUsersJSON writes rows as an indented JSON array.
+func writeUsersJSON(w io.Writer, rows []listedUser) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(rows); err != nil {
+		return fmt.Errorf("encoding users: %w", err)
 	}
+	return nil
+}
 
+// usersCSVHeader is the header row of the CSV output.  Columns are only ever
+// appended so that scripts reading by position keep working.
+var usersCSVHeader = []string{
+	"login", "id", "name", "email", "type", "created_at", "updated_at",
+	"pending_cancellation_date", "last_activity_at", "last_activity_editor",
+	"plan", "assigning_team", "organization", "pru_exception", "target_cost_center",
+}
+
+// writeUsersCSV writes rows as CSV with usersCSVHeader.  assigning_team is
+// the team slug, empty for seats assigned directly.
+func writeUsersCSV(w io.Writer, rows []listedUser) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(usersCSVHeader); err != nil {
+		return fmt.Errorf("writing CSV: %w", err)
+	}
+	for _, r := range rows {
+		team := ""
+		if r.AssigningTeam != nil {
+			team = r.AssigningTeam.Slug
+		}
+		record := []string{
+			r.Login, strconv.FormatInt(r.ID, 10), r.Name, r.Email, r.Type, r.CreatedAt, r.UpdatedAt,
+			r.PendingCancellationDate, r.LastActivityAt, r.LastActivityEditor,
+			r.Plan, team, r.Organization, strconv.FormatBool(r.PRUException), r.TargetCostCenter,
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("writing CSV: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("writing CSV: %w", err)
+	}
 	return nil
 }
+
+// userMarkers returns the bracketed annotations shown after a login.
+func userMarkers(u github.CopilotUser, exception bool) string {
+	marker := ""
+	if !github.IsUserAccount(u.Type) {
+		marker += fmt.Sprintf(" [%s]", u.Type)
+	}
+	if exception {
+		marker += " [PRUs Exception]"
+	}
+	if date, pending := u.PendingCancellation(); pending {
+		when := u.PendingCancellationDate
+		if !date.IsZero() {
+			when = date.Format("2006-01-02")
+		}
+		marker += fmt.Sprintf(" [cancelling %s]", when)
+	}
+	return marker
+}
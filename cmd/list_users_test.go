@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/pru"
+)
+
+// listUsersRows evaluates a bot, a PRU exception with an assigning team, and
+// a user without an email under a config with alice as the only exception.
+func listUsersRows(t *testing.T) []listedUser {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	yaml := `github:
+  enterprise: "test-ent"
+cost_center:
+  users:
+    no_prus_cost_center_id: "CC-NO"
+    prus_allowed_cost_center_id: "CC-YES"
+    exception_users: ["alice"]
+`
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg, err := config.Load(path, logger)
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+
+	users := []github.CopilotUser{
+		{Login: "alice", ID: 1, Name: "Alice, A.", Email: "alice@example.com", Type: "User",
+			CreatedAt: "2025-01-01T00:00:00Z", Plan: "business", Organization: "my-org",
+			AssigningTeam: &github.AssigningTeam{Slug: "ml-team", Name: "ML"}},
+		{Login: "bob", ID: 2, Type: "User", PendingCancellationDate: "2025-07-01"},
+		{Login: "build-bot", ID: 3, Type: "Bot"},
+	}
+	return listUserRows(users, pru.NewManager(cfg, logger))
+}
+
+func TestWriteUsersJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeUsersJSON(&buf, listUsersRows(t)); err != nil {
+		t.Fatalf("writeUsersJSON: %v", err)
+	}
+
+	var got []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d users, want 3", len(got))
+	}
+
+	alice, bob, bot := got[0], got[1], got[2]
+	if alice["login"] != "alice" || alice["email"] != "alice@example.com" || alice["name"] != "Alice, A." ||
+		alice["plan"] != "business" || alice["organization"] != "my-org" || alice["id"] != float64(1) {
+		t.Errorf("alice = %v", alice)
+	}
+	if team, _ := alice["assigning_team"].(map[string]any); team["slug"] != "ml-team" {
+		t.Errorf("alice assigning_team = %v", alice["assigning_team"])
+	}
+	if alice["pru_exception"] != true || alice["target_cost_center"] != "CC-YES" {
+		t.Errorf("alice pru_exception/target = %v/%v, want true/CC-YES", alice["pru_exception"], alice["target_cost_center"])
+	}
+	if email, ok := bob["email"]; !ok || email != "" {
+		t.Errorf("bob email = %v (present %v), want an empty string", email, ok)
+	}
+	if bob["pending_cancellation_date"] != "2025-07-01" || bob["pru_exception"] != false || bob["target_cost_center"] != "CC-NO" {
+		t.Errorf("bob = %v", bob)
+	}
+	if bob["assigning_team"] != nil {
+		t.Errorf("bob assigning_team = %v, want null", bob["assigning_team"])
+	}
+	if bot["type"] != "Bot" || bot["target_cost_center"] != "" {
+		t.Errorf("bot = %v, want no target cost center for a skipped seat", bot)
+	}
+}
+
+func TestWriteUsersCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeUsersCSV(&buf, listUsersRows(t)); err != nil {
+		t.Fatalf("writeUsersCSV: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not CSV: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("got %d records, want a header and 3 users", len(records))
+	}
+	col := make(map[string]int, len(records[0]))
+	for i, name := range records[0] {
+		col[name] = i
+	}
+	if len(col) != len(usersCSVHeader) || records[0][0] != "login" {
+		t.Fatalf("header = %v", records[0])
+	}
+
+	alice, bob, bot := records[1], records[2], records[3]
+	for field, want := range map[string]string{
+		"login": "alice", "id": "1", "name": "Alice, A.", "email": "alice@example.com",
+		"created_at": "2025-01-01T00:00:00Z", "plan": "business", "assigning_team": "ml-team",
+		"organization": "my-org", "pru_exception": "true", "target_cost_center": "CC-YES",
+	} {
+		if got := alice[col[field]]; got != want {
+			t.Errorf("alice %s = %q, want %q", field, got, want)
+		}
+	}
+	if bob[col["email"]] != "" || bob[col["assigning_team"]] != "" || bob[col["pru_exception"]] != "false" ||
+		bob[col["target_cost_center"]] != "CC-NO" || bob[col["pending_cancellation_date"]] != "2025-07-01" {
+		t.Errorf("bob = %v", bob)
+	}
+	if bot[col["type"]] != "Bot" || bot[col["target_cost_center"]] != "" {
+		t.Errorf("bot = %v", bot)
+	}
+}
+
+func TestFilterListedUsers(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	rows := []listedUser{
+		{CopilotUser: github.CopilotUser{Login: "recent", Plan: "business", LastActivityAt: "2026-05-20T00:00:00Z"}},
+		{CopilotUser: github.CopilotUser{Login: "stale", Plan: "enterprise", LastActivityAt: "2026-02-01T00:00:00Z"}, PRUException: true},
+		{CopilotUser: github.CopilotUser{Login: "never", Plan: "business"}},
+		{CopilotUser: github.CopilotUser{Login: "garbled", Plan: "Business", LastActivityAt: "last tuesday"}, PRUException: true},
+	}
+	logins := func(rs []listedUser) string {
+		var out []string
+		for _, r := range rs {
+			out = append(out, r.Login)
+		}
+		return strings.Join(out, ",")
+	}
+
+	tests := []struct {
+		name   string
+		filter listUsersFilter
+		want   string
+	}{
+		{"none", listUsersFilter{}, "recent,stale,never,garbled"},
+		{"inactive, never-active included", listUsersFilter{inactiveDays: 60}, "stale,never,garbled"},
+		{"inactive long ago", listUsersFilter{inactiveDays: 365}, "never,garbled"},
+		{"plan ignores case", listUsersFilter{plan: "business"}, "recent,never,garbled"},
+		{"exceptions only", listUsersFilter{exceptionsOnly: true}, "stale,garbled"},
+		{"combined", listUsersFilter{inactiveDays: 60, plan: "business", exceptionsOnly: true}, "garbled"},
+		{"no match", listUsersFilter{plan: "enterprise", inactiveDays: 365}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterListedUsers(rows, tt.filter, now)
+			if logins(got) != tt.want {
+				t.Errorf("filtered = %q, want %q", logins(got), tt.want)
+			}
+			if got == nil {
+				t.Error("filtered rows should be an empty slice, not nil, so JSON output is []")
+			}
+		})
+	}
+}
+
+func TestSortListedUsers(t *testing.T) {
+	rows := func() []listedUser {
+		return []listedUser{
+			{CopilotUser: github.CopilotUser{Login: "carol", CreatedAt: "2024-03-01T00:00:00Z", LastActivityAt: "2026-05-01T00:00:00Z"}},
+			{CopilotUser: github.CopilotUser{Login: "Bob", CreatedAt: "2024-01-01T00:00:00Z"}},
+			{CopilotUser: github.CopilotUser{Login: "alice", CreatedAt: "2024-02-01T00:00:00Z", LastActivityAt: "2026-01-01T00:00:00Z"}},
+			{CopilotUser: github.CopilotUser{Login: "dave", CreatedAt: "2024-02-01T00:00:00Z", LastActivityAt: "n/a"}},
+		}
+	}
+	tests := []struct{ key, want string }{
+		{"", "carol,Bob,alice,dave"},
+		{"login", "alice,Bob,carol,dave"},
+		{"last-activity", "Bob,dave,alice,carol"},
+		{"created", "Bob,alice,dave,carol"},
+	}
+	for _, tt := range tests {
+		rs := rows()
+		sortListedUsers(rs, tt.key)
+		var got []string
+		for _, r := range rs {
+			got = append(got, r.Login)
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("sort %q = %v, want %s", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// confirmYes prints the question and returns true only if the user types
+// "yes".  It returns an error if reading from stdin fails.
+func confirmYes(question string) (bool, error) {
+	fmt.Printf("\n%s (yes/no): ", question)
+	scanner := bufio.NewScanner(os.Stdin)
+	if scanner.Scan() {
+		return strings.TrimSpace(strings.ToLower(scanner.Text())) == "yes", nil
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("reading user input: %w", err)
+	}
+	return false, nil
+}
+
+// prompter reads answers to interactive questions.  Tests replace
+// applyPrompter with scripted input.
+type prompter interface {
+	// Interactive reports whether input comes from a terminal.
+	Interactive() bool
+	// ReadLine returns the next line of input without its newline.
+	ReadLine() (string, error)
+}
+
+// applyPrompter is used by the apply-mode confirmation in assign and by the
+// questions of config init.
+var applyPrompter prompter = &stdinPrompter{in: bufio.NewScanner(os.Stdin)}
+
+// stdinPrompter reads from the process's standard input.
+type stdinPrompter struct {
+	in *bufio.Scanner
+}
+
+// Interactive reports whether stdin is a character device (a TTY).
+func (p *stdinPrompter) Interactive() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// ReadLine reads one line from stdin; at EOF it returns "".
+func (p *stdinPrompter) ReadLine() (string, error) {
+	if p.in.Scan() {
+		return p.in.Text(), nil
+	}
+	if err := p.in.Err(); err != nil {
+		return "", fmt.Errorf("reading user input: %w", err)
+	}
+	return "", nil
+}
+
+// errNotInteractive is returned when a confirmation is needed but stdin is
+// not a terminal (e.g. in CI), where waiting for input would hang.
+var errNotInteractive = errors.New("stdin is not a terminal; pass --yes to apply without confirmation")
+
+// askYesNo prints question with a "[y/N]" suffix and returns true only for
+// "y" or "yes" (case-insensitive).  It fails with errNotInteractive when p is
+// not interactive.
+func askYesNo(p prompter, question string) (bool, error) {
+	if !p.Interactive() {
+		return false, errNotInteractive
+	}
+	fmt.Printf("\n%s [y/N]: ", question)
+	line, err := p.ReadLine()
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+var (
+	removeUserCostCenter string
+	removeUserAllManaged bool
+	removeUserYes        bool
+)
+
+var removeUserCmd = &cobra.Command{
+	Use:   "remove-user <login>",
+	Short: "Remove a user from cost centers",
+	Long: `Remove one user from a single cost center (--cost-center, by ID or name) or
+from every cost center this configuration manages (--all-managed), e.g. when
+offboarding.
+
+The cost centers that contain the user are looked up and shown first, and
+the removal is confirmed unless --yes is passed.  A user who is in none of
+them is reported and the command succeeds without changes.
+
+Examples:
+  gh cost-center remove-user octocat --all-managed
+  gh cost-center remove-user octocat --cost-center "PRUs Allowed" --yes`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRemoveUser,
+}
+
+func init() {
+	removeUserCmd.Flags().StringVar(&removeUserCostCenter, "cost-center", "", "cost center ID or name to remove the user from")
+	removeUserCmd.Flags().BoolVar(&removeUserAllManaged, "all-managed", false, "remove the user from every managed cost center")
+	removeUserCmd.Flags().BoolVarP(&removeUserYes, "yes", "y", false, "skip confirmation prompt")
+	removeUserCmd.MarkFlagsOneRequired("cost-center", "all-managed")
+	removeUserCmd.MarkFlagsMutuallyExclusive("cost-center", "all-managed")
+
+	rootCmd.AddCommand(removeUserCmd)
+}
+
+func runRemoveUser(_ *cobra.Command, args []string) error {
+	logger := slog.Default()
+
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	attachCache(client, logger)
+	attachAuditLog(client, logger)
+
+	confirm := confirmYes
+	if removeUserYes {
+		confirm = nil
+	}
+	return removeUser(client, args[0], removeUserCostCenter, confirm)
+}
+
+// userMembership is a cost center that contains the user, with the login as
+// the cost center lists it.
+type userMembership struct {
+	id, name, login string
+}
+
+// removeUser removes login from the cost center ref or, when ref is empty,
+// from every managed cost center, after confirmation when confirm is
+// non-nil.
+func removeUser(client github.CostCenterAPI, login, ref string, confirm func(string) (bool, error)) error {
+	targets := map[string]string{} // ID -> name
+	if ref != "" {
+		id, name, err := resolveCostCenterRef(client, ref)
+		if err != nil {
+			return notFoundExit(ref, err)
+		}
+		targets[id] = name
+	} else {
+		active, err := client.GetAllActiveCostCenters()
+		if err != nil {
+			return fmt.Errorf("fetching active cost centers: %w", err)
+		}
+		for name, id := range managedCostCenters(active, managedCostCenterPatterns(cfgManager)) {
+			targets[id] = name
+		}
+	}
+
+	found, err := userCostCenters(client, login, targets)
+	if err != nil {
+		return err
+	}
+	if len(found) == 0 {
+		fmt.Printf("User %s is not in any of the %d cost center(s) checked; nothing to do.\n", login, len(targets))
+		return nil
+	}
+
+	fmt.Printf("User %s is in %d cost center(s):\n", login, len(found))
+	for _, m := range found {
+		fmt.Printf("  - %s (%s)\n", m.name, m.id)
+	}
+	if confirm != nil {
+		proceed, err := confirm(fmt.Sprintf("Remove %s from these cost centers?", login))
+		if err != nil {
+			return fmt.Errorf("confirmation failed: %w", err)
+		}
+		if !proceed {
+			slog.Default().Warn("Aborted by user")
+			return nil
+		}
+	}
+
+	failed := 0
+	for _, m := range found {
+		if _, err := client.RemoveUsersFromCostCenter(m.id, []string{m.login}); err != nil {
+			failed++
+			fmt.Printf("✗ %s (%s): %v\n", m.name, m.id, err)
+			continue
+		}
+		fmt.Printf("✓ Removed %s from %s (%s)\n", m.login, m.name, m.id)
+	}
+	if failed > 0 {
+		return fmt.Errorf("could not remove %s from %d of %d cost center(s)", login, failed, len(found))
+	}
+	return nil
+}
+
+// userCostCenters returns the cost centers among targets (ID -> name) whose
+// members include login (case-insensitively), sorted by name.
+func userCostCenters(client github.CostCenterAPI, login string, targets map[string]string) ([]userMembership, error) {
+	var found []userMembership
+	for id, name := range targets {
+		members, err := client.GetCostCenterUsers(id)
+		if err != nil {
+			return nil, fmt.Errorf("fetching members of cost center %q: %w", name, err)
+		}
+		for _, m := range members {
+			if strings.EqualFold(m, login) {
+				found = append(found, userMembership{id: id, name: name, login: m})
+				break
+			}
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].name < found[j].name })
+	return found, nil
+}
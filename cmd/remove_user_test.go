@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/githubtest"
+)
+
+// removeUserServer serves three cost centers — "Live Team" and "Second
+// Team" (managed by useRemoveUserConfig) and "Someone Else's" (not) — with
+// the given members, and records removals as "<id> <login>".
+func removeUserServer(t *testing.T, members map[string][]string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var (
+		mu      sync.Mutex
+		removed []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodDelete:
+			var body struct {
+				Users []string `json:"users"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			id := filepath.Base(filepath.Dir(r.URL.Path))
+			mu.Lock()
+			for _, u := range body.Users {
+				removed = append(removed, id+" "+u)
+			}
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{}`))
+		case strings.HasSuffix(r.URL.Path, "/cost-centers"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"costCenters": []map[string]string{
+				{"id": testCCID, "name": "Live Team", "state": "active"},
+				{"id": testOtherCCID, "name": "Second Team", "state": "active"},
+				{"id": testPRUCCID, "name": "Someone Else's", "state": "active"},
+			}})
+		default:
+			id := filepath.Base(r.URL.Path)
+			var res []map[string]string
+			for _, m := range members[id] {
+				res = append(res, map[string]string{"type": "User", "name": m})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": id, "resources": res})
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &removed
+}
+
+func useRemoveUserConfig(t *testing.T) {
+	t.Helper()
+	prev := cfgManager
+	t.Cleanup(func() { cfgManager = prev })
+	cfgManager = &config.Manager{
+		Enterprise:            "test-ent",
+		CostCenterMode:        "custom-prop",
+		CustomPropCostCenters: []config.CustomPropCostCenter{{Name: "Live Team"}, {Name: "Second Team"}},
+	}
+}
+
+func TestUserCostCenters_Lookup(t *testing.T) {
+	srv, _ := removeUserServer(t, map[string][]string{
+		testCCID:      {"alice", "Bob"},
+		testOtherCCID: {"bob"},
+		testPRUCCID:   {"carol"},
+	})
+	client := newTestGitHubClient(t, srv.URL)
+
+	targets := map[string]string{testCCID: "Live Team", testOtherCCID: "Second Team", testPRUCCID: "Someone Else's"}
+	found, err := userCostCenters(client, "BOB", targets)
+	if err != nil {
+		t.Fatalf("userCostCenters: %v", err)
+	}
+	want := []userMembership{{testCCID, "Live Team", "Bob"}, {testOtherCCID, "Second Team", "bob"}}
+	if len(found) != 2 || found[0] != want[0] || found[1] != want[1] {
+		t.Errorf("found = %+v, want %+v", found, want)
+	}
+}
+
+func TestRemoveUser_TargetedCostCenter(t *testing.T) {
+	useRemoveUserConfig(t)
+	srv, removed := removeUserServer(t, map[string][]string{
+		testCCID:    {"alice"},
+		testPRUCCID: {"alice"},
+	})
+	client := newTestGitHubClient(t, srv.URL)
+
+	// Only the named cost center, even though it is not managed.
+	if err := removeUser(client, "alice", "Someone Else's", nil); err != nil {
+		t.Fatalf("removeUser: %v", err)
+	}
+	if got := strings.Join(*removed, ","); got != testPRUCCID+" alice" {
+		t.Errorf("removed = %q, want alice from Someone Else's only", got)
+	}
+}
+
+func TestRemoveUser_AllManaged(t *testing.T) {
+	useRemoveUserConfig(t)
+	srv, removed := removeUserServer(t, map[string][]string{
+		testCCID:      {"Alice"},
+		testOtherCCID: {"alice", "bob"},
+		testPRUCCID:   {"alice"},
+	})
+	client := newTestGitHubClient(t, srv.URL)
+
+	if err := removeUser(client, "alice", "", nil); err != nil {
+		t.Fatalf("removeUser: %v", err)
+	}
+	got := append([]string(nil), *removed...)
+	sort.Strings(got)
+	want := []string{testOtherCCID + " alice", testCCID + " Alice"}
+	sort.Strings(want)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("removed = %v, want %v (the unmanaged cost center is left alone)", got, want)
+	}
+}
+
+func TestRemoveUser_NotAMemberIsNoOp(t *testing.T) {
+	useRemoveUserConfig(t)
+	srv, removed := removeUserServer(t, map[string][]string{testCCID: {"bob"}})
+	client := newTestGitHubClient(t, srv.URL)
+
+	confirm := func(string) (bool, error) {
+		t.Error("asked for confirmation with nothing to remove")
+		return false, nil
+	}
+	if err := removeUser(client, "alice", "", confirm); err != nil {
+		t.Fatalf("removeUser: %v", err)
+	}
+	if len(*removed) != 0 {
+		t.Errorf("removed = %v, want none", *removed)
+	}
+}
+
+func TestRemoveUser_DeclinedConfirmation(t *testing.T) {
+	useRemoveUserConfig(t)
+	srv, removed := removeUserServer(t, map[string][]string{testCCID: {"alice"}})
+	client := newTestGitHubClient(t, srv.URL)
+
+	if err := removeUser(client, "alice", "", func(string) (bool, error) { return false, nil }); err != nil {
+		t.Fatalf("removeUser: %v", err)
+	}
+	if len(*removed) != 0 {
+		t.Errorf("removed = %v after declining", *removed)
+	}
+}
+
+func TestRemoveUser_FakeAPI(t *testing.T) {
+	useRemoveUserConfig(t)
+	fake := githubtest.New()
+	live := fake.AddCostCenter("Live Team", "alice", "bob")
+	second := fake.AddCostCenter("Second Team", "alice")
+	other := fake.AddCostCenter("Someone Else's", "alice")
+
+	if err := removeUser(fake, "alice", "", nil); err != nil {
+		t.Fatalf("removeUser: %v", err)
+	}
+	if got := fake.Users(live); strings.Join(got, ",") != "bob" {
+		t.Errorf("Live Team = %v, want bob only", got)
+	}
+	if got := fake.Users(second); len(got) != 0 {
+		t.Errorf("Second Team = %v, want alice removed", got)
+	}
+	if got := fake.Users(other); strings.Join(got, ",") != "alice" {
+		t.Errorf("Someone Else's = %v, want the unmanaged cost center left alone", got)
+	}
+
+	fake.Fail("RemoveUsersFromCostCenter", errors.New("boom"))
+	err := removeUser(fake, "bob", "", nil)
+	if err == nil || !strings.Contains(err.Error(), "could not remove bob from 1 of 1") {
+		t.Errorf("err = %v, want the failed removal reported", err)
+	}
+}
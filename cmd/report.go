@@ -1,15 +1,26 @@
 package cmd
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
-	"github.com/renan-alm/gh-cost-center/internal/cache"
+	"github.com/renan-alm/gh-cost-center/internal/budgets"
 	"github.com/renan-alm/gh-cost-center/internal/customprop"
 	"github.com/renan-alm/gh-cost-center/internal/github"
 	"github.com/renan-alm/gh-cost-center/internal/pru"
+	"github.com/renan-alm/gh-cost-center/internal/render"
 	"github.com/renan-alm/gh-cost-center/internal/teams"
 )
 
@@ -20,26 +31,85 @@ var reportCmd = &cobra.Command{
 
 Shows per-cost-center user counts and assignment breakdown.
 The report type is determined by cost_center.mode in config.yaml.
+In teams mode the report also lists unmapped teams and Copilot users.
+
+In users mode --output selects table (default), json, csv, or markdown (a
+table to paste into a GitHub issue), and --export writes the report to a
+file instead of stdout: to the given path, or with a bare --export to a
+timestamped file in export_dir.
+
+--budgets adds, for every active cost center, its budgets and flags cost
+centers with no budget, with several budgets for one SKU, or with an amount
+that differs from budgets.products in the config.
 
 Examples:
-  gh cost-center report`,
+  gh cost-center report
+  gh cost-center report --budgets
+  gh cost-center report --output markdown
+  gh cost-center report --output csv --export
+
+  # Fail in CI when a team or Copilot user has no cost center (teams mode)
+  gh cost-center report --fail-on-unmapped`,
 	RunE: runReport,
 }
 
+var (
+	reportFailOnUnmapped bool
+	reportOutput         string
+	reportExport         string
+	reportBudgets        bool
+)
+
+// reportExportDefault is the --export value given without a path.
+const reportExportDefault = "auto"
+
+// reportExtensions maps --output formats to export file extensions.
+var reportExtensions = map[string]string{
+	"table":    ".txt",
+	"json":     ".json",
+	"csv":      ".csv",
+	"markdown": ".md",
+}
+
 func init() {
+	reportCmd.Flags().BoolVar(&reportFailOnUnmapped, "fail-on-unmapped", false, "exit non-zero if teams with Copilot seat holders or Copilot users are left unmapped (teams mode)")
+	reportCmd.Flags().StringVarP(&reportOutput, "output", "o", "table", "output format: table, json, csv, or markdown (users mode)")
+	reportCmd.Flags().StringVar(&reportExport, "export", "", "write the report to this file; without a path, to a timestamped file in export_dir (users mode)")
+	reportCmd.Flags().Lookup("export").NoOptDefVal = reportExportDefault
+	reportCmd.Flags().BoolVar(&reportBudgets, "budgets", false, "also report the budgets of every active cost center and where they differ from the config")
 	rootCmd.AddCommand(reportCmd)
 }
 
 func runReport(_ *cobra.Command, _ []string) error {
+	if _, ok := reportExtensions[reportOutput]; !ok {
+		return usageErrorf("invalid --output %q: must be 'table', 'json', 'csv', or 'markdown'", reportOutput)
+	}
+	if (reportOutput != "table" || reportExport != "") &&
+		(cfgManager.CostCenterMode == "teams" || cfgManager.CostCenterMode == "custom-prop") {
+		return usageErrorf("--output and --export are only supported in users mode")
+	}
+	if reportBudgets && (reportOutput != "table" || reportExport != "") {
+		return usageErrorf("--budgets only supports table output to stdout")
+	}
+
+	var err error
 	switch cfgManager.CostCenterMode {
 	case "teams":
-		return runTeamsReport()
+		err = runTeamsReport()
 	case "custom-prop":
-		return runCustomPropReport()
+		err = runCustomPropReport()
 	default:
 		// "users" (PRU) is the default
+		err = runUsersReport()
 	}
+	if err != nil || !reportBudgets {
+		return err
+	}
+	return runBudgetReport(os.Stdout)
+}
 
+// runUsersReport generates the users-mode (PRU) summary.
+func runUsersReport() error {
 	logger := slog.Default()
 
 	// Create GitHub API client.
@@ -57,16 +127,218 @@ func runReport(_ *cobra.Command, _ []string) error {
 		return fmt.Errorf("fetching copilot users: %w", err)
 	}
 
-	// Generate and display summary.
-	summary := mgr.GenerateSummary(users)
+	if err := loadTierTeams(client, mgr, false, logger); err != nil {
+		return err
+	}
+
+	// Cost center names for the IDs in the summary.
+	attachCache(client, logger)
+	names := map[string]string{}
+	if active, err := client.GetAllActiveCostCenters(); err != nil {
+		logger.Warn("Could not resolve cost center names; reporting IDs only", "error", err)
+	} else {
+		for name, id := range active {
+			names[id] = name
+		}
+	}
+
+	now := time.Now().UTC()
+	report := buildUsersReport(cfgManager.Enterprise, mgr.GenerateSummary(users), names, now)
+	for _, r := range report.CostCenters {
+		logger.Info("Cost center", "id", r.ID, "name", r.Name, "users", r.Users)
+	}
+
+	if reportExport == "" {
+		return writeUsersReport(os.Stdout, reportOutput, report)
+	}
+	path := reportExport
+	if path == reportExportDefault {
+		path = filepath.Join(cfgManager.ExportDir,
+			"cost_center_report_"+now.Format("20060102-150405")+reportExtensions[reportOutput])
+	}
+	if err := exportUsersReport(path, reportOutput, report); err != nil {
+		return err
+	}
+	logger.Info("Report exported", "path", path, "format", reportOutput)
+	return nil
+}
+
+// usersReport is the users-mode summary: users per cost center.
+type usersReport struct {
+	Enterprise  string            `json:"enterprise"`
+	GeneratedAt time.Time         `json:"generated_at"`
+	CostCenters []usersReportLine `json:"cost_centers"`
+	TotalUsers  int               `json:"total_users"`
+}
+
+// usersReportLine is one cost center of the report.  Skipped seats are
+// lines with only a name, such as "skipped (non-user)".
+type usersReportLine struct {
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name"`
+	Users int    `json:"users"`
+}
+
+// buildUsersReport turns a pru.Manager summary into report lines, naming cost
+// centers from names (ID → name).  Cost centers are sorted by name, then ID,
+// with skipped seats last; an ID missing from names is shown as its name.
+func buildUsersReport(enterprise string, summary map[string]int, names map[string]string, now time.Time) usersReport {
+	report := usersReport{Enterprise: enterprise, GeneratedAt: now, CostCenters: []usersReportLine{}}
+	var skipped []usersReportLine
+	for key, count := range summary {
+		report.TotalUsers += count
+		if key == pru.SkippedNonUser || key == pru.SkippedPendingCancellation {
+			skipped = append(skipped, usersReportLine{Name: key, Users: count})
+			continue
+		}
+		name := names[key]
+		if name == "" {
+			name = key
+		}
+		report.CostCenters = append(report.CostCenters, usersReportLine{ID: key, Name: name, Users: count})
+	}
+	sort.Slice(report.CostCenters, func(i, j int) bool {
+		a, b := report.CostCenters[i], report.CostCenters[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	})
+	sort.Slice(skipped, func(i, j int) bool { return skipped[i].Name < skipped[j].Name })
+	report.CostCenters = append(report.CostCenters, skipped...)
+	return report
+}
+
+// writeUsersReport writes report to w in format: table, json, csv, or
+// markdown.
+func writeUsersReport(w io.Writer, format string, report usersReport) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("encoding report: %w", err)
+		}
+		return nil
+	case "csv":
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"cost_center_id", "cost_center_name", "users"})
+		for _, l := range report.CostCenters {
+			_ = cw.Write([]string{l.ID, l.Name, strconv.Itoa(l.Users)})
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return fmt.Errorf("writing CSV: %w", err)
+		}
+		return nil
+	case "markdown":
+		_, _ = fmt.Fprintf(w, "## Cost center summary — %s\n\n", report.Enterprise)
+		_, _ = fmt.Fprintf(w, "_Generated %s_\n\n", report.GeneratedAt.Format(time.RFC3339))
+		_, _ = fmt.Fprintln(w, "| Cost center | ID | Users |")
+		_, _ = fmt.Fprintln(w, "|---|---|---:|")
+		for _, l := range report.CostCenters {
+			id := ""
+			if l.ID != "" && l.ID != l.Name {
+				id = "`" + l.ID + "`"
+			}
+			_, _ = fmt.Fprintf(w, "| %s | %s | %d |\n", markdownCell(l.Name), id, l.Users)
+		}
+		_, _ = fmt.Fprintf(w, "| **Total** | | **%d** |\n", report.TotalUsers)
+		return nil
+	default:
+		p := render.Auto(w)
+		p.Println("\n=== Cost Center Summary ===")
+		rows := make([][]string, 0, len(report.CostCenters)+1)
+		for _, l := range report.CostCenters {
+			label := l.Name
+			if l.ID != "" && l.ID != l.Name {
+				label = fmt.Sprintf("%s (%s)", l.Name, l.ID)
+			}
+			if l.ID == "" {
+				label = p.Warn(label) // skipped seats
+			}
+			rows = append(rows, []string{label + ":", fmt.Sprintf("%d users", l.Users)})
+		}
+		rows = append(rows, []string{"Total:", fmt.Sprintf("%d users", report.TotalUsers)})
+		p.Table("", rows)
+		return nil
+	}
+}
+
+// runBudgetReport writes the budgets of every active cost center to w.  When
+// the Budgets API is unavailable the section says so instead of failing.
+func runBudgetReport(w io.Writer) error {
+	logger := slog.Default()
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	attachCache(client, logger)
+
+	active, err := client.GetAllActiveCostCenters()
+	if err != nil {
+		return fmt.Errorf("fetching active cost centers: %w", err)
+	}
+	list, err := client.ListBudgets()
+	if err != nil {
+		var uaErr *github.BudgetsAPIUnavailableError
+		if !errors.As(err, &uaErr) {
+			return fmt.Errorf("listing budgets: %w", err)
+		}
+		printBudgetReport(w, nil, err)
+		return nil
+	}
+	printBudgetReport(w, budgets.Audit(active, list, cfgManager.BudgetProducts), nil)
+	return nil
+}
 
-	fmt.Println("\n=== Cost Center Summary ===")
-	logger.Info("Cost Center Assignment Summary")
-	for cc, count := range summary {
-		fmt.Printf("%s: %d users\n", cc, count)
-		logger.Info("Cost center", "id", cc, "users", count)
+// printBudgetReport writes the budget section: one block per cost center
+// with its budgets and issues, then the number of cost centers needing
+// attention.  A non-nil unavailable replaces the section with a note.
+func printBudgetReport(w io.Writer, statuses []budgets.CostCenterBudgets, unavailable error) {
+	_, _ = fmt.Fprintln(w, "\n=== Cost Center Budgets ===")
+	if unavailable != nil {
+		_, _ = fmt.Fprintf(w, "Budgets not reported: %v\n", unavailable)
+		return
 	}
+	flagged := 0
+	for _, cc := range statuses {
+		_, _ = fmt.Fprintf(w, "%s (%s)\n", cc.Name, cc.ID)
+		for _, b := range cc.Budgets {
+			_, _ = fmt.Fprintf(w, "  %s: %d\n", b.BudgetProductSKU, b.BudgetAmount)
+		}
+		for _, issue := range cc.Issues {
+			_, _ = fmt.Fprintf(w, "  ! %s\n", issue)
+		}
+		if len(cc.Issues) > 0 {
+			flagged++
+		}
+	}
+	_, _ = fmt.Fprintf(w, "%d of %d cost centers need attention\n", flagged, len(statuses))
+}
+
+// markdownCell escapes the characters that would break a table cell.
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
 
+// exportUsersReport writes report to path in format, creating the parent
+// directory.
+func exportUsersReport(path, format string, report usersReport) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating export directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating report file: %w", err)
+	}
+	if err := writeUsersReport(f, format, report); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing report file: %w", err)
+	}
 	return nil
 }
 
@@ -88,7 +360,7 @@ func runTeamsReport() error {
 
 	summary.Print(cfgManager.Enterprise)
 
-	return nil
+	return unmappedError(summary.Unmapped, reportFailOnUnmapped)
 }
 
 // runCustomPropReport generates a custom-property cost center summary.
@@ -105,10 +377,7 @@ func runCustomPropReport() error {
 		return fmt.Errorf("creating GitHub client: %w", err)
 	}
 
-	cc, cacheErr := cache.New("", logger)
-	if cacheErr == nil {
-		client.SetCache(cc)
-	}
+	attachCache(client, logger)
 
 	cpMgr, err := customprop.NewManager(cfgManager, client, logger)
 	if err != nil {
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renan-alm/gh-cost-center/internal/pru"
+)
+
+// testUsersReport builds a report with two named cost centers, one unknown
+// ID, and skipped seats.
+func testUsersReport() usersReport {
+	summary := map[string]int{
+		"id-zeta":                      4,
+		"id-alpha":                     2,
+		"CC-UNKNOWN":                   1,
+		pru.SkippedNonUser:             3,
+		pru.SkippedPendingCancellation: 5,
+	}
+	names := map[string]string{"id-zeta": "Zeta | Ops", "id-alpha": "Alpha", "id-unused": "Unused"}
+	return buildUsersReport("test-ent", summary, names, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+}
+
+func TestBuildUsersReport_Deterministic(t *testing.T) {
+	want := []string{"Alpha", "CC-UNKNOWN", "Zeta | Ops", pru.SkippedNonUser, pru.SkippedPendingCancellation}
+	for range 20 {
+		report := testUsersReport()
+		var got []string
+		for _, l := range report.CostCenters {
+			got = append(got, l.Name)
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("order = %v, want %v", got, want)
+		}
+		if report.TotalUsers != 15 {
+			t.Fatalf("TotalUsers = %d, want 15", report.TotalUsers)
+		}
+	}
+}
+
+func TestWriteUsersReport_Formats(t *testing.T) {
+	report := testUsersReport()
+
+	var table bytes.Buffer
+	if err := writeUsersReport(&table, "table", report); err != nil {
+		t.Fatal(err)
+	}
+	wantTable := "\n=== Cost Center Summary ===\n" +
+		"Alpha (id-alpha):                2 users\n" +
+		"CC-UNKNOWN:                      1 users\n" +
+		"Zeta | Ops (id-zeta):            4 users\n" +
+		"skipped (non-user):              3 users\n" +
+		"skipped (pending cancellation):  5 users\n" +
+		"Total:                           15 users\n"
+	if table.String() != wantTable {
+		t.Errorf("table =\n%s\nwant\n%s", table.String(), wantTable)
+	}
+
+	var js bytes.Buffer
+	if err := writeUsersReport(&js, "json", report); err != nil {
+		t.Fatal(err)
+	}
+	var decoded usersReport
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil {
+		t.Fatalf("json output: %v", err)
+	}
+	if decoded.Enterprise != "test-ent" || decoded.TotalUsers != 15 || len(decoded.CostCenters) != 5 ||
+		decoded.CostCenters[0] != (usersReportLine{ID: "id-alpha", Name: "Alpha", Users: 2}) {
+		t.Errorf("json = %+v", decoded)
+	}
+
+	var cs bytes.Buffer
+	if err := writeUsersReport(&cs, "csv", report); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&cs).ReadAll()
+	if err != nil {
+		t.Fatalf("csv output: %v", err)
+	}
+	if len(records) != 6 || strings.Join(records[0], ",") != "cost_center_id,cost_center_name,users" ||
+		strings.Join(records[3], ",") != "id-zeta,Zeta | Ops,4" || strings.Join(records[4], ",") != ",skipped (non-user),3" {
+		t.Errorf("csv = %v", records)
+	}
+
+	var md bytes.Buffer
+	if err := writeUsersReport(&md, "markdown", report); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"## Cost center summary — test-ent\n",
+		"| Cost center | ID | Users |\n|---|---|---:|\n| Alpha | `id-alpha` | 2 |\n",
+		"| CC-UNKNOWN |  | 1 |\n",
+		`| Zeta \| Ops | ` + "`id-zeta`" + ` | 4 |`,
+		"| **Total** | | **15** |\n",
+	} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("markdown missing %q:\n%s", want, md.String())
+		}
+	}
+}
+
+func TestExportUsersReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reports", "summary.csv")
+	report := testUsersReport()
+	if err := exportUsersReport(path, "csv", report); err != nil {
+		t.Fatalf("exportUsersReport: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading export: %v", err)
+	}
+	var want bytes.Buffer
+	if err := writeUsersReport(&want, "csv", report); err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want.String() {
+		t.Errorf("exported file =\n%s\nwant\n%s", got, want.String())
+	}
+}
+
+func TestRunReport_ExportDefaultsToExportDir(t *testing.T) {
+	srv, _ := pruTestServer(t, "")
+	setupPRUAssign(t, srv, "plan")
+	reportOutput, reportExport = "markdown", reportExportDefault
+	t.Cleanup(func() { reportOutput, reportExport = "table", "" })
+
+	if err := runReport(reportCmd, nil); err != nil {
+		t.Fatalf("runReport: %v", err)
+	}
+	matches, err := filepath.Glob(filepath.Join(cfgManager.ExportDir, "cost_center_report_*.md"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("exported reports = %v (err %v), want one timestamped file", matches, err)
+	}
+	data, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "| **Total** | | **2** |") {
+		t.Errorf("exported report =\n%s", data)
+	}
+}
+
+func TestRunReport_RejectsFormatsOutsideUsersMode(t *testing.T) {
+	srv, _ := pruTestServer(t, "")
+	setupPRUAssign(t, srv, "plan")
+	cfgManager.CostCenterMode = "teams"
+	reportOutput = "json"
+	t.Cleanup(func() { reportOutput = "table" })
+
+	if err := runReport(reportCmd, nil); err == nil || !strings.Contains(err.Error(), "only supported in users mode") {
+		t.Errorf("err = %v, want a users-mode-only error", err)
+	}
+	reportOutput = "yaml"
+	if err := runReport(reportCmd, nil); err == nil || !strings.Contains(err.Error(), "invalid --output") {
+		t.Errorf("err = %v, want invalid --output", err)
+	}
+}
+
+// budgetReportServer serves two active cost centers and, unless budgetsFound
+// is false, one matching and one mismatched budget for the first.
+func budgetReportServer(t *testing.T, budgetsFound bool) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/cost-centers"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"costCenters": []map[string]string{
+				{"id": testCCID, "name": "No PRUs", "state": "active"},
+				{"id": testPRUCCID, "name": "PRUs Allowed", "state": "active"},
+			}})
+		case strings.HasSuffix(r.URL.Path, "/settings/billing/budgets"):
+			if !budgetsFound {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"has_next_page": false, "budgets": []map[string]any{
+				{"id": "b1", "budget_scope": "cost_center", "budget_entity_name": testCCID, "budget_product_sku": "copilot", "budget_amount": 100},
+				{"id": "b2", "budget_scope": "cost_center", "budget_entity_name": "No PRUs", "budget_product_sku": "actions", "budget_amount": 999},
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRunBudgetReport(t *testing.T) {
+	setupPRUAssign(t, budgetReportServer(t, true), "plan")
+
+	var buf bytes.Buffer
+	if err := runBudgetReport(&buf); err != nil {
+		t.Fatalf("runBudgetReport: %v", err)
+	}
+	want := "\n=== Cost Center Budgets ===\n" +
+		"No PRUs (" + testCCID + ")\n" +
+		"  actions: 999\n" +
+		"  copilot: 100\n" +
+		"  ! actions budget is 999, configured 125\n" +
+		"PRUs Allowed (" + testPRUCCID + ")\n" +
+		"  ! no budget\n" +
+		"2 of 2 cost centers need attention\n"
+	if buf.String() != want {
+		t.Errorf("budget report =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestRunBudgetReport_BudgetsAPIUnavailable(t *testing.T) {
+	setupPRUAssign(t, budgetReportServer(t, false), "plan")
+
+	var buf bytes.Buffer
+	if err := runBudgetReport(&buf); err != nil {
+		t.Fatalf("runBudgetReport: %v, want the report to degrade gracefully", err)
+	}
+	if !strings.Contains(buf.String(), "Budgets not reported: Budgets API is not available") {
+		t.Errorf("budget report =\n%s", buf.String())
+	}
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+// resolveCostCenterRef resolves a cost center reference given on the command
+// line — either a UUID or an exact cost center name — to its ID and name
+// using the list of active cost centers.  An unknown reference is reported as
+// *github.CostCenterNotFoundError.
+func resolveCostCenterRef(client github.CostCenterAPI, ref string) (id, name string, err error) {
+	active, err := client.GetAllActiveCostCenters()
+	if err != nil {
+		return "", "", fmt.Errorf("fetching active cost centers: %w", err)
+	}
+
+	if github.IsValidCostCenterUUID(ref) {
+		for n, ccID := range active {
+			if ccID == ref {
+				return ccID, n, nil
+			}
+		}
+		return "", "", &github.CostCenterNotFoundError{ID: ref}
+	}
+
+	if ccID, ok := active[ref]; ok {
+		return ccID, ref, nil
+	}
+	return "", "", &github.CostCenterNotFoundError{ID: ref}
+}
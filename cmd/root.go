@@ -2,25 +2,46 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
+	"slices"
 
 	"github.com/spf13/cobra"
 
 	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/logging"
+	"github.com/renan-alm/gh-cost-center/internal/progress"
+	"github.com/renan-alm/gh-cost-center/internal/render"
 )
 
 var (
 	// Global flags
-	cfgFile   string
+	cfgFiles  []string
 	verbose   bool
 	tokenFlag string
+	noCache   bool
+
+	// forceUnlock removes another apply run's lock file before starting.
+	forceUnlock bool
 
 	// cfgManager is the loaded configuration, available to all subcommands.
 	cfgManager *config.Manager
 )
 
+var (
+	// quiet raises the console log level to WARN; noColor disables colored
+	// output, as does a non-empty NO_COLOR environment variable.
+	quiet   bool
+	noColor bool
+
+	// logOptions is the logging configuration installed by PersistentPreRunE.
+	logOptions logging.Options
+)
+
 // rootCmd represents the base command when called without any subcommands.
 var rootCmd = &cobra.Command{
 	Use:   "gh-cost-center",
@@ -43,6 +64,9 @@ The operational mode is determined by cost_center.mode in config.yaml:
   custom-prop:      Assigns repositories using custom property filters
                     with AND logic across multiple properties.
 
+  assigning-team:   Assigns Copilot users to cost centers named after the
+                    team that granted their seat.
+
 Examples:
   # Assign (mode from config)
   gh cost-center assign --mode plan
@@ -59,37 +83,167 @@ Examples:
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		// Set up logger.
-		level := slog.LevelInfo
-		if verbose {
-			level = slog.LevelDebug
-		}
-		logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
-		slog.SetDefault(logger)
+		logger := setupLogger()
+		resolveConfigFiles(cmd, logger)
 
 		// Load configuration.
-		mgr, err := config.Load(cfgFile, logger)
+		mgr, err := config.LoadFiles(cfgFiles, logger)
 		if err != nil {
-			return fmt.Errorf("loading configuration: %w", err)
+			return &exitError{code: exitCodeConfig, err: fmt.Errorf("loading configuration: %w", err)}
 		}
 		cfgManager = mgr
+
+		// Reinstall the logger with the configured level and log file.
+		logOptions = loggingOptions(mgr.LogLevel, mgr.LogFile)
+		logOptions.MaxSizeMB, logOptions.MaxBackups, logOptions.MaxAgeDays = mgr.LogMaxSizeMB, mgr.LogMaxBackups, mgr.LogMaxAgeDays
+		logOptions.KeepRuns = mgr.LogKeepRuns
+		installProgress(cmd, installLogger(logOptions))
 		cfgManager.Token = tokenFlag
 		cfgManager.CheckConfigWarnings()
 		return nil
 	},
 }
 
+// resolveConfigFiles replaces the --config default with the first config
+// file found in config.SearchPaths when --config is not given, logging
+// which one was picked.
+func resolveConfigFiles(cmd *cobra.Command, logger *slog.Logger) {
+	if cmd.Flags().Changed("config") || !slices.Equal(cfgFiles, []string{config.DefaultPath}) {
+		return
+	}
+	if path, ok := config.FindConfigFile(); ok {
+		cfgFiles = []string{path}
+		logger.Info("Using config file", "path", path)
+	}
+}
+
+// setupLogger installs the default logger from the flags alone, for use
+// before the configuration is loaded, and returns it.
+func setupLogger() *slog.Logger {
+	return installLogger(loggingOptions("", ""))
+}
+
+// loggingOptions resolves the logging configuration.  The console level is
+// WARN with --quiet, else DEBUG with --verbose, else configLevel (INFO when
+// empty).  A configured log file always records DEBUG.
+func loggingOptions(configLevel, file string) logging.Options {
+	level := logging.ParseLevel(configLevel)
+	switch {
+	case quiet:
+		level = slog.LevelWarn
+	case verbose:
+		level = slog.LevelDebug
+	}
+	return logging.Options{
+		Level:    level,
+		FilePath: file,
+		NoColor:  noColor || os.Getenv("NO_COLOR") != "",
+	}
+}
+
+// installLogger makes a logger built from opts the default and returns it.
+// opts.NoColor also turns off the colors of the command output.
+func installLogger(opts logging.Options) *slog.Logger {
+	render.NoColor = opts.NoColor
+	logger, err := logging.New(opts)
+	if err != nil {
+		logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: opts.Level}))
+		logger.Warn("Could not set up logging, logging to stderr only", "error", err)
+	}
+	slog.SetDefault(logger)
+	return logger
+}
+
+// installProgress makes long phases report their progress on stderr,
+// unless --quiet is set or cmd writes JSON to stdout (--output json).
+func installProgress(cmd *cobra.Command, logger *slog.Logger) {
+	if f := cmd.Flags().Lookup("output"); quiet || (f != nil && f.Value.String() == "json") {
+		progress.SetDefault(nil)
+		return
+	}
+	progress.SetDefault(progress.New(os.Stderr, render.IsTerminal(os.Stderr), logger))
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
+	}
+}
+
+// exitCode maps the error a command returned onto its exit status: an
+// explicit exitError keeps its code, a PartialFailureError exits with
+// exitCodePartial, an AuthError or any other HTTP 401 or 403 from the API
+// with exitCodeAuth, and anything else with exitCodeError.
+func exitCode(err error) int {
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	var pe *PartialFailureError
+	if errors.As(err, &pe) {
+		return exitCodePartial
+	}
+	var authErr *github.AuthError
+	if errors.As(err, &authErr) {
+		return exitCodeAuth
+	}
+	var apiErr *github.APIError
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden) {
+		return exitCodeAuth
 	}
+	return exitCodeError
+}
+
+// exitError makes Execute exit with a specific status code instead of the
+// generic 1.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// Exit statuses.  They are documented in the README and scripts rely on
+// them, so never renumber one.
+const (
+	exitCodeError    = 1 // unexpected error
+	exitCodeConfig   = 2 // invalid configuration, flags, or arguments
+	exitCodeAuth     = 3 // the API rejected the token (HTTP 401 or 403)
+	exitCodePartial  = 4 // some users could not be assigned
+	exitCodeDrift    = 5 // audit --fail-on-drift found drift
+	exitCodeNotFound = 6 // a requested object, such as a cost center, does not exist
+	exitCodeExists   = 7 // create --fail-if-exists found the object already there
+
+	exitCodeInterrupted = 130 // stopped by SIGINT or SIGTERM; 128 + SIGINT, as shells report it
+)
+
+// usageErrorf formats an error about invalid flags or arguments, which exits
+// with exitCodeConfig.
+func usageErrorf(format string, args ...any) error {
+	return &exitError{code: exitCodeConfig, err: fmt.Errorf(format, args...)}
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "config/config.yaml", "configuration file path")
+	rootCmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return &exitError{code: exitCodeConfig, err: err}
+	})
+	rootCmd.PersistentFlags().StringSliceVar(&cfgFiles, "config", []string{config.DefaultPath}, "configuration file path; repeat it or separate paths with commas to merge overlays over a base file; when not given, the first of ./config/config.yaml, ./gh-cost-center.yaml, and gh-cost-center/config.yaml in $XDG_CONFIG_HOME or ~/.config that exists")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "enable verbose (debug) logging")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "only log warnings and errors to the console (overrides --verbose and logging.level)")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colored output (also set by the NO_COLOR environment variable)")
+	rootCmd.PersistentFlags().StringVar(&config.EnterpriseFlag, "enterprise", "", "enterprise slug, overriding GITHUB_ENTERPRISE and github.enterprise")
+	rootCmd.PersistentFlags().StringArrayVar(&config.Overrides, "set", nil, "override a config key, e.g. --set budgets.products.copilot.amount=250 (repeatable; comma-separate list values)")
+	rootCmd.PersistentFlags().BoolVar(&config.Strict, "strict-config", false, "reject a configuration file with unknown keys (also set by strict: true in the file)")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "do not read or write the on-disk cost center and property schema cache")
+	rootCmd.PersistentFlags().BoolVar(&forceUnlock, "force-unlock", false, "remove the lock left by another apply run before starting")
 	rootCmd.PersistentFlags().StringVar(&tokenFlag, "token", "", "GitHub personal access token (overrides GITHUB_TOKEN, GH_TOKEN, and gh auth)")
 }
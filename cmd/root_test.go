@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/logging"
+	"github.com/renan-alm/gh-cost-center/internal/progress"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"unexpected", errors.New("boom"), exitCodeError},
+		{"usage", usageErrorf("invalid --mode %q", "x"), exitCodeConfig},
+		{"partial", fmt.Errorf("wrapped: %w", &PartialFailureError{Failed: 1, Total: 2}), exitCodePartial},
+		{"unauthorized", fmt.Errorf("fetching: %w", &github.APIError{StatusCode: http.StatusUnauthorized}), exitCodeAuth},
+		{"forbidden parsed", &github.APIMessageError{Err: &github.APIError{StatusCode: http.StatusForbidden}}, exitCodeAuth},
+		{"other API error", &github.APIError{StatusCode: http.StatusUnprocessableEntity}, exitCodeError},
+		{"missing scopes", fmt.Errorf("preflight: %w", &github.AuthError{Missing: []string{"read:enterprise"}}), exitCodeAuth},
+		{"explicit", &exitError{code: exitCodeNotFound, err: errors.New("no such cost center")}, exitCodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitCode_Scenarios(t *testing.T) {
+	t.Run("invalid config", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte("cost_center: [not, a, map\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		prevFiles, prevCfg := cfgFiles, cfgManager
+		t.Cleanup(func() { cfgFiles, cfgManager = prevFiles, prevCfg })
+		cfgFiles = []string{path}
+		if got := exitCode(rootCmd.PersistentPreRunE(rootCmd, nil)); got != exitCodeConfig {
+			t.Errorf("exit code = %d, want %d", got, exitCodeConfig)
+		}
+	})
+
+	t.Run("invalid flag", func(t *testing.T) {
+		srv, _ := pruTestServer(t, "")
+		setupPRUAssign(t, srv, "sideways")
+		if got := exitCode(runAssign(assignCmd, nil)); got != exitCodeConfig {
+			t.Errorf("exit code = %d, want %d", got, exitCodeConfig)
+		}
+	})
+
+	t.Run("token rejected", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"Bad credentials"}`))
+		}))
+		t.Cleanup(srv.Close)
+		setupPRUAssign(t, srv, "apply")
+		if got := exitCode(runAssign(assignCmd, nil)); got != exitCodeAuth {
+			t.Errorf("exit code = %d, want %d", got, exitCodeAuth)
+		}
+	})
+
+	t.Run("partial failure", func(t *testing.T) {
+		srv, _ := pruTestServer(t, testPRUCCID)
+		setupPRUAssign(t, srv, "apply")
+		if got := exitCode(runAssign(assignCmd, nil)); got != exitCodePartial {
+			t.Errorf("exit code = %d, want %d", got, exitCodePartial)
+		}
+	})
+
+	t.Run("drift", func(t *testing.T) {
+		srv, _ := pruTestServer(t, "")
+		setupPRUAssign(t, srv, "plan")
+		prevOutput, prevFail := auditOutput, auditFailOnDrift
+		t.Cleanup(func() { auditOutput, auditFailOnDrift = prevOutput, prevFail })
+		auditOutput, auditFailOnDrift = "json", true
+		if got := exitCode(runAudit(auditCmd, nil)); got != exitCodeDrift {
+			t.Errorf("exit code = %d, want %d", got, exitCodeDrift)
+		}
+	})
+}
+
+func TestLoggingOptions_Precedence(t *testing.T) {
+	tests := []struct {
+		name           string
+		quiet, verbose bool
+		configLevel    string
+		want           slog.Level
+	}{
+		{"config level", false, false, "ERROR", slog.LevelError},
+		{"default", false, false, "", slog.LevelInfo},
+		{"verbose over config", false, true, "ERROR", slog.LevelDebug},
+		{"quiet over config", true, false, "DEBUG", slog.LevelWarn},
+		{"quiet over verbose", true, true, "INFO", slog.LevelWarn},
+	}
+	prevQuiet, prevVerbose := quiet, verbose
+	t.Cleanup(func() { quiet, verbose = prevQuiet, prevVerbose })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quiet, verbose = tt.quiet, tt.verbose
+			if got := loggingOptions(tt.configLevel, "").Level; got != tt.want {
+				t.Errorf("level = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPersistentPreRunE_LoggingOptions(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "logs", "run.log")
+	path := filepath.Join(dir, "config.yaml")
+	yaml := "github:\n  enterprise: \"test-ent\"\nlogging:\n  level: \"ERROR\"\n  file: \"" + logFile + "\"\n"
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	prevFiles, prevCfg, prevQuiet, prevVerbose, prevNoColor := cfgFiles, cfgManager, quiet, verbose, noColor
+	prevLogger := slog.Default()
+	t.Cleanup(func() {
+		cfgFiles, cfgManager, quiet, verbose, noColor = prevFiles, prevCfg, prevQuiet, prevVerbose, prevNoColor
+		logOptions = logging.Options{}
+		slog.SetDefault(prevLogger)
+		progress.SetDefault(nil)
+	})
+	cfgFiles = []string{path}
+	t.Setenv("NO_COLOR", "")
+
+	run := func(q, v bool) logging.Options {
+		t.Helper()
+		quiet, verbose = q, v
+		if err := rootCmd.PersistentPreRunE(rootCmd, nil); err != nil {
+			t.Fatalf("PersistentPreRunE: %v", err)
+		}
+		return logOptions
+	}
+
+	if opts := run(false, false); opts.Level != slog.LevelError || opts.FilePath != logFile || opts.NoColor {
+		t.Errorf("config only: %+v, want ERROR to %s with color", opts, logFile)
+	}
+	if opts := run(false, true); opts.Level != slog.LevelDebug {
+		t.Errorf("--verbose: level = %v, want DEBUG", opts.Level)
+	}
+	if opts := run(true, true); opts.Level != slog.LevelWarn || opts.FilePath != logFile {
+		t.Errorf("--quiet --verbose: %+v, want WARN with the log file kept", opts)
+	}
+
+	// The file keeps recording DEBUG under --quiet.
+	slog.Debug("quiet debug line")
+	data, err := os.ReadFile(logFile)
+	if err != nil || !strings.Contains(string(data), "quiet debug line") {
+		t.Errorf("log file = %q (err %v), want the debug line", data, err)
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if opts := run(false, false); !opts.NoColor {
+		t.Error("NO_COLOR set: NoColor = false")
+	}
+	t.Setenv("NO_COLOR", "")
+	noColor = true
+	if opts := run(false, false); !opts.NoColor {
+		t.Error("--no-color: NoColor = false")
+	}
+}
+
+func TestInstallProgress(t *testing.T) {
+	prevQuiet, prevFormat := quiet, assignOutputFormat
+	t.Cleanup(func() {
+		quiet, assignOutputFormat = prevQuiet, prevFormat
+		progress.SetDefault(nil)
+	})
+
+	tests := []struct {
+		name   string
+		quiet  bool
+		format string
+		silent bool
+	}{
+		{"text", false, "text", false},
+		{"quiet", true, "text", true},
+		{"json output", false, "json", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quiet, assignOutputFormat = tt.quiet, tt.format
+			installProgress(assignCmd, quietLogger())
+			if silent := progress.Start("phase", 1) == nil; silent != tt.silent {
+				t.Errorf("silent = %v, want %v", silent, tt.silent)
+			}
+		})
+	}
+}
+
+func TestResolveConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("HOME", filepath.Join(dir, "home"))
+	t.Setenv("XDG_CONFIG_HOME", "")
+	prevFiles := cfgFiles
+	t.Cleanup(func() { cfgFiles = prevFiles })
+
+	cfgFiles = []string{config.DefaultPath}
+	resolveConfigFiles(rootCmd, quietLogger())
+	if !slices.Equal(cfgFiles, []string{config.DefaultPath}) {
+		t.Errorf("nothing found: cfgFiles = %v, want the default", cfgFiles)
+	}
+
+	if err := os.WriteFile("gh-cost-center.yaml", []byte("github:\n  enterprise: ent\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resolveConfigFiles(rootCmd, quietLogger())
+	if !slices.Equal(cfgFiles, []string{"gh-cost-center.yaml"}) {
+		t.Errorf("cfgFiles = %v, want [gh-cost-center.yaml]", cfgFiles)
+	}
+
+	// An explicit --config is kept, even when it names the default path.
+	cmd := &cobra.Command{}
+	cmd.Flags().StringSliceVar(&cfgFiles, "config", []string{config.DefaultPath}, "")
+	if err := cmd.Flags().Parse([]string{"--config", config.DefaultPath}); err != nil {
+		t.Fatal(err)
+	}
+	resolveConfigFiles(cmd, quietLogger())
+	if !slices.Equal(cfgFiles, []string{config.DefaultPath}) {
+		t.Errorf("--config given: cfgFiles = %v, want [%s]", cfgFiles, config.DefaultPath)
+	}
+}
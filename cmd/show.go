@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+var showOutput string
+
+var showCmd = &cobra.Command{
+	Use:   "show <id-or-name>",
+	Short: "Show a single cost center and its attached resources",
+	Long: `Show the details of one cost center: its name, ID, state, and the users,
+repositories, and organizations attached to it.
+
+The argument may be a cost center UUID or its exact name.  Exits with status 6
+if the cost center does not exist.
+
+Examples:
+  gh cost-center show "00 - No PRU overages"
+  gh cost-center show d1e2f3a4-b5c6-7890-abcd-ef1234567890 --output json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runShow,
+}
+
+func init() {
+	showCmd.Flags().StringVarP(&showOutput, "output", "o", "table", "output format: table or json")
+
+	rootCmd.AddCommand(showCmd)
+}
+
+func runShow(_ *cobra.Command, args []string) error {
+	if showOutput != "table" && showOutput != "json" {
+		return usageErrorf("invalid --output %q: must be 'table' or 'json'", showOutput)
+	}
+
+	logger := slog.Default()
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+
+	detail, err := lookupCostCenterDetail(client, args[0])
+	if err != nil {
+		return err
+	}
+	if showOutput == "json" {
+		return writeCostCenterJSON(os.Stdout, detail)
+	}
+	printCostCenterDetail(os.Stdout, detail)
+	return nil
+}
+
+// lookupCostCenterDetail fetches a cost center by UUID, or by exact name via
+// the active cost center list.  A missing cost center is returned as an
+// exitError carrying exitCodeNotFound.
+func lookupCostCenterDetail(client github.CostCenterAPI, ref string) (*github.CostCenterDetail, error) {
+	id := ref
+	if !github.IsValidCostCenterUUID(ref) {
+		resolved, _, err := resolveCostCenterRef(client, ref)
+		if err != nil {
+			return nil, notFoundExit(ref, err)
+		}
+		id = resolved
+	}
+
+	detail, err := client.GetCostCenter(id)
+	if err != nil {
+		return nil, notFoundExit(ref, err)
+	}
+	return detail, nil
+}
+
+// notFoundExit converts a cost center not-found error into an exitError with
+// a friendly message; other errors are returned unchanged.
+func notFoundExit(ref string, err error) error {
+	var nf *github.CostCenterNotFoundError
+	if errors.As(err, &nf) {
+		return &exitError{code: exitCodeNotFound, err: fmt.Errorf("no such cost center %q", ref)}
+	}
+	return err
+}
+
+// writeCostCenterJSON writes the cost center detail as indented JSON.
+func writeCostCenterJSON(w io.Writer, detail *github.CostCenterDetail) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(detail); err != nil {
+		return fmt.Errorf("encoding cost center: %w", err)
+	}
+	return nil
+}
+
+// printCostCenterDetail prints a human-readable view of the cost center with
+// its resources grouped by type.
+func printCostCenterDetail(w io.Writer, detail *github.CostCenterDetail) {
+	_, _ = fmt.Fprintf(w, "Name:  %s\n", detail.Name)
+	_, _ = fmt.Fprintf(w, "ID:    %s\n", detail.ID)
+	_, _ = fmt.Fprintf(w, "State: %s\n", detail.State)
+
+	byType := make(map[string][]string)
+	for _, r := range detail.Resources {
+		byType[r.Type] = append(byType[r.Type], r.Name)
+	}
+	if len(byType) == 0 {
+		_, _ = fmt.Fprintln(w, "\nNo resources attached.")
+		return
+	}
+
+	types := make([]string, 0, len(byType))
+	for t := range byType {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	for _, t := range types {
+		names := byType[t]
+		sort.Strings(names)
+		_, _ = fmt.Fprintf(w, "\n%s (%d):\n", t, len(names))
+		for _, n := range names {
+			_, _ = fmt.Fprintf(w, "  - %s\n", n)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+// showTestServer serves one active cost center with a user and a repository
+// attached; any other detail lookup returns 404.
+func showTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/cost-centers"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"costCenters": []map[string]string{
+				{"id": testCCID, "name": "Platform", "state": "active"},
+			}})
+		case strings.HasSuffix(r.URL.Path, "/cost-centers/"+testCCID):
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"id": testCCID, "name": "Platform", "state": "active",
+				"resources": []map[string]string{
+					{"type": "User", "name": "alice"},
+					{"type": "Repository", "name": "org/api"},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+		}
+	}))
+}
+
+func TestLookupCostCenterDetail_ByName(t *testing.T) {
+	srv := showTestServer(t)
+	defer srv.Close()
+
+	detail, err := lookupCostCenterDetail(newTestGitHubClient(t, srv.URL), "Platform")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if detail.ID != testCCID || len(detail.Resources) != 2 {
+		t.Errorf("detail = %+v", detail)
+	}
+
+	var buf bytes.Buffer
+	printCostCenterDetail(&buf, detail)
+	out := buf.String()
+	for _, want := range []string{"Name:  Platform", "User (1):", "  - alice", "Repository (1):", "  - org/api"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestLookupCostCenterDetail_NotFound(t *testing.T) {
+	srv := showTestServer(t)
+	defer srv.Close()
+	client := newTestGitHubClient(t, srv.URL)
+
+	for _, ref := range []string{"Missing", "a1b2c3d4-b5c6-7890-abcd-ef1234567890"} {
+		t.Run(ref, func(t *testing.T) {
+			_, err := lookupCostCenterDetail(client, ref)
+			var ee *exitError
+			if !errors.As(err, &ee) || ee.code != exitCodeNotFound {
+				t.Fatalf("expected exit code %d, got %v", exitCodeNotFound, err)
+			}
+			if !strings.Contains(err.Error(), "no such cost center") {
+				t.Errorf("error = %q", err.Error())
+			}
+		})
+	}
+}
+
+func TestWriteCostCenterJSON(t *testing.T) {
+	detail := &github.CostCenterDetail{
+		ID: testCCID, Name: "Platform", State: "active",
+		Resources: []github.Resource{{Type: "User", Name: "alice"}},
+	}
+	var buf bytes.Buffer
+	if err := writeCostCenterJSON(&buf, detail); err != nil {
+		t.Fatalf("writeCostCenterJSON: %v", err)
+	}
+	var got github.CostCenterDetail
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if got.Name != "Platform" || len(got.Resources) != 1 || got.Resources[0].Name != "alice" {
+		t.Errorf("decoded = %+v", got)
+	}
+}
@@ -16,24 +16,31 @@ var versionCmd = &cobra.Command{
 	Short: "Print the version number",
 	Long:  "Display the version of gh-cost-center.",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// If version is "dev", try to read from VERSION file
-		v := version
-		if v == "dev" {
-			data, err := os.ReadFile("VERSION")
-			if err != nil && !os.IsNotExist(err) {
-				return fmt.Errorf("reading VERSION file: %w", err)
-			}
-			if err == nil {
-				if trimmed := strings.TrimSpace(string(data)); trimmed != "" {
-					v = trimmed
-				}
-			}
+		v, err := toolVersion()
+		if err != nil {
+			return err
 		}
 		fmt.Printf("gh-cost-center version %s\n", v)
 		return nil
 	},
 }
 
+// toolVersion returns the build version or, for "dev" builds, the contents
+// of the VERSION file when there is one.
+func toolVersion() (string, error) {
+	if version != "dev" {
+		return version, nil
+	}
+	data, err := os.ReadFile("VERSION")
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("reading VERSION file: %w", err)
+	}
+	if trimmed := strings.TrimSpace(string(data)); err == nil && trimmed != "" {
+		return trimmed, nil
+	}
+	return version, nil
+}
+
 func init() {
 	rootCmd.AddCommand(versionCmd)
 }
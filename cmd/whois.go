@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/pru"
+	"github.com/renan-alm/gh-cost-center/internal/teams"
+)
+
+var whoisJSON bool
+
+var whoisCmd = &cobra.Command{
+	Use:   "whois <login>",
+	Short: "Explain which cost center a user is in and why",
+	Long: `Show one user's Copilot seat (plan, created, last activity, assigning team),
+how the configuration treats them — exception list, tier and user_overrides
+matches in users mode, team memberships in teams mode, the assigning team in
+assigning-team mode — the cost center they should be in, and the cost center
+they are in right now.
+
+A login without a Copilot seat is reported as such: it has no desired cost
+center, but its configuration matches and current membership are still
+shown.  Supported in users, teams, and assigning-team modes.
+
+Examples:
+  gh cost-center whois octocat
+  gh cost-center whois octocat --json | jq .desired_cost_center`,
+	Args: cobra.ExactArgs(1),
+	RunE: runWhois,
+}
+
+func init() {
+	whoisCmd.Flags().BoolVar(&whoisJSON, "json", false, "print the result as JSON")
+	rootCmd.AddCommand(whoisCmd)
+}
+
+// whoisResult is everything whois knows about one user.
+type whoisResult struct {
+	Login      string              `json:"login"`
+	Mode       string              `json:"mode"`
+	SeatHolder bool                `json:"seat_holder"`
+	Seat       *github.CopilotUser `json:"seat,omitempty"`
+
+	// Users mode.
+	PRUException bool     `json:"pru_exception"`
+	Matched      []string `json:"matched_tiers,omitempty"` // "tier: entry"
+	Override     string   `json:"override,omitempty"`
+	Tier         string   `json:"tier,omitempty"`
+	SkipReason   string   `json:"skip_reason,omitempty"`
+
+	// Teams mode: the teams the user was found in.
+	Teams []string `json:"teams,omitempty"`
+
+	DesiredCostCenter   string `json:"desired_cost_center,omitempty"`
+	DesiredCostCenterID string `json:"desired_cost_center_id,omitempty"`
+	ActualCostCenter    string `json:"actual_cost_center,omitempty"`
+	ActualCostCenterID  string `json:"actual_cost_center_id,omitempty"`
+}
+
+func runWhois(_ *cobra.Command, args []string) error {
+	mode := cfgManager.CostCenterMode
+	if mode == "repos" || mode == "custom-prop" {
+		return fmt.Errorf("whois explains user assignment and is not supported in %s mode, which assigns repositories", mode)
+	}
+
+	logger := slog.Default()
+	client, err := github.NewClient(cfgManager, logger)
+	if err != nil {
+		return fmt.Errorf("creating GitHub client: %w", err)
+	}
+	attachCache(client, logger)
+
+	res, err := whois(client, args[0], logger)
+	if err != nil {
+		return err
+	}
+	if whoisJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(res); err != nil {
+			return fmt.Errorf("encoding result: %w", err)
+		}
+		return nil
+	}
+	printWhois(os.Stdout, res)
+	return nil
+}
+
+// whois looks up login's seat, how the active mode assigns it, and its
+// current cost center.
+func whois(client github.CostCenterAPI, login string, logger *slog.Logger) (*whoisResult, error) {
+	mode := cfgManager.CostCenterMode
+	if mode == "" {
+		mode = "users"
+	}
+	res := &whoisResult{Login: login, Mode: mode}
+
+	users, err := client.GetCopilotUsers()
+	if err != nil {
+		return nil, fmt.Errorf("fetching copilot users: %w", err)
+	}
+	user := github.CopilotUser{Login: login}
+	for _, u := range users {
+		if strings.EqualFold(u.Login, login) {
+			user, res.SeatHolder = u, true
+			res.Login = u.Login
+			res.Seat = &u
+			break
+		}
+	}
+	excluded := cfgManager.IsExcludedUser(user.Login)
+
+	active, err := client.GetAllActiveCostCenters()
+	if err != nil {
+		return nil, fmt.Errorf("fetching active cost centers: %w", err)
+	}
+
+	var desired string // group key: cost center ID or name
+	var names map[string]string
+	switch mode {
+	case "teams":
+		mgr := teams.NewManager(cfgManager, client, logger)
+		assignments, err := mgr.BuildTeamAssignments()
+		if err != nil {
+			return nil, fmt.Errorf("building team assignments: %w", err)
+		}
+		for cc, list := range assignments {
+			for _, a := range list {
+				if strings.EqualFold(a.Username, user.Login) {
+					desired = cc
+					res.Teams = []string{teamKeyOf(a)}
+				}
+			}
+		}
+		for _, c := range mgr.Conflicts() {
+			if strings.EqualFold(c.Username, user.Login) {
+				res.Teams = c.Teams
+			}
+		}
+
+	case "assigning-team":
+		if res.SeatHolder && !excluded {
+			a := teams.BuildAssigningTeamAssignments([]github.CopilotUser{user}, cfgManager.AssigningTeamDefaultCostCenter)
+			for cc := range a {
+				desired = cc
+			}
+		}
+
+	default:
+		mgr := pru.NewManager(cfgManager, logger)
+		if err := loadTierTeams(client, mgr, false, logger); err != nil {
+			return nil, err
+		}
+		e := mgr.Explain(user)
+		res.PRUException = mgr.IsExceptionUser(user)
+		res.Matched, res.Override, res.Tier, res.SkipReason = e.Matched, e.Override, e.Tier, e.SkipReason
+		if excluded {
+			res.SkipReason = "excluded (excluded_users)"
+		}
+		if res.SeatHolder && res.SkipReason == "" {
+			desired = e.CostCenter
+		}
+		names = groupNames(mgr)
+	}
+
+	if desired != "" {
+		if names == nil {
+			names = map[string]string{desired: desired}
+		}
+		cc := resolveDesired(map[string][]string{desired: {user.Login}}, names, active)[user.Login]
+		res.DesiredCostCenter, res.DesiredCostCenterID = cc.name, cc.id
+	}
+
+	ref, err := client.CheckUserCostCenterMembership(user.Login)
+	if err != nil {
+		return nil, fmt.Errorf("checking cost center membership: %w", err)
+	}
+	if ref != nil {
+		res.ActualCostCenter, res.ActualCostCenterID = ref.Name, ref.ID
+		if res.ActualCostCenter == "" {
+			res.ActualCostCenter = reverseMap(active)[ref.ID]
+		}
+	}
+	return res, nil
+}
+
+// teamKeyOf returns the team key of a team assignment: "org/slug" in
+// organization scope, the slug in enterprise scope.
+func teamKeyOf(a teams.UserAssignment) string {
+	if cfgManager.TeamsScope == "enterprise" || a.Org == "" {
+		return a.TeamSlug
+	}
+	return a.Org + "/" + a.TeamSlug
+}
+
+// printWhois writes a human-readable view of res.
+func printWhois(w io.Writer, res *whoisResult) {
+	_, _ = fmt.Fprintf(w, "User: %s\n", res.Login)
+	if s := res.Seat; s != nil {
+		team := "(assigned directly)"
+		if s.AssigningTeam != nil {
+			team = s.AssigningTeam.Slug
+		}
+		lastActivity := s.LastActivityAt
+		if lastActivity == "" {
+			lastActivity = "never"
+		}
+		_, _ = fmt.Fprintf(w, "Seat:  plan %s, created %s, last activity %s\n", s.Plan, s.CreatedAt, lastActivity)
+		_, _ = fmt.Fprintf(w, "       assigning team %s\n", team)
+	} else {
+		_, _ = fmt.Fprintln(w, "Seat:  none — this login is not a Copilot seat holder, so it is not assigned")
+	}
+
+	_, _ = fmt.Fprintf(w, "\nConfiguration (%s mode):\n", res.Mode)
+	switch res.Mode {
+	case "teams":
+		if len(res.Teams) == 0 {
+			_, _ = fmt.Fprintln(w, "  in no mapped team")
+		} else {
+			_, _ = fmt.Fprintf(w, "  teams: %s\n", strings.Join(res.Teams, ", "))
+		}
+	case "assigning-team":
+		_, _ = fmt.Fprintln(w, "  assigned by the team that granted the seat")
+	default:
+		_, _ = fmt.Fprintf(w, "  PRU exception: %v\n", res.PRUException)
+		for _, m := range res.Matched {
+			_, _ = fmt.Fprintf(w, "  matches tier %s\n", m)
+		}
+		if res.Override != "" {
+			_, _ = fmt.Fprintf(w, "  user_overrides: %s\n", res.Override)
+		} else {
+			_, _ = fmt.Fprintf(w, "  tier: %s\n", res.Tier)
+		}
+		if res.SkipReason != "" {
+			_, _ = fmt.Fprintf(w, "  left out of assignment: %s\n", res.SkipReason)
+		}
+	}
+
+	_, _ = fmt.Fprintln(w)
+	if res.DesiredCostCenter != "" {
+		_, _ = fmt.Fprintf(w, "Desired cost center: %s\n", ccLabel(res.DesiredCostCenter, res.DesiredCostCenterID))
+	} else {
+		_, _ = fmt.Fprintln(w, "Desired cost center: none")
+	}
+	if res.ActualCostCenterID != "" {
+		_, _ = fmt.Fprintf(w, "Actual cost center:  %s\n", ccLabel(res.ActualCostCenter, res.ActualCostCenterID))
+	} else {
+		_, _ = fmt.Fprintln(w, "Actual cost center:  none")
+	}
+}
+
+// ccLabel formats a cost center as "name (id)", or just the name when it
+// does not exist yet.
+func ccLabel(name, id string) string {
+	if id == "" {
+		return name + " (not created yet)"
+	}
+	if name == "" {
+		return id
+	}
+	return fmt.Sprintf("%s (%s)", name, id)
+}
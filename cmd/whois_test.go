@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// whoisTestServer serves the seats of alice and bob, three cost centers,
+// and bob's current membership of "No PRUs".
+func whoisTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/copilot/billing/seats"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"total_seats": 2, "seats": []map[string]any{
+				{"assignee": map[string]string{"login": "alice", "type": "User"}, "plan": "business", "created_at": "2024-01-01T00:00:00Z"},
+				{"assignee": map[string]string{"login": "bob", "type": "User"}, "plan": "enterprise", "created_at": "2025-06-01T00:00:00Z"},
+			}})
+		case strings.HasSuffix(r.URL.Path, "/cost-centers"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"costCenters": []map[string]string{
+				{"id": testCCID, "name": "No PRUs", "state": "active"},
+				{"id": testPRUCCID, "name": "PRUs Allowed", "state": "active"},
+				{"id": testOtherCCID, "name": "Platform", "state": "active"},
+			}})
+		case strings.HasSuffix(r.URL.Path, "/memberships"):
+			var ms []map[string]any
+			if r.URL.Query().Get("name") == "bob" {
+				ms = append(ms, map[string]any{"cost_center": map[string]string{"id": testCCID, "name": "No PRUs"}})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"memberships": ms})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWhois_ExceptionUser(t *testing.T) {
+	srv := whoisTestServer(t)
+	setupPRUAssign(t, srv, "plan")
+
+	res, err := whois(newTestGitHubClient(t, srv.URL), "ALICE", quietLogger())
+	if err != nil {
+		t.Fatalf("whois: %v", err)
+	}
+	if !res.SeatHolder || res.Login != "alice" || res.Seat.Plan != "business" {
+		t.Errorf("seat: %+v", res)
+	}
+	if !res.PRUException || len(res.Matched) != 1 || !strings.HasSuffix(res.Matched[0], ": alice") {
+		t.Errorf("exception match: %+v", res)
+	}
+	if res.DesiredCostCenter != "PRUs Allowed" || res.DesiredCostCenterID != testPRUCCID {
+		t.Errorf("desired = %s (%s), want PRUs Allowed", res.DesiredCostCenter, res.DesiredCostCenterID)
+	}
+	if res.ActualCostCenterID != "" {
+		t.Errorf("actual = %s, want none", res.ActualCostCenterID)
+	}
+}
+
+func TestWhois_OverrideUser(t *testing.T) {
+	srv := whoisTestServer(t)
+	setupPRUAssign(t, srv, "plan")
+	cfgManager.PRUsUserOverrides = map[string]string{"bob": "Platform"}
+
+	res, err := whois(newTestGitHubClient(t, srv.URL), "bob", quietLogger())
+	if err != nil {
+		t.Fatalf("whois: %v", err)
+	}
+	if res.Override != "Platform" || res.PRUException {
+		t.Errorf("override: %+v", res)
+	}
+	if res.DesiredCostCenterID != testOtherCCID || res.ActualCostCenter != "No PRUs" || res.ActualCostCenterID != testCCID {
+		t.Errorf("desired %s / actual %s (%s); want Platform desired, No PRUs actual",
+			res.DesiredCostCenterID, res.ActualCostCenter, res.ActualCostCenterID)
+	}
+
+	var buf bytes.Buffer
+	printWhois(&buf, res)
+	for _, want := range []string{"user_overrides: Platform", "Desired cost center: Platform (" + testOtherCCID + ")", "Actual cost center:  No PRUs"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestWhois_NotASeatHolder(t *testing.T) {
+	srv := whoisTestServer(t)
+	setupPRUAssign(t, srv, "plan")
+
+	res, err := whois(newTestGitHubClient(t, srv.URL), "mallory", quietLogger())
+	if err != nil {
+		t.Fatalf("whois: %v", err)
+	}
+	if res.SeatHolder || res.Seat != nil || res.DesiredCostCenter != "" || res.PRUException {
+		t.Errorf("unmatched user: %+v", res)
+	}
+	if res.Tier == "" {
+		t.Errorf("tier = %q, want the default tier the login would fall into", res.Tier)
+	}
+
+	var buf bytes.Buffer
+	printWhois(&buf, res)
+	if !strings.Contains(buf.String(), "not a Copilot seat holder") || !strings.Contains(buf.String(), "Desired cost center: none") {
+		t.Errorf("output:\n%s", buf.String())
+	}
+}
+
+func quietLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
@@ -0,0 +1,94 @@
+// Package actions integrates gh-cost-center with GitHub Actions workflows.
+//
+// When running inside a workflow, commands can append a markdown job summary
+// to the file named by GITHUB_STEP_SUMMARY and emit ::warning:: / ::error::
+// workflow commands, which Actions shows as annotations on the run.  Outside
+// Actions every function here is a no-op.
+package actions
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Environment variables set by the Actions runner.
+const (
+	envActions     = "GITHUB_ACTIONS"
+	envStepSummary = "GITHUB_STEP_SUMMARY"
+)
+
+// Running reports whether the process runs inside a GitHub Actions job.
+func Running() bool {
+	return os.Getenv(envActions) == "true" || os.Getenv(envStepSummary) != ""
+}
+
+// AppendSummary appends markdown to the job's step summary file.  It does
+// nothing when GITHUB_STEP_SUMMARY is not set.
+func AppendSummary(markdown string) error {
+	path := os.Getenv(envStepSummary)
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening step summary: %w", err)
+	}
+	if !strings.HasSuffix(markdown, "\n") {
+		markdown += "\n"
+	}
+	if _, err := f.WriteString(markdown); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing step summary: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing step summary: %w", err)
+	}
+	return nil
+}
+
+// Warning writes a ::warning:: workflow command for message to w.
+func Warning(w io.Writer, message string) {
+	annotate(w, "warning", message)
+}
+
+// Error writes an ::error:: workflow command for message to w.
+func Error(w io.Writer, message string) {
+	annotate(w, "error", message)
+}
+
+func annotate(w io.Writer, level, message string) {
+	if !Running() {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "::%s::%s\n", level, escapeData(message))
+}
+
+// escapeData escapes a workflow command message so that it stays on one line
+// and is not cut at a '%'.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// Table renders a markdown table.  Pipes in cells are escaped.
+func Table(headers []string, rows [][]string) string {
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for _, c := range cells {
+			b.WriteString(" " + strings.ReplaceAll(c, "|", `\|`) + " |")
+		}
+		b.WriteString("\n")
+	}
+	writeRow(headers)
+	b.WriteString("|")
+	for range headers {
+		b.WriteString(" --- |")
+	}
+	b.WriteString("\n")
+	for _, r := range rows {
+		writeRow(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package actions
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAppendSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", path)
+
+	if err := AppendSummary("## One"); err != nil {
+		t.Fatalf("AppendSummary: %v", err)
+	}
+	if err := AppendSummary("## Two\n"); err != nil {
+		t.Fatalf("AppendSummary: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "## One\n## Two\n"; got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+}
+
+func TestOutsideActionsIsNoOp(t *testing.T) {
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	t.Setenv("GITHUB_ACTIONS", "")
+
+	if Running() {
+		t.Error("Running() = true outside Actions")
+	}
+	if err := AppendSummary("## ignored"); err != nil {
+		t.Fatalf("AppendSummary: %v", err)
+	}
+	var buf bytes.Buffer
+	Error(&buf, "boom")
+	Warning(&buf, "careful")
+	if buf.Len() != 0 {
+		t.Errorf("annotations written outside Actions: %q", buf.String())
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	t.Setenv("GITHUB_ACTIONS", "true")
+
+	var buf bytes.Buffer
+	Error(&buf, "2 of 3 users failed\nretry: 100%")
+	Warning(&buf, "drift")
+	want := "::error::2 of 3 users failed%0Aretry: 100%25\n::warning::drift\n"
+	if buf.String() != want {
+		t.Errorf("annotations = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestTable(t *testing.T) {
+	got := Table([]string{"Cost center", "Users"}, [][]string{{"a|b", "2"}})
+	want := "| Cost center | Users |\n| --- | --- |\n| a\\|b | 2 |\n"
+	if got != want {
+		t.Errorf("Table = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,136 @@
+// Package auditlog keeps an append-only trail of the changes gh-cost-center
+// makes through the API.  Each mutation is one JSON line in
+// <export_dir>/audit.log; lines are only ever appended, never rewritten.
+package auditlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileName is the name of the audit trail under the export directory.
+const FileName = "audit.log"
+
+// Results of an entry.
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Entry is one line of the audit trail.
+type Entry struct {
+	Time       time.Time `json:"time"`
+	Actor      string    `json:"actor,omitempty"` // login of the token owner, when known
+	Enterprise string    `json:"enterprise"`
+	Operation  string    `json:"operation"`
+	Target     string    `json:"target"`
+	Items      []string  `json:"items,omitempty"`
+	Detail     string    `json:"detail,omitempty"`
+	Result     string    `json:"result"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Log appends entries to an audit trail file.  The file is created and the
+// actor resolved on the first Append, so a run that changes nothing leaves
+// no trace.  It is safe for concurrent use.
+type Log struct {
+	path       string
+	enterprise string
+	now        func() time.Time
+
+	actorOnce sync.Once
+	resolve   func() string
+	actor     string
+
+	mu sync.Mutex
+}
+
+// New returns a Log appending to path.  actor is called once, on the first
+// Append, to name the token owner; it may return "".
+func New(path, enterprise string, actor func() string) *Log {
+	return &Log{path: path, enterprise: enterprise, now: time.Now, resolve: actor}
+}
+
+// Append records one mutation; opErr is nil when it succeeded.
+func (l *Log) Append(operation, target string, items []string, detail string, opErr error) error {
+	l.actorOnce.Do(func() {
+		if l.resolve != nil {
+			l.actor = l.resolve()
+		}
+	})
+	e := Entry{
+		Time:       l.now().UTC(),
+		Actor:      l.actor,
+		Enterprise: l.enterprise,
+		Operation:  operation,
+		Target:     target,
+		Items:      items,
+		Detail:     detail,
+		Result:     ResultSuccess,
+	}
+	if opErr != nil {
+		e.Result, e.Error = ResultFailure, opErr.Error()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding audit entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return fmt.Errorf("creating audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening audit log: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing audit log: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing audit log: %w", err)
+	}
+	return nil
+}
+
+// Read returns the entries of the trail at path recorded at or after since,
+// oldest first.  A missing file has no entries.  Lines that do not parse are
+// skipped and counted in skipped.
+func Read(path string, since time.Time) (entries []Entry, skipped int, err error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("opening audit log: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			skipped++
+			continue
+		}
+		if !e.Time.Before(since) {
+			entries = append(entries, e)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, 0, fmt.Errorf("reading audit log: %w", err)
+	}
+	return entries, skipped, nil
+}
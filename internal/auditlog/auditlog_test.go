@@ -0,0 +1,93 @@
+package auditlog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppend_LineFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exports", FileName)
+	resolved := 0
+	l := New(path, "test-ent", func() string { resolved++; return "octocat" })
+	l.now = func() time.Time { return time.Date(2026, 1, 2, 16, 4, 5, 0, time.FixedZone("CET", 3600)) }
+
+	if err := l.Append("add_users", "cc-1", []string{"alice", "bob"}, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Append("create_budget", "cc-1", nil, "product_sku=copilot amount=100", errors.New("API error 422")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"time":"2026-01-02T15:04:05Z","actor":"octocat","enterprise":"test-ent","operation":"add_users","target":"cc-1","items":["alice","bob"],"result":"success"}` + "\n" +
+		`{"time":"2026-01-02T15:04:05Z","actor":"octocat","enterprise":"test-ent","operation":"create_budget","target":"cc-1","detail":"product_sku=copilot amount=100","result":"failure","error":"API error 422"}` + "\n"
+	if string(data) != want {
+		t.Errorf("audit log =\n%s\nwant\n%s", data, want)
+	}
+	if resolved != 1 {
+		t.Errorf("actor resolved %d times, want once", resolved)
+	}
+}
+
+func TestAppend_KeepsExistingLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("{\"operation\":\"earlier\"}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := New(path, "test-ent", nil).Append("remove_users", "cc-1", []string{"carol"}, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || lines[0] != `{"operation":"earlier"}` || strings.Contains(lines[1], `"actor"`) {
+		t.Errorf("lines = %q, want the earlier line kept and no actor", lines)
+	}
+}
+
+func TestRead_Since(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	l := New(path, "test-ent", nil)
+	for _, d := range []int{0, 5, 10} {
+		l.now = func() time.Time { return base.AddDate(0, 0, d) }
+		if err := l.Append("add_users", "cc-1", []string{"u"}, "", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("not json\n\n")
+	_ = f.Close()
+
+	entries, skipped, err := Read(path, base.AddDate(0, 0, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || !entries[0].Time.Equal(base.AddDate(0, 0, 5)) || !entries[1].Time.Equal(base.AddDate(0, 0, 10)) {
+		t.Errorf("entries = %+v, want the ones from day 5 on", entries)
+	}
+	if skipped != 1 {
+		t.Errorf("skipped = %d, want the malformed line", skipped)
+	}
+
+	all, _, err := Read(path, time.Time{})
+	if err != nil || len(all) != 3 {
+		t.Errorf("Read(zero) = %d entries, %v; want all 3", len(all), err)
+	}
+}
+
+func TestRead_Missing(t *testing.T) {
+	entries, skipped, err := Read(filepath.Join(t.TempDir(), FileName), time.Time{})
+	if err != nil || entries != nil || skipped != 0 {
+		t.Errorf("Read(missing) = %v, %d, %v; want nothing", entries, skipped, err)
+	}
+}
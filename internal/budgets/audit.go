@@ -0,0 +1,132 @@
+package budgets
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+// CostCenterBudgets is the budget status of one active cost center.
+type CostCenterBudgets struct {
+	Name    string
+	ID      string
+	Budgets []github.Budget // sorted by SKU
+	Issues  []string        // empty when the budgets match the configuration
+}
+
+// Audit joins the active cost centers (name → ID, as returned by
+// GetAllActiveCostCenters) with the cost-center-scoped budgets and flags cost
+// centers without a budget, with several budgets for the same SKU, or with a
+// budget whose amount differs from the enabled product's configured amount.
+// Budgets are matched by entity name against both the cost center name and
+// ID because the API may store either.  The result is sorted by name.
+func Audit(active map[string]string, budgets []github.Budget, products map[string]config.ProductBudget) []CostCenterBudgets {
+	byEntity := make(map[string][]github.Budget)
+	for _, b := range budgets {
+		if b.BudgetScope == "cost_center" {
+			byEntity[b.BudgetEntityName] = append(byEntity[b.BudgetEntityName], b)
+		}
+	}
+
+	// Configured amount per SKU, for enabled products only.
+	expected := make(map[string]int, len(products))
+	for product, pb := range products {
+		if pb.Enabled {
+			_, sku := github.GetBudgetTypeAndSKU(product)
+			expected[sku] = pb.Amount
+		}
+	}
+
+	out := make([]CostCenterBudgets, 0, len(active))
+	for name, id := range active {
+		cc := CostCenterBudgets{Name: name, ID: id}
+		cc.Budgets = append(cc.Budgets, byEntity[id]...)
+		if name != id {
+			cc.Budgets = append(cc.Budgets, byEntity[name]...)
+		}
+		sort.SliceStable(cc.Budgets, func(i, j int) bool {
+			return cc.Budgets[i].BudgetProductSKU < cc.Budgets[j].BudgetProductSKU
+		})
+
+		if len(cc.Budgets) == 0 {
+			cc.Issues = append(cc.Issues, "no budget")
+		}
+		perSKU := make(map[string]int, len(cc.Budgets))
+		for _, b := range cc.Budgets {
+			perSKU[b.BudgetProductSKU]++
+		}
+		for i, b := range cc.Budgets {
+			sku := b.BudgetProductSKU
+			if n := perSKU[sku]; n > 1 && (i == 0 || cc.Budgets[i-1].BudgetProductSKU != sku) {
+				cc.Issues = append(cc.Issues, fmt.Sprintf("%d budgets for %s", n, sku))
+			}
+			if want, ok := expected[sku]; ok && b.BudgetAmount != want {
+				cc.Issues = append(cc.Issues, fmt.Sprintf("%s budget is %d, configured %d", sku, b.BudgetAmount, want))
+			}
+		}
+		out = append(out, cc)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Change is one budget difference found by PlanReconcile.
+type Change struct {
+	CostCenterName string
+	CostCenterID   string
+	Product        string // budgets.products key
+	SKU            string
+	BudgetID       string // existing budget to update; "" to create one
+	From           int    // current amount when updating
+	To             int    // configured amount
+}
+
+// IsCreate reports whether the change creates a missing budget.
+func (c Change) IsCreate() bool { return c.BudgetID == "" }
+
+// PlanReconcile compares the enabled products with the budgets of the given
+// cost centers (name → ID) and returns a create for every missing product
+// budget and an update for every budget whose amount differs.  Changes are
+// sorted by cost center name, then product.
+func PlanReconcile(costCenters map[string]string, budgets []github.Budget, products map[string]config.ProductBudget) []Change {
+	productNames := make([]string, 0, len(products))
+	for name, pb := range products {
+		if pb.Enabled {
+			productNames = append(productNames, name)
+		}
+	}
+	sort.Strings(productNames)
+
+	ccNames := make([]string, 0, len(costCenters))
+	for name := range costCenters {
+		ccNames = append(ccNames, name)
+	}
+	sort.Strings(ccNames)
+
+	var changes []Change
+	for _, name := range ccNames {
+		id := costCenters[name]
+		for _, product := range productNames {
+			_, sku := github.GetBudgetTypeAndSKU(product)
+			want := products[product].Amount
+			found := false
+			for _, b := range budgets {
+				if b.BudgetScope != "cost_center" || b.BudgetProductSKU != sku ||
+					(b.BudgetEntityName != id && b.BudgetEntityName != name) {
+					continue
+				}
+				found = true
+				if b.BudgetAmount != want {
+					changes = append(changes, Change{CostCenterName: name, CostCenterID: id, Product: product,
+						SKU: sku, BudgetID: b.ID, From: b.BudgetAmount, To: want})
+				}
+			}
+			if !found {
+				changes = append(changes, Change{CostCenterName: name, CostCenterID: id, Product: product, SKU: sku, To: want})
+			}
+		}
+	}
+	return changes
+}
@@ -1,5 +1,6 @@
 // Package budgets provides helper functions for creating product budgets for
-// newly-created cost centers.  It wraps the lower-level github.Client budget
+// newly-created cost centers and for comparing existing budgets with the
+// configuration.  It wraps the lower-level github.CostCenterAPI budget
 // operations and handles the case where the Budgets API is unavailable.
 package budgets
 
@@ -14,14 +15,14 @@ import (
 
 // Manager orchestrates product-budget creation for cost centers.
 type Manager struct {
-	client      *github.Client
+	client      github.CostCenterAPI
 	log         *slog.Logger
 	products    map[string]config.ProductBudget
 	unavailable bool
 }
 
 // NewManager creates a budget manager from a GitHub client, logger, and product budget map.
-func NewManager(client *github.Client, logger *slog.Logger, products map[string]config.ProductBudget) *Manager {
+func NewManager(client github.CostCenterAPI, logger *slog.Logger, products map[string]config.ProductBudget) *Manager {
 	return &Manager{
 		client:   client,
 		log:      logger,
@@ -51,7 +52,7 @@ func (m *Manager) EnsureBudgetsForCostCenter(ccID, ccName string) error {
 			continue
 		}
 
-		ok, err := m.client.CreateProductBudget(ccID, ccName, product, pc.Amount)
+		ok, err := m.client.CreateProductBudget(ccID, ccName, product, pc.Amount, github.AlertingFromConfig(pc))
 		if err != nil {
 			if _, uaErr := err.(*github.BudgetsAPIUnavailableError); uaErr {
 				m.log.Warn("Budgets API unavailable, disabling budget creation",
@@ -239,3 +239,105 @@ func TestEnsureBudgets_NoEnabledProducts(t *testing.T) {
 
 // Ensure the test client builder uses a short timeout so tests don't hang.
 var _ = time.Second
+
+func TestAudit(t *testing.T) {
+	active := map[string]string{
+		"Platform": "id-platform",
+		"Data":     "id-data",
+		"Mobile":   "id-mobile",
+		"Web":      "id-web",
+	}
+	budgets := []github.Budget{
+		// Platform: matches the configuration, one by ID and one by name.
+		{ID: "b1", BudgetScope: "cost_center", BudgetEntityName: "id-platform", BudgetProductSKU: "copilot", BudgetAmount: 100},
+		{ID: "b2", BudgetScope: "cost_center", BudgetEntityName: "Platform", BudgetProductSKU: "actions", BudgetAmount: 125},
+		// Data: duplicate copilot budgets, one with the wrong amount.
+		{ID: "b3", BudgetScope: "cost_center", BudgetEntityName: "id-data", BudgetProductSKU: "copilot", BudgetAmount: 100},
+		{ID: "b4", BudgetScope: "cost_center", BudgetEntityName: "Data", BudgetProductSKU: "copilot", BudgetAmount: 300},
+		// Web: an SKU without a configured product is not compared.
+		{ID: "b5", BudgetScope: "cost_center", BudgetEntityName: "id-web", BudgetProductSKU: "packages", BudgetAmount: 7},
+		// Mobile has none; enterprise-scoped and orphaned budgets are ignored.
+		{ID: "b6", BudgetScope: "enterprise", BudgetEntityName: "test-ent", BudgetProductSKU: "copilot", BudgetAmount: 1},
+		{ID: "b7", BudgetScope: "cost_center", BudgetEntityName: "Gone", BudgetProductSKU: "copilot", BudgetAmount: 1},
+	}
+	products := map[string]config.ProductBudget{
+		"copilot":  {Amount: 100, Enabled: true},
+		"actions":  {Amount: 125, Enabled: true},
+		"packages": {Amount: 50, Enabled: false},
+	}
+
+	got := Audit(active, budgets, products)
+	if len(got) != 4 {
+		t.Fatalf("got %d cost centers, want 4", len(got))
+	}
+	byName := map[string]CostCenterBudgets{}
+	var names []string
+	for _, cc := range got {
+		byName[cc.Name] = cc
+		names = append(names, cc.Name)
+	}
+	if strings.Join(names, ",") != "Data,Mobile,Platform,Web" {
+		t.Errorf("order = %v, want sorted by name", names)
+	}
+
+	tests := []struct {
+		name    string
+		budgets int
+		issues  string
+	}{
+		{"Platform", 2, ""},
+		{"Data", 2, "2 budgets for copilot; copilot budget is 300, configured 100"},
+		{"Mobile", 0, "no budget"},
+		{"Web", 1, ""},
+	}
+	for _, tt := range tests {
+		cc := byName[tt.name]
+		if len(cc.Budgets) != tt.budgets {
+			t.Errorf("%s: %d budgets, want %d", tt.name, len(cc.Budgets), tt.budgets)
+		}
+		if got := strings.Join(cc.Issues, "; "); got != tt.issues {
+			t.Errorf("%s: issues = %q, want %q", tt.name, got, tt.issues)
+		}
+	}
+	if p := byName["Platform"]; p.ID != "id-platform" || p.Budgets[0].BudgetProductSKU != "actions" {
+		t.Errorf("Platform = %+v, want its budgets sorted by SKU", p)
+	}
+}
+
+func TestPlanReconcile(t *testing.T) {
+	costCenters := map[string]string{"Platform": "id-platform", "Data": "id-data"}
+	budgets := []github.Budget{
+		{ID: "b1", BudgetScope: "cost_center", BudgetEntityName: "id-platform", BudgetProductSKU: "copilot", BudgetAmount: 100},
+		{ID: "b2", BudgetScope: "cost_center", BudgetEntityName: "Platform", BudgetProductSKU: "actions", BudgetAmount: 50},
+		{ID: "b3", BudgetScope: "cost_center", BudgetEntityName: "id-data", BudgetProductSKU: "actions", BudgetAmount: 125},
+		// Another cost center's and an org budget never count.
+		{ID: "b4", BudgetScope: "cost_center", BudgetEntityName: "Other", BudgetProductSKU: "copilot", BudgetAmount: 100},
+		{ID: "b5", BudgetScope: "organization", BudgetEntityName: "id-data", BudgetProductSKU: "copilot", BudgetAmount: 100},
+	}
+	products := map[string]config.ProductBudget{
+		"copilot":  {Amount: 100, Enabled: true},
+		"actions":  {Amount: 125, Enabled: true},
+		"packages": {Amount: 10, Enabled: false},
+	}
+
+	got := PlanReconcile(costCenters, budgets, products)
+	want := []Change{
+		{CostCenterName: "Data", CostCenterID: "id-data", Product: "copilot", SKU: "copilot", To: 100},
+		{CostCenterName: "Platform", CostCenterID: "id-platform", Product: "actions", SKU: "actions", BudgetID: "b2", From: 50, To: 125},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("changes = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if !got[0].IsCreate() || got[1].IsCreate() {
+		t.Errorf("IsCreate = %v, %v; want create then update", got[0].IsCreate(), got[1].IsCreate())
+	}
+
+	if changes := PlanReconcile(costCenters, nil, map[string]config.ProductBudget{}); len(changes) != 0 {
+		t.Errorf("no enabled products should plan nothing, got %+v", changes)
+	}
+}
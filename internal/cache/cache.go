@@ -1,6 +1,8 @@
 // Package cache provides a file-based cost center cache that reduces
-// API calls on repeated runs.  Each entry has a configurable TTL
-// (default 24 hours) and the cache is stored as JSON.
+// API calls on repeated runs.  It holds cost center name → ID lookups and
+// whole API responses (the active cost center list, org property schemas),
+// each kind in its own JSON file.  Entries are timestamped and expire after a
+// configurable TTL (default 24 hours).
 package cache
 
 import (
@@ -9,6 +11,7 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 )
@@ -32,7 +35,8 @@ type Entry struct {
 	TTLHours int       `json:"ttl_hours"`
 }
 
-// IsExpired reports whether the entry has exceeded its TTL.
+// IsExpired reports whether the entry has exceeded the TTL it was cached
+// with.  The cache itself applies its current TTL (see SetTTL).
 func (e Entry) IsExpired() bool {
 	ttl := time.Duration(e.TTLHours) * time.Hour
 	return time.Since(e.CachedAt) > ttl
@@ -44,21 +48,37 @@ type cacheData struct {
 	Entries map[string]Entry `json:"entries"`
 }
 
-// Stats holds cache statistics for display.
+// Stats holds cache statistics for display.  The top-level counts cover
+// every kind; FilePath and FileSizeBytes describe the name → ID file.
 type Stats struct {
 	TotalEntries   int
 	ExpiredEntries int
 	ValidEntries   int
 	FilePath       string
 	FileSizeBytes  int64
+	TTL            time.Duration
+	Kinds          []KindStats // one per kind, sorted by name
+}
+
+// KindStats holds the statistics of one kind of cached data.
+type KindStats struct {
+	Kind           string
+	FilePath       string
+	FileSizeBytes  int64
+	TotalEntries   int
+	ExpiredEntries int
+	OldestAge      time.Duration // zero without entries
+	NewestAge      time.Duration
 }
 
 // Cache is a file-backed cost center cache.
 type Cache struct {
 	mu       sync.Mutex
+	dir      string
 	filePath string
-	ttlHours int
+	ttl      time.Duration
 	data     cacheData
+	docs     map[string]*docData // kind -> cached responses
 	log      *slog.Logger
 }
 
@@ -71,22 +91,43 @@ func New(dir string, logger *slog.Logger) (*Cache, error) {
 	path := filepath.Join(dir, DefaultCacheFile)
 
 	c := &Cache{
+		dir:      dir,
 		filePath: path,
-		ttlHours: DefaultTTLHours,
+		ttl:      DefaultTTLHours * time.Hour,
 		log:      logger,
 		data: cacheData{
 			Version: currentVersion,
 			Entries: make(map[string]Entry),
 		},
+		docs: make(map[string]*docData, len(kinds)),
 	}
 
 	if err := c.load(); err != nil {
-		c.log.Debug("No existing cache file, starting fresh", "path", path, "error", err)
+		c.log.Debug("No usable cache file, starting fresh", "path", path, "error", err)
+	}
+	for _, kind := range kinds {
+		c.docs[kind] = c.loadDocs(kind)
 	}
 
 	return c, nil
 }
 
+// SetTTL sets how long entries stay valid, measured from when each was
+// cached.  Non-positive values are ignored.
+func (c *Cache) SetTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
+}
+
+// expired reports whether something cached at cachedAt is past the TTL.
+func (c *Cache) expired(cachedAt time.Time) bool {
+	return time.Since(cachedAt) > c.ttl
+}
+
 // Get retrieves a cached entry by key.  Returns the entry and true if
 // a valid (non-expired) entry exists, or a zero Entry and false otherwise.
 func (c *Cache) Get(key string) (Entry, bool) {
@@ -97,7 +138,7 @@ func (c *Cache) Get(key string) (Entry, bool) {
 	if !ok {
 		return Entry{}, false
 	}
-	if e.IsExpired() {
+	if c.expired(e.CachedAt) {
 		c.log.Debug("Cache entry expired", "key", key)
 		return Entry{}, false
 	}
@@ -114,38 +155,91 @@ func (c *Cache) Set(key, id, name string) error {
 		ID:       id,
 		Name:     name,
 		CachedAt: time.Now().UTC(),
-		TTLHours: c.ttlHours,
+		TTLHours: int(c.ttl / time.Hour),
 	}
 	c.log.Debug("Cache set", "key", key, "id", id)
 	return c.save()
 }
 
+// DeleteByID removes every entry whose cached ID matches id and flushes to
+// disk when anything was removed.
+func (c *Cache) DeleteByID(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for key, e := range c.data.Entries {
+		if e.ID == id {
+			delete(c.data.Entries, key)
+			removed++
+		}
+	}
+	if removed == 0 {
+		return nil
+	}
+	c.log.Debug("Cache entries deleted", "id", id, "removed", removed)
+	return c.save()
+}
+
 // GetStats returns statistics about the current cache.
 func (c *Cache) GetStats() Stats {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	s := Stats{
-		TotalEntries: len(c.data.Entries),
-		FilePath:     c.filePath,
+	names := KindStats{Kind: KindCostCenterNames, FilePath: c.filePath}
+	cachedAt := make([]time.Time, 0, len(c.data.Entries))
+	for _, e := range c.data.Entries {
+		cachedAt = append(cachedAt, e.CachedAt)
 	}
+	c.fillKindStats(&names, cachedAt)
 
-	for _, e := range c.data.Entries {
-		if e.IsExpired() {
-			s.ExpiredEntries++
-		} else {
-			s.ValidEntries++
+	s := Stats{
+		FilePath:      c.filePath,
+		FileSizeBytes: names.FileSizeBytes,
+		TTL:           c.ttl,
+		Kinds:         []KindStats{names},
+	}
+	for _, kind := range kinds {
+		ks := KindStats{Kind: kind, FilePath: c.docPath(kind)}
+		cachedAt = cachedAt[:0]
+		for _, e := range c.docs[kind].Entries {
+			cachedAt = append(cachedAt, e.CachedAt)
 		}
+		c.fillKindStats(&ks, cachedAt)
+		s.Kinds = append(s.Kinds, ks)
 	}
+	sort.Slice(s.Kinds, func(i, j int) bool { return s.Kinds[i].Kind < s.Kinds[j].Kind })
 
-	if info, err := os.Stat(c.filePath); err == nil {
-		s.FileSizeBytes = info.Size()
+	for _, ks := range s.Kinds {
+		s.TotalEntries += ks.TotalEntries
+		s.ExpiredEntries += ks.ExpiredEntries
 	}
-
+	s.ValidEntries = s.TotalEntries - s.ExpiredEntries
 	return s
 }
 
-// Clear removes all cache entries and deletes the cache file.
+// fillKindStats sets the entry counts, ages, and file size of ks from the
+// times its entries were cached.
+func (c *Cache) fillKindStats(ks *KindStats, cachedAt []time.Time) {
+	ks.TotalEntries = len(cachedAt)
+	for i, t := range cachedAt {
+		if c.expired(t) {
+			ks.ExpiredEntries++
+		}
+		age := time.Since(t)
+		if i == 0 || age > ks.OldestAge {
+			ks.OldestAge = age
+		}
+		if i == 0 || age < ks.NewestAge {
+			ks.NewestAge = age
+		}
+	}
+	if info, err := os.Stat(ks.FilePath); err == nil {
+		ks.FileSizeBytes = info.Size()
+	}
+}
+
+// Clear removes all cache entries and deletes the cache files.
 func (c *Cache) Clear() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -156,6 +250,12 @@ func (c *Cache) Clear() error {
 	if err := os.Remove(c.filePath); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("removing cache file: %w", err)
 	}
+	for _, kind := range kinds {
+		c.docs[kind] = newDocData()
+		if err := os.Remove(c.docPath(kind)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing cache file: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -167,7 +267,7 @@ func (c *Cache) CleanupExpired() (int, error) {
 
 	removed := 0
 	for key, e := range c.data.Entries {
-		if e.IsExpired() {
+		if c.expired(e.CachedAt) {
 			delete(c.data.Entries, key)
 			removed++
 			c.log.Debug("Removed expired entry", "key", key)
@@ -180,6 +280,23 @@ func (c *Cache) CleanupExpired() (int, error) {
 		}
 	}
 
+	for _, kind := range kinds {
+		n := 0
+		for key, e := range c.docs[kind].Entries {
+			if c.expired(e.CachedAt) {
+				delete(c.docs[kind].Entries, key)
+				n++
+				c.log.Debug("Removed expired entry", "kind", kind, "key", key)
+			}
+		}
+		if n > 0 {
+			if err := c.saveDocs(kind); err != nil {
+				return removed + n, err
+			}
+		}
+		removed += n
+	}
+
 	c.log.Debug("Cleanup complete", "removed", removed, "remaining", len(c.data.Entries))
 	return removed, nil
 }
@@ -220,12 +337,21 @@ func (c *Cache) load() error {
 
 // save writes the cache data to disk, creating the directory if needed.
 func (c *Cache) save() error {
-	dir := filepath.Dir(c.filePath)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
+	if err := writeJSON(c.filePath, c.data); err != nil {
+		return err
+	}
+	c.log.Debug("Cache saved", "entries", len(c.data.Entries), "path", c.filePath)
+	return nil
+}
+
+// writeJSON writes v as indented JSON to path, creating its directory if
+// needed.
+func writeJSON(path string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return fmt.Errorf("creating cache directory: %w", err)
 	}
 
-	f, err := os.Create(c.filePath)
+	f, err := os.Create(path)
 	if err != nil {
 		return fmt.Errorf("creating cache file: %w", err)
 	}
@@ -233,10 +359,8 @@ func (c *Cache) save() error {
 
 	enc := json.NewEncoder(f)
 	enc.SetIndent("", "  ")
-	if err := enc.Encode(c.data); err != nil {
+	if err := enc.Encode(v); err != nil {
 		return fmt.Errorf("encoding cache file: %w", err)
 	}
-
-	c.log.Debug("Cache saved", "entries", len(c.data.Entries), "path", c.filePath)
 	return nil
 }
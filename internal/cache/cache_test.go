@@ -240,3 +240,178 @@ func TestNew_DefaultDir(t *testing.T) {
 		t.Errorf("expected default path, got %q", c.filePath)
 	}
 }
+
+func TestDeleteByID(t *testing.T) {
+	dir := t.TempDir()
+	c, _ := New(dir, testLogger())
+	_ = c.Set("a", "uuid-1", "A")
+	_ = c.Set("b", "uuid-2", "B")
+
+	if err := c.DeleteByID("uuid-1"); err != nil {
+		t.Fatalf("DeleteByID: %v", err)
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected entry a to be removed")
+	}
+	if _, ok := c.Get("b"); !ok {
+		t.Error("expected entry b to remain")
+	}
+
+	reloaded, _ := New(dir, testLogger())
+	if _, ok := reloaded.Get("a"); ok {
+		t.Error("deletion was not persisted")
+	}
+}
+
+func TestSetTTL(t *testing.T) {
+	dir := t.TempDir()
+	c, _ := New(dir, testLogger())
+	c.SetTTL(time.Hour)
+
+	c.data.Entries["recent"] = Entry{ID: "id-1", CachedAt: time.Now().Add(-30 * time.Minute), TTLHours: DefaultTTLHours}
+	c.data.Entries["old"] = Entry{ID: "id-2", CachedAt: time.Now().Add(-2 * time.Hour), TTLHours: DefaultTTLHours}
+	if _, ok := c.Get("recent"); !ok {
+		t.Error("expected a hit for an entry younger than the TTL")
+	}
+	if _, ok := c.Get("old"); ok {
+		t.Error("expected the configured TTL, not the stored 24h, to expire the entry")
+	}
+
+	c.SetTTL(0) // ignored
+	if c.ttl != time.Hour {
+		t.Errorf("ttl = %s, want non-positive values ignored", c.ttl)
+	}
+}
+
+func TestJSON_SetGetAndExpiry(t *testing.T) {
+	dir := t.TempDir()
+	c, _ := New(dir, testLogger())
+
+	want := map[string]string{"Platform": "uuid-1"}
+	if err := c.SetJSON(KindActiveCostCenters, "ent", want); err != nil {
+		t.Fatalf("SetJSON: %v", err)
+	}
+	var got map[string]string
+	if !c.GetJSON(KindActiveCostCenters, "ent", &got) || got["Platform"] != "uuid-1" {
+		t.Fatalf("GetJSON = %v, want %v", got, want)
+	}
+	if c.GetJSON(KindActiveCostCenters, "other-ent", &got) {
+		t.Error("expected a miss for another key")
+	}
+
+	// Survives a reload, and expires by its timestamp.
+	reloaded, _ := New(dir, testLogger())
+	if !reloaded.GetJSON(KindActiveCostCenters, "ent", &got) {
+		t.Fatal("expected the entry to survive a reload")
+	}
+	e := reloaded.docs[KindActiveCostCenters].Entries["ent"]
+	e.CachedAt = time.Now().Add(-25 * time.Hour)
+	reloaded.docs[KindActiveCostCenters].Entries["ent"] = e
+	if reloaded.GetJSON(KindActiveCostCenters, "ent", &got) {
+		t.Error("expected a miss for an expired entry")
+	}
+
+	if err := c.SetJSON("unknown", "k", 1); err == nil {
+		t.Error("expected an error for an unknown kind")
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	dir := t.TempDir()
+	c, _ := New(dir, testLogger())
+	_ = c.SetJSON(KindActiveCostCenters, "ent", map[string]string{"A": "1"})
+	_ = c.SetJSON(KindPropertySchemas, "org", []string{"team"})
+
+	if err := c.Invalidate(KindActiveCostCenters); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	var v any
+	if c.GetJSON(KindActiveCostCenters, "ent", &v) {
+		t.Error("expected the invalidated kind to miss")
+	}
+	if !c.GetJSON(KindPropertySchemas, "org", &v) {
+		t.Error("expected other kinds to be kept")
+	}
+	reloaded, _ := New(dir, testLogger())
+	if reloaded.GetJSON(KindActiveCostCenters, "ent", &v) {
+		t.Error("invalidation was not persisted")
+	}
+}
+
+func TestCorruptFilesAreMisses(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{DefaultCacheFile, KindActiveCostCenters + ".json", KindPropertySchemas + ".json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{not json"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := New(dir, testLogger())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, ok := c.Get("anything"); ok {
+		t.Error("expected a miss from a corrupt name cache")
+	}
+	var v any
+	if c.GetJSON(KindActiveCostCenters, "ent", &v) || c.GetJSON(KindPropertySchemas, "org", &v) {
+		t.Error("expected misses from corrupt response caches")
+	}
+
+	// A corrupt file is replaced on the next write.
+	if err := c.SetJSON(KindPropertySchemas, "org", []string{"team"}); err != nil {
+		t.Fatalf("SetJSON: %v", err)
+	}
+	reloaded, _ := New(dir, testLogger())
+	if !reloaded.GetJSON(KindPropertySchemas, "org", &v) {
+		t.Error("expected the rewritten file to be readable")
+	}
+
+	// An entry whose value does not decode into dest is a miss too.
+	var wrong map[string]int
+	if reloaded.GetJSON(KindPropertySchemas, "org", &wrong) {
+		t.Error("expected a miss for a value of the wrong shape")
+	}
+}
+
+func TestStatsAndCleanupCoverAllKinds(t *testing.T) {
+	dir := t.TempDir()
+	c, _ := New(dir, testLogger())
+	_ = c.Set("a", "id-a", "A")
+	_ = c.SetJSON(KindActiveCostCenters, "ent", map[string]string{"A": "id-a"})
+	_ = c.SetJSON(KindPropertySchemas, "org1", []string{"team"})
+	_ = c.SetJSON(KindPropertySchemas, "org2", []string{"team"})
+	c.docs[KindPropertySchemas].Entries["org2"] = docEntry{CachedAt: time.Now().Add(-30 * time.Hour), Value: []byte(`[]`)}
+
+	stats := c.GetStats()
+	if stats.TotalEntries != 4 || stats.ExpiredEntries != 1 || stats.ValidEntries != 3 {
+		t.Errorf("totals = %d/%d/%d, want 4 total, 1 expired, 3 valid", stats.TotalEntries, stats.ExpiredEntries, stats.ValidEntries)
+	}
+	if stats.TTL != DefaultTTLHours*time.Hour {
+		t.Errorf("TTL = %s", stats.TTL)
+	}
+	byKind := make(map[string]KindStats)
+	for _, ks := range stats.Kinds {
+		byKind[ks.Kind] = ks
+	}
+	schemas := byKind[KindPropertySchemas]
+	if len(stats.Kinds) != 3 || schemas.TotalEntries != 2 || schemas.ExpiredEntries != 1 || schemas.FileSizeBytes == 0 {
+		t.Errorf("kinds = %+v", stats.Kinds)
+	}
+	if schemas.OldestAge < 29*time.Hour || schemas.NewestAge > time.Minute {
+		t.Errorf("ages = oldest %s, newest %s", schemas.OldestAge, schemas.NewestAge)
+	}
+
+	removed, err := c.CleanupExpired()
+	if err != nil || removed != 1 {
+		t.Fatalf("CleanupExpired = %d, %v; want 1 removed", removed, err)
+	}
+	if err := c.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	for _, kind := range []string{KindActiveCostCenters, KindPropertySchemas} {
+		if _, err := os.Stat(filepath.Join(dir, kind+".json")); !os.IsNotExist(err) {
+			t.Errorf("expected %s file to be removed by Clear", kind)
+		}
+	}
+}
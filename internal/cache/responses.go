@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Kinds of cached data.  Each API response kind is stored in <kind>.json in
+// the cache directory; cost center names use DefaultCacheFile.
+const (
+	KindCostCenterNames   = "cost_centers"
+	KindActiveCostCenters = "active_cost_centers"
+	KindPropertySchemas   = "property_schemas"
+)
+
+// kinds lists the API response kinds.
+var kinds = []string{KindActiveCostCenters, KindPropertySchemas}
+
+// docEntry is one cached API response.
+type docEntry struct {
+	CachedAt time.Time       `json:"cached_at"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// docData is the on-disk JSON structure of one response kind.
+type docData struct {
+	Version int                 `json:"version"`
+	Entries map[string]docEntry `json:"entries"`
+}
+
+func newDocData() *docData {
+	return &docData{Version: currentVersion, Entries: make(map[string]docEntry)}
+}
+
+// GetJSON decodes the cached response of kind under key into dest.  It
+// reports false when there is no valid entry; an expired or undecodable
+// entry is a miss.
+func (c *Cache) GetJSON(kind, key string, dest any) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	d, ok := c.docs[kind]
+	if !ok {
+		return false
+	}
+	e, ok := d.Entries[key]
+	if !ok {
+		return false
+	}
+	if c.expired(e.CachedAt) {
+		c.log.Debug("Cache entry expired", "kind", kind, "key", key)
+		return false
+	}
+	if err := json.Unmarshal(e.Value, dest); err != nil {
+		c.log.Warn("Ignoring unreadable cache entry", "kind", kind, "key", key, "error", err)
+		return false
+	}
+	c.log.Debug("Cache hit", "kind", kind, "key", key)
+	return true
+}
+
+// SetJSON stores v as the response of kind under key and flushes the kind's
+// file to disk.
+func (c *Cache) SetJSON(kind, key string, v any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	d, ok := c.docs[kind]
+	if !ok {
+		return fmt.Errorf("unknown cache kind %q", kind)
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding %s cache entry: %w", kind, err)
+	}
+	d.Entries[key] = docEntry{CachedAt: time.Now().UTC(), Value: raw}
+	c.log.Debug("Cache set", "kind", kind, "key", key)
+	return c.saveDocs(kind)
+}
+
+// Invalidate drops every cached response of kind, e.g. after a write makes
+// them stale.
+func (c *Cache) Invalidate(kind string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	d, ok := c.docs[kind]
+	if !ok || len(d.Entries) == 0 {
+		return nil
+	}
+	c.docs[kind] = newDocData()
+	c.log.Debug("Cache invalidated", "kind", kind)
+	return c.saveDocs(kind)
+}
+
+// docPath returns the file the responses of kind are stored in.
+func (c *Cache) docPath(kind string) string {
+	return filepath.Join(c.dir, kind+".json")
+}
+
+// loadDocs reads the responses of kind from disk.  A missing, corrupt, or
+// outdated file yields an empty set so that every lookup is a miss.
+func (c *Cache) loadDocs(kind string) *docData {
+	path := c.docPath(kind)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return newDocData()
+	}
+	var d docData
+	if err := json.Unmarshal(raw, &d); err != nil {
+		c.log.Warn("Ignoring corrupt cache file", "path", path, "error", err)
+		return newDocData()
+	}
+	if d.Version != currentVersion || d.Entries == nil {
+		return newDocData()
+	}
+	return &d
+}
+
+// saveDocs writes the responses of kind to disk.
+func (c *Cache) saveDocs(kind string) error {
+	if err := writeJSON(c.docPath(kind), c.docs[kind]); err != nil {
+		return err
+	}
+	c.log.Debug("Cache saved", "kind", kind, "entries", len(c.docs[kind].Entries))
+	return nil
+}
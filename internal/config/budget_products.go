@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// productLevelBudgets are the product-level budget identifiers
+// (ProductPricing).
+//
+// Reference: https://docs.github.com/enterprise-cloud@latest/billing/reference/product-and-sku-names
+var productLevelBudgets = map[string]bool{
+	"actions":    true,
+	"packages":   true,
+	"codespaces": true,
+	"copilot":    true,
+	"ghas":       true,
+	"ghec":       true,
+}
+
+// skuLevelBudgets are the SKU-level budget identifiers (SkuPricing).
+var skuLevelBudgets = map[string]bool{
+	// Copilot
+	"copilot_premium_request":       true,
+	"copilot_agent_premium_request": true,
+	"copilot_enterprise":            true,
+	"copilot_for_business":          true,
+	"copilot_standalone":            true,
+	// Actions
+	"actions_linux":   true,
+	"actions_macos":   true,
+	"actions_windows": true,
+	"actions_storage": true,
+	// Codespaces
+	"codespaces_storage":          true,
+	"codespaces_prebuild_storage": true,
+	// Packages
+	"packages_storage":   true,
+	"packages_bandwidth": true,
+	// GHAS
+	"ghas_licenses":                   true,
+	"ghas_code_security_licenses":     true,
+	"ghas_secret_protection_licenses": true,
+	// Other
+	"ghec_licenses":         true,
+	"git_lfs_storage":       true,
+	"git_lfs_bandwidth":     true,
+	"models_inference":      true,
+	"spark_premium_request": true,
+}
+
+// IsKnownBudgetProduct reports whether product is a known product or SKU
+// budget identifier.
+func IsKnownBudgetProduct(product string) bool {
+	p := strings.ToLower(product)
+	return productLevelBudgets[p] || skuLevelBudgets[p]
+}
+
+// IsProductLevelBudget reports whether product is a product-level budget
+// identifier rather than a SKU.
+func IsProductLevelBudget(product string) bool {
+	return productLevelBudgets[strings.ToLower(product)]
+}
+
+// problemList is an error made of several problems, which Validate reports
+// one by one.
+type problemList []Problem
+
+func (l problemList) Error() string {
+	msgs := make([]string, len(l))
+	for i, p := range l {
+		msgs[i] = p.String()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// budgetProductProblems reports the products under budgets.products and
+// budgets.cost_center_overrides that are not a known product or SKU name,
+// sorted by path, each with the closest known name when there is one.
+func (m *Manager) budgetProductProblems() []Problem {
+	known := slices.Sorted(maps.Keys(productLevelBudgets))
+	known = slices.Concat(known, slices.Sorted(maps.Keys(skuLevelBudgets)))
+	problem := func(path, product string) Problem {
+		msg := fmt.Sprintf("unknown product or SKU %q", product)
+		if s := SuggestName(product, known); s != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", s)
+		}
+		return Problem{Path: path, Message: msg + "; see " +
+			"https://docs.github.com/enterprise-cloud@latest/billing/reference/product-and-sku-names " +
+			"or set budgets.allow_unknown_products"}
+	}
+
+	b := m.cfg.Budgets
+	var problems []Problem
+	for _, product := range slices.Sorted(maps.Keys(b.Products)) {
+		if !IsKnownBudgetProduct(product) {
+			problems = append(problems, problem("budgets.products."+product, product))
+		}
+	}
+	for _, cc := range slices.Sorted(maps.Keys(b.CostCenterOverrides)) {
+		for _, product := range slices.Sorted(maps.Keys(b.CostCenterOverrides[cc])) {
+			if !IsKnownBudgetProduct(product) {
+				problems = append(problems, problem("budgets.cost_center_overrides."+cc+"."+product, product))
+			}
+		}
+	}
+	return problems
+}
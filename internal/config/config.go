@@ -1,13 +1,20 @@
 package config
 
 import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -20,6 +27,8 @@ const (
 	DefaultCostCenterMode    = "users"
 	DefaultTeamsStrategy     = "auto"
 	DefaultTeamsScope        = "enterprise"
+	DefaultTeamsConflict     = "first_alphabetical"
+	DefaultTeamsNameTemplate = "{team_name}"
 	DefaultLogLevel          = "INFO"
 	DefaultExportDir         = "exports"
 	DefaultNoPRUsCCID        = "CC-001-NO-PRUS"
@@ -28,15 +37,42 @@ const (
 	DefaultPRUsAllowedCCName = "01 - PRU overages allowed"
 	DefaultAPIBaseURL        = "https://api.github.com"
 
+	DefaultAssigningTeamDefaultCC = "No assigning team"
+	DefaultReposUnmatchedPolicy   = "skip"
+	DefaultSeatFetchConcurrency   = 5
+	DefaultCopilotScope           = "enterprise"
+	DefaultCacheTTL               = 24 * time.Hour
+	DefaultLockStaleAfter         = 6 * time.Hour
+	DefaultHTTPTimeout            = 30 * time.Second
+	DefaultLogMaxSizeMB           = 100
+	DefaultLogMaxBackups          = 5
+
 	timestampFileName = ".last_run_timestamp"
+	cacheDirName      = "cache"
+	lockFileName      = ".lock"
+	ledgerFileName    = "processed_users.json"
+
+	// legacyCacheFile is where cost center names were cached before the
+	// cache moved under the export directory.
+	legacyCacheFile = ".cache/cost_centers.json"
 )
 
 // Valid mode values.
 var validModes = map[string]bool{
-	"users":       true,
-	"teams":       true,
-	"repos":       true,
-	"custom-prop": true,
+	"users":          true,
+	"teams":          true,
+	"repos":          true,
+	"custom-prop":    true,
+	"assigning-team": true,
+}
+
+// modeAliases are cost_center.mode values that are easily mistaken for a
+// mode, such as the YAML section name of one.
+var modeAliases = map[string]string{
+	"repository":     "repos",
+	"repositories":   "repos",
+	"custom_prop":    "custom-prop",
+	"assigning_team": "assigning-team",
 }
 
 // Placeholder values that indicate the config has not been customised.
@@ -52,32 +88,93 @@ type Manager struct {
 	path string
 	log  *slog.Logger
 
+	// Files are the config files loaded, in layer order; sources records,
+	// by YAML path, the one each setting came from (see Source).
+	Files   []string
+	sources map[string]string
+
 	// Resolved values after applying env overrides and defaults.
 	Enterprise    string
 	APIBaseURL    string
 	Organizations []string
 
+	// SeatFetchConcurrency is the number of Copilot seat pages fetched in
+	// parallel.
+	SeatFetchConcurrency int
+
+	// CopilotScope is "enterprise" or "organization".
+	CopilotScope string
+
+	// CACertPath is the resolved github.ca_cert_path, "" when unset.
+	CACertPath string
+
+	// InsecureSkipVerify disables TLS certificate verification.
+	InsecureSkipVerify bool
+
 	// Cost center mode.
 	CostCenterMode string
 
+	// SkipPendingCancellation filters out users whose seat is pending
+	// cancellation.
+	SkipPendingCancellation bool
+
+	// IncludeNonUserAccounts keeps bot and other non-User accounts in
+	// assignments.
+	IncludeNonUserAccounts bool
+
+	// ExcludedUsers are lower-cased logins never assigned or removed.
+	ExcludedUsers []string
+
 	// Users (PRU) mode fields.
 	NoPRUsCostCenterID        string
 	PRUsAllowedCostCenterID   string
 	PRUsExceptionUsers        []string
+	PRUsExceptionUsersFile    string   // resolved users.exception_users_file path, merged into PRUsExceptionUsers
+	PRUsExceptionTeams        []string // enterprise team slugs or "org/team-slug", resolved at run time
 	AutoCreate                bool
+	PersistCreatedIDs         bool // write auto-created cost center IDs back to the config file
+	RemoveDepartedUsers       bool // remove users whose seat is gone (incremental runs)
 	NoPRUsCostCenterName      string
 	PRUsAllowedCostCenterName string
 	EnableIncremental         bool
 
+	// PRUsUserOverrides maps lower-cased logins to a cost center ID or name.
+	PRUsUserOverrides map[string]string
+
+	// PRUTiers is the configured tier list; empty means the legacy two-tier
+	// no-PRU/PRU-allowed settings above apply.
+	PRUTiers []PRUTier
+
+	// EnforceExclusiveMembership removes users from other tier cost centers
+	// during apply so nobody is billed twice.
+	EnforceExclusiveMembership bool
+
 	// Teams mode fields.
 	TeamsScope                string
 	TeamsStrategy             string
 	TeamsAutoCreate           bool
 	TeamsRemoveUnmatchedUsers bool
+	TeamsMappingsFile         string // resolved teams.mappings_file path, merged into TeamsMappings
 	TeamsMappings             map[string]string
+	TeamsNameTemplate         string // auto-mode cost center name template
+	TeamsConflictResolution   string // "first_alphabetical", "priority_list", or "error"
+	TeamsPriority             []string
+	TeamsBudgetOverrides      map[string]int
+	TeamsInclude              []string // team slug / key patterns to keep; empty keeps all
+	TeamsExclude              []string // team slug / key patterns to drop
+	TeamsCopilotHoldersOnly   bool     // assign only team members with a Copilot seat
+
+	// Assigning-team mode fields.
+	AssigningTeamAutoCreate        bool
+	AssigningTeamDefaultCostCenter string
 
 	// Repos mode fields.
-	ReposMappings []ExplicitMapping
+	ReposOrganizations     []string // repos.organizations, else Organizations
+	ReposMappings          []ExplicitMapping
+	ReposUnmatchedPolicy   string // "skip", "assign_default", or "error"
+	ReposDefaultCostCenter string
+	ReposIncludeArchived   bool
+	ReposRemoveUnmatched   bool
 
 	// Custom-prop mode fields.
 	CustomPropCostCenters     []CustomPropCostCenter
@@ -86,12 +183,43 @@ type Manager struct {
 	// Budgets.
 	BudgetsEnabled bool
 	BudgetProducts map[string]ProductBudget
+	// BudgetCostCenterOverrides maps a cost center name, "no_prus", or
+	// "prus_allowed" to product -> budget amount.
+	BudgetCostCenterOverrides map[string]map[string]int
 
 	// Logging & export.
 	ExportDir string
 	LogLevel  string
 	LogFile   string
 
+	// Log file rotation: size in megabytes, number of rotated files kept,
+	// and their maximum age in days (0 keeps them regardless of age).
+	LogMaxSizeMB  int
+	LogMaxBackups int
+	LogMaxAgeDays int
+
+	// LogKeepRuns is how many per-run log files ({timestamp} in LogFile) are
+	// kept; 0 keeps them all.
+	LogKeepRuns int
+
+	// StateDir is <export_dir>/<enterprise>, or <export_dir>/<host>/<enterprise>
+	// for a non-default API base URL: it holds the cache and the last-run
+	// timestamp so that enterprises sharing an export_dir stay apart.
+	StateDir string
+
+	// ProcessedUsersFile is the processed-users ledger of incremental
+	// users-mode runs (<state_dir>/processed_users.json).
+	ProcessedUsersFile string
+
+	// Cache location (<state_dir>/cache) and entry lifetime.
+	CacheDir string
+	CacheTTL time.Duration
+
+	// Apply-run lock file (<state_dir>/.lock) and the age after which a
+	// lock left behind by a crashed run is taken over.
+	LockFile       string
+	LockStaleAfter time.Duration
+
 	// Token from --token flag.
 	Token string
 
@@ -99,33 +227,48 @@ type Manager struct {
 	// schema definitions loaded from the config file.
 	RepoCustomProperties []RepoCustomPropertyDef
 
-	timestampFile string
+	timestampFile       string
+	legacyTimestampFile string
 }
 
 // Load reads the YAML config at path, applies env-var overrides, and validates.
 func Load(path string, logger *slog.Logger) (*Manager, error) {
+	return LoadFiles([]string{path}, logger)
+}
+
+// LoadFiles reads the YAML config files in paths, each deep-merged over the
+// ones before it, applies env-var overrides, and validates.  A single file
+// that does not exist means the defaults; a missing layer is an error.
+func LoadFiles(paths []string, logger *slog.Logger) (*Manager, error) {
 	if logger == nil {
 		logger = slog.Default()
 	}
 
-	loadDotEnv(path, logger)
-
-	m := &Manager{
-		path: path,
-		log:  logger,
+	m, unknown, err := parseFiles(paths, logger)
+	if errors.Is(err, os.ErrNotExist) && len(paths) == 1 {
+		logger.Warn("Config file not found, using defaults", "path", paths[0])
+		m = &Manager{path: paths[0], log: logger, Files: paths, sources: map[string]string{}}
+		err = m.decode(nil)
 	}
-
-	data, err := os.ReadFile(path)
 	if err != nil {
-		if os.IsNotExist(err) {
-			logger.Warn("Config file not found, using defaults", "path", path)
-		} else {
-			return nil, fmt.Errorf("reading config file: %w", err)
+		return nil, err
+	}
+	if len(unknown) > 0 && (Strict || m.cfg.Strict) {
+		keys := make([]string, len(unknown))
+		for i, k := range unknown {
+			keys[i] = k.String()
 		}
-	} else {
-		if err := yaml.Unmarshal(data, &m.cfg); err != nil {
-			return nil, fmt.Errorf("parsing config YAML: %w", err)
+		return nil, fmt.Errorf("unknown config keys (strict mode): %s", strings.Join(keys, ", "))
+	}
+	for _, k := range unknown {
+		args := []any{"key", k.Path, "line", k.Line}
+		if k.File != "" {
+			args = append(args, "file", k.File)
+		}
+		if k.Suggest != "" {
+			args = append(args, "did_you_mean", k.Suggest)
 		}
+		logger.Warn("Ignoring unknown config key", args...)
 	}
 
 	if err := m.resolve(); err != nil {
@@ -135,6 +278,90 @@ func Load(path string, logger *slog.Logger) (*Manager, error) {
 	return m, nil
 }
 
+// parseFiles loads the .env files next to the first path and parses the
+// YAML config files in paths into an unresolved Manager.  Each file is
+// deep-merged over the ones before it (see mergeLayer).  It also returns the
+// keys that match no setting.  A missing file is an error wrapping
+// os.ErrNotExist.
+func parseFiles(paths []string, logger *slog.Logger) (*Manager, []unknownKey, error) {
+	loadDotEnv(paths[0], logger)
+
+	m := &Manager{
+		path:    paths[0],
+		log:     logger,
+		Files:   paths,
+		sources: map[string]string{},
+	}
+	if len(paths) > 1 {
+		logger.Debug("Merging config files; later files override earlier ones", "order", paths)
+	}
+
+	var merged *yaml.Node
+	var unknown []unknownKey
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading config file: %w", err)
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, nil, fmt.Errorf("parsing config YAML %s: %w", path, err)
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		if err := doc.Decode(&Config{}); err != nil {
+			return nil, nil, fmt.Errorf("parsing config YAML %s: %w", path, err)
+		}
+		root := doc.Content[0]
+		if root.Kind != yaml.MappingNode {
+			continue
+		}
+
+		file := ""
+		if len(paths) > 1 {
+			file = path
+		}
+		unknown = append(unknown, findUnknownKeys(root, file)...)
+		if merged == nil {
+			merged = root
+			recordOrigin(root, "", path, m.sources)
+		} else {
+			mergeLayer(merged, root, "", path, m.sources)
+		}
+		if len(paths) > 1 {
+			logger.Debug("Merged config layer", "layer", i+1, "file", path)
+		}
+	}
+	if err := m.decode(merged); err != nil {
+		return nil, nil, err
+	}
+	return m, unknown, nil
+}
+
+// decode applies the GHCC_ environment variables and then Overrides to
+// merged, the merged config files, and decodes the result into m.cfg.
+// merged may be nil when no file sets anything.
+func (m *Manager) decode(merged *yaml.Node) error {
+	if merged == nil {
+		merged = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+	if err := applyEnvSettings(merged, m.sources); err != nil {
+		return err
+	}
+	if err := applyOverrides(merged, Overrides, m.sources); err != nil {
+		return err
+	}
+	if slug := strings.TrimSpace(EnterpriseFlag); slug != "" {
+		setNode(merged, []string{"github", "enterprise"}, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: slug})
+		m.sources["github.enterprise"] = EnterpriseFlagSource
+	}
+	if err := merged.Decode(&m.cfg); err != nil {
+		return fmt.Errorf("parsing config YAML: %w", err)
+	}
+	return nil
+}
+
 // loadDotEnv loads .env files if present, without overriding already-exported
 // environment variables.
 func loadDotEnv(configPath string, logger *slog.Logger) {
@@ -168,10 +395,60 @@ func (m *Manager) Raw() *Config {
 	return &m.cfg
 }
 
-// resolve applies env-var overrides, defaults, and validation.
+// resolve applies env-var overrides, defaults, and validation, stopping at
+// the first invalid section.
 func (m *Manager) resolve() error {
-	// --- Enterprise ---
-	m.Enterprise = envOrFallback("GITHUB_ENTERPRISE", m.cfg.GitHub.Enterprise)
+	for _, s := range m.resolveSteps() {
+		if err := s.run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveStep resolves one section of the config.  path is the YAML key
+// Validate reports the step's error under.
+type resolveStep struct {
+	path string
+	run  func() error
+}
+
+// modeKeys maps each cost center mode to its settings key under cost_center.
+var modeKeys = map[string]string{
+	"users":          "users",
+	"teams":          "teams",
+	"repos":          "repos",
+	"custom-prop":    "custom_prop",
+	"assigning-team": "assigning_team",
+}
+
+// resolveSteps returns the sections of resolve in order.  Each step only
+// depends on the fields set by the steps before it.
+func (m *Manager) resolveSteps() []resolveStep {
+	modeKey := modeKeys[defaultString(m.cfg.CostCenter.Mode, DefaultCostCenterMode)]
+	return []resolveStep{
+		{"github.enterprise", m.resolveEnterprise},
+		{"github.api_base_url", m.resolveAPIBaseURL},
+		{"github.ca_cert_path", m.resolveTLS},
+		{"github.seat_fetch_concurrency", m.resolveSeatFetching},
+		{"github.copilot_scope", m.resolveCopilotScope},
+		{"cost_center.mode", m.resolveCostCenterMode},
+		{"cost_center." + modeKey, m.resolveModeSettings},
+		{"budgets", m.resolveBudgets},
+		{"logging", m.resolveLogging},
+		{"export_dir", m.resolveExportDir},
+		{"cache.ttl", m.resolveCache},
+		{"lock.stale_after", m.resolveLock},
+		{"repo_custom_properties", m.resolveRepoCustomProperties},
+	}
+}
+
+// resolveEnterprise resolves the enterprise slug, rejecting placeholders.
+func (m *Manager) resolveEnterprise() error {
+	m.Enterprise = m.envOverride("GITHUB_ENTERPRISE", "github.enterprise", m.cfg.GitHub.Enterprise)
+	if m.sources["github.enterprise"] == EnterpriseFlagSource && placeholderEnterpriseValues[m.Enterprise] {
+		return fmt.Errorf("invalid --enterprise %q: it is a placeholder", m.Enterprise)
+	}
 	if placeholderEnterpriseValues[m.Enterprise] {
 		if v := os.Getenv("GITHUB_ENTERPRISE"); v != "" && !placeholderEnterpriseValues[v] {
 			m.Enterprise = v
@@ -179,9 +456,12 @@ func (m *Manager) resolve() error {
 			return fmt.Errorf("github enterprise must be configured (set env GITHUB_ENTERPRISE or update config github.enterprise)")
 		}
 	}
+	return nil
+}
 
-	// --- API base URL ---
-	rawURL := envOrFallback("GITHUB_API_BASE_URL", m.cfg.GitHub.APIBaseURL)
+// resolveAPIBaseURL resolves and validates the API base URL.
+func (m *Manager) resolveAPIBaseURL() error {
+	rawURL := m.envOverride("GITHUB_API_BASE_URL", "github.api_base_url", m.cfg.GitHub.APIBaseURL)
 	if rawURL == "" {
 		rawURL = DefaultAPIBaseURL
 	}
@@ -190,40 +470,102 @@ func (m *Manager) resolve() error {
 		return err
 	}
 	m.APIBaseURL = apiURL
+	return nil
+}
 
-	// --- Organizations ---
+// resolveTLS resolves the CA bundle and the TLS verification settings.  A
+// relative ca_cert_path is relative to the config file's directory.
+func (m *Manager) resolveTLS() error {
+	if path := m.cfg.GitHub.CACertPath; path != "" {
+		file := m.relativeToConfig(path, "github.ca_cert_path")
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("reading github.ca_cert_path: %w", err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(data) {
+			return fmt.Errorf("invalid github.ca_cert_path %q: no PEM certificates found", file)
+		}
+		m.CACertPath = file
+	}
+	m.InsecureSkipVerify = m.cfg.GitHub.InsecureSkipVerify
+	if m.InsecureSkipVerify {
+		m.log.Warn("TLS CERTIFICATE VERIFICATION IS DISABLED (github.insecure_skip_verify): API traffic, including the token, can be intercepted; use github.ca_cert_path instead",
+			"url", m.APIBaseURL)
+	}
+	return nil
+}
+
+// resolveSeatFetching resolves the Copilot seat page concurrency.
+func (m *Manager) resolveSeatFetching() error {
+	m.SeatFetchConcurrency = m.cfg.GitHub.SeatFetchConcurrency
+	if m.SeatFetchConcurrency < 0 {
+		return fmt.Errorf("invalid github.seat_fetch_concurrency %d: must not be negative", m.SeatFetchConcurrency)
+	}
+	if m.SeatFetchConcurrency == 0 {
+		m.SeatFetchConcurrency = DefaultSeatFetchConcurrency
+	}
+	return nil
+}
+
+// resolveCopilotScope resolves the organizations and the Copilot seat scope.
+func (m *Manager) resolveCopilotScope() error {
 	m.Organizations = m.cfg.GitHub.Organizations
 	if m.Organizations == nil {
 		m.Organizations = []string{}
 	}
 
-	// --- Cost center mode ---
+	m.CopilotScope = defaultString(m.cfg.GitHub.CopilotScope, DefaultCopilotScope)
+	if m.CopilotScope != "enterprise" && m.CopilotScope != "organization" {
+		return fmt.Errorf("invalid github.copilot_scope %q: must be 'enterprise' or 'organization'", m.CopilotScope)
+	}
+	if m.CopilotScope == "organization" && len(m.Organizations) == 0 {
+		return fmt.Errorf("github.copilot_scope 'organization' requires github.organizations to be configured")
+	}
+	return nil
+}
+
+// resolveCostCenterMode resolves the mode and the settings shared by all
+// modes.
+func (m *Manager) resolveCostCenterMode() error {
 	m.CostCenterMode = defaultString(m.cfg.CostCenter.Mode, DefaultCostCenterMode)
+	m.SkipPendingCancellation = m.cfg.CostCenter.SkipPendingCancellation
+	m.IncludeNonUserAccounts = m.cfg.CostCenter.IncludeNonUserAccounts
+	m.MergeExcludedUsers(m.cfg.CostCenter.ExcludedUsers)
+
 	if !validModes[m.CostCenterMode] {
-		return fmt.Errorf("invalid cost_center.mode %q: must be one of: users, teams, repos, custom-prop", m.CostCenterMode)
+		hint := modeAliases[strings.ToLower(m.CostCenterMode)]
+		if hint == "" {
+			hint = SuggestName(m.CostCenterMode, []string{"users", "teams", "repos", "custom-prop", "assigning-team"})
+		}
+		if hint != "" {
+			hint = fmt.Sprintf(" (did you mean %q?)", hint)
+		}
+		return fmt.Errorf("invalid cost_center.mode %q%s: must be one of: users, teams, repos, custom-prop, assigning-team", m.CostCenterMode, hint)
 	}
+	return nil
+}
 
-	// --- Validate and resolve per-mode settings ---
+// resolveModeSettings validates and resolves the settings of the configured
+// mode.  An invalid mode has no settings to resolve.
+func (m *Manager) resolveModeSettings() error {
 	switch m.CostCenterMode {
 	case "users":
-		if err := m.resolveUsersMode(); err != nil {
-			return err
-		}
+		return m.resolveUsersMode()
 	case "teams":
-		if err := m.resolveTeamsMode(); err != nil {
-			return err
-		}
+		return m.resolveTeamsMode()
 	case "repos":
-		if err := m.resolveReposMode(); err != nil {
-			return err
-		}
+		return m.resolveReposMode()
 	case "custom-prop":
-		if err := m.resolveCustomPropMode(); err != nil {
-			return err
-		}
+		return m.resolveCustomPropMode()
+	case "assigning-team":
+		m.resolveAssigningTeamMode()
 	}
+	return nil
+}
 
-	// --- Budgets ---
+// resolveBudgets resolves the budget products, defaulting to Copilot and
+// Actions budgets.
+func (m *Manager) resolveBudgets() error {
 	b := m.cfg.Budgets
 	m.BudgetsEnabled = b.Enabled
 	m.BudgetProducts = b.Products
@@ -233,20 +575,124 @@ func (m *Manager) resolve() error {
 			"actions": {Amount: 125, Enabled: true},
 		}
 	}
+	m.BudgetCostCenterOverrides = make(map[string]map[string]int, len(b.CostCenterOverrides))
+	for cc, products := range b.CostCenterOverrides {
+		key := strings.TrimSpace(cc)
+		if key == "" {
+			return fmt.Errorf("invalid budgets.cost_center_overrides: empty cost center name")
+		}
+		for product, amount := range products {
+			if strings.TrimSpace(product) == "" || amount < 0 {
+				return fmt.Errorf("invalid budgets.cost_center_overrides.%s entry %q: %d (want a product and an amount of 0 or more)", cc, product, amount)
+			}
+		}
+		m.BudgetCostCenterOverrides[key] = products
+	}
+	if problems := m.budgetProductProblems(); len(problems) > 0 {
+		if !b.AllowUnknownProducts {
+			return problemList(problems)
+		}
+		for _, p := range problems {
+			m.log.Warn("Using an unknown budget product (budgets.allow_unknown_products)", "key", p.Path)
+		}
+	}
+	return m.validateBudgetAlerting()
+}
+
+// Symbolic budgets.cost_center_overrides keys for the two users-mode cost
+// centers.
+const (
+	BudgetKeyNoPRUs      = "no_prus"
+	BudgetKeyPRUsAllowed = "prus_allowed"
+)
 
-	// --- Logging ---
+// BudgetOverrideFor returns the budgets.cost_center_overrides entry of the
+// first of keys that has one, such as a cost center name followed by its
+// symbolic key, or nil.
+func (m *Manager) BudgetOverrideFor(keys ...string) map[string]int {
+	for _, key := range keys {
+		if o, ok := m.BudgetCostCenterOverrides[key]; ok {
+			return o
+		}
+	}
+	return nil
+}
+
+// BudgetProductsFor returns the product budgets of the cost center named
+// ccName: BudgetProducts with its budgets.cost_center_overrides entry
+// applied (see WithBudgetOverride).
+func (m *Manager) BudgetProductsFor(ccName string) map[string]ProductBudget {
+	return WithBudgetOverride(m.BudgetProducts, m.BudgetOverrideFor(ccName))
+}
+
+// WithBudgetOverride returns products with the amounts of override: each
+// overridden product is enabled with the override amount, keeping its alert
+// settings.  products itself is left unchanged.
+func WithBudgetOverride(products map[string]ProductBudget, override map[string]int) map[string]ProductBudget {
+	if len(override) == 0 {
+		return products
+	}
+	out := maps.Clone(products)
+	if out == nil {
+		out = make(map[string]ProductBudget, len(override))
+	}
+	for product, amount := range override {
+		pb := out[product]
+		pb.Amount, pb.Enabled = amount, true
+		out[product] = pb
+	}
+	return out
+}
+
+// resolveLogging resolves the log level, file, and rotation.
+func (m *Manager) resolveLogging() error {
 	m.LogLevel = defaultString(m.cfg.Logging.Level, DefaultLogLevel)
 	m.LogFile = m.cfg.Logging.File
+	return m.resolveLogRotation()
+}
 
-	// --- Export ---
+// resolveExportDir resolves the export directory and the per-enterprise
+// state directory under it.
+func (m *Manager) resolveExportDir() error {
 	m.ExportDir = defaultString(m.cfg.ExportDir, DefaultExportDir)
-	m.timestampFile = filepath.Join(m.ExportDir, timestampFileName)
+	m.StateDir = filepath.Join(m.ExportDir, stateNamespace(m.Enterprise, m.APIBaseURL))
+	m.timestampFile = filepath.Join(m.StateDir, timestampFileName)
+	m.legacyTimestampFile = filepath.Join(m.ExportDir, timestampFileName)
+	m.ProcessedUsersFile = filepath.Join(m.StateDir, ledgerFileName)
+	return nil
+}
 
-	// --- Repo custom properties ---
-	if err := m.resolveRepoCustomProperties(); err != nil {
-		return err
+// resolveCache resolves the API response cache directory and TTL.
+func (m *Manager) resolveCache() error {
+	m.CacheDir = filepath.Join(m.StateDir, cacheDirName)
+	m.CacheTTL = DefaultCacheTTL
+	if ttl := strings.TrimSpace(m.cfg.Cache.TTL); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return fmt.Errorf("invalid cache.ttl %q: %w", ttl, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("invalid cache.ttl %q: must be positive", ttl)
+		}
+		m.CacheTTL = d
 	}
+	return nil
+}
 
+// resolveLock resolves the lock file and its staleness threshold.
+func (m *Manager) resolveLock() error {
+	m.LockFile = filepath.Join(m.StateDir, lockFileName)
+	m.LockStaleAfter = DefaultLockStaleAfter
+	if sa := strings.TrimSpace(m.cfg.Lock.StaleAfter); sa != "" {
+		d, err := time.ParseDuration(sa)
+		if err != nil {
+			return fmt.Errorf("invalid lock.stale_after %q: %w", sa, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("invalid lock.stale_after %q: must be positive", sa)
+		}
+		m.LockStaleAfter = d
+	}
 	return nil
 }
 
@@ -259,16 +705,53 @@ func (m *Manager) resolveUsersMode() error {
 	m.NoPRUsCostCenterName = defaultString(u.NoPRUsCostCenterName, DefaultNoPRUsCCName)
 	m.PRUsAllowedCostCenterName = defaultString(u.PRUsAllowedCostCenterName, DefaultPRUsAllowedCCName)
 
-	m.PRUsExceptionUsers = u.ExceptionUsers
-	if m.PRUsExceptionUsers == nil {
-		m.PRUsExceptionUsers = []string{}
+	if err := m.resolveExceptionUsers(); err != nil {
+		return err
+	}
+	m.PRUsExceptionTeams = nil
+	for _, team := range u.ExceptionTeams {
+		team = strings.TrimSpace(team)
+		org, slug, isOrgTeam := strings.Cut(team, "/")
+		if team == "" || strings.ContainsAny(team, "*?[@ \t") || (isOrgTeam && (org == "" || slug == "" || strings.Contains(slug, "/"))) {
+			return fmt.Errorf("invalid cost_center.users.exception_teams entry %q: want an enterprise team slug or \"org/team-slug\"", team)
+		}
+		m.PRUsExceptionTeams = append(m.PRUsExceptionTeams, team)
+	}
+
+	m.PRUsUserOverrides = make(map[string]string, len(u.UserOverrides))
+	for login, cc := range u.UserOverrides {
+		key := strings.ToLower(strings.TrimSpace(login))
+		cc = strings.TrimSpace(cc)
+		if key == "" {
+			return fmt.Errorf("invalid cost_center.users.user_overrides: empty login")
+		}
+		if cc == "" || strings.HasPrefix(cc, "REPLACE_WITH_") {
+			return fmt.Errorf("invalid cost_center.users.user_overrides entry %q: cost center %q is empty or a placeholder", login, cc)
+		}
+		if prev, dup := m.PRUsUserOverrides[key]; dup && prev != cc {
+			return fmt.Errorf("invalid cost_center.users.user_overrides: login %q is mapped twice (%q and %q)", login, prev, cc)
+		}
+		m.PRUsUserOverrides[key] = cc
 	}
 
 	m.AutoCreate = u.AutoCreate
+	m.PersistCreatedIDs = u.PersistCreatedIDs
+	m.RemoveDepartedUsers = u.RemoveDepartedUsers
 	m.EnableIncremental = u.EnableIncremental
+	m.EnforceExclusiveMembership = u.EnforceExclusiveMembership == nil || *u.EnforceExclusiveMembership
+
+	if len(u.Tiers) > 0 {
+		if err := m.validateTiers(u.Tiers); err != nil {
+			return err
+		}
+		m.PRUTiers = u.Tiers
+	}
 
 	m.log.Info("Users (PRU) mode enabled",
 		"exception_users", len(m.PRUsExceptionUsers),
+		"exception_teams", len(m.PRUsExceptionTeams),
+		"user_overrides", len(m.PRUsUserOverrides),
+		"tiers", len(m.PRUTiers),
 		"auto_create", m.AutoCreate)
 	return nil
 }
@@ -282,11 +765,12 @@ func (m *Manager) resolveTeamsMode() error {
 	m.TeamsAutoCreate = t.AutoCreate
 	m.TeamsRemoveUnmatchedUsers = t.RemoveUnmatchedUsers
 
-	m.TeamsMappings = t.Mappings
-	if m.TeamsMappings == nil {
-		m.TeamsMappings = map[string]string{}
-	}
+	m.TeamsMappings = make(map[string]string, len(t.Mappings))
+	maps.Copy(m.TeamsMappings, t.Mappings)
 
+	if m.TeamsScope != "organization" && m.TeamsScope != "enterprise" {
+		return fmt.Errorf("invalid cost_center.teams.scope %q: must be 'organization' or 'enterprise'", m.TeamsScope)
+	}
 	// Validate: organization scope requires organizations
 	if m.TeamsScope == "organization" && len(m.Organizations) == 0 {
 		return fmt.Errorf("teams mode with scope 'organization' requires github.organizations to be configured")
@@ -296,6 +780,58 @@ func (m *Manager) resolveTeamsMode() error {
 		return fmt.Errorf("invalid cost_center.teams.strategy %q: must be 'auto' or 'manual'", m.TeamsStrategy)
 	}
 
+	if t.MappingsFile != "" {
+		if m.TeamsStrategy != "manual" {
+			m.log.Warn("cost_center.teams.mappings_file is only used in manual strategy; ignoring it")
+		} else if err := m.mergeMappingsFile(t.MappingsFile); err != nil {
+			return err
+		}
+	}
+	if m.TeamsStrategy == "manual" && len(m.TeamsMappings) == 0 {
+		return fmt.Errorf("teams mode with strategy 'manual' requires cost_center.teams.mappings or mappings_file: no team would be assigned")
+	}
+
+	if err := validateNameTemplate("cost_center.teams.cost_center_name_template", t.CostCenterNameTemplate, TeamsNamePlaceholders); err != nil {
+		return err
+	}
+	m.TeamsNameTemplate = defaultString(t.CostCenterNameTemplate, DefaultTeamsNameTemplate)
+	if t.CostCenterNameTemplate != "" && m.TeamsStrategy == "manual" {
+		m.log.Warn("cost_center.teams.cost_center_name_template is only used in auto strategy; ignoring it")
+	}
+
+	m.TeamsInclude, m.TeamsExclude = t.Include, t.Exclude
+	m.TeamsCopilotHoldersOnly = t.CopilotHoldersOnly == nil || *t.CopilotHoldersOnly
+	if err := validateTeamPatterns("cost_center.teams.include", m.TeamsInclude); err != nil {
+		return err
+	}
+	if err := validateTeamPatterns("cost_center.teams.exclude", m.TeamsExclude); err != nil {
+		return err
+	}
+
+	m.TeamsBudgetOverrides = t.BudgetOverrides
+	for team, amount := range m.TeamsBudgetOverrides {
+		if strings.TrimSpace(team) == "" || amount <= 0 {
+			return fmt.Errorf("invalid cost_center.teams.budget_overrides entry %q: %d (want a team slug and a positive amount)", team, amount)
+		}
+	}
+
+	// The PRU exception list protects users from removal in teams mode too.
+	if err := m.resolveExceptionUsers(); err != nil {
+		return err
+	}
+
+	m.TeamsConflictResolution = defaultString(t.ConflictResolution, DefaultTeamsConflict)
+	m.TeamsPriority = t.Priority
+	switch m.TeamsConflictResolution {
+	case "first_alphabetical", "error":
+	case "priority_list":
+		if len(m.TeamsPriority) == 0 {
+			return fmt.Errorf("cost_center.teams.conflict_resolution \"priority_list\" requires cost_center.teams.priority")
+		}
+	default:
+		return fmt.Errorf("invalid cost_center.teams.conflict_resolution %q: must be 'first_alphabetical', 'priority_list', or 'error'", m.TeamsConflictResolution)
+	}
+
 	// Warn about mapping values that don't look like UUIDs when auto-create
 	// is disabled. These will be resolved by name at runtime, but a mismatch
 	// will cause a failure.
@@ -316,13 +852,105 @@ func (m *Manager) resolveTeamsMode() error {
 	return nil
 }
 
+// resolveExceptionUsers resolves the PRU exception users: the inline list
+// followed by the entries of users.exception_users_file that it does not
+// already hold, compared case-insensitively.
+func (m *Manager) resolveExceptionUsers() error {
+	u := m.cfg.CostCenter.Users
+	m.PRUsExceptionUsers = []string{}
+	m.PRUsExceptionUsersFile = ""
+	seen := make(map[string]bool)
+	add := func(entries []string) {
+		for _, e := range entries {
+			if key := strings.ToLower(strings.TrimSpace(e)); !seen[key] {
+				seen[key] = true
+				m.PRUsExceptionUsers = append(m.PRUsExceptionUsers, e)
+			}
+		}
+	}
+	add(u.ExceptionUsers)
+
+	if u.ExceptionUsersFile != "" {
+		file := m.relativeToConfig(u.ExceptionUsersFile, "cost_center.users.exception_users_file")
+		entries, err := loadExceptionUsersFile(file)
+		if err != nil {
+			return err
+		}
+		m.PRUsExceptionUsersFile = file
+		add(entries)
+		m.log.Info("Loaded PRU exception users file", "path", file, "users", len(entries))
+	}
+	return validateExceptionUsers(m.PRUsExceptionUsers)
+}
+
+// relativeToConfig resolves file, the value of the setting at path, against
+// the directory of the config file that set it.
+func (m *Manager) relativeToConfig(file, path string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	base := m.path
+	if src := m.Source(path); slices.Contains(m.Files, src) {
+		base = src
+	}
+	return filepath.Join(filepath.Dir(base), file)
+}
+
+// mergeMappingsFile adds the entries of teams.mappings_file to TeamsMappings.
+// A relative path is relative to the config file's directory.  Inline
+// mappings win over the file.
+func (m *Manager) mergeMappingsFile(file string) error {
+	file = m.relativeToConfig(file, "cost_center.teams.mappings_file")
+	fileMappings, err := loadMappingsFile(file)
+	if err != nil {
+		return err
+	}
+	m.TeamsMappingsFile = file
+
+	teamKeys := slices.Sorted(maps.Keys(fileMappings))
+	for _, teamKey := range teamKeys {
+		cc := fileMappings[teamKey]
+		if inline, ok := m.TeamsMappings[teamKey]; ok {
+			if inline != cc {
+				m.log.Warn("Team is mapped both inline and in the mappings file; using the inline mapping",
+					"team", teamKey, "inline", inline, "file", cc)
+			}
+			continue
+		}
+		m.TeamsMappings[teamKey] = cc
+	}
+	m.log.Info("Loaded teams mappings file", "path", file, "mappings", len(fileMappings))
+	return nil
+}
+
+// resolveAssigningTeamMode resolves assigning-team mode settings.
+func (m *Manager) resolveAssigningTeamMode() {
+	a := m.cfg.CostCenter.AssigningTeam
+
+	m.AssigningTeamAutoCreate = a.AutoCreate
+	m.AssigningTeamDefaultCostCenter = defaultString(a.DefaultCostCenter, DefaultAssigningTeamDefaultCC)
+
+	m.log.Info("Assigning-team mode enabled",
+		"default_cost_center", m.AssigningTeamDefaultCostCenter,
+		"auto_create", m.AssigningTeamAutoCreate)
+}
+
 // resolveReposMode resolves repository (explicit mapping) mode settings.
 func (m *Manager) resolveReposMode() error {
-	if len(m.Organizations) == 0 {
-		return fmt.Errorf("repos mode requires github.organizations to be configured")
+	r := m.cfg.CostCenter.Repos
+	m.ReposOrganizations = m.Organizations
+	if len(r.Organizations) > 0 {
+		m.ReposOrganizations = nil
+		for _, org := range r.Organizations {
+			if org = strings.TrimSpace(org); org != "" {
+				m.ReposOrganizations = append(m.ReposOrganizations, org)
+			}
+		}
+	}
+	if len(m.ReposOrganizations) == 0 {
+		return fmt.Errorf("repos mode requires cost_center.repos.organizations or github.organizations to be configured")
 	}
 
-	r := m.cfg.CostCenter.Repos
 	if len(r.Mappings) == 0 {
 		return fmt.Errorf("repos mode requires at least one mapping in cost_center.repos.mappings")
 	}
@@ -330,9 +958,34 @@ func (m *Manager) resolveReposMode() error {
 	if err := validateExplicitMappings(r.Mappings); err != nil {
 		return err
 	}
+	for i, em := range r.Mappings {
+		if em.Organization != "" && !slices.Contains(m.ReposOrganizations, em.Organization) {
+			return fmt.Errorf("repos.mappings[%d]: organization %q is not one of the repos mode organizations (%s)",
+				i, em.Organization, strings.Join(m.ReposOrganizations, ", "))
+		}
+	}
 
 	m.ReposMappings = r.Mappings
-	m.log.Info("Repos mode enabled", "mappings", len(r.Mappings))
+	m.ReposIncludeArchived = r.IncludeArchived
+	m.ReposRemoveUnmatched = r.RemoveUnmatched
+
+	m.ReposUnmatchedPolicy = defaultString(r.UnmatchedPolicy, DefaultReposUnmatchedPolicy)
+	m.ReposDefaultCostCenter = strings.TrimSpace(r.DefaultCostCenter)
+	switch m.ReposUnmatchedPolicy {
+	case "assign_default":
+		if m.ReposDefaultCostCenter == "" {
+			return fmt.Errorf("cost_center.repos.unmatched_policy \"assign_default\" requires cost_center.repos.default_cost_center")
+		}
+	case "skip", "error":
+		if m.ReposDefaultCostCenter != "" {
+			m.log.Warn("cost_center.repos.default_cost_center is only used with unmatched_policy \"assign_default\"; ignoring it",
+				"unmatched_policy", m.ReposUnmatchedPolicy)
+		}
+	default:
+		return fmt.Errorf("invalid cost_center.repos.unmatched_policy %q: must be 'skip', 'assign_default', or 'error'", m.ReposUnmatchedPolicy)
+	}
+
+	m.log.Info("Repos mode enabled", "mappings", len(r.Mappings), "unmatched_policy", m.ReposUnmatchedPolicy)
 	return nil
 }
 
@@ -392,14 +1045,32 @@ func (m *Manager) CheckConfigWarnings() {
 	}
 }
 
+// Incremental timestamp modes: each assignment flow keeps its own last-run
+// timestamp so one flow's run does not make another skip users.
+const (
+	TimestampModePRU        = "pru"
+	TimestampModeTeams      = "teams"
+	TimestampModeRepository = "repository"
+)
+
 // timestampData represents the JSON stored in the last-run timestamp file.
+// LastRun and SavedAt hold the single-value format written before
+// timestamps were kept per mode; it is read as the pru entry.
 type timestampData struct {
+	Modes   map[string]timestampEntry `json:"modes,omitempty"`
+	LastRun string                    `json:"last_run,omitempty"`
+	SavedAt string                    `json:"saved_at,omitempty"`
+}
+
+// timestampEntry is the last run of one mode.
+type timestampEntry struct {
 	LastRun string `json:"last_run"`
 	SavedAt string `json:"saved_at"`
 }
 
-// SaveLastRunTimestamp persists the given timestamp (or now) to the export dir.
-func (m *Manager) SaveLastRunTimestamp(t *time.Time) error {
+// SaveLastRunTimestamp persists the given timestamp (or now) as the last run
+// of mode, keeping the other modes' timestamps.
+func (m *Manager) SaveLastRunTimestamp(mode string, t *time.Time) error {
 	now := time.Now().UTC()
 	if t == nil {
 		t = &now
@@ -410,7 +1081,11 @@ func (m *Manager) SaveLastRunTimestamp(t *time.Time) error {
 		return fmt.Errorf("creating export directory: %w", err)
 	}
 
-	td := timestampData{
+	td, err := m.readTimestamps()
+	if err != nil {
+		return err
+	}
+	td.Modes[mode] = timestampEntry{
 		LastRun: t.UTC().Format(time.RFC3339),
 		SavedAt: now.Format(time.RFC3339),
 	}
@@ -424,51 +1099,215 @@ func (m *Manager) SaveLastRunTimestamp(t *time.Time) error {
 		return fmt.Errorf("writing timestamp file: %w", err)
 	}
 
-	m.log.Info("Saved last run timestamp", "timestamp", td.LastRun)
+	m.log.Info("Saved last run timestamp", "mode", mode, "timestamp", td.Modes[mode].LastRun)
 	return nil
 }
 
-// LoadLastRunTimestamp reads the last-run timestamp from the export dir.
-// Returns nil if no previous timestamp exists.
-func (m *Manager) LoadLastRunTimestamp() (*time.Time, error) {
+// LoadLastRunTimestamp reads the last-run timestamp of mode from the state
+// dir, first moving a legacy <export_dir>/.last_run_timestamp there if this
+// enterprise has none yet.  Returns nil if mode has no previous timestamp.
+func (m *Manager) LoadLastRunTimestamp(mode string) (*time.Time, error) {
+	if err := m.migrateLegacy(m.legacyTimestampFile, m.timestampFile); err != nil {
+		return nil, err
+	}
+
+	td, err := m.readTimestamps()
+	if err != nil {
+		return nil, err
+	}
+
+	e, ok := td.Modes[mode]
+	if !ok {
+		m.log.Info("No previous run timestamp found — will process all users", "mode", mode)
+		return nil, nil
+	}
+	if e.LastRun == "" {
+		m.log.Warn("Invalid timestamp file format", "mode", mode)
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, e.LastRun)
+	if err != nil {
+		return nil, fmt.Errorf("parsing timestamp value: %w", err)
+	}
+
+	m.log.Info("Loaded last run timestamp", "mode", mode, "timestamp", e.LastRun)
+	return &t, nil
+}
+
+// readTimestamps reads the timestamp file, converting the single-value
+// format into the pru entry.  A missing file yields no entries.
+func (m *Manager) readTimestamps() (timestampData, error) {
+	td := timestampData{Modes: make(map[string]timestampEntry)}
 	data, err := os.ReadFile(m.timestampFile)
 	if err != nil {
 		if os.IsNotExist(err) {
-			m.log.Info("No previous run timestamp found — will process all users")
-			return nil, nil
+			return td, nil
 		}
-		return nil, fmt.Errorf("reading timestamp file: %w", err)
+		return td, fmt.Errorf("reading timestamp file: %w", err)
 	}
 
-	var td timestampData
 	if err := json.Unmarshal(data, &td); err != nil {
-		return nil, fmt.Errorf("parsing timestamp file: %w", err)
+		return td, fmt.Errorf("parsing timestamp file: %w", err)
+	}
+	if td.Modes == nil {
+		td.Modes = make(map[string]timestampEntry)
+	}
+	if td.LastRun != "" || td.SavedAt != "" {
+		if _, ok := td.Modes[TimestampModePRU]; !ok {
+			td.Modes[TimestampModePRU] = timestampEntry{LastRun: td.LastRun, SavedAt: td.SavedAt}
+		}
+		td.LastRun, td.SavedAt = "", ""
 	}
+	return td, nil
+}
 
-	if td.LastRun == "" {
-		m.log.Warn("Invalid timestamp file format")
-		return nil, nil
+// MigrateLegacyCache moves an un-namespaced cache — <export_dir>/cache, or
+// the older .cache/cost_centers.json — into CacheDir, unless this enterprise
+// already has a cache.
+func (m *Manager) MigrateLegacyCache() error {
+	if err := m.migrateLegacy(filepath.Join(m.ExportDir, cacheDirName), m.CacheDir); err != nil {
+		return err
 	}
+	return m.migrateLegacy(legacyCacheFile, filepath.Join(m.CacheDir, filepath.Base(legacyCacheFile)))
+}
 
-	t, err := time.Parse(time.RFC3339, td.LastRun)
+// migrateLegacy moves the file or directory at legacy to target when legacy
+// exists and target does not.
+func (m *Manager) migrateLegacy(legacy, target string) error {
+	if legacy == target {
+		return nil
+	}
+	if _, err := os.Stat(target); err == nil {
+		return nil
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+	if err := os.Rename(legacy, target); err != nil {
+		return fmt.Errorf("migrating %s: %w", legacy, err)
+	}
+	m.log.Info("Migrated legacy state to the per-enterprise directory", "from", legacy, "to", target)
+	return nil
+}
+
+// stateNamespace returns the path, relative to the export dir, of the state
+// of enterprise: its slug, under the API host when that is not github.com.
+func stateNamespace(enterprise, apiBaseURL string) string {
+	ns := safePathSegment(enterprise)
+	if apiBaseURL == DefaultAPIBaseURL {
+		return ns
+	}
+	u, err := url.Parse(apiBaseURL)
+	if err != nil || u.Host == "" {
+		return ns
+	}
+	return filepath.Join(safePathSegment(u.Host), ns)
+}
+
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// safePathSegment replaces characters that are not safe in a file name.
+func safePathSegment(s string) string {
+	s = unsafePathChars.ReplaceAllString(s, "_")
+	if s == "" || s == "." || s == ".." {
+		return "_"
+	}
+	return s
+}
+
+// ConfigHash returns a SHA-256 fingerprint of the parsed configuration and
+// the resolved enterprise and API URL.  Comments and formatting do not affect
+// it; plan files use it to detect configuration changes before apply.
+func (m *Manager) ConfigHash() string {
+	data, err := json.Marshal(struct {
+		Config     Config
+		Enterprise string
+		APIBaseURL string
+	}{m.cfg, m.Enterprise, m.APIBaseURL})
 	if err != nil {
-		return nil, fmt.Errorf("parsing timestamp value: %w", err)
+		// Config holds only plain data; Marshal cannot fail in practice.
+		return ""
 	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
 
-	m.log.Info("Loaded last run timestamp", "timestamp", td.LastRun)
-	return &t, nil
+// MergeExcludedUsers adds logins (e.g. from --exclude-users) to
+// ExcludedUsers, lower-cased and without duplicates.
+func (m *Manager) MergeExcludedUsers(logins []string) {
+	for _, l := range logins {
+		l = strings.ToLower(strings.TrimSpace(l))
+		if l != "" && !m.IsExcludedUser(l) {
+			m.ExcludedUsers = append(m.ExcludedUsers, l)
+		}
+	}
+}
+
+// IsExcludedUser reports whether login is in ExcludedUsers
+// (case-insensitively).
+func (m *Manager) IsExcludedUser(login string) bool {
+	return slices.Contains(m.ExcludedUsers, strings.ToLower(login))
+}
+
+// IsPRUExceptionUser reports whether login matches a PRUsExceptionUsers
+// login or login glob (case-insensitively).  Email-domain and team entries
+// cannot be checked from a login alone and never match.
+func (m *Manager) IsPRUExceptionUser(login string) bool {
+	login = strings.ToLower(login)
+	for _, e := range m.PRUsExceptionUsers {
+		e = strings.ToLower(e)
+		if e == login {
+			return true
+		}
+		if strings.ContainsAny(e, "*?[") {
+			if ok, _ := path.Match(e, login); ok {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // Summary returns a human-readable map of current configuration for display.
 func (m *Manager) Summary() map[string]any {
 	s := map[string]any{
-		"enterprise":       m.Enterprise,
-		"api_base_url":     m.APIBaseURL,
-		"organizations":    m.Organizations,
-		"cost_center_mode": m.CostCenterMode,
-		"budgets_enabled":  m.BudgetsEnabled,
-		"log_level":        m.LogLevel,
-		"export_dir":       m.ExportDir,
+		"enterprise":                m.Enterprise,
+		"api_base_url":              m.APIBaseURL,
+		"organizations":             m.Organizations,
+		"copilot_scope":             m.CopilotScope,
+		"cost_center_mode":          m.CostCenterMode,
+		"budgets_enabled":           m.BudgetsEnabled,
+		"log_level":                 m.LogLevel,
+		"export_dir":                m.ExportDir,
+		"state_dir":                 m.StateDir,
+		"cache_ttl":                 m.CacheTTL.String(),
+		"lock_stale_after":          m.LockStaleAfter.String(),
+		"skip_pending_cancellation": m.SkipPendingCancellation,
+		"include_non_user_accounts": m.IncludeNonUserAccounts,
+		"excluded_users_count":      len(m.ExcludedUsers),
+		"http_timeout":              DefaultHTTPTimeout.String(),
+	}
+	if m.CACertPath != "" {
+		s["ca_cert_path"] = m.CACertPath
+	}
+	if m.InsecureSkipVerify {
+		s["insecure_skip_verify"] = true
+	}
+	if m.Token != "" {
+		s["token"] = maskSecret(m.Token)
+	}
+	if m.BudgetsEnabled {
+		var products []string
+		for _, name := range slices.Sorted(maps.Keys(m.BudgetProducts)) {
+			if m.BudgetProducts[name].Enabled {
+				products = append(products, name)
+			}
+		}
+		s["budget_products"] = products
 	}
 
 	switch m.CostCenterMode {
@@ -476,8 +1315,23 @@ func (m *Manager) Summary() map[string]any {
 		s["no_prus_cost_center_id"] = m.NoPRUsCostCenterID
 		s["prus_allowed_cost_center_id"] = m.PRUsAllowedCostCenterID
 		s["prus_exception_users_count"] = len(m.PRUsExceptionUsers)
+		if m.PRUsExceptionUsersFile != "" {
+			s["prus_exception_users_file"] = m.PRUsExceptionUsersFile
+		}
+		if len(m.PRUsExceptionTeams) > 0 {
+			s["prus_exception_teams"] = m.PRUsExceptionTeams
+		}
+		s["prus_user_overrides_count"] = len(m.PRUsUserOverrides)
+		s["enforce_exclusive_membership"] = m.EnforceExclusiveMembership
+		if len(m.PRUTiers) > 0 {
+			s["prus_tiers_count"] = len(m.PRUTiers)
+		}
 		s["auto_create"] = m.AutoCreate
+		if m.PersistCreatedIDs {
+			s["persist_created_ids"] = true
+		}
 		s["enable_incremental"] = m.EnableIncremental
+		s["remove_departed_users"] = m.RemoveDepartedUsers
 		if m.Enterprise != "" {
 			s["no_prus_cost_center_url"] = fmt.Sprintf(
 				"https://github.com/enterprises/%s/billing/cost_centers/%s",
@@ -491,22 +1345,57 @@ func (m *Manager) Summary() map[string]any {
 
 	case "teams":
 		s["teams_scope"] = m.TeamsScope
+		if m.TeamsScope == "organization" {
+			s["teams_organizations_count"] = len(m.Organizations)
+		}
 		s["teams_strategy"] = m.TeamsStrategy
 		s["teams_auto_create"] = m.TeamsAutoCreate
 		s["teams_remove_unmatched_users"] = m.TeamsRemoveUnmatchedUsers
 		s["teams_mappings_count"] = len(m.TeamsMappings)
+		if len(m.TeamsBudgetOverrides) > 0 {
+			s["teams_budget_overrides_count"] = len(m.TeamsBudgetOverrides)
+		}
+		if m.TeamsMappingsFile != "" {
+			s["teams_mappings_file"] = m.TeamsMappingsFile
+		}
+		if m.TeamsNameTemplate != "" {
+			s["teams_cost_center_name_template"] = m.TeamsNameTemplate
+		}
+		s["teams_conflict_resolution"] = m.TeamsConflictResolution
+		s["teams_copilot_holders_only"] = m.TeamsCopilotHoldersOnly
+		if len(m.TeamsInclude) > 0 || len(m.TeamsExclude) > 0 {
+			s["teams_include"] = m.TeamsInclude
+			s["teams_exclude"] = m.TeamsExclude
+		}
 
 	case "repos":
+		s["repos_organizations"] = m.ReposOrganizations
 		s["repos_mappings_count"] = len(m.ReposMappings)
+		s["repos_unmatched_policy"] = m.ReposUnmatchedPolicy
+		s["repos_include_archived"] = m.ReposIncludeArchived
+		s["repos_remove_unmatched"] = m.ReposRemoveUnmatched
+		if m.ReposUnmatchedPolicy == "assign_default" {
+			s["repos_default_cost_center"] = m.ReposDefaultCostCenter
+		}
 
 	case "custom-prop":
 		s["custom_prop_cost_centers_count"] = len(m.CustomPropCostCenters)
 		s["custom_prop_remove_unmatched_repos"] = m.CustomPropRemoveUnmatched
+
+	case "assigning-team":
+		s["assigning_team_auto_create"] = m.AssigningTeamAutoCreate
+		s["assigning_team_default_cost_center"] = m.AssigningTeamDefaultCostCenter
 	}
 
 	if len(m.RepoCustomProperties) > 0 {
 		s["repo_custom_properties_count"] = len(m.RepoCustomProperties)
 	}
+	if overridden := m.Overridden(); len(overridden) > 0 {
+		s["overridden_keys"] = overridden
+	}
+	if vars := m.EnvOverridden(); len(vars) > 0 {
+		s["env_overrides"] = vars
+	}
 
 	return s
 }
@@ -624,6 +1513,9 @@ func validateExplicitMappings(mappings []ExplicitMapping) error {
 		if len(em.PropertyValues) == 0 {
 			return fmt.Errorf("repos.mappings[%d]: missing 'property_values'", i)
 		}
+		if _, err := NewValueMatcher(em.PropertyValues, em.CaseInsensitive); err != nil {
+			return fmt.Errorf("repos.mappings[%d]: property_values: %w", i, err)
+		}
 	}
 	return nil
 }
@@ -654,6 +1546,15 @@ func validateCustomPropCostCenters(entries []CustomPropCostCenter) error {
 	return nil
 }
 
+// maskSecret hides all but the last 4 characters of a token or other
+// secret, for display.
+func maskSecret(secret string) string {
+	if len(secret) <= 4 {
+		return strings.Repeat("*", len(secret))
+	}
+	return "****" + secret[len(secret)-4:]
+}
+
 // envOrFallback returns the env var value if set, otherwise the YAML fallback.
 func envOrFallback(envKey, yamlValue string) string {
 	if v := os.Getenv(envKey); v != "" {
@@ -662,6 +1563,48 @@ func envOrFallback(envKey, yamlValue string) string {
 	return yamlValue
 }
 
+// envOverride returns envOrFallback(envKey, yamlValue), recording the
+// environment variable as the source of the setting at path when it is set.
+// A --set override of path, or --enterprise for github.enterprise, beats the
+// environment variable.
+func (m *Manager) envOverride(envKey, path, yamlValue string) string {
+	if src := m.sources[path]; src == SetSource || src == EnterpriseFlagSource {
+		return yamlValue
+	}
+	if os.Getenv(envKey) != "" {
+		if m.sources == nil {
+			m.sources = map[string]string{}
+		}
+		m.sources[path] = envSourcePrefix + envKey
+	}
+	return envOrFallback(envKey, yamlValue)
+}
+
+// resolveLogRotation validates the logging rotation and run retention
+// settings and applies the defaults for the size and backup limits.
+func (m *Manager) resolveLogRotation() error {
+	lc := m.cfg.Logging
+	for _, v := range []struct {
+		key string
+		val int
+	}{{"max_size_mb", lc.MaxSizeMB}, {"max_backups", lc.MaxBackups}, {"max_age_days", lc.MaxAgeDays}, {"keep_runs", lc.KeepRuns}} {
+		if v.val < 0 {
+			return fmt.Errorf("invalid logging.%s %d: must not be negative", v.key, v.val)
+		}
+	}
+	m.LogMaxSizeMB = lc.MaxSizeMB
+	if m.LogMaxSizeMB == 0 {
+		m.LogMaxSizeMB = DefaultLogMaxSizeMB
+	}
+	m.LogMaxBackups = lc.MaxBackups
+	if m.LogMaxBackups == 0 {
+		m.LogMaxBackups = DefaultLogMaxBackups
+	}
+	m.LogMaxAgeDays = lc.MaxAgeDays
+	m.LogKeepRuns = lc.KeepRuns
+	return nil
+}
+
 // defaultString returns val if non-empty, otherwise def.
 func defaultString(val, def string) string {
 	if val != "" {
@@ -670,6 +1613,131 @@ func defaultString(val, def string) string {
 	return def
 }
 
+// validateBudgetAlerting checks that every configured alert recipient looks
+// like a GitHub login or an email address.
+func (m *Manager) validateBudgetAlerting() error {
+	for product, pb := range m.BudgetProducts {
+		for _, r := range pb.AlertRecipients {
+			if !loginPattern.MatchString(r) && !emailPattern.MatchString(r) {
+				return fmt.Errorf("invalid budgets.products.%s.alert_recipients entry %q: must be a GitHub login or an email address",
+					product, r)
+			}
+		}
+		if pb.AlertsEnabled && len(pb.AlertRecipients) == 0 {
+			m.log.Warn("Budget alerts enabled without recipients", "product", product)
+		}
+	}
+	return nil
+}
+
+// validateExceptionUsers checks the PRU exception entries: plain logins, glob
+// patterns ("svc-*"), or email domains ("@example.com").
+func validateExceptionUsers(entries []string) error {
+	return validateMemberEntries("cost_center.users.exception_users", entries, false)
+}
+
+// validateMemberEntries checks user-matching entries under the given config
+// key.  Team slugs ("org/team-slug") are only accepted when allowTeams is set.
+func validateMemberEntries(key string, entries []string, allowTeams bool) error {
+	for _, e := range entries {
+		switch {
+		case strings.HasPrefix(e, "@"):
+			if len(e) == 1 || strings.ContainsAny(e[1:], "@ \t") {
+				return fmt.Errorf("invalid %s entry %q: email domain must look like \"@example.com\"", key, e)
+			}
+		case strings.ContainsAny(e, "*?["):
+			if _, err := path.Match(e, ""); err != nil {
+				return fmt.Errorf("invalid %s entry %q: %w", key, e, err)
+			}
+		case allowTeams && strings.Contains(e, "/"):
+			org, slug, _ := strings.Cut(e, "/")
+			if org == "" || slug == "" || strings.Contains(slug, "/") {
+				return fmt.Errorf("invalid %s entry %q: team must look like \"org/team-slug\"", key, e)
+			}
+		case strings.TrimSpace(e) == "":
+			return fmt.Errorf("invalid %s entry %q: must not be empty", key, e)
+		}
+	}
+	return nil
+}
+
+// validateTiers checks the users-mode tier list: unique non-empty names, a
+// cost center ID or name on every tier, and valid member entries.
+func (m *Manager) validateTiers(tiers []PRUTier) error {
+	names := make(map[string]bool, len(tiers))
+	for i, t := range tiers {
+		if t.Name == "" {
+			return fmt.Errorf("invalid cost_center.users.tiers[%d]: name is required", i)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("invalid cost_center.users.tiers: duplicate tier name %q", t.Name)
+		}
+		names[t.Name] = true
+		if t.CostCenterID == "" && t.CostCenterName == "" {
+			return fmt.Errorf("invalid cost_center.users.tiers %q: cost_center_id or cost_center_name is required", t.Name)
+		}
+		if strings.HasPrefix(t.CostCenterID, "REPLACE_WITH_") && t.CostCenterName == "" {
+			return fmt.Errorf("invalid cost_center.users.tiers %q: cost_center_id is a placeholder and no cost_center_name is set", t.Name)
+		}
+		if t.BudgetAmount < 0 {
+			return fmt.Errorf("invalid cost_center.users.tiers %q: budget_amount must not be negative", t.Name)
+		}
+		key := fmt.Sprintf("cost_center.users.tiers %q members", t.Name)
+		if err := validateMemberEntries(key, t.Members, true); err != nil {
+			return err
+		}
+	}
+	if last := tiers[len(tiers)-1]; len(last.Members) > 0 {
+		m.log.Warn("Members of the last (default) tier are ignored", "tier", last.Name)
+	}
+	if u := m.cfg.CostCenter.Users; len(u.ExceptionUsers) > 0 || u.ExceptionUsersFile != "" || len(u.ExceptionTeams) > 0 {
+		m.log.Warn("cost_center.users.exception_users and exception_teams are ignored when tiers are configured")
+	}
+	return nil
+}
+
+// validateTeamPatterns rejects blank or malformed team slug / glob patterns.
+func validateTeamPatterns(key string, patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil || strings.TrimSpace(p) == "" {
+			return fmt.Errorf("%s: invalid team pattern %q", key, p)
+		}
+	}
+	return nil
+}
+
+// TeamsNamePlaceholders are the placeholders accepted in
+// cost_center.teams.cost_center_name_template.
+var TeamsNamePlaceholders = []string{"org", "team_name", "team_slug"}
+
+// placeholderPattern matches a "{name}" template placeholder.
+var placeholderPattern = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// validateNameTemplate rejects a blank name template or one using
+// placeholders other than allowed.  An empty template means the default.
+func validateNameTemplate(key, tmpl string, allowed []string) error {
+	if tmpl == "" {
+		return nil
+	}
+	if strings.TrimSpace(tmpl) == "" {
+		return fmt.Errorf("%s must not be blank", key)
+	}
+	for _, match := range placeholderPattern.FindAllStringSubmatch(tmpl, -1) {
+		if !slices.Contains(allowed, match[1]) {
+			return fmt.Errorf("%s: unknown placeholder {%s} (allowed: {%s})",
+				key, match[1], strings.Join(allowed, "}, {"))
+		}
+	}
+	return nil
+}
+
+// loginPattern matches a GitHub login: up to 39 alphanumerics or hyphens, not
+// starting or ending with a hyphen.
+var loginPattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]{0,37}[A-Za-z0-9])?$`)
+
+// emailPattern is a deliberately loose email check (something@domain.tld).
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
 // uuidPattern matches a standard UUID format (lowercase hex).
 var uuidPattern = regexp.MustCompile(
 	`^[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}$`,
@@ -1,12 +1,24 @@
 package config
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
+	"fmt"
 	"log/slog"
+	"math/big"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // helper to write a temp YAML config and return its path.
@@ -250,6 +262,123 @@ github:
 
 // ---------- Teams mode ----------
 
+func TestLoad_UsersModeExceptionPatterns(t *testing.T) {
+	valid := `
+github:
+  enterprise: "ent"
+cost_center:
+  users:
+    exception_users: ["alice", "svc-*", "@acme.com"]
+`
+	if _, err := Load(writeConfig(t, valid), logger()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	for _, bad := range []string{"svc-[", "@"} {
+		yaml := "github:\n  enterprise: \"ent\"\ncost_center:\n  users:\n    exception_users: [\"" + bad + "\"]\n"
+		_, err := Load(writeConfig(t, yaml), logger())
+		if err == nil || !strings.Contains(err.Error(), bad) {
+			t.Errorf("entry %q: expected error naming the entry, got %v", bad, err)
+		}
+	}
+}
+
+func TestLoad_UsersModeUserOverrides(t *testing.T) {
+	yaml := `
+github:
+  enterprise: "ent"
+cost_center:
+  users:
+    user_overrides:
+      Contractor-1: "Contractors"
+      bob: "d1e2f3a4-b5c6-7890-abcd-ef1234567890"
+`
+	m, err := Load(writeConfig(t, yaml), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.PRUsUserOverrides["contractor-1"] != "Contractors" || len(m.PRUsUserOverrides) != 2 {
+		t.Errorf("PRUsUserOverrides = %v", m.PRUsUserOverrides)
+	}
+
+	placeholder := "github:\n  enterprise: \"ent\"\ncost_center:\n  users:\n    user_overrides:\n      bob: \"REPLACE_WITH_COST_CENTER_ID\"\n"
+	if _, err := Load(writeConfig(t, placeholder), logger()); err == nil || !strings.Contains(err.Error(), "bob") {
+		t.Errorf("expected placeholder override to be rejected, got %v", err)
+	}
+}
+
+func TestLoad_UsersTiers(t *testing.T) {
+	yaml := `
+github:
+  enterprise: "ent"
+cost_center:
+  users:
+    tiers:
+      - name: "Power users"
+        cost_center_name: "CC Power"
+        budget_amount: 500
+        members: ["alice", "svc-*", "@example.com", "my-org/ml-team"]
+      - name: "Standard"
+        cost_center_id: "d1e2f3a4-b5c6-7890-abcd-ef1234567890"
+`
+	m, err := Load(writeConfig(t, yaml), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(m.PRUTiers) != 2 || m.PRUTiers[0].BudgetAmount != 500 || len(m.PRUTiers[0].Members) != 4 {
+		t.Errorf("PRUTiers = %+v", m.PRUTiers)
+	}
+
+	bad := map[string]string{
+		"missing name":    "      - cost_center_name: \"A\"\n",
+		"duplicate name":  "      - name: \"A\"\n        cost_center_name: \"A\"\n      - name: \"A\"\n        cost_center_name: \"B\"\n",
+		"no cost center":  "      - name: \"A\"\n",
+		"bad team":        "      - name: \"A\"\n        cost_center_name: \"A\"\n        members: [\"org/\"]\n",
+		"negative budget": "      - name: \"A\"\n        cost_center_name: \"A\"\n        budget_amount: -1\n",
+	}
+	for name, tiers := range bad {
+		t.Run(name, func(t *testing.T) {
+			cfg := "github:\n  enterprise: \"ent\"\ncost_center:\n  users:\n    tiers:\n" + tiers
+			if _, err := Load(writeConfig(t, cfg), logger()); err == nil || !strings.Contains(err.Error(), "cost_center.users.tiers") {
+				t.Errorf("expected tiers validation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLoad_EnforceExclusiveMembership(t *testing.T) {
+	m, err := Load(writeConfig(t, "github:\n  enterprise: \"ent\"\n"), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !m.EnforceExclusiveMembership {
+		t.Error("EnforceExclusiveMembership should default to true")
+	}
+
+	off := "github:\n  enterprise: \"ent\"\ncost_center:\n  users:\n    enforce_exclusive_membership: false\n"
+	if m, err = Load(writeConfig(t, off), logger()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.EnforceExclusiveMembership {
+		t.Error("EnforceExclusiveMembership = true; want false when disabled")
+	}
+}
+
+func TestLoad_ExcludedUsers(t *testing.T) {
+	yaml := "github:\n  enterprise: \"ent\"\ncost_center:\n  excluded_users: [\"CEO\", \" cfo \"]\n"
+	m, err := Load(writeConfig(t, yaml), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	m.MergeExcludedUsers([]string{"Cto", "ceo"})
+	if strings.Join(m.ExcludedUsers, ",") != "ceo,cfo,cto" {
+		t.Errorf("ExcludedUsers = %v, want [ceo cfo cto]", m.ExcludedUsers)
+	}
+	if !m.IsExcludedUser("CFO") || m.IsExcludedUser("alice") {
+		t.Error("IsExcludedUser must match case-insensitively and only listed logins")
+	}
+}
+
 func TestLoad_TeamsMode(t *testing.T) {
 	yaml := `
 github:
@@ -339,6 +468,226 @@ cost_center:
 	}
 }
 
+// writeMappingsConfig writes a manual-strategy teams config next to a
+// mappings file called name and returns the config path.
+func writeMappingsConfig(t *testing.T, name, fileContent, inline string) string {
+	t.Helper()
+	p := writeConfig(t, `
+github:
+  enterprise: "ent"
+  organizations: ["my-org"]
+cost_center:
+  mode: "teams"
+  teams:
+    scope: "organization"
+    strategy: "manual"
+    auto_create: true
+    mappings_file: "`+name+`"
+    mappings:
+`+inline)
+	file := filepath.Join(filepath.Dir(p), name)
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(fileContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLoad_TeamsMappingsFile(t *testing.T) {
+	want := map[string]string{"my-org/frontend": "CC-FRONTEND", "my-org/backend": "CC Backend, Ops"}
+	tests := []struct {
+		name, file, content string
+	}{
+		{"csv with header", "mappings/teams.csv", "team_slug,cost_center\n# comment\nmy-org/frontend,CC-FRONTEND\nmy-org/backend, \"CC Backend, Ops\"\n"},
+		{"csv without header", "teams.csv", "my-org/frontend,CC-FRONTEND\nmy-org/backend,\"CC Backend, Ops\"\n"},
+		{"yaml mapping", "teams.yaml", "my-org/frontend: CC-FRONTEND\nmy-org/backend: \"CC Backend, Ops\"\n"},
+		{"yaml list", "teams.yml", "- team_slug: my-org/frontend\n  cost_center: CC-FRONTEND\n- team_slug: my-org/backend\n  cost_center: \"CC Backend, Ops\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Load(writeMappingsConfig(t, tt.file, tt.content, "      {}\n"), logger())
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if len(m.TeamsMappings) != len(want) {
+				t.Errorf("TeamsMappings = %v, want %v", m.TeamsMappings, want)
+			}
+			for k, v := range want {
+				if m.TeamsMappings[k] != v {
+					t.Errorf("TeamsMappings[%q] = %q, want %q", k, m.TeamsMappings[k], v)
+				}
+			}
+			if !filepath.IsAbs(m.TeamsMappingsFile) || !strings.HasSuffix(m.TeamsMappingsFile, filepath.FromSlash(tt.file)) {
+				t.Errorf("TeamsMappingsFile = %q, want resolved next to the config", m.TeamsMappingsFile)
+			}
+		})
+	}
+}
+
+func TestLoad_TeamsMappingsFileInlineWins(t *testing.T) {
+	p := writeMappingsConfig(t, "teams.csv", "my-org/frontend,CC-FILE\nmy-org/backend,CC-BACKEND\n",
+		"      \"my-org/frontend\": \"CC-INLINE\"\n      \"my-org/docs\": \"CC-DOCS\"\n")
+	m, err := Load(p, logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := map[string]string{"my-org/frontend": "CC-INLINE", "my-org/backend": "CC-BACKEND", "my-org/docs": "CC-DOCS"}
+	if len(m.TeamsMappings) != len(want) {
+		t.Errorf("TeamsMappings = %v, want %v", m.TeamsMappings, want)
+	}
+	for k, v := range want {
+		if m.TeamsMappings[k] != v {
+			t.Errorf("TeamsMappings[%q] = %q, want %q", k, m.TeamsMappings[k], v)
+		}
+	}
+}
+
+func TestLoad_TeamsMappingsFileErrors(t *testing.T) {
+	tests := []struct {
+		name, file, content, wantErr string
+	}{
+		{"csv missing column", "teams.csv", "team_slug,cost_center\nmy-org/frontend,CC\nmy-org/backend\n", "teams.csv: line 3: want 2 columns"},
+		{"csv empty cost center", "teams.csv", "my-org/frontend,CC\n\nmy-org/backend,  \n", "line 3: cost_center for \"my-org/backend\" is empty"},
+		{"csv duplicate team", "teams.csv", "my-org/frontend,A\nmy-org/frontend,B\n", "line 2: team \"my-org/frontend\" is already mapped on line 1"},
+		{"yaml non-string value", "teams.yaml", "my-org/frontend: CC\nmy-org/backend:\n  - a\n", "line 3: cost center for \"my-org/backend\" must be a string"},
+		{"yaml list entry without team", "teams.yaml", "- team_slug: my-org/frontend\n  cost_center: CC\n- cost_center: CC\n", "line 3: team_slug is empty"},
+		{"missing file", "nope.csv", "", "reading teams mappings file"},
+		{"unsupported extension", "teams.txt", "my-org/frontend,CC\n", "unsupported extension"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := writeMappingsConfig(t, tt.file, tt.content, "      {}\n")
+			if tt.name == "missing file" {
+				if err := os.Remove(filepath.Join(filepath.Dir(p), tt.file)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, err := Load(p, logger())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad_TeamsBudgetOverrides(t *testing.T) {
+	config := func(amount string) string {
+		return `
+github:
+  enterprise: "ent"
+cost_center:
+  mode: "teams"
+  teams:
+    budget_overrides:
+      platform: ` + amount + `
+      "my-org/sre": 800
+`
+	}
+	m, err := Load(writeConfig(t, config("500")), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.TeamsBudgetOverrides["platform"] != 500 || m.TeamsBudgetOverrides["my-org/sre"] != 800 {
+		t.Errorf("TeamsBudgetOverrides = %v", m.TeamsBudgetOverrides)
+	}
+
+	if _, err := Load(writeConfig(t, config("0")), logger()); err == nil || !strings.Contains(err.Error(), "budget_overrides") {
+		t.Errorf("Load error = %v, want invalid budget_overrides entry", err)
+	}
+}
+
+func TestLoad_CopilotScope(t *testing.T) {
+	yaml := `
+github:
+  enterprise: "ent"
+  copilot_scope: "organization"
+  organizations: ["org-a", "org-b"]
+`
+	m, err := Load(writeConfig(t, yaml), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.CopilotScope != "organization" {
+		t.Errorf("CopilotScope = %q, want organization", m.CopilotScope)
+	}
+
+	for name, bad := range map[string]string{
+		"missing orgs":  "github:\n  enterprise: \"ent\"\n  copilot_scope: \"organization\"\n",
+		"invalid scope": "github:\n  enterprise: \"ent\"\n  copilot_scope: \"team\"\n",
+	} {
+		if _, err := Load(writeConfig(t, bad), logger()); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// testCertPEM returns a self-signed certificate in PEM form.
+func testCertPEM(t *testing.T) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "ghes.internal"}, NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestLoad_TLSSettings(t *testing.T) {
+	p := writeConfig(t, "github:\n  enterprise: \"ent\"\n  ca_cert_path: \"certs/ca.pem\"\n")
+	file := filepath.Join(filepath.Dir(p), "certs", "ca.pem")
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, testCertPEM(t), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Load(p, logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.CACertPath != file || m.InsecureSkipVerify {
+		t.Errorf("CACertPath = %q, InsecureSkipVerify = %v; want %s and false", m.CACertPath, m.InsecureSkipVerify, file)
+	}
+	if s := m.Summary(); s["ca_cert_path"] != file {
+		t.Errorf("summary ca_cert_path = %v", s["ca_cert_path"])
+	}
+
+	if err := os.WriteFile(file, []byte("not a certificate\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(p, logger()); err == nil || !strings.Contains(err.Error(), "no PEM certificates") {
+		t.Errorf("Load error = %v, want no PEM certificates", err)
+	}
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(p, logger()); err == nil || !strings.Contains(err.Error(), "github.ca_cert_path") {
+		t.Errorf("Load error = %v, want a github.ca_cert_path error", err)
+	}
+
+	t.Run("insecure skip verify from env", func(t *testing.T) {
+		t.Setenv("GHCC_INSECURE_SKIP_VERIFY", "true")
+		var buf strings.Builder
+		log := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+		m, err := Load(writeConfig(t, "github:\n  enterprise: \"ent\"\n"), log)
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if !m.InsecureSkipVerify || m.Source("github.insecure_skip_verify") != "env GHCC_INSECURE_SKIP_VERIFY" {
+			t.Errorf("InsecureSkipVerify = %v from %q", m.InsecureSkipVerify, m.Source("github.insecure_skip_verify"))
+		}
+		if !strings.Contains(buf.String(), "level=WARN") || !strings.Contains(buf.String(), "VERIFICATION IS DISABLED") {
+			t.Errorf("log = %q, want a warning that verification is disabled", buf.String())
+		}
+	})
+}
+
 func TestLooksLikeUUID(t *testing.T) {
 	tests := []struct {
 		input string
@@ -362,6 +711,47 @@ func TestLooksLikeUUID(t *testing.T) {
 
 // ---------- Repos mode ----------
 
+func TestLoad_AssigningTeamMode(t *testing.T) {
+	yaml := `
+github:
+  enterprise: "ent"
+cost_center:
+  mode: "assigning-team"
+  assigning_team:
+    auto_create: true
+    default_cost_center: "Unassigned seats"
+`
+	m, err := Load(writeConfig(t, yaml), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.CostCenterMode != "assigning-team" {
+		t.Errorf("CostCenterMode = %q", m.CostCenterMode)
+	}
+	if !m.AssigningTeamAutoCreate {
+		t.Error("AssigningTeamAutoCreate = false, want true")
+	}
+	if m.AssigningTeamDefaultCostCenter != "Unassigned seats" {
+		t.Errorf("AssigningTeamDefaultCostCenter = %q", m.AssigningTeamDefaultCostCenter)
+	}
+}
+
+func TestLoad_AssigningTeamModeDefaults(t *testing.T) {
+	yaml := `
+github:
+  enterprise: "ent"
+cost_center:
+  mode: "assigning-team"
+`
+	m, err := Load(writeConfig(t, yaml), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.AssigningTeamDefaultCostCenter != DefaultAssigningTeamDefaultCC {
+		t.Errorf("AssigningTeamDefaultCostCenter = %q, want default", m.AssigningTeamDefaultCostCenter)
+	}
+}
+
 func TestLoad_ReposMode(t *testing.T) {
 	yaml := `
 github:
@@ -394,6 +784,9 @@ cost_center:
 	if len(m.ReposMappings[0].PropertyValues) != 2 {
 		t.Errorf("expected 2 property values, got %d", len(m.ReposMappings[0].PropertyValues))
 	}
+	if m.ReposIncludeArchived {
+		t.Error("ReposIncludeArchived should default to false")
+	}
 }
 
 func TestLoad_ReposModeRequiresOrgs(t *testing.T) {
@@ -428,70 +821,240 @@ cost_center:
 	}
 }
 
-// ---------- Custom-prop mode ----------
-
-func TestLoad_CustomPropMode(t *testing.T) {
-	yaml := `
+func TestLoad_ReposUnmatchedPolicy(t *testing.T) {
+	config := func(extra string) string {
+		return `
 github:
   enterprise: "ent"
-  organizations:
-    - "my-org"
+  organizations: ["org"]
 cost_center:
-  mode: "custom-prop"
-  custom_prop:
-    cost_centers:
-      - name: "Backend Engineering"
-        filters:
-          - property: "team"
-            value: "backend"
-          - property: "cost-center-id"
-            value: "CC-1234"
-      - name: "Frontend Engineering"
-        filters:
-          - property: "team"
-            value: "frontend"
-`
-	m, err := Load(writeConfig(t, yaml), logger())
+  mode: "repos"
+  repos:
+    mappings:
+      - cost_center: "Platform"
+        property_name: "team"
+        property_values: ["platform"]
+` + extra
+	}
+
+	m, err := Load(writeConfig(t, config("")), logger())
 	if err != nil {
 		t.Fatalf("Load: %v", err)
 	}
-	if m.CostCenterMode != "custom-prop" {
-		t.Errorf("mode = %q", m.CostCenterMode)
-	}
-	if len(m.CustomPropCostCenters) != 2 {
-		t.Fatalf("expected 2 custom-prop cost centers, got %d", len(m.CustomPropCostCenters))
+	if m.ReposUnmatchedPolicy != "skip" {
+		t.Errorf("ReposUnmatchedPolicy = %q, want default skip", m.ReposUnmatchedPolicy)
 	}
 
-	backend := m.CustomPropCostCenters[0]
-	if backend.Name != "Backend Engineering" {
-		t.Errorf("name = %q", backend.Name)
-	}
-	if len(backend.Filters) != 2 {
-		t.Fatalf("expected 2 filters, got %d", len(backend.Filters))
+	m, err = Load(writeConfig(t, config("    unmatched_policy: assign_default\n    default_cost_center: \"Unallocated\"\n")), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
 	}
-	if backend.Filters[0].Property != "team" || backend.Filters[0].Value != "backend" {
-		t.Errorf("filter[0] = {%q, %q}", backend.Filters[0].Property, backend.Filters[0].Value)
+	if m.ReposUnmatchedPolicy != "assign_default" || m.ReposDefaultCostCenter != "Unallocated" {
+		t.Errorf("policy = %q, default = %q", m.ReposUnmatchedPolicy, m.ReposDefaultCostCenter)
 	}
-	if backend.Filters[1].Property != "cost-center-id" || backend.Filters[1].Value != "CC-1234" {
-		t.Errorf("filter[1] = {%q, %q}", backend.Filters[1].Property, backend.Filters[1].Value)
+
+	for extra, wantErr := range map[string]string{
+		"    unmatched_policy: assign_default\n": "requires cost_center.repos.default_cost_center",
+		"    unmatched_policy: ignore\n":         "invalid cost_center.repos.unmatched_policy",
+	} {
+		if _, err := Load(writeConfig(t, config(extra)), logger()); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("%q: Load error = %v, want %q", extra, err, wantErr)
+		}
 	}
 }
 
-func TestLoad_CustomPropModeRequiresOrgs(t *testing.T) {
-	yaml := `
+func TestLoad_ReposOrganizations(t *testing.T) {
+	config := func(repos string) string {
+		return `
 github:
   enterprise: "ent"
+  organizations: ["org1"]
 cost_center:
-  mode: "custom-prop"
-  custom_prop:
-    cost_centers:
-      - name: "Backend"
-        filters:
-          - property: "team"
-            value: "backend"
-`
-	_, err := Load(writeConfig(t, yaml), logger())
-	if err == nil {
+  mode: "repos"
+  repos:
+` + repos
+	}
+
+	m, err := Load(writeConfig(t, config(`    mappings:
+      - cost_center: "Platform"
+        property_name: "team"
+        property_values: ["platform"]
+`)), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if strings.Join(m.ReposOrganizations, ",") != "org1" {
+		t.Errorf("ReposOrganizations = %v, want github.organizations", m.ReposOrganizations)
+	}
+
+	m, err = Load(writeConfig(t, config(`    organizations: ["org-a", " org-b "]
+    mappings:
+      - cost_center: "Platform"
+        property_name: "team"
+        property_values: ["platform"]
+      - cost_center: "Data"
+        organization: "org-b"
+        property_name: "team"
+        property_values: ["data"]
+`)), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if strings.Join(m.ReposOrganizations, ",") != "org-a,org-b" {
+		t.Errorf("ReposOrganizations = %v, want org-a,org-b", m.ReposOrganizations)
+	}
+	if m.ReposMappings[1].Organization != "org-b" {
+		t.Errorf("mappings[1].Organization = %q", m.ReposMappings[1].Organization)
+	}
+
+	_, err = Load(writeConfig(t, config(`    mappings:
+      - cost_center: "Platform"
+        property_name: "team"
+        property_values: ["platform"]
+      - cost_center: "Data"
+        organization: "org-b"
+        property_name: "team"
+        property_values: ["data"]
+`)), logger())
+	if err == nil || !strings.Contains(err.Error(), `repos.mappings[1]: organization "org-b"`) {
+		t.Errorf("Load error = %v, want the out-of-scope organization rejected", err)
+	}
+}
+
+func TestLoad_ReposPatternValues(t *testing.T) {
+	config := func(values string) string {
+		return `
+github:
+  enterprise: "ent"
+  organizations: ["org"]
+cost_center:
+  mode: "repos"
+  repos:
+    mappings:
+      - cost_center: "Platform"
+        property_name: "team"
+        property_values: ["platform"]
+      - cost_center: "Payments"
+        property_name: "team"
+        case_insensitive: true
+        property_values: ` + values + "\n"
+	}
+
+	m, err := Load(writeConfig(t, config(`["payments-*", "/^billing-(api|web)$/"]`)), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !m.ReposMappings[1].CaseInsensitive || m.ReposMappings[0].CaseInsensitive {
+		t.Errorf("case_insensitive = %v, %v; want false, true", m.ReposMappings[0].CaseInsensitive, m.ReposMappings[1].CaseInsensitive)
+	}
+
+	for _, values := range []string{`["/billing-(/"]`, `["payments-["]`} {
+		_, err := Load(writeConfig(t, config(values)), logger())
+		if err == nil || !strings.Contains(err.Error(), "repos.mappings[1]") {
+			t.Errorf("%s: Load error = %v, want one naming repos.mappings[1]", values, err)
+		}
+	}
+}
+
+func TestValueMatcher(t *testing.T) {
+	vm, err := NewValueMatcher([]string{"platform", "payments-*", "/^billing-\\d+$/"}, false)
+	if err != nil {
+		t.Fatalf("NewValueMatcher: %v", err)
+	}
+	for val, want := range map[string]bool{
+		"platform":     true,
+		"Platform":     false,
+		"platform-x":   false,
+		"payments-api": true,
+		"payments":     false,
+		"billing-42":   true,
+		"billing-x":    false,
+		"/^billing-/":  false,
+	} {
+		if got := vm.Match(val); got != want {
+			t.Errorf("Match(%q) = %v, want %v", val, got, want)
+		}
+	}
+
+	folded, err := NewValueMatcher([]string{"Platform", "PAYMENTS-*", "/^billing-/"}, true)
+	if err != nil {
+		t.Fatalf("NewValueMatcher: %v", err)
+	}
+	for _, val := range []string{"platform", "payments-API", "Billing-1"} {
+		if !folded.Match(val) {
+			t.Errorf("case-insensitive Match(%q) = false, want true", val)
+		}
+	}
+
+	if _, err := NewValueMatcher([]string{"/(/"}, false); err == nil {
+		t.Error("expected error for invalid regular expression")
+	}
+}
+
+// ---------- Custom-prop mode ----------
+
+func TestLoad_CustomPropMode(t *testing.T) {
+	yaml := `
+github:
+  enterprise: "ent"
+  organizations:
+    - "my-org"
+cost_center:
+  mode: "custom-prop"
+  custom_prop:
+    cost_centers:
+      - name: "Backend Engineering"
+        filters:
+          - property: "team"
+            value: "backend"
+          - property: "cost-center-id"
+            value: "CC-1234"
+      - name: "Frontend Engineering"
+        filters:
+          - property: "team"
+            value: "frontend"
+`
+	m, err := Load(writeConfig(t, yaml), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.CostCenterMode != "custom-prop" {
+		t.Errorf("mode = %q", m.CostCenterMode)
+	}
+	if len(m.CustomPropCostCenters) != 2 {
+		t.Fatalf("expected 2 custom-prop cost centers, got %d", len(m.CustomPropCostCenters))
+	}
+
+	backend := m.CustomPropCostCenters[0]
+	if backend.Name != "Backend Engineering" {
+		t.Errorf("name = %q", backend.Name)
+	}
+	if len(backend.Filters) != 2 {
+		t.Fatalf("expected 2 filters, got %d", len(backend.Filters))
+	}
+	if backend.Filters[0].Property != "team" || backend.Filters[0].Value != "backend" {
+		t.Errorf("filter[0] = {%q, %q}", backend.Filters[0].Property, backend.Filters[0].Value)
+	}
+	if backend.Filters[1].Property != "cost-center-id" || backend.Filters[1].Value != "CC-1234" {
+		t.Errorf("filter[1] = {%q, %q}", backend.Filters[1].Property, backend.Filters[1].Value)
+	}
+}
+
+func TestLoad_CustomPropModeRequiresOrgs(t *testing.T) {
+	yaml := `
+github:
+  enterprise: "ent"
+cost_center:
+  mode: "custom-prop"
+  custom_prop:
+    cost_centers:
+      - name: "Backend"
+        filters:
+          - property: "team"
+            value: "backend"
+`
+	_, err := Load(writeConfig(t, yaml), logger())
+	if err == nil {
 		t.Fatal("expected error for custom-prop mode without organizations")
 	}
 }
@@ -745,10 +1308,10 @@ export_dir: "` + dir + `"
 		t.Fatalf("Load: %v", err)
 	}
 	ts := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
-	if err := m.SaveLastRunTimestamp(&ts); err != nil {
+	if err := m.SaveLastRunTimestamp(TimestampModePRU, &ts); err != nil {
 		t.Fatalf("SaveLastRunTimestamp: %v", err)
 	}
-	got, err := m.LoadLastRunTimestamp()
+	got, err := m.LoadLastRunTimestamp(TimestampModePRU)
 	if err != nil {
 		t.Fatalf("LoadLastRunTimestamp: %v", err)
 	}
@@ -772,7 +1335,7 @@ export_dir: "` + dir + `"
 	if err != nil {
 		t.Fatalf("Load: %v", err)
 	}
-	got, err := m.LoadLastRunTimestamp()
+	got, err := m.LoadLastRunTimestamp(TimestampModePRU)
 	if err != nil {
 		t.Fatalf("LoadLastRunTimestamp: %v", err)
 	}
@@ -931,6 +1494,53 @@ github:
 	}
 }
 
+func TestLoad_BudgetAlerting(t *testing.T) {
+	yaml := `
+github:
+  enterprise: "ent"
+budgets:
+  enabled: true
+  products:
+    copilot:
+      amount: 100
+      enabled: true
+      alerts_enabled: true
+      alert_recipients: ["octocat", "finance@example.com"]
+`
+	m, err := Load(writeConfig(t, yaml), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	pb := m.BudgetProducts["copilot"]
+	if !pb.AlertsEnabled {
+		t.Error("AlertsEnabled = false, want true")
+	}
+	if len(pb.AlertRecipients) != 2 || pb.AlertRecipients[1] != "finance@example.com" {
+		t.Errorf("AlertRecipients = %v", pb.AlertRecipients)
+	}
+}
+
+func TestLoad_BudgetAlertingInvalidRecipient(t *testing.T) {
+	for _, bad := range []string{"-octocat", "not an email@", "a@b"} {
+		t.Run(bad, func(t *testing.T) {
+			yaml := `
+github:
+  enterprise: "ent"
+budgets:
+  products:
+    copilot:
+      amount: 100
+      enabled: true
+      alert_recipients: ["` + bad + `"]
+`
+			_, err := Load(writeConfig(t, yaml), logger())
+			if err == nil || !strings.Contains(err.Error(), "alert_recipients") {
+				t.Fatalf("expected alert_recipients validation error, got %v", err)
+			}
+		})
+	}
+}
+
 // ---------- Timestamp file JSON structure ----------
 
 func TestTimestamp_JSONFormat(t *testing.T) {
@@ -945,10 +1555,10 @@ export_dir: "` + dir + `"
 		t.Fatalf("Load: %v", err)
 	}
 	ts := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
-	if err := m.SaveLastRunTimestamp(&ts); err != nil {
+	if err := m.SaveLastRunTimestamp(TimestampModePRU, &ts); err != nil {
 		t.Fatalf("SaveLastRunTimestamp: %v", err)
 	}
-	data, err := os.ReadFile(filepath.Join(dir, timestampFileName))
+	data, err := os.ReadFile(filepath.Join(m.StateDir, timestampFileName))
 	if err != nil {
 		t.Fatalf("reading timestamp file: %v", err)
 	}
@@ -956,11 +1566,15 @@ export_dir: "` + dir + `"
 	if err := json.Unmarshal(data, &td); err != nil {
 		t.Fatalf("unmarshalling: %v", err)
 	}
-	if td.LastRun != "2025-01-01T00:00:00Z" {
-		t.Errorf("last_run = %q", td.LastRun)
+	e := td.Modes[TimestampModePRU]
+	if e.LastRun != "2025-01-01T00:00:00Z" {
+		t.Errorf("modes.pru.last_run = %q", e.LastRun)
+	}
+	if e.SavedAt == "" {
+		t.Error("modes.pru.saved_at is empty")
 	}
-	if td.SavedAt == "" {
-		t.Error("saved_at is empty")
+	if td.LastRun != "" || td.SavedAt != "" {
+		t.Errorf("single-value fields should not be written: %+v", td)
 	}
 }
 
@@ -1245,3 +1859,1411 @@ github:
 		t.Error("Summary should not include repo_custom_properties_count when empty")
 	}
 }
+
+func TestLoad_TeamsNameTemplate(t *testing.T) {
+	yaml := `
+github:
+  enterprise: "ent"
+cost_center:
+  mode: "teams"
+  teams:
+    cost_center_name_template: "Eng - {team_name} ({org}/{team_slug})"
+`
+	m, err := Load(writeConfig(t, yaml), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.TeamsNameTemplate != "Eng - {team_name} ({org}/{team_slug})" {
+		t.Errorf("TeamsNameTemplate = %q", m.TeamsNameTemplate)
+	}
+}
+
+func TestLoad_TeamsNameTemplateDefault(t *testing.T) {
+	m, err := Load(writeConfig(t, "github:\n  enterprise: \"ent\"\ncost_center:\n  mode: \"teams\"\n"), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.TeamsNameTemplate != "{team_name}" {
+		t.Errorf("TeamsNameTemplate = %q, want the team name alone", m.TeamsNameTemplate)
+	}
+}
+
+func TestLoad_TeamsNameTemplateUnknownPlaceholder(t *testing.T) {
+	yaml := `
+github:
+  enterprise: "ent"
+cost_center:
+  mode: "teams"
+  teams:
+    cost_center_name_template: "Eng - {team}"
+`
+	_, err := Load(writeConfig(t, yaml), logger())
+	if err == nil || !strings.Contains(err.Error(), "unknown placeholder {team}") {
+		t.Fatalf("err = %v, want unknown placeholder error", err)
+	}
+}
+
+func TestLoad_TeamsConflictResolution(t *testing.T) {
+	base := `
+github:
+  enterprise: "ent"
+cost_center:
+  mode: "teams"
+  teams:
+`
+	m, err := Load(writeConfig(t, base+"    strategy: \"auto\"\n"), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.TeamsConflictResolution != DefaultTeamsConflict {
+		t.Errorf("TeamsConflictResolution = %q, want default %q", m.TeamsConflictResolution, DefaultTeamsConflict)
+	}
+
+	m, err = Load(writeConfig(t, base+"    conflict_resolution: \"priority_list\"\n    priority: [\"security\", \"platform\"]\n"), logger())
+	if err != nil {
+		t.Fatalf("Load priority_list: %v", err)
+	}
+	if strings.Join(m.TeamsPriority, ",") != "security,platform" {
+		t.Errorf("TeamsPriority = %v", m.TeamsPriority)
+	}
+
+	for _, bad := range []string{
+		"    conflict_resolution: \"priority_list\"\n",
+		"    conflict_resolution: \"last_wins\"\n",
+	} {
+		if _, err := Load(writeConfig(t, base+bad), logger()); err == nil {
+			t.Errorf("expected error for %q", strings.TrimSpace(bad))
+		}
+	}
+}
+
+func TestLoad_TeamsModeKeepsPRUExceptionUsers(t *testing.T) {
+	yaml := `
+github:
+  enterprise: "ent"
+cost_center:
+  mode: "teams"
+  users:
+    exception_users: ["VIP", "svc-*", "@example.com"]
+`
+	m, err := Load(writeConfig(t, yaml), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	for login, want := range map[string]bool{"vip": true, "svc-build": true, "alice": false, "alice@example.com": false} {
+		if got := m.IsPRUExceptionUser(login); got != want {
+			t.Errorf("IsPRUExceptionUser(%q) = %v, want %v", login, got, want)
+		}
+	}
+}
+
+func TestLoad_TeamsIncludeExclude(t *testing.T) {
+	base := `
+github:
+  enterprise: "ent"
+cost_center:
+  mode: "teams"
+  teams:
+`
+	m, err := Load(writeConfig(t, base+"    include: [\"eng-*\"]\n    exclude: [\"eng-bots\"]\n"), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if strings.Join(m.TeamsInclude, ",") != "eng-*" || strings.Join(m.TeamsExclude, ",") != "eng-bots" {
+		t.Errorf("include = %v, exclude = %v", m.TeamsInclude, m.TeamsExclude)
+	}
+	if _, err := Load(writeConfig(t, base+"    exclude: [\"eng-[\"]\n"), logger()); err == nil {
+		t.Error("expected error for a malformed glob")
+	}
+}
+
+func TestLoad_TeamsCopilotHoldersOnly(t *testing.T) {
+	base := `
+github:
+  enterprise: "ent"
+cost_center:
+  mode: "teams"
+  teams:
+    strategy: "auto"
+`
+	m, err := Load(writeConfig(t, base), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !m.TeamsCopilotHoldersOnly {
+		t.Error("TeamsCopilotHoldersOnly should default to true")
+	}
+	if m, err = Load(writeConfig(t, base+"    copilot_holders_only: false\n"), logger()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.TeamsCopilotHoldersOnly {
+		t.Error("TeamsCopilotHoldersOnly = true; want false when disabled")
+	}
+}
+
+func TestLoad_CacheTTL(t *testing.T) {
+	base := `
+github:
+  enterprise: "ent"
+export_dir: "out"
+`
+	m, err := Load(writeConfig(t, base), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.CacheTTL != DefaultCacheTTL {
+		t.Errorf("CacheTTL = %s, want %s", m.CacheTTL, DefaultCacheTTL)
+	}
+	if m.CacheDir != filepath.Join(m.StateDir, "cache") {
+		t.Errorf("CacheDir = %q, want the cache directory under %s", m.CacheDir, m.StateDir)
+	}
+
+	if m, err = Load(writeConfig(t, base+"cache:\n  ttl: \"90m\"\n"), logger()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.CacheTTL != 90*time.Minute {
+		t.Errorf("CacheTTL = %s, want 1h30m0s", m.CacheTTL)
+	}
+
+	for _, ttl := range []string{"tomorrow", "0s", "-1h"} {
+		_, err := Load(writeConfig(t, base+"cache:\n  ttl: \""+ttl+"\"\n"), logger())
+		if err == nil || !strings.Contains(err.Error(), "invalid cache.ttl") {
+			t.Errorf("ttl %q: err = %v, want invalid cache.ttl error", ttl, err)
+		}
+	}
+}
+
+func TestStateDir_PerEnterprise(t *testing.T) {
+	dir := t.TempDir()
+	load := func(enterprise, apiURL string) *Manager {
+		t.Helper()
+		t.Setenv("GITHUB_ENTERPRISE", enterprise)
+		t.Setenv("GITHUB_API_BASE_URL", apiURL)
+		m, err := Load(writeConfig(t, "export_dir: \""+dir+"\"\n"), logger())
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		return m
+	}
+
+	acme := load("acme-corp", "")
+	globex := load("globex", "")
+	if acme.StateDir != filepath.Join(dir, "acme-corp") || globex.StateDir != filepath.Join(dir, "globex") {
+		t.Errorf("StateDir = %q, %q; want one directory per enterprise", acme.StateDir, globex.StateDir)
+	}
+	if acme.CacheDir != filepath.Join(dir, "acme-corp", "cache") {
+		t.Errorf("CacheDir = %q", acme.CacheDir)
+	}
+	ghes := load("acme-corp", "https://ghes.example.com:8443/api/v3")
+	if want := filepath.Join(dir, "ghes.example.com_8443", "acme-corp"); ghes.StateDir != want {
+		t.Errorf("GHES StateDir = %q, want %q", ghes.StateDir, want)
+	}
+
+	// Each enterprise keeps its own incremental-run timestamp.
+	ts1 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts2 := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	if err := acme.SaveLastRunTimestamp(TimestampModePRU, &ts1); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := globex.LoadLastRunTimestamp(TimestampModePRU); err != nil || got != nil {
+		t.Fatalf("globex timestamp = %v, %v; want none", got, err)
+	}
+	if err := globex.SaveLastRunTimestamp(TimestampModePRU, &ts2); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := acme.LoadLastRunTimestamp(TimestampModePRU); err != nil || got == nil || !got.Equal(ts1) {
+		t.Errorf("acme timestamp = %v, %v; want %v", got, err, ts1)
+	}
+	if got, err := globex.LoadLastRunTimestamp(TimestampModePRU); err != nil || got == nil || !got.Equal(ts2) {
+		t.Errorf("globex timestamp = %v, %v; want %v", got, err, ts2)
+	}
+}
+
+func TestStateDir_MigratesLegacyFiles(t *testing.T) {
+	dir := t.TempDir()
+	legacyTS := filepath.Join(dir, timestampFileName)
+	if err := os.WriteFile(legacyTS, []byte(`{"last_run":"2025-03-01T00:00:00Z"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	legacyCache := filepath.Join(dir, "cache")
+	if err := os.MkdirAll(legacyCache, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(legacyCache, "cost_centers.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("GITHUB_ENTERPRISE", "acme-corp")
+	m, err := Load(writeConfig(t, "export_dir: \""+dir+"\"\n"), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	got, err := m.LoadLastRunTimestamp(TimestampModePRU)
+	if err != nil || got == nil || !got.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("LoadLastRunTimestamp = %v, %v; want the legacy timestamp", got, err)
+	}
+	if _, err := os.Stat(legacyTS); !os.IsNotExist(err) {
+		t.Error("legacy timestamp file should have been moved")
+	}
+	if _, err := os.Stat(filepath.Join(m.StateDir, timestampFileName)); err != nil {
+		t.Errorf("timestamp not moved into the state dir: %v", err)
+	}
+
+	if err := m.MigrateLegacyCache(); err != nil {
+		t.Fatalf("MigrateLegacyCache: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(m.CacheDir, "cost_centers.json")); err != nil {
+		t.Errorf("cache not moved into the state dir: %v", err)
+	}
+	if _, err := os.Stat(legacyCache); !os.IsNotExist(err) {
+		t.Error("legacy cache directory should have been moved")
+	}
+}
+
+func TestTimestamp_PerMode(t *testing.T) {
+	dir := t.TempDir()
+	m, err := Load(writeConfig(t, "github:\n  enterprise: \"ent\"\nexport_dir: \""+dir+"\"\n"), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	pru := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	teams := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	if err := m.SaveLastRunTimestamp(TimestampModePRU, &pru); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := m.LoadLastRunTimestamp(TimestampModeTeams); err != nil || got != nil {
+		t.Fatalf("teams timestamp = %v, %v; a pru run must not set it", got, err)
+	}
+	if err := m.SaveLastRunTimestamp(TimestampModeTeams, &teams); err != nil {
+		t.Fatal(err)
+	}
+
+	for mode, want := range map[string]time.Time{TimestampModePRU: pru, TimestampModeTeams: teams} {
+		got, err := m.LoadLastRunTimestamp(mode)
+		if err != nil || got == nil || !got.Equal(want) {
+			t.Errorf("%s timestamp = %v, %v; want %v", mode, got, err, want)
+		}
+	}
+	if got, err := m.LoadLastRunTimestamp(TimestampModeRepository); err != nil || got != nil {
+		t.Errorf("repository timestamp = %v, %v; want none", got, err)
+	}
+}
+
+func TestTimestamp_LegacyFormatIsPRU(t *testing.T) {
+	dir := t.TempDir()
+	m, err := Load(writeConfig(t, "github:\n  enterprise: \"ent\"\nexport_dir: \""+dir+"\"\n"), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if err := os.MkdirAll(m.StateDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	legacy := `{"last_run": "2025-03-01T00:00:00Z", "saved_at": "2025-03-01T00:00:05Z"}`
+	if err := os.WriteFile(filepath.Join(m.StateDir, timestampFileName), []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	if got, err := m.LoadLastRunTimestamp(TimestampModePRU); err != nil || got == nil || !got.Equal(want) {
+		t.Fatalf("pru timestamp = %v, %v; want %v", got, err, want)
+	}
+	if got, err := m.LoadLastRunTimestamp(TimestampModeTeams); err != nil || got != nil {
+		t.Errorf("teams timestamp = %v, %v; want none", got, err)
+	}
+
+	// Saving another mode rewrites the file in the per-mode format and keeps
+	// the legacy value as the pru entry.
+	teams := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	if err := m.SaveLastRunTimestamp(TimestampModeTeams, &teams); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(m.StateDir, timestampFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var td timestampData
+	if err := json.Unmarshal(data, &td); err != nil {
+		t.Fatal(err)
+	}
+	if td.LastRun != "" || td.Modes[TimestampModePRU].LastRun != "2025-03-01T00:00:00Z" ||
+		td.Modes[TimestampModeTeams].LastRun != "2025-04-01T00:00:00Z" {
+		t.Errorf("timestamp file = %s", data)
+	}
+}
+
+func TestLoad_LockStaleAfter(t *testing.T) {
+	base := `
+github:
+  enterprise: "ent"
+`
+	m, err := Load(writeConfig(t, base), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.LockStaleAfter != DefaultLockStaleAfter {
+		t.Errorf("LockStaleAfter = %s, want %s", m.LockStaleAfter, DefaultLockStaleAfter)
+	}
+	if m.LockFile != filepath.Join(m.StateDir, ".lock") {
+		t.Errorf("LockFile = %q, want .lock in %s", m.LockFile, m.StateDir)
+	}
+
+	if m, err = Load(writeConfig(t, base+"lock:\n  stale_after: \"45m\"\n"), logger()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.LockStaleAfter != 45*time.Minute {
+		t.Errorf("LockStaleAfter = %s, want 45m0s", m.LockStaleAfter)
+	}
+	if _, err := Load(writeConfig(t, base+"lock:\n  stale_after: \"soon\"\n"), logger()); err == nil || !strings.Contains(err.Error(), "invalid lock.stale_after") {
+		t.Errorf("err = %v, want invalid lock.stale_after error", err)
+	}
+}
+
+func TestLoad_LogRotation(t *testing.T) {
+	base := `
+github:
+  enterprise: "ent"
+`
+	m, err := Load(writeConfig(t, base), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.LogMaxSizeMB != DefaultLogMaxSizeMB || m.LogMaxBackups != DefaultLogMaxBackups || m.LogMaxAgeDays != 0 {
+		t.Errorf("rotation = %d MB / %d backups / %d days, want the defaults", m.LogMaxSizeMB, m.LogMaxBackups, m.LogMaxAgeDays)
+	}
+
+	if m, err = Load(writeConfig(t, base+"logging:\n  max_size_mb: 10\n  max_backups: 2\n  max_age_days: 30\n"), logger()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.LogMaxSizeMB != 10 || m.LogMaxBackups != 2 || m.LogMaxAgeDays != 30 {
+		t.Errorf("rotation = %d MB / %d backups / %d days, want 10/2/30", m.LogMaxSizeMB, m.LogMaxBackups, m.LogMaxAgeDays)
+	}
+	if _, err := Load(writeConfig(t, base+"logging:\n  max_backups: -1\n"), logger()); err == nil || !strings.Contains(err.Error(), "invalid logging.max_backups") {
+		t.Errorf("err = %v, want invalid logging.max_backups error", err)
+	}
+
+	if m, err = Load(writeConfig(t, base+"logging:\n  file: \"logs/run-{timestamp}.log\"\n  keep_runs: 7\n"), logger()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.LogFile != "logs/run-{timestamp}.log" || m.LogKeepRuns != 7 {
+		t.Errorf("LogFile = %q, LogKeepRuns = %d, want the placeholder kept and 7", m.LogFile, m.LogKeepRuns)
+	}
+	if _, err := Load(writeConfig(t, base+"logging:\n  keep_runs: -2\n"), logger()); err == nil || !strings.Contains(err.Error(), "invalid logging.keep_runs") {
+		t.Errorf("err = %v, want invalid logging.keep_runs error", err)
+	}
+}
+
+// ---------- Validate ----------
+
+func TestValidate_ReportsEveryProblem(t *testing.T) {
+	t.Setenv("GITHUB_ENTERPRISE", "")
+	t.Setenv("GITHUB_API_BASE_URL", "")
+	p := writeConfig(t, `
+github:
+  enterprise: "REPLACE_WITH_ENTERPRISE_SLUG"
+  api_base_url: "http://api.github.com"
+  seat_fetch_concurrency: -1
+cost_center:
+  users:
+    no_prus_cost_center_id: "REPLACE_WITH_NO_PRUS_COST_CENTER_ID"
+    prus_allowed_cost_center_id: "CC-002-PRUS-ALLOWED"
+    auto_create: false
+budgets:
+  products:
+    copilot:
+      amount: 100
+      alert_recipients: ["not an email"]
+cache:
+  ttl: "soon"
+lock:
+  stale_after: "-1h"
+`)
+	m, problems, err := Validate([]string{p}, logger())
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if m == nil {
+		t.Fatal("Validate returned no Manager")
+	}
+	want := []string{
+		"budgets",
+		"cache.ttl",
+		"cost_center.users.no_prus_cost_center_id",
+		"cost_center.users.prus_allowed_cost_center_id",
+		"github.api_base_url",
+		"github.enterprise",
+		"github.seat_fetch_concurrency",
+		"lock.stale_after",
+	}
+	var got []string
+	for _, pr := range problems {
+		got = append(got, pr.Path)
+		if pr.Message == "" {
+			t.Errorf("problem at %s has no message", pr.Path)
+		}
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("problem paths =\n  %v\nwant\n  %v", got, want)
+	}
+}
+
+func TestValidate_ModeAndTeamMappings(t *testing.T) {
+	p := writeConfig(t, "github:\n  enterprise: ent\ncost_center:\n  mode: pru\n")
+	_, problems, err := Validate([]string{p}, logger())
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if len(problems) != 1 || problems[0].Path != "cost_center.mode" || !strings.Contains(problems[0].Message, `"pru"`) {
+		t.Errorf("problems = %v, want only the unknown mode", problems)
+	}
+
+	p = writeMappingsConfig(t, "teams.csv", "my-org/frontend,CC-FILE\n",
+		"      my-org/frontend: CC-INLINE\n      My-Org/Backend: CC-A\n      my-org/backend: CC-B\n")
+	_, problems, err = Validate([]string{p}, logger())
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	var got []string
+	for _, pr := range problems {
+		got = append(got, pr.String())
+	}
+	if len(got) != 2 ||
+		!strings.HasPrefix(got[0], `cost_center.teams.mappings.my-org/backend: conflicts with "My-Org/Backend"`) ||
+		!strings.HasPrefix(got[1], `cost_center.teams.mappings.my-org/frontend: mapped to "CC-INLINE" inline but to "CC-FILE"`) {
+		t.Errorf("problems =\n  %s\nwant the case conflict and the inline/file conflict", strings.Join(got, "\n  "))
+	}
+}
+
+func TestValidate_UnreadableFile(t *testing.T) {
+	if _, _, err := Validate([]string{filepath.Join(t.TempDir(), "missing.yaml")}, logger()); err == nil {
+		t.Error("Validate(missing file) succeeded, want an error")
+	}
+	if _, _, err := Validate([]string{writeConfig(t, "github: [\n")}, logger()); err == nil || !strings.Contains(err.Error(), "parsing config YAML") {
+		t.Errorf("err = %v, want a parse error", err)
+	}
+}
+
+func TestCostCenterRefs(t *testing.T) {
+	const id = "0b9e9a4e-1c1b-4a5f-9d7e-2f1f4c3b5a61"
+	m, err := Load(writeConfig(t, `
+github:
+  enterprise: ent
+cost_center:
+  users:
+    no_prus_cost_center_id: "`+id+`"
+    prus_allowed_cost_center_id: "REPLACE_WITH_PRUS_ALLOWED_COST_CENTER_ID"
+    user_overrides:
+      alice: "`+id+`"
+      bob: "Contractors"
+`), logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	refs := m.CostCenterRefs()
+	want := []CostCenterRef{
+		{Path: "cost_center.users.no_prus_cost_center_id", ID: id},
+		{Path: "cost_center.users.user_overrides.alice", ID: id},
+	}
+	if len(refs) != len(want) || refs[0] != want[0] || refs[1] != want[1] {
+		t.Errorf("CostCenterRefs = %v, want %v", refs, want)
+	}
+}
+
+// ---------- Unknown keys ----------
+
+const misspelledConfig = `
+github:
+  enterprise: "ent"
+cost_center:
+  users:
+    prus_exception_user: ["alice"]
+exports_dir: "out"
+`
+
+func TestLoad_UnknownKeysWarn(t *testing.T) {
+	var buf strings.Builder
+	log := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	m, err := Load(writeConfig(t, misspelledConfig), log)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(m.PRUsExceptionUsers) != 0 {
+		t.Errorf("PRUsExceptionUsers = %v, want the misspelled key ignored", m.PRUsExceptionUsers)
+	}
+	for _, want := range []string{
+		`msg="Ignoring unknown config key" key=cost_center.users.prus_exception_user line=6`,
+		`msg="Ignoring unknown config key" key=exports_dir line=7`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("log missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestLoad_UnknownKeysStrict(t *testing.T) {
+	want := `unknown config keys (strict mode): cost_center.users.prus_exception_user (line 6) (did you mean "exception_users"?), exports_dir (line 7) (did you mean "export_dir"?)`
+
+	if _, err := Load(writeConfig(t, misspelledConfig+"strict: true\n"), logger()); err == nil || err.Error() != want {
+		t.Errorf("strict: true: err = %v, want %q", err, want)
+	}
+
+	Strict = true
+	t.Cleanup(func() { Strict = false })
+	if _, err := Load(writeConfig(t, misspelledConfig), logger()); err == nil || err.Error() != want {
+		t.Errorf("Strict: err = %v, want %q", err, want)
+	}
+	if _, err := Load(writeConfig(t, "github:\n  enterprise: ent\n"), logger()); err != nil {
+		t.Errorf("Strict with known keys only: %v", err)
+	}
+}
+
+func TestFindUnknownKeys_Nested(t *testing.T) {
+	var doc yaml.Node
+	err := yaml.Unmarshal([]byte(`
+defaults: &tier
+  cost_center_name: "Std"
+  budget: 5
+cost_center:
+  users:
+    tiers:
+      - name: "Std"
+        <<: *tier
+  teams:
+    mappings:
+      my-org/any-key: "CC"
+budgets:
+  products:
+    copilot: {amount: 1, alert: true}
+`), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, k := range findUnknownKeys(doc.Content[0], "") {
+		got = append(got, k.String())
+	}
+	want := `defaults (line 2), cost_center.users.tiers[0].budget (line 4) (did you mean "budget_amount"?), budgets.products.copilot.alert (line 15) (did you mean "alert_recipients"?)`
+	if strings.Join(got, ", ") != want {
+		t.Errorf("unknown keys = %s, want %s", strings.Join(got, ", "), want)
+	}
+}
+
+func TestValidate_UnknownKeys(t *testing.T) {
+	_, problems, err := Validate([]string{writeConfig(t, misspelledConfig)}, logger())
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if len(problems) != 2 || problems[0].Path != "cost_center.users.prus_exception_user" || problems[1].Path != "exports_dir" ||
+		!strings.Contains(problems[0].Message, "line 6") {
+		t.Errorf("problems = %v, want both unknown keys", problems)
+	}
+}
+
+// ---------- Layered config files ----------
+
+// writeLayers writes each content to its own file in one directory and
+// returns the paths in order.
+func writeLayers(t *testing.T, contents ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	paths := make([]string, len(contents))
+	for i, c := range contents {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("layer%d.yaml", i))
+		if err := os.WriteFile(paths[i], []byte(c), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return paths
+}
+
+func TestLoadFiles_MapsMerge(t *testing.T) {
+	t.Setenv("GITHUB_ENTERPRISE", "")
+	paths := writeLayers(t, `
+github:
+  enterprise: "base-ent"
+  seat_fetch_concurrency: 3
+cost_center:
+  users:
+    auto_create: true
+    user_overrides:
+      alice: "CC-A"
+      bob: "CC-B"
+`, `
+github:
+  enterprise: "prod-ent"
+cost_center:
+  users:
+    user_overrides:
+      bob: "CC-PROD"
+      carol: "CC-C"
+`)
+	m, err := LoadFiles(paths, logger())
+	if err != nil {
+		t.Fatalf("LoadFiles: %v", err)
+	}
+	if m.Enterprise != "prod-ent" || m.SeatFetchConcurrency != 3 || !m.AutoCreate {
+		t.Errorf("enterprise %q, concurrency %d, auto_create %v; want the overlay enterprise and the base rest",
+			m.Enterprise, m.SeatFetchConcurrency, m.AutoCreate)
+	}
+	want := map[string]string{"alice": "CC-A", "bob": "CC-PROD", "carol": "CC-C"}
+	if len(m.PRUsUserOverrides) != len(want) {
+		t.Errorf("user_overrides = %v, want %v", m.PRUsUserOverrides, want)
+	}
+	for login, cc := range want {
+		if m.PRUsUserOverrides[login] != cc {
+			t.Errorf("user_overrides[%s] = %q, want %q", login, m.PRUsUserOverrides[login], cc)
+		}
+	}
+
+	for path, src := range map[string]string{
+		"github.enterprise":                        paths[1],
+		"github.seat_fetch_concurrency":            paths[0],
+		"cost_center.users.user_overrides.alice":   paths[0],
+		"cost_center.users.user_overrides.bob":     paths[1],
+		"cost_center.users.user_overrides":         paths[0] + ", " + paths[1],
+		"cost_center.users.no_prus_cost_center_id": DefaultSource,
+	} {
+		if got := m.Source(path); got != src {
+			t.Errorf("Source(%s) = %q, want %q", path, got, src)
+		}
+	}
+	if got := m.SummarySource("enterprise"); got != paths[1] {
+		t.Errorf("SummarySource(enterprise) = %q, want %q", got, paths[1])
+	}
+}
+
+func TestLoadFiles_ListsReplace(t *testing.T) {
+	paths := writeLayers(t, `
+github:
+  enterprise: "ent"
+  organizations: ["org-a", "org-b"]
+cost_center:
+  users:
+    exception_users: ["alice", "bob"]
+`, `
+github:
+  organizations: ["org-c"]
+cost_center:
+  users:
+    exception_users: []
+`)
+	m, err := LoadFiles(paths, logger())
+	if err != nil {
+		t.Fatalf("LoadFiles: %v", err)
+	}
+	if strings.Join(m.Organizations, ",") != "org-c" || len(m.PRUsExceptionUsers) != 0 {
+		t.Errorf("organizations %v, exception_users %v; want the overlay lists only", m.Organizations, m.PRUsExceptionUsers)
+	}
+	if got := m.Source("cost_center.users.exception_users"); got != paths[1] {
+		t.Errorf("Source(exception_users) = %q, want %q", got, paths[1])
+	}
+}
+
+func TestLoadFiles_ThreeLayers(t *testing.T) {
+	t.Setenv("GITHUB_ENTERPRISE", "")
+	paths := writeLayers(t, `
+github:
+  enterprise: "base-ent"
+cost_center:
+  mode: "teams"
+  teams:
+    scope: "enterprise"
+    strategy: "manual"
+    mappings:
+      "org/a": "CC-A"
+logging:
+  level: "INFO"
+`, `
+github:
+  enterprise: "staging-ent"
+cost_center:
+  teams:
+    mappings:
+      "org/b": "CC-B"
+logging:
+  level: "DEBUG"
+`, `
+cost_center:
+  teams:
+    mappings:
+      "org/a": "CC-A2"
+    mappings_file: "teams.csv"
+logging: {}
+`)
+	if err := os.WriteFile(filepath.Join(filepath.Dir(paths[2]), "teams.csv"), []byte("org/c,CC-C\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var buf strings.Builder
+	m, err := LoadFiles(paths, slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	if err != nil {
+		t.Fatalf("LoadFiles: %v", err)
+	}
+	if m.Enterprise != "staging-ent" || m.LogLevel != "DEBUG" {
+		t.Errorf("enterprise %q, log level %q; want the middle layer's", m.Enterprise, m.LogLevel)
+	}
+	want := map[string]string{"org/a": "CC-A2", "org/b": "CC-B", "org/c": "CC-C"}
+	if len(m.TeamsMappings) != len(want) {
+		t.Errorf("mappings = %v, want %v", m.TeamsMappings, want)
+	}
+	for k, v := range want {
+		if m.TeamsMappings[k] != v {
+			t.Errorf("mappings[%s] = %q, want %q", k, m.TeamsMappings[k], v)
+		}
+	}
+	if got := m.Source("cost_center.teams.mappings"); got != paths[1]+", "+paths[2] {
+		t.Errorf("Source(mappings) = %q, want the two layers left in it", got)
+	}
+	if !strings.Contains(buf.String(), "Merging config files") || !strings.Contains(buf.String(), "layer=3") {
+		t.Errorf("merge order not logged at DEBUG:\n%s", buf.String())
+	}
+
+	t.Setenv("GITHUB_ENTERPRISE", "env-ent")
+	if m, err = LoadFiles(paths, logger()); err != nil || m.Source("github.enterprise") != "env GITHUB_ENTERPRISE" {
+		t.Errorf("Source(enterprise) with env override = %q, %v", m.Source("github.enterprise"), err)
+	}
+	if _, err := LoadFiles(append(paths, filepath.Join(t.TempDir(), "missing.yaml")), logger()); err == nil {
+		t.Error("LoadFiles with a missing overlay succeeded, want an error")
+	}
+}
+
+func TestLoad_SetOverrides(t *testing.T) {
+	t.Setenv("GITHUB_ENTERPRISE", "env-ent")
+	path := writeConfig(t, `
+github:
+  enterprise: "file-ent"
+cost_center:
+  users:
+    auto_create: false
+    exception_users: ["alice"]
+budgets:
+  products:
+    copilot:
+      amount: 100
+      enabled: true
+`)
+	Overrides = []string{
+		"github.enterprise=set-ent",
+		"cost_center.users.auto_create=true",
+		"budgets.products.copilot.amount=250",
+		"github.seat_fetch_concurrency=2",
+		"cost_center.users.exception_users=bob, carol",
+		"cost_center.users.user_overrides.dave=CC-D",
+	}
+	t.Cleanup(func() { Overrides = nil })
+
+	m, err := Load(path, logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.Enterprise != "set-ent" {
+		t.Errorf("Enterprise = %q, want the --set value over the file and env", m.Enterprise)
+	}
+	if !m.AutoCreate {
+		t.Error("AutoCreate = false, want true")
+	}
+	if got := m.BudgetProducts["copilot"]; got.Amount != 250 || !got.Enabled {
+		t.Errorf("copilot budget = %+v, want amount 250 still enabled", got)
+	}
+	if m.SeatFetchConcurrency != 2 {
+		t.Errorf("SeatFetchConcurrency = %d, want 2", m.SeatFetchConcurrency)
+	}
+	if !slices.Equal(m.PRUsExceptionUsers, []string{"bob", "carol"}) {
+		t.Errorf("PRUsExceptionUsers = %v, want [bob carol]", m.PRUsExceptionUsers)
+	}
+	if m.PRUsUserOverrides["dave"] != "CC-D" {
+		t.Errorf("PRUsUserOverrides = %v, want dave: CC-D", m.PRUsUserOverrides)
+	}
+	if src := m.SummarySource("auto_create"); src != SetSource {
+		t.Errorf("auto_create source = %q, want %q", src, SetSource)
+	}
+	got, _ := m.Summary()["overridden_keys"].([]string)
+	if !slices.Contains(got, "github.enterprise") || len(got) != len(Overrides) {
+		t.Errorf("overridden_keys = %v, want every --set path", got)
+	}
+}
+
+func TestLoad_SetOverridesInvalid(t *testing.T) {
+	path := writeConfig(t, "github:\n  enterprise: ent\n")
+	t.Cleanup(func() { Overrides = nil })
+	for _, tc := range []struct {
+		set  string
+		want string
+	}{
+		{"teams.enabled=true", `unknown --set key "teams.enabled"; valid keys:`},
+		{"cost_center.users=x", `unknown --set key "cost_center.users"`},
+		{"cost_center.users.auto_create=maybe", `"maybe" is not a boolean`},
+		{"github.seat_fetch_concurrency=many", `"many" is not an integer`},
+		{"github.enterprise", "want key=value"},
+	} {
+		Overrides = []string{tc.set}
+		_, err := Load(path, logger())
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("--set %s: err = %v, want it to contain %q", tc.set, err, tc.want)
+		}
+	}
+	Overrides = []string{"teams.enabled=true"}
+	_, err := Load(path, logger())
+	if err == nil || !strings.Contains(err.Error(), "\n  budgets.products.<key>.amount\n") {
+		t.Errorf("err = %v, want the valid keys listed", err)
+	}
+}
+
+func TestLoad_ExceptionUsersFile(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		content string
+	}{
+		{"exceptions.txt", "# synced from the HR export\nBob\n\ncarol  # contractor\nsvc-*\n"},
+		{"exceptions.csv", "login,team\n# synced from the HR export\nBob,eng\ncarol,ops\nsvc-*,\n"},
+		{"exceptions.yaml", "- Bob\n- carol\n- \"svc-*\"\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := writeConfig(t, `
+github:
+  enterprise: "ent"
+cost_center:
+  users:
+    exception_users: ["alice", "bob"]
+    exception_users_file: "`+tc.name+`"
+`)
+			file := filepath.Join(filepath.Dir(p), tc.name)
+			if err := os.WriteFile(file, []byte(tc.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			m, err := Load(p, logger())
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if want := []string{"alice", "bob", "carol", "svc-*"}; !slices.Equal(m.PRUsExceptionUsers, want) {
+				t.Errorf("PRUsExceptionUsers = %v, want %v", m.PRUsExceptionUsers, want)
+			}
+			s := m.Summary()
+			if s["prus_exception_users_count"] != 4 || s["prus_exception_users_file"] != file {
+				t.Errorf("summary count = %v, file = %v; want 4 and %s", s["prus_exception_users_count"], s["prus_exception_users_file"], file)
+			}
+		})
+	}
+}
+
+func TestLoad_ExceptionUsersFileMissing(t *testing.T) {
+	p := writeConfig(t, `
+github:
+  enterprise: "ent"
+cost_center:
+  users:
+    exception_users_file: "missing.txt"
+`)
+	want := filepath.Join(filepath.Dir(p), "missing.txt")
+	if _, err := Load(p, logger()); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Load error = %v, want it to name %s", err, want)
+	}
+	_, problems, err := Validate([]string{p}, logger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || !strings.Contains(problems[0].String(), want) {
+		t.Errorf("problems = %v, want one naming %s", problems, want)
+	}
+}
+
+func TestLoad_ExceptionTeams(t *testing.T) {
+	p := writeConfig(t, `
+github:
+  enterprise: "ent"
+cost_center:
+  users:
+    exception_teams: ["ml-research", " my-org/data "]
+`)
+	m, err := Load(p, logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !slices.Equal(m.PRUsExceptionTeams, []string{"ml-research", "my-org/data"}) {
+		t.Errorf("PRUsExceptionTeams = %v", m.PRUsExceptionTeams)
+	}
+
+	p = writeConfig(t, "github:\n  enterprise: ent\ncost_center:\n  users:\n    exception_teams: [\"my-org/\"]\n")
+	if _, err := Load(p, logger()); err == nil || !strings.Contains(err.Error(), "exception_teams entry") {
+		t.Errorf("Load error = %v, want the invalid team rejected", err)
+	}
+}
+
+func TestBudgetProductsFor_OverridesWin(t *testing.T) {
+	p := writeConfig(t, `
+github:
+  enterprise: "ent"
+budgets:
+  products:
+    copilot:
+      amount: 100
+      enabled: true
+      alerts_enabled: true
+      alert_recipients: ["finance@example.com"]
+    actions:
+      amount: 125
+      enabled: false
+  cost_center_overrides:
+    "Platform":
+      copilot: 500
+      actions: 0
+`)
+	m, err := Load(p, logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	got := m.BudgetProductsFor("Platform")
+	if c := got["copilot"]; c.Amount != 500 || !c.Enabled || !c.AlertsEnabled || len(c.AlertRecipients) != 1 {
+		t.Errorf("copilot = %+v, want amount 500 with the global alerts", c)
+	}
+	if a := got["actions"]; a.Amount != 0 || !a.Enabled {
+		t.Errorf("actions = %+v, want an enabled $0 budget", a)
+	}
+	if m.BudgetProducts["copilot"].Amount != 100 {
+		t.Error("BudgetProductsFor changed the global products")
+	}
+	if other := m.BudgetProductsFor("Other"); other["copilot"].Amount != 100 || other["actions"].Enabled {
+		t.Errorf("Other = %+v, want the global products", other)
+	}
+
+	p = writeConfig(t, "github:\n  enterprise: ent\nbudgets:\n  cost_center_overrides:\n    no_prus:\n      copilot: -1\n")
+	if _, err := Load(p, logger()); err == nil || !strings.Contains(err.Error(), "budgets.cost_center_overrides.no_prus") {
+		t.Errorf("Load error = %v, want the negative amount rejected", err)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"team", "team", 0},
+		{"costcenter", "cost-center", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	} {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestIsKnownBudgetProduct(t *testing.T) {
+	for _, p := range []string{"copilot", "Actions", "copilot_premium_request"} {
+		if !IsKnownBudgetProduct(p) {
+			t.Errorf("IsKnownBudgetProduct(%q) = false, want true", p)
+		}
+	}
+	if IsKnownBudgetProduct("copilot_premium_requests") {
+		t.Error("IsKnownBudgetProduct(copilot_premium_requests) = true, want false")
+	}
+}
+
+func TestLoad_UnknownBudgetProducts(t *testing.T) {
+	const products = `
+budgets:
+  products:
+    copilot_premum_request:
+      amount: 50
+      enabled: true
+  cost_center_overrides:
+    prus_allowed:
+      actoins: 10
+`
+	p := writeConfig(t, "github:\n  enterprise: ent\n"+products)
+	_, err := Load(p, logger())
+	for _, want := range []string{
+		`budgets.products.copilot_premum_request: unknown product or SKU "copilot_premum_request" (did you mean "copilot_premium_request"?)`,
+		`budgets.cost_center_overrides.prus_allowed.actoins: unknown product or SKU "actoins" (did you mean "actions"?)`,
+		"budgets.allow_unknown_products",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Load error = %v, want it to contain %q", err, want)
+		}
+	}
+
+	_, problems, err := Validate([]string{p}, logger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 2 || problems[0].Path != "budgets.cost_center_overrides.prus_allowed.actoins" || problems[1].Path != "budgets.products.copilot_premum_request" {
+		t.Errorf("problems = %v, want one per unknown product", problems)
+	}
+
+	p = writeConfig(t, "github:\n  enterprise: ent\n"+strings.Replace(products, "budgets:\n", "budgets:\n  allow_unknown_products: true\n", 1))
+	m, err := Load(p, logger())
+	if err != nil {
+		t.Fatalf("Load with allow_unknown_products: %v", err)
+	}
+	if m.BudgetProducts["copilot_premum_request"].Amount != 50 {
+		t.Errorf("BudgetProducts = %v, want the unknown product kept", m.BudgetProducts)
+	}
+}
+
+func TestLoad_GHCCEnvOverrides(t *testing.T) {
+	const noPRU = "0b9e9a4e-1c1b-4a5f-9d7e-2f1f4c3b5a61"
+	const pruAllowed = "7c3f5a2e-9d8b-4e6f-a1c2-3b4d5e6f7a8b"
+	t.Setenv("GITHUB_ENTERPRISE", "env-ent")
+	t.Setenv("GHCC_NO_PRUS_COST_CENTER_ID", noPRU)
+	t.Setenv("GHCC_PRUS_ALLOWED_COST_CENTER_ID", pruAllowed)
+	t.Setenv("GHCC_EXPORT_DIR", "/tmp/ci-exports")
+	t.Setenv("GHCC_LOG_LEVEL", "DEBUG")
+	t.Setenv("GHCC_BUDGETS_ENABLED", "true")
+	t.Setenv("GHCC_AUTO_CREATE", "false")
+	p := writeConfig(t, `
+github:
+  enterprise: "file-ent"
+cost_center:
+  users:
+    no_prus_cost_center_id: "REPLACE_WITH_NO_PRUS_COST_CENTER_ID"
+    auto_create: true
+export_dir: "exports"
+logging:
+  level: "INFO"
+`)
+	m, err := Load(p, logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.NoPRUsCostCenterID != noPRU || m.PRUsAllowedCostCenterID != pruAllowed {
+		t.Errorf("cost center IDs = %q, %q; want the env values", m.NoPRUsCostCenterID, m.PRUsAllowedCostCenterID)
+	}
+	if m.ExportDir != "/tmp/ci-exports" || m.LogLevel != "DEBUG" || !m.BudgetsEnabled || m.AutoCreate {
+		t.Errorf("export_dir %q, log level %q, budgets %v, auto_create %v; want the env values",
+			m.ExportDir, m.LogLevel, m.BudgetsEnabled, m.AutoCreate)
+	}
+	if src := m.SummarySource("no_prus_cost_center_id"); src != "env GHCC_NO_PRUS_COST_CENTER_ID" {
+		t.Errorf("no_prus_cost_center_id source = %q", src)
+	}
+	want := []string{"GHCC_AUTO_CREATE", "GHCC_BUDGETS_ENABLED", "GHCC_EXPORT_DIR", "GHCC_LOG_LEVEL",
+		"GHCC_NO_PRUS_COST_CENTER_ID", "GHCC_PRUS_ALLOWED_COST_CENTER_ID", "GITHUB_ENTERPRISE"}
+	if got, _ := m.Summary()["env_overrides"].([]string); !slices.Equal(got, want) {
+		t.Errorf("env_overrides = %v, want %v", got, want)
+	}
+
+	// --set still wins over the environment.
+	Overrides = []string{"export_dir=set-exports"}
+	t.Cleanup(func() { Overrides = nil })
+	if m, err = Load(p, logger()); err != nil || m.ExportDir != "set-exports" {
+		t.Errorf("with --set: err = %v, ExportDir = %q", err, m.ExportDir)
+	}
+	Overrides = nil
+
+	t.Setenv("GHCC_BUDGETS_ENABLED", "yes please")
+	if _, err := Load(p, logger()); err == nil || !strings.Contains(err.Error(), "invalid GHCC_BUDGETS_ENABLED") {
+		t.Errorf("Load error = %v, want the bad boolean rejected", err)
+	}
+}
+
+func TestLoad_InvalidModeCombinations(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{"misspelled mode", `cost_center: {mode: "team"}`,
+			`invalid cost_center.mode "team" (did you mean "teams"?): must be one of: users, teams, repos, custom-prop, assigning-team`},
+		{"section name as mode", `cost_center: {mode: "repository"}`, `(did you mean "repos"?)`},
+		{"unrelated mode", `cost_center: {mode: "everything"}`, `invalid cost_center.mode "everything": must be one of`},
+		{"teams with an unknown scope", `cost_center: {mode: "teams", teams: {scope: "org"}}`,
+			`invalid cost_center.teams.scope "org"`},
+		{"organization teams without organizations", `cost_center: {mode: "teams", teams: {scope: "organization"}}`,
+			"requires github.organizations"},
+		{"manual teams without mappings", `cost_center: {mode: "teams", teams: {strategy: "manual"}}`,
+			"requires cost_center.teams.mappings or mappings_file"},
+		{"repos without mappings", `{github: {organizations: ["my-org"]}, cost_center: {mode: "repos"}}`,
+			"requires at least one mapping in cost_center.repos.mappings"},
+		{"repos without organizations", `cost_center: {mode: "repos"}`,
+			"requires cost_center.repos.organizations or github.organizations"},
+		{"custom-prop without cost centers", `{github: {organizations: ["my-org"]}, cost_center: {mode: "custom-prop"}}`,
+			"requires at least one entry in cost_center.custom_prop.cost_centers"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GITHUB_ENTERPRISE", "ent")
+			_, err := Load(writeConfig(t, tc.config), logger())
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Load error = %v, want it to contain %q", err, tc.want)
+			}
+		})
+	}
+}
+
+// ---------- Saving settings back to the config file ----------
+
+func TestSaveSettings_PreservesComments(t *testing.T) {
+	const noPRU = "0b9e9a4e-1c1b-4a5f-9d7e-2f1f4c3b5a61"
+	const pruAllowed = "7c3f5a2e-9d8b-4e6f-a1c2-3b4d5e6f7a8b"
+	t.Setenv("GITHUB_ENTERPRISE", "test-ent")
+	original := `# Top comment
+github:
+  enterprise: "test-ent" # trailing comment
+
+cost_center:
+  mode: users
+  users:
+    # PRU exceptions
+    exception_users:
+      - alice
+    no_prus_cost_center_id: "REPLACE_WITH_NO_PRUS_ID"
+    prus_allowed_cost_center_id: "REPLACE_WITH_PRUS_ID" # set by --save-ids
+    auto_create: true
+    persist_created_ids: true
+# Closing comment
+`
+	p := writeConfig(t, original)
+	m, err := Load(p, logger())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !m.PersistCreatedIDs {
+		t.Error("PersistCreatedIDs = false, want true")
+	}
+
+	files, err := m.SaveSettings(map[string]string{
+		"cost_center.users.no_prus_cost_center_id":      noPRU,
+		"cost_center.users.prus_allowed_cost_center_id": pruAllowed,
+	})
+	if err != nil {
+		t.Fatalf("SaveSettings: %v", err)
+	}
+	if !slices.Equal(files, []string{p}) {
+		t.Errorf("files = %v, want [%s]", files, p)
+	}
+
+	backup, err := os.ReadFile(p + ".bak")
+	if err != nil {
+		t.Fatalf("reading backup: %v", err)
+	}
+	if string(backup) != original {
+		t.Errorf("backup differs from the original:\n%s", backup)
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"# Top comment",
+		"# trailing comment",
+		"# PRU exceptions",
+		"# set by --save-ids",
+		"# Closing comment",
+		`no_prus_cost_center_id: "` + noPRU + `"`,
+		`prus_allowed_cost_center_id: "` + pruAllowed + `" # set by --save-ids`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("saved config missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Index(got, "exception_users:") > strings.Index(got, "no_prus_cost_center_id:") {
+		t.Errorf("key order changed:\n%s", got)
+	}
+
+	m, err = Load(p, logger())
+	if err != nil {
+		t.Fatalf("Load saved config: %v", err)
+	}
+	if m.NoPRUsCostCenterID != noPRU || m.PRUsAllowedCostCenterID != pruAllowed {
+		t.Errorf("IDs = %q, %q; want %q, %q", m.NoPRUsCostCenterID, m.PRUsAllowedCostCenterID, noPRU, pruAllowed)
+	}
+}
+
+func TestSaveSettings_LayerAndMissingKey(t *testing.T) {
+	const noPRU = "0b9e9a4e-1c1b-4a5f-9d7e-2f1f4c3b5a61"
+	t.Setenv("GITHUB_ENTERPRISE", "test-ent")
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	overlay := filepath.Join(dir, "overlay.yaml")
+	for path, content := range map[string]string{
+		base:    "# base\ncost_center:\n  mode: users\n  users:\n    auto_create: true\n",
+		overlay: "# overlay\ncost_center:\n  users:\n    no_prus_cost_center_id: \"REPLACE_WITH_NO_PRUS_ID\"\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m, err := LoadFiles([]string{base, overlay}, logger())
+	if err != nil {
+		t.Fatalf("LoadFiles: %v", err)
+	}
+	if _, err := m.SaveSettings(map[string]string{
+		"cost_center.users.no_prus_cost_center_id":      noPRU,
+		"cost_center.users.prus_allowed_cost_center_id": "7c3f5a2e-9d8b-4e6f-a1c2-3b4d5e6f7a8b",
+	}); err != nil {
+		t.Fatalf("SaveSettings: %v", err)
+	}
+
+	// The overlay sets no_prus_cost_center_id; the unset key goes to the
+	// first file.
+	data, _ := os.ReadFile(overlay)
+	if !strings.Contains(string(data), noPRU) || !strings.Contains(string(data), "# overlay") {
+		t.Errorf("overlay = %s", data)
+	}
+	data, _ = os.ReadFile(base)
+	if !strings.Contains(string(data), "    prus_allowed_cost_center_id: \"7c3f5a2e") || !strings.Contains(string(data), "# base") {
+		t.Errorf("base = %s", data)
+	}
+}
+
+// Old key names were never read as fallbacks, so a stale one next to the
+// current key is an unknown key that names its replacement.
+func TestLoad_RetiredKeyNames(t *testing.T) {
+	const noPRU = "0b9e9a4e-1c1b-4a5f-9d7e-2f1f4c3b5a61"
+	t.Setenv("GITHUB_ENTERPRISE", "test-ent")
+	var buf strings.Builder
+	log := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	p := writeConfig(t, `
+cost_center:
+  users:
+    no_prus_cost_center_id: "`+noPRU+`"
+    no_prus_cost_center: "stale"
+  teams:
+    remove_unmatched: true
+`)
+	m, err := Load(p, log)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.NoPRUsCostCenterID != noPRU {
+		t.Errorf("NoPRUsCostCenterID = %q, want %q", m.NoPRUsCostCenterID, noPRU)
+	}
+	for _, want := range []string{
+		`key=cost_center.users.no_prus_cost_center line=5 did_you_mean=no_prus_cost_center_id`,
+		`key=cost_center.teams.remove_unmatched line=7 did_you_mean=remove_unmatched_users`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("log missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	_, problems, err := Validate([]string{p}, logger())
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	var msgs []string
+	for _, pr := range problems {
+		msgs = append(msgs, pr.String())
+	}
+	if got := strings.Join(msgs, "\n"); !strings.Contains(got, `(did you mean "no_prus_cost_center_id"?)`) ||
+		!strings.Contains(got, `(did you mean "remove_unmatched_users"?)`) {
+		t.Errorf("Validate problems missing suggestions:\n%s", got)
+	}
+}
+
+// ---------- Default config lookup ----------
+
+func TestFindConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	home := filepath.Join(dir, "home")
+	xdg := filepath.Join(dir, "xdg")
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	if path, ok := FindConfigFile(); ok || path != DefaultPath {
+		t.Errorf("nothing found: FindConfigFile() = %q, %v; want %q, false", path, ok, DefaultPath)
+	}
+
+	// Create the candidates from the last to the first; each one found
+	// before they are all there must be the newest.
+	candidates := []string{
+		DefaultPath,
+		"gh-cost-center.yaml",
+		filepath.Join(xdg, "gh-cost-center", "config.yaml"),
+		filepath.Join(home, ".config", "gh-cost-center", "config.yaml"),
+	}
+	if got := SearchPaths(); !slices.Equal(got, candidates) {
+		t.Errorf("SearchPaths() = %v, want %v", got, candidates)
+	}
+	for i := len(candidates) - 1; i >= 0; i-- {
+		if err := os.MkdirAll(filepath.Dir(candidates[i]), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(candidates[i], []byte("github:\n  enterprise: ent\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if path, ok := FindConfigFile(); !ok || path != candidates[i] {
+			t.Errorf("FindConfigFile() = %q, %v; want %q", path, ok, candidates[i])
+		}
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	if got := SearchPaths(); slices.Contains(got, candidates[2]) {
+		t.Errorf("SearchPaths() without XDG_CONFIG_HOME = %v", got)
+	}
+}
+
+// ---------- --enterprise ----------
+
+func TestLoad_EnterpriseFlag(t *testing.T) {
+	path := writeConfig(t, "github:\n  enterprise: \"file-ent\"\n")
+	t.Cleanup(func() { EnterpriseFlag = "" })
+
+	load := func(t *testing.T) *Manager {
+		t.Helper()
+		m, err := Load(path, logger())
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		return m
+	}
+
+	t.Run("yaml", func(t *testing.T) {
+		t.Setenv("GITHUB_ENTERPRISE", "")
+		EnterpriseFlag = ""
+		if m := load(t); m.Enterprise != "file-ent" || m.Source("github.enterprise") != path {
+			t.Errorf("Enterprise = %q from %q, want file-ent from the file", m.Enterprise, m.Source("github.enterprise"))
+		}
+	})
+
+	t.Run("env over yaml", func(t *testing.T) {
+		t.Setenv("GITHUB_ENTERPRISE", "env-ent")
+		EnterpriseFlag = ""
+		if m := load(t); m.Enterprise != "env-ent" || m.Source("github.enterprise") != "env GITHUB_ENTERPRISE" {
+			t.Errorf("Enterprise = %q from %q, want env-ent from the environment", m.Enterprise, m.Source("github.enterprise"))
+		}
+	})
+
+	t.Run("flag over env and --set", func(t *testing.T) {
+		t.Setenv("GITHUB_ENTERPRISE", "env-ent")
+		EnterpriseFlag = "flag-ent"
+		Overrides = []string{"github.enterprise=set-ent"}
+		t.Cleanup(func() { Overrides = nil })
+		m := load(t)
+		if m.Enterprise != "flag-ent" {
+			t.Errorf("Enterprise = %q, want flag-ent", m.Enterprise)
+		}
+		if got := m.SummarySource("enterprise"); got != EnterpriseFlagSource {
+			t.Errorf("SummarySource(enterprise) = %q, want %q", got, EnterpriseFlagSource)
+		}
+		if m.Summary()["enterprise"] != "flag-ent" {
+			t.Errorf("Summary enterprise = %v, want flag-ent", m.Summary()["enterprise"])
+		}
+		if !strings.Contains(m.StateDir, "flag-ent") {
+			t.Errorf("StateDir = %q, want it namespaced by the flag enterprise", m.StateDir)
+		}
+	})
+
+	t.Run("placeholder", func(t *testing.T) {
+		t.Setenv("GITHUB_ENTERPRISE", "env-ent")
+		EnterpriseFlag = "REPLACE_WITH_ENTERPRISE_SLUG"
+		_, err := Load(path, logger())
+		if err == nil || !strings.Contains(err.Error(), `invalid --enterprise "REPLACE_WITH_ENTERPRISE_SLUG"`) {
+			t.Errorf("err = %v, want a placeholder error", err)
+		}
+	})
+}
+
+func TestMaskSecret(t *testing.T) {
+	for in, want := range map[string]string{
+		"":                   "",
+		"abc":                "***",
+		"abcd":               "****",
+		"ghp_0123456789wxyz": "****wxyz",
+	} {
+		if got := maskSecret(in); got != want {
+			t.Errorf("maskSecret(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
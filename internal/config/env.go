@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envSettings maps the GHCC_ environment variables to the YAML path of the
+// setting each overrides.  Values are converted like --set values.
+var envSettings = []struct {
+	Env  string
+	Path string
+}{
+	{"GHCC_CA_CERT_PATH", "github.ca_cert_path"},
+	{"GHCC_INSECURE_SKIP_VERIFY", "github.insecure_skip_verify"},
+	{"GHCC_COST_CENTER_MODE", "cost_center.mode"},
+	{"GHCC_NO_PRUS_COST_CENTER_ID", "cost_center.users.no_prus_cost_center_id"},
+	{"GHCC_PRUS_ALLOWED_COST_CENTER_ID", "cost_center.users.prus_allowed_cost_center_id"},
+	{"GHCC_NO_PRUS_COST_CENTER_NAME", "cost_center.users.no_prus_cost_center_name"},
+	{"GHCC_PRUS_ALLOWED_COST_CENTER_NAME", "cost_center.users.prus_allowed_cost_center_name"},
+	{"GHCC_AUTO_CREATE", "cost_center.users.auto_create"},
+	{"GHCC_TEAMS_AUTO_CREATE", "cost_center.teams.auto_create"},
+	{"GHCC_REPOS_DEFAULT_COST_CENTER", "cost_center.repos.default_cost_center"},
+	{"GHCC_ASSIGNING_TEAM_DEFAULT_COST_CENTER", "cost_center.assigning_team.default_cost_center"},
+	{"GHCC_BUDGETS_ENABLED", "budgets.enabled"},
+	{"GHCC_EXPORT_DIR", "export_dir"},
+	{"GHCC_LOG_LEVEL", "logging.level"},
+}
+
+// envSourcePrefix starts the Source of a setting from an environment
+// variable, as in "env GHCC_EXPORT_DIR".
+const envSourcePrefix = "env "
+
+// applyEnvSettings sets the settings of the envSettings variables that are
+// set and not empty in the YAML mapping root, recording the variable as
+// their source in sources.
+func applyEnvSettings(root *yaml.Node, sources map[string]string) error {
+	for _, s := range envSettings {
+		value := os.Getenv(s.Env)
+		if value == "" {
+			continue
+		}
+		segments := strings.Split(s.Path, ".")
+		t, _ := settingType(reflect.TypeFor[Config](), segments)
+		node, err := overrideNode(t, value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", s.Env, err)
+		}
+		setNode(root, segments, node)
+		sources[s.Path] = envSourcePrefix + s.Env
+	}
+	return nil
+}
+
+// EnvOverridden returns the environment variables that override a setting,
+// sorted.
+func (m *Manager) EnvOverridden() []string {
+	var vars []string
+	for _, src := range m.sources {
+		if v, ok := strings.CutPrefix(src, envSourcePrefix); ok && !slices.Contains(vars, v) {
+			vars = append(vars, v)
+		}
+	}
+	slices.Sort(vars)
+	return vars
+}
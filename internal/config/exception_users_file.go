@@ -0,0 +1,105 @@
+package config
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadExceptionUsersFile reads a users.exception_users_file: the exception
+// entries (logins, globs, or email domains) it lists.
+//
+// A .csv file has the entry in the first column (a "login" header row is
+// optional, "#" starts a comment).  A .yaml/.yml file is a list like the
+// inline exception_users.  Any other file is plain text with one entry per
+// line, where "#" starts a comment.  Errors name the file.
+func loadExceptionUsersFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading PRU exception users file: %w", err)
+	}
+
+	var entries []string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		entries, err = parseExceptionUsersCSV(data)
+	case ".yaml", ".yml":
+		entries, err = parseExceptionUsersYAML(data)
+	default:
+		entries = parseExceptionUsersText(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("PRU exception users file %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// parseExceptionUsersText parses one entry per line.
+func parseExceptionUsersText(data []byte) []string {
+	var entries []string
+	for line := range strings.Lines(string(data)) {
+		line, _, _ = strings.Cut(line, "#")
+		if line = strings.TrimSpace(line); line != "" {
+			entries = append(entries, line)
+		}
+	}
+	return entries
+}
+
+// parseExceptionUsersCSV parses the first column of each row.
+func parseExceptionUsersCSV(data []byte) ([]string, error) {
+	r := csv.NewReader(strings.NewReader(string(data)))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	var entries []string
+	for first := true; ; first = false {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err // csv.ParseError already carries the line
+		}
+		entry := strings.TrimSpace(record[0])
+		if first && strings.EqualFold(entry, "login") {
+			continue
+		}
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// parseExceptionUsersYAML parses a list of entries.
+func parseExceptionUsersYAML(data []byte) ([]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing YAML: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("line %d: want a list of logins", root.Line)
+	}
+	var entries []string
+	for _, item := range root.Content {
+		if item.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("line %d: want a login, glob, or email domain", item.Line)
+		}
+		if entry := strings.TrimSpace(item.Value); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
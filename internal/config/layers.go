@@ -0,0 +1,183 @@
+package config
+
+import (
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultSource is what Source reports for a setting no file or environment
+// variable sets.
+const DefaultSource = "default"
+
+// mergeLayer deep-merges the mapping src, read from file, over dst:
+// mappings merge per key, while lists and scalars replace.  origin records,
+// by YAML path, the file every leaf value of the result came from.
+func mergeLayer(dst, src *yaml.Node, prefix, file string, origin map[string]string) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		path := joinPath(prefix, key.Value)
+		j := mappingIndex(dst, key.Value)
+		if j < 0 {
+			dst.Content = append(dst.Content, key, value)
+			recordOrigin(value, path, file, origin)
+			continue
+		}
+		if old := dst.Content[j+1]; old.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			mergeLayer(old, value, path, file, origin)
+			continue
+		}
+		dst.Content[j+1] = value
+		for p := range origin {
+			if p == path || strings.HasPrefix(p, path+".") || strings.HasPrefix(p, path+"[") {
+				delete(origin, p)
+			}
+		}
+		recordOrigin(value, path, file, origin)
+	}
+}
+
+// mappingIndex returns the index of key in the mapping node n, or -1.
+func mappingIndex(n *yaml.Node, key string) int {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// recordOrigin records file as the origin of every leaf under node: the
+// values of non-empty mappings, and everything else as a whole.
+func recordOrigin(node *yaml.Node, path, file string, origin map[string]string) {
+	if node.Kind == yaml.MappingNode && len(node.Content) > 0 {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			recordOrigin(node.Content[i+1], joinPath(path, node.Content[i].Value), file, origin)
+		}
+		return
+	}
+	origin[path] = file
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// Source returns where the setting at the YAML path, such as
+// "cost_center.users.auto_create", came from: the config file that set
+// it, the environment variable overriding it, or DefaultSource.  A mapping
+// whose keys come from several files lists them in layer order.
+func (m *Manager) Source(path string) string {
+	if src, ok := m.sources[path]; ok {
+		return src
+	}
+	var files []string
+	for p, src := range m.sources {
+		if strings.HasPrefix(p, path+".") && !slices.Contains(files, src) {
+			files = append(files, src)
+		}
+	}
+	if len(files) == 0 {
+		return DefaultSource
+	}
+	slices.SortFunc(files, func(a, b string) int { return slices.Index(m.Files, a) - slices.Index(m.Files, b) })
+	return strings.Join(files, ", ")
+}
+
+// Overridden returns the YAML paths set with --set, sorted.
+func (m *Manager) Overridden() []string {
+	var paths []string
+	for p, src := range m.sources {
+		if src == SetSource {
+			paths = append(paths, p)
+		}
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// summaryPaths maps the keys of Summary to the YAML path they are read from.
+var summaryPaths = map[string]string{
+	"enterprise":                "github.enterprise",
+	"api_base_url":              "github.api_base_url",
+	"organizations":             "github.organizations",
+	"copilot_scope":             "github.copilot_scope",
+	"ca_cert_path":              "github.ca_cert_path",
+	"insecure_skip_verify":      "github.insecure_skip_verify",
+	"cost_center_mode":          "cost_center.mode",
+	"budgets_enabled":           "budgets.enabled",
+	"budget_products":           "budgets.products",
+	"log_level":                 "logging.level",
+	"export_dir":                "export_dir",
+	"state_dir":                 "export_dir",
+	"cache_ttl":                 "cache.ttl",
+	"lock_stale_after":          "lock.stale_after",
+	"skip_pending_cancellation": "cost_center.skip_pending_cancellation",
+	"include_non_user_accounts": "cost_center.include_non_user_accounts",
+	"excluded_users_count":      "cost_center.excluded_users",
+
+	"no_prus_cost_center_id":       "cost_center.users.no_prus_cost_center_id",
+	"no_prus_cost_center_url":      "cost_center.users.no_prus_cost_center_id",
+	"prus_allowed_cost_center_id":  "cost_center.users.prus_allowed_cost_center_id",
+	"prus_allowed_cost_center_url": "cost_center.users.prus_allowed_cost_center_id",
+	"prus_exception_users_count":   "cost_center.users.exception_users",
+	"prus_exception_users_file":    "cost_center.users.exception_users_file",
+	"prus_exception_teams":         "cost_center.users.exception_teams",
+	"prus_user_overrides_count":    "cost_center.users.user_overrides",
+	"enforce_exclusive_membership": "cost_center.users.enforce_exclusive_membership",
+	"prus_tiers_count":             "cost_center.users.tiers",
+	"auto_create":                  "cost_center.users.auto_create",
+	"persist_created_ids":          "cost_center.users.persist_created_ids",
+	"enable_incremental":           "cost_center.users.enable_incremental",
+	"remove_departed_users":        "cost_center.users.remove_departed_users",
+
+	"teams_scope":                     "cost_center.teams.scope",
+	"teams_organizations_count":       "github.organizations",
+	"teams_strategy":                  "cost_center.teams.strategy",
+	"teams_auto_create":               "cost_center.teams.auto_create",
+	"teams_remove_unmatched_users":    "cost_center.teams.remove_unmatched_users",
+	"teams_mappings_count":            "cost_center.teams.mappings",
+	"teams_budget_overrides_count":    "cost_center.teams.budget_overrides",
+	"teams_mappings_file":             "cost_center.teams.mappings_file",
+	"teams_cost_center_name_template": "cost_center.teams.cost_center_name_template",
+	"teams_conflict_resolution":       "cost_center.teams.conflict_resolution",
+	"teams_copilot_holders_only":      "cost_center.teams.copilot_holders_only",
+	"teams_include":                   "cost_center.teams.include",
+	"teams_exclude":                   "cost_center.teams.exclude",
+
+	"repos_organizations":       "cost_center.repos.organizations",
+	"repos_mappings_count":      "cost_center.repos.mappings",
+	"repos_unmatched_policy":    "cost_center.repos.unmatched_policy",
+	"repos_include_archived":    "cost_center.repos.include_archived",
+	"repos_remove_unmatched":    "cost_center.repos.remove_unmatched",
+	"repos_default_cost_center": "cost_center.repos.default_cost_center",
+
+	"custom_prop_cost_centers_count":     "cost_center.custom_prop.cost_centers",
+	"custom_prop_remove_unmatched_repos": "cost_center.custom_prop.remove_unmatched_repos",
+
+	"assigning_team_auto_create":         "cost_center.assigning_team.auto_create",
+	"assigning_team_default_cost_center": "cost_center.assigning_team.default_cost_center",
+
+	"repo_custom_properties_count": "repo_custom_properties",
+}
+
+// SummarySource returns the Source of the Summary entry key.
+func (m *Manager) SummarySource(key string) string {
+	switch key {
+	case "overridden_keys":
+		return SetSource
+	case "env_overrides":
+		return "env"
+	case "token":
+		return "--token"
+	}
+	path, ok := summaryPaths[key]
+	if !ok {
+		return DefaultSource
+	}
+	return m.Source(path)
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// DefaultPath is the config file read when --config is not given and no
+// file exists in the SearchPaths.
+const DefaultPath = "config/config.yaml"
+
+// SearchPaths returns the config files looked for, in order, when --config
+// is not given: ./config/config.yaml, ./gh-cost-center.yaml,
+// $XDG_CONFIG_HOME/gh-cost-center/config.yaml, and
+// ~/.config/gh-cost-center/config.yaml.
+func SearchPaths() []string {
+	paths := []string{DefaultPath, "gh-cost-center.yaml"}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		paths = append(paths, filepath.Join(xdg, "gh-cost-center", "config.yaml"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".config", "gh-cost-center", "config.yaml"))
+	}
+	return paths
+}
+
+// FindConfigFile returns the first of the SearchPaths that exists, and
+// false with DefaultPath when none does.
+func FindConfigFile() (string, bool) {
+	for _, p := range SearchPaths() {
+		if _, err := os.Stat(p); err == nil {
+			return p, true
+		}
+	}
+	return DefaultPath, false
+}
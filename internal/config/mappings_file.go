@@ -0,0 +1,132 @@
+package config
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadMappingsFile reads a teams.mappings_file: team key -> cost center.
+//
+// A .csv file has the columns team_slug,cost_center (the header row is
+// optional, "#" starts a comment).  A .yaml/.yml file is either a mapping
+// like the inline teams.mappings or a list of {team_slug, cost_center}
+// entries.  Errors name the file and line of the offending entry.
+func loadMappingsFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading teams mappings file: %w", err)
+	}
+
+	var mappings map[string]string
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		mappings, err = parseMappingsCSV(data)
+	case ".yaml", ".yml":
+		mappings, err = parseMappingsYAML(data)
+	default:
+		return nil, fmt.Errorf("teams mappings file %s: unsupported extension %q (want .csv, .yaml, or .yml)", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("teams mappings file %s: %w", path, err)
+	}
+	return mappings, nil
+}
+
+// parseMappingsCSV parses team_slug,cost_center rows.
+func parseMappingsCSV(data []byte) (map[string]string, error) {
+	r := csv.NewReader(strings.NewReader(string(data)))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	mappings := make(map[string]string)
+	lines := make(map[string]int) // team key -> line it was defined on
+	for first := true; ; first = false {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err // csv.ParseError already carries the line
+		}
+		line, _ := r.FieldPos(0)
+		if len(record) != 2 {
+			return nil, fmt.Errorf("line %d: want 2 columns (team_slug,cost_center), got %d", line, len(record))
+		}
+		team, cc := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		if first && team == "team_slug" && cc == "cost_center" {
+			continue
+		}
+		if err := addFileMapping(mappings, lines, line, team, cc); err != nil {
+			return nil, err
+		}
+	}
+	return mappings, nil
+}
+
+// parseMappingsYAML parses a team -> cost center mapping or a list of
+// {team_slug, cost_center} entries.
+func parseMappingsYAML(data []byte) (map[string]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing YAML: %w", err)
+	}
+
+	mappings := make(map[string]string)
+	lines := make(map[string]int)
+	if len(doc.Content) == 0 {
+		return mappings, nil
+	}
+	root := doc.Content[0]
+	switch root.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			key, val := root.Content[i], root.Content[i+1]
+			if val.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: cost center for %q must be a string", val.Line, key.Value)
+			}
+			if err := addFileMapping(mappings, lines, key.Line, strings.TrimSpace(key.Value), strings.TrimSpace(val.Value)); err != nil {
+				return nil, err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range root.Content {
+			var entry struct {
+				TeamSlug   string `yaml:"team_slug"`
+				CostCenter string `yaml:"cost_center"`
+			}
+			if err := item.Decode(&entry); err != nil {
+				return nil, fmt.Errorf("line %d: want an entry with team_slug and cost_center", item.Line)
+			}
+			if err := addFileMapping(mappings, lines, item.Line, strings.TrimSpace(entry.TeamSlug), strings.TrimSpace(entry.CostCenter)); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("line %d: want a mapping or a list of {team_slug, cost_center} entries", root.Line)
+	}
+	return mappings, nil
+}
+
+// addFileMapping validates and records one mappings file entry defined on
+// line.
+func addFileMapping(mappings map[string]string, lines map[string]int, line int, team, cc string) error {
+	switch {
+	case team == "":
+		return fmt.Errorf("line %d: team_slug is empty", line)
+	case cc == "":
+		return fmt.Errorf("line %d: cost_center for %q is empty", line, team)
+	}
+	if prev, ok := lines[team]; ok {
+		return fmt.Errorf("line %d: team %q is already mapped on line %d", line, team, prev)
+	}
+	mappings[team], lines[team] = cc, line
+	return nil
+}
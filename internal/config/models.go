@@ -7,8 +7,14 @@ type Config struct {
 	CostCenter           CostCenterConfig        `yaml:"cost_center"`
 	Budgets              BudgetsConfig           `yaml:"budgets"`
 	Logging              LoggingConfig           `yaml:"logging"`
+	Cache                CacheConfig             `yaml:"cache"`
+	Lock                 LockConfig              `yaml:"lock"`
 	ExportDir            string                  `yaml:"export_dir"`
 	RepoCustomProperties []RepoCustomPropertyDef `yaml:"repo_custom_properties"`
+
+	// Strict rejects the file when it has keys that match no setting,
+	// instead of ignoring them with a warning.
+	Strict bool `yaml:"strict"`
 }
 
 // GitHubConfig holds GitHub-related settings.
@@ -16,15 +22,44 @@ type GitHubConfig struct {
 	Enterprise    string   `yaml:"enterprise"`
 	APIBaseURL    string   `yaml:"api_base_url"`
 	Organizations []string `yaml:"organizations"`
+
+	// SeatFetchConcurrency bounds the number of Copilot seat pages fetched
+	// in parallel.  Defaults to 5.
+	SeatFetchConcurrency int `yaml:"seat_fetch_concurrency"`
+
+	// CopilotScope selects where Copilot seats are listed from: "enterprise"
+	// (default) or "organization", which aggregates the seats of every org in
+	// Organizations.
+	CopilotScope string `yaml:"copilot_scope"`
+
+	// CACertPath is a PEM bundle of CA certificates trusted in addition to
+	// the system roots, for a GitHub Enterprise Server with an internal CA.
+	CACertPath string `yaml:"ca_cert_path"`
+
+	// InsecureSkipVerify disables TLS certificate verification.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
 }
 
 // CostCenterConfig holds the mode selector and per-mode settings.
 type CostCenterConfig struct {
-	Mode       string           `yaml:"mode"` // "users", "teams", "repos", or "custom-prop"
-	Users      UsersConfig      `yaml:"users"`
-	Teams      TeamsConfig      `yaml:"teams"`
-	Repos      ReposConfig      `yaml:"repos"`
-	CustomProp CustomPropConfig `yaml:"custom_prop"`
+	Mode          string              `yaml:"mode"` // "users", "teams", "repos", "custom-prop", or "assigning-team"
+	Users         UsersConfig         `yaml:"users"`
+	Teams         TeamsConfig         `yaml:"teams"`
+	Repos         ReposConfig         `yaml:"repos"`
+	CustomProp    CustomPropConfig    `yaml:"custom_prop"`
+	AssigningTeam AssigningTeamConfig `yaml:"assigning_team"`
+
+	// SkipPendingCancellation leaves out users whose seat has a
+	// pending_cancellation_date.
+	SkipPendingCancellation bool `yaml:"skip_pending_cancellation"`
+
+	// IncludeNonUserAccounts assigns seats whose assignee type is not "User"
+	// (bots, mannequins).  They are skipped by default.
+	IncludeNonUserAccounts bool `yaml:"include_non_user_accounts"`
+
+	// ExcludedUsers are logins kept out of every assignment and removal, in
+	// all modes.
+	ExcludedUsers []string `yaml:"excluded_users"`
 }
 
 // UsersConfig holds PRU-based cost center settings.
@@ -36,6 +71,49 @@ type UsersConfig struct {
 	NoPRUsCostCenterName      string   `yaml:"no_prus_cost_center_name"`
 	PRUsAllowedCostCenterName string   `yaml:"prus_allowed_cost_center_name"`
 	EnableIncremental         bool     `yaml:"enable_incremental"`
+
+	// ExceptionUsersFile is a text (one entry per line), CSV, or YAML list
+	// file of more exception users, relative to the config file.
+	ExceptionUsersFile string `yaml:"exception_users_file"`
+
+	// PersistCreatedIDs writes the IDs of auto-created cost centers back
+	// to no_prus_cost_center_id and prus_allowed_cost_center_id in the
+	// config file (like assign --save-ids).
+	PersistCreatedIDs bool `yaml:"persist_created_ids"`
+
+	// RemoveDepartedUsers removes users who lost their Copilot seat from the
+	// cost center the processed-users ledger last recorded for them
+	// (--incremental runs only).
+	RemoveDepartedUsers bool `yaml:"remove_departed_users"`
+
+	// ExceptionTeams adds the members of teams to the exception users: an
+	// enterprise team slug, or "org/team-slug" for an organization team.
+	ExceptionTeams []string `yaml:"exception_teams"`
+
+	// UserOverrides pins individual logins to a cost center (ID or name),
+	// taking precedence over the exception list.
+	UserOverrides map[string]string `yaml:"user_overrides"`
+
+	// Tiers replaces the two-tier no-PRU/PRU-allowed model with an ordered
+	// list.  Users go to the first tier whose members match them; the last
+	// tier is the default.
+	Tiers []PRUTier `yaml:"tiers"`
+
+	// EnforceExclusiveMembership removes users from the other tier cost
+	// centers when they move between tiers (default true).
+	EnforceExclusiveMembership *bool `yaml:"enforce_exclusive_membership"`
+}
+
+// PRUTier is one cost center tier in users mode.
+type PRUTier struct {
+	Name           string `yaml:"name"`
+	CostCenterID   string `yaml:"cost_center_id"`
+	CostCenterName string `yaml:"cost_center_name"` // used when cost_center_id is unset
+	BudgetAmount   int    `yaml:"budget_amount"`    // Copilot budget created with --create-budgets; 0 = none
+
+	// Members are logins, globs ("svc-*"), email domains ("@example.com"),
+	// or team slugs ("org/team-slug").  Ignored on the last (default) tier.
+	Members []string `yaml:"members"`
 }
 
 // TeamsConfig holds teams-based cost center settings.
@@ -45,18 +123,64 @@ type TeamsConfig struct {
 	AutoCreate           bool              `yaml:"auto_create"`
 	RemoveUnmatchedUsers bool              `yaml:"remove_unmatched_users"`
 	Mappings             map[string]string `yaml:"mappings"` // "org/team-slug" -> "cost-center-name"
+	// MappingsFile is a YAML or CSV file (team_slug,cost_center) of more
+	// mappings, relative to the config file.  Inline Mappings win.
+	MappingsFile string `yaml:"mappings_file"`
+	// CostCenterNameTemplate names auto-mode cost centers, e.g.
+	// "Eng - {team_name}".  Placeholders: {org}, {team_name}, {team_slug}.
+	CostCenterNameTemplate string `yaml:"cost_center_name_template"`
+	// ConflictResolution decides where a user in several teams goes:
+	// "first_alphabetical" (default), "priority_list" (Priority), or "error".
+	ConflictResolution string   `yaml:"conflict_resolution"`
+	Priority           []string `yaml:"priority"` // team keys, highest priority first
+	// Include and Exclude filter teams by slug or "org/slug" key; entries
+	// may be glob patterns.  Include is applied first, then Exclude.
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+	// CopilotHoldersOnly assigns only team members who hold a Copilot seat.
+	// Defaults to true.
+	CopilotHoldersOnly *bool `yaml:"copilot_holders_only"`
+	// BudgetOverrides sets the copilot_premium_request budget amount (with
+	// --create-budgets) of a team's cost center, by team slug or "org/slug"
+	// key.
+	BudgetOverrides map[string]int `yaml:"budget_overrides"`
+}
+
+// AssigningTeamConfig holds settings for the assigning-team mode, which groups
+// users by the team that granted their Copilot seat.
+type AssigningTeamConfig struct {
+	AutoCreate        bool   `yaml:"auto_create"`
+	DefaultCostCenter string `yaml:"default_cost_center"` // for seats with no assigning team
 }
 
 // ReposConfig holds repository-based (explicit OR-mapping) cost center settings.
 type ReposConfig struct {
 	Mappings []ExplicitMapping `yaml:"mappings"`
+	// Organizations whose repos are evaluated; defaults to
+	// github.organizations.
+	Organizations []string `yaml:"organizations"`
+	// UnmatchedPolicy decides what happens to repos matching no mapping:
+	// "skip" (default), "assign_default" (to DefaultCostCenter), or "error".
+	UnmatchedPolicy   string `yaml:"unmatched_policy"`
+	DefaultCostCenter string `yaml:"default_cost_center"`
+	// IncludeArchived also maps archived and disabled repos, which are
+	// skipped by default.
+	IncludeArchived bool `yaml:"include_archived"`
+	// RemoveUnmatched detaches repos that match no mapping from the mapped
+	// cost centers.
+	RemoveUnmatched bool `yaml:"remove_unmatched"`
 }
 
 // ExplicitMapping maps a custom-property value set to a cost center.
+// PropertyValues entries may be globs ("payments-*") or regular expressions
+// wrapped in slashes ("/^payments-/"); see ValueMatcher.  A mapping with an
+// Organization applies to that org's repos only.
 type ExplicitMapping struct {
-	CostCenter     string   `yaml:"cost_center"`
-	PropertyName   string   `yaml:"property_name"`
-	PropertyValues []string `yaml:"property_values"`
+	CostCenter      string   `yaml:"cost_center"`
+	PropertyName    string   `yaml:"property_name"`
+	PropertyValues  []string `yaml:"property_values"`
+	CaseInsensitive bool     `yaml:"case_insensitive"`
+	Organization    string   `yaml:"organization"`
 }
 
 // CustomPropConfig holds AND-filter custom-property cost center definitions.
@@ -85,18 +209,51 @@ type CustomPropertyFilter struct {
 type LoggingConfig struct {
 	Level string `yaml:"level"`
 	File  string `yaml:"file"`
+
+	// Rotation of the log file.
+	MaxSizeMB  int `yaml:"max_size_mb"`
+	MaxBackups int `yaml:"max_backups"`
+	MaxAgeDays int `yaml:"max_age_days"`
+
+	// KeepRuns bounds the per-run log files of a file path with {timestamp}.
+	KeepRuns int `yaml:"keep_runs"`
+}
+
+// CacheConfig controls the on-disk API response cache.
+type CacheConfig struct {
+	TTL string `yaml:"ttl"` // Go duration, e.g. "24h" or "30m"
+}
+
+// LockConfig controls the lock file that keeps apply runs from overlapping.
+type LockConfig struct {
+	StaleAfter string `yaml:"stale_after"` // Go duration, e.g. "6h"
 }
 
 // BudgetsConfig holds budget auto-creation settings.
 type BudgetsConfig struct {
 	Enabled  bool                     `yaml:"enabled"`
 	Products map[string]ProductBudget `yaml:"products"`
+
+	// CostCenterOverrides sets product budget amounts for single cost
+	// centers, by cost center name or the symbolic keys "no_prus" and
+	// "prus_allowed" (users mode), taking precedence over Products.
+	CostCenterOverrides map[string]map[string]int `yaml:"cost_center_overrides"`
+
+	// AllowUnknownProducts accepts product names that are not a known
+	// product or SKU, such as one newer than this release.
+	AllowUnknownProducts bool `yaml:"allow_unknown_products"`
 }
 
 // ProductBudget is the budget configuration for a single product.
 type ProductBudget struct {
 	Amount  int  `yaml:"amount"`
 	Enabled bool `yaml:"enabled"`
+
+	// AlertsEnabled turns on budget alert emails for AlertRecipients, which
+	// may be GitHub logins or email addresses.  Alert thresholds (75%, 90%,
+	// 100%) are fixed by the budgets API.
+	AlertsEnabled   bool     `yaml:"alerts_enabled"`
+	AlertRecipients []string `yaml:"alert_recipients"`
 }
 
 // RepoCustomPropertyDef defines a GitHub repository custom property schema.
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Overrides are "path=value" settings applied over the config files and
+// the environment, such as "budgets.products.copilot.amount=250".  The path
+// mirrors the YAML structure; list values are comma-separated.
+var Overrides []string
+
+// SetSource is what Source reports for a setting from Overrides.
+const SetSource = "--set"
+
+// EnterpriseFlag is the --enterprise slug.  When set, it replaces
+// github.enterprise over the config files, GITHUB_ENTERPRISE, and
+// Overrides.
+var EnterpriseFlag string
+
+// EnterpriseFlagSource is what Source reports for github.enterprise set by
+// EnterpriseFlag.
+const EnterpriseFlagSource = "--enterprise"
+
+// applyOverrides sets each of overrides in the YAML mapping root, coercing
+// the value to the type of the setting, and records SetSource for it in
+// sources.
+func applyOverrides(root *yaml.Node, overrides []string, sources map[string]string) error {
+	for _, o := range overrides {
+		path, value, ok := strings.Cut(o, "=")
+		path = strings.TrimSpace(path)
+		if !ok || path == "" {
+			return fmt.Errorf("invalid --set %q: want key=value", o)
+		}
+		t, ok := settingType(reflect.TypeFor[Config](), strings.Split(path, "."))
+		if !ok {
+			return fmt.Errorf("unknown --set key %q; valid keys:\n  %s", path, strings.Join(settingPaths(reflect.TypeFor[Config](), ""), "\n  "))
+		}
+		node, err := overrideNode(t, value)
+		if err != nil {
+			return fmt.Errorf("invalid --set %s: %w", path, err)
+		}
+		setNode(root, strings.Split(path, "."), node)
+		for p := range sources {
+			if p == path || strings.HasPrefix(p, path+".") {
+				delete(sources, p)
+			}
+		}
+		sources[path] = SetSource
+	}
+	return nil
+}
+
+// settingType returns the type of the setting at the YAML path segments
+// under t.  Only scalars, pointers to them, and string lists are settings.
+func settingType(t reflect.Type, segments []string) (reflect.Type, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if len(segments) == 0 {
+		return t, isSettable(t)
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := range t.NumField() {
+			if yamlName(t.Field(i)) == segments[0] {
+				return settingType(t.Field(i).Type, segments[1:])
+			}
+		}
+	case reflect.Map:
+		if segments[0] != "" {
+			return settingType(t.Elem(), segments[1:])
+		}
+	}
+	return nil, false
+}
+
+// isSettable reports whether --set can coerce a value to t.
+func isSettable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.String:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+// settingPaths lists the paths --set accepts under t, with "<key>" for a
+// map key.
+func settingPaths(t reflect.Type, prefix string) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if isSettable(t) {
+		return []string{prefix}
+	}
+	var paths []string
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := range t.NumField() {
+			if name := yamlName(t.Field(i)); name != "" {
+				paths = append(paths, settingPaths(t.Field(i).Type, joinPath(prefix, name))...)
+			}
+		}
+	case reflect.Map:
+		paths = settingPaths(t.Elem(), joinPath(prefix, "<key>"))
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// yamlName returns the YAML key of a struct field, or "" when it has none.
+func yamlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// overrideNode turns value into a YAML node of the setting type t.
+func overrideNode(t reflect.Type, value string) (*yaml.Node, error) {
+	value = strings.TrimSpace(value)
+	switch t.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", value)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(b)}, nil
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", value)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(n)}, nil
+	case reflect.Slice:
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for item := range strings.SplitSeq(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: item})
+			}
+		}
+		return seq, nil
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, nil
+}
+
+// setNode sets the value at the path segments in the mapping root to
+// value, creating the mappings on the way.
+func setNode(root *yaml.Node, segments []string, value *yaml.Node) {
+	for _, seg := range segments[:len(segments)-1] {
+		i := mappingIndex(root, seg)
+		if i < 0 || root.Content[i+1].Kind != yaml.MappingNode {
+			child := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			if i < 0 {
+				root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: seg}, child)
+			} else {
+				root.Content[i+1] = child
+			}
+		}
+		root = root.Content[mappingIndex(root, seg)+1]
+	}
+	last := segments[len(segments)-1]
+	if i := mappingIndex(root, last); i >= 0 {
+		root.Content[i+1] = value
+		return
+	}
+	root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: last}, value)
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ValueMatcher matches custom property values against a mapping's
+// property_values.  Plain entries match exactly; entries containing *, ? or
+// [ are globs ("payments-*"); entries wrapped in slashes are regular
+// expressions ("/^payments-/").
+type ValueMatcher struct {
+	fold    bool
+	exact   map[string]bool
+	globs   []string
+	regexps []*regexp.Regexp
+}
+
+// NewValueMatcher compiles values.  With caseInsensitive every kind of entry
+// ignores case.
+func NewValueMatcher(values []string, caseInsensitive bool) (*ValueMatcher, error) {
+	vm := &ValueMatcher{fold: caseInsensitive, exact: make(map[string]bool, len(values))}
+	for _, v := range values {
+		switch {
+		case len(v) >= 2 && strings.HasPrefix(v, "/") && strings.HasSuffix(v, "/"):
+			expr := v[1 : len(v)-1]
+			if caseInsensitive {
+				expr = "(?i)" + expr
+			}
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression %s: %w", v, err)
+			}
+			vm.regexps = append(vm.regexps, re)
+		case strings.ContainsAny(v, "*?["):
+			if _, err := path.Match(v, ""); err != nil {
+				return nil, fmt.Errorf("invalid glob %q: %w", v, err)
+			}
+			vm.globs = append(vm.globs, vm.normalize(v))
+		default:
+			vm.exact[vm.normalize(v)] = true
+		}
+	}
+	return vm, nil
+}
+
+// normalize lower-cases s when matching ignores case.
+func (vm *ValueMatcher) normalize(s string) string {
+	if vm.fold {
+		return strings.ToLower(s)
+	}
+	return s
+}
+
+// Match reports whether s matches any entry.
+func (vm *ValueMatcher) Match(s string) bool {
+	if vm.exact[vm.normalize(s)] {
+		return true
+	}
+	for _, g := range vm.globs {
+		if ok, _ := path.Match(g, vm.normalize(s)); ok {
+			return true
+		}
+	}
+	for _, re := range vm.regexps {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
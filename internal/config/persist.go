@@ -0,0 +1,86 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SaveSettings writes the string settings in values, by YAML path such as
+// "cost_center.users.no_prus_cost_center_id", back to the config file that
+// sets each one, or the first config file.  Only those keys change: the
+// file is edited as a YAML node tree, so comments and key order are kept.
+// Each file is copied to <file>.bak first.  It returns the files written.
+func (m *Manager) SaveSettings(values map[string]string) ([]string, error) {
+	byFile := map[string][]string{}
+	for _, path := range slices.Sorted(maps.Keys(values)) {
+		file := m.path
+		if src := m.Source(path); slices.Contains(m.Files, src) {
+			file = src
+		}
+		byFile[file] = append(byFile[file], path)
+	}
+
+	var written []string
+	for _, file := range slices.Sorted(maps.Keys(byFile)) {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return written, fmt.Errorf("reading config file: %w", err)
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return written, fmt.Errorf("parsing config YAML %s: %w", file, err)
+		}
+		if len(doc.Content) == 0 {
+			doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+		}
+		root := doc.Content[0]
+		if root.Kind != yaml.MappingNode {
+			return written, fmt.Errorf("config file %s: top level is not a mapping", file)
+		}
+		for _, path := range byFile[file] {
+			setScalar(root, strings.Split(path, "."), values[path])
+		}
+
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&doc); err != nil {
+			return written, fmt.Errorf("encoding config file %s: %w", file, err)
+		}
+		if err := os.WriteFile(file+".bak", data, 0o644); err != nil {
+			return written, fmt.Errorf("backing up config file: %w", err)
+		}
+		if err := os.WriteFile(file, buf.Bytes(), 0o644); err != nil {
+			return written, fmt.Errorf("writing config file: %w", err)
+		}
+		written = append(written, file)
+		m.log.Info("Saved settings to the config file", "file", file, "keys", strings.Join(byFile[file], ", "))
+	}
+	return written, nil
+}
+
+// setScalar sets the string at the path segments under the mapping root.
+// An existing scalar is edited in place, keeping its comments and quoting.
+func setScalar(root *yaml.Node, segments []string, value string) {
+	node := root
+	for _, seg := range segments {
+		i := mappingIndex(node, seg)
+		if i < 0 {
+			break
+		}
+		if node = node.Content[i+1]; node.Kind == yaml.ScalarNode && seg == segments[len(segments)-1] {
+			node.Value, node.Tag = value, "!!str"
+			return
+		}
+		if node.Kind != yaml.MappingNode {
+			break
+		}
+	}
+	setNode(root, segments, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value, Style: yaml.DoubleQuotedStyle})
+}
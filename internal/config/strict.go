@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Strict makes Load reject config files with unknown keys, as strict: true
+// in the file does.  Without it, unknown keys are logged and ignored.
+var Strict bool
+
+// unknownKey is a key in the config file that matches no setting.
+type unknownKey struct {
+	Path string // YAML path, e.g. "cost_center.users.prus_exception_user"
+	File string // set when several files are layered
+	Line int
+
+	// Suggest is the closest setting name at the same level, such as
+	// no_prus_cost_center_id for a stale no_prus_cost_center, or "".
+	Suggest string
+}
+
+func (k unknownKey) String() string {
+	s := fmt.Sprintf("%s (line %d)", k.Path, k.Line)
+	if k.File != "" {
+		s = fmt.Sprintf("%s (%s line %d)", k.Path, k.File, k.Line)
+	}
+	if k.Suggest != "" {
+		s += fmt.Sprintf(" (did you mean %q?)", k.Suggest)
+	}
+	return s
+}
+
+// findUnknownKeys returns the keys under root, the top-level mapping of a
+// config file, that match no field of Config, in document order.  file is
+// recorded in each key.
+func findUnknownKeys(root *yaml.Node, file string) []unknownKey {
+	unknown := unknownKeysIn(root, reflect.TypeFor[Config](), "")
+	for i := range unknown {
+		unknown[i].File = file
+	}
+	return unknown
+}
+
+// unknownKeysIn walks node alongside the type t it decodes into and returns
+// the mapping keys that t has no field for.  prefix is the YAML path of
+// node.
+func unknownKeysIn(node *yaml.Node, t reflect.Type, prefix string) []unknownKey {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	var unknown []unknownKey
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := make(map[string]reflect.Type, t.NumField())
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if name := yamlName(f); name != "" {
+				fields[name] = f.Type
+			}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" { // merge key: the merged mapping's keys are t's
+				unknown = append(unknown, unknownKeysIn(value, t, prefix)...)
+				continue
+			}
+			ft, ok := fields[key.Value]
+			if !ok {
+				suggest := suggestKey(key.Value, slices.Sorted(maps.Keys(fields)))
+				unknown = append(unknown, unknownKey{Path: join(key.Value), Line: key.Line, Suggest: suggest})
+				continue
+			}
+			unknown = append(unknown, unknownKeysIn(value, ft, join(key.Value))...)
+		}
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			unknown = append(unknown, unknownKeysIn(node.Content[i+1], t.Elem(), join(node.Content[i].Value))...)
+		}
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for i, item := range node.Content {
+			unknown = append(unknown, unknownKeysIn(item, t.Elem(), fmt.Sprintf("%s[%d]", prefix, i))...)
+		}
+	}
+	return unknown
+}
+
+// suggestKey returns the setting name of names an unknown key most likely
+// meant: the shortest one extending it with a suffix, as
+// remove_unmatched_users extends remove_unmatched, or else the closest one
+// by edit distance.
+func suggestKey(key string, names []string) string {
+	best := ""
+	for _, n := range names {
+		if strings.HasPrefix(n, key+"_") && (best == "" || len(n) < len(best)) {
+			best = n
+		}
+	}
+	if best != "" {
+		return best
+	}
+	return SuggestName(key, names)
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Problem is one invalid setting found by Validate.
+type Problem struct {
+	Path    string `json:"path"` // YAML path of the offending key, e.g. "cost_center.mode"
+	Message string `json:"message"`
+}
+
+// String formats the problem as "path: message".
+func (p Problem) String() string {
+	return p.Path + ": " + p.Message
+}
+
+// Validate checks the config files in paths like LoadFiles, but resolves every section
+// instead of stopping at the first invalid one, and adds checks that Load
+// leaves to run time or only warns about: unknown keys, placeholder cost
+// center IDs without auto_create, and conflicting team mappings.  It returns the resolved Manager, for further
+// checks, and every problem found, sorted by path.  Only a config file that
+// cannot be read or parsed is an error.
+func Validate(paths []string, logger *slog.Logger) (*Manager, []Problem, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	m, unknown, err := parseFiles(paths, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var problems []Problem
+	for _, k := range unknown {
+		where := fmt.Sprintf("line %d", k.Line)
+		if k.File != "" {
+			where = k.File + " " + where
+		}
+		msg := "unknown key on " + where + ": it matches no setting and is ignored"
+		if k.Suggest != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", k.Suggest)
+		}
+		problems = append(problems, Problem{Path: k.Path, Message: msg})
+	}
+	for _, s := range m.resolveSteps() {
+		var list problemList
+		if err := s.run(); errors.As(err, &list) {
+			problems = append(problems, list...)
+		} else if err != nil {
+			problems = append(problems, Problem{Path: s.path, Message: err.Error()})
+		}
+	}
+	switch m.CostCenterMode {
+	case "users":
+		problems = append(problems, m.userPlaceholderProblems()...)
+	case "teams":
+		problems = append(problems, m.teamMappingProblems()...)
+	case "repos":
+		problems = append(problems, m.repoPlaceholderProblems()...)
+	}
+	slices.SortStableFunc(problems, func(a, b Problem) int { return strings.Compare(a.Path, b.Path) })
+	return m, problems, nil
+}
+
+// isPlaceholderCostCenter reports whether a cost center value is one of the
+// placeholders of the example config or the built-in defaults.
+func isPlaceholderCostCenter(cc string) bool {
+	return strings.HasPrefix(cc, "REPLACE_WITH_") || cc == DefaultNoPRUsCCID || cc == DefaultPRUsAllowedCCID
+}
+
+// userPlaceholderProblems reports the legacy users-mode cost center IDs left
+// as placeholders while auto_create is off.
+func (m *Manager) userPlaceholderProblems() []Problem {
+	u := m.cfg.CostCenter.Users
+	if u.AutoCreate || len(u.Tiers) > 0 {
+		return nil
+	}
+	var problems []Problem
+	for _, f := range []struct{ key, id string }{
+		{"no_prus_cost_center_id", u.NoPRUsCostCenterID},
+		{"prus_allowed_cost_center_id", u.PRUsAllowedCostCenterID},
+	} {
+		if isPlaceholderCostCenter(f.id) {
+			problems = append(problems, Problem{
+				Path:    "cost_center.users." + f.key,
+				Message: fmt.Sprintf("cost center ID %q is a placeholder and auto_create is off: set the real cost center ID or enable cost_center.users.auto_create", f.id),
+			})
+		}
+	}
+	return problems
+}
+
+// teamMappingProblems reports manual teams mappings that conflict with one
+// another or point at a placeholder cost center.
+func (m *Manager) teamMappingProblems() []Problem {
+	t := m.cfg.CostCenter.Teams
+	if m.TeamsStrategy != "manual" {
+		return nil
+	}
+	var problems []Problem
+	byFolded := make(map[string]string, len(m.TeamsMappings))
+	for _, key := range slices.Sorted(maps.Keys(m.TeamsMappings)) {
+		cc := m.TeamsMappings[key]
+		path := "cost_center.teams.mappings." + key
+		if strings.TrimSpace(cc) == "" || (!t.AutoCreate && isPlaceholderCostCenter(cc)) {
+			problems = append(problems, Problem{Path: path, Message: fmt.Sprintf("cost center %q is empty or a placeholder", cc)})
+		}
+		folded := strings.ToLower(key)
+		if other, ok := byFolded[folded]; ok && m.TeamsMappings[other] != cc {
+			problems = append(problems, Problem{
+				Path:    path,
+				Message: fmt.Sprintf("conflicts with %q, which maps the same team (slugs are case-insensitive) to %q", other, m.TeamsMappings[other]),
+			})
+		}
+		byFolded[folded] = key
+	}
+
+	if m.TeamsMappingsFile == "" {
+		return problems
+	}
+	fileMappings, err := loadMappingsFile(m.TeamsMappingsFile)
+	if err != nil {
+		return problems // reported by the teams section already
+	}
+	for _, key := range slices.Sorted(maps.Keys(fileMappings)) {
+		if inline, ok := t.Mappings[key]; ok && inline != fileMappings[key] {
+			problems = append(problems, Problem{
+				Path:    "cost_center.teams.mappings." + key,
+				Message: fmt.Sprintf("mapped to %q inline but to %q in %s", inline, fileMappings[key], m.TeamsMappingsFile),
+			})
+		}
+	}
+	return problems
+}
+
+// repoPlaceholderProblems reports repos-mode cost centers left as
+// placeholders; repos mode never creates cost centers.
+func (m *Manager) repoPlaceholderProblems() []Problem {
+	r := m.cfg.CostCenter.Repos
+	var problems []Problem
+	for i, em := range r.Mappings {
+		if isPlaceholderCostCenter(em.CostCenter) {
+			problems = append(problems, Problem{
+				Path:    fmt.Sprintf("cost_center.repos.mappings[%d].cost_center", i),
+				Message: fmt.Sprintf("cost center %q is a placeholder: set the cost center ID or name", em.CostCenter),
+			})
+		}
+	}
+	if isPlaceholderCostCenter(r.DefaultCostCenter) {
+		problems = append(problems, Problem{
+			Path:    "cost_center.repos.default_cost_center",
+			Message: fmt.Sprintf("cost center %q is a placeholder: set the cost center ID or name", r.DefaultCostCenter),
+		})
+	}
+	return problems
+}
+
+// CostCenterRef is a cost center ID configured at a YAML path.
+type CostCenterRef struct {
+	Path string
+	ID   string
+}
+
+// CostCenterRefs returns the settings of the configured mode whose value is
+// a cost center UUID rather than a name, sorted by path, so that callers
+// can check the cost centers exist.
+func (m *Manager) CostCenterRefs() []CostCenterRef {
+	c := m.cfg.CostCenter
+	var refs []CostCenterRef
+	add := func(path, v string) {
+		if looksLikeUUID(v) {
+			refs = append(refs, CostCenterRef{Path: path, ID: v})
+		}
+	}
+	switch m.CostCenterMode {
+	case "users":
+		if len(c.Users.Tiers) == 0 {
+			add("cost_center.users.no_prus_cost_center_id", c.Users.NoPRUsCostCenterID)
+			add("cost_center.users.prus_allowed_cost_center_id", c.Users.PRUsAllowedCostCenterID)
+		}
+		for i, t := range c.Users.Tiers {
+			add(fmt.Sprintf("cost_center.users.tiers[%d].cost_center_id", i), t.CostCenterID)
+		}
+		for login, cc := range c.Users.UserOverrides {
+			add("cost_center.users.user_overrides."+login, cc)
+		}
+	case "teams":
+		for key, cc := range m.TeamsMappings {
+			add("cost_center.teams.mappings."+key, cc)
+		}
+	case "repos":
+		for i, em := range c.Repos.Mappings {
+			add(fmt.Sprintf("cost_center.repos.mappings[%d].cost_center", i), em.CostCenter)
+		}
+		add("cost_center.repos.default_cost_center", c.Repos.DefaultCostCenter)
+	case "assigning-team":
+		add("cost_center.assigning_team.default_cost_center", c.AssigningTeam.DefaultCostCenter)
+	}
+	slices.SortFunc(refs, func(a, b CostCenterRef) int { return strings.Compare(a.Path, b.Path) })
+	return refs
+}
@@ -71,14 +71,14 @@ func (s *Summary) Print() {
 // assigns them to cost centers.
 type Manager struct {
 	cfg         *config.Manager
-	client      *github.Client
+	client      github.CostCenterAPI
 	log         *slog.Logger
 	costCenters []config.CustomPropCostCenter
 }
 
 // NewManager creates a Manager from configuration.
 // It returns an error if no custom-property cost centers are configured.
-func NewManager(cfg *config.Manager, client *github.Client, logger *slog.Logger) (*Manager, error) {
+func NewManager(cfg *config.Manager, client github.CostCenterAPI, logger *slog.Logger) (*Manager, error) {
 	if len(cfg.CustomPropCostCenters) == 0 {
 		return nil, fmt.Errorf("custom-prop mode requires at least one entry in cost_center.custom_prop.cost_centers")
 	}
@@ -354,13 +354,19 @@ func (m *Manager) processCostCenter(
 		m.log.Info("...and more", "remaining", len(repoNames)-10)
 	}
 
-	if err := m.client.AddRepositoriesToCostCenter(ccID, repoNames); err != nil {
+	repoResults, err := m.client.AddReposToCostCenter(ccID, repoNames)
+	for _, ok := range repoResults {
+		if ok {
+			result.ReposAssigned++
+		}
+	}
+	if err != nil {
 		result.Message = fmt.Sprintf("failed to assign repos: %v", err)
-		m.log.Error("Failed to assign repos", "cost_center", cc.Name, "error", err)
+		m.log.Error("Failed to assign repos",
+			"cost_center", cc.Name, "assigned", result.ReposAssigned, "error", err)
 		return result
 	}
 
-	result.ReposAssigned = len(repoNames)
 	result.Success = true
 	result.Message = fmt.Sprintf("successfully assigned %d/%d repositories",
 		len(repoNames), len(matching))
@@ -411,7 +417,7 @@ func (m *Manager) removeUnmatchedRepos(ccID, ccName string, matchedRepos []strin
 		m.log.Debug("Removing repo", "repo", r, "cost_center", ccName)
 	}
 
-	if err := m.client.RemoveRepositoriesFromCostCenter(ccID, stale); err != nil {
+	if _, err := m.client.RemoveReposFromCostCenter(ccID, stale); err != nil {
 		return 0, fmt.Errorf("removing unmatched repos from %s: %w", ccName, err)
 	}
 
@@ -423,13 +429,13 @@ func (m *Manager) createBudgets(ccID, ccName string) error {
 	m.log.Info("Creating budgets for cost center", "name", ccName)
 
 	var failures []string
-	for product, pc := range m.cfg.BudgetProducts {
+	for product, pc := range m.cfg.BudgetProductsFor(ccName) {
 		if !pc.Enabled {
 			m.log.Debug("Skipping disabled product budget", "product", product)
 			continue
 		}
 
-		ok, err := m.client.CreateProductBudget(ccID, ccName, product, pc.Amount)
+		ok, err := m.client.CreateProductBudget(ccID, ccName, product, pc.Amount, github.AlertingFromConfig(pc))
 		if err != nil {
 			if _, unavailable := err.(*github.BudgetsAPIUnavailableError); unavailable {
 				m.log.Warn("Budgets API unavailable, skipping remaining budgets", "error", err)
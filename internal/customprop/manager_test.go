@@ -2,6 +2,7 @@ package customprop
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/renan-alm/gh-cost-center/internal/config"
 	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/githubtest"
 )
 
 // newTestManager creates a Manager with test defaults.
@@ -866,3 +868,39 @@ func TestRemoveUnmatchedRepos_NothingToRemove(t *testing.T) {
 		t.Errorf("removed = %d, want 0", removed)
 	}
 }
+
+func TestRun_ApplyWithFakeAPI(t *testing.T) {
+	fake := githubtest.New()
+	id := fake.AddCostCenter("Backend")
+	fake.CostCenters[id].Resources = append(fake.CostCenters[id].Resources,
+		github.Resource{Type: "Repository", Name: "org/old"})
+	fake.OrgRepos["org"] = []github.RepoProperties{
+		{RepositoryName: "api", RepositoryFullName: "org/api", Properties: []github.Property{{PropertyName: "team", Value: "backend"}}},
+		{RepositoryName: "web", RepositoryFullName: "org/web", Properties: []github.Property{{PropertyName: "team", Value: "frontend"}}},
+	}
+
+	ccs := []config.CustomPropCostCenter{{Name: "Backend", Filters: []config.CustomPropertyFilter{{Property: "team", Value: "backend"}}}}
+	mgr := newTestManager(ccs)
+	mgr.client = fake
+	mgr.cfg.CustomPropRemoveUnmatched = true
+
+	summary, err := mgr.Run("org", "apply", false)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if r := summary.Results[0]; !r.Success || r.ReposAssigned != 1 || r.ReposRemoved != 1 {
+		t.Errorf("result = %+v, want 1 assigned and 1 removed", r)
+	}
+	if got := fake.CostCenters[id].ResourceNames("Repository"); len(got) != 1 || got[0] != "org/api" {
+		t.Errorf("repositories = %v, want [org/api]", got)
+	}
+
+	fake.Fail("AddReposToCostCenter", errors.New("boom"))
+	summary, err = mgr.Run("org", "apply", false)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if r := summary.Results[0]; r.Success || r.ReposAssigned != 0 {
+		t.Errorf("with a failing add: result = %+v, want a failure with nothing assigned", r)
+	}
+}
@@ -0,0 +1,56 @@
+package github
+
+// CostCenterAPI is the part of the GitHub API the assignment managers and
+// commands use: Copilot seats, cost centers and their members, budgets,
+// teams, and repositories.  *Client implements it; internal/githubtest
+// provides an in-memory fake for tests that need no HTTP server.
+//
+// Client settings such as the cache, the recorders, and the context are not
+// part of the interface; they are applied to a *Client before it is handed
+// out as a CostCenterAPI.
+type CostCenterAPI interface {
+	// Copilot seats.
+	GetCopilotUsers() ([]CopilotUser, error)
+
+	// Cost centers.
+	GetAllActiveCostCenters() (map[string]string, error)
+	GetAllActiveCostCentersWithMembers(concurrency int) (map[string]string, map[string][]string, error)
+	GetCostCenter(id string) (*CostCenterDetail, error)
+	CreateCostCenter(name string) (string, error)
+	CreateCostCenterWithPreload(name string, activeMap map[string]string) (string, error)
+	EnsureCostCenter(name string) (id string, created bool, err error)
+	UpdateCostCenter(id, newName string) error
+	DeleteCostCenter(id string) error
+	ResolveCostCenters(noPRUName, pruAllowedName string) (noPRUID, pruAllowedID string, err error)
+
+	// Cost center users.
+	GetCostCenterUsers(id string) ([]string, error)
+	GetCostCentersUsers(ids []string, concurrency int) (map[string][]string, error)
+	CheckUserCostCenterMembership(username string) (*CostCenterRef, error)
+	AddUsersToCostCenter(costCenterID string, usernames []string, ignoreCurrentCC bool) (map[string]bool, error)
+	BulkUpdateCostCenterAssignments(assignments map[string][]string, ignoreCurrentCC bool) (map[string]map[string]bool, error)
+	RemoveUsersFromCostCenter(costCenterID string, usernames []string) (map[string]bool, error)
+
+	// Budgets.
+	ListBudgets() ([]Budget, error)
+	CheckCostCenterHasProductBudget(costCenterID, costCenterName, product string) (bool, error)
+	CreateProductBudget(costCenterID, costCenterName, product string, amount int, alerting BudgetAlerting) (bool, error)
+	UpdateBudget(budgetID string, amount int, preventFurtherUsage bool) error
+	DeleteBudget(budgetID string) error
+
+	// Teams.
+	GetOrgTeams(org string) ([]Team, error)
+	GetOrgTeamMembers(org, teamSlug string) ([]TeamMember, error)
+	GetEnterpriseTeams() ([]Team, error)
+	GetEnterpriseTeamMembers(teamSlug string) ([]TeamMember, error)
+
+	// Repositories.
+	GetOrgPropertySchema(org string) ([]PropertyDefinition, error)
+	GetOrgReposWithProperties(org string, query string) ([]RepoProperties, error)
+	GetOrgRepoStatuses(org string) ([]RepoStatus, error)
+	GetCostCenterRepos(id string) ([]string, error)
+	AddReposToCostCenter(costCenterID string, repoFullNames []string) (map[string]bool, error)
+	RemoveReposFromCostCenter(costCenterID string, repoFullNames []string) (map[string]bool, error)
+}
+
+var _ CostCenterAPI = (*Client)(nil)
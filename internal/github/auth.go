@@ -0,0 +1,181 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RequiredScopes are the classic token scopes the cost center and Copilot
+// billing APIs need.
+var RequiredScopes = []string{"manage_billing:enterprise", "read:enterprise"}
+
+// impliedScopes lists the scopes that grant another scope as well.
+var impliedScopes = map[string][]string{
+	"admin:enterprise": {"manage_billing:enterprise", "read:enterprise"},
+}
+
+// AuthError reports that the API rejected the token: a 401, or a 403
+// "Resource not accessible" or missing-scope response.  Its message says
+// which scopes are needed and how to refresh the token.  It unwraps to the
+// underlying *APIError, which is nil when the error comes from CheckScopes.
+type AuthError struct {
+	Err     *APIError
+	Missing []string // required scopes the token lacks, when known
+	host    string   // gh host of the API, "" for github.com
+}
+
+func (e *AuthError) Error() string {
+	var b strings.Builder
+	switch {
+	case e.Err != nil && e.Err.StatusCode == http.StatusUnauthorized:
+		b.WriteString("GitHub API error 401: the token is invalid or expired")
+	case e.Err != nil:
+		fmt.Fprintf(&b, "GitHub API error %d: %s", e.Err.StatusCode, e.Err.Message())
+	default:
+		b.WriteString("the token lacks required scopes")
+	}
+	scopes := RequiredScopes
+	if len(e.Missing) > 0 {
+		scopes = e.Missing
+		fmt.Fprintf(&b, "; missing scopes: %s", strings.Join(e.Missing, ", "))
+	} else if e.Err == nil || e.Err.StatusCode != http.StatusUnauthorized {
+		fmt.Fprintf(&b, "; the token needs the %s scopes", strings.Join(RequiredScopes, " and "))
+	}
+	refresh := "gh auth refresh"
+	if e.host != "" {
+		refresh += " -h " + e.host
+	}
+	fmt.Fprintf(&b, "; run '%s -s %s' or set a new GITHUB_TOKEN", refresh, strings.Join(scopes, ","))
+	return b.String()
+}
+
+func (e *AuthError) Unwrap() error {
+	if e.Err == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// apiError returns the error of a non-retried non-2xx response: an
+// *AuthError when the token was rejected, an *APIError otherwise.  A 403 is
+// a rejection when GitHub says "Resource not accessible", or when the token
+// holds none of the scopes the endpoint accepts (X-Accepted-OAuth-Scopes).
+func (c *Client) apiError(resp *http.Response, body string) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode, Body: body}
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return &AuthError{Err: apiErr, host: ghHost(c.baseURL)}
+	case http.StatusForbidden:
+		missing := acceptedScopesMissing(resp.Header)
+		if len(missing) > 0 || strings.Contains(apiErr.Message(), "Resource not accessible") {
+			return &AuthError{Err: apiErr, Missing: missing, host: ghHost(c.baseURL)}
+		}
+	}
+	return apiErr
+}
+
+// CheckScopes is a preflight of the token's scopes: it reads the
+// X-OAuth-Scopes header of GET /user, giving up after timeout, and returns
+// an *AuthError listing the RequiredScopes the token lacks.  Fine-grained
+// and GitHub App tokens send no such header and always pass.
+func (c *Client) CheckScopes(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	resp, err := c.doContext(ctx, http.MethodGet, c.baseURL+"/user", nil)
+	if err != nil {
+		return fmt.Errorf("checking token scopes: %w", err)
+	}
+	body := readBody(resp)
+	_ = resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusForbidden:
+		// App installation tokens cannot read /user but carry no scopes.
+		return nil
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("checking token scopes: %w", c.apiError(resp, body))
+	}
+	granted, ok := grantedScopes(resp.Header)
+	if !ok {
+		return nil
+	}
+	var missing []string
+	for _, s := range RequiredScopes {
+		if !granted[s] {
+			missing = append(missing, s)
+		}
+	}
+	if len(missing) > 0 {
+		return &AuthError{Missing: missing, host: ghHost(c.baseURL)}
+	}
+	return nil
+}
+
+// grantedScopes returns the scopes in the X-OAuth-Scopes header h, with the
+// scopes they imply, and false when the header is absent.
+func grantedScopes(h http.Header) (map[string]bool, bool) {
+	values, ok := h[http.CanonicalHeaderKey("X-OAuth-Scopes")]
+	if !ok {
+		return nil, false
+	}
+	granted := map[string]bool{}
+	for _, s := range splitScopes(values) {
+		granted[s] = true
+		for _, implied := range impliedScopes[s] {
+			granted[implied] = true
+		}
+	}
+	return granted, true
+}
+
+// acceptedScopesMissing returns the scopes of the X-Accepted-OAuth-Scopes
+// header h when the token, per X-OAuth-Scopes, holds none of them, and nil
+// otherwise.
+func acceptedScopesMissing(h http.Header) []string {
+	granted, ok := grantedScopes(h)
+	accepted := splitScopes(h.Values("X-Accepted-OAuth-Scopes"))
+	if !ok || len(accepted) == 0 {
+		return nil
+	}
+	for _, s := range accepted {
+		if granted[s] {
+			return nil
+		}
+	}
+	return accepted
+}
+
+// splitScopes splits comma-separated scope header values.
+func splitScopes(values []string) []string {
+	var scopes []string
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+	return scopes
+}
+
+// ghHost returns the host to pass to gh -h for the API at baseURL, or ""
+// for github.com: api.github.com is github.com, api.SUB.ghe.com is
+// SUB.ghe.com, and a GHES https://HOST/api/v3 is HOST.
+func ghHost(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	host := u.Hostname()
+	if host == "api.github.com" {
+		return ""
+	}
+	if rest, ok := strings.CutPrefix(host, "api."); ok && strings.HasSuffix(rest, ".ghe.com") {
+		return rest
+	}
+	return host
+}
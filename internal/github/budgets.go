@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
 )
 
 // BudgetsAPIUnavailableError indicates the GitHub Budgets API is not enabled
@@ -19,31 +21,75 @@ func (e *BudgetsAPIUnavailableError) Error() string {
 
 // Budget represents a single budget entry from the API.
 type Budget struct {
-	BudgetType       string `json:"budget_type"`
-	BudgetProductSKU string `json:"budget_product_sku"`
-	BudgetScope      string `json:"budget_scope"`
-	BudgetAmount     int    `json:"budget_amount"`
-	BudgetEntityName string `json:"budget_entity_name"`
+	ID                  string         `json:"id"`
+	BudgetType          string         `json:"budget_type"`
+	BudgetProductSKU    string         `json:"budget_product_sku"`
+	BudgetScope         string         `json:"budget_scope"`
+	BudgetAmount        int            `json:"budget_amount"`
+	BudgetEntityName    string         `json:"budget_entity_name"`
+	PreventFurtherUsage bool           `json:"prevent_further_usage"`
+	BudgetAlerting      BudgetAlerting `json:"budget_alerting"`
+}
+
+// BudgetAlerting holds the alert settings of a budget.
+type BudgetAlerting struct {
+	WillAlert       bool     `json:"will_alert"`
+	AlertRecipients []string `json:"alert_recipients"`
+}
+
+// AlertingFromConfig converts a product budget's alert settings into the
+// BudgetAlerting sent to the API.
+func AlertingFromConfig(pb config.ProductBudget) BudgetAlerting {
+	return BudgetAlerting{
+		WillAlert:       pb.AlertsEnabled,
+		AlertRecipients: pb.AlertRecipients,
+	}
 }
 
 // budgetsListResponse is the JSON envelope for the budgets list endpoint.
+// HasNextPage is optional; when the API omits it pagination falls back to
+// stopping on a short page.
 type budgetsListResponse struct {
-	Budgets []Budget `json:"budgets"`
+	Budgets     []Budget `json:"budgets"`
+	HasNextPage *bool    `json:"has_next_page"`
 }
 
-// ListBudgets returns all budgets for the enterprise.
+// ListBudgets returns all budgets for the enterprise, handling pagination
+// automatically.
 func (c *Client) ListBudgets() ([]Budget, error) {
-	url := c.enterpriseURL("/settings/billing/budgets")
-	var resp budgetsListResponse
-	_, err := c.doJSON(http.MethodGet, url, nil, &resp)
-	if err != nil {
-		var apiErr *APIError
-		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
-			return nil, &BudgetsAPIUnavailableError{Enterprise: c.enterprise}
+	baseURL := c.enterpriseURL("/settings/billing/budgets")
+
+	var all []Budget
+	page := 1
+	const perPage = 100
+
+	for {
+		pageURL := pagedURL(baseURL, page, perPage, nil)
+		var resp budgetsListResponse
+		if _, err := c.doJSON(http.MethodGet, pageURL, nil, &resp); err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+				return nil, &BudgetsAPIUnavailableError{Enterprise: c.enterprise}
+			}
+			return nil, fmt.Errorf("listing budgets page %d: %w", page, err)
+		}
+		all = append(all, resp.Budgets...)
+		c.log.Debug("Fetched budgets page", "page", page, "count", len(resp.Budgets))
+
+		if resp.HasNextPage != nil {
+			if !*resp.HasNextPage {
+				break
+			}
+		} else if len(resp.Budgets) < perPage {
+			break
+		}
+		if len(resp.Budgets) == 0 {
+			break
 		}
-		return nil, fmt.Errorf("listing budgets: %w", err)
+		page++
 	}
-	return resp.Budgets, nil
+
+	return all, nil
 }
 
 // CheckCostCenterHasBudget returns true if any budget targets the given cost
@@ -68,25 +114,96 @@ func (c *Client) CheckCostCenterHasBudget(costCenterID, costCenterName string) (
 // CheckCostCenterHasProductBudget returns true if a budget exists for the
 // given cost center and product combination.
 func (c *Client) CheckCostCenterHasProductBudget(costCenterID, costCenterName, product string) (bool, error) {
-	budgets, err := c.ListBudgets()
+	b, err := c.findProductBudget(costCenterID, costCenterName, product)
 	if err != nil {
 		return false, err
 	}
+	return b != nil, nil
+}
+
+// findProductBudget returns the existing budget for the given cost center and
+// product, or nil if there is none.
+func (c *Client) findProductBudget(costCenterID, costCenterName, product string) (*Budget, error) {
+	budgets, err := c.ListBudgets()
+	if err != nil {
+		return nil, err
+	}
 	_, sku := GetBudgetTypeAndSKU(product)
-	for _, b := range budgets {
+	for i, b := range budgets {
 		if b.BudgetScope == "cost_center" &&
 			(b.BudgetEntityName == costCenterID || b.BudgetEntityName == costCenterName) &&
 			b.BudgetProductSKU == sku {
 			c.log.Info("Found existing budget", "product", product, "cost_center", costCenterName)
-			return true, nil
+			return &budgets[i], nil
 		}
 	}
-	return false, nil
+	return nil, nil
+}
+
+// UpdateBudget changes the amount and hard-stop setting of an existing budget.
+func (c *Client) UpdateBudget(budgetID string, amount int, preventFurtherUsage bool) error {
+	if budgetID == "" {
+		return fmt.Errorf("updating budget: budget ID is required")
+	}
+	url := c.enterpriseURL("/settings/billing/budgets" + escapePath(budgetID))
+	body := map[string]any{
+		"budget_amount":         amount,
+		"prevent_further_usage": preventFurtherUsage,
+	}
+
+	_, err := c.doJSON(http.MethodPatch, url, body, nil)
+	c.mutated(Mutation{Operation: "update_budget", Target: budgetID, Detail: fmt.Sprintf("amount=%d", amount), Err: err})
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("updating budget %s: budget not found: %w", budgetID, err)
+		}
+		return fmt.Errorf("updating budget %s: %w", budgetID, err)
+	}
+	return nil
+}
+
+// DeleteBudget deletes the budget with the given ID.
+func (c *Client) DeleteBudget(budgetID string) error {
+	if budgetID == "" {
+		return fmt.Errorf("deleting budget: budget ID is required")
+	}
+	url := c.enterpriseURL("/settings/billing/budgets" + escapePath(budgetID))
+	_, err := c.doJSON(http.MethodDelete, url, nil, nil)
+	c.mutated(Mutation{Operation: "delete_budget", Target: budgetID, Err: err})
+	if err != nil {
+		return fmt.Errorf("deleting budget %s: %w", budgetID, err)
+	}
+	c.log.Info("Deleted budget", "id", budgetID)
+	return nil
+}
+
+// FindOrphanedBudgets returns the cost-center-scoped budgets whose entity no
+// longer matches any active cost center.  activeCostCenters maps cost center
+// name → ID (as returned by GetAllActiveCostCenters); entities are matched
+// against both because the API may store either.
+func FindOrphanedBudgets(budgets []Budget, activeCostCenters map[string]string) []Budget {
+	known := make(map[string]bool, len(activeCostCenters)*2)
+	for name, id := range activeCostCenters {
+		known[name] = true
+		known[id] = true
+	}
+
+	var orphaned []Budget
+	for _, b := range budgets {
+		if b.BudgetScope != "cost_center" {
+			continue
+		}
+		if !known[b.BudgetEntityName] {
+			orphaned = append(orphaned, b)
+		}
+	}
+	return orphaned
 }
 
 // CreateBudget creates a default Copilot Premium Request budget for a cost
 // center.  If a budget already exists it returns true without error.
-func (c *Client) CreateBudget(costCenterID, costCenterName string, amount int) (bool, error) {
+func (c *Client) CreateBudget(costCenterID, costCenterName string, amount int, alerting BudgetAlerting) (bool, error) {
 	exists, err := c.CheckCostCenterHasBudget(costCenterID, costCenterName)
 	if err != nil {
 		return false, err
@@ -96,29 +213,47 @@ func (c *Client) CreateBudget(costCenterID, costCenterName string, amount int) (
 		return true, nil
 	}
 
-	return c.createBudgetRequest(costCenterID, costCenterName, "SkuPricing", "copilot_premium_request", amount)
+	return c.createBudgetRequest(costCenterID, costCenterName, "SkuPricing", "copilot_premium_request", amount, alerting)
 }
 
 // CreateProductBudget creates a product-specific budget for a cost center.
-func (c *Client) CreateProductBudget(costCenterID, costCenterName, product string, amount int) (bool, error) {
-	exists, err := c.CheckCostCenterHasProductBudget(costCenterID, costCenterName, product)
+//
+// When budget reconciliation is enabled (see SetReconcileBudgets) and a budget
+// already exists with a different amount, the existing budget is updated to
+// the requested amount instead of being left untouched.
+func (c *Client) CreateProductBudget(costCenterID, costCenterName, product string, amount int, alerting BudgetAlerting) (bool, error) {
+	existing, err := c.findProductBudget(costCenterID, costCenterName, product)
 	if err != nil {
 		return false, err
 	}
-	if exists {
-		c.log.Info("Product budget already exists",
-			"product", product, "cost_center", costCenterName)
+	if existing != nil {
+		if !c.reconcileBudgets || existing.BudgetAmount == amount {
+			c.log.Info("Product budget already exists",
+				"product", product, "cost_center", costCenterName)
+			return true, nil
+		}
+		if err := c.UpdateBudget(existing.ID, amount, existing.PreventFurtherUsage); err != nil {
+			return false, fmt.Errorf("reconciling %s budget for cost center %q: %w", product, costCenterName, err)
+		}
+		c.log.Info("Updated budget amount",
+			"product", product, "cost_center", costCenterName,
+			"old_amount", existing.BudgetAmount, "new_amount", amount)
 		return true, nil
 	}
 
 	budgetType, sku := GetBudgetTypeAndSKU(product)
-	return c.createBudgetRequest(costCenterID, costCenterName, budgetType, sku, amount)
+	return c.createBudgetRequest(costCenterID, costCenterName, budgetType, sku, amount, alerting)
 }
 
 // createBudgetRequest sends the POST to create a budget.
-func (c *Client) createBudgetRequest(costCenterID, costCenterName, budgetType, productSKU string, amount int) (bool, error) {
+func (c *Client) createBudgetRequest(costCenterID, costCenterName, budgetType, productSKU string, amount int, alerting BudgetAlerting) (bool, error) {
 	url := c.enterpriseURL("/settings/billing/budgets")
 
+	recipients := alerting.AlertRecipients
+	if recipients == nil {
+		recipients = []string{}
+	}
+
 	body := map[string]any{
 		"budget_type":           budgetType,
 		"budget_product_sku":    productSKU,
@@ -127,12 +262,14 @@ func (c *Client) createBudgetRequest(costCenterID, costCenterName, budgetType, p
 		"prevent_further_usage": true,
 		"budget_entity_name":    costCenterID,
 		"budget_alerting": map[string]any{
-			"will_alert":       false,
-			"alert_recipients": []string{},
+			"will_alert":       alerting.WillAlert,
+			"alert_recipients": recipients,
 		},
 	}
 
 	_, err := c.doJSON(http.MethodPost, url, body, nil)
+	c.mutated(Mutation{Operation: "create_budget", Target: costCenterID,
+		Detail: fmt.Sprintf("product_sku=%s amount=%d", productSKU, amount), Err: err})
 	if err != nil {
 		var apiErr *APIError
 		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
@@ -149,59 +286,11 @@ func (c *Client) createBudgetRequest(costCenterID, costCenterName, budgetType, p
 // GetBudgetTypeAndSKU maps a product name to the appropriate (budgetType,
 // productSKU) tuple.  Product-level identifiers use "ProductPricing", while
 // SKU-level identifiers use "SkuPricing".
-//
-// Reference: https://docs.github.com/enterprise-cloud@latest/billing/reference/product-and-sku-names
 func GetBudgetTypeAndSKU(product string) (budgetType, productSKU string) {
 	p := strings.ToLower(product)
-
-	// Product-level identifiers (ProductPricing).
-	productLevel := map[string]string{
-		"actions":    "actions",
-		"packages":   "packages",
-		"codespaces": "codespaces",
-		"copilot":    "copilot",
-		"ghas":       "ghas",
-		"ghec":       "ghec",
-	}
-
-	// SKU-level identifiers (SkuPricing).
-	skuLevel := map[string]string{
-		// Copilot
-		"copilot_premium_request":       "copilot_premium_request",
-		"copilot_agent_premium_request": "copilot_agent_premium_request",
-		"copilot_enterprise":            "copilot_enterprise",
-		"copilot_for_business":          "copilot_for_business",
-		"copilot_standalone":            "copilot_standalone",
-		// Actions
-		"actions_linux":   "actions_linux",
-		"actions_macos":   "actions_macos",
-		"actions_windows": "actions_windows",
-		"actions_storage": "actions_storage",
-		// Codespaces
-		"codespaces_storage":          "codespaces_storage",
-		"codespaces_prebuild_storage": "codespaces_prebuild_storage",
-		// Packages
-		"packages_storage":   "packages_storage",
-		"packages_bandwidth": "packages_bandwidth",
-		// GHAS
-		"ghas_licenses":                   "ghas_licenses",
-		"ghas_code_security_licenses":     "ghas_code_security_licenses",
-		"ghas_secret_protection_licenses": "ghas_secret_protection_licenses",
-		// Other
-		"ghec_licenses":         "ghec_licenses",
-		"git_lfs_storage":       "git_lfs_storage",
-		"git_lfs_bandwidth":     "git_lfs_bandwidth",
-		"models_inference":      "models_inference",
-		"spark_premium_request": "spark_premium_request",
-	}
-
-	if sku, ok := skuLevel[p]; ok {
-		return "SkuPricing", sku
-	}
-	if prod, ok := productLevel[p]; ok {
-		return "ProductPricing", prod
-	}
-
-	// Unknown — default to SkuPricing.
+	if config.IsProductLevelBudget(p) {
+		return "ProductPricing", p
+	}
+	// SKU-level, or unknown (allowed by budgets.allow_unknown_products).
 	return "SkuPricing", p
 }
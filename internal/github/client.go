@@ -2,16 +2,25 @@ package github
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"math"
+	"mime"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/renan-alm/gh-cost-center/internal/cache"
@@ -48,6 +57,58 @@ type Client struct {
 	token      string // Bearer token for GitHub API
 	log        *slog.Logger
 	ccCache    *cache.Cache // optional cost center cache
+
+	// reconcileBudgets makes CreateProductBudget update existing budgets whose
+	// amount differs from the requested one.
+	reconcileBudgets bool
+
+	// recordAssignments, when set, receives the results of every batch of
+	// AddUsersToCostCenter as soon as it completes.
+	recordAssignments AssignmentRecorder
+
+	// ctx, when set, interrupts apply loops between batches once done.
+	ctx context.Context
+
+	// recordMutation, when set, receives every create, update, and delete
+	// request the client sends.
+	recordMutation MutationRecorder
+
+	// seatConcurrency bounds parallel Copilot seat page requests.
+	seatConcurrency int
+
+	// seatOrgs, when non-empty, makes GetCopilotUsers aggregate the seats of
+	// these organizations instead of listing enterprise seats.
+	seatOrgs []string
+
+	// rateLimitUntil is shared by all goroutines using the client: after a
+	// 429, every request waits until this time before being sent.
+	rateLimitMu    sync.Mutex
+	rateLimitUntil time.Time
+
+	// seatFetch holds the counts of the last GetCopilotUsers call.
+	seatFetchMu sync.Mutex
+	seatFetch   SeatFetch
+
+	// API traffic counters reported by Metrics.
+	requests       atomic.Int64
+	retries        atomic.Int64
+	rateLimitWaits atomic.Int64
+}
+
+// Metrics counts the API traffic of a client since it was created.
+type Metrics struct {
+	Requests       int64 `json:"requests"`         // HTTP requests sent, retries included
+	Retries        int64 `json:"retries"`          // requests repeated after a transient error
+	RateLimitWaits int64 `json:"rate_limit_waits"` // 429 responses waited out
+}
+
+// Metrics returns the client's API traffic counters.
+func (c *Client) Metrics() Metrics {
+	return Metrics{
+		Requests:       c.requests.Load(),
+		Retries:        c.retries.Load(),
+		RateLimitWaits: c.rateLimitWaits.Load(),
+	}
 }
 
 // NewClient creates a Client from a loaded config.Manager.
@@ -58,8 +119,10 @@ type Client struct {
 //  3. GH_TOKEN environment variable.
 //  4. `gh auth token` shell-out (silent fallback if gh is installed).
 //
+// Options such as WithTransportMiddleware customize the HTTP stack.
+//
 // Returns an error if no token can be obtained.
-func NewClient(cfg *config.Manager, logger *slog.Logger) (*Client, error) {
+func NewClient(cfg *config.Manager, logger *slog.Logger, opts ...Option) (*Client, error) {
 	if cfg.Enterprise == "" {
 		return nil, fmt.Errorf("enterprise slug is required")
 	}
@@ -73,13 +136,28 @@ func NewClient(cfg *config.Manager, logger *slog.Logger) (*Client, error) {
 
 	logger.Debug("GitHub token resolved", "source", tokenSource(cfg.Token))
 
-	return &Client{
-		http:       &http.Client{Timeout: 30 * time.Second},
-		baseURL:    baseURL,
-		enterprise: cfg.Enterprise,
-		token:      token,
-		log:        logger,
-	}, nil
+	var o clientOptions
+	base, err := tlsTransport(cfg.CACertPath, cfg.InsecureSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+	o.base = base
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c := &Client{
+		http:            newHTTPClient(token, o),
+		baseURL:         baseURL,
+		enterprise:      cfg.Enterprise,
+		token:           token,
+		log:             logger,
+		seatConcurrency: cfg.SeatFetchConcurrency,
+	}
+	if cfg.CopilotScope == "organization" {
+		c.seatOrgs = cfg.Organizations
+	}
+	return c, nil
 }
 
 // resolveToken returns the first non-empty token from the chain:
@@ -124,28 +202,134 @@ func (c *Client) SetCache(cc *cache.Cache) {
 	c.ccCache = cc
 }
 
+// SetReconcileBudgets enables or disables updating existing budgets whose
+// amount no longer matches the configured one.  It is off by default so that
+// runs never modify existing budgets unless asked to.
+func (c *Client) SetReconcileBudgets(enabled bool) {
+	c.reconcileBudgets = enabled
+}
+
+// AssignmentRecorder receives per-user results of cost center assignments.
+// err is the batch error, if any, behind failed entries.
+type AssignmentRecorder func(costCenterID string, results map[string]bool, err error)
+
+// SetAssignmentRecorder registers fn to be called after every assignment
+// batch, e.g. to persist progress so that an interrupted run can be resumed.
+func (c *Client) SetAssignmentRecorder(fn AssignmentRecorder) {
+	c.recordAssignments = fn
+}
+
+// Mutation is one create, update, or delete request sent to the API.
+type Mutation struct {
+	// Operation is add_users, remove_users, add_repositories,
+	// remove_repositories, add_organizations, remove_organizations,
+	// create_cost_center, rename_cost_center, delete_cost_center,
+	// create_budget, update_budget, or delete_budget.
+	Operation string
+	Target    string   // cost center or budget ID; the name of a new cost center
+	Items     []string // the users, repositories, or organizations of a batch
+	Detail    string   // e.g. the new name or the budget amount
+	Err       error    // nil when the request succeeded
+}
+
+// MutationRecorder receives the mutations a client sends.
+type MutationRecorder func(Mutation)
+
+// SetMutationRecorder registers fn to be called after every mutating
+// request, successful or not, e.g. to keep an audit trail.
+func (c *Client) SetMutationRecorder(fn MutationRecorder) {
+	c.recordMutation = fn
+}
+
+// mutated passes m to the mutation recorder, if one is set.
+func (c *Client) mutated(m Mutation) {
+	if c.recordMutation != nil {
+		c.recordMutation(m)
+	}
+}
+
+// ErrInterrupted is returned by the apply loops when the context set with
+// SetContext is done before every batch was sent.
+var ErrInterrupted = errors.New("interrupted before every batch was sent")
+
+// SetContext makes the assignment loops stop starting new batches once ctx
+// is done, returning the results so far with ErrInterrupted.  Requests
+// already in flight are allowed to finish, so every result is known.
+func (c *Client) SetContext(ctx context.Context) {
+	c.ctx = ctx
+}
+
+// interrupted reports whether the context set with SetContext is done.
+func (c *Client) interrupted() bool {
+	return c.ctx != nil && c.ctx.Err() != nil
+}
+
 // APIError is returned when the GitHub API responds with a non-2xx status
 // that is not retried (or all retries are exhausted).
 type APIError struct {
 	StatusCode int
-	Body       string
+	Body       string // response body, capped at maxErrorBody (see readBody)
 }
 
+// Error reports the status and the body, elided past maxErrorBodyInMessage
+// bytes so that an HTML error page does not flood the logs.
 func (e *APIError) Error() string {
-	return fmt.Sprintf("GitHub API error %d: %s", e.StatusCode, e.Body)
+	return fmt.Sprintf("GitHub API error %d: %s", e.StatusCode, elideBody(e.Body))
+}
+
+// apiErrorEnvelope is the JSON error shape returned by the GitHub REST API.
+type apiErrorEnvelope struct {
+	Message string `json:"message"`
+	Errors  []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Message returns the human-readable message from a JSON error body, joined
+// with any nested error messages.  Falls back to the raw body when it is not
+// a JSON error envelope.
+func (e *APIError) Message() string {
+	var env apiErrorEnvelope
+	if err := json.Unmarshal([]byte(e.Body), &env); err != nil || env.Message == "" {
+		return elideBody(strings.TrimSpace(e.Body))
+	}
+	msgs := []string{env.Message}
+	for _, sub := range env.Errors {
+		if sub.Message != "" {
+			msgs = append(msgs, sub.Message)
+		}
+	}
+	return strings.Join(msgs, ": ")
+}
+
+// APIMessageError reports an APIError by its parsed message instead of the raw
+// body.  It unwraps to the underlying *APIError so status checks still work.
+type APIMessageError struct {
+	Err *APIError
+}
+
+func (e *APIMessageError) Error() string {
+	return fmt.Sprintf("GitHub API error %d: %s", e.Err.StatusCode, e.Err.Message())
 }
 
+// Message returns the parsed API message.
+func (e *APIMessageError) Message() string { return e.Err.Message() }
+
+func (e *APIMessageError) Unwrap() error { return e.Err }
+
 // --------------------------------------------------------------------
 // Core request helpers
 // --------------------------------------------------------------------
 
 // doJSON performs an HTTP request, retrying on transient errors and rate
-// limits. If dest is non-nil the response body is JSON-decoded into it.
-// The body parameter, when non-nil, is JSON-encoded as the request body.
-func (c *Client) doJSON(method, url string, body any, dest any) (*http.Response, error) {
+// limits. If dest is non-nil the response body is JSON-decoded into it (see
+// decodeJSON). The body parameter, when non-nil, is JSON-encoded as the
+// request body.
+func (c *Client) doJSON(method, reqURL string, body any, dest any) (*http.Response, error) {
 	attempt := 0
 	for attempt < maxRetries {
-		resp, err := c.do(method, url, body)
+		c.waitForRateLimit()
+		resp, err := c.do(method, reqURL, body)
 		if err != nil {
 			if isTransient(err) && attempt < maxRetries-1 {
 				wait := c.backoff(attempt, nil)
@@ -155,6 +339,7 @@ func (c *Client) doJSON(method, url string, body any, dest any) (*http.Response,
 					"err", err,
 				)
 				time.Sleep(wait)
+				c.retries.Add(1)
 				attempt++
 				continue
 			}
@@ -163,13 +348,11 @@ func (c *Client) doJSON(method, url string, body any, dest any) (*http.Response,
 
 		// Successful 2xx — decode response.
 		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			defer func() { _ = resp.Body.Close() }()
 			if dest != nil {
-				defer func() { _ = resp.Body.Close() }()
-				if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
-					return resp, fmt.Errorf("decoding response from %s %s: %w", method, url, err)
+				if err := decodeJSON(method, reqURL, resp, dest); err != nil {
+					return resp, err
 				}
-			} else {
-				_ = resp.Body.Close()
 			}
 			return resp, nil
 		}
@@ -184,9 +367,10 @@ func (c *Client) doJSON(method, url string, body any, dest any) (*http.Response,
 			wait := c.rateLimitWait(resp)
 			c.log.Warn("rate limit hit, waiting",
 				"wait", wait,
-				"url", url,
+				"url", reqURL,
 			)
-			time.Sleep(wait)
+			c.pauseForRateLimit(wait)
+			c.rateLimitWaits.Add(1)
 			continue // do NOT increment attempt
 		}
 
@@ -197,26 +381,31 @@ func (c *Client) doJSON(method, url string, body any, dest any) (*http.Response,
 				"status", resp.StatusCode,
 				"attempt", attempt+1,
 				"wait", wait,
-				"url", url,
+				"url", reqURL,
 			)
 			time.Sleep(wait)
+			c.retries.Add(1)
 			attempt++
 			continue
 		}
 
-		// Non-retryable error — return APIError.
-		return resp, &APIError{
-			StatusCode: resp.StatusCode,
-			Body:       errBody,
-		}
+		// Non-retryable error — return APIError, or AuthError when the
+		// token was rejected.
+		return resp, c.apiError(resp, errBody)
 	}
 
 	// Should not typically be reached, but guard against it.
-	return nil, fmt.Errorf("request to %s %s failed after %d retries", method, url, maxRetries)
+	return nil, fmt.Errorf("request to %s %s failed after %d retries", method, reqURL, maxRetries)
 }
 
 // do builds and executes a single HTTP request (no retry logic).
-func (c *Client) do(method, url string, body any) (*http.Response, error) {
+func (c *Client) do(method, reqURL string, body any) (*http.Response, error) {
+	return c.doContext(context.Background(), method, reqURL, body)
+}
+
+// doContext is do with a context bounding the request.
+func (c *Client) doContext(ctx context.Context, method, reqURL string, body any) (*http.Response, error) {
+	c.requests.Add(1)
 	var bodyReader io.Reader
 	if body != nil {
 		b, err := json.Marshal(body)
@@ -226,29 +415,27 @@ func (c *Client) do(method, url string, body any) (*http.Response, error) {
 		bodyReader = bytes.NewReader(b)
 	}
 
-	req, err := http.NewRequest(method, url, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
 
-	req.Header.Set("Accept", acceptHeader)
-	req.Header.Set("User-Agent", userAgent)
-	req.Header.Set("X-GitHub-Api-Version", apiVersion)
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
-	}
+	// The other headers are set by headerTransport.
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
 	c.log.Debug("HTTP request",
 		"method", method,
-		"url", url,
+		"url", reqURL,
 	)
 
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("%s %s: %w", method, url, err)
+		if isCertificateError(err) {
+			return nil, fmt.Errorf("%s %s: %w (set github.ca_cert_path to the PEM bundle of the server's CA)", method, reqURL, err)
+		}
+		return nil, fmt.Errorf("%s %s: %w", method, reqURL, err)
 	}
 	return resp, nil
 }
@@ -257,12 +444,67 @@ func (c *Client) do(method, url string, body any) (*http.Response, error) {
 // URL helpers
 // --------------------------------------------------------------------
 
-// enterpriseURL builds a full API URL for an enterprise-scoped endpoint.
+// enterpriseURL builds a full API URL for an enterprise-scoped endpoint.  The
+// enterprise slug is escaped; path must already be escaped (use escapePath
+// for dynamic segments).
 //
 //	c.enterpriseURL("/copilot/billing/seats")
 //	→ "https://api.github.com/enterprises/SLUG/copilot/billing/seats"
 func (c *Client) enterpriseURL(path string) string {
-	return fmt.Sprintf("%s/enterprises/%s%s", c.baseURL, c.enterprise, path)
+	return c.baseURL + escapePath("enterprises", c.enterprise) + path
+}
+
+// escapePath joins path segments with "/", escaping each with url.PathEscape
+// so that names containing spaces, slashes, or unicode stay a single segment.
+//
+//	escapePath("orgs", "my org", "teams") → "/orgs/my%20org/teams"
+func escapePath(segments ...string) string {
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteByte('/')
+		b.WriteString(url.PathEscape(s))
+	}
+	return b.String()
+}
+
+// pagedURL appends page, per_page, and any extra query parameters to base.
+func pagedURL(base string, page, perPage int, extra url.Values) string {
+	q := url.Values{}
+	for k, v := range extra {
+		q[k] = v
+	}
+	q.Set("page", strconv.Itoa(page))
+	q.Set("per_page", strconv.Itoa(perPage))
+	return base + "?" + q.Encode()
+}
+
+// linkNextRe extracts the URL of the rel="next" entry from a Link header.
+var linkNextRe = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// nextPageURL returns the URL of the next page advertised by the response's
+// Link header, or "" when there is none.  Because the request carries the
+// token, a next URL pointing at a different scheme or host than the client's
+// base URL is rejected.
+func (c *Client) nextPageURL(resp *http.Response) (string, error) {
+	if resp == nil {
+		return "", nil
+	}
+	m := linkNextRe.FindStringSubmatch(resp.Header.Get("Link"))
+	if len(m) != 2 {
+		return "", nil
+	}
+	next, err := url.Parse(m[1])
+	if err != nil {
+		return "", fmt.Errorf("parsing next page URL %q: %w", m[1], err)
+	}
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("parsing base URL %q: %w", c.baseURL, err)
+	}
+	if next.Scheme != base.Scheme || next.Host != base.Host {
+		return "", fmt.Errorf("refusing next page URL %q: host does not match %s", m[1], c.baseURL)
+	}
+	return m[1], nil
 }
 
 // --------------------------------------------------------------------
@@ -293,10 +535,32 @@ func (c *Client) rateLimitWait(resp *http.Response) time.Duration {
 	return wait
 }
 
+// pauseForRateLimit records that no request should be sent for the next wait
+// duration.  Concurrent callers pick this up in waitForRateLimit.
+func (c *Client) pauseForRateLimit(wait time.Duration) {
+	until := time.Now().Add(wait)
+	c.rateLimitMu.Lock()
+	if until.After(c.rateLimitUntil) {
+		c.rateLimitUntil = until
+	}
+	c.rateLimitMu.Unlock()
+}
+
+// waitForRateLimit blocks until any rate-limit pause set by another request
+// has elapsed.
+func (c *Client) waitForRateLimit() {
+	c.rateLimitMu.Lock()
+	until := c.rateLimitUntil
+	c.rateLimitMu.Unlock()
+	if wait := time.Until(until); wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
 // isTransient returns true for errors that are typically caused by network
 // hiccups and are safe to retry (connection refused, timeouts, etc.).
 func isTransient(err error) bool {
-	if err == nil {
+	if err == nil || isCertificateError(err) {
 		return false
 	}
 	s := err.Error()
@@ -314,14 +578,110 @@ func isTransient(err error) bool {
 	return false
 }
 
-// readBody reads and returns the response body as a string, capped at 4 KB.
+// isCertificateError reports whether err is a TLS certificate verification
+// failure, which no retry fixes.
+func isCertificateError(err error) bool {
+	var verifyErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalid x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	return errors.As(err, &verifyErr) || errors.As(err, &unknownAuthority) ||
+		errors.As(err, &invalid) || errors.As(err, &hostname)
+}
+
+// maxDecodeErrorBody caps the body quoted by decodeJSON errors.
+const maxDecodeErrorBody = 200
+
+// decodeJSON decodes the body of the 2xx response resp into dest.  204 and
+// 205 responses and empty bodies leave dest untouched.  A body that is not
+// JSON, such as a proxy's HTML maintenance page served with 200, fails with
+// the request, the status, and the start of the body instead of a bare
+// syntax error.
+func decodeJSON(method, reqURL string, resp *http.Response, dest any) error {
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusResetContent {
+		return nil
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response from %s %s: %w", method, reqURL, err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if ct := resp.Header.Get("Content-Type"); !isJSONContentType(ct) {
+		return fmt.Errorf("unexpected response from %s %s: status %d with content type %q, want JSON: %s",
+			method, reqURL, resp.StatusCode, ct, bodyStart(data))
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("decoding response from %s %s (status %d): %w: %s",
+			method, reqURL, resp.StatusCode, err, bodyStart(data))
+	}
+	return nil
+}
+
+// isJSONContentType reports whether the Content-Type header ct names JSON:
+// application/json or a +json type.
+func isJSONContentType(ct string) bool {
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// bodyStart quotes the first maxDecodeErrorBody bytes of a response body.
+func bodyStart(data []byte) string {
+	if len(data) > maxDecodeErrorBody {
+		return strconv.Quote(strings.ToValidUTF8(string(data[:maxDecodeErrorBody]), "")) + "..."
+	}
+	return strconv.Quote(string(data))
+}
+
+const (
+	// maxErrorBody caps the error response body kept by readBody.
+	maxErrorBody = 64 << 10
+
+	// maxErrorBodyInMessage caps the body shown by APIError.Error.
+	maxErrorBodyInMessage = 300
+
+	// bodyTruncatedMarker ends a body that readBody cut at maxErrorBody.
+	bodyTruncatedMarker = "...[truncated]"
+)
+
+// readBody reads and returns the response body as a string.  Bodies longer
+// than maxErrorBody are cut there and end with bodyTruncatedMarker; the rest
+// is never read.
 func readBody(resp *http.Response) string {
 	if resp.Body == nil {
 		return ""
 	}
-	b, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody+1))
 	if err != nil {
 		return "<error reading body>"
 	}
+	if len(b) > maxErrorBody {
+		return strings.ToValidUTF8(string(b[:maxErrorBody]), "") + bodyTruncatedMarker
+	}
 	return string(b)
 }
+
+// elideBody shortens body to maxErrorBodyInMessage bytes for error
+// messages, noting how much was left out.
+func elideBody(body string) string {
+	if len(body) <= maxErrorBodyInMessage {
+		return body
+	}
+	return fmt.Sprintf("%s... (%d more bytes)", strings.ToValidUTF8(body[:maxErrorBodyInMessage], ""), len(body)-maxErrorBodyInMessage)
+}
+
+// AuthenticatedLogin returns the login of the token's owner.  GitHub App
+// installation tokens have no owner and get an *APIError (403).
+func (c *Client) AuthenticatedLogin() (string, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	if _, err := c.doJSON(http.MethodGet, c.baseURL+"/user", nil, &user); err != nil {
+		return "", fmt.Errorf("fetching the authenticated user: %w", err)
+	}
+	return user.Login, nil
+}
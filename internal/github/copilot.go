@@ -1,27 +1,93 @@
 package github
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/progress"
 )
 
 // CopilotUser represents a Copilot seat holder returned by the billing/seats
 // endpoint.
 type CopilotUser struct {
-	Login                   string `json:"login"`
-	ID                      int64  `json:"id"`
-	Name                    string `json:"name"`
-	Email                   string `json:"email"`
-	Type                    string `json:"type"`
-	CreatedAt               string `json:"created_at"`
-	UpdatedAt               string `json:"updated_at"`
-	PendingCancellationDate string `json:"pending_cancellation_date"`
-	LastActivityAt          string `json:"last_activity_at"`
-	LastActivityEditor      string `json:"last_activity_editor"`
-	Plan                    string `json:"plan"`
-	AssigningTeam           any    `json:"assigning_team"` // may be object or null
+	Login                   string         `json:"login"`
+	ID                      int64          `json:"id"`
+	Name                    string         `json:"name"`
+	Email                   string         `json:"email"`
+	Type                    string         `json:"type"`
+	CreatedAt               string         `json:"created_at"`
+	UpdatedAt               string         `json:"updated_at"`
+	PendingCancellationDate string         `json:"pending_cancellation_date"`
+	LastActivityAt          string         `json:"last_activity_at"`
+	LastActivityEditor      string         `json:"last_activity_editor"`
+	Plan                    string         `json:"plan"`
+	AssigningTeam           *AssigningTeam `json:"assigning_team"` // nil when the seat was assigned directly
+	Organization            string         `json:"organization"`   // login of the org granting the seat, if reported
+}
+
+// IsUserAccount reports whether an assignee type denotes a regular user.  An
+// empty type is treated as a user since older API versions omit it.
+func IsUserAccount(accountType string) bool {
+	return accountType == "" || accountType == "User"
+}
+
+// PendingCancellation reports whether the seat is scheduled for cancellation.
+// The date is parsed when possible; a malformed date still counts as pending
+// and returns the zero time.
+func (u CopilotUser) PendingCancellation() (time.Time, bool) {
+	raw := strings.TrimSpace(u.PendingCancellationDate)
+	if raw == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, true
+}
+
+// LastActivity returns the parsed last_activity_at.  It reports false when
+// the user has never been active or the value is malformed.
+func (u CopilotUser) LastActivity() (time.Time, bool) {
+	raw := strings.TrimSpace(u.LastActivityAt)
+	if raw == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// AssigningTeam is the team through which a Copilot seat was granted.
+type AssigningTeam struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Slug    string `json:"slug"`
+	HTMLURL string `json:"html_url"`
+}
+
+// Org returns the login of the organization owning the team, parsed from its
+// html_url (".../orgs/{org}/teams/{slug}"), or "" when it cannot be derived.
+func (t *AssigningTeam) Org() string {
+	_, rest, ok := strings.Cut(t.HTMLURL, "/orgs/")
+	if !ok {
+		return ""
+	}
+	org, _, ok := strings.Cut(rest, "/teams/")
+	if !ok {
+		return ""
+	}
+	return org
 }
 
 // seatsResponse is the JSON envelope returned by the Copilot billing seats API.
@@ -31,14 +97,19 @@ type seatsResponse struct {
 }
 
 type seatEntry struct {
-	Assignee                assignee `json:"assignee"`
-	CreatedAt               string   `json:"created_at"`
-	UpdatedAt               string   `json:"updated_at"`
-	PendingCancellationDate string   `json:"pending_cancellation_date"`
-	LastActivityAt          string   `json:"last_activity_at"`
-	LastActivityEditor      string   `json:"last_activity_editor"`
-	Plan                    string   `json:"plan"`
-	AssigningTeam           any      `json:"assigning_team"`
+	Assignee                assignee       `json:"assignee"`
+	CreatedAt               string         `json:"created_at"`
+	UpdatedAt               string         `json:"updated_at"`
+	PendingCancellationDate string         `json:"pending_cancellation_date"`
+	LastActivityAt          string         `json:"last_activity_at"`
+	LastActivityEditor      string         `json:"last_activity_editor"`
+	Plan                    string         `json:"plan"`
+	AssigningTeam           *AssigningTeam `json:"assigning_team"`
+	Organization            *seatOrg       `json:"organization"`
+}
+
+type seatOrg struct {
+	Login string `json:"login"`
 }
 
 type assignee struct {
@@ -49,62 +120,266 @@ type assignee struct {
 	Type  string `json:"type"`
 }
 
+// seatsPerPage is the page size used for the Copilot billing seats API.
+const seatsPerPage = 100
+
 // GetCopilotUsers returns all Copilot seat holders across the enterprise,
-// handling pagination and deduplicating by login.
+// handling pagination and deduplicating by login.  When the client is
+// configured with copilot_scope "organization", the seats of each configured
+// organization are aggregated instead.
+//
+// The first page reports total_seats, so the remaining pages are fetched
+// concurrently (bounded by the client's seat concurrency) and merged in page
+// order before deduplication.  The counts are kept for SeatFetch, and a
+// warning is logged when the seats fetched do not add up to total_seats.
 func (c *Client) GetCopilotUsers() ([]CopilotUser, error) {
+	if len(c.seatOrgs) > 0 {
+		return c.getOrgsCopilotUsers(c.seatOrgs)
+	}
+
 	c.log.Info("Fetching Copilot users", "enterprise", c.enterprise)
+	allUsers, total, err := c.fetchAllSeats(c.enterpriseURL("/copilot/billing/seats"))
+	if err != nil {
+		return nil, err
+	}
+	c.log.Info("Total Copilot users found", "count", len(allUsers))
 
-	url := c.enterpriseURL("/copilot/billing/seats")
+	// Deduplicate by login.
+	unique := deduplicateUsers(allUsers, c.log)
+	c.recordSeatFetch(len(allUsers), total, len(unique))
+	return unique, nil
+}
+
+// GetOrgCopilotUsers returns the Copilot seat holders of a single
+// organization, deduplicated by login.  It only needs organization-level
+// billing access.
+func (c *Client) GetOrgCopilotUsers(org string) ([]CopilotUser, error) {
+	users, _, err := c.fetchOrgSeats(org)
+	if err != nil {
+		return nil, err
+	}
+	return deduplicateUsers(users, c.log), nil
+}
+
+// fetchOrgSeats lists the Copilot seats of one organization, duplicates
+// included, and returns them with the organization's total_seats.
+func (c *Client) fetchOrgSeats(org string) ([]CopilotUser, int, error) {
+	c.log.Info("Fetching Copilot users", "org", org)
+	users, total, err := c.fetchAllSeats(c.baseURL + escapePath("orgs", org, "copilot", "billing", "seats"))
+	if err != nil {
+		return nil, 0, fmt.Errorf("org %s: %w", org, err)
+	}
+	for i := range users {
+		if users[i].Organization == "" {
+			users[i].Organization = org
+		}
+	}
+	return users, total, nil
+}
+
+// getOrgsCopilotUsers aggregates the seats of several organizations and
+// deduplicates by login, keeping the freshest entry of users holding a seat
+// in more than one.  The total_seats of the organizations are summed.
+func (c *Client) getOrgsCopilotUsers(orgs []string) ([]CopilotUser, error) {
 	var allUsers []CopilotUser
-	page := 1
-	const perPage = 100
+	total := 0
+	for _, org := range orgs {
+		users, orgTotal, err := c.fetchOrgSeats(org)
+		if err != nil {
+			return nil, err
+		}
+		allUsers = append(allUsers, users...)
+		total += orgTotal
+	}
+	c.log.Info("Total Copilot users found", "orgs", len(orgs), "count", len(allUsers))
+	unique := deduplicateUsers(allUsers, c.log)
+	c.recordSeatFetch(len(allUsers), total, len(unique))
+	return unique, nil
+}
 
-	for {
-		pageURL := fmt.Sprintf("%s?page=%d&per_page=%d", url, page, perPage)
-		var resp seatsResponse
-		if _, err := c.doJSON(http.MethodGet, pageURL, nil, &resp); err != nil {
-			return nil, fmt.Errorf("fetching copilot seats page %d: %w", page, err)
+// SeatFetch describes the last Copilot seat listing of GetCopilotUsers.
+type SeatFetch struct {
+	Fetched    int  `json:"fetched"`     // seat entries received, duplicates included
+	TotalSeats int  `json:"total_seats"` // total_seats reported by the API (0 when missing)
+	Unique     int  `json:"unique"`      // users left after deduplication
+	Mismatch   bool `json:"mismatch"`    // Fetched and TotalSeats disagree beyond the duplicates
+}
+
+// SeatFetch returns the counts of the last GetCopilotUsers call, or the zero
+// SeatFetch when it was not called.
+func (c *Client) SeatFetch() SeatFetch {
+	c.seatFetchMu.Lock()
+	defer c.seatFetchMu.Unlock()
+	return c.seatFetch
+}
+
+// recordSeatFetch keeps the counts of a seat listing and warns when the
+// seats fetched differ from total_seats by more than the duplicates removed:
+// a dropped page would otherwise leave a silently partial user list.
+func (c *Client) recordSeatFetch(fetched, total, unique int) {
+	f := SeatFetch{Fetched: fetched, TotalSeats: total, Unique: unique}
+	diff := fetched - total
+	if diff < 0 {
+		diff = -diff
+	}
+	f.Mismatch = total > 0 && diff > fetched-unique
+	if f.Mismatch {
+		c.log.Warn("Copilot seats fetched do not match total_seats; the user list may be incomplete",
+			"fetched", fetched, "total_seats", total, "duplicates", fetched-unique)
+	}
+	c.seatFetchMu.Lock()
+	c.seatFetch = f
+	c.seatFetchMu.Unlock()
+}
+
+// fetchAllSeats lists every page of a Copilot billing seats endpoint and
+// returns the seats with the total_seats of the first page.
+func (c *Client) fetchAllSeats(base string) ([]CopilotUser, int, error) {
+	first, err := c.fetchSeatsPage(base, 1)
+	if err != nil {
+		return nil, 0, err
+	}
+	allUsers := seatsToUsers(first.Seats)
+	if len(first.Seats) < seatsPerPage {
+		return allUsers, first.TotalSeats, nil
+	}
+
+	task := progress.Start("Fetching Copilot seats", first.TotalSeats)
+	defer task.Done()
+	task.Add(len(first.Seats))
+
+	if first.TotalSeats <= 0 {
+		// total_seats missing: page serially until a short page.
+		more, err := c.fetchSeatPagesSerially(base, 2, task)
+		if err != nil {
+			return nil, 0, err
 		}
+		return append(allUsers, more...), first.TotalSeats, nil
+	}
 
-		if len(resp.Seats) == 0 {
-			break
+	totalPages := (first.TotalSeats + seatsPerPage - 1) / seatsPerPage
+	pages, err := c.fetchSeatPagesConcurrently(base, 2, totalPages, task)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, seats := range pages {
+		allUsers = append(allUsers, seatsToUsers(seats)...)
+	}
+	if len(allUsers) < first.TotalSeats {
+		c.log.Warn("Copilot seats fetched fewer than total_seats; paging serially",
+			"fetched", len(allUsers), "total_seats", first.TotalSeats)
+		more, err := c.fetchSeatPagesSerially(base, totalPages+1, task)
+		if err != nil {
+			return nil, 0, err
 		}
+		allUsers = append(allUsers, more...)
+	}
+	return allUsers, first.TotalSeats, nil
+}
 
-		for _, s := range resp.Seats {
-			allUsers = append(allUsers, CopilotUser{
-				Login:                   s.Assignee.Login,
-				ID:                      s.Assignee.ID,
-				Name:                    s.Assignee.Name,
-				Email:                   s.Assignee.Email,
-				Type:                    s.Assignee.Type,
-				CreatedAt:               s.CreatedAt,
-				UpdatedAt:               s.UpdatedAt,
-				PendingCancellationDate: s.PendingCancellationDate,
-				LastActivityAt:          s.LastActivityAt,
-				LastActivityEditor:      s.LastActivityEditor,
-				Plan:                    s.Plan,
-				AssigningTeam:           s.AssigningTeam,
-			})
-		}
-
-		c.log.Debug("Fetched copilot seats page", "page", page, "count", len(resp.Seats))
-		if len(resp.Seats) < perPage {
-			break
+// fetchSeatsPage fetches a single page of the Copilot billing seats API.
+func (c *Client) fetchSeatsPage(base string, page int) (*seatsResponse, error) {
+	var resp seatsResponse
+	if _, err := c.doJSON(http.MethodGet, pagedURL(base, page, seatsPerPage, nil), nil, &resp); err != nil {
+		return nil, fmt.Errorf("fetching copilot seats page %d: %w", page, err)
+	}
+	c.log.Debug("Fetched copilot seats page", "page", page, "count", len(resp.Seats))
+	return &resp, nil
+}
+
+// maxSerialSeatPages caps the serial fallback so a misbehaving server that
+// keeps returning full pages cannot loop forever.
+const maxSerialSeatPages = 1000
+
+// fetchSeatPagesSerially fetches pages starting at from until a short page,
+// giving up after maxSerialSeatPages.  The seats fetched are added to task.
+func (c *Client) fetchSeatPagesSerially(base string, from int, task *progress.Task) ([]CopilotUser, error) {
+	var users []CopilotUser
+	for page := from; page < from+maxSerialSeatPages; page++ {
+		resp, err := c.fetchSeatsPage(base, page)
+		if err != nil {
+			return nil, err
+		}
+		task.Add(len(resp.Seats))
+		users = append(users, seatsToUsers(resp.Seats)...)
+		if len(resp.Seats) < seatsPerPage {
+			return users, nil
 		}
-		page++
 	}
+	return nil, fmt.Errorf("fetching copilot seats: exceeded %d pages without reaching the end", maxSerialSeatPages)
+}
 
-	c.log.Info("Total Copilot users found", "count", len(allUsers))
+// fetchSeatPagesConcurrently fetches pages from..to (inclusive) with at most
+// c.seatConcurrency requests in flight, stopping at the first error.  The
+// returned slice is indexed by page-from so callers can merge results in page
+// order.  The seats fetched are added to task.
+func (c *Client) fetchSeatPagesConcurrently(base string, from, to int, task *progress.Task) ([][]seatEntry, error) {
+	if to < from {
+		return nil, nil
+	}
+	workers := c.seatConcurrency
+	if workers < 1 {
+		workers = config.DefaultSeatFetchConcurrency
+	}
 
-	// Deduplicate by login.
-	unique := deduplicateUsers(allUsers, c.log)
-	return unique, nil
+	results := make([][]seatEntry, to-from+1)
+	g, ctx := errgroup.WithContext(context.Background())
+	g.SetLimit(workers)
+	for page := from; page <= to; page++ {
+		if ctx.Err() != nil {
+			break
+		}
+		g.Go(func() error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			resp, err := c.fetchSeatsPage(base, page)
+			if err != nil {
+				return err
+			}
+			results[page-from] = resp.Seats
+			task.Add(len(resp.Seats))
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// seatsToUsers flattens seat entries into CopilotUser values.
+func seatsToUsers(seats []seatEntry) []CopilotUser {
+	users := make([]CopilotUser, 0, len(seats))
+	for _, s := range seats {
+		var org string
+		if s.Organization != nil {
+			org = s.Organization.Login
+		}
+		users = append(users, CopilotUser{
+			Login:                   s.Assignee.Login,
+			ID:                      s.Assignee.ID,
+			Name:                    s.Assignee.Name,
+			Email:                   s.Assignee.Email,
+			Type:                    s.Assignee.Type,
+			CreatedAt:               s.CreatedAt,
+			UpdatedAt:               s.UpdatedAt,
+			PendingCancellationDate: s.PendingCancellationDate,
+			LastActivityAt:          s.LastActivityAt,
+			LastActivityEditor:      s.LastActivityEditor,
+			Plan:                    s.Plan,
+			AssigningTeam:           s.AssigningTeam,
+			Organization:            org,
+		})
+	}
+	return users
 }
 
-// deduplicateUsers removes duplicate entries, keeping the first occurrence of
-// each login.
+// deduplicateUsers removes duplicate entries of a login, such as an org and
+// an enterprise grant of the same seat, keeping the freshest one (see
+// fresherSeat) at the position of the first.
 func deduplicateUsers(users []CopilotUser, logger *slog.Logger) []CopilotUser {
-	seen := make(map[string]bool, len(users))
+	index := make(map[string]int, len(users))
 	dupCounts := make(map[string]int)
 	unique := make([]CopilotUser, 0, len(users))
 
@@ -112,11 +387,14 @@ func deduplicateUsers(users []CopilotUser, logger *slog.Logger) []CopilotUser {
 		if u.Login == "" {
 			continue
 		}
-		if seen[u.Login] {
+		if i, ok := index[u.Login]; ok {
 			dupCounts[u.Login]++
+			if fresherSeat(u, unique[i]) {
+				unique[i] = u
+			}
 			continue
 		}
-		seen[u.Login] = true
+		index[u.Login] = len(unique)
 		unique = append(unique, u)
 	}
 
@@ -133,23 +411,57 @@ func deduplicateUsers(users []CopilotUser, logger *slog.Logger) []CopilotUser {
 	return unique
 }
 
+// fresherSeat reports whether the seat entry later in the list should
+// replace the earlier one: it has the later updated_at, else the later
+// created_at, and on a tie or unparseable dates it wins by position.
+func fresherSeat(later, earlier CopilotUser) bool {
+	for _, pair := range [][2]string{{later.UpdatedAt, earlier.UpdatedAt}, {later.CreatedAt, earlier.CreatedAt}} {
+		tl, okL := parseSeatTime(pair[0])
+		te, okE := parseSeatTime(pair[1])
+		if okL && okE && !tl.Equal(te) {
+			return tl.After(te)
+		}
+	}
+	return true
+}
+
+// createdAtLayouts are the created_at and updated_at formats seen across API versions and
+// GHES: an RFC 3339 offset or Z, fractional seconds, or no zone (UTC).
+var createdAtLayouts = []string{time.RFC3339, time.RFC3339Nano, "2006-01-02T15:04:05"}
+
+// Created returns the parsed created_at.  It reports false when the value is
+// empty or in none of the createdAtLayouts.
+func (u CopilotUser) Created() (time.Time, bool) {
+	return parseSeatTime(u.CreatedAt)
+}
+
+// parseSeatTime parses a seat timestamp in one of the createdAtLayouts.
+func parseSeatTime(raw string) (time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range createdAtLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
 // FilterUsersByTimestamp returns only users whose created_at is strictly after
 // the given threshold.  This is used for incremental processing — only new
-// users since the last run are returned.
+// users since the last run are returned.  Users whose created_at is empty or
+// cannot be parsed are kept, with a warning, so nobody is silently excluded.
 func FilterUsersByTimestamp(users []CopilotUser, after time.Time) []CopilotUser {
 	var filtered []CopilotUser
 	for _, u := range users {
-		if u.CreatedAt == "" {
+		t, ok := u.Created()
+		if !ok {
+			slog.Warn("Seat created_at is missing or unparseable; including the user", "user", u.Login, "created_at", u.CreatedAt)
+			filtered = append(filtered, u)
 			continue
 		}
-		t, err := time.Parse(time.RFC3339, u.CreatedAt)
-		if err != nil {
-			// Try alternative format without timezone (some API responses).
-			t, err = time.Parse("2006-01-02T15:04:05Z", u.CreatedAt)
-			if err != nil {
-				continue
-			}
-		}
 		if t.After(after) {
 			filtered = append(filtered, u)
 		}
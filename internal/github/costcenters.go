@@ -1,11 +1,17 @@
 package github
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
+	"sync"
+
+	"github.com/renan-alm/gh-cost-center/internal/cache"
+	"github.com/renan-alm/gh-cost-center/internal/progress"
 )
 
 // costCentersListResponse is the JSON envelope for the list endpoint.
@@ -26,14 +32,27 @@ type costCenterCreateResponse struct {
 	Name string `json:"name"`
 }
 
-// costCenterDetailResponse is the JSON envelope for the detail endpoint.
-type costCenterDetailResponse struct {
+// CostCenterDetail is a single cost center together with the resources
+// (users, repositories, organizations) attached to it.
+type CostCenterDetail struct {
 	ID        string     `json:"id"`
 	Name      string     `json:"name"`
 	State     string     `json:"state"`
 	Resources []Resource `json:"resources"`
 }
 
+// ResourceNames returns the names of all attached resources of the given type
+// ("User", "Repository", "Org", …).
+func (d *CostCenterDetail) ResourceNames(resourceType string) []string {
+	var names []string
+	for _, r := range d.Resources {
+		if r.Type == resourceType && r.Name != "" {
+			names = append(names, r.Name)
+		}
+	}
+	return names
+}
+
 // Resource represents a user or repository assigned to a cost center.
 type Resource struct {
 	Type string `json:"type"` // "User", "Repository", etc.
@@ -61,11 +80,76 @@ var uuidFromConflictRe = regexp.MustCompile(
 	`(?i)existing cost center UUID:\s*([a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12})`,
 )
 
+// CostCenterConflictError is returned by CreateCostCenter when the API reports
+// that the cost center already exists (409) but the existing ID could not be
+// determined from the response or by looking the name up.
+type CostCenterConflictError struct {
+	Name string
+	Body string // raw 409 response body
+	Err  error  // underlying API error
+}
+
+func (e *CostCenterConflictError) Error() string {
+	return fmt.Sprintf("cost center %q already exists but its ID could not be determined: %s", e.Name, elideBody(e.Body))
+}
+
+func (e *CostCenterConflictError) Unwrap() error {
+	return e.Err
+}
+
+// costCenterConflictEnvelope is the JSON shape of a 409 response from the
+// create endpoint.
+type costCenterConflictEnvelope struct {
+	Message              string `json:"message"`
+	ExistingCostCenterID string `json:"existing_cost_center_id"`
+	Errors               []struct {
+		ExistingCostCenterID string `json:"existing_cost_center_id"`
+		CostCenterID         string `json:"cost_center_id"`
+	} `json:"errors"`
+}
+
+// existingIDFromConflict extracts the existing cost center ID from a 409
+// response body.  It tries the JSON envelope first and falls back to the
+// legacy plain-text "Existing cost center UUID: …" message.
+func existingIDFromConflict(body string) string {
+	var env costCenterConflictEnvelope
+	if err := json.Unmarshal([]byte(body), &env); err == nil {
+		candidates := []string{env.ExistingCostCenterID}
+		for _, e := range env.Errors {
+			candidates = append(candidates, e.ExistingCostCenterID, e.CostCenterID)
+		}
+		for _, id := range candidates {
+			if IsValidCostCenterUUID(strings.ToLower(id)) {
+				return strings.ToLower(id)
+			}
+		}
+	}
+	if m := uuidFromConflictRe.FindStringSubmatch(body); len(m) == 2 {
+		return m[1]
+	}
+	return ""
+}
+
 // uuidRe matches a standard UUID format.
 var uuidRe = regexp.MustCompile(
 	`^[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}$`,
 )
 
+// CostCenterNotFoundError is returned by GetCostCenter when no cost center
+// exists with the requested ID.
+type CostCenterNotFoundError struct {
+	ID  string
+	Err error // underlying 404 API error
+}
+
+func (e *CostCenterNotFoundError) Error() string {
+	return fmt.Sprintf("no such cost center: %s", e.ID)
+}
+
+func (e *CostCenterNotFoundError) Unwrap() error {
+	return e.Err
+}
+
 // IsCostCenterNotFound returns true if the error is a 404 API error
 // for a cost center lookup.
 func IsCostCenterNotFound(err error) bool {
@@ -73,6 +157,13 @@ func IsCostCenterNotFound(err error) bool {
 	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
 }
 
+// IsCostCenterConflict returns true if the error is a 409 API error, which the
+// cost center endpoints use when a name is already taken.
+func IsCostCenterConflict(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict
+}
+
 // IsValidCostCenterUUID returns true if the string is a valid UUID format.
 func IsValidCostCenterUUID(id string) bool {
 	return uuidRe.MatchString(id)
@@ -102,12 +193,21 @@ func ValidateCostCenterID(id string) error {
 }
 
 // GetAllActiveCostCenters returns a map of cost center name → ID for all
-// active cost centers in the enterprise.
+// active cost centers in the enterprise.  With a cache attached, a cached
+// list that has not expired is returned without an API call.
 func (c *Client) GetAllActiveCostCenters() (map[string]string, error) {
-	url := c.enterpriseURL("/settings/billing/cost-centers")
+	if c.ccCache != nil {
+		var cached map[string]string
+		if c.ccCache.GetJSON(cache.KindActiveCostCenters, c.enterprise, &cached) {
+			c.log.Debug("Active cost centers loaded from cache", "active", len(cached))
+			return cached, nil
+		}
+	}
+
+	reqURL := c.enterpriseURL("/settings/billing/cost-centers")
 
 	var resp costCentersListResponse
-	if _, err := c.doJSON(http.MethodGet, url, nil, &resp); err != nil {
+	if _, err := c.doJSON(http.MethodGet, reqURL, nil, &resp); err != nil {
 		return nil, fmt.Errorf("fetching cost centers: %w", err)
 	}
 
@@ -121,44 +221,129 @@ func (c *Client) GetAllActiveCostCenters() (map[string]string, error) {
 			}
 		}
 	}
+	if c.ccCache != nil {
+		_ = c.ccCache.SetJSON(cache.KindActiveCostCenters, c.enterprise, active)
+	}
 	c.log.Debug("Found active cost centers", "active", len(active), "total", len(resp.CostCenters))
 	return active, nil
 }
 
 // GetCostCenter returns the details of a single cost center including its
-// assigned resources.
-func (c *Client) GetCostCenter(id string) (*costCenterDetailResponse, error) {
+// attached resources.  The detail endpoint is followed through its Link
+// "next" relation when the API paginates the resource list.  A 404 is
+// returned as *CostCenterNotFoundError.
+func (c *Client) GetCostCenter(id string) (*CostCenterDetail, error) {
 	if err := ValidateCostCenterID(id); err != nil {
 		return nil, err
 	}
-	url := c.enterpriseURL(fmt.Sprintf("/settings/billing/cost-centers/%s", id))
-	var resp costCenterDetailResponse
-	if _, err := c.doJSON(http.MethodGet, url, nil, &resp); err != nil {
-		return nil, fmt.Errorf("fetching cost center %s: %w", id, err)
+
+	pageURL := c.enterpriseURL("/settings/billing/cost-centers" + escapePath(id))
+	var detail *CostCenterDetail
+	for page := 1; pageURL != ""; page++ {
+		var pageDetail CostCenterDetail
+		resp, err := c.doJSON(http.MethodGet, pageURL, nil, &pageDetail)
+		if err != nil {
+			if IsCostCenterNotFound(err) {
+				return nil, &CostCenterNotFoundError{ID: id, Err: err}
+			}
+			return nil, fmt.Errorf("fetching cost center %s page %d: %w", id, page, err)
+		}
+		if detail == nil {
+			detail = &pageDetail
+		} else {
+			detail.Resources = append(detail.Resources, pageDetail.Resources...)
+		}
+		pageURL, err = c.nextPageURL(resp)
+		if err != nil {
+			return nil, fmt.Errorf("fetching cost center %s page %d: %w", id, page+1, err)
+		}
 	}
-	return &resp, nil
+	return detail, nil
 }
 
-// GetCostCenterMembers returns the usernames of all users assigned to the
-// given cost center.
-func (c *Client) GetCostCenterMembers(id string) ([]string, error) {
+// GetCostCenterUsers returns the usernames of all users currently assigned to
+// the given cost center.
+func (c *Client) GetCostCenterUsers(id string) ([]string, error) {
 	detail, err := c.GetCostCenter(id)
 	if err != nil {
 		return nil, err
 	}
-	var users []string
-	for _, r := range detail.Resources {
-		if r.Type == "User" && r.Name != "" {
-			users = append(users, r.Name)
-		}
-	}
+	users := detail.ResourceNames("User")
 	c.log.Debug("Cost center members", "cost_center_id", id, "count", len(users))
 	return users, nil
 }
 
+// GetCostCentersUsers fetches the current user membership of every given cost
+// center, running at most concurrency requests at a time.  Returns a map of
+// cost center ID → usernames.  The first error encountered is returned after
+// all in-flight requests complete.
+func (c *Client) GetCostCentersUsers(ids []string, concurrency int) (map[string][]string, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		sem      = make(chan struct{}, concurrency)
+		members  = make(map[string][]string, len(ids))
+	)
+
+	for _, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			users, err := c.GetCostCenterUsers(id)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			members[id] = users
+		}(id)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return members, nil
+}
+
+// GetAllActiveCostCentersWithMembers returns the same name → ID map as
+// GetAllActiveCostCenters and additionally hydrates the user membership of
+// every active cost center (keyed by ID), using at most concurrency
+// simultaneous requests.
+func (c *Client) GetAllActiveCostCentersWithMembers(concurrency int) (map[string]string, map[string][]string, error) {
+	active, err := c.GetAllActiveCostCenters()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]string, 0, len(active))
+	for _, id := range active {
+		ids = append(ids, id)
+	}
+
+	members, err := c.GetCostCentersUsers(ids, concurrency)
+	if err != nil {
+		return nil, nil, fmt.Errorf("hydrating cost center membership: %w", err)
+	}
+	return active, members, nil
+}
+
 // CreateCostCenter creates a new cost center with the given name.  If the cost
 // center already exists (409 Conflict) it attempts to extract the existing UUID
-// from the error message.  If that fails it falls back to searching by name.
+// from the error response.  If that fails it falls back to searching by name,
+// and returns a *CostCenterConflictError when the name search finds nothing.
 func (c *Client) CreateCostCenter(name string) (string, error) {
 	// Check cache first.
 	if c.ccCache != nil {
@@ -168,18 +353,29 @@ func (c *Client) CreateCostCenter(name string) (string, error) {
 		}
 	}
 
-	url := c.enterpriseURL("/settings/billing/cost-centers")
+	id, _, err := c.EnsureCostCenter(name)
+	return id, err
+}
+
+// EnsureCostCenter is CreateCostCenter without the name cache lookup: it
+// always asks the API, and created reports whether the cost center is new
+// (false when the API answered 409 Conflict and the existing ID was
+// resolved).
+func (c *Client) EnsureCostCenter(name string) (id string, created bool, err error) {
+	reqURL := c.enterpriseURL("/settings/billing/cost-centers")
 	body := map[string]string{"name": name}
 
 	var resp costCenterCreateResponse
-	_, err := c.doJSON(http.MethodPost, url, body, &resp)
+	_, err = c.doJSON(http.MethodPost, reqURL, body, &resp)
+	if err == nil {
+		c.mutated(Mutation{Operation: "create_cost_center", Target: name, Detail: "id=" + resp.ID})
+	} else if !IsCostCenterConflict(err) {
+		c.mutated(Mutation{Operation: "create_cost_center", Target: name, Err: err})
+	}
 	if err == nil {
 		c.log.Info("Created cost center", "name", name, "id", resp.ID)
-		// Update cache with newly created cost center.
-		if c.ccCache != nil {
-			_ = c.ccCache.Set(name, resp.ID, name)
-		}
-		return resp.ID, nil
+		c.rememberCostCenter(name, resp.ID)
+		return resp.ID, true, nil
 	}
 
 	// Handle 409 Conflict — cost center already exists.
@@ -187,20 +383,92 @@ func (c *Client) CreateCostCenter(name string) (string, error) {
 	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
 		c.log.Info("Cost center already exists, extracting existing ID", "name", name)
 
-		if m := uuidFromConflictRe.FindStringSubmatch(apiErr.Body); len(m) == 2 {
-			c.log.Info("Extracted existing cost center ID from API response", "id", m[1])
-			// Update cache with extracted ID.
-			if c.ccCache != nil {
-				_ = c.ccCache.Set(name, m[1], name)
-			}
-			return m[1], nil
+		if id := existingIDFromConflict(apiErr.Body); id != "" {
+			c.log.Info("Extracted existing cost center ID from API response", "id", id)
+			c.rememberCostCenter(name, id)
+			return id, false, nil
 		}
 
 		c.log.Warn("Could not extract UUID from 409 response, falling back to name search", "name", name)
-		return c.findCostCenterByName(name)
+		id, found, lookupErr := c.findCostCenterByName(name)
+		if lookupErr != nil {
+			return "", false, fmt.Errorf("creating cost center %q: resolving existing ID after conflict: %w", name, lookupErr)
+		}
+		if !found {
+			return "", false, &CostCenterConflictError{Name: name, Body: apiErr.Body, Err: err}
+		}
+		c.rememberCostCenter(name, id)
+		return id, false, nil
+	}
+
+	return "", false, fmt.Errorf("creating cost center %q: %w", name, err)
+}
+
+// rememberCostCenter records name -> id in the name cache and drops the
+// cached active cost center list, which no longer reflects the enterprise.
+func (c *Client) rememberCostCenter(name, id string) {
+	if c.ccCache == nil {
+		return
+	}
+	_ = c.ccCache.Set(name, id, name)
+	_ = c.ccCache.Invalidate(cache.KindActiveCostCenters)
+}
+
+// UpdateCostCenter renames the cost center with the given ID.  A 409 Conflict
+// means another cost center already uses newName; callers can detect it with
+// IsCostCenterConflict.
+func (c *Client) UpdateCostCenter(id, newName string) error {
+	if err := ValidateCostCenterID(id); err != nil {
+		return err
+	}
+
+	reqURL := c.enterpriseURL("/settings/billing/cost-centers" + escapePath(id))
+	body := map[string]string{"name": newName}
+	_, err := c.doJSON(http.MethodPatch, reqURL, body, nil)
+	c.mutated(Mutation{Operation: "rename_cost_center", Target: id, Detail: "name=" + newName, Err: err})
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+			return fmt.Errorf("renaming cost center %s to %q: %w", id, newName, &APIMessageError{Err: apiErr})
+		}
+		return fmt.Errorf("renaming cost center %s to %q: %w", id, newName, err)
 	}
 
-	return "", fmt.Errorf("creating cost center %q: %w", name, err)
+	// Drop any entry cached under the old name before recording the new one.
+	if c.ccCache != nil {
+		_ = c.ccCache.DeleteByID(id)
+		_ = c.ccCache.Set(newName, id, newName)
+		_ = c.ccCache.Invalidate(cache.KindActiveCostCenters)
+	}
+	c.log.Info("Renamed cost center", "id", id, "name", newName)
+	return nil
+}
+
+// DeleteCostCenter deletes the cost center with the given ID.  Conflict and
+// validation errors (409/422) are returned with the API's parsed message.
+func (c *Client) DeleteCostCenter(id string) error {
+	if err := ValidateCostCenterID(id); err != nil {
+		return err
+	}
+
+	reqURL := c.enterpriseURL("/settings/billing/cost-centers" + escapePath(id))
+	_, err := c.doJSON(http.MethodDelete, reqURL, nil, nil)
+	c.mutated(Mutation{Operation: "delete_cost_center", Target: id, Err: err})
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) &&
+			(apiErr.StatusCode == http.StatusConflict || apiErr.StatusCode == http.StatusUnprocessableEntity) {
+			return fmt.Errorf("deleting cost center %s: %w", id, &APIMessageError{Err: apiErr})
+		}
+		return fmt.Errorf("deleting cost center %s: %w", id, err)
+	}
+
+	if c.ccCache != nil {
+		_ = c.ccCache.DeleteByID(id)
+		_ = c.ccCache.Invalidate(cache.KindActiveCostCenters)
+	}
+	c.log.Info("Deleted cost center", "id", id)
+	return nil
 }
 
 // CreateCostCenterWithPreload creates a cost center with preload optimization.
@@ -230,17 +498,18 @@ func (c *Client) CreateCostCenterWithPreload(name string, activeMap map[string]s
 }
 
 // findCostCenterByName searches the list of all cost centers for an active one
-// with the exact name.
-func (c *Client) findCostCenterByName(name string) (string, error) {
+// with the given name.  found is false when the list was fetched but has no
+// such cost center; err is only set when the list itself could not be fetched.
+func (c *Client) findCostCenterByName(name string) (id string, found bool, err error) {
 	active, err := c.GetAllActiveCostCenters()
 	if err != nil {
-		return "", fmt.Errorf("finding cost center by name %q: %w", name, err)
+		return "", false, fmt.Errorf("finding cost center by name %q: %w", name, err)
 	}
 	if id, ok := active[name]; ok {
 		c.log.Info("Found active cost center by name", "name", name, "id", id)
-		return id, nil
+		return id, true, nil
 	}
-	return "", fmt.Errorf("no active cost center found with name %q", name)
+	return "", false, nil
 }
 
 // EnsureCostCentersExist creates (or retrieves) the two PRU-tier cost centers,
@@ -305,6 +574,12 @@ func (c *Client) ResolveCostCenters(noPRUName, pruAllowedName string) (noPRUID,
 //
 // Returns a map of username → success status.
 func (c *Client) AddUsersToCostCenter(costCenterID string, usernames []string, ignoreCurrentCC bool) (map[string]bool, error) {
+	return c.addUsersToCostCenter(costCenterID, usernames, ignoreCurrentCC, nil)
+}
+
+// addUsersToCostCenter implements AddUsersToCostCenter, adding each user to
+// task once it is handled.  Nothing is added when an error is returned.
+func (c *Client) addUsersToCostCenter(costCenterID string, usernames []string, ignoreCurrentCC bool, task *progress.Task) (map[string]bool, error) {
 	if len(usernames) == 0 {
 		return map[string]bool{}, nil
 	}
@@ -316,7 +591,7 @@ func (c *Client) AddUsersToCostCenter(costCenterID string, usernames []string, i
 	results := make(map[string]bool, len(usernames))
 
 	// Check which users are already in the target cost center.
-	currentMembers, err := c.GetCostCenterMembers(costCenterID)
+	currentMembers, err := c.GetCostCenterUsers(costCenterID)
 	if err != nil {
 		if IsCostCenterNotFound(err) {
 			return nil, fmt.Errorf(
@@ -347,6 +622,9 @@ func (c *Client) AddUsersToCostCenter(costCenterID string, usernames []string, i
 		toAdd = append(toAdd, u)
 	}
 
+	c.record(costCenterID, results, nil)
+	task.Add(len(usernames) - len(toAdd))
+
 	if len(toAdd) == 0 {
 		c.log.Info("All users already assigned", "cost_center_id", costCenterID)
 		return results, nil
@@ -361,48 +639,78 @@ func (c *Client) AddUsersToCostCenter(costCenterID string, usernames []string, i
 	// Chunk into batches of 50.
 	const batchSize = 50
 	for i := 0; i < len(toAdd); i += batchSize {
+		if c.interrupted() {
+			return results, ErrInterrupted
+		}
 		end := i + batchSize
 		if end > len(toAdd) {
 			end = len(toAdd)
 		}
 		batch := toAdd[i:end]
 
-		url := c.enterpriseURL(fmt.Sprintf("/settings/billing/cost-centers/%s/resource", costCenterID))
+		reqURL := c.enterpriseURL("/settings/billing/cost-centers" + escapePath(costCenterID, "resource"))
 		body := map[string]any{"users": batch}
 
-		_, err := c.doJSON(http.MethodPost, url, body, nil)
+		_, err := c.doJSON(http.MethodPost, reqURL, body, nil)
+		c.mutated(Mutation{Operation: "add_users", Target: costCenterID, Items: batch, Err: err})
+		batchResults := make(map[string]bool, len(batch))
+		for _, u := range batch {
+			batchResults[u] = err == nil
+			results[u] = err == nil
+		}
 		if err != nil {
 			c.log.Error("Failed to add users batch", "cost_center_id", costCenterID, "batch_size", len(batch), "error", err)
-			for _, u := range batch {
-				results[u] = false
-			}
-			continue
-		}
-		c.log.Info("Successfully added users batch", "cost_center_id", costCenterID, "batch_size", len(batch))
-		for _, u := range batch {
-			results[u] = true
+		} else {
+			c.log.Info("Successfully added users batch", "cost_center_id", costCenterID, "batch_size", len(batch))
 		}
+		c.record(costCenterID, batchResults, err)
+		task.Add(len(batch))
 	}
 
 	return results, nil
 }
 
+// record passes results to the assignment recorder, if one is set.
+func (c *Client) record(costCenterID string, results map[string]bool, err error) {
+	if c.recordAssignments != nil && len(results) > 0 {
+		c.recordAssignments(costCenterID, results, err)
+	}
+}
+
 // BulkUpdateCostCenterAssignments processes multiple cost center → usernames
-// mappings, chunking and deduplicating as needed.
+// mappings, chunking and deduplicating as needed.  When the client's context
+// is done it stops between batches and returns the results of the batches
+// sent so far together with ErrInterrupted.
 func (c *Client) BulkUpdateCostCenterAssignments(assignments map[string][]string, ignoreCurrentCC bool) (map[string]map[string]bool, error) {
 	results := make(map[string]map[string]bool)
 	totalUsers := 0
 	successUsers := 0
 	failedUsers := 0
 
+	for _, usernames := range assignments {
+		totalUsers += len(usernames)
+	}
+	task := progress.Start("Applying cost center assignments", totalUsers)
+	defer task.Done()
+
+	var interrupted error
 	for ccID, usernames := range assignments {
 		if len(usernames) == 0 {
 			continue
 		}
-		totalUsers += len(usernames)
+		if c.interrupted() {
+			interrupted = ErrInterrupted
+			break
+		}
 
-		ccResults, err := c.AddUsersToCostCenter(ccID, usernames, ignoreCurrentCC)
+		ccResults, err := c.addUsersToCostCenter(ccID, usernames, ignoreCurrentCC, task)
+		if errors.Is(err, ErrInterrupted) {
+			// Keep the batches that were sent, then stop.
+			interrupted = err
+			err = nil
+		}
 		if err != nil {
+			task.Add(len(usernames))
 			if IsCostCenterNotFound(err) {
 				c.log.Error("Cost center not found — this usually means a cost center name was used instead of a UUID",
 					"cost_center_id", ccID,
@@ -425,12 +733,19 @@ func (c *Client) BulkUpdateCostCenterAssignments(assignments map[string][]string
 				failedUsers++
 			}
 		}
+		if interrupted != nil {
+			break
+		}
 	}
 
 	c.log.Info("Assignment results", "successful", successUsers, "total", totalUsers)
 	if failedUsers > 0 {
 		c.log.Error("Some users failed assignment", "failed", failedUsers)
 	}
+	if interrupted != nil {
+		c.log.Warn("Assignment interrupted", "attempted", successUsers+failedUsers, "total", totalUsers)
+		return results, interrupted
+	}
 	return results, nil
 }
 
@@ -444,10 +759,11 @@ func (c *Client) RemoveUsersFromCostCenter(costCenterID string, usernames []stri
 		return nil, err
 	}
 
-	url := c.enterpriseURL(fmt.Sprintf("/settings/billing/cost-centers/%s/resource", costCenterID))
+	reqURL := c.enterpriseURL("/settings/billing/cost-centers" + escapePath(costCenterID, "resource"))
 	body := map[string]any{"users": usernames}
 
-	_, err := c.doJSON(http.MethodDelete, url, body, nil)
+	_, err := c.doJSON(http.MethodDelete, reqURL, body, nil)
+	c.mutated(Mutation{Operation: "remove_users", Target: costCenterID, Items: usernames, Err: err})
 	if err != nil {
 		c.log.Error("Failed to remove users from cost center",
 			"cost_center_id", costCenterID, "error", err)
@@ -470,12 +786,11 @@ func (c *Client) RemoveUsersFromCostCenter(costCenterID string, usernames []stri
 // CheckUserCostCenterMembership checks whether a user belongs to any cost
 // center.  Returns the cost center reference if found, nil otherwise.
 func (c *Client) CheckUserCostCenterMembership(username string) (*CostCenterRef, error) {
-	url := c.enterpriseURL(fmt.Sprintf(
-		"/settings/billing/cost-centers/memberships?resource_type=user&name=%s", username,
-	))
+	query := url.Values{"resource_type": {"user"}, "name": {username}}
+	reqURL := c.enterpriseURL("/settings/billing/cost-centers/memberships?" + query.Encode())
 
 	var resp membershipResponse
-	if _, err := c.doJSON(http.MethodGet, url, nil, &resp); err != nil {
+	if _, err := c.doJSON(http.MethodGet, reqURL, nil, &resp); err != nil {
 		c.log.Debug("Failed to check cost center membership", "user", username, "error", err)
 		return nil, nil // treat lookup failures as "not in any cost center"
 	}
@@ -489,50 +804,85 @@ func (c *Client) CheckUserCostCenterMembership(username string) (*CostCenterRef,
 	return nil, nil
 }
 
-// AddRepositoriesToCostCenter adds repository full-names (org/repo) to a cost
-// center.
-func (c *Client) AddRepositoriesToCostCenter(costCenterID string, repoNames []string) error {
-	if len(repoNames) == 0 {
-		return nil
-	}
-
-	c.log.Info("Adding repositories to cost center",
-		"cost_center_id", costCenterID, "count", len(repoNames))
+// AddReposToCostCenter adds repositories (org/repo full names) to a cost
+// center in batches of 50.  It returns a map of repository → success status;
+// the error reports any failed batch.
+func (c *Client) AddReposToCostCenter(costCenterID string, repoFullNames []string) (map[string]bool, error) {
+	return c.updateCostCenterResources(http.MethodPost, costCenterID, "repositories", repoFullNames)
+}
 
-	url := c.enterpriseURL(fmt.Sprintf("/settings/billing/cost-centers/%s/resource", costCenterID))
-	body := map[string]any{"repositories": repoNames}
+// RemoveReposFromCostCenter removes repositories (org/repo full names) from a
+// cost center in batches of 50.  It returns a map of repository → success
+// status; the error reports any failed batch.
+func (c *Client) RemoveReposFromCostCenter(costCenterID string, repoFullNames []string) (map[string]bool, error) {
+	return c.updateCostCenterResources(http.MethodDelete, costCenterID, "repositories", repoFullNames)
+}
 
-	_, err := c.doJSON(http.MethodPost, url, body, nil)
-	if err != nil {
-		return fmt.Errorf("adding repositories to cost center %s: %w", costCenterID, err)
-	}
+// AddOrgsToCostCenter adds whole organizations to a cost center in batches of
+// 50.  It returns a map of organization → success status; the error reports
+// any failed batch.
+func (c *Client) AddOrgsToCostCenter(costCenterID string, orgs []string) (map[string]bool, error) {
+	return c.updateCostCenterResources(http.MethodPost, costCenterID, "organizations", orgs)
+}
 
-	c.log.Info("Successfully added repositories to cost center",
-		"cost_center_id", costCenterID, "count", len(repoNames))
-	return nil
+// RemoveOrgsFromCostCenter removes organizations from a cost center in
+// batches of 50.  It returns a map of organization → success status; the
+// error reports any failed batch.
+func (c *Client) RemoveOrgsFromCostCenter(costCenterID string, orgs []string) (map[string]bool, error) {
+	return c.updateCostCenterResources(http.MethodDelete, costCenterID, "organizations", orgs)
 }
 
-// RemoveRepositoriesFromCostCenter removes repository full-names (org/repo)
-// from a cost center.
-func (c *Client) RemoveRepositoriesFromCostCenter(costCenterID string, repoNames []string) error {
-	if len(repoNames) == 0 {
-		return nil
+// resourceBatchSize is the most resources the cost center resource endpoint
+// accepts per request.
+const resourceBatchSize = 50
+
+// updateCostCenterResources POSTs (adds) or DELETEs (removes) names of the
+// given resource kind ("repositories" or "organizations") on a cost center,
+// resourceBatchSize at a time.  A failed batch marks its names false; the
+// other batches are still sent.
+func (c *Client) updateCostCenterResources(method, costCenterID, kind string, names []string) (map[string]bool, error) {
+	if len(names) == 0 {
+		return map[string]bool{}, nil
+	}
+	if err := ValidateCostCenterID(costCenterID); err != nil {
+		return nil, err
 	}
 
-	c.log.Info("Removing repositories from cost center",
-		"cost_center_id", costCenterID, "count", len(repoNames))
+	verb, prep, op := "adding", "to", "add_"+kind
+	if method == http.MethodDelete {
+		verb, prep, op = "removing", "from", "remove_"+kind
+	}
+	c.log.Info("Updating cost center "+kind,
+		"action", verb, "cost_center_id", costCenterID, "count", len(names))
 
-	url := c.enterpriseURL(fmt.Sprintf("/settings/billing/cost-centers/%s/resource", costCenterID))
-	body := map[string]any{"repositories": repoNames}
+	reqURL := c.enterpriseURL("/settings/billing/cost-centers" + escapePath(costCenterID, "resource"))
+	results := make(map[string]bool, len(names))
+	var failed []error
+	for i := 0; i < len(names); i += resourceBatchSize {
+		batch := names[i:min(i+resourceBatchSize, len(names))]
 
-	_, err := c.doJSON(http.MethodDelete, url, body, nil)
-	if err != nil {
-		return fmt.Errorf("removing repositories from cost center %s: %w", costCenterID, err)
+		_, err := c.doJSON(method, reqURL, map[string]any{kind: batch}, nil)
+		c.mutated(Mutation{Operation: op, Target: costCenterID, Items: batch, Err: err})
+		for _, name := range batch {
+			results[name] = err == nil
+		}
+		if err != nil {
+			c.log.Error("Failed to update cost center "+kind+" batch",
+				"action", verb, "cost_center_id", costCenterID, "batch_size", len(batch), "error", err)
+			failed = append(failed, err)
+			continue
+		}
+		c.log.Debug("Updated cost center "+kind+" batch",
+			"action", verb, "cost_center_id", costCenterID, "batch_size", len(batch))
 	}
 
-	c.log.Info("Successfully removed repositories from cost center",
-		"cost_center_id", costCenterID, "count", len(repoNames))
-	return nil
+	if len(failed) > 0 {
+		return results, fmt.Errorf("%s %s %s cost center %s: %d of %d batches failed: %w",
+			verb, kind, prep, costCenterID, len(failed), (len(names)+resourceBatchSize-1)/resourceBatchSize, failed[0])
+	}
+	c.log.Info("Successfully updated cost center "+kind,
+		"action", verb, "cost_center_id", costCenterID, "count", len(names))
+	return results, nil
 }
 
 // GetCostCenterRepos returns the repository names assigned to the given
@@ -542,12 +892,7 @@ func (c *Client) GetCostCenterRepos(id string) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
-	var repos []string
-	for _, r := range detail.Resources {
-		if r.Type == "Repository" && r.Name != "" {
-			repos = append(repos, r.Name)
-		}
-	}
+	repos := detail.ResourceNames("Repository")
 	c.log.Debug("Cost center repositories", "cost_center_id", id, "count", len(repos))
 	return repos, nil
 }
@@ -1,18 +1,28 @@
 package github
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/renan-alm/gh-cost-center/internal/cache"
 	"github.com/renan-alm/gh-cost-center/internal/config"
 )
 
@@ -27,7 +37,7 @@ func (discardW) Write(p []byte) (int, error) { return len(p), nil }
 func newTestClient(t *testing.T, url string) *Client {
 	t.Helper()
 	return &Client{
-		http:       &http.Client{Timeout: 5 * time.Second},
+		http:       newHTTPClient("test-token", clientOptions{httpClient: &http.Client{Timeout: 5 * time.Second}}),
 		baseURL:    url,
 		enterprise: "test-ent",
 		token:      "test-token",
@@ -113,6 +123,156 @@ func TestNewClient(t *testing.T) {
 	})
 }
 
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestNewClient_TransportMiddleware(t *testing.T) {
+	var served atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if served.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"login":"octocat"}`))
+	}))
+	defer srv.Close()
+
+	var order []string
+	var seen atomic.Int32
+	counting := func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			seen.Add(1)
+			order = append(order, "counting")
+			if got := r.Header.Get("Authorization"); got != "Bearer mw-token" {
+				t.Errorf("middleware saw Authorization %q, want the final header", got)
+			}
+			if r.Header.Get("X-GitHub-Api-Version") != apiVersion {
+				t.Errorf("middleware saw X-GitHub-Api-Version %q", r.Header.Get("X-GitHub-Api-Version"))
+			}
+			return next.RoundTrip(r)
+		})
+	}
+	inner := func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			order = append(order, "inner")
+			return next.RoundTrip(r)
+		})
+	}
+	var base atomic.Int32
+	hc := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		base.Add(1)
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+
+	cfg := &config.Manager{Enterprise: "ent", APIBaseURL: srv.URL, Token: "mw-token"}
+	c, err := NewClient(cfg, testLogger(), WithHTTPClient(hc), WithTransportMiddleware(counting), WithTransportMiddleware(inner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	login, err := c.AuthenticatedLogin()
+	if err != nil || login != "octocat" {
+		t.Fatalf("AuthenticatedLogin = %q, %v", login, err)
+	}
+	if seen.Load() != 2 || base.Load() != 2 {
+		t.Errorf("middleware saw %d requests and the base transport %d, want 2 each (the retry included)", seen.Load(), base.Load())
+	}
+	if want := []string{"counting", "inner", "counting", "inner"}; !slices.Equal(order, want) {
+		t.Errorf("middleware order = %v, want %v", order, want)
+	}
+	if _, ok := hc.Transport.(roundTripperFunc); !ok {
+		t.Error("WithHTTPClient modified the caller's http.Client")
+	}
+}
+
+func TestNewClient_DefaultTransportSetsHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer plain" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if r.Header.Get("Accept") != acceptHeader || r.Header.Get("User-Agent") != userAgent {
+			t.Errorf("Accept = %q, User-Agent = %q", r.Header.Get("Accept"), r.Header.Get("User-Agent"))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(&config.Manager{Enterprise: "ent", APIBaseURL: srv.URL, Token: "plain"}, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.http.Timeout != config.DefaultHTTPTimeout {
+		t.Errorf("Timeout = %v, want %v", c.http.Timeout, config.DefaultHTTPTimeout)
+	}
+	if _, err := c.doJSON(http.MethodGet, srv.URL+"/user", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewClient_TLSSettings(t *testing.T) {
+	var served atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"login":"octocat"}`))
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // the rejected handshake
+	srv.StartTLS()
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, certPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	login := func(cfg *config.Manager) error {
+		t.Helper()
+		cfg.Enterprise, cfg.APIBaseURL, cfg.Token = "ent", srv.URL, "t"
+		c, err := NewClient(cfg, testLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.AuthenticatedLogin()
+		return err
+	}
+
+	t.Run("unknown CA fails without retries", func(t *testing.T) {
+		served.Store(0)
+		err := login(&config.Manager{})
+		if err == nil || !isCertificateError(err) || !strings.Contains(err.Error(), "github.ca_cert_path") {
+			t.Fatalf("err = %v, want a certificate error naming github.ca_cert_path", err)
+		}
+		if isTransient(err) {
+			t.Error("isTransient = true for a certificate error")
+		}
+	})
+	t.Run("CA bundle", func(t *testing.T) {
+		if err := login(&config.Manager{CACertPath: caFile}); err != nil {
+			t.Fatalf("err = %v, want the bundle to be trusted", err)
+		}
+	})
+	t.Run("insecure skip verify", func(t *testing.T) {
+		if err := login(&config.Manager{InsecureSkipVerify: true}); err != nil {
+			t.Fatalf("err = %v, want verification skipped", err)
+		}
+	})
+	t.Run("invalid bundle", func(t *testing.T) {
+		bad := filepath.Join(t.TempDir(), "bad.pem")
+		if err := os.WriteFile(bad, []byte("garbage"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cfg := &config.Manager{Enterprise: "ent", APIBaseURL: srv.URL, Token: "t", CACertPath: bad}
+		if _, err := NewClient(cfg, testLogger()); err == nil || !strings.Contains(err.Error(), "no PEM certificates") {
+			t.Errorf("err = %v, want no PEM certificates", err)
+		}
+	})
+	if served.Load() != 2 {
+		t.Errorf("server handled %d requests, want 2", served.Load())
+	}
+}
+
 func TestEnterpriseURL(t *testing.T) {
 	c := &Client{baseURL: "https://api.github.com", enterprise: "my-ent"}
 	tests := []struct {
@@ -327,6 +487,9 @@ func TestDoJSON_RetryOnServerError(t *testing.T) {
 	if got := calls.Load(); got != 3 {
 		t.Errorf("calls = %d, want 3", got)
 	}
+	if m := c.Metrics(); m.Requests != 3 || m.Retries != 2 || m.RateLimitWaits != 0 {
+		t.Errorf("Metrics = %+v, want 3 requests and 2 retries", m)
+	}
 }
 
 func TestDoJSON_ExhaustedRetries(t *testing.T) {
@@ -365,6 +528,131 @@ func TestReadBody(t *testing.T) {
 			t.Errorf("readBody(NoBody) = %q", got)
 		}
 	})
+	t.Run("huge body", func(t *testing.T) {
+		body := &countingReader{size: 8 << 20}
+		got := readBody(&http.Response{Body: io.NopCloser(body)})
+		if body.read > maxErrorBody+1 {
+			t.Errorf("read %d bytes of an 8 MB body, want at most %d", body.read, maxErrorBody+1)
+		}
+		if !strings.HasSuffix(got, bodyTruncatedMarker) || len(got) != maxErrorBody+len(bodyTruncatedMarker) {
+			t.Errorf("len = %d, want %d bytes ending in %q", len(got), maxErrorBody, bodyTruncatedMarker)
+		}
+	})
+	t.Run("exactly the cap", func(t *testing.T) {
+		got := readBody(&http.Response{Body: io.NopCloser(&countingReader{size: maxErrorBody})})
+		if len(got) != maxErrorBody || strings.HasSuffix(got, bodyTruncatedMarker) {
+			t.Errorf("len = %d, want the whole body without a marker", len(got))
+		}
+	})
+}
+
+// countingReader serves size bytes of HTML-ish filler without holding them
+// in memory, and counts how many were read.
+type countingReader struct {
+	size, read int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	if r.read >= r.size {
+		return 0, io.EOF
+	}
+	n := min(len(p), r.size-r.read)
+	for i := range n {
+		p[i] = "<div>"[(r.read+i)%5]
+	}
+	r.read += n
+	return n, nil
+}
+
+func TestDoJSON_HTMLWith200(t *testing.T) {
+	page := "<!DOCTYPE html><html><body>" + strings.Repeat("Down for maintenance. ", 50) + "</body></html>"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(page))
+	}))
+	defer srv.Close()
+
+	var dest seatsResponse
+	_, err := newTestClient(t, srv.URL).doJSON(http.MethodGet, srv.URL+"/seats?page=3", nil, &dest)
+	if err == nil {
+		t.Fatal("doJSON decoded an HTML page")
+	}
+	for _, want := range []string{"GET " + srv.URL + "/seats?page=3", "status 200", `"text/html; charset=utf-8"`, "<!DOCTYPE html>"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %q, want it to contain %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "</body>") {
+		t.Errorf("err quotes the whole page, want the first %d bytes: %q", maxDecodeErrorBody, err)
+	}
+}
+
+func TestDoJSON_NoBodyWithTarget(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{"204", http.StatusNoContent, ""},
+		{"205", http.StatusResetContent, ""},
+		{"empty 200", http.StatusOK, ""},
+		{"whitespace 200", http.StatusOK, " \n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			dest := Budget{ID: "unchanged"}
+			if _, err := newTestClient(t, srv.URL).doJSON(http.MethodPost, srv.URL+"/x", nil, &dest); err != nil {
+				t.Fatalf("doJSON: %v", err)
+			}
+			if dest.ID != "unchanged" {
+				t.Errorf("dest = %+v, want it untouched", dest)
+			}
+		})
+	}
+}
+
+func TestDoJSON_InvalidJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.github+json")
+		_, _ = w.Write([]byte(`{"seats": [`))
+	}))
+	defer srv.Close()
+
+	var dest seatsResponse
+	_, err := newTestClient(t, srv.URL).doJSON(http.MethodGet, srv.URL+"/seats", nil, &dest)
+	if err == nil || !strings.Contains(err.Error(), "status 200") || !strings.Contains(err.Error(), `"{\"seats\": ["`) {
+		t.Errorf("err = %v, want a decode error with the status and the body", err)
+	}
+}
+
+func TestAPIError_HugeBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = io.Copy(w, &countingReader{size: 4 << 20})
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL)
+	_, err := c.doJSON(http.MethodGet, srv.URL+"/x", nil, nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %T %v, want *APIError", err, err)
+	}
+	if len(apiErr.Body) != maxErrorBody+len(bodyTruncatedMarker) || !strings.HasSuffix(apiErr.Body, bodyTruncatedMarker) {
+		t.Errorf("Body has %d bytes, want the capped body with the truncation marker", len(apiErr.Body))
+	}
+	msg := apiErr.Error()
+	if len(msg) > maxErrorBodyInMessage+100 || !strings.Contains(msg, "more bytes)") {
+		t.Errorf("Error() = %d bytes %q..., want the body elided", len(msg), msg[:min(len(msg), 80)])
+	}
+	if !strings.HasPrefix(msg, "GitHub API error 403: <div>") {
+		t.Errorf("Error() = %q, want the status and the start of the body", msg[:min(len(msg), 80)])
+	}
 }
 
 func TestDeduplicateUsers(t *testing.T) {
@@ -387,6 +675,36 @@ func TestDeduplicateUsers(t *testing.T) {
 	}
 }
 
+func TestDeduplicateUsers_KeepsFreshestEntry(t *testing.T) {
+	team := func(slug string) *AssigningTeam { return &AssigningTeam{Slug: slug} }
+	users := []CopilotUser{
+		// alice: the later updated_at wins even though it comes first.
+		{Login: "alice", UpdatedAt: "2024-09-01T00:00:00Z", LastActivityAt: "2024-09-01T00:00:00Z", AssigningTeam: team("new")},
+		{Login: "bob", CreatedAt: "2024-01-01T00:00:00Z", AssigningTeam: team("old")},
+		{Login: "alice", UpdatedAt: "2024-03-01T00:00:00Z", LastActivityAt: "2024-03-01T00:00:00Z", AssigningTeam: team("old")},
+		// bob: same updated_at (none), so the later created_at wins.
+		{Login: "bob", CreatedAt: "2024-05-01T10:00:00+02:00", AssigningTeam: team("new")},
+		// carol: no usable dates, so the later entry wins.
+		{Login: "carol", UpdatedAt: "garbage", AssigningTeam: team("old")},
+		{Login: "carol", AssigningTeam: team("new")},
+	}
+	got := deduplicateUsers(users, testLogger())
+	if len(got) != 3 {
+		t.Fatalf("len = %d, want 3", len(got))
+	}
+	for i, login := range []string{"alice", "bob", "carol"} {
+		if got[i].Login != login {
+			t.Errorf("got[%d] = %s, want %s (first-occurrence order)", i, got[i].Login, login)
+		}
+		if got[i].AssigningTeam.Slug != "new" {
+			t.Errorf("%s: kept the %s entry, want the fresher one", login, got[i].AssigningTeam.Slug)
+		}
+	}
+	if got[0].LastActivityAt != "2024-09-01T00:00:00Z" {
+		t.Errorf("alice LastActivityAt = %s, want the fresher entry's", got[0].LastActivityAt)
+	}
+}
+
 func TestDeduplicateUsers_Empty(t *testing.T) {
 	if got := deduplicateUsers(nil, testLogger()); len(got) != 0 {
 		t.Errorf("len = %d, want 0", len(got))
@@ -395,24 +713,29 @@ func TestDeduplicateUsers_Empty(t *testing.T) {
 
 func TestFilterUsersByTimestamp(t *testing.T) {
 	threshold := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
-	users := []CopilotUser{
-		{Login: "old", CreatedAt: "2024-05-01T00:00:00Z"},
-		{Login: "new1", CreatedAt: "2024-07-01T12:00:00Z"},
-		{Login: "new2", CreatedAt: "2024-06-02T00:00:00Z"},
-		{Login: "exact", CreatedAt: "2024-06-01T00:00:00Z"},
-		{Login: "empty", CreatedAt: ""},
-		{Login: "bad", CreatedAt: "not-a-date"},
-	}
-	got := FilterUsersByTimestamp(users, threshold)
-	if len(got) != 2 {
-		t.Fatalf("len = %d, want 2", len(got))
-	}
-	logins := make(map[string]bool)
-	for _, u := range got {
-		logins[u.Login] = true
+	tests := []struct {
+		name, createdAt string
+		want            bool
+	}{
+		{"before", "2024-05-01T00:00:00Z", false},
+		{"after", "2024-07-01T12:00:00Z", true},
+		{"day after", "2024-06-02T00:00:00Z", true},
+		{"exact", "2024-06-01T00:00:00Z", false},
+		{"offset after", "2024-06-01T10:00:00+02:00", true},
+		{"offset before", "2024-06-01T01:00:00+02:00", false}, // 2024-05-31T23:00Z
+		{"fractional seconds", "2024-06-01T10:00:00.000Z", true},
+		{"fractional exact", "2024-06-01T00:00:00.000Z", false},
+		{"no offset", "2024-06-01T10:00:00", true},
+		{"empty", "", true},
+		{"garbage", "not-a-date", true},
 	}
-	if !logins["new1"] || !logins["new2"] {
-		t.Errorf("logins = %v", logins)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FilterUsersByTimestamp([]CopilotUser{{Login: "u", CreatedAt: tt.createdAt}}, threshold)
+			if (len(got) == 1) != tt.want {
+				t.Errorf("FilterUsersByTimestamp(%q) kept = %v, want %v", tt.createdAt, len(got) == 1, tt.want)
+			}
+		})
 	}
 }
 
@@ -522,6 +845,202 @@ func TestGetCopilotUsers_Pagination(t *testing.T) {
 	}
 }
 
+func TestGetCopilotUsers_SeatCountMismatch(t *testing.T) {
+	// total_seats says 250 but the pages hold only 240 seats.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		n := 0
+		switch page {
+		case 1, 2:
+			n = 100
+		case 3:
+			n = 40
+		}
+		seats := make([]seatEntry, n)
+		for i := range seats {
+			seats[i] = seatEntry{Assignee: assignee{Login: fmt.Sprintf("user-%d-%d", page, i)}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(seatsResponse{TotalSeats: 250, Seats: seats})
+	}))
+	defer srv.Close()
+
+	var logs bytes.Buffer
+	c := newTestClient(t, srv.URL)
+	c.log = slog.New(slog.NewTextHandler(&logs, nil))
+	if got := c.SeatFetch(); got != (SeatFetch{}) {
+		t.Errorf("SeatFetch before listing = %+v, want zero", got)
+	}
+	users, err := c.GetCopilotUsers()
+	if err != nil {
+		t.Fatalf("GetCopilotUsers: %v", err)
+	}
+	if len(users) != 240 {
+		t.Fatalf("got %d users, want 240", len(users))
+	}
+	want := SeatFetch{Fetched: 240, TotalSeats: 250, Unique: 240, Mismatch: true}
+	if got := c.SeatFetch(); got != want {
+		t.Errorf("SeatFetch = %+v, want %+v", got, want)
+	}
+	if !strings.Contains(logs.String(), "level=WARN") || !strings.Contains(logs.String(), "total_seats=250") {
+		t.Errorf("want a mismatch warning, logs:\n%s", logs.String())
+	}
+}
+
+func TestRecordSeatFetch_DuplicatesAreNotAMismatch(t *testing.T) {
+	c := newTestClient(t, "http://unused")
+	// 105 entries, 5 of them duplicate grants of 100 seats.
+	c.recordSeatFetch(105, 100, 100)
+	if c.SeatFetch().Mismatch {
+		t.Errorf("SeatFetch = %+v, want no mismatch within the duplicates", c.SeatFetch())
+	}
+	// total_seats missing: nothing to compare with.
+	c.recordSeatFetch(40, 0, 40)
+	if c.SeatFetch().Mismatch {
+		t.Errorf("SeatFetch = %+v, want no mismatch without total_seats", c.SeatFetch())
+	}
+}
+
+func TestGetCopilotUsers_ConcurrentPages(t *testing.T) {
+	const (
+		pages       = 10
+		concurrency = 3
+	)
+	var inFlight, maxInFlight, requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		pg, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if pg < 1 || pg > pages {
+			t.Errorf("unexpected page %d", pg)
+		}
+		seats := make([]seatEntry, 100)
+		for i := range seats {
+			// The first seat of every page after the first repeats user-0,
+			// so dedupe must hold regardless of which page arrives first.
+			login := fmt.Sprintf("user-%d", (pg-1)*100+i)
+			if pg > 1 && i == 0 {
+				login = "user-0"
+			}
+			seats[i] = seatEntry{Assignee: assignee{Login: login}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(seatsResponse{TotalSeats: pages * 100, Seats: seats})
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL)
+	c.seatConcurrency = concurrency
+	users, err := c.GetCopilotUsers()
+	if err != nil {
+		t.Fatalf("GetCopilotUsers: %v", err)
+	}
+	if got := requests.Load(); got != pages {
+		t.Errorf("requests = %d, want %d", got, pages)
+	}
+	if want := pages*100 - (pages - 1); len(users) != want {
+		t.Errorf("got %d users, want %d", len(users), want)
+	}
+	if users[0].Login != "user-0" || users[len(users)-1].Login != "user-999" {
+		t.Errorf("users not merged in page order: first=%q last=%q", users[0].Login, users[len(users)-1].Login)
+	}
+	if got := maxInFlight.Load(); got > concurrency {
+		t.Errorf("max in-flight requests = %d, want <= %d", got, concurrency)
+	}
+}
+
+func TestGetCopilotUsers_OrgScopeMergesOrgs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/orgs/org-a/copilot/billing/seats":
+			_ = json.NewEncoder(w).Encode(seatsResponse{TotalSeats: 2, Seats: []seatEntry{
+				{Assignee: assignee{Login: "alice"}},
+				{Assignee: assignee{Login: "bob"}},
+			}})
+		case "/orgs/org-b/copilot/billing/seats":
+			_ = json.NewEncoder(w).Encode(seatsResponse{TotalSeats: 2, Seats: []seatEntry{
+				{Assignee: assignee{Login: "bob"}},
+				{Assignee: assignee{Login: "carol"}},
+			}})
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL)
+	c.seatOrgs = []string{"org-a", "org-b"}
+	users, err := c.GetCopilotUsers()
+	if err != nil {
+		t.Fatalf("GetCopilotUsers: %v", err)
+	}
+	var logins []string
+	for _, u := range users {
+		logins = append(logins, u.Login+"@"+u.Organization)
+	}
+	// bob's seats carry no dates, so the later entry (org-b) is kept.
+	if got := strings.Join(logins, ","); got != "alice@org-a,bob@org-b,carol@org-b" {
+		t.Errorf("users = %s", got)
+	}
+}
+
+func TestCopilotUser_PendingCancellation(t *testing.T) {
+	tests := []struct {
+		raw         string
+		wantPending bool
+		wantDate    string
+	}{
+		{"", false, ""},
+		{"   ", false, ""},
+		{"2026-11-01", true, "2026-11-01"},
+		{"2026-11-01T00:00:00Z", true, "2026-11-01"},
+		{"soon", true, ""},
+	}
+	for _, tt := range tests {
+		date, pending := CopilotUser{PendingCancellationDate: tt.raw}.PendingCancellation()
+		if pending != tt.wantPending {
+			t.Errorf("%q: pending = %v, want %v", tt.raw, pending, tt.wantPending)
+		}
+		got := ""
+		if !date.IsZero() {
+			got = date.Format("2006-01-02")
+		}
+		if got != tt.wantDate {
+			t.Errorf("%q: date = %q, want %q", tt.raw, got, tt.wantDate)
+		}
+	}
+}
+
+func TestCopilotUser_LastActivity(t *testing.T) {
+	tests := []struct {
+		raw    string
+		wantOK bool
+	}{
+		{"", false},
+		{"yesterday", false},
+		{"2026-09-01", false},
+		{"2026-09-01T10:00:00Z", true},
+		{"2026-09-01T10:00:00+02:00", true},
+	}
+	for _, tt := range tests {
+		got, ok := CopilotUser{LastActivityAt: tt.raw}.LastActivity()
+		if ok != tt.wantOK || ok == got.IsZero() {
+			t.Errorf("%q: LastActivity = %v, %v; want ok %v", tt.raw, got, ok, tt.wantOK)
+		}
+	}
+}
+
 func TestGetAllActiveCostCenters(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -734,6 +1253,51 @@ func TestAddUsersToCostCenter_InvalidID(t *testing.T) {
 	}
 }
 
+func TestBulkUpdateCostCenterAssignments_Interrupted(t *testing.T) {
+	const ccA, ccB = "a1b2c3d4-b5c6-7890-abcd-ef1234567890", "d1e2f3a4-b5c6-7890-abcd-ef1234567890"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var posts atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			// Interrupt while the first batch is in flight; it still completes.
+			posts.Add(1)
+			cancel()
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"x","name":"x","state":"active","resources":[]}`))
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv.URL)
+	c.SetContext(ctx)
+
+	users := make([]string, 120)
+	for i := range users {
+		users[i] = fmt.Sprintf("user%03d", i)
+	}
+	results, err := c.BulkUpdateCostCenterAssignments(map[string][]string{ccA: users, ccB: {"zed"}}, true)
+	if !errors.Is(err, ErrInterrupted) {
+		t.Fatalf("err = %v, want ErrInterrupted", err)
+	}
+	if posts.Load() != 1 {
+		t.Errorf("POST requests = %d, want only the batch in flight", posts.Load())
+	}
+	total := 0
+	for _, r := range results {
+		for _, ok := range r {
+			if !ok {
+				t.Error("the batch in flight is recorded as failed")
+			}
+			total++
+		}
+	}
+	if total != 1 && total != 50 {
+		t.Errorf("results cover %d users, want the first batch only", total)
+	}
+}
+
 func TestGetCostCenter_InvalidID(t *testing.T) {
 	c := newTestClient(t, "http://unused")
 	_, err := c.GetCostCenter("Ölbrück-Straße")
@@ -827,3 +1391,1081 @@ func TestGetOrgPropertySchema(t *testing.T) {
 		t.Errorf("first = %q", defs[0].PropertyName)
 	}
 }
+
+func TestCache_ActiveCostCentersAndSchemas(t *testing.T) {
+	var lists, schemas atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/properties/schema"):
+			schemas.Add(1)
+			_ = json.NewEncoder(w).Encode([]PropertyDefinition{{PropertyName: "team", ValueType: "string"}})
+		case r.Method == http.MethodPost:
+			_ = json.NewEncoder(w).Encode(costCenterCreateResponse{ID: "22222222-2222-2222-2222-222222222222", Name: "New"})
+		default:
+			lists.Add(1)
+			_ = json.NewEncoder(w).Encode(costCentersListResponse{CostCenters: []CostCenter{
+				{ID: "11111111-1111-1111-1111-111111111111", Name: "Platform", State: "active"},
+			}})
+		}
+	}))
+	defer srv.Close()
+
+	cc, err := cache.New(t.TempDir(), testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newTestClient(t, srv.URL)
+	c.SetCache(cc)
+
+	for range 2 {
+		active, err := c.GetAllActiveCostCenters()
+		if err != nil || active["Platform"] != "11111111-1111-1111-1111-111111111111" {
+			t.Fatalf("GetAllActiveCostCenters = %v, %v", active, err)
+		}
+		defs, err := c.GetOrgPropertySchema("my-org")
+		if err != nil || len(defs) != 1 || defs[0].PropertyName != "team" {
+			t.Fatalf("GetOrgPropertySchema = %v, %v", defs, err)
+		}
+	}
+	if lists.Load() != 1 || schemas.Load() != 1 {
+		t.Errorf("API calls = %d lists, %d schemas; want 1 each, the second from the cache", lists.Load(), schemas.Load())
+	}
+
+	// Creating a cost center makes the cached list stale.
+	if _, err := c.CreateCostCenter("New"); err != nil {
+		t.Fatalf("CreateCostCenter: %v", err)
+	}
+	if _, err := c.GetAllActiveCostCenters(); err != nil {
+		t.Fatal(err)
+	}
+	if lists.Load() != 2 {
+		t.Errorf("list calls = %d, want the list refetched after a create", lists.Load())
+	}
+}
+
+func TestGetOrgRepoStatuses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/orgs/my-org/repos" || r.URL.Query().Get("type") != "all" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		var repos []RepoStatus
+		if r.URL.Query().Get("page") == "1" {
+			for i := range 100 {
+				repos = append(repos, RepoStatus{FullName: fmt.Sprintf("my-org/r%d", i), Archived: i == 7})
+			}
+		} else {
+			repos = []RepoStatus{{FullName: "my-org/last", Disabled: true}}
+		}
+		_ = json.NewEncoder(w).Encode(repos)
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv.URL)
+	repos, err := c.GetOrgRepoStatuses("my-org")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(repos) != 101 {
+		t.Fatalf("got %d, want 101 across two pages", len(repos))
+	}
+	if !repos[7].Archived || repos[8].Archived || !repos[100].Disabled {
+		t.Errorf("flags not decoded: %+v %+v %+v", repos[7], repos[8], repos[100])
+	}
+}
+
+func TestGetCostCenterUsers_Pagination(t *testing.T) {
+	const id = "d1e2f3a4-b5c6-7890-abcd-ef1234567890"
+	var srvURL string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "2" {
+			_ = json.NewEncoder(w).Encode(CostCenterDetail{ID: id, Resources: []Resource{
+				{Type: "User", Name: "carol"},
+			}})
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=2>; rel="next"`, srvURL, r.URL.Path))
+		_ = json.NewEncoder(w).Encode(CostCenterDetail{ID: id, Resources: []Resource{
+			{Type: "User", Name: "alice"},
+			{Type: "Repository", Name: "org/repo"},
+			{Type: "User", Name: "bob"},
+		}})
+	}))
+	defer srv.Close()
+	srvURL = srv.URL
+	c := newTestClient(t, srv.URL)
+	users, err := c.GetCostCenterUsers(id)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if strings.Join(users, ",") != "alice,bob,carol" {
+		t.Errorf("users = %v, want [alice bob carol]", users)
+	}
+}
+
+func TestGetCostCenter_RejectsForeignNextPage(t *testing.T) {
+	const id = "d1e2f3a4-b5c6-7890-abcd-ef1234567890"
+	var foreignHits atomic.Int32
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		foreignHits.Add(1)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer foreign.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Link", fmt.Sprintf(`<%s/steal?page=2>; rel="next"`, foreign.URL))
+		_ = json.NewEncoder(w).Encode(CostCenterDetail{ID: id})
+	}))
+	defer srv.Close()
+
+	_, err := newTestClient(t, srv.URL).GetCostCenter(id)
+	if err == nil || !strings.Contains(err.Error(), "refusing next page URL") {
+		t.Fatalf("expected foreign next page to be rejected, got %v", err)
+	}
+	if foreignHits.Load() != 0 {
+		t.Errorf("token-bearing request was sent to the foreign host")
+	}
+}
+
+func TestGetCostCentersUsers_BoundedConcurrency(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(CostCenterDetail{ID: id, Resources: []Resource{
+			{Type: "User", Name: "user-" + id[:8]},
+		}})
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv.URL)
+
+	var ids []string
+	for i := 0; i < 8; i++ {
+		ids = append(ids, fmt.Sprintf("%08d-b5c6-7890-abcd-ef1234567890", i))
+	}
+	members, err := c.GetCostCentersUsers(ids, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(members) != len(ids) {
+		t.Fatalf("got %d cost centers, want %d", len(members), len(ids))
+	}
+	if got := maxInFlight.Load(); got > 2 {
+		t.Errorf("max in-flight requests = %d, want <= 2", got)
+	}
+}
+
+func TestGetAllActiveCostCentersWithMembers(t *testing.T) {
+	const id = "d1e2f3a4-b5c6-7890-abcd-ef1234567890"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/cost-centers") {
+			_ = json.NewEncoder(w).Encode(costCentersListResponse{CostCenters: []CostCenter{
+				{ID: id, Name: "No PRU", State: "active"},
+			}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(CostCenterDetail{ID: id, Resources: []Resource{
+			{Type: "User", Name: "alice"},
+		}})
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv.URL)
+	active, members, err := c.GetAllActiveCostCentersWithMembers(3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if active["No PRU"] != id {
+		t.Errorf("active = %v", active)
+	}
+	if len(members[id]) != 1 || members[id][0] != "alice" {
+		t.Errorf("members = %v", members)
+	}
+}
+
+func TestAPIError_Message(t *testing.T) {
+	tests := []struct {
+		name, body, want string
+	}{
+		{"json envelope", `{"message":"Validation Failed","errors":[{"message":"cost center has budgets"}]}`, "Validation Failed: cost center has budgets"},
+		{"json message only", `{"message":"Conflict"}`, "Conflict"},
+		{"plain text", "  oops  ", "oops"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &APIError{StatusCode: 422, Body: tt.body}
+			if got := e.Message(); got != tt.want {
+				t.Errorf("Message() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteCostCenter(t *testing.T) {
+	const id = "d1e2f3a4-b5c6-7890-abcd-ef1234567890"
+	t.Run("success", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodDelete {
+				t.Errorf("method = %s", r.Method)
+			}
+			if !strings.HasSuffix(r.URL.Path, "/settings/billing/cost-centers/"+id) {
+				t.Errorf("path = %s", r.URL.Path)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer srv.Close()
+		if err := newTestClient(t, srv.URL).DeleteCostCenter(id); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	})
+	t.Run("conflict message surfaced", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"message":"Cost center has active budgets"}`))
+		}))
+		defer srv.Close()
+		err := newTestClient(t, srv.URL).DeleteCostCenter(id)
+		if err == nil || !strings.Contains(err.Error(), "Cost center has active budgets") {
+			t.Fatalf("expected parsed conflict message, got %v", err)
+		}
+		if n := strings.Count(err.Error(), "Cost center has active budgets"); n != 1 {
+			t.Errorf("message appears %d times in %q, want once", n, err.Error())
+		}
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+			t.Errorf("expected *APIError with 409 in chain, got %v", err)
+		}
+	})
+	t.Run("invalid id", func(t *testing.T) {
+		if err := newTestClient(t, "http://unused").DeleteCostCenter("not-a-uuid"); err == nil {
+			t.Fatal("expected error for invalid ID")
+		}
+	})
+}
+
+func TestUpdateCostCenter(t *testing.T) {
+	const id = "d1e2f3a4-b5c6-7890-abcd-ef1234567890"
+	t.Run("success", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPatch {
+				t.Errorf("method = %s", r.Method)
+			}
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["name"] != "New Name" {
+				t.Errorf("body name = %q", body["name"])
+			}
+			_, _ = w.Write([]byte(`{"id":"` + id + `","name":"New Name"}`))
+		}))
+		defer srv.Close()
+		if err := newTestClient(t, srv.URL).UpdateCostCenter(id, "New Name"); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	})
+	t.Run("name taken", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"message":"Name already exists"}`))
+		}))
+		defer srv.Close()
+		err := newTestClient(t, srv.URL).UpdateCostCenter(id, "Taken")
+		if !IsCostCenterConflict(err) {
+			t.Fatalf("expected conflict error, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "Name already exists") {
+			t.Errorf("error should include API message: %v", err)
+		}
+	})
+}
+
+func TestListBudgets_Pagination(t *testing.T) {
+	t.Run("short page", func(t *testing.T) {
+		var requests int
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			requests++
+			var budgets []Budget
+			count := 100
+			if r.URL.Query().Get("page") == "2" {
+				count = 3
+			}
+			for i := 0; i < count; i++ {
+				budgets = append(budgets, Budget{ID: fmt.Sprintf("b-%s-%d", r.URL.Query().Get("page"), i)})
+			}
+			_ = json.NewEncoder(w).Encode(budgetsListResponse{Budgets: budgets})
+		}))
+		defer srv.Close()
+
+		budgets, err := newTestClient(t, srv.URL).ListBudgets()
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if len(budgets) != 103 {
+			t.Errorf("got %d budgets, want 103", len(budgets))
+		}
+		if requests != 2 {
+			t.Errorf("requests = %d, want 2", requests)
+		}
+	})
+	t.Run("has_next_page", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			page := r.URL.Query().Get("page")
+			next := page == "1"
+			_, _ = fmt.Fprintf(w, `{"budgets":[{"id":"b-%s"}],"has_next_page":%t}`, page, next)
+		}))
+		defer srv.Close()
+
+		budgets, err := newTestClient(t, srv.URL).ListBudgets()
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if len(budgets) != 2 || budgets[1].ID != "b-2" {
+			t.Errorf("budgets = %+v, want b-1 and b-2", budgets)
+		}
+	})
+}
+
+func TestBudget_DecodesAPIPayload(t *testing.T) {
+	body := `{
+		"id": "2066deda-923f-43f9-88d2-62395a28c0cdd",
+		"budget_type": "SkuPricing",
+		"budget_product_sku": "copilot_premium_request",
+		"budget_scope": "cost_center",
+		"budget_amount": 250,
+		"prevent_further_usage": true,
+		"budget_entity_name": "cc-1",
+		"budget_alerting": {"will_alert": true, "alert_recipients": ["octocat", "hubot"]}
+	}`
+	var b Budget
+	if err := json.Unmarshal([]byte(body), &b); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if b.ID != "2066deda-923f-43f9-88d2-62395a28c0cdd" {
+		t.Errorf("ID = %q", b.ID)
+	}
+	if !b.PreventFurtherUsage {
+		t.Error("PreventFurtherUsage = false, want true")
+	}
+	if !b.BudgetAlerting.WillAlert || len(b.BudgetAlerting.AlertRecipients) != 2 ||
+		b.BudgetAlerting.AlertRecipients[0] != "octocat" {
+		t.Errorf("BudgetAlerting = %+v", b.BudgetAlerting)
+	}
+
+	out, err := json.Marshal(b)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var again Budget
+	if err := json.Unmarshal(out, &again); err != nil {
+		t.Fatalf("unmarshal round-trip: %v", err)
+	}
+	if again.ID != b.ID || again.BudgetAmount != 250 || len(again.BudgetAlerting.AlertRecipients) != 2 {
+		t.Errorf("round-trip mismatch: %+v", again)
+	}
+}
+
+func TestCheckCostCenterHasProductBudget_PaginatesAcrossPages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "1" {
+			_, _ = w.Write([]byte(`{"budgets":[{"id":"b-1","budget_scope":"cost_center","budget_entity_name":"other","budget_product_sku":"actions"}],"has_next_page":true}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"budgets":[{"id":"b-2","budget_scope":"cost_center","budget_entity_name":"cc-1","budget_product_sku":"actions"}],"has_next_page":false}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL)
+	ok, err := c.CheckCostCenterHasProductBudget("cc-1", "CC One", "actions")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !ok {
+		t.Error("expected budget on page 2 to be found")
+	}
+	ok, err = c.CheckCostCenterHasBudget("cc-1", "CC One")
+	if err != nil || !ok {
+		t.Errorf("CheckCostCenterHasBudget = %v, %v; want true", ok, err)
+	}
+}
+
+func TestUpdateBudget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			t.Errorf("method = %s", r.Method)
+		}
+		if !strings.HasSuffix(r.URL.Path, "/settings/billing/budgets/b-1") {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["budget_amount"] != float64(250) || body["prevent_further_usage"] != true {
+			t.Errorf("body = %v", body)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	if err := newTestClient(t, srv.URL).UpdateBudget("b-1", 250, true); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := newTestClient(t, srv.URL).UpdateBudget("", 250, true); err == nil {
+		t.Error("expected error for empty budget ID")
+	}
+}
+
+func TestCreateProductBudget_Reconcile(t *testing.T) {
+	const existing = `{"budgets":[{"id":"b-1","budget_scope":"cost_center","budget_entity_name":"cc-1",` +
+		`"budget_product_sku":"copilot","budget_amount":100,"prevent_further_usage":true}]}`
+
+	tests := []struct {
+		name        string
+		reconcile   bool
+		amount      int
+		wantPatches int
+	}{
+		{"disabled leaves budget alone", false, 250, 0},
+		{"enabled updates changed amount", true, 250, 1},
+		{"enabled skips unchanged amount", true, 100, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patches, posts int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.Method {
+				case http.MethodGet:
+					_, _ = w.Write([]byte(existing))
+				case http.MethodPatch:
+					patches++
+					w.WriteHeader(http.StatusOK)
+				case http.MethodPost:
+					posts++
+					w.WriteHeader(http.StatusCreated)
+				}
+			}))
+			defer srv.Close()
+
+			c := newTestClient(t, srv.URL)
+			c.SetReconcileBudgets(tt.reconcile)
+			ok, err := c.CreateProductBudget("cc-1", "CC One", "copilot", tt.amount, BudgetAlerting{})
+			if err != nil || !ok {
+				t.Fatalf("CreateProductBudget = %v, %v", ok, err)
+			}
+			if patches != tt.wantPatches {
+				t.Errorf("patches = %d, want %d", patches, tt.wantPatches)
+			}
+			if posts != 0 {
+				t.Errorf("posts = %d, want 0 for an existing budget", posts)
+			}
+		})
+	}
+}
+
+func TestDeleteBudget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || !strings.HasSuffix(r.URL.Path, "/settings/billing/budgets/b-1") {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	if err := newTestClient(t, srv.URL).DeleteBudget("b-1"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := newTestClient(t, srv.URL).DeleteBudget(""); err == nil {
+		t.Error("expected error for empty budget ID")
+	}
+}
+
+func TestFindOrphanedBudgets(t *testing.T) {
+	active := map[string]string{"Live": "cc-live"}
+	budgets := []Budget{
+		{ID: "by-id", BudgetScope: "cost_center", BudgetEntityName: "cc-live"},
+		{ID: "by-name", BudgetScope: "cost_center", BudgetEntityName: "Live"},
+		{ID: "orphan", BudgetScope: "cost_center", BudgetEntityName: "cc-gone"},
+		{ID: "org", BudgetScope: "organization", BudgetEntityName: "my-org"},
+	}
+
+	got := FindOrphanedBudgets(budgets, active)
+	if len(got) != 1 || got[0].ID != "orphan" {
+		t.Errorf("FindOrphanedBudgets = %+v, want only orphan", got)
+	}
+}
+
+func TestCreateProductBudget_AlertingInBody(t *testing.T) {
+	var alerting map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`{"budgets":[]}`))
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		alerting, _ = body["budget_alerting"].(map[string]any)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL)
+	a := AlertingFromConfig(config.ProductBudget{AlertsEnabled: true, AlertRecipients: []string{"octocat", "finance@example.com"}})
+	if _, err := c.CreateProductBudget("cc-1", "CC One", "copilot", 100, a); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if alerting["will_alert"] != true {
+		t.Errorf("will_alert = %v, want true", alerting["will_alert"])
+	}
+	recipients, _ := alerting["alert_recipients"].([]any)
+	if len(recipients) != 2 || recipients[0] != "octocat" || recipients[1] != "finance@example.com" {
+		t.Errorf("alert_recipients = %v", alerting["alert_recipients"])
+	}
+
+	// Without alerting configured the request still sends an empty list.
+	if _, err := c.CreateProductBudget("cc-1", "CC One", "copilot", 100, BudgetAlerting{}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if alerting["will_alert"] != false {
+		t.Errorf("will_alert = %v, want false", alerting["will_alert"])
+	}
+	if recipients, ok := alerting["alert_recipients"].([]any); !ok || len(recipients) != 0 {
+		t.Errorf("alert_recipients = %v, want empty list", alerting["alert_recipients"])
+	}
+}
+
+func TestCreateCostCenter_ConflictJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"message":"Cost center already exists","errors":[{"existing_cost_center_id":"D1E2F3A4-B5C6-7890-ABCD-EF1234567890"}]}`))
+	}))
+	defer srv.Close()
+
+	id, err := newTestClient(t, srv.URL).CreateCostCenter("Existing")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if id != "d1e2f3a4-b5c6-7890-abcd-ef1234567890" {
+		t.Errorf("id = %q", id)
+	}
+}
+
+func TestCreateCostCenter_ConflictLookupFallback(t *testing.T) {
+	const existingID = "a1b2c3d4-b5c6-7890-abcd-ef1234567890"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"message":"Cost center already exists"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(costCentersListResponse{CostCenters: []CostCenter{
+			{ID: existingID, Name: "Existing", State: "active"},
+		}})
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL)
+	id, err := c.CreateCostCenter("Existing")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if id != existingID {
+		t.Errorf("id = %q, want %q", id, existingID)
+	}
+
+	_, err = c.CreateCostCenter("Missing")
+	var conflictErr *CostCenterConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("expected CostCenterConflictError, got %v", err)
+	}
+	if conflictErr.Name != "Missing" || !strings.Contains(conflictErr.Body, "already exists") {
+		t.Errorf("conflict error = %+v", conflictErr)
+	}
+	if !IsCostCenterConflict(err) {
+		t.Error("IsCostCenterConflict should see the wrapped 409")
+	}
+}
+
+func TestCreateCostCenter_ConflictLookupFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"message":"Cost center already exists"}`))
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"message":"Resource not accessible"}`))
+	}))
+	defer srv.Close()
+
+	_, err := newTestClient(t, srv.URL).CreateCostCenter("Existing")
+	var conflictErr *CostCenterConflictError
+	if errors.As(err, &conflictErr) {
+		t.Fatalf("lookup failure must not be reported as a conflict: %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("expected the list error to be wrapped, got %v", err)
+	}
+}
+
+func TestExistingIDFromConflict(t *testing.T) {
+	const id = "d1e2f3a4-b5c6-7890-abcd-ef1234567890"
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"json errors field", `{"errors":[{"existing_cost_center_id":"` + id + `"}]}`, id},
+		{"json top-level field", `{"message":"exists","existing_cost_center_id":"` + id + `"}`, id},
+		{"json message text", `{"message":"Existing cost center UUID: ` + id + `"}`, id},
+		{"legacy text", "Existing cost center UUID: " + id, id},
+		{"json without id", `{"message":"Cost center already exists"}`, ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := existingIDFromConflict(tt.body); got != tt.want {
+				t.Errorf("existingIDFromConflict() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCostCenter_NotFound(t *testing.T) {
+	const id = "d1e2f3a4-b5c6-7890-abcd-ef1234567890"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	_, err := newTestClient(t, srv.URL).GetCostCenter(id)
+	var nf *CostCenterNotFoundError
+	if !errors.As(err, &nf) || nf.ID != id {
+		t.Fatalf("expected CostCenterNotFoundError for %s, got %v", id, err)
+	}
+	if !IsCostCenterNotFound(err) {
+		t.Error("IsCostCenterNotFound should still see the wrapped 404")
+	}
+}
+
+func TestCostCenterDetail_ResourceNames(t *testing.T) {
+	d := &CostCenterDetail{Resources: []Resource{
+		{Type: "User", Name: "alice"},
+		{Type: "Repository", Name: "org/api"},
+		{Type: "Org", Name: "my-org"},
+		{Type: "User", Name: ""},
+	}}
+	if got := d.ResourceNames("User"); len(got) != 1 || got[0] != "alice" {
+		t.Errorf("ResourceNames(User) = %v", got)
+	}
+	if got := d.ResourceNames("Org"); len(got) != 1 || got[0] != "my-org" {
+		t.Errorf("ResourceNames(Org) = %v", got)
+	}
+}
+
+func TestEscapePath(t *testing.T) {
+	tests := []struct {
+		segments []string
+		want     string
+	}{
+		{[]string{"orgs", "my-org", "teams"}, "/orgs/my-org/teams"},
+		{[]string{"orgs", "my org", "teams"}, "/orgs/my%20org/teams"},
+		{[]string{"teams", "front/end", "members"}, "/teams/front%2Fend/members"},
+		{[]string{"orgs", "Ölbrück"}, "/orgs/%C3%96lbr%C3%BCck"},
+	}
+	for _, tt := range tests {
+		if got := escapePath(tt.segments...); got != tt.want {
+			t.Errorf("escapePath(%q) = %q, want %q", tt.segments, got, tt.want)
+		}
+	}
+}
+
+func TestEnterpriseURL_EscapesSlug(t *testing.T) {
+	c := &Client{baseURL: "https://api.github.com", enterprise: "my ent"}
+	want := "https://api.github.com/enterprises/my%20ent/settings/billing/cost-centers"
+	if got := c.enterpriseURL("/settings/billing/cost-centers"); got != want {
+		t.Errorf("enterpriseURL = %q, want %q", got, want)
+	}
+}
+
+func TestPagedURL(t *testing.T) {
+	got := pagedURL("https://api.github.com/orgs/o/properties/values", 2, 100,
+		url.Values{"repository_query": {"topic:go archived:false"}})
+	want := "https://api.github.com/orgs/o/properties/values?page=2&per_page=100&repository_query=topic%3Ago+archived%3Afalse"
+	if got != want {
+		t.Errorf("pagedURL = %q, want %q", got, want)
+	}
+}
+
+func TestRequestURLs_EscapeSegments(t *testing.T) {
+	var paths []string
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		paths = append(paths, r.URL.EscapedPath())
+		queries = append(queries, r.URL.Query().Get("repository_query"))
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv.URL)
+
+	if _, err := c.GetOrgTeamMembers("my org", "front/end"); err != nil {
+		t.Fatalf("GetOrgTeamMembers: %v", err)
+	}
+	if _, err := c.GetOrgReposWithProperties("Ölbrück", "name:a&b"); err != nil {
+		t.Fatalf("GetOrgReposWithProperties: %v", err)
+	}
+	if _, err := c.GetRepoProperties("my org", "répo"); err != nil {
+		t.Fatalf("GetRepoProperties: %v", err)
+	}
+
+	wantPaths := []string{
+		"/orgs/my%20org/teams/front%2Fend/members",
+		"/orgs/%C3%96lbr%C3%BCck/properties/values",
+		"/repos/my%20org/r%C3%A9po/properties/values",
+	}
+	if len(paths) != len(wantPaths) {
+		t.Fatalf("paths = %v, want %v", paths, wantPaths)
+	}
+	for i, want := range wantPaths {
+		if paths[i] != want {
+			t.Errorf("path[%d] = %q, want %q", i, paths[i], want)
+		}
+	}
+	if queries[1] != "name:a&b" {
+		t.Errorf("repository_query = %q, want it decoded intact", queries[1])
+	}
+}
+
+func TestGetCopilotUsers_AssigningTeam(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"total_seats":2,"seats":[
+			{"assignee":{"login":"alice","id":1},"assigning_team":{"id":42,"name":"Justice League","slug":"justice-league","html_url":"https://github.com/orgs/octo/teams/justice-league"}},
+			{"assignee":{"login":"bob","id":2},"assigning_team":null}
+		]}`))
+	}))
+	defer srv.Close()
+
+	users, err := newTestClient(t, srv.URL).GetCopilotUsers()
+	if err != nil {
+		t.Fatalf("GetCopilotUsers: %v", err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("got %d users, want 2", len(users))
+	}
+	team := users[0].AssigningTeam
+	if team == nil || team.ID != 42 || team.Name != "Justice League" || team.Slug != "justice-league" {
+		t.Errorf("alice AssigningTeam = %+v", team)
+	}
+	if team != nil && team.Org() != "octo" {
+		t.Errorf("alice AssigningTeam.Org() = %q, want octo", team.Org())
+	}
+	if users[1].AssigningTeam != nil {
+		t.Errorf("bob AssigningTeam = %+v, want nil", users[1].AssigningTeam)
+	}
+}
+
+func TestGetEnterpriseTeamMembers_ResponseShapes(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"nested memberships", `[{"user":{"login":"alice","id":1}},{"user":{"login":"bob","id":2}}]`},
+		{"plain users (GHES)", `[{"login":"alice","id":1},{"login":"bob","id":2}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if !strings.HasSuffix(r.URL.Path, "/teams/platform/memberships") {
+					t.Errorf("path = %s", r.URL.Path)
+				}
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			members, err := newTestClient(t, srv.URL).GetEnterpriseTeamMembers("platform")
+			if err != nil {
+				t.Fatalf("GetEnterpriseTeamMembers: %v", err)
+			}
+			if len(members) != 2 {
+				t.Fatalf("got %d members, want 2", len(members))
+			}
+			if members[0].Login != "alice" || members[0].ID != 1 || members[1].Login != "bob" {
+				t.Errorf("members = %+v", members)
+			}
+		})
+	}
+}
+
+func TestAddReposToCostCenter_Batches(t *testing.T) {
+	const ccID = "11111111-2222-3333-4444-555555555555"
+	var batches []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/cost-centers/"+ccID+"/resource") {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string][]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if len(body) != 1 || body["repositories"] == nil {
+			t.Errorf("body = %v, want only repositories", body)
+		}
+		batches = append(batches, len(body["repositories"]))
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	repos := make([]string, 120)
+	for i := range repos {
+		repos[i] = fmt.Sprintf("org/repo-%03d", i)
+	}
+	results, err := newTestClient(t, srv.URL).AddReposToCostCenter(ccID, repos)
+	if err != nil {
+		t.Fatalf("AddReposToCostCenter: %v", err)
+	}
+	if fmt.Sprint(batches) != "[50 50 20]" {
+		t.Errorf("batch sizes = %v, want [50 50 20]", batches)
+	}
+	if len(results) != 120 || !results["org/repo-000"] || !results["org/repo-119"] {
+		t.Errorf("results: %d entries, want all 120 successful", len(results))
+	}
+}
+
+func TestRemoveOrgsFromCostCenter_MixedResults(t *testing.T) {
+	const ccID = "11111111-2222-3333-4444-555555555555"
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("method = %s, want DELETE", r.Method)
+		}
+		var body map[string][]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		calls++
+		// The second batch is rejected.
+		if slices.Contains(body["organizations"], "org-050") {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"message":"invalid organization"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	orgs := make([]string, 60)
+	for i := range orgs {
+		orgs[i] = fmt.Sprintf("org-%03d", i)
+	}
+	results, err := newTestClient(t, srv.URL).RemoveOrgsFromCostCenter(ccID, orgs)
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 batches failed") {
+		t.Errorf("err = %v, want one failed batch", err)
+	}
+	if calls != 2 {
+		t.Errorf("requests = %d, want 2 (a failed batch does not stop the rest)", calls)
+	}
+	if !results["org-000"] || !results["org-049"] || results["org-050"] || results["org-059"] || len(results) != 60 {
+		t.Errorf("results = %v, want the first batch true and the second false", results)
+	}
+}
+
+func TestCostCenterResourceMethods_KindsAndValidation(t *testing.T) {
+	const ccID = "11111111-2222-3333-4444-555555555555"
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string][]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		for kind := range body {
+			got = append(got, r.Method+" "+kind)
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv.URL)
+
+	if _, err := c.AddOrgsToCostCenter(ccID, []string{"org1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.RemoveReposFromCostCenter(ccID, []string{"org1/a"}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "POST organizations,DELETE repositories" {
+		t.Errorf("requests = %v", got)
+	}
+
+	if results, err := c.AddOrgsToCostCenter(ccID, nil); err != nil || len(results) != 0 || len(got) != 2 {
+		t.Errorf("empty list: results=%v err=%v, want no request", results, err)
+	}
+	if _, err := c.AddReposToCostCenter("not-a-uuid", []string{"org1/a"}); err == nil || !strings.Contains(err.Error(), "not a valid UUID") {
+		t.Errorf("invalid ID err = %v", err)
+	}
+}
+
+func TestProbe_NoRetriesAndTimeout(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch r.URL.Path {
+		case "/slow":
+			<-r.Context().Done()
+		case "/enterprises/test-ent/settings/billing/cost-centers":
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv.URL)
+
+	if status, err := c.ProbeEnterprise("/settings/billing/cost-centers", time.Second); err != nil || status != http.StatusOK {
+		t.Errorf("ProbeEnterprise = %d, %v; want 200", status, err)
+	}
+
+	calls.Store(0)
+	status, err := c.Probe("/flaky", time.Second)
+	var apiErr *APIError
+	if status != http.StatusBadGateway || !errors.As(err, &apiErr) || calls.Load() != 1 {
+		t.Errorf("Probe 502 = %d, %v after %d calls; want one call and an APIError", status, err, calls.Load())
+	}
+
+	start := time.Now()
+	if status, err := c.Probe("/slow", 50*time.Millisecond); status != 0 || err == nil {
+		t.Errorf("Probe slow = %d, %v; want a timeout", status, err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Probe took %s with a 50ms timeout", elapsed)
+	}
+}
+
+func TestDoJSON_AuthErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		headers  map[string]string
+		body     string
+		wantAuth bool
+		want     []string
+	}{
+		{
+			name: "401", status: http.StatusUnauthorized, body: `{"message":"Bad credentials"}`, wantAuth: true,
+			want: []string{"401", "invalid or expired", "gh auth refresh -h 127.0.0.1 -s manage_billing:enterprise,read:enterprise"},
+		},
+		{
+			name: "resource not accessible", status: http.StatusForbidden,
+			body: `{"message":"Resource not accessible by personal access token"}`, wantAuth: true,
+			want: []string{"403: Resource not accessible by personal access token", "needs the manage_billing:enterprise and read:enterprise scopes"},
+		},
+		{
+			name: "accepted scope missing", status: http.StatusForbidden,
+			headers: map[string]string{"X-OAuth-Scopes": "repo, read:org", "X-Accepted-OAuth-Scopes": "manage_billing:enterprise"},
+			body:    `{"message":"Must have admin rights"}`, wantAuth: true,
+			want: []string{"missing scopes: manage_billing:enterprise", "-s manage_billing:enterprise'"},
+		},
+		{
+			name: "scope held", status: http.StatusForbidden,
+			headers: map[string]string{"X-OAuth-Scopes": "admin:enterprise", "X-Accepted-OAuth-Scopes": "manage_billing:enterprise"},
+			body:    `{"message":"SSO required"}`,
+		},
+		{name: "other 403", status: http.StatusForbidden, body: `{"message":"SAML enforcement"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				for k, v := range tt.headers {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			_, err := newTestClient(t, srv.URL).doJSON(http.MethodGet, srv.URL+"/x", nil, nil)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Fatalf("err = %v, want it to unwrap to a %d APIError", err, tt.status)
+			}
+			var authErr *AuthError
+			if got := errors.As(err, &authErr); got != tt.wantAuth {
+				t.Fatalf("AuthError = %v, want %v (err %v)", got, tt.wantAuth, err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("err = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  string // X-OAuth-Scopes; "-" sends no header
+		status  int
+		missing []string
+	}{
+		{"all scopes", "manage_billing:enterprise, read:enterprise, read:org", http.StatusOK, nil},
+		{"admin implies both", "admin:enterprise", http.StatusOK, nil},
+		{"one missing", "manage_billing:enterprise, repo", http.StatusOK, []string{"read:enterprise"}},
+		{"no scopes", "", http.StatusOK, []string{"manage_billing:enterprise", "read:enterprise"}},
+		{"fine-grained token", "-", http.StatusOK, nil},
+		{"app installation token", "-", http.StatusForbidden, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/user" {
+					t.Errorf("path = %s, want /user", r.URL.Path)
+				}
+				if tt.scopes != "-" {
+					w.Header().Set("X-OAuth-Scopes", tt.scopes)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"message":"Resource not accessible by integration"}`))
+			}))
+			defer srv.Close()
+
+			err := newTestClient(t, srv.URL).CheckScopes(time.Second)
+			if tt.missing == nil {
+				if err != nil {
+					t.Errorf("CheckScopes: %v, want nil", err)
+				}
+				return
+			}
+			var authErr *AuthError
+			if !errors.As(err, &authErr) || !slices.Equal(authErr.Missing, tt.missing) {
+				t.Fatalf("err = %v, want an AuthError missing %v", err, tt.missing)
+			}
+			if want := "gh auth refresh -h 127.0.0.1 -s " + strings.Join(tt.missing, ","); !strings.Contains(err.Error(), want) {
+				t.Errorf("err = %q, want it to contain %q", err, want)
+			}
+		})
+	}
+}
+
+func TestGHHost(t *testing.T) {
+	for base, want := range map[string]string{
+		"https://api.github.com":            "",
+		"https://api.octocorp.ghe.com":      "octocorp.ghe.com",
+		"https://ghes.example.com/api/v3":   "ghes.example.com",
+		"https://ghes.example.com:8443/api": "ghes.example.com",
+	} {
+		if got := ghHost(base); got != want {
+			t.Errorf("ghHost(%q) = %q, want %q", base, got, want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Probe sends a single GET request to path (relative to the API base URL,
+// already escaped) without retries or rate-limit waits, giving up after
+// timeout.  It returns the response status; a non-2xx status is also
+// returned as an *APIError.  A zero status means no response was received.
+func (c *Client) Probe(path string, timeout time.Duration) (int, error) {
+	return c.probeURL(c.baseURL+path, timeout)
+}
+
+// ProbeEnterprise is Probe for an enterprise-scoped path such as
+// "/settings/billing/cost-centers".
+func (c *Client) ProbeEnterprise(path string, timeout time.Duration) (int, error) {
+	return c.probeURL(c.enterpriseURL(path), timeout)
+}
+
+func (c *Client) probeURL(reqURL string, timeout time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	resp, err := c.doContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	body := readBody(resp)
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, &APIError{StatusCode: resp.StatusCode, Body: body}
+	}
+	return resp.StatusCode, nil
+}
@@ -3,6 +3,9 @@ package github
 import (
 	"fmt"
 	"net/http"
+	"net/url"
+
+	"github.com/renan-alm/gh-cost-center/internal/cache"
 )
 
 // RepoProperties represents a repository with its custom property values.
@@ -30,15 +33,26 @@ type PropertyDefinition struct {
 }
 
 // GetOrgPropertySchema returns all custom property definitions for the given
-// organization.
+// organization, from the cache when one is attached and holds a valid copy.
 func (c *Client) GetOrgPropertySchema(org string) ([]PropertyDefinition, error) {
+	if c.ccCache != nil {
+		var cached []PropertyDefinition
+		if c.ccCache.GetJSON(cache.KindPropertySchemas, org, &cached) {
+			c.log.Debug("Custom property schema loaded from cache", "org", org, "count", len(cached))
+			return cached, nil
+		}
+	}
+
 	c.log.Info("Fetching custom property schema", "org", org)
-	url := fmt.Sprintf("%s/orgs/%s/properties/schema", c.baseURL, org)
+	reqURL := c.baseURL + escapePath("orgs", org, "properties", "schema")
 
 	var defs []PropertyDefinition
-	if _, err := c.doJSON(http.MethodGet, url, nil, &defs); err != nil {
+	if _, err := c.doJSON(http.MethodGet, reqURL, nil, &defs); err != nil {
 		return nil, fmt.Errorf("fetching property schema for org %s: %w", org, err)
 	}
+	if c.ccCache != nil {
+		_ = c.ccCache.SetJSON(cache.KindPropertySchemas, org, defs)
+	}
 	c.log.Info("Custom properties defined", "org", org, "count", len(defs))
 	return defs, nil
 }
@@ -48,17 +62,18 @@ func (c *Client) GetOrgPropertySchema(org string) ([]PropertyDefinition, error)
 // query string (GitHub search syntax) narrows the results.
 func (c *Client) GetOrgReposWithProperties(org string, query string) ([]RepoProperties, error) {
 	c.log.Info("Fetching repositories with custom properties", "org", org)
-	baseURL := fmt.Sprintf("%s/orgs/%s/properties/values", c.baseURL, org)
+	baseURL := c.baseURL + escapePath("orgs", org, "properties", "values")
 
 	var allRepos []RepoProperties
 	page := 1
 	const perPage = 100
 
 	for {
-		pageURL := fmt.Sprintf("%s?page=%d&per_page=%d", baseURL, page, perPage)
+		var extra url.Values
 		if query != "" {
-			pageURL += "&repository_query=" + query
+			extra = url.Values{"repository_query": {query}}
 		}
+		pageURL := pagedURL(baseURL, page, perPage, extra)
 
 		var repos []RepoProperties
 		if _, err := c.doJSON(http.MethodGet, pageURL, nil, &repos); err != nil {
@@ -79,13 +94,47 @@ func (c *Client) GetOrgReposWithProperties(org string, query string) ([]RepoProp
 	return allRepos, nil
 }
 
+// RepoStatus is the archived/disabled state of a repository, which the
+// custom property endpoints do not report.
+type RepoStatus struct {
+	FullName string `json:"full_name"`
+	Archived bool   `json:"archived"`
+	Disabled bool   `json:"disabled"`
+}
+
+// GetOrgRepoStatuses returns the archived/disabled state of every repository
+// in the given organization, handling pagination.
+func (c *Client) GetOrgRepoStatuses(org string) ([]RepoStatus, error) {
+	c.log.Info("Fetching repository states", "org", org)
+	baseURL := c.baseURL + escapePath("orgs", org, "repos")
+
+	var all []RepoStatus
+	page := 1
+	const perPage = 100
+
+	for {
+		pageURL := pagedURL(baseURL, page, perPage, url.Values{"type": {"all"}})
+
+		var repos []RepoStatus
+		if _, err := c.doJSON(http.MethodGet, pageURL, nil, &repos); err != nil {
+			return nil, fmt.Errorf("fetching repositories for org %s page %d: %w", org, page, err)
+		}
+		all = append(all, repos...)
+		if len(repos) < perPage {
+			break
+		}
+		page++
+	}
+	return all, nil
+}
+
 // GetRepoProperties returns custom property values for a specific repository.
 func (c *Client) GetRepoProperties(owner, repo string) ([]Property, error) {
 	c.log.Debug("Fetching custom properties for repository", "repo", owner+"/"+repo)
-	url := fmt.Sprintf("%s/repos/%s/%s/properties/values", c.baseURL, owner, repo)
+	reqURL := c.baseURL + escapePath("repos", owner, repo, "properties", "values")
 
 	var props []Property
-	if _, err := c.doJSON(http.MethodGet, url, nil, &props); err != nil {
+	if _, err := c.doJSON(http.MethodGet, reqURL, nil, &props); err != nil {
 		return nil, fmt.Errorf("fetching properties for %s/%s: %w", owner, repo, err)
 	}
 	return props, nil
@@ -22,18 +22,34 @@ type TeamMember struct {
 	Type  string `json:"type"`
 }
 
+// enterpriseTeamMembership is one entry of the enterprise team memberships
+// response, which nests the member under "user".  Some servers (GHES) return
+// plain user objects instead; those decode into the embedded TeamMember.
+type enterpriseTeamMembership struct {
+	TeamMember
+	User *TeamMember `json:"user"`
+}
+
+// member returns the team member from either response shape.
+func (m enterpriseTeamMembership) member() TeamMember {
+	if m.User != nil && m.User.Login != "" {
+		return *m.User
+	}
+	return m.TeamMember
+}
+
 // GetOrgTeams returns all teams for the given organization, handling
 // pagination automatically.
 func (c *Client) GetOrgTeams(org string) ([]Team, error) {
 	c.log.Info("Fetching teams for organization", "org", org)
-	baseURL := fmt.Sprintf("%s/orgs/%s/teams", c.baseURL, org)
+	baseURL := c.baseURL + escapePath("orgs", org, "teams")
 
 	var allTeams []Team
 	page := 1
 	const perPage = 100
 
 	for {
-		pageURL := fmt.Sprintf("%s?page=%d&per_page=%d", baseURL, page, perPage)
+		pageURL := pagedURL(baseURL, page, perPage, nil)
 		var teams []Team
 		if _, err := c.doJSON(http.MethodGet, pageURL, nil, &teams); err != nil {
 			return nil, fmt.Errorf("fetching teams for org %s page %d: %w", org, page, err)
@@ -57,14 +73,14 @@ func (c *Client) GetOrgTeams(org string) ([]Team, error) {
 // handling pagination automatically.
 func (c *Client) GetOrgTeamMembers(org, teamSlug string) ([]TeamMember, error) {
 	c.log.Debug("Fetching members for team", "org", org, "team", teamSlug)
-	baseURL := fmt.Sprintf("%s/orgs/%s/teams/%s/members", c.baseURL, org, teamSlug)
+	baseURL := c.baseURL + escapePath("orgs", org, "teams", teamSlug, "members")
 
 	var allMembers []TeamMember
 	page := 1
 	const perPage = 100
 
 	for {
-		pageURL := fmt.Sprintf("%s?page=%d&per_page=%d", baseURL, page, perPage)
+		pageURL := pagedURL(baseURL, page, perPage, nil)
 		var members []TeamMember
 		if _, err := c.doJSON(http.MethodGet, pageURL, nil, &members); err != nil {
 			return nil, fmt.Errorf("fetching members for team %s/%s page %d: %w", org, teamSlug, page, err)
@@ -96,7 +112,7 @@ func (c *Client) GetEnterpriseTeams() ([]Team, error) {
 	const perPage = 100
 
 	for {
-		pageURL := fmt.Sprintf("%s?page=%d&per_page=%d", baseURL, page, perPage)
+		pageURL := pagedURL(baseURL, page, perPage, nil)
 		var teams []Team
 		if _, err := c.doJSON(http.MethodGet, pageURL, nil, &teams); err != nil {
 			return nil, fmt.Errorf("fetching enterprise teams page %d: %w", page, err)
@@ -120,22 +136,24 @@ func (c *Client) GetEnterpriseTeams() ([]Team, error) {
 // team, handling pagination automatically.
 func (c *Client) GetEnterpriseTeamMembers(teamSlug string) ([]TeamMember, error) {
 	c.log.Debug("Fetching members for enterprise team", "team", teamSlug)
-	baseURL := c.enterpriseURL(fmt.Sprintf("/teams/%s/memberships", teamSlug))
+	baseURL := c.enterpriseURL(escapePath("teams", teamSlug, "memberships"))
 
 	var allMembers []TeamMember
 	page := 1
 	const perPage = 100
 
 	for {
-		pageURL := fmt.Sprintf("%s?page=%d&per_page=%d", baseURL, page, perPage)
-		var members []TeamMember
+		pageURL := pagedURL(baseURL, page, perPage, nil)
+		var members []enterpriseTeamMembership
 		if _, err := c.doJSON(http.MethodGet, pageURL, nil, &members); err != nil {
 			return nil, fmt.Errorf("fetching enterprise team %s members page %d: %w", teamSlug, page, err)
 		}
 		if len(members) == 0 {
 			break
 		}
-		allMembers = append(allMembers, members...)
+		for _, m := range members {
+			allMembers = append(allMembers, m.member())
+		}
 		c.log.Debug("Fetched enterprise team members page",
 			"team", teamSlug, "page", page, "count", len(members))
 		if len(members) < perPage {
@@ -0,0 +1,109 @@
+package github
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+)
+
+// Option customizes the HTTP stack of a Client built by NewClient.
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	httpClient *http.Client
+	middleware []func(http.RoundTripper) http.RoundTripper
+
+	// base, when set, replaces http.DefaultTransport as the innermost
+	// transport; NewClient sets it from the TLS settings of the config.
+	base http.RoundTripper
+}
+
+// WithHTTPClient makes the client send its requests through a copy of hc
+// instead of a default http.Client.  hc's Transport, or the default transport
+// when nil, is the innermost transport; hc itself is not modified.  The
+// github.ca_cert_path and github.insecure_skip_verify settings only apply to
+// the default transport.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(o *clientOptions) { o.httpClient = hc }
+}
+
+// WithTransportMiddleware wraps the transport of the client with mw, for
+// tracing or metrics.  mw sees every request sent, retries included, with
+// the Accept, User-Agent, API version, and Authorization headers already
+// set.  Given several times, the first middleware is the outermost.
+func WithTransportMiddleware(mw func(http.RoundTripper) http.RoundTripper) Option {
+	return func(o *clientOptions) { o.middleware = append(o.middleware, mw) }
+}
+
+// newHTTPClient returns the http.Client of a Client authenticating with
+// token: the middleware of o around the base transport, behind a
+// headerTransport.
+func newHTTPClient(token string, o clientOptions) *http.Client {
+	hc := &http.Client{Timeout: config.DefaultHTTPTimeout}
+	if o.httpClient != nil {
+		custom := *o.httpClient
+		hc = &custom
+	}
+	rt := hc.Transport
+	if rt == nil {
+		rt = o.base
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	for i := len(o.middleware) - 1; i >= 0; i-- {
+		rt = o.middleware[i](rt)
+	}
+	hc.Transport = &headerTransport{next: rt, token: token}
+	return hc
+}
+
+// headerTransport sets the headers every GitHub API request carries on a
+// copy of the request before passing it to next.
+type headerTransport struct {
+	next  http.RoundTripper
+	token string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept", acceptHeader)
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("X-GitHub-Api-Version", apiVersion)
+	if t.token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// tlsTransport returns a clone of http.DefaultTransport that trusts the
+// certificates of the PEM bundle caCertPath as well as the system roots and,
+// when insecure, skips certificate verification.  It returns nil when
+// neither is set.
+func tlsTransport(caCertPath string, insecure bool) (http.RoundTripper, error) {
+	if caCertPath == "" && !insecure {
+		return nil, nil
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	if caCertPath != "" {
+		data, err := os.ReadFile(caCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("CA bundle %s holds no PEM certificates", caCertPath)
+		}
+		tr.TLSClientConfig.RootCAs = pool
+	}
+	tr.TLSClientConfig.InsecureSkipVerify = insecure
+	return tr, nil
+}
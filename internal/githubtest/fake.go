@@ -0,0 +1,645 @@
+// Package githubtest provides Fake, an in-memory github.CostCenterAPI for
+// tests of the managers and commands that should not need an HTTP server.
+//
+// A Fake holds one enterprise: its Copilot seats, cost centers with their
+// user and repository resources, budgets, teams, and repositories.  Tests
+// fill the exported fields (or use the helpers) before the run and inspect
+// them, or Calls, afterwards.  Failures are injected per method with Fail.
+package githubtest
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+// Call is one mutating call made on a Fake.
+type Call struct {
+	Method string
+	Target string   // cost center or budget ID, or a cost center name
+	Items  []string // users or repositories, when the call has any
+}
+
+// Fake is an in-memory github.CostCenterAPI.  It is safe for concurrent use.
+type Fake struct {
+	mu sync.Mutex
+
+	Seats       []github.CopilotUser
+	CostCenters map[string]*github.CostCenterDetail // by ID
+	Budgets     []github.Budget
+
+	OrgTeams              map[string][]github.Team       // by org
+	OrgTeamMembers        map[string][]github.TeamMember // by "org/slug"
+	EnterpriseTeams       []github.Team
+	EnterpriseTeamMembers map[string][]github.TeamMember // by slug
+
+	PropertySchemas map[string][]github.PropertyDefinition // by org
+	OrgRepos        map[string][]github.RepoProperties     // by org
+	RepoStatuses    map[string][]github.RepoStatus         // by org
+
+	// Calls lists the mutating calls in the order they were made.
+	Calls []Call
+
+	errs   map[string]error
+	nextID int
+}
+
+var _ github.CostCenterAPI = (*Fake)(nil)
+
+// New returns an empty Fake.
+func New() *Fake {
+	return &Fake{
+		CostCenters:           map[string]*github.CostCenterDetail{},
+		OrgTeams:              map[string][]github.Team{},
+		OrgTeamMembers:        map[string][]github.TeamMember{},
+		EnterpriseTeamMembers: map[string][]github.TeamMember{},
+		PropertySchemas:       map[string][]github.PropertyDefinition{},
+		OrgRepos:              map[string][]github.RepoProperties{},
+		RepoStatuses:          map[string][]github.RepoStatus{},
+		errs:                  map[string]error{},
+	}
+}
+
+// Fail makes every later call of the method with the given name, such as
+// "RemoveUsersFromCostCenter", return err.  A nil err clears the failure.
+func (f *Fake) Fail(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, method)
+		return
+	}
+	f.errs[method] = err
+}
+
+// AddSeats gives the logins a Copilot seat.
+func (f *Fake) AddSeats(logins ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, login := range logins {
+		f.Seats = append(f.Seats, github.CopilotUser{Login: login, Type: "User"})
+	}
+}
+
+// AddCostCenter creates an active cost center holding users and returns its
+// ID.
+func (f *Fake) AddCostCenter(name string, users ...string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cc := f.create(name)
+	for _, u := range users {
+		cc.Resources = append(cc.Resources, github.Resource{Type: "User", Name: u})
+	}
+	return cc.ID
+}
+
+// Users returns the users of cost center id, sorted.
+func (f *Fake) Users(id string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.resources(id, "User")
+}
+
+// create adds an active cost center with a fresh UUID.  f.mu must be held.
+func (f *Fake) create(name string) *github.CostCenterDetail {
+	f.nextID++
+	id := fmt.Sprintf("00000000-0000-4000-8000-%012d", f.nextID)
+	cc := &github.CostCenterDetail{ID: id, Name: name, State: "active"}
+	f.CostCenters[id] = cc
+	return cc
+}
+
+// fail returns the error injected for method.  f.mu must be held.
+func (f *Fake) fail(method string) error {
+	return f.errs[method]
+}
+
+// record appends a mutating call.  f.mu must be held.
+func (f *Fake) record(method, target string, items []string) {
+	f.Calls = append(f.Calls, Call{Method: method, Target: target, Items: slices.Clone(items)})
+}
+
+// notFound is the error of a lookup of a cost center the Fake does not hold.
+func notFound(id string) error {
+	return &github.CostCenterNotFoundError{ID: id, Err: &github.APIError{StatusCode: http.StatusNotFound, Body: `{"message":"Not Found"}`}}
+}
+
+// active returns name -> ID of the active cost centers.  f.mu must be held.
+func (f *Fake) active() map[string]string {
+	out := make(map[string]string, len(f.CostCenters))
+	for id, cc := range f.CostCenters {
+		if cc.State == "" || cc.State == "active" {
+			out[cc.Name] = id
+		}
+	}
+	return out
+}
+
+// resources returns the sorted names of the resources of type typ attached
+// to cost center id.  f.mu must be held.
+func (f *Fake) resources(id, typ string) []string {
+	cc, ok := f.CostCenters[id]
+	if !ok {
+		return nil
+	}
+	names := cc.ResourceNames(typ)
+	sort.Strings(names)
+	return names
+}
+
+// attach adds (or, with remove, detaches) names as resources of type typ and
+// reports every name as successful.  f.mu must be held.
+func (f *Fake) attach(id, typ string, names []string, remove bool) (map[string]bool, error) {
+	cc, ok := f.CostCenters[id]
+	if !ok {
+		return nil, notFound(id)
+	}
+	results := make(map[string]bool, len(names))
+	for _, name := range names {
+		results[name] = true
+		i := slices.IndexFunc(cc.Resources, func(r github.Resource) bool { return r.Type == typ && r.Name == name })
+		switch {
+		case remove && i >= 0:
+			cc.Resources = slices.Delete(cc.Resources, i, i+1)
+		case !remove && i < 0:
+			cc.Resources = append(cc.Resources, github.Resource{Type: typ, Name: name})
+		}
+	}
+	return results, nil
+}
+
+// GetCopilotUsers returns the Seats.
+func (f *Fake) GetCopilotUsers() ([]github.CopilotUser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetCopilotUsers"); err != nil {
+		return nil, err
+	}
+	return slices.Clone(f.Seats), nil
+}
+
+// GetAllActiveCostCenters returns name -> ID of the active cost centers.
+func (f *Fake) GetAllActiveCostCenters() (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetAllActiveCostCenters"); err != nil {
+		return nil, err
+	}
+	return f.active(), nil
+}
+
+// GetAllActiveCostCentersWithMembers returns the active cost centers and the
+// users of each, by ID.
+func (f *Fake) GetAllActiveCostCentersWithMembers(int) (map[string]string, map[string][]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetAllActiveCostCentersWithMembers"); err != nil {
+		return nil, nil, err
+	}
+	active := f.active()
+	members := make(map[string][]string, len(active))
+	for _, id := range active {
+		members[id] = f.resources(id, "User")
+	}
+	return active, members, nil
+}
+
+// GetCostCenter returns a copy of cost center id.
+func (f *Fake) GetCostCenter(id string) (*github.CostCenterDetail, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetCostCenter"); err != nil {
+		return nil, err
+	}
+	cc, ok := f.CostCenters[id]
+	if !ok {
+		return nil, notFound(id)
+	}
+	detail := *cc
+	detail.Resources = slices.Clone(cc.Resources)
+	return &detail, nil
+}
+
+// CreateCostCenter returns the ID of the active cost center called name,
+// creating it when there is none.
+func (f *Fake) CreateCostCenter(name string) (string, error) {
+	id, _, err := f.EnsureCostCenter(name)
+	return id, err
+}
+
+// CreateCostCenterWithPreload is CreateCostCenter that first looks name up
+// in activeMap and records the ID there.
+func (f *Fake) CreateCostCenterWithPreload(name string, activeMap map[string]string) (string, error) {
+	if id, ok := activeMap[name]; ok {
+		return id, nil
+	}
+	id, err := f.CreateCostCenter(name)
+	if err != nil {
+		return "", err
+	}
+	activeMap[name] = id
+	return id, nil
+}
+
+// EnsureCostCenter is CreateCostCenter reporting whether the cost center is
+// new.
+func (f *Fake) EnsureCostCenter(name string) (string, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("CreateCostCenter"); err != nil {
+		return "", false, err
+	}
+	if id, ok := f.active()[name]; ok {
+		return id, false, nil
+	}
+	cc := f.create(name)
+	f.record("CreateCostCenter", name, nil)
+	return cc.ID, true, nil
+}
+
+// UpdateCostCenter renames cost center id.
+func (f *Fake) UpdateCostCenter(id, newName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("UpdateCostCenter"); err != nil {
+		return err
+	}
+	cc, ok := f.CostCenters[id]
+	if !ok {
+		return notFound(id)
+	}
+	cc.Name = newName
+	f.record("UpdateCostCenter", id, []string{newName})
+	return nil
+}
+
+// DeleteCostCenter deletes cost center id.
+func (f *Fake) DeleteCostCenter(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("DeleteCostCenter"); err != nil {
+		return err
+	}
+	if _, ok := f.CostCenters[id]; !ok {
+		return notFound(id)
+	}
+	delete(f.CostCenters, id)
+	f.record("DeleteCostCenter", id, nil)
+	return nil
+}
+
+// ResolveCostCenters returns the IDs of two active cost centers by name.
+func (f *Fake) ResolveCostCenters(noPRUName, pruAllowedName string) (string, string, error) {
+	active, err := f.GetAllActiveCostCenters()
+	if err != nil {
+		return "", "", err
+	}
+	var missing []string
+	for _, name := range []string{noPRUName, pruAllowedName} {
+		if _, ok := active[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", "", fmt.Errorf("cost center(s) not found: %s", strings.Join(missing, ", "))
+	}
+	return active[noPRUName], active[pruAllowedName], nil
+}
+
+// GetCostCenterUsers returns the users of cost center id, sorted.
+func (f *Fake) GetCostCenterUsers(id string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetCostCenterUsers"); err != nil {
+		return nil, err
+	}
+	if _, ok := f.CostCenters[id]; !ok {
+		return nil, notFound(id)
+	}
+	return f.resources(id, "User"), nil
+}
+
+// GetCostCentersUsers returns the users of each cost center in ids.
+func (f *Fake) GetCostCentersUsers(ids []string, _ int) (map[string][]string, error) {
+	out := make(map[string][]string, len(ids))
+	for _, id := range ids {
+		users, err := f.GetCostCenterUsers(id)
+		if err != nil {
+			return nil, err
+		}
+		out[id] = users
+	}
+	return out, nil
+}
+
+// CheckUserCostCenterMembership returns the cost center holding username,
+// or nil.
+func (f *Fake) CheckUserCostCenterMembership(username string) (*github.CostCenterRef, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("CheckUserCostCenterMembership"); err != nil {
+		return nil, err
+	}
+	return f.membership(username), nil
+}
+
+// membership returns the cost center, lowest ID first, holding username.
+// f.mu must be held.
+func (f *Fake) membership(username string) *github.CostCenterRef {
+	ids := make([]string, 0, len(f.CostCenters))
+	for id := range f.CostCenters {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if slices.Contains(f.resources(id, "User"), username) {
+			return &github.CostCenterRef{ID: id, Name: f.CostCenters[id].Name}
+		}
+	}
+	return nil
+}
+
+// AddUsersToCostCenter adds usernames to cost center costCenterID.  Unless
+// ignoreCurrentCC is set, users in another cost center are skipped and
+// reported as failed, as the API client does.
+func (f *Fake) AddUsersToCostCenter(costCenterID string, usernames []string, ignoreCurrentCC bool) (map[string]bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("AddUsersToCostCenter"); err != nil {
+		return nil, err
+	}
+	if _, ok := f.CostCenters[costCenterID]; !ok {
+		return nil, notFound(costCenterID)
+	}
+	current := f.resources(costCenterID, "User")
+	results := make(map[string]bool, len(usernames))
+	var add []string
+	for _, u := range usernames {
+		if slices.Contains(current, u) {
+			results[u] = true
+			continue
+		}
+		if !ignoreCurrentCC && f.membership(u) != nil {
+			results[u] = false
+			continue
+		}
+		add = append(add, u)
+	}
+	if len(add) > 0 {
+		added, _ := f.attach(costCenterID, "User", add, false)
+		for u, ok := range added {
+			results[u] = ok
+		}
+		f.record("AddUsersToCostCenter", costCenterID, add)
+	}
+	return results, nil
+}
+
+// BulkUpdateCostCenterAssignments calls AddUsersToCostCenter for every cost
+// center; a failing cost center marks all of its users failed.
+func (f *Fake) BulkUpdateCostCenterAssignments(assignments map[string][]string, ignoreCurrentCC bool) (map[string]map[string]bool, error) {
+	results := make(map[string]map[string]bool, len(assignments))
+	for id, usernames := range assignments {
+		if len(usernames) == 0 {
+			continue
+		}
+		ccResults, err := f.AddUsersToCostCenter(id, usernames, ignoreCurrentCC)
+		if err != nil {
+			ccResults = make(map[string]bool, len(usernames))
+			for _, u := range usernames {
+				ccResults[u] = false
+			}
+		}
+		results[id] = ccResults
+	}
+	return results, nil
+}
+
+// RemoveUsersFromCostCenter removes usernames from cost center costCenterID.
+func (f *Fake) RemoveUsersFromCostCenter(costCenterID string, usernames []string) (map[string]bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(usernames) == 0 {
+		return map[string]bool{}, nil
+	}
+	if err := f.fail("RemoveUsersFromCostCenter"); err != nil {
+		results := make(map[string]bool, len(usernames))
+		for _, u := range usernames {
+			results[u] = false
+		}
+		return results, err
+	}
+	results, err := f.attach(costCenterID, "User", usernames, true)
+	if err == nil {
+		f.record("RemoveUsersFromCostCenter", costCenterID, usernames)
+	}
+	return results, err
+}
+
+// ListBudgets returns the Budgets.
+func (f *Fake) ListBudgets() ([]github.Budget, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("ListBudgets"); err != nil {
+		return nil, err
+	}
+	return slices.Clone(f.Budgets), nil
+}
+
+// productBudget returns the index of the budget of a cost center, matched by
+// ID or name, and product, or -1.  f.mu must be held.
+func (f *Fake) productBudget(costCenterID, costCenterName, product string) int {
+	_, sku := github.GetBudgetTypeAndSKU(product)
+	return slices.IndexFunc(f.Budgets, func(b github.Budget) bool {
+		return b.BudgetScope == "cost_center" &&
+			(b.BudgetEntityName == costCenterID || b.BudgetEntityName == costCenterName) &&
+			b.BudgetProductSKU == sku
+	})
+}
+
+// CheckCostCenterHasProductBudget reports whether the cost center has a
+// budget for product.  Like the client, it fails with ListBudgets.
+func (f *Fake) CheckCostCenterHasProductBudget(costCenterID, costCenterName, product string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("ListBudgets"); err != nil {
+		return false, err
+	}
+	return f.productBudget(costCenterID, costCenterName, product) >= 0, nil
+}
+
+// CreateProductBudget adds a budget for product unless the cost center
+// already has one.
+func (f *Fake) CreateProductBudget(costCenterID, costCenterName, product string, amount int, alerting github.BudgetAlerting) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("CreateProductBudget"); err != nil {
+		return false, err
+	}
+	if f.productBudget(costCenterID, costCenterName, product) >= 0 {
+		return true, nil
+	}
+	budgetType, sku := github.GetBudgetTypeAndSKU(product)
+	f.nextID++
+	f.Budgets = append(f.Budgets, github.Budget{
+		ID:               fmt.Sprintf("budget-%d", f.nextID),
+		BudgetType:       budgetType,
+		BudgetProductSKU: sku,
+		BudgetScope:      "cost_center",
+		BudgetAmount:     amount,
+		BudgetEntityName: costCenterName,
+		BudgetAlerting:   alerting,
+	})
+	f.record("CreateProductBudget", costCenterID, []string{product})
+	return true, nil
+}
+
+// UpdateBudget changes the amount and hard stop of budget budgetID.
+func (f *Fake) UpdateBudget(budgetID string, amount int, preventFurtherUsage bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("UpdateBudget"); err != nil {
+		return err
+	}
+	i := slices.IndexFunc(f.Budgets, func(b github.Budget) bool { return b.ID == budgetID })
+	if i < 0 {
+		return fmt.Errorf("updating budget %s: budget not found", budgetID)
+	}
+	f.Budgets[i].BudgetAmount = amount
+	f.Budgets[i].PreventFurtherUsage = preventFurtherUsage
+	f.record("UpdateBudget", budgetID, nil)
+	return nil
+}
+
+// DeleteBudget deletes budget budgetID.
+func (f *Fake) DeleteBudget(budgetID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("DeleteBudget"); err != nil {
+		return err
+	}
+	f.Budgets = slices.DeleteFunc(f.Budgets, func(b github.Budget) bool { return b.ID == budgetID })
+	f.record("DeleteBudget", budgetID, nil)
+	return nil
+}
+
+// GetOrgTeams returns the OrgTeams of org.
+func (f *Fake) GetOrgTeams(org string) ([]github.Team, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetOrgTeams"); err != nil {
+		return nil, err
+	}
+	return slices.Clone(f.OrgTeams[org]), nil
+}
+
+// GetOrgTeamMembers returns the OrgTeamMembers of org/teamSlug.
+func (f *Fake) GetOrgTeamMembers(org, teamSlug string) ([]github.TeamMember, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetOrgTeamMembers"); err != nil {
+		return nil, err
+	}
+	return slices.Clone(f.OrgTeamMembers[org+"/"+teamSlug]), nil
+}
+
+// GetEnterpriseTeams returns the EnterpriseTeams.
+func (f *Fake) GetEnterpriseTeams() ([]github.Team, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetEnterpriseTeams"); err != nil {
+		return nil, err
+	}
+	return slices.Clone(f.EnterpriseTeams), nil
+}
+
+// GetEnterpriseTeamMembers returns the EnterpriseTeamMembers of teamSlug.
+func (f *Fake) GetEnterpriseTeamMembers(teamSlug string) ([]github.TeamMember, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetEnterpriseTeamMembers"); err != nil {
+		return nil, err
+	}
+	return slices.Clone(f.EnterpriseTeamMembers[teamSlug]), nil
+}
+
+// GetOrgPropertySchema returns the PropertySchemas of org.
+func (f *Fake) GetOrgPropertySchema(org string) ([]github.PropertyDefinition, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetOrgPropertySchema"); err != nil {
+		return nil, err
+	}
+	return slices.Clone(f.PropertySchemas[org]), nil
+}
+
+// GetOrgReposWithProperties returns the OrgRepos of org.  The query is not
+// evaluated.
+func (f *Fake) GetOrgReposWithProperties(org string, _ string) ([]github.RepoProperties, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetOrgReposWithProperties"); err != nil {
+		return nil, err
+	}
+	return slices.Clone(f.OrgRepos[org]), nil
+}
+
+// GetOrgRepoStatuses returns the RepoStatuses of org.
+func (f *Fake) GetOrgRepoStatuses(org string) ([]github.RepoStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetOrgRepoStatuses"); err != nil {
+		return nil, err
+	}
+	return slices.Clone(f.RepoStatuses[org]), nil
+}
+
+// GetCostCenterRepos returns the repositories of cost center id, sorted.
+func (f *Fake) GetCostCenterRepos(id string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetCostCenterRepos"); err != nil {
+		return nil, err
+	}
+	if _, ok := f.CostCenters[id]; !ok {
+		return nil, notFound(id)
+	}
+	return f.resources(id, "Repository"), nil
+}
+
+// AddReposToCostCenter attaches repositories to cost center costCenterID.
+func (f *Fake) AddReposToCostCenter(costCenterID string, repoFullNames []string) (map[string]bool, error) {
+	return f.updateRepos("AddReposToCostCenter", costCenterID, repoFullNames, false)
+}
+
+// RemoveReposFromCostCenter detaches repositories from cost center
+// costCenterID.
+func (f *Fake) RemoveReposFromCostCenter(costCenterID string, repoFullNames []string) (map[string]bool, error) {
+	return f.updateRepos("RemoveReposFromCostCenter", costCenterID, repoFullNames, true)
+}
+
+// updateRepos implements the repository resource methods.
+func (f *Fake) updateRepos(method, costCenterID string, names []string, remove bool) (map[string]bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(names) == 0 {
+		return map[string]bool{}, nil
+	}
+	if err := f.fail(method); err != nil {
+		results := make(map[string]bool, len(names))
+		for _, name := range names {
+			results[name] = false
+		}
+		return results, err
+	}
+	results, err := f.attach(costCenterID, "Repository", names, remove)
+	if err == nil {
+		f.record(method, costCenterID, names)
+	}
+	return results, err
+}
@@ -0,0 +1,95 @@
+package githubtest
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+func TestFake_AssignmentSemantics(t *testing.T) {
+	f := New()
+	noPRU := f.AddCostCenter("No PRUs", "alice")
+	pru := f.AddCostCenter("PRUs Allowed", "bob")
+	if !github.IsValidCostCenterUUID(noPRU) || noPRU == pru {
+		t.Fatalf("IDs %q and %q, want two distinct UUIDs", noPRU, pru)
+	}
+
+	// bob is in another cost center: skipped unless ignoreCurrentCC.
+	results, err := f.AddUsersToCostCenter(noPRU, []string{"alice", "bob", "carol"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results["alice"] || results["bob"] || !results["carol"] {
+		t.Errorf("results = %v, want alice and carol only", results)
+	}
+	if got := f.Users(noPRU); !slices.Equal(got, []string{"alice", "carol"}) {
+		t.Errorf("No PRUs = %v", got)
+	}
+	if _, err := f.AddUsersToCostCenter(noPRU, []string{"bob"}, true); err != nil || !slices.Contains(f.Users(noPRU), "bob") {
+		t.Errorf("ignoreCurrentCC: err %v, users %v, want bob added", err, f.Users(noPRU))
+	}
+
+	if _, err := f.RemoveUsersFromCostCenter(pru, []string{"bob"}); err != nil || len(f.Users(pru)) != 0 {
+		t.Errorf("remove: err %v, users %v", err, f.Users(pru))
+	}
+	if ref, _ := f.CheckUserCostCenterMembership("carol"); ref == nil || ref.ID != noPRU {
+		t.Errorf("membership of carol = %+v, want %s", ref, noPRU)
+	}
+
+	want := []Call{
+		{Method: "AddUsersToCostCenter", Target: noPRU, Items: []string{"carol"}},
+		{Method: "AddUsersToCostCenter", Target: noPRU, Items: []string{"bob"}},
+		{Method: "RemoveUsersFromCostCenter", Target: pru, Items: []string{"bob"}},
+	}
+	if !slices.EqualFunc(f.Calls, want, func(a, b Call) bool {
+		return a.Method == b.Method && a.Target == b.Target && slices.Equal(a.Items, b.Items)
+	}) {
+		t.Errorf("Calls = %+v, want %+v", f.Calls, want)
+	}
+}
+
+func TestFake_NotFoundAndFailures(t *testing.T) {
+	f := New()
+	if _, err := f.GetCostCenter("00000000-0000-4000-8000-999999999999"); !github.IsCostCenterNotFound(err) {
+		t.Errorf("err = %v, want a cost center not found error", err)
+	}
+
+	id, created, err := f.EnsureCostCenter("Team A")
+	if err != nil || !created {
+		t.Fatalf("EnsureCostCenter = %q, %v, %v", id, created, err)
+	}
+	if again, created, _ := f.EnsureCostCenter("Team A"); again != id || created {
+		t.Errorf("second EnsureCostCenter = %q, %v, want the existing %q", again, created, id)
+	}
+
+	boom := errors.New("boom")
+	f.Fail("GetAllActiveCostCenters", boom)
+	if _, err := f.GetAllActiveCostCenters(); !errors.Is(err, boom) {
+		t.Errorf("err = %v, want the injected failure", err)
+	}
+	f.Fail("GetAllActiveCostCenters", nil)
+	if active, err := f.GetAllActiveCostCenters(); err != nil || active["Team A"] != id {
+		t.Errorf("after clearing: %v, %v", active, err)
+	}
+}
+
+func TestFake_Budgets(t *testing.T) {
+	f := New()
+	id := f.AddCostCenter("Team A")
+	for range 2 {
+		if ok, err := f.CreateProductBudget(id, "Team A", "actions", 50, github.BudgetAlerting{}); !ok || err != nil {
+			t.Fatalf("CreateProductBudget = %v, %v", ok, err)
+		}
+	}
+	if len(f.Budgets) != 1 {
+		t.Fatalf("budgets = %+v, want one", f.Budgets)
+	}
+	if has, _ := f.CheckCostCenterHasProductBudget(id, "Team A", "actions"); !has {
+		t.Error("CheckCostCenterHasProductBudget = false")
+	}
+	if err := f.DeleteBudget(f.Budgets[0].ID); err != nil || len(f.Budgets) != 0 {
+		t.Errorf("DeleteBudget: %v, budgets %+v", err, f.Budgets)
+	}
+}
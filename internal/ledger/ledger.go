@@ -0,0 +1,152 @@
+// Package ledger keeps the processed-users ledger of incremental users-mode
+// runs: every login successfully assigned, with the cost center it went to
+// and when.  An incremental run processes the users missing from the ledger
+// and those whose desired cost center changed, so a user is never skipped
+// for a malformed seat date, and users of a failed batch are sent again.
+//
+// The ledger is one JSON file, rewritten atomically after every successful
+// batch so that a crash loses at most the batch in flight.
+package ledger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is the last successful assignment of one login.
+type Entry struct {
+	CostCenter string    `json:"cost_center"`
+	Time       time.Time `json:"time"`
+}
+
+// file is the JSON stored in the ledger file.
+type file struct {
+	Users map[string]Entry `json:"users"`
+}
+
+// Ledger is a loaded processed-users ledger.  It is safe for concurrent use.
+type Ledger struct {
+	mu    sync.Mutex
+	path  string
+	users map[string]Entry // by lower-cased login
+}
+
+// Load reads the ledger at path.  A missing file is an empty ledger.
+func Load(path string) (*Ledger, error) {
+	l := &Ledger{path: path, users: map[string]Entry{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading processed-users ledger: %w", err)
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing processed-users ledger %s: %w", path, err)
+	}
+	for login, e := range f.Users {
+		l.users[strings.ToLower(login)] = e
+	}
+	return l, nil
+}
+
+// Len returns the number of logins in the ledger.
+func (l *Ledger) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.users)
+}
+
+// NeedsProcessing reports whether login must be assigned to costCenterID:
+// it is not in the ledger, or was last assigned to another cost center.
+func (l *Ledger) NeedsProcessing(login, costCenterID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.users[strings.ToLower(login)]
+	return !ok || e.CostCenter != costCenterID
+}
+
+// Departure is a login in the ledger that no longer holds a Copilot seat.
+type Departure struct {
+	Login      string
+	CostCenter string // the cost center it was last assigned to
+}
+
+// Departed returns the logins in the ledger that are not in seatHolders
+// (lower-cased logins), sorted.
+func (l *Ledger) Departed(seatHolders map[string]bool) []Departure {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []Departure
+	for login, e := range l.users {
+		if !seatHolders[login] {
+			out = append(out, Departure{Login: login, CostCenter: e.CostCenter})
+		}
+	}
+	slices.SortFunc(out, func(a, b Departure) int { return strings.Compare(a.Login, b.Login) })
+	return out
+}
+
+// Forget removes logins from the ledger and saves it.
+func (l *Ledger) Forget(logins []string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	changed := false
+	for _, login := range logins {
+		if _, ok := l.users[strings.ToLower(login)]; ok {
+			delete(l.users, strings.ToLower(login))
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return l.save()
+}
+
+// Record adds the logins of one batch that were successfully assigned to
+// costCenterID and saves the ledger.  Failed logins are left as they were.
+func (l *Ledger) Record(costCenterID string, results map[string]bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now().UTC()
+	changed := false
+	for login, ok := range results {
+		if ok {
+			l.users[strings.ToLower(login)] = Entry{CostCenter: costCenterID, Time: now}
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return l.save()
+}
+
+// save writes the ledger to a temporary file and renames it over the ledger
+// file, so that a crash never leaves a truncated ledger.
+func (l *Ledger) save() error {
+	data, err := json.MarshalIndent(file{Users: l.users}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding processed-users ledger: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing processed-users ledger: %w", err)
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		return fmt.Errorf("writing processed-users ledger: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package ledger
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoad_Missing(t *testing.T) {
+	l, err := Load(filepath.Join(t.TempDir(), "processed_users.json"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if l.Len() != 0 || !l.NeedsProcessing("alice", "CC-1") {
+		t.Errorf("missing ledger: Len = %d, want an empty ledger", l.Len())
+	}
+}
+
+func TestRecord_SavesPerBatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "processed_users.json")
+	l, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Record("CC-1", map[string]bool{"Alice": true, "bob": false}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	// Each batch is on disk before the next one.
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if reloaded.Len() != 1 {
+		t.Errorf("Len = %d, want only the successful login", reloaded.Len())
+	}
+	for _, tt := range []struct {
+		login, cc string
+		want      bool
+	}{
+		{"alice", "CC-1", false},
+		{"ALICE", "CC-1", false},
+		{"alice", "CC-2", true}, // moved since
+		{"bob", "CC-1", true},   // failed
+		{"carol", "CC-1", true}, // new
+	} {
+		if got := reloaded.NeedsProcessing(tt.login, tt.cc); got != tt.want {
+			t.Errorf("NeedsProcessing(%q, %q) = %v, want %v", tt.login, tt.cc, got, tt.want)
+		}
+	}
+
+	// A later assignment to another cost center replaces the entry.
+	if err := reloaded.Record("CC-2", map[string]bool{"alice": true}); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.NeedsProcessing("alice", "CC-2") || !reloaded.NeedsProcessing("alice", "CC-1") {
+		t.Error("alice: want CC-2 recorded over CC-1")
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+}
+
+func TestLoad_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "processed_users.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("err = %v, want a parse error naming the file", err)
+	}
+}
+
+func TestDepartedAndForget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "processed_users.json")
+	l, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Record("CC-1", map[string]bool{"alice": true, "bob": true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Record("CC-2", map[string]bool{"carol": true}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := l.Departed(map[string]bool{"bob": true})
+	want := []Departure{{Login: "alice", CostCenter: "CC-1"}, {Login: "carol", CostCenter: "CC-2"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Departed = %v, want %v", got, want)
+	}
+
+	if err := l.Forget([]string{"ALICE"}); err != nil {
+		t.Fatalf("Forget: %v", err)
+	}
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.Departed(map[string]bool{"bob": true}); !slices.Equal(got, want[1:]) {
+		t.Errorf("Departed after Forget = %v, want %v", got, want[1:])
+	}
+}
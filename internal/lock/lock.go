@@ -0,0 +1,134 @@
+// Package lock provides the advisory lock file that keeps apply runs against
+// the same enterprise from overlapping.  The lock is a file created with
+// O_EXCL holding the owner's PID, host, and start time; a lock whose owner
+// has exited or that is older than the stale threshold is taken over.
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Info is the content of a lock file.
+type Info struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// HeldError is returned by Acquire when another run holds the lock.
+type HeldError struct {
+	Path string
+	Info Info
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("another apply run holds %s (pid %d on %s, started %s); if it is no longer running, retry with --force-unlock",
+		e.Path, e.Info.PID, e.Info.Host, e.Info.StartedAt.Format(time.RFC3339))
+}
+
+// Lock is a held lock file.
+type Lock struct {
+	path string
+	log  *slog.Logger
+}
+
+// Acquire creates the lock file at path.  An existing lock is taken over when
+// it is older than staleAfter or its owner, on this host, is no longer
+// running; otherwise a *HeldError is returned.
+func Acquire(path string, staleAfter time.Duration, logger *slog.Logger) (*Lock, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating lock directory: %w", err)
+	}
+
+	host, _ := os.Hostname()
+	info := Info{PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC()}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, fmt.Errorf("encoding lock file: %w", err)
+	}
+
+	// Two attempts: the second follows the removal of a stale lock.
+	for attempt := 0; ; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_, werr := f.Write(data)
+			cerr := f.Close()
+			if werr != nil || cerr != nil {
+				_ = os.Remove(path)
+				return nil, fmt.Errorf("writing lock file: %w", errors.Join(werr, cerr))
+			}
+			logger.Debug("Acquired run lock", "path", path, "pid", info.PID)
+			return &Lock{path: path, log: logger}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("creating lock file: %w", err)
+		}
+
+		held, reason := readHolder(path, staleAfter, host)
+		if reason == "" || attempt > 0 {
+			return nil, &HeldError{Path: path, Info: held}
+		}
+		logger.Warn("Taking over stale run lock", "path", path, "pid", held.PID,
+			"host", held.Host, "started_at", held.StartedAt, "reason", reason)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("removing stale lock file: %w", err)
+		}
+	}
+}
+
+// readHolder returns the owner of the lock at path and, when the lock is
+// stale, why.  An unreadable lock file may be mid-write by its owner, so its
+// age is judged by the file's modification time.
+func readHolder(path string, staleAfter time.Duration, host string) (Info, string) {
+	var held Info
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &held)
+	}
+	if err != nil || held.StartedAt.IsZero() {
+		st, serr := os.Stat(path)
+		if serr != nil {
+			// Released in the meantime.
+			return held, "released"
+		}
+		held.StartedAt = st.ModTime()
+	}
+
+	switch {
+	case staleAfter > 0 && time.Since(held.StartedAt) > staleAfter:
+		return held, fmt.Sprintf("older than %s", staleAfter)
+	case held.PID > 0 && held.Host == host && !processAlive(held.PID):
+		return held, "owner process has exited"
+	}
+	return held, ""
+}
+
+// Release removes the lock file.
+func (l *Lock) Release() error {
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing lock file: %w", err)
+	}
+	l.log.Debug("Released run lock", "path", l.path)
+	return nil
+}
+
+// ForceUnlock removes the lock file at path whoever holds it.  It reports
+// whether there was a lock to remove.
+func ForceUnlock(path string) (bool, error) {
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("removing lock file: %w", err)
+	}
+	return true, nil
+}
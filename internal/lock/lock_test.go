@@ -0,0 +1,160 @@
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func writeLock(t *testing.T, path string, info Info) {
+	t.Helper()
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcquireRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", ".lock")
+	l, err := Acquire(path, time.Hour, testLogger())
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading lock file: %v", err)
+	}
+	var info Info
+	if err := json.Unmarshal(data, &info); err != nil {
+		t.Fatalf("decoding lock file: %v", err)
+	}
+	if info.PID != os.Getpid() || info.StartedAt.IsZero() {
+		t.Errorf("lock info = %+v, want this process and a start time", info)
+	}
+
+	var held *HeldError
+	if _, err := Acquire(path, time.Hour, testLogger()); !errors.As(err, &held) || held.Info.PID != os.Getpid() {
+		t.Fatalf("second Acquire err = %v, want a HeldError naming this process", err)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("lock file should be gone after Release (stat err = %v)", err)
+	}
+	l, err = Acquire(path, time.Hour, testLogger())
+	if err != nil {
+		t.Fatalf("Acquire after Release: %v", err)
+	}
+	_ = l.Release()
+}
+
+func TestAcquire_Contention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".lock")
+	const workers = 8
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		locks  []*Lock
+		denied int
+	)
+	start := make(chan struct{})
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			l, err := Acquire(path, time.Hour, testLogger())
+			mu.Lock()
+			defer mu.Unlock()
+			var held *HeldError
+			switch {
+			case err == nil:
+				locks = append(locks, l)
+			case errors.As(err, &held):
+				denied++
+			default:
+				t.Errorf("Acquire: %v", err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if len(locks) != 1 || denied != workers-1 {
+		t.Fatalf("%d acquired, %d denied; want exactly one holder", len(locks), denied)
+	}
+	_ = locks[0].Release()
+}
+
+func TestAcquire_TakesOverStaleLocks(t *testing.T) {
+	host, _ := os.Hostname()
+
+	// A process that has already exited.
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("running helper process: %v", err)
+	}
+	deadPID := cmd.Process.Pid
+
+	tests := []struct {
+		name  string
+		info  Info
+		stale bool
+	}{
+		{"old", Info{PID: os.Getpid(), Host: host, StartedAt: time.Now().Add(-3 * time.Hour)}, true},
+		{"dead owner", Info{PID: deadPID, Host: host, StartedAt: time.Now()}, true},
+		{"other host", Info{PID: deadPID, Host: host + "-elsewhere", StartedAt: time.Now()}, false},
+		{"live owner", Info{PID: os.Getpid(), Host: host, StartedAt: time.Now()}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".lock")
+			writeLock(t, path, tt.info)
+
+			l, err := Acquire(path, 2*time.Hour, testLogger())
+			if tt.stale {
+				if err != nil {
+					t.Fatalf("Acquire: %v, want the stale lock taken over", err)
+				}
+				_ = l.Release()
+				return
+			}
+			var held *HeldError
+			if !errors.As(err, &held) {
+				t.Fatalf("Acquire err = %v, want a HeldError", err)
+			}
+		})
+	}
+}
+
+func TestForceUnlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".lock")
+	if removed, err := ForceUnlock(path); err != nil || removed {
+		t.Fatalf("ForceUnlock without a lock = %v, %v", removed, err)
+	}
+	writeLock(t, path, Info{PID: os.Getpid(), Host: "h", StartedAt: time.Now()})
+	if removed, err := ForceUnlock(path); err != nil || !removed {
+		t.Fatalf("ForceUnlock = %v, %v; want the lock removed", removed, err)
+	}
+	if _, err := Acquire(path, time.Hour, testLogger()); err != nil {
+		t.Errorf("Acquire after ForceUnlock: %v", err)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // Options controls the behaviour of the logger returned by New.
@@ -21,8 +22,22 @@ type Options struct {
 	Level slog.Level
 	// FilePath is the optional path for a log file.  When set, a second
 	// handler writes DEBUG-level logs to this file.  The parent directory
-	// is created automatically.
+	// is created automatically.  A {timestamp} placeholder is replaced by
+	// the start time of the run, giving every run its own file.
 	FilePath string
+	// MaxSizeMB rotates the log file once it would grow past this many
+	// megabytes; MaxBackups and MaxAgeDays bound how many rotated files are
+	// kept and for how long.  Zero disables the respective limit.
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+	// KeepRuns, for a FilePath with the {timestamp} placeholder, deletes the
+	// oldest run logs beyond this many.  Zero keeps them all.
+	KeepRuns int
+	// NoColor disables ANSI colors on the console (--no-color or NO_COLOR).
+	// Log records are never colored; the setting is carried here so that
+	// the command output rendered next to them can follow it.
+	NoColor bool
 }
 
 // New creates a new slog.Logger with a console handler (stderr) and, if
@@ -38,17 +53,26 @@ func New(opts Options) (*slog.Logger, error) {
 		return slog.New(consoleHandler), nil
 	}
 
+	pattern := opts.FilePath
+	if strings.Contains(pattern, TimestampPlaceholder) {
+		opts.FilePath = expandTimestamp(pattern, time.Now())
+	}
+
 	// Ensure the log directory exists.
 	dir := filepath.Dir(opts.FilePath)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return slog.New(consoleHandler), nil // fall back to console-only
 	}
 
-	f, err := os.OpenFile(opts.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	f, err := openRotatingFile(opts.FilePath, int64(opts.MaxSizeMB)<<20, opts.MaxBackups, time.Duration(opts.MaxAgeDays)*24*time.Hour)
 	if err != nil {
 		return slog.New(consoleHandler), nil // fall back to console-only
 	}
 
+	if pattern != opts.FilePath && opts.KeepRuns > 0 {
+		pruneRunLogs(pattern, opts.KeepRuns)
+	}
+
 	// File handler always logs at DEBUG for full diagnostic traces.
 	fileHandler := slog.NewTextHandler(f, &slog.HandlerOptions{
 		Level: slog.LevelDebug,
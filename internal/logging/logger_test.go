@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseLevel(t *testing.T) {
@@ -186,3 +187,126 @@ func TestNew_NoSIGPIPEHandler(t *testing.T) {
 	// Logging to a pipe-like writer should not panic.
 	logger.Info("test message to verify no SIGPIPE interference")
 }
+
+func TestRotatingFile_RotatesPastMaxSize(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	r, err := openRotatingFile(path, 100, 0, 0)
+	if err != nil {
+		t.Fatalf("openRotatingFile: %v", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	line := []byte(strings.Repeat("x", 39) + "\n") // 40 bytes
+	for range 3 {
+		if _, err := r.Write(line); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v, want one rotated file", backups)
+	}
+	if info, err := os.Stat(backups[0]); err != nil || info.Size() != 80 {
+		t.Errorf("rotated file size = %v (err %v), want 80", info.Size(), err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 40 {
+		t.Errorf("active file size = %v (err %v), want 40 after rotation", info.Size(), err)
+	}
+}
+
+func TestRotatingFile_PrunesBackups(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	r, err := openRotatingFile(path, 10, 3, 48*time.Hour)
+	if err != nil {
+		t.Fatalf("openRotatingFile: %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	// A backup from last week is removed by age at the next rotation.
+	stale := filepath.Join(dir, "app-20251226T150405.000.log")
+	if err := os.WriteFile(stale, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	week := now.Add(-7 * 24 * time.Hour)
+	if err := os.Chtimes(stale, week, week); err != nil {
+		t.Fatal(err)
+	}
+	write := func(n int) []string {
+		t.Helper()
+		for range n {
+			now = now.Add(time.Second)
+			if _, err := r.Write([]byte("0123456789\n")); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+		}
+		backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+		return backups
+	}
+
+	if backups := write(2); len(backups) != 1 || backups[0] == stale {
+		t.Errorf("after one rotation backups = %v, want only the new one", backups)
+	}
+	if backups := write(5); len(backups) != 3 {
+		t.Errorf("backups = %v, want the 3 newest", backups)
+	}
+}
+
+func TestExpandTimestamp(t *testing.T) {
+	t.Parallel()
+	at := time.Date(2026, 1, 2, 16, 4, 5, 0, time.FixedZone("CET", 3600))
+	if got, want := expandTimestamp("logs/run-{timestamp}.log", at), "logs/run-2026-01-02T15-04-05Z.log"; got != want {
+		t.Errorf("expandTimestamp = %q, want %q", got, want)
+	}
+	if got := expandTimestamp("logs/app.log", at); got != "logs/app.log" {
+		t.Errorf("path without placeholder changed to %q", got)
+	}
+}
+
+func TestNew_TimestampedFileAndKeepRuns(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	pattern := filepath.Join(dir, "run-{timestamp}.log")
+
+	// Three earlier runs, one with a rotated backup, and an unrelated file.
+	old := []string{"2026-01-01T00-00-00Z", "2026-01-02T00-00-00Z", "2026-01-03T00-00-00Z"}
+	for _, stamp := range old {
+		if err := os.WriteFile(filepath.Join(dir, "run-"+stamp+".log"), []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backup := filepath.Join(dir, "run-2026-01-01T00-00-00Z-20260101T010000.000.log")
+	other := filepath.Join(dir, "run-notes.log")
+	for _, p := range []string{backup, other} {
+		if err := os.WriteFile(p, []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logger, err := New(Options{Level: slog.LevelInfo, FilePath: pattern, KeepRuns: 2})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	logger.Info("this run")
+
+	runs := runLogs(pattern)
+	if len(runs) != 2 || runs[0] != filepath.Join(dir, "run-2026-01-03T00-00-00Z.log") {
+		t.Fatalf("run logs = %v, want the newest earlier run and this one", runs)
+	}
+	data, err := os.ReadFile(runs[1])
+	if err != nil || !strings.Contains(string(data), "this run") {
+		t.Errorf("current run log = %q (err %v), want this run's line", data, err)
+	}
+	if _, err := os.Stat(backup); !os.IsNotExist(err) {
+		t.Error("backup of a pruned run was kept")
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("unrelated file was removed: %v", err)
+	}
+}
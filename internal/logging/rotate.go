@@ -0,0 +1,135 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat stamps rotated files: app.log becomes
+// app-20260102T150405.000.log.  It sorts chronologically.
+const backupTimeFormat = "20060102T150405.000"
+
+// rotatingFile is an io.Writer appending to a file that is rotated once it
+// would grow past maxSize bytes.  The full file is renamed with a timestamp
+// next to the original, and the oldest backups beyond maxBackups or older
+// than maxAge are deleted.  Zero limits are not enforced.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+	now        func() time.Time
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// openRotatingFile opens (or creates) path for appending.
+func openRotatingFile(path string, maxSize int64, maxBackups int, maxAge time.Duration) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups, maxAge: maxAge, now: time.Now}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("reading log file size: %w", err)
+	}
+	r.file, r.size = f, info.Size()
+	return nil
+}
+
+// Write appends p, first rotating the file if p would take it past the size
+// limit.  A record larger than the limit still goes to a fresh file whole.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the active file.
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// rotate renames the active file to a timestamped backup, reopens an empty
+// one, and prunes old backups.
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("closing log file: %w", err)
+	}
+	if err := os.Rename(r.path, r.backupName(r.now())); err != nil {
+		return fmt.Errorf("rotating log file: %w", err)
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.prune()
+	return nil
+}
+
+// backupName returns a timestamped path next to the original that does not
+// exist yet.
+func (r *rotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(r.path)
+	base := strings.TrimSuffix(r.path, ext)
+	name := base + "-" + t.Format(backupTimeFormat) + ext
+	for i := 1; ; i++ {
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			return name
+		}
+		name = fmt.Sprintf("%s-%s-%d%s", base, t.Format(backupTimeFormat), i, ext)
+	}
+}
+
+// backups returns the rotated files of r, oldest first.
+func (r *rotatingFile) backups() []string {
+	ext := filepath.Ext(r.path)
+	matches, _ := filepath.Glob(strings.TrimSuffix(r.path, ext) + "-*" + ext)
+	sort.Strings(matches)
+	return matches
+}
+
+// prune deletes the backups beyond maxBackups and those older than maxAge.
+// Failures are ignored; the next rotation tries again.
+func (r *rotatingFile) prune() {
+	backups := r.backups()
+	if r.maxBackups > 0 && len(backups) > r.maxBackups {
+		for _, name := range backups[:len(backups)-r.maxBackups] {
+			_ = os.Remove(name)
+		}
+		backups = backups[len(backups)-r.maxBackups:]
+	}
+	if r.maxAge > 0 {
+		cutoff := r.now().Add(-r.maxAge)
+		for _, name := range backups {
+			if info, err := os.Stat(name); err == nil && info.ModTime().Before(cutoff) {
+				_ = os.Remove(name)
+			}
+		}
+	}
+}
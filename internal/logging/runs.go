@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TimestampPlaceholder in Options.FilePath is replaced by the run's start
+// time, e.g. logs/run-{timestamp}.log becomes logs/run-2026-01-02T15-04-05Z.log.
+const TimestampPlaceholder = "{timestamp}"
+
+// runTimeFormat is RFC 3339 in UTC without the colons, which some file
+// systems reject.  It sorts chronologically.
+const runTimeFormat = "2006-01-02T15-04-05Z"
+
+// expandTimestamp replaces the placeholder in pattern with t.
+func expandTimestamp(pattern string, t time.Time) string {
+	return strings.ReplaceAll(pattern, TimestampPlaceholder, t.UTC().Format(runTimeFormat))
+}
+
+// runLogs returns the run logs of pattern, oldest first: the files whose name
+// is pattern with a valid timestamp in place of the placeholder.
+func runLogs(pattern string) []string {
+	prefix, suffix, _ := strings.Cut(pattern, TimestampPlaceholder)
+	matches, _ := filepath.Glob(strings.ReplaceAll(pattern, TimestampPlaceholder, "*"))
+	var runs []string
+	for _, m := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(m, prefix), suffix)
+		if _, err := time.Parse(runTimeFormat, stamp); err == nil {
+			runs = append(runs, m)
+		}
+	}
+	sort.Strings(runs)
+	return runs
+}
+
+// pruneRunLogs deletes the oldest run logs of pattern beyond keep, together
+// with their rotated backups.  Failures are ignored; the next run tries again.
+func pruneRunLogs(pattern string, keep int) {
+	runs := runLogs(pattern)
+	if len(runs) <= keep {
+		return
+	}
+	for _, run := range runs[:len(runs)-keep] {
+		_ = os.Remove(run)
+		ext := filepath.Ext(run)
+		backups, _ := filepath.Glob(strings.TrimSuffix(run, ext) + "-*" + ext)
+		for _, b := range backups {
+			_ = os.Remove(b)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Package plan serializes computed assignment changes so that they can be
+// reviewed and then applied exactly as planned (assign --mode plan --out,
+// then assign --mode apply --plan).
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// currentVersion is the plan file format version.
+const currentVersion = 1
+
+// DefaultMaxAge is how old a plan may be before apply refuses it.
+const DefaultMaxAge = 24 * time.Hour
+
+// File is a serialized plan.
+type File struct {
+	Version    int       `json:"version"`
+	Mode       string    `json:"mode"`
+	Enterprise string    `json:"enterprise"`
+	ConfigHash string    `json:"config_hash"`
+	CreatedAt  time.Time `json:"created_at"`
+	// CheckCurrent records that Add is a delta against current membership
+	// (--check-current), so apply also skips users in other cost centers.
+	CheckCurrent bool               `json:"check_current"`
+	CostCenters  []CostCenterChange `json:"cost_centers"`
+}
+
+// CostCenterChange lists the users to add to and remove from one cost center.
+type CostCenterChange struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name,omitempty"`
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+// New returns an empty plan stamped with the current time.
+func New(mode, enterprise, configHash string) *File {
+	return &File{
+		Version:    currentVersion,
+		Mode:       mode,
+		Enterprise: enterprise,
+		ConfigHash: configHash,
+		CreatedAt:  time.Now().UTC(),
+	}
+}
+
+// Write saves the plan as indented JSON.
+func Write(path string, f *File) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding plan: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing plan file: %w", err)
+	}
+	return nil
+}
+
+// Read loads a plan written by Write.
+func Read(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading plan file: %w", err)
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing plan file: %w", err)
+	}
+	if f.Version != currentVersion {
+		return nil, fmt.Errorf("unsupported plan file version %d (want %d)", f.Version, currentVersion)
+	}
+	return &f, nil
+}
+
+// Check refuses a plan that was made for another mode, enterprise, or
+// configuration, or that is older than maxAge at now.
+func (f *File) Check(mode, enterprise, configHash string, maxAge time.Duration, now time.Time) error {
+	switch {
+	case f.Mode != mode:
+		return fmt.Errorf("plan was made for mode %q, config is in mode %q", f.Mode, mode)
+	case f.Enterprise != enterprise:
+		return fmt.Errorf("plan was made for enterprise %q, config targets %q", f.Enterprise, enterprise)
+	case f.ConfigHash != configHash:
+		return fmt.Errorf("configuration changed since the plan was made; run plan again")
+	}
+	if age := now.Sub(f.CreatedAt); maxAge > 0 && age > maxAge {
+		return fmt.Errorf("plan is %s old, older than the maximum of %s; run plan again",
+			age.Round(time.Minute), maxAge)
+	}
+	return nil
+}
+
+// Adds returns the users to add per cost center ID.
+func (f *File) Adds() map[string][]string {
+	out := make(map[string][]string, len(f.CostCenters))
+	for _, cc := range f.CostCenters {
+		if len(cc.Add) > 0 {
+			out[cc.ID] = cc.Add
+		}
+	}
+	return out
+}
+
+// Removes returns the users to remove per cost center ID.
+func (f *File) Removes() map[string][]string {
+	out := make(map[string][]string, len(f.CostCenters))
+	for _, cc := range f.CostCenters {
+		if len(cc.Remove) > 0 {
+			out[cc.ID] = cc.Remove
+		}
+	}
+	return out
+}
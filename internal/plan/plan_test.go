@@ -0,0 +1,87 @@
+package plan
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteRead_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	want := New("users", "ent", "abc123")
+	want.CheckCurrent = true
+	want.CostCenters = []CostCenterChange{
+		{ID: "cc1", Name: "No PRUs", Add: []string{"alice", "bob"}, Remove: []string{}},
+		{ID: "cc2", Add: []string{}, Remove: []string{"carol"}},
+	}
+
+	if err := Write(path, want); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	got, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("CreatedAt = %v, want %v", got.CreatedAt, want.CreatedAt)
+	}
+	got.CreatedAt = want.CreatedAt
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip:\n got %+v\nwant %+v", got, want)
+	}
+
+	if adds := got.Adds(); !reflect.DeepEqual(adds, map[string][]string{"cc1": {"alice", "bob"}}) {
+		t.Errorf("Adds = %v", adds)
+	}
+	if removes := got.Removes(); !reflect.DeepEqual(removes, map[string][]string{"cc2": {"carol"}}) {
+		t.Errorf("Removes = %v", removes)
+	}
+}
+
+func TestRead_RejectsUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path); err == nil || !strings.Contains(err.Error(), "version 99") {
+		t.Errorf("Read error = %v, want unsupported version", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	created := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	f := &File{Version: currentVersion, Mode: "users", Enterprise: "ent", ConfigHash: "abc", CreatedAt: created}
+
+	tests := []struct {
+		name       string
+		mode, ent  string
+		hash       string
+		maxAge     time.Duration
+		now        time.Time
+		wantErrSub string
+	}{
+		{"fresh", "users", "ent", "abc", time.Hour, created.Add(30 * time.Minute), ""},
+		{"stale", "users", "ent", "abc", time.Hour, created.Add(2 * time.Hour), "older than the maximum"},
+		{"no max age", "users", "ent", "abc", 0, created.Add(1000 * time.Hour), ""},
+		{"config changed", "users", "ent", "def", time.Hour, created, "configuration changed"},
+		{"other enterprise", "users", "other", "abc", time.Hour, created, "enterprise"},
+		{"other mode", "teams", "ent", "abc", time.Hour, created, "mode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := f.Check(tt.mode, tt.ent, tt.hash, tt.maxAge, tt.now)
+			if tt.wantErrSub == "" {
+				if err != nil {
+					t.Errorf("Check: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErrSub) {
+				t.Errorf("Check error = %v, want %q", err, tt.wantErrSub)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Package progress reports the progress of long-running phases such as
+// paging through Copilot seats or applying assignment batches.
+//
+// On a terminal a "phase: x/y (pct%)" line is rewritten in place; otherwise
+// an INFO log line is emitted at most once per interval.  Phases report to
+// the default Reporter installed with SetDefault; without one they are
+// silent.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultInterval is how often a phase is logged when the output is not a
+// terminal.
+const DefaultInterval = 10 * time.Second
+
+// Reporter starts the progress Tasks of phases.  A nil *Reporter is silent.
+type Reporter struct {
+	w        io.Writer // receives the live line when tty is set
+	tty      bool
+	log      *slog.Logger
+	interval time.Duration
+	now      func() time.Time
+}
+
+// New returns a Reporter that rewrites a line on w when tty is true and logs
+// to logger every DefaultInterval otherwise.
+func New(w io.Writer, tty bool, logger *slog.Logger) *Reporter {
+	return &Reporter{w: w, tty: tty, log: logger, interval: DefaultInterval, now: time.Now}
+}
+
+var defaultReporter atomic.Pointer[Reporter]
+
+// SetDefault makes r the Reporter used by Start.  A nil r silences progress.
+func SetDefault(r *Reporter) {
+	defaultReporter.Store(r)
+}
+
+// Start begins a phase of total units on the default Reporter.
+func Start(phase string, total int) *Task {
+	return defaultReporter.Load().Start(phase, total)
+}
+
+// Start begins a phase of total units.  A total of zero or less means the
+// size is unknown and only the count is shown.
+func (r *Reporter) Start(phase string, total int) *Task {
+	if r == nil {
+		return nil
+	}
+	return &Task{r: r, phase: phase, total: total, last: r.now()}
+}
+
+// Task tracks one phase.  Its methods are safe for concurrent use, and are
+// no-ops on a nil *Task.
+type Task struct {
+	r     *Reporter
+	phase string
+	total int
+
+	mu   sync.Mutex
+	done int
+	last time.Time // when the phase was last logged
+}
+
+// Add records n more units done.
+func (t *Task) Add(n int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.done += n
+	if t.r.tty {
+		_, _ = fmt.Fprintf(t.r.w, "\r\x1b[K%s", t.line())
+		return
+	}
+	if now := t.r.now(); now.Sub(t.last) >= t.r.interval {
+		t.last = now
+		t.logProgress()
+	}
+}
+
+// Done ends the phase, leaving the final count on the terminal.
+func (t *Task) Done() {
+	if t == nil || !t.r.tty {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = fmt.Fprintf(t.r.w, "\r\x1b[K%s\n", t.line())
+}
+
+// line formats the progress as "phase: x/y (pct%)".  Called with t.mu held.
+func (t *Task) line() string {
+	if t.total <= 0 {
+		return fmt.Sprintf("%s: %d", t.phase, t.done)
+	}
+	return fmt.Sprintf("%s: %d/%d (%d%%)", t.phase, t.done, t.total, t.percent())
+}
+
+// logProgress logs the progress.  Called with t.mu held.
+func (t *Task) logProgress() {
+	if t.total <= 0 {
+		t.r.log.Info(t.phase, "done", t.done)
+		return
+	}
+	t.r.log.Info(t.phase, "done", t.done, "total", t.total, "percent", t.percent())
+}
+
+func (t *Task) percent() int {
+	return min(100, t.done*100/t.total)
+}
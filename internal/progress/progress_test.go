@@ -0,0 +1,90 @@
+package progress
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newLogReporter(buf *bytes.Buffer, clock *fakeClock) *Reporter {
+	r := New(nil, false, slog.New(slog.NewTextHandler(buf, nil)))
+	r.now = clock.now
+	return r
+}
+
+func TestTask_LogsPeriodically(t *testing.T) {
+	var buf bytes.Buffer
+	clock := &fakeClock{t: time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)}
+	task := newLogReporter(&buf, clock).Start("Fetching Copilot seats", 400)
+
+	task.Add(100)
+	clock.advance(DefaultInterval - time.Second)
+	task.Add(100)
+	if buf.Len() != 0 {
+		t.Fatalf("logged before the interval elapsed:\n%s", buf.String())
+	}
+
+	clock.advance(time.Second)
+	task.Add(50)
+	clock.advance(time.Second)
+	task.Add(50)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("want one log line, got:\n%s", buf.String())
+	}
+	for _, want := range []string{`msg="Fetching Copilot seats"`, "done=250", "total=400", "percent=62"} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("log line %q missing %s", lines[0], want)
+		}
+	}
+
+	clock.advance(DefaultInterval)
+	task.Add(100)
+	task.Done()
+	if !strings.Contains(buf.String(), "done=400 total=400 percent=100") {
+		t.Errorf("second interval not logged:\n%s", buf.String())
+	}
+}
+
+func TestTask_UnknownTotal(t *testing.T) {
+	var buf bytes.Buffer
+	clock := &fakeClock{}
+	task := newLogReporter(&buf, clock).Start("Fetching pages", 0)
+	clock.advance(DefaultInterval)
+	task.Add(3)
+	if !strings.Contains(buf.String(), "done=3") || strings.Contains(buf.String(), "total=") {
+		t.Errorf("log = %q, want only the count", buf.String())
+	}
+}
+
+func TestTask_Terminal(t *testing.T) {
+	var buf bytes.Buffer
+	task := New(&buf, true, slog.New(slog.DiscardHandler)).Start("Applying assignments", 3)
+	task.Add(1)
+	task.Add(2)
+	task.Done()
+	want := "\r\x1b[KApplying assignments: 1/3 (33%)" +
+		"\r\x1b[KApplying assignments: 3/3 (100%)" +
+		"\r\x1b[KApplying assignments: 3/3 (100%)\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestSilent(t *testing.T) {
+	var r *Reporter
+	task := r.Start("x", 1)
+	task.Add(1)
+	task.Done()
+
+	SetDefault(nil)
+	Start("x", 1).Add(1)
+}
@@ -1,13 +1,18 @@
 // Package pru implements PRU-based cost center assignment — the default mode.
 //
-// The logic is simple: every Copilot user is assigned to one of two cost
-// centers.  Users on the PRU exception list go to the "PRU-allowed" cost
-// center; everyone else goes to the "no-PRU" cost center.
+// Every Copilot user is assigned to one tier's cost center.  Users go to the
+// first tier whose members match them; the last tier is the default.  The
+// legacy configuration is two tiers: users on the PRU exception list go to
+// the "PRU-allowed" cost center; everyone else goes to the "no-PRU" cost
+// center.
 package pru
 
 import (
 	"fmt"
 	"log/slog"
+	"maps"
+	"path"
+	"sort"
 	"strings"
 
 	"github.com/renan-alm/gh-cost-center/internal/config"
@@ -16,83 +21,240 @@ import (
 
 // Manager handles PRU-based cost center assignment.
 type Manager struct {
-	noPRUCCID      string
-	pruAllowedCCID string
-	exceptions     map[string]bool // set of exception logins (lower-cased)
+	tiers          []*tier           // in match order; the last tier is the default
+	legacy         bool              // tiers were built from the two-tier settings
+	overrides      map[string]string // lower-cased login -> cost center ID (or name until resolved)
+	skipPending    bool              // leave out seats pending cancellation
+	includeNonUser bool              // keep bots and other non-User accounts
+	exceptionTeams []string          // lower-cased exception_teams entries, members of the first tier
 	log            *slog.Logger
 }
 
 // NewManager creates a PRU manager from the loaded configuration.
 func NewManager(cfg *config.Manager, logger *slog.Logger) *Manager {
-	exceptions := make(map[string]bool, len(cfg.PRUsExceptionUsers))
-	for _, u := range cfg.PRUsExceptionUsers {
-		exceptions[strings.ToLower(u)] = true
+	m := &Manager{
+		tiers:          buildTiers(cfg),
+		legacy:         len(cfg.PRUTiers) == 0,
+		overrides:      maps.Clone(cfg.PRUsUserOverrides),
+		skipPending:    cfg.SkipPendingCancellation,
+		includeNonUser: cfg.IncludeNonUserAccounts,
+		log:            logger,
 	}
 
-	logger.Info("Initialized PRU manager",
-		"exception_users", len(exceptions),
-		"no_pru_cc", cfg.NoPRUsCostCenterID,
-		"pru_allowed_cc", cfg.PRUsAllowedCostCenterID,
-	)
-
-	return &Manager{
-		noPRUCCID:      cfg.NoPRUsCostCenterID,
-		pruAllowedCCID: cfg.PRUsAllowedCostCenterID,
-		exceptions:     exceptions,
-		log:            logger,
+	if m.legacy {
+		for _, team := range cfg.PRUsExceptionTeams {
+			team = strings.ToLower(team)
+			if _, ok := m.tiers[0].teams[team]; !ok {
+				m.tiers[0].teams[team] = map[string]bool{}
+				m.exceptionTeams = append(m.exceptionTeams, team)
+			}
+		}
+		logger.Info("Initialized PRU manager",
+			"exception_users", len(cfg.PRUsExceptionUsers),
+			"exception_teams", len(m.exceptionTeams),
+			"no_pru_cc", cfg.NoPRUsCostCenterID,
+			"pru_allowed_cc", cfg.PRUsAllowedCostCenterID,
+		)
+	} else {
+		logger.Info("Initialized PRU manager", "tiers", len(m.tiers), "default_tier", m.defaultTier().Name)
 	}
+	return m
 }
 
 // SetCostCenterIDs updates the cost center IDs at runtime (e.g. after
-// auto-creation resolves placeholders into real UUIDs).
+// auto-creation resolves placeholders into real UUIDs).  noPRU is the default
+// (last) tier and pruAllowed the first tier.
 func (m *Manager) SetCostCenterIDs(noPRU, pruAllowed string) {
-	m.noPRUCCID = noPRU
-	m.pruAllowedCCID = pruAllowed
+	m.defaultTier().CostCenterID = noPRU
+	m.tiers[0].CostCenterID = pruAllowed
 	m.log.Info("Updated cost center IDs", "no_pru", noPRU, "pru_allowed", pruAllowed)
 }
 
-// NoPRUCCID returns the current no-PRU cost center ID.
-func (m *Manager) NoPRUCCID() string { return m.noPRUCCID }
+// OverrideCostCenters returns the distinct cost centers referenced by
+// user_overrides, sorted.
+func (m *Manager) OverrideCostCenters() []string {
+	seen := make(map[string]bool, len(m.overrides))
+	var ccs []string
+	for _, cc := range m.overrides {
+		if !seen[cc] {
+			seen[cc] = true
+			ccs = append(ccs, cc)
+		}
+	}
+	sort.Strings(ccs)
+	return ccs
+}
+
+// SetOverrideIDs replaces override cost center names with resolved IDs.
+// resolved maps the configured value (name or ID) to its UUID; values not in
+// the map are kept.
+func (m *Manager) SetOverrideIDs(resolved map[string]string) {
+	for login, cc := range m.overrides {
+		if id, ok := resolved[cc]; ok {
+			m.overrides[login] = id
+		}
+	}
+}
+
+// NoPRUCCID returns the current no-PRU (default tier) cost center ID.
+func (m *Manager) NoPRUCCID() string { return m.defaultTier().CostCenterID }
+
+// PRUAllowedCCID returns the current PRU-allowed (first tier) cost center ID.
+func (m *Manager) PRUAllowedCCID() string { return m.tiers[0].CostCenterID }
+
+// Kinds of exception entries.
+const (
+	kindLogin  = iota // exact login
+	kindGlob          // login glob such as "svc-*"
+	kindDomain        // email domain such as "@example.com"
+	kindTeam          // team member entry such as "org/team-slug"
+)
+
+// exceptionKind classifies a (lower-cased) exception entry.
+func exceptionKind(entry string) int {
+	switch {
+	case strings.HasPrefix(entry, "@"):
+		return kindDomain
+	case strings.ContainsAny(entry, "*?["):
+		return kindGlob
+	case strings.Contains(entry, "/"):
+		return kindTeam
+	default:
+		return kindLogin
+	}
+}
 
-// PRUAllowedCCID returns the current PRU-allowed cost center ID.
-func (m *Manager) PRUAllowedCCID() string { return m.pruAllowedCCID }
+// matchesEntry reports whether the user matches one lower-cased exception
+// entry.  Patterns were validated at config load, so match errors are treated
+// as no match.
+func matchesEntry(entry string, user github.CopilotUser) bool {
+	switch exceptionKind(entry) {
+	case kindDomain:
+		email := strings.ToLower(user.Email)
+		return email != "" && strings.HasSuffix(email, entry)
+	case kindGlob:
+		ok, _ := path.Match(entry, strings.ToLower(user.Login))
+		return ok
+	case kindTeam:
+		return false // matched through the loaded team members
+	default:
+		return entry == strings.ToLower(user.Login)
+	}
+}
 
-// IsException returns true if the login is in the PRU exception list.
+// IsException returns true if the login is in the PRU exception list, either
+// literally or through a glob pattern.  Email-domain entries need the full
+// user; see IsExceptionUser.
 func (m *Manager) IsException(login string) bool {
-	return m.exceptions[strings.ToLower(login)]
+	return m.IsExceptionUser(github.CopilotUser{Login: login})
+}
+
+// IsExceptionUser returns true if the user is a member of any non-default
+// tier — with the legacy settings, if they match an exception entry: an exact
+// login, a login glob, or the domain of their email address.
+func (m *Manager) IsExceptionUser(user github.CopilotUser) bool {
+	return len(m.matchingTiers(user)) > 0
+}
+
+// UnmatchedExceptions returns the configured exception entries (logins or
+// patterns) that match no Copilot seat holder (case-insensitively), in
+// configuration order.  These are
+// usually typos: the intended user silently lands in the no-PRU cost center.
+// With tiers configured, the member entries of every tier are checked.
+func (m *Manager) UnmatchedExceptions(users []github.CopilotUser) []string {
+	var unmatched []string
+	for _, t := range m.tiers {
+		for _, entry := range t.entries {
+			if !t.matchesAnyUser(strings.ToLower(entry), users) {
+				unmatched = append(unmatched, entry)
+			}
+		}
+	}
+	return unmatched
 }
 
 // AssignCostCenter returns the cost center ID for a given user.
 //
-//	exception user → pru_allowed_cost_center_id
-//	everyone else  → no_prus_cost_center_id
+//	user override  → the override cost center
+//	tier member    → the first matching tier's cost center
+//	                 (legacy: exception user → pru_allowed_cost_center_id)
+//	everyone else  → the default tier (legacy: no_prus_cost_center_id)
 func (m *Manager) AssignCostCenter(user github.CopilotUser) string {
-	if m.IsException(user.Login) {
-		m.log.Debug("User is PRU exception", "user", user.Login, "cc", m.pruAllowedCCID)
-		return m.pruAllowedCCID
+	if cc, ok := m.overrides[strings.ToLower(user.Login)]; ok {
+		m.log.Debug("User has cost center override", "user", user.Login, "cc", cc)
+		return cc
 	}
-	m.log.Debug("User assigned to default CC", "user", user.Login, "cc", m.noPRUCCID)
-	return m.noPRUCCID
+	t := m.tierFor(user)
+	m.log.Debug("User assigned to tier", "user", user.Login, "tier", t.Name, "cc", t.CostCenterID)
+	return t.CostCenterID
+}
+
+// Summary keys for users left out of assignment.
+const (
+	SkippedNonUser             = "skipped (non-user)"
+	SkippedPendingCancellation = "skipped (pending cancellation)"
+)
+
+// skipReason returns the GenerateSummary key for a user who is left out of
+// assignment, or "" when the user is assigned.
+func (m *Manager) skipReason(user github.CopilotUser) string {
+	if !m.includeNonUser && !github.IsUserAccount(user.Type) {
+		return SkippedNonUser
+	}
+	if m.skipPending {
+		if _, pending := user.PendingCancellation(); pending {
+			return SkippedPendingCancellation
+		}
+	}
+	return ""
+}
+
+// IsSkipped reports whether the user is left out of assignment: non-User
+// accounts unless include_non_user_accounts is set, and seats pending
+// cancellation when skip_pending_cancellation is set.
+func (m *Manager) IsSkipped(user github.CopilotUser) bool {
+	return m.skipReason(user) != ""
 }
 
 // AssignmentGroups builds the desired {cost_center_id: [usernames]} map for a
-// list of users.
+// list of users.  Skipped users (see IsSkipped) are left out.  A user who
+// matches several tiers goes to the first one and a warning is logged.
 func (m *Manager) AssignmentGroups(users []github.CopilotUser) map[string][]string {
-	groups := map[string][]string{
-		m.pruAllowedCCID: {},
-		m.noPRUCCID:      {},
+	groups := make(map[string][]string, len(m.tiers))
+	for _, t := range m.tiers {
+		groups[t.CostCenterID] = []string{}
+	}
+	for _, cc := range m.OverrideCostCenters() {
+		groups[cc] = []string{}
 	}
 	for _, u := range users {
+		if reason := m.skipReason(u); reason != "" {
+			m.log.Debug("Skipping user", "user", u.Login, "type", u.Type, "reason", reason)
+			continue
+		}
+		if idx := m.matchingTiers(u); len(idx) > 1 {
+			names := make([]string, len(idx))
+			for i, n := range idx {
+				names[i] = m.tiers[n].Name
+			}
+			m.log.Warn("User matches several tiers, using the first",
+				"user", u.Login, "tiers", strings.Join(names, ", "))
+		}
 		cc := m.AssignCostCenter(u)
 		groups[cc] = append(groups[cc], u.Login)
 	}
 	return groups
 }
 
-// GenerateSummary returns a cost-center → user-count map for display.
+// GenerateSummary returns a cost-center → user-count map for display.  Users
+// left out of assignment are counted under the Skipped* keys.
 func (m *Manager) GenerateSummary(users []github.CopilotUser) map[string]int {
 	summary := make(map[string]int)
 	for _, u := range users {
+		if reason := m.skipReason(u); reason != "" {
+			summary[reason]++
+			continue
+		}
 		cc := m.AssignCostCenter(u)
 		summary[cc]++
 	}
@@ -102,14 +264,36 @@ func (m *Manager) GenerateSummary(users []github.CopilotUser) map[string]int {
 // ValidateConfiguration checks that the PRU configuration is usable and
 // returns a list of issues (empty = valid).
 func (m *Manager) ValidateConfiguration() []string {
+	if m.legacy {
+		return m.validateLegacy()
+	}
 	var issues []string
-	if m.noPRUCCID == "" {
+	owner := make(map[string]string, len(m.tiers))
+	for _, t := range m.tiers {
+		if t.CostCenterID == "" {
+			issues = append(issues, fmt.Sprintf("tier %q has no cost center", t.Name))
+			continue
+		}
+		if prev, dup := owner[t.CostCenterID]; dup {
+			issues = append(issues, fmt.Sprintf("tiers %q and %q cannot use the same cost center", prev, t.Name))
+			continue
+		}
+		owner[t.CostCenterID] = t.Name
+	}
+	return issues
+}
+
+// validateLegacy checks the two-tier no-PRU/PRU-allowed settings.
+func (m *Manager) validateLegacy() []string {
+	var issues []string
+	noPRU, pruAllowed := m.NoPRUCCID(), m.PRUAllowedCCID()
+	if noPRU == "" {
 		issues = append(issues, "no_prus_cost_center_id is not defined")
 	}
-	if m.pruAllowedCCID == "" {
+	if pruAllowed == "" {
 		issues = append(issues, "prus_allowed_cost_center_id is not defined")
 	}
-	if m.noPRUCCID != "" && m.noPRUCCID == m.pruAllowedCCID {
+	if noPRU != "" && noPRU == pruAllowed {
 		issues = append(issues, "no_prus_cost_center_id and prus_allowed_cost_center_id cannot be the same")
 	}
 	return issues
@@ -121,45 +305,90 @@ func (m *Manager) PrintConfigSummary(cfg *config.Manager, autoCreate bool) {
 	fmt.Println("===== Current Configuration =====")
 	fmt.Printf("Enterprise: %s\n", cfg.Enterprise)
 
+	if !m.legacy {
+		fmt.Printf("Tiers (%d, last is the default):\n", len(m.tiers))
+		for i, t := range m.tiers {
+			fmt.Printf("  %d. %s: %s (%s)\n", i+1, t.Name, t.CostCenterID, t.CostCenterName)
+			printCCURL(cfg.Enterprise, t.CostCenterID)
+			if t.BudgetAmount > 0 {
+				fmt.Printf("     budget: $%d\n", t.BudgetAmount)
+			}
+			for _, e := range t.entries {
+				fmt.Printf("     - %s\n", e)
+			}
+		}
+		fmt.Println("===== End of Configuration =====")
+		fmt.Println()
+		return
+	}
+
 	if autoCreate {
 		fmt.Printf("No PRUs Cost Center: New cost center %q to be created\n", cfg.NoPRUsCostCenterName)
 		fmt.Printf("PRUs Allowed Cost Center: New cost center %q to be created\n", cfg.PRUsAllowedCostCenterName)
 	} else {
-		fmt.Printf("No PRUs Cost Center: %s\n", m.noPRUCCID)
-		printCCURL(cfg.Enterprise, m.noPRUCCID)
+		fmt.Printf("No PRUs Cost Center: %s\n", m.NoPRUCCID())
+		printCCURL(cfg.Enterprise, m.NoPRUCCID())
 
-		fmt.Printf("PRUs Allowed Cost Center: %s\n", m.pruAllowedCCID)
-		printCCURL(cfg.Enterprise, m.pruAllowedCCID)
+		fmt.Printf("PRUs Allowed Cost Center: %s\n", m.PRUAllowedCCID())
+		printCCURL(cfg.Enterprise, m.PRUAllowedCCID())
 	}
 
 	fmt.Printf("PRUs Exception Users (%d):\n", len(cfg.PRUsExceptionUsers))
 	for _, u := range cfg.PRUsExceptionUsers {
 		fmt.Printf("  - %s\n", u)
 	}
+	if len(cfg.PRUsExceptionTeams) > 0 {
+		fmt.Printf("PRUs Exception Teams (%d):\n", len(cfg.PRUsExceptionTeams))
+		for _, t := range cfg.PRUsExceptionTeams {
+			fmt.Printf("  - %s\n", t)
+		}
+	}
 	fmt.Println("===== End of Configuration =====")
 	fmt.Println()
 }
 
+// RunStats carries the outcome of a run for ShowSuccessSummary.
+type RunStats struct {
+	Results map[string]map[string]bool // cost center -> login -> success
+	Moved   map[string]bool            // lower-cased logins removed from another tier's cost center
+	Applied bool
+
+	RunID          string // run-state ID of an apply run
+	Resumed        bool   // the run continued an earlier one (--resume)
+	ResumedSkipped int    // users skipped because the earlier attempt assigned them
+
+	Departed        int // ledger users who no longer hold a seat (--incremental)
+	DepartedRemoved int // departed users removed from their cost center
+}
+
 // ShowSuccessSummary prints a comprehensive success summary at the end of a
 // run, including cost center URLs, user statistics, and assignment results.
-func ShowSuccessSummary(cfg *config.Manager, users []github.CopilotUser, originalCount *int, results map[string]map[string]bool, applied bool) {
+func (m *Manager) ShowSuccessSummary(cfg *config.Manager, users []github.CopilotUser, originalCount *int, stats RunStats) {
+	results, moved := stats.Results, stats.Moved
 	fmt.Println()
 	fmt.Println(strings.Repeat("=", 60))
 	fmt.Println("SUCCESS SUMMARY")
 	fmt.Println(strings.Repeat("=", 60))
 
-	// Cost center links.
+	// Cost center links, default tier first.
 	if cfg.Enterprise != "" && !strings.HasPrefix(cfg.Enterprise, "REPLACE_WITH_") {
 		fmt.Printf("\nCOST CENTERS (%s):\n", cfg.Enterprise)
-		if !strings.HasPrefix(cfg.NoPRUsCostCenterID, "REPLACE_WITH_") {
-			fmt.Printf("  No PRU Overages: %s\n", cfg.NoPRUsCostCenterID)
+		for i := len(m.tiers) - 1; i >= 0; i-- {
+			t := m.tiers[i]
+			if t.CostCenterID == "" || strings.HasPrefix(t.CostCenterID, "REPLACE_WITH_") {
+				continue
+			}
+			fmt.Printf("  %s: %s\n", t.Name, t.CostCenterID)
 			fmt.Printf("     -> https://github.com/enterprises/%s/billing/cost_centers/%s\n",
-				cfg.Enterprise, cfg.NoPRUsCostCenterID)
+				cfg.Enterprise, t.CostCenterID)
 		}
-		if !strings.HasPrefix(cfg.PRUsAllowedCostCenterID, "REPLACE_WITH_") {
-			fmt.Printf("  PRU Overages Allowed: %s\n", cfg.PRUsAllowedCostCenterID)
-			fmt.Printf("     -> https://github.com/enterprises/%s/billing/cost_centers/%s\n",
-				cfg.Enterprise, cfg.PRUsAllowedCostCenterID)
+	}
+
+	// Run details.
+	if stats.RunID != "" {
+		fmt.Printf("\nRUN: %s\n", stats.RunID)
+		if stats.Resumed {
+			fmt.Printf("  Resumed run: %d users already assigned by the previous attempt were skipped\n", stats.ResumedSkipped)
 		}
 	}
 
@@ -170,25 +399,50 @@ func ShowSuccessSummary(cfg *config.Manager, users []github.CopilotUser, origina
 		if originalCount != nil {
 			fmt.Printf("  Incremental processing: %d of %d total users\n", len(users), *originalCount)
 		}
+		if cfg.SkipPendingCancellation {
+			skipped := 0
+			for _, u := range users {
+				if m.skipReason(u) == SkippedPendingCancellation {
+					skipped++
+				}
+			}
+			fmt.Printf("  Skipped (pending cancellation): %d users\n", skipped)
+		}
 
-		if results != nil && applied {
+		if results != nil && stats.Applied {
 			totalAttempted := 0
 			totalSuccessful := 0
+			movedSuccessful := 0
 			for _, ccResults := range results {
-				for _, ok := range ccResults {
+				for login, ok := range ccResults {
 					totalAttempted++
 					if ok {
 						totalSuccessful++
+						if moved[strings.ToLower(login)] {
+							movedSuccessful++
+						}
 					}
 				}
 			}
 			fmt.Printf("  Assignment success rate: %d/%d users\n", totalSuccessful, totalAttempted)
+			if len(moved) > 0 {
+				fmt.Printf("  Added: %d users\n", totalSuccessful-movedSuccessful)
+				fmt.Printf("  Moved between cost centers: %d users\n", movedSuccessful)
+			}
 			if totalSuccessful < totalAttempted {
 				fmt.Printf("  Failed assignments: %d users\n", totalAttempted-totalSuccessful)
 			}
 		}
 	}
 
+	if stats.Departed > 0 {
+		fmt.Printf("\nSEATS REMOVED:\n")
+		fmt.Printf("  Users without a Copilot seat anymore: %d\n", stats.Departed)
+		if stats.Applied {
+			fmt.Printf("  Removed from their cost center: %d users\n", stats.DepartedRemoved)
+		}
+	}
+
 	fmt.Println(strings.Repeat("=", 60))
 }
 
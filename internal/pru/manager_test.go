@@ -1,8 +1,12 @@
 package pru
 
 import (
+	"bytes"
+	"io"
 	"log/slog"
 	"os"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/renan-alm/gh-cost-center/internal/config"
@@ -239,3 +243,312 @@ func TestNewManager_NilExceptions(t *testing.T) {
 		t.Error("IsException should return false when exception list is nil")
 	}
 }
+
+func TestAssignmentGroups_SkipPendingCancellation(t *testing.T) {
+	users := []github.CopilotUser{
+		{Login: "alice", PendingCancellationDate: "2026-11-01"},
+		{Login: "bob", PendingCancellationDate: "not-a-date"},
+		{Login: "charlie"},
+	}
+
+	cfg := testConfig("cc-no-pru", "cc-pru-allowed", nil)
+	if got := len(NewManager(cfg, testLogger()).AssignmentGroups(users)["cc-no-pru"]); got != 3 {
+		t.Errorf("without skip: %d users grouped, want 3", got)
+	}
+
+	cfg.SkipPendingCancellation = true
+	groups := NewManager(cfg, testLogger()).AssignmentGroups(users)
+	if got := groups["cc-no-pru"]; len(got) != 1 || got[0] != "charlie" {
+		t.Errorf("with skip: no-pru group = %v, want [charlie]", got)
+	}
+}
+
+func TestGenerateSummary_NonUserAccounts(t *testing.T) {
+	users := []github.CopilotUser{
+		{Login: "alice", Type: "User"},
+		{Login: "ci-bot", Type: "Bot"},
+		{Login: "ghost", Type: "Mannequin"},
+		{Login: "bob"},
+	}
+
+	cfg := testConfig("cc-no-pru", "cc-pru-allowed", nil)
+	summary := NewManager(cfg, testLogger()).GenerateSummary(users)
+	if summary["cc-no-pru"] != 2 || summary[SkippedNonUser] != 2 {
+		t.Errorf("summary = %v, want 2 assigned and 2 %q", summary, SkippedNonUser)
+	}
+	if groups := NewManager(cfg, testLogger()).AssignmentGroups(users); len(groups["cc-no-pru"]) != 2 {
+		t.Errorf("groups = %v, want bots excluded", groups)
+	}
+
+	cfg.IncludeNonUserAccounts = true
+	summary = NewManager(cfg, testLogger()).GenerateSummary(users)
+	if summary["cc-no-pru"] != 4 || summary[SkippedNonUser] != 0 {
+		t.Errorf("with include_non_user_accounts: summary = %v", summary)
+	}
+}
+
+func TestShowSuccessSummary_PendingCancellationCount(t *testing.T) {
+	users := []github.CopilotUser{
+		{Login: "alice", Type: "User", PendingCancellationDate: "2026-11-01"},
+		{Login: "ci-bot", Type: "Bot", PendingCancellationDate: "2026-11-01"},
+		{Login: "bob", Type: "User"},
+	}
+
+	cfg := testConfig("cc-no-pru", "cc-pru-allowed", nil)
+	cfg.SkipPendingCancellation = true
+	m := NewManager(cfg, testLogger())
+	out := captureStdout(t, func() { m.ShowSuccessSummary(cfg, users, nil, RunStats{}) })
+	// The bot is skipped as a non-User account, not as pending cancellation.
+	if !strings.Contains(out, "Skipped (pending cancellation): 1 users") {
+		t.Errorf("summary = %q, want 1 pending-cancellation skip", out)
+	}
+}
+
+// captureStdout returns what fn prints to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = orig }()
+
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	fn()
+	_ = w.Close()
+	return <-done
+}
+
+func TestUnmatchedExceptions(t *testing.T) {
+	users := []github.CopilotUser{{Login: "Alice"}, {Login: "bob"}}
+	tests := []struct {
+		name       string
+		exceptions []string
+		want       []string
+	}{
+		{"all matched, case differs", []string{"alice", "BOB"}, nil},
+		{"typo reported as configured", []string{"alice", "Bobb"}, []string{"Bobb"}},
+		{"no exceptions", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := NewManager(testConfig("cc-no-pru", "cc-pru-allowed", tt.exceptions), testLogger())
+			got := mgr.UnmatchedExceptions(users)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("UnmatchedExceptions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	mgr := NewManager(testConfig("cc-no-pru", "cc-pru-allowed", []string{"alice"}), testLogger())
+	if got := mgr.UnmatchedExceptions(nil); len(got) != 1 {
+		t.Errorf("no seat holders: UnmatchedExceptions = %v, want [alice]", got)
+	}
+}
+
+func TestIsExceptionUser_Patterns(t *testing.T) {
+	cfg := testConfig("cc-no-pru", "cc-pru-allowed", []string{"svc-*", "*-ADMIN", "@Acme-Research.com", "carol"})
+	mgr := NewManager(cfg, testLogger())
+
+	tests := []struct {
+		user github.CopilotUser
+		want bool
+	}{
+		{github.CopilotUser{Login: "svc-build"}, true},
+		{github.CopilotUser{Login: "SVC-Deploy"}, true},
+		{github.CopilotUser{Login: "ops-admin"}, true},
+		{github.CopilotUser{Login: "admin-ops"}, false},
+		{github.CopilotUser{Login: "dave", Email: "dave@ACME-research.com"}, true},
+		{github.CopilotUser{Login: "erin", Email: "erin@evilacme-research.com"}, false},
+		{github.CopilotUser{Login: "frank", Email: "frank@sub.acme-research.com"}, false},
+		{github.CopilotUser{Login: "Carol"}, true},
+		{github.CopilotUser{Login: "mallory"}, false},
+	}
+	for _, tt := range tests {
+		if got := mgr.IsExceptionUser(tt.user); got != tt.want {
+			t.Errorf("IsExceptionUser(%s <%s>) = %v, want %v", tt.user.Login, tt.user.Email, got, tt.want)
+		}
+	}
+
+	if mgr.IsException("dave") {
+		t.Error("IsException(login) must not match email-domain entries")
+	}
+}
+
+func TestAssignmentGroups_UserMatchingSeveralExceptionEntries(t *testing.T) {
+	cfg := testConfig("cc-no-pru", "cc-pru-allowed", []string{"svc-*", "@acme.com", "svc-build"})
+	mgr := NewManager(cfg, testLogger())
+	users := []github.CopilotUser{{Login: "svc-build", Email: "svc-build@acme.com"}, {Login: "bob"}}
+
+	groups := mgr.AssignmentGroups(users)
+	if got := groups["cc-pru-allowed"]; len(got) != 1 || got[0] != "svc-build" {
+		t.Errorf("pru-allowed group = %v, want [svc-build] exactly once", got)
+	}
+	if got := groups["cc-no-pru"]; len(got) != 1 || got[0] != "bob" {
+		t.Errorf("no-pru group = %v, want [bob]", got)
+	}
+	if got := mgr.UnmatchedExceptions(users); len(got) != 0 {
+		t.Errorf("UnmatchedExceptions = %v, want none", got)
+	}
+}
+
+func TestAssignCostCenter_UserOverrides(t *testing.T) {
+	cfg := testConfig("cc-no-pru", "cc-pru-allowed", []string{"alice", "contractor-*"})
+	cfg.PRUsUserOverrides = map[string]string{"contractor-1": "Contractors", "bob": "Contractors"}
+	mgr := NewManager(cfg, testLogger())
+
+	users := []github.CopilotUser{{Login: "alice"}, {Login: "Contractor-1"}, {Login: "contractor-2"}, {Login: "charlie"}}
+	if got := mgr.AssignCostCenter(users[1]); got != "Contractors" {
+		t.Errorf("override must win over the exception list, got %q", got)
+	}
+
+	groups := mgr.AssignmentGroups(users)
+	if got := groups["Contractors"]; len(got) != 1 || got[0] != "Contractor-1" {
+		t.Errorf("override group = %v, want [Contractor-1]", got)
+	}
+	if len(groups["cc-pru-allowed"]) != 2 || len(groups["cc-no-pru"]) != 1 {
+		t.Errorf("groups = %v", groups)
+	}
+	if summary := mgr.GenerateSummary(users); summary["Contractors"] != 1 {
+		t.Errorf("summary = %v, want Contractors=1", summary)
+	}
+
+	mgr.SetOverrideIDs(map[string]string{"Contractors": "cc-contractors"})
+	if got := mgr.AssignCostCenter(github.CopilotUser{Login: "bob"}); got != "cc-contractors" {
+		t.Errorf("after SetOverrideIDs, bob -> %q, want cc-contractors", got)
+	}
+}
+
+func tieredConfig() *config.Manager {
+	cfg := testConfig("", "", nil)
+	cfg.PRUTiers = []config.PRUTier{
+		{Name: "Power", CostCenterID: "cc-power", Members: []string{"alice", "ml-*"}},
+		{Name: "ML", CostCenterID: "cc-ml", BudgetAmount: 200, Members: []string{"ml-*", "my-org/ml-team"}},
+		{Name: "Standard", CostCenterName: "Standard CC", Members: []string{"ignored"}},
+	}
+	return cfg
+}
+
+func TestTiers_AssignFirstMatchAndDefault(t *testing.T) {
+	mgr := NewManager(tieredConfig(), testLogger())
+	mgr.SetTeamMembers("My-Org/ML-Team", []string{"Bob"})
+
+	tests := map[string]string{
+		"alice":   "cc-power",
+		"ml-carl": "cc-power", // matches Power and ML; the first tier wins
+		"bob":     "cc-ml",
+		"ignored": "Standard CC", // members of the default tier are ignored
+		"dave":    "Standard CC",
+	}
+	for login, want := range tests {
+		if got := mgr.AssignCostCenter(github.CopilotUser{Login: login}); got != want {
+			t.Errorf("AssignCostCenter(%s) = %q, want %q", login, got, want)
+		}
+	}
+
+	if got := mgr.TeamSlugs(); len(got) != 1 || got[0] != "my-org/ml-team" {
+		t.Errorf("TeamSlugs = %v", got)
+	}
+	tiers := mgr.Tiers()
+	if len(tiers) != 3 || tiers[1].BudgetAmount != 200 || tiers[2].CostCenterName != "Standard CC" {
+		t.Errorf("Tiers = %+v", tiers)
+	}
+	mgr.SetTierCostCenterID(2, "cc-standard")
+	if got := mgr.AssignCostCenter(github.CopilotUser{Login: "dave"}); got != "cc-standard" {
+		t.Errorf("after SetTierCostCenterID, dave -> %q", got)
+	}
+	if issues := mgr.ValidateConfiguration(); len(issues) != 0 {
+		t.Errorf("ValidateConfiguration = %v", issues)
+	}
+}
+
+func TestTiers_OverlapWarning(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	mgr := NewManager(tieredConfig(), logger)
+
+	groups := mgr.AssignmentGroups([]github.CopilotUser{{Login: "ml-carl"}, {Login: "alice"}, {Login: "dave"}})
+	if len(groups["cc-power"]) != 2 || len(groups["cc-ml"]) != 0 || len(groups["Standard CC"]) != 1 {
+		t.Errorf("groups = %v", groups)
+	}
+	out := buf.String()
+	if strings.Count(out, "several tiers") != 1 || !strings.Contains(out, "ml-carl") || !strings.Contains(out, "Power, ML") {
+		t.Errorf("expected one overlap warning for ml-carl, got:\n%s", out)
+	}
+}
+
+func TestTiers_UnmatchedAndValidation(t *testing.T) {
+	cfg := tieredConfig()
+	cfg.PRUTiers[1].CostCenterID = "cc-power"
+	mgr := NewManager(cfg, testLogger())
+
+	got := mgr.UnmatchedExceptions([]github.CopilotUser{{Login: "alice"}})
+	want := []string{"ml-*", "ml-*", "my-org/ml-team"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("UnmatchedExceptions = %v, want %v", got, want)
+	}
+	if issues := mgr.ValidateConfiguration(); len(issues) != 1 {
+		t.Errorf("shared cost center: issues = %v, want 1", issues)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	cfg := testConfig("cc-no-pru", "cc-pru-allowed", []string{"svc-*", "@example.com"})
+	cfg.PRUsUserOverrides = map[string]string{"svc-deploy": "Platform"}
+	mgr := NewManager(cfg, testLogger())
+
+	e := mgr.Explain(github.CopilotUser{Login: "svc-build", Type: "User"})
+	if e.Tier != legacyPRUAllowedTier || e.CostCenter != "cc-pru-allowed" || strings.Join(e.Matched, ",") != legacyPRUAllowedTier+": svc-*" {
+		t.Errorf("glob exception: %+v", e)
+	}
+
+	e = mgr.Explain(github.CopilotUser{Login: "svc-deploy", Type: "User"})
+	if e.Override != "Platform" || e.Tier != "" || e.CostCenter != "Platform" || len(e.Matched) != 1 {
+		t.Errorf("override: %+v, want the override with the tier match still listed", e)
+	}
+
+	e = mgr.Explain(github.CopilotUser{Login: "carol", Email: "carol@example.com", Type: "User"})
+	if strings.Join(e.Matched, ",") != legacyPRUAllowedTier+": @example.com" {
+		t.Errorf("domain exception: %+v", e)
+	}
+
+	e = mgr.Explain(github.CopilotUser{Login: "dave", Type: "Bot"})
+	if e.SkipReason != SkippedNonUser || e.Tier != legacyNoPRUTier || len(e.Matched) != 0 {
+		t.Errorf("unmatched bot: %+v", e)
+	}
+}
+
+func TestExceptionTeams(t *testing.T) {
+	cfg := testConfig("cc-no", "cc-yes", []string{"alice", "svc-*"})
+	cfg.PRUsExceptionTeams = []string{"ML-Research", "my-org/Data"}
+	mgr := NewManager(cfg, testLogger())
+
+	if got := mgr.ExceptionTeams(); !slices.Equal(got, []string{"ml-research", "my-org/data"}) {
+		t.Errorf("ExceptionTeams = %v", got)
+	}
+	if got := mgr.TeamSlugs(); len(got) != 0 {
+		t.Errorf("TeamSlugs = %v, want exception teams left out", got)
+	}
+	mgr.SetTeamMembers("ml-research", []string{"Bob", "svc-bot"})
+	mgr.SetTeamMembers("my-org/data", []string{"carol"})
+
+	users := []github.CopilotUser{{Login: "alice"}, {Login: "bob"}, {Login: "carol"}, {Login: "svc-bot"}, {Login: "dave"}}
+	for _, u := range users[:4] {
+		if got := mgr.AssignCostCenter(u); got != "cc-yes" {
+			t.Errorf("AssignCostCenter(%s) = %q, want cc-yes", u.Login, got)
+		}
+	}
+	if got := mgr.AssignCostCenter(users[4]); got != "cc-no" {
+		t.Errorf("AssignCostCenter(dave) = %q, want cc-no", got)
+	}
+	// svc-bot matches the glob, so only bob and carol come from teams.
+	if explicit, fromTeams := mgr.ExceptionSources(users); explicit != 2 || fromTeams != 2 {
+		t.Errorf("ExceptionSources = %d, %d; want 2, 2", explicit, fromTeams)
+	}
+}
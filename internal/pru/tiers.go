@@ -0,0 +1,290 @@
+package pru
+
+import (
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+// Display names of the two tiers built from the legacy no-PRU/PRU-allowed
+// settings.
+const (
+	legacyPRUAllowedTier = "PRU Overages Allowed"
+	legacyNoPRUTier      = "No PRU Overages"
+)
+
+// Tier describes one users-mode tier for display and cost center
+// resolution.
+type Tier struct {
+	Name           string
+	CostCenterID   string // UUID once resolved; may be a placeholder or name before
+	CostCenterName string
+	BudgetAmount   int
+}
+
+// tier is a compiled tier: its member entries split by kind for matching.
+type tier struct {
+	Tier
+	entries []string                   // member entries as configured
+	logins  map[string]bool            // lower-cased exact logins
+	globs   []string                   // lower-cased login glob patterns
+	domains []string                   // lower-cased email domains, including "@"
+	teams   map[string]map[string]bool // lower-cased "org/slug" -> member logins
+}
+
+// newTier compiles a tier from its configured member entries.
+func newTier(info Tier, members []string) *tier {
+	t := &tier{
+		Tier:    info,
+		entries: members,
+		logins:  make(map[string]bool, len(members)),
+		teams:   map[string]map[string]bool{},
+	}
+	for _, m := range members {
+		entry := strings.ToLower(m)
+		switch exceptionKind(entry) {
+		case kindDomain:
+			t.domains = append(t.domains, entry)
+		case kindGlob:
+			t.globs = append(t.globs, entry)
+		case kindTeam:
+			t.teams[entry] = map[string]bool{}
+		default:
+			t.logins[entry] = true
+		}
+	}
+	return t
+}
+
+// buildTiers returns the configured tiers, or the legacy two tiers (PRU
+// exceptions first, no-PRU default last) when none are configured.
+func buildTiers(cfg *config.Manager) []*tier {
+	if len(cfg.PRUTiers) == 0 {
+		return []*tier{
+			newTier(Tier{
+				Name:           legacyPRUAllowedTier,
+				CostCenterID:   cfg.PRUsAllowedCostCenterID,
+				CostCenterName: cfg.PRUsAllowedCostCenterName,
+			}, cfg.PRUsExceptionUsers),
+			newTier(Tier{
+				Name:           legacyNoPRUTier,
+				CostCenterID:   cfg.NoPRUsCostCenterID,
+				CostCenterName: cfg.NoPRUsCostCenterName,
+			}, nil),
+		}
+	}
+	tiers := make([]*tier, 0, len(cfg.PRUTiers))
+	for i, t := range cfg.PRUTiers {
+		members := t.Members
+		if i == len(cfg.PRUTiers)-1 {
+			members = nil // the default tier takes everyone left
+		}
+		id := t.CostCenterID
+		if id == "" {
+			id = t.CostCenterName
+		}
+		tiers = append(tiers, newTier(Tier{
+			Name:           t.Name,
+			CostCenterID:   id,
+			CostCenterName: defaultName(t.CostCenterName, t.Name),
+			BudgetAmount:   t.BudgetAmount,
+		}, members))
+	}
+	return tiers
+}
+
+// defaultName returns name, or fallback when name is empty.
+func defaultName(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// matches reports whether the user is a member of the tier.
+func (t *tier) matches(user github.CopilotUser) bool {
+	return t.matchedEntry(user) != ""
+}
+
+// matchedEntry returns the (lower-cased) member entry through which the user
+// is a member of the tier, or "" when they are not.  Exact logins are
+// checked first, then globs, email domains, and teams (sorted).
+func (t *tier) matchedEntry(user github.CopilotUser) string {
+	login := strings.ToLower(user.Login)
+	if t.logins[login] {
+		return login
+	}
+	for _, g := range t.globs {
+		if ok, _ := path.Match(g, login); ok {
+			return g
+		}
+	}
+	if user.Email != "" {
+		for _, d := range t.domains {
+			if matchesEntry(d, user) {
+				return d
+			}
+		}
+	}
+	if len(t.teams) == 0 {
+		return ""
+	}
+	teams := make([]string, 0, len(t.teams))
+	for team := range t.teams {
+		teams = append(teams, team)
+	}
+	sort.Strings(teams)
+	for _, team := range teams {
+		if t.teams[team][login] {
+			return team
+		}
+	}
+	return ""
+}
+
+// matchesAnyUser reports whether one lower-cased member entry of the tier
+// matches any of the users.
+func (t *tier) matchesAnyUser(entry string, users []github.CopilotUser) bool {
+	for _, u := range users {
+		if exceptionKind(entry) == kindTeam {
+			if t.teams[entry][strings.ToLower(u.Login)] {
+				return true
+			}
+			continue
+		}
+		if matchesEntry(entry, u) {
+			return true
+		}
+	}
+	return false
+}
+
+// Tiers returns the tiers in match order; the last one is the default.
+func (m *Manager) Tiers() []Tier {
+	out := make([]Tier, len(m.tiers))
+	for i, t := range m.tiers {
+		out[i] = t.Tier
+	}
+	return out
+}
+
+// SetTierCostCenterID sets the cost center of tier i once it has been
+// resolved or created.
+func (m *Manager) SetTierCostCenterID(i int, id string) {
+	m.tiers[i].CostCenterID = id
+	m.log.Info("Updated tier cost center ID", "tier", m.tiers[i].Name, "cc", id)
+}
+
+// TeamSlugs returns the distinct "org/team-slug" member entries across all
+// tiers, sorted.  Their members must be loaded with SetTeamMembers before
+// assignment.  Exception teams are listed by ExceptionTeams instead.
+func (m *Manager) TeamSlugs() []string {
+	seen := map[string]bool{}
+	var slugs []string
+	for _, t := range m.tiers {
+		for team := range t.teams {
+			if !seen[team] && !slices.Contains(m.exceptionTeams, team) {
+				seen[team] = true
+				slugs = append(slugs, team)
+			}
+		}
+	}
+	sort.Strings(slugs)
+	return slugs
+}
+
+// ExceptionTeams returns the lower-cased exception_teams entries: enterprise
+// team slugs, or "org/team-slug".  Their members must be loaded with
+// SetTeamMembers before assignment; they count as PRU exception users.
+func (m *Manager) ExceptionTeams() []string {
+	return slices.Clone(m.exceptionTeams)
+}
+
+// ExceptionSources counts the users in the PRU exception tier by how they
+// got there: explicitly, through exception_users, or only through the
+// members of exception_teams.
+func (m *Manager) ExceptionSources(users []github.CopilotUser) (explicit, fromTeams int) {
+	if !m.legacy {
+		return 0, 0
+	}
+	for _, u := range users {
+		switch entry := m.tiers[0].matchedEntry(u); {
+		case entry == "":
+		case slices.Contains(m.exceptionTeams, entry):
+			fromTeams++
+		default:
+			explicit++
+		}
+	}
+	return explicit, fromTeams
+}
+
+// SetTeamMembers records the logins of an "org/team-slug" member entry, or
+// of an exception team, in every tier that lists it.
+func (m *Manager) SetTeamMembers(team string, logins []string) {
+	team = strings.ToLower(team)
+	for _, t := range m.tiers {
+		members, ok := t.teams[team]
+		if !ok {
+			continue
+		}
+		for _, l := range logins {
+			members[strings.ToLower(l)] = true
+		}
+	}
+}
+
+// matchingTiers returns the indexes of the non-default tiers the user is a
+// member of, in tier order.
+func (m *Manager) matchingTiers(user github.CopilotUser) []int {
+	var idx []int
+	for i, t := range m.tiers[:len(m.tiers)-1] {
+		if t.matches(user) {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// tierFor returns the tier the user is assigned to: the first matching
+// non-default tier, or the default tier.
+func (m *Manager) tierFor(user github.CopilotUser) *tier {
+	for _, t := range m.tiers[:len(m.tiers)-1] {
+		if t.matches(user) {
+			return t
+		}
+	}
+	return m.defaultTier()
+}
+
+// defaultTier returns the last tier, which takes every unmatched user.
+func (m *Manager) defaultTier() *tier { return m.tiers[len(m.tiers)-1] }
+
+// Explanation says why a user is assigned where they are.
+type Explanation struct {
+	SkipReason string   // why the user is left out of assignment; "" if assigned
+	Override   string   // user_overrides cost center; "" if none
+	Tier       string   // the tier the user is in when there is no override
+	Matched    []string // "tier: entry" for every non-default tier the user matches
+	CostCenter string   // what AssignCostCenter returns
+}
+
+// Explain returns why AssignCostCenter picks the user's cost center.
+func (m *Manager) Explain(user github.CopilotUser) Explanation {
+	e := Explanation{SkipReason: m.skipReason(user)}
+	for _, t := range m.tiers[:len(m.tiers)-1] {
+		if entry := t.matchedEntry(user); entry != "" {
+			e.Matched = append(e.Matched, t.Name+": "+entry)
+		}
+	}
+	e.Override = m.overrides[strings.ToLower(user.Login)]
+	if e.Override == "" {
+		e.Tier = m.tierFor(user).Name
+	}
+	e.CostCenter = m.AssignCostCenter(user)
+	return e
+}
@@ -0,0 +1,127 @@
+// Package render prints the human-readable console output of the commands:
+// aligned tables and colored text, green for additions, red for removals and
+// yellow for warnings.
+//
+// Color is only used when the output is a terminal, NO_COLOR is unset and
+// NoColor is false, so redirected output and test buffers stay plain text.
+package render
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// NoColor disables color for every Printer created by Auto.  The root command
+// sets it from --no-color.
+var NoColor bool
+
+// ANSI SGR sequences.
+const (
+	green  = "\x1b[32m"
+	red    = "\x1b[31m"
+	yellow = "\x1b[33m"
+	reset  = "\x1b[0m"
+)
+
+// ansiPattern matches the escape sequences this package emits.
+var ansiPattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// ColorEnabled reports whether output written to w should be colored: w must
+// be a terminal and neither NoColor nor the NO_COLOR environment variable may
+// be set.
+func ColorEnabled(w io.Writer) bool {
+	return !NoColor && os.Getenv("NO_COLOR") == "" && IsTerminal(w)
+}
+
+// IsTerminal reports whether w is a file open on a terminal.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Printer writes console output to w, colored when color is true.
+type Printer struct {
+	w     io.Writer
+	color bool
+}
+
+// New returns a Printer writing to w with color forced on or off.
+func New(w io.Writer, color bool) *Printer {
+	return &Printer{w: w, color: color}
+}
+
+// Auto returns a Printer writing to w, colored when ColorEnabled(w).
+func Auto(w io.Writer) *Printer {
+	return New(w, ColorEnabled(w))
+}
+
+// Stdout returns a Printer writing to os.Stdout.
+func Stdout() *Printer {
+	return Auto(os.Stdout)
+}
+
+// Add colors s as an addition.
+func (p *Printer) Add(s string) string { return p.paint(green, s) }
+
+// Remove colors s as a removal.
+func (p *Printer) Remove(s string) string { return p.paint(red, s) }
+
+// Warn colors s as a warning.
+func (p *Printer) Warn(s string) string { return p.paint(yellow, s) }
+
+func (p *Printer) paint(code, s string) string {
+	if !p.color || s == "" {
+		return s
+	}
+	return code + s + reset
+}
+
+// Printf writes formatted output.
+func (p *Printer) Printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(p.w, format, args...)
+}
+
+// Println writes its arguments followed by a newline.
+func (p *Printer) Println(args ...any) {
+	_, _ = fmt.Fprintln(p.w, args...)
+}
+
+// Table writes rows as left-aligned columns separated by two spaces, each
+// line prefixed with indent.  Widths ignore color sequences, so colored cells
+// line up with plain ones.  The last cell of a row is not padded.
+func (p *Printer) Table(indent string, rows [][]string) {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], Width(cell))
+		}
+	}
+	for _, row := range rows {
+		var b strings.Builder
+		b.WriteString(indent)
+		for i, cell := range row {
+			b.WriteString(cell)
+			if i < len(row)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-Width(cell)+2))
+			}
+		}
+		p.Println(b.String())
+	}
+}
+
+// Width returns the number of characters s takes on screen, not counting
+// color sequences.
+func Width(s string) int {
+	return utf8.RuneCountInString(ansiPattern.ReplaceAllString(s, ""))
+}
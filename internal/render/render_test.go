@@ -0,0 +1,90 @@
+package render
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestPrinter_Colors(t *testing.T) {
+	var buf bytes.Buffer
+	on := New(&buf, true)
+	if got := on.Add("+ alice"); got != "\x1b[32m+ alice\x1b[0m" {
+		t.Errorf("Add = %q", got)
+	}
+	if got := on.Remove("- bob"); got != "\x1b[31m- bob\x1b[0m" {
+		t.Errorf("Remove = %q", got)
+	}
+	if got := on.Warn("unmapped"); got != "\x1b[33munmapped\x1b[0m" {
+		t.Errorf("Warn = %q", got)
+	}
+	if got := on.Add(""); got != "" {
+		t.Errorf("Add(\"\") = %q, want no escape sequences", got)
+	}
+
+	off := New(&buf, false)
+	for _, got := range []string{off.Add("x"), off.Remove("x"), off.Warn("x")} {
+		if got != "x" {
+			t.Errorf("color off: %q, want plain x", got)
+		}
+	}
+}
+
+func TestPrinter_Table(t *testing.T) {
+	for _, color := range []bool{false, true} {
+		var buf bytes.Buffer
+		p := New(&buf, color)
+		p.Table("  ", [][]string{
+			{"Alpha (id-a):", p.Add("+2"), p.Remove("-10"), "3 unchanged"},
+			{"B:", p.Add("+100"), "-0", "0 unchanged"},
+		})
+		want := "  Alpha (id-a):  +2    -10  3 unchanged\n" +
+			"  B:             +100  -0   0 unchanged\n"
+		if got := ansiPattern.ReplaceAllString(buf.String(), ""); got != want {
+			t.Errorf("color=%v: table =\n%q\nwant\n%q", color, got, want)
+		}
+		if hasColor := bytes.Contains(buf.Bytes(), []byte("\x1b[")); hasColor != color {
+			t.Errorf("color=%v: output has escape sequences = %v", color, hasColor)
+		}
+	}
+}
+
+func TestWidth(t *testing.T) {
+	if got := Width("\x1b[32mcafé\x1b[0m"); got != 4 {
+		t.Errorf("Width = %d, want 4", got)
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	if ColorEnabled(&bytes.Buffer{}) {
+		t.Error("buffer: color enabled")
+	}
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	if ColorEnabled(f) {
+		t.Error("regular file: color enabled")
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip("no terminal available")
+	}
+	defer func() { _ = tty.Close() }()
+	if !ColorEnabled(tty) {
+		t.Error("terminal: color disabled")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if ColorEnabled(tty) {
+		t.Error("terminal with NO_COLOR: color enabled")
+	}
+	t.Setenv("NO_COLOR", "")
+	NoColor = true
+	t.Cleanup(func() { NoColor = false })
+	if ColorEnabled(tty) {
+		t.Error("terminal with NoColor: color enabled")
+	}
+}
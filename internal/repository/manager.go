@@ -5,6 +5,7 @@ package repository
 import (
 	"fmt"
 	"log/slog"
+	"sort"
 	"strings"
 
 	"github.com/renan-alm/gh-cost-center/internal/config"
@@ -13,22 +14,44 @@ import (
 
 // MappingResult records the outcome of processing a single explicit mapping.
 type MappingResult struct {
+	Organization   string
 	CostCenter     string
 	CostCenterID   string
 	PropertyName   string
 	PropertyValues []string
 	ReposMatched   int
 	ReposAssigned  int
+	ReposUnchanged int // already attached, not sent again
 	Success        bool
 	Message        string
+	Default        bool // the default cost center for unmatched repos
 }
 
 // Summary holds the overall result of a repository assignment run.
 type Summary struct {
+	Organizations   []string
 	TotalRepos      int
 	MappingsTotal   int
 	MappingsApplied int
 	MappingResults  []MappingResult
+	CostCenterRepos map[string]int // CC name -> matched repositories
+	UnmatchedRepos  []string       // full names of repos matching no mapping
+	UnmatchedPolicy string
+
+	// OrgCostCenterRepos holds the matched repositories per org and CC name.
+	OrgCostCenterRepos map[string]map[string]int
+	// ArchivedSkipped counts the archived or disabled repos left out.
+	ArchivedSkipped int
+	// Changes holds the delta against the current repos per CC name.
+	Changes map[string]*RepoChanges
+}
+
+// RepoChanges is the change needed for one cost center against the repos
+// currently attached to it.
+type RepoChanges struct {
+	Add       []string // matched repos not attached yet
+	Remove    []string // attached repos that moved elsewhere or, with remove_unmatched, match no mapping
+	Unchanged int
 }
 
 // Print displays the summary to stdout.
@@ -37,46 +60,158 @@ func (s *Summary) Print() {
 	fmt.Println(strings.Repeat("=", 80))
 	fmt.Println("REPOSITORY ASSIGNMENT SUMMARY")
 	fmt.Println(strings.Repeat("=", 80))
-	fmt.Printf("Total repositories in organization: %d\n", s.TotalRepos)
+	if len(s.Organizations) > 0 {
+		fmt.Printf("Organizations: %s\n", strings.Join(s.Organizations, ", "))
+	}
+	fmt.Printf("Total repositories: %d\n", s.TotalRepos)
+	if s.ArchivedSkipped > 0 {
+		fmt.Printf("Archived or disabled repositories skipped: %d\n", s.ArchivedSkipped)
+	}
 	fmt.Printf("Mappings processed: %d / %d\n", s.MappingsApplied, s.MappingsTotal)
 
 	for _, r := range s.MappingResults {
 		fmt.Println()
 		fmt.Printf("Cost Center: %s\n", r.CostCenter)
-		fmt.Printf("  Property:  %s\n", r.PropertyName)
-		fmt.Printf("  Values:    %s\n", strings.Join(r.PropertyValues, ", "))
+		if r.Organization != "" {
+			fmt.Printf("  Org:       %s\n", r.Organization)
+		}
+		if r.Default {
+			fmt.Println("  Default for repositories matching no mapping")
+		} else {
+			fmt.Printf("  Property:  %s\n", r.PropertyName)
+			fmt.Printf("  Values:    %s\n", strings.Join(r.PropertyValues, ", "))
+		}
 		fmt.Printf("  Matched:   %d repositories\n", r.ReposMatched)
 		fmt.Printf("  Assigned:  %d repositories\n", r.ReposAssigned)
+		if r.ReposUnchanged > 0 {
+			fmt.Printf("  Unchanged: %d repositories\n", r.ReposUnchanged)
+		}
 		if r.Success {
 			fmt.Println("  Status:    Success")
 		} else {
 			fmt.Printf("  Status:    Failed \u2014 %s\n", r.Message)
 		}
 	}
+
+	if len(s.Organizations) > 1 && len(s.OrgCostCenterRepos) > 0 {
+		fmt.Println()
+		fmt.Println("Repositories per organization and cost center:")
+		for _, org := range s.Organizations {
+			counts := s.OrgCostCenterRepos[org]
+			names := make([]string, 0, len(counts))
+			for name := range counts {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			fmt.Printf("  %s\n", org)
+			if len(names) == 0 {
+				fmt.Println("    (none)")
+			}
+			for _, name := range names {
+				fmt.Printf("    %s: %d\n", name, counts[name])
+			}
+		}
+	}
+
+	if len(s.CostCenterRepos) > 0 {
+		names := make([]string, 0, len(s.CostCenterRepos))
+		for name := range s.CostCenterRepos {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Println()
+		fmt.Println("Repositories per cost center:")
+		for _, name := range names {
+			fmt.Printf("  %s: %d\n", name, s.CostCenterRepos[name])
+		}
+	}
+	if s.Changes != nil {
+		printRepoChanges(s.Changes)
+	}
+	if s.TotalRepos > 0 {
+		fmt.Println()
+		fmt.Printf("Repositories matching no mapping: %d (unmatched_policy: %s)\n",
+			len(s.UnmatchedRepos), defaultPolicy(s.UnmatchedPolicy))
+		for _, name := range s.UnmatchedRepos {
+			fmt.Printf("  - %s\n", name)
+		}
+	}
 	fmt.Println(strings.Repeat("=", 80))
 }
 
+// printRepoChanges prints the adds, removes, and unchanged count per cost
+// center, or an explicit "nothing to do" line.
+func printRepoChanges(changes map[string]*RepoChanges) {
+	names := make([]string, 0, len(changes))
+	adds, removes, unchanged := 0, 0, 0
+	for name, c := range changes {
+		names = append(names, name)
+		adds, removes, unchanged = adds+len(c.Add), removes+len(c.Remove), unchanged+c.Unchanged
+	}
+	sort.Strings(names)
+
+	fmt.Println()
+	fmt.Printf("Changes against current assignments: %d to add, %d to remove, %d unchanged\n", adds, removes, unchanged)
+	if adds == 0 && removes == 0 {
+		fmt.Println("Nothing to do: current repository assignments already match the mappings.")
+		return
+	}
+	for _, name := range names {
+		c := changes[name]
+		if len(c.Add) == 0 && len(c.Remove) == 0 {
+			continue
+		}
+		fmt.Printf("  %s: +%d -%d (%d unchanged)\n", name, len(c.Add), len(c.Remove), c.Unchanged)
+		for _, r := range c.Add {
+			fmt.Printf("    + %s\n", r)
+		}
+		for _, r := range c.Remove {
+			fmt.Printf("    - %s\n", r)
+		}
+	}
+}
+
 // Manager handles repository-based cost center assignment.
 type Manager struct {
 	cfg      *config.Manager
-	client   *github.Client
+	client   github.CostCenterAPI
 	log      *slog.Logger
 	mappings []config.ExplicitMapping
+
+	// What to do with repos matching no mapping, and where assign_default
+	// puts them.
+	unmatchedPolicy string
+	defaultCC       string
+
+	includeArchived bool // map archived and disabled repos too
+	removeUnmatched bool // detach repos that match no mapping
 }
 
 // NewManager creates a new repository manager from configuration.
-func NewManager(cfg *config.Manager, client *github.Client, logger *slog.Logger) (*Manager, error) {
+func NewManager(cfg *config.Manager, client github.CostCenterAPI, logger *slog.Logger) (*Manager, error) {
 	if len(cfg.ReposMappings) == 0 {
 		return nil, fmt.Errorf("repos mode requires at least one mapping in cost_center.repos.mappings")
 	}
 	return &Manager{
-		cfg:      cfg,
-		client:   client,
-		log:      logger,
-		mappings: cfg.ReposMappings,
+		cfg:             cfg,
+		client:          client,
+		log:             logger,
+		mappings:        cfg.ReposMappings,
+		unmatchedPolicy: cfg.ReposUnmatchedPolicy,
+		defaultCC:       cfg.ReposDefaultCostCenter,
+		includeArchived: cfg.ReposIncludeArchived,
+		removeUnmatched: cfg.ReposRemoveUnmatched,
 	}, nil
 }
 
+// defaultPolicy returns policy, or "skip" when it is unset.
+func defaultPolicy(policy string) string {
+	if policy == "" {
+		return "skip"
+	}
+	return policy
+}
+
 // ValidateConfiguration checks mapping definitions and returns any issues.
 func (m *Manager) ValidateConfiguration() []string {
 	var issues []string
@@ -95,39 +230,66 @@ func (m *Manager) ValidateConfiguration() []string {
 }
 
 // PrintConfigSummary displays the repository mode configuration.
-func (m *Manager) PrintConfigSummary(org string) {
+func (m *Manager) PrintConfigSummary(orgs ...string) {
 	fmt.Println()
 	fmt.Println(strings.Repeat("=", 80))
 	fmt.Println("Repository-Based Cost Center Assignment")
 	fmt.Println(strings.Repeat("=", 80))
-	fmt.Printf("Organization: %s\n", org)
+	fmt.Printf("Organizations: %s\n", strings.Join(orgs, ", "))
 	fmt.Printf("Mappings:     %d\n", len(m.mappings))
 	for i, mp := range m.mappings {
 		fmt.Printf("\n  Mapping %d:\n", i+1)
 		fmt.Printf("    Cost Center:    %s\n", mp.CostCenter)
+		if mp.Organization != "" {
+			fmt.Printf("    Organization:   %s\n", mp.Organization)
+		}
 		fmt.Printf("    Property:       %s\n", mp.PropertyName)
-		fmt.Printf("    Values:         %s\n", strings.Join(mp.PropertyValues, ", "))
+		fmt.Printf("    Values:         %s", strings.Join(mp.PropertyValues, ", "))
+		if mp.CaseInsensitive {
+			fmt.Print(" (case-insensitive)")
+		}
+		fmt.Println()
 	}
+	fmt.Printf("\nUnmatched repos: %s", defaultPolicy(m.unmatchedPolicy))
+	if m.unmatchedPolicy == "assign_default" {
+		fmt.Printf(" (to %s)", m.defaultCC)
+	}
+	fmt.Println()
 	fmt.Println(strings.Repeat("=", 80))
 }
 
-// Run executes the full repository-based assignment flow.
+// Run executes the full repository-based assignment flow across orgs, applying
+// to each org only the mappings scoped to it or to no org.
 // mode is "plan" or "apply".  createBudgets enables budget creation for new CCs.
-func (m *Manager) Run(org, mode string, createBudgets bool) (*Summary, error) {
+func (m *Manager) Run(orgs []string, mode string, createBudgets bool) (*Summary, error) {
 	m.log.Info("Starting repository-based cost center assignment",
-		"org", org, "mode", mode, "mappings", len(m.mappings))
+		"orgs", strings.Join(orgs, ","), "mode", mode, "mappings", len(m.mappings))
 
 	// Fetch all repos with custom properties.
-	m.log.Info("Fetching repositories with custom properties...", "org", org)
-	allRepos, err := m.client.GetOrgReposWithProperties(org, "")
-	if err != nil {
-		return nil, fmt.Errorf("fetching repos with properties: %w", err)
+	reposByOrg := make(map[string][]github.RepoProperties, len(orgs))
+	total, skipped := 0, 0
+	for _, org := range orgs {
+		m.log.Info("Fetching repositories with custom properties...", "org", org)
+		repos, err := m.client.GetOrgReposWithProperties(org, "")
+		if err != nil {
+			return nil, fmt.Errorf("fetching repos with properties for %s: %w", org, err)
+		}
+		m.log.Info("Repositories found", "org", org, "count", len(repos))
+		if !m.includeArchived {
+			var n int
+			repos, n, err = m.dropInactive(org, repos)
+			if err != nil {
+				return nil, err
+			}
+			skipped += n
+		}
+		reposByOrg[org] = repos
+		total += len(repos)
 	}
-	if len(allRepos) == 0 {
-		m.log.Warn("No repositories found", "org", org)
-		return &Summary{TotalRepos: 0, MappingsTotal: len(m.mappings)}, nil
+	if total == 0 {
+		m.log.Warn("No repositories found", "orgs", strings.Join(orgs, ","))
+		return &Summary{Organizations: orgs, TotalRepos: 0, MappingsTotal: len(m.mappings), UnmatchedPolicy: m.unmatchedPolicy, ArchivedSkipped: skipped}, nil
 	}
-	m.log.Info("Repositories found", "org", org, "count", len(allRepos))
 
 	// Preload existing cost centers for efficient lookups.
 	activeCCs, err := m.client.GetAllActiveCostCenters()
@@ -137,33 +299,271 @@ func (m *Manager) Run(org, mode string, createBudgets bool) (*Summary, error) {
 	m.log.Info("Existing cost centers loaded", "count", len(activeCCs))
 
 	summary := &Summary{
-		TotalRepos:    len(allRepos),
-		MappingsTotal: len(m.mappings),
+		Organizations:      orgs,
+		TotalRepos:         total,
+		CostCenterRepos:    make(map[string]int),
+		OrgCostCenterRepos: make(map[string]map[string]int, len(orgs)),
+		UnmatchedPolicy:    m.unmatchedPolicy,
+		ArchivedSkipped:    skipped,
+	}
+	matchesByOrg := make(map[string][][]github.RepoProperties, len(orgs))
+	unmatchedByOrg := make(map[string][]string, len(orgs))
+	desired := make(map[string][]string) // CC name -> repo full names
+	for _, org := range orgs {
+		matches, err := m.matchRepos(org, reposByOrg[org])
+		if err != nil {
+			return nil, err
+		}
+		groups, unmatched := groupRepos(reposByOrg[org], m.mappings, matches)
+		matchesByOrg[org], unmatchedByOrg[org] = matches, unmatched
+		summary.OrgCostCenterRepos[org] = make(map[string]int, len(groups))
+		for cc, repos := range groups {
+			summary.CostCenterRepos[cc] += len(repos)
+			summary.OrgCostCenterRepos[org][cc] = len(repos)
+			desired[cc] = append(desired[cc], repos...)
+		}
+		if m.unmatchedPolicy == "assign_default" {
+			desired[m.defaultCC] = append(desired[m.defaultCC], unmatched...)
+		}
+		summary.UnmatchedRepos = append(summary.UnmatchedRepos, unmatched...)
+		for _, mp := range m.mappings {
+			if appliesTo(mp, org) {
+				summary.MappingsTotal++
+			}
+		}
+	}
+	sort.Strings(summary.UnmatchedRepos)
+	if n := len(summary.UnmatchedRepos); n > 0 {
+		m.log.Info("Repositories matching no mapping", "count", n, "unmatched_policy", defaultPolicy(m.unmatchedPolicy))
+		if m.unmatchedPolicy == "error" {
+			return summary, fmt.Errorf("%d repositories match no mapping and cost_center.repos.unmatched_policy is \"error\"", n)
+		}
 	}
 
-	// Process each mapping.
-	for i, mp := range m.mappings {
-		m.log.Info("Processing mapping",
-			"index", i+1, "total", len(m.mappings),
-			"cost_center", mp.CostCenter,
-			"property", mp.PropertyName,
-			"values", strings.Join(mp.PropertyValues, ","))
+	// Diff against the repos currently attached to the target cost centers,
+	// so only the delta is sent.
+	current, err := m.currentRepos(activeCCs)
+	if err != nil {
+		return summary, err
+	}
+	evaluated := make(map[string]bool, total)
+	for _, repos := range reposByOrg {
+		for _, r := range repos {
+			evaluated[r.RepositoryFullName] = true
+		}
+	}
+	summary.Changes = diffRepos(desired, current, evaluated, m.removeUnmatched)
+	attached := make(map[string]map[string]bool, len(current))
+	for cc, repos := range current {
+		attached[cc] = make(map[string]bool, len(repos))
+		for _, r := range repos {
+			attached[cc][r] = true
+		}
+	}
+
+	// Detach moved and unmatched repos before attaching new ones.
+	if err := m.removeRepos(summary.Changes, activeCCs, mode); err != nil {
+		return summary, err
+	}
 
-		result := m.processMapping(mp, allRepos, activeCCs, mode, createBudgets)
-		if result.Success {
-			summary.MappingsApplied++
+	for _, org := range orgs {
+		// Process each mapping that applies to the org.
+		for i, mp := range m.mappings {
+			if !appliesTo(mp, org) {
+				continue
+			}
+			m.log.Info("Processing mapping",
+				"org", org,
+				"index", i+1, "total", len(m.mappings),
+				"cost_center", mp.CostCenter,
+				"property", mp.PropertyName,
+				"values", strings.Join(mp.PropertyValues, ","))
+
+			result := m.processMapping(mp, matchesByOrg[org][i], attached[mp.CostCenter], activeCCs, mode, createBudgets)
+			result.Organization = org
+			if result.Success {
+				summary.MappingsApplied++
+			}
+			summary.MappingResults = append(summary.MappingResults, result)
+		}
+
+		// Assign the repos no mapping matched to the default cost center.
+		unmatched := unmatchedByOrg[org]
+		if m.unmatchedPolicy == "assign_default" && len(unmatched) > 0 {
+			isUnmatched := make(map[string]bool, len(unmatched))
+			for _, name := range unmatched {
+				isUnmatched[name] = true
+			}
+			var repos []github.RepoProperties
+			for _, r := range reposByOrg[org] {
+				if isUnmatched[r.RepositoryFullName] {
+					repos = append(repos, r)
+				}
+			}
+			result := MappingResult{Organization: org, CostCenter: m.defaultCC, ReposMatched: len(repos), Default: true}
+			m.assignRepos(&result, repos, attached[m.defaultCC], activeCCs, mode, createBudgets)
+			summary.MappingResults = append(summary.MappingResults, result)
+			summary.CostCenterRepos[m.defaultCC] += len(repos)
+			summary.OrgCostCenterRepos[org][m.defaultCC] += len(repos)
 		}
-		summary.MappingResults = append(summary.MappingResults, result)
 	}
 
 	return summary, nil
 }
 
-// processMapping handles a single explicit mapping -- find matching repos,
-// ensure CC exists, and assign.
+// targetCostCenters returns the sorted names of the cost centers repos are
+// assigned to: every mapping's, plus the default one with assign_default.
+func (m *Manager) targetCostCenters() []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, mp := range m.mappings {
+		add(mp.CostCenter)
+	}
+	if m.unmatchedPolicy == "assign_default" {
+		add(m.defaultCC)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// currentRepos returns the repos currently attached to each existing target
+// cost center, by name.
+func (m *Manager) currentRepos(activeCCs map[string]string) (map[string][]string, error) {
+	current := make(map[string][]string)
+	for _, name := range m.targetCostCenters() {
+		id, ok := activeCCs[name]
+		if !ok {
+			continue
+		}
+		repos, err := m.client.GetCostCenterRepos(id)
+		if err != nil {
+			return nil, fmt.Errorf("fetching current repos for %s: %w", name, err)
+		}
+		current[name] = repos
+	}
+	return current, nil
+}
+
+// diffRepos compares the desired repos per cost center with the current ones.
+// A repo attached to one target cost center but desired in another is
+// removed from the first.  Attached repos desired nowhere are removed only
+// with removeUnmatched, and only if they were evaluated this run (repos of
+// other orgs, or skipped archived ones, are left alone).
+func diffRepos(desired, current map[string][]string, evaluated map[string]bool, removeUnmatched bool) map[string]*RepoChanges {
+	changes := make(map[string]*RepoChanges)
+	get := func(cc string) *RepoChanges {
+		if changes[cc] == nil {
+			changes[cc] = &RepoChanges{}
+		}
+		return changes[cc]
+	}
+
+	wantIn := make(map[string]string) // repo -> desired CC name
+	for cc, repos := range desired {
+		have := make(map[string]bool, len(current[cc]))
+		for _, r := range current[cc] {
+			have[r] = true
+		}
+		c := get(cc)
+		for _, r := range repos {
+			wantIn[r] = cc
+			if have[r] {
+				c.Unchanged++
+			} else {
+				c.Add = append(c.Add, r)
+			}
+		}
+	}
+	for cc, repos := range current {
+		c := get(cc)
+		for _, r := range repos {
+			want, ok := wantIn[r]
+			switch {
+			case ok && want == cc:
+			case ok, removeUnmatched && evaluated[r]:
+				c.Remove = append(c.Remove, r)
+			}
+		}
+	}
+	for _, c := range changes {
+		sort.Strings(c.Add)
+		sort.Strings(c.Remove)
+	}
+	return changes
+}
+
+// removeRepos detaches every repo in changes' Remove lists from its cost
+// center.  In plan mode it only reports.
+func (m *Manager) removeRepos(changes map[string]*RepoChanges, activeCCs map[string]string, mode string) error {
+	names := make([]string, 0, len(changes))
+	for name := range changes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		remove := changes[name].Remove
+		if len(remove) == 0 {
+			continue
+		}
+		if mode == "plan" {
+			m.log.Info("mode=plan: would remove repos", "cost_center", name, "count", len(remove))
+			continue
+		}
+		m.log.Info("Removing repos from cost center", "cost_center", name, "count", len(remove))
+		if _, err := m.client.RemoveReposFromCostCenter(activeCCs[name], remove); err != nil {
+			return fmt.Errorf("removing repos from cost center %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// dropInactive returns repos without the archived or disabled ones of org,
+// and how many it dropped.
+func (m *Manager) dropInactive(org string, repos []github.RepoProperties) ([]github.RepoProperties, int, error) {
+	statuses, err := m.client.GetOrgRepoStatuses(org)
+	if err != nil {
+		return nil, 0, fmt.Errorf("fetching repository states for %s: %w", org, err)
+	}
+	inactive := make(map[string]bool)
+	for _, st := range statuses {
+		if st.Archived || st.Disabled {
+			inactive[st.FullName] = true
+		}
+	}
+
+	kept := make([]github.RepoProperties, 0, len(repos))
+	for _, r := range repos {
+		if inactive[r.RepositoryFullName] {
+			m.log.Debug("Skipping archived or disabled repository", "repo", r.RepositoryFullName)
+			continue
+		}
+		kept = append(kept, r)
+	}
+	if n := len(repos) - len(kept); n > 0 {
+		m.log.Info("Skipped archived or disabled repositories", "org", org, "count", n)
+	}
+	return kept, len(repos) - len(kept), nil
+}
+
+// appliesTo reports whether mp applies to org: it is scoped to org or to no
+// org at all.
+func appliesTo(mp config.ExplicitMapping, org string) bool {
+	return mp.Organization == "" || mp.Organization == org
+}
+
+// processMapping handles a single explicit mapping -- given the repos it
+// matched, ensure CC exists, and assign.
 func (m *Manager) processMapping(
 	mp config.ExplicitMapping,
-	allRepos []github.RepoProperties,
+	matching []github.RepoProperties,
+	attached map[string]bool,
 	activeCCs map[string]string,
 	mode string,
 	createBudgets bool,
@@ -181,8 +581,6 @@ func (m *Manager) processMapping(
 		return result
 	}
 
-	// Find matching repos.
-	matching := findMatchingRepos(allRepos, mp.PropertyName, mp.PropertyValues)
 	result.ReposMatched = len(matching)
 
 	if len(matching) == 0 {
@@ -197,6 +595,38 @@ func (m *Manager) processMapping(
 	m.log.Info("Repositories matched",
 		"cost_center", mp.CostCenter, "count", len(matching))
 
+	m.assignRepos(&result, matching, attached, activeCCs, mode, createBudgets)
+	return result
+}
+
+// assignRepos assigns repos to result.CostCenter, creating it if needed, and
+// records the outcome in result.  Repos already attached to it are not sent
+// again.  In plan mode it only reports.
+func (m *Manager) assignRepos(
+	result *MappingResult,
+	repos []github.RepoProperties,
+	attached map[string]bool,
+	activeCCs map[string]string,
+	mode string,
+	createBudgets bool,
+) {
+	ccName := result.CostCenter
+
+	var matching []github.RepoProperties
+	for _, r := range repos {
+		if attached[r.RepositoryFullName] {
+			result.ReposUnchanged++
+			continue
+		}
+		matching = append(matching, r)
+	}
+	if len(matching) == 0 {
+		result.Success = true
+		result.Message = fmt.Sprintf("all %d repositories already assigned", result.ReposUnchanged)
+		m.log.Info("Repositories already assigned", "cost_center", ccName, "count", result.ReposUnchanged)
+		return
+	}
+
 	// Plan mode -- just report what would happen.
 	if mode == "plan" {
 		result.ReposAssigned = len(matching)
@@ -204,38 +634,38 @@ func (m *Manager) processMapping(
 		result.Message = fmt.Sprintf("would assign %d repositories (plan mode)", len(matching))
 
 		m.log.Info("mode=plan: would assign repos",
-			"cost_center", mp.CostCenter, "count", len(matching))
+			"cost_center", ccName, "count", len(matching))
 		for _, r := range matching {
-			m.log.Debug("Would assign", "repo", r.RepositoryFullName, "cost_center", mp.CostCenter)
+			m.log.Debug("Would assign", "repo", r.RepositoryFullName, "cost_center", ccName)
 		}
-		return result
+		return
 	}
 
 	// Apply mode -- ensure CC exists.
-	ccID, ok := activeCCs[mp.CostCenter]
+	ccID, ok := activeCCs[ccName]
 	if !ok {
-		m.log.Info("Cost center does not exist, creating...", "name", mp.CostCenter)
+		m.log.Info("Cost center does not exist, creating...", "name", ccName)
 		var err error
-		ccID, err = m.client.CreateCostCenterWithPreload(mp.CostCenter, activeCCs)
+		ccID, err = m.client.CreateCostCenterWithPreload(ccName, activeCCs)
 		if err != nil {
 			result.Message = fmt.Sprintf("failed to create cost center: %v", err)
 			m.log.Error("Failed to create cost center",
-				"name", mp.CostCenter, "error", err)
-			return result
+				"name", ccName, "error", err)
+			return
 		}
-		activeCCs[mp.CostCenter] = ccID
-		m.log.Info("Created cost center", "name", mp.CostCenter, "id", ccID)
+		activeCCs[ccName] = ccID
+		m.log.Info("Created cost center", "name", ccName, "id", ccID)
 
 		// Create budgets if enabled.
 		if createBudgets && m.cfg.BudgetsEnabled {
-			if err := m.createBudgets(ccID, mp.CostCenter); err != nil {
+			if err := m.createBudgets(ccID, ccName); err != nil {
 				result.Message = fmt.Sprintf("budget creation failed: %v", err)
-				m.log.Error("Budget creation failed for cost center", "name", mp.CostCenter, "error", err)
-				return result
+				m.log.Error("Budget creation failed for cost center", "name", ccName, "error", err)
+				return
 			}
 		}
 	} else {
-		m.log.Info("Cost center already exists", "name", mp.CostCenter, "id", ccID)
+		m.log.Info("Cost center already exists", "name", ccName, "id", ccID)
 	}
 
 	result.CostCenterID = ccID
@@ -252,14 +682,14 @@ func (m *Manager) processMapping(
 
 	if len(repoNames) == 0 {
 		result.Message = "no valid repository names to assign"
-		m.log.Error("No valid repo names", "cost_center", mp.CostCenter)
-		return result
+		m.log.Error("No valid repo names", "cost_center", ccName)
+		return
 	}
 
 	// Log repos being assigned.
 	for i, name := range repoNames {
 		if i < 10 {
-			m.log.Info("Assigning repo", "repo", name, "cost_center", mp.CostCenter)
+			m.log.Info("Assigning repo", "repo", name, "cost_center", ccName)
 		}
 	}
 	if len(repoNames) > 10 {
@@ -267,21 +697,25 @@ func (m *Manager) processMapping(
 	}
 
 	// Call API to assign repos.
-	if err := m.client.AddRepositoriesToCostCenter(ccID, repoNames); err != nil {
+	repoResults, err := m.client.AddReposToCostCenter(ccID, repoNames)
+	for _, ok := range repoResults {
+		if ok {
+			result.ReposAssigned++
+		}
+	}
+	if err != nil {
 		result.Message = fmt.Sprintf("failed to assign repos: %v", err)
 		m.log.Error("Failed to assign repos",
-			"cost_center", mp.CostCenter, "error", err)
-		return result
+			"cost_center", ccName, "assigned", result.ReposAssigned, "error", err)
+		return
 	}
 
-	result.ReposAssigned = len(repoNames)
 	result.Success = true
 	result.Message = fmt.Sprintf("successfully assigned %d/%d repositories",
 		len(repoNames), len(matching))
 	m.log.Info("Successfully assigned repos",
-		"cost_center", mp.CostCenter, "assigned", len(repoNames))
+		"cost_center", ccName, "assigned", len(repoNames))
 
-	return result
 }
 
 // createBudgets creates configured budgets for a single cost center.
@@ -289,13 +723,13 @@ func (m *Manager) createBudgets(ccID, ccName string) error {
 	m.log.Info("Creating budgets for cost center", "name", ccName)
 
 	var failures []string
-	for product, pc := range m.cfg.BudgetProducts {
+	for product, pc := range m.cfg.BudgetProductsFor(ccName) {
 		if !pc.Enabled {
 			m.log.Debug("Skipping disabled product budget", "product", product)
 			continue
 		}
 
-		ok, err := m.client.CreateProductBudget(ccID, ccName, product, pc.Amount)
+		ok, err := m.client.CreateProductBudget(ccID, ccName, product, pc.Amount, github.AlertingFromConfig(pc))
 		if err != nil {
 			// If budgets API is unavailable, log and stop trying.
 			if _, unavailable := err.(*github.BudgetsAPIUnavailableError); unavailable {
@@ -320,17 +754,71 @@ func (m *Manager) createBudgets(ccID, ccName string) error {
 	return nil
 }
 
-// findMatchingRepos returns repos whose custom properties match the mapping criteria.
+// matchRepos evaluates every mapping that applies to org against its repos
+// and returns the matching repos per mapping index.  A repo matching several
+// mappings belongs to the first one only; a later mapping for another cost
+// center is logged.
+func (m *Manager) matchRepos(org string, repos []github.RepoProperties) ([][]github.RepoProperties, error) {
+	matches := make([][]github.RepoProperties, len(m.mappings))
+	owner := make(map[string]int) // repo full name -> mapping index
+	for i, mp := range m.mappings {
+		if !appliesTo(mp, org) {
+			continue
+		}
+		vm, err := config.NewValueMatcher(mp.PropertyValues, mp.CaseInsensitive)
+		if err != nil {
+			return nil, fmt.Errorf("repos.mappings[%d]: property_values: %w", i, err)
+		}
+		for _, r := range findMatchingRepos(repos, mp.PropertyName, vm) {
+			if first, ok := owner[r.RepositoryFullName]; ok {
+				if m.mappings[first].CostCenter != mp.CostCenter {
+					m.log.Warn("Repository matches several mappings, keeping the first",
+						"repo", r.RepositoryFullName,
+						"cost_center", m.mappings[first].CostCenter,
+						"ignored_cost_center", mp.CostCenter)
+				}
+				continue
+			}
+			owner[r.RepositoryFullName] = i
+			matches[i] = append(matches[i], r)
+		}
+	}
+	return matches, nil
+}
+
+// groupRepos returns the sorted repository full names per cost center for
+// the per-mapping matches from matchRepos, and the sorted full names of repos
+// that match no mapping.
+func groupRepos(repos []github.RepoProperties, mappings []config.ExplicitMapping, matches [][]github.RepoProperties) (map[string][]string, []string) {
+	matched := make(map[string]bool)
+	groups := make(map[string][]string)
+	for i, mp := range mappings {
+		for _, r := range matches[i] {
+			groups[mp.CostCenter] = append(groups[mp.CostCenter], r.RepositoryFullName)
+			matched[r.RepositoryFullName] = true
+		}
+	}
+	for cc := range groups {
+		sort.Strings(groups[cc])
+	}
+
+	var unmatched []string
+	for _, r := range repos {
+		if !matched[r.RepositoryFullName] {
+			unmatched = append(unmatched, r.RepositoryFullName)
+		}
+	}
+	sort.Strings(unmatched)
+	return groups, unmatched
+}
+
+// findMatchingRepos returns repos whose custom property propertyName has a
+// value accepted by vm.
 func findMatchingRepos(
 	repos []github.RepoProperties,
 	propertyName string,
-	propertyValues []string,
+	vm *config.ValueMatcher,
 ) []github.RepoProperties {
-	valueSet := make(map[string]bool, len(propertyValues))
-	for _, v := range propertyValues {
-		valueSet[v] = true
-	}
-
 	var matched []github.RepoProperties
 	for _, repo := range repos {
 		for _, prop := range repo.Properties {
@@ -338,7 +826,7 @@ func findMatchingRepos(
 				continue
 			}
 			// Property value can be a string or []string.
-			if matchesValue(prop.Value, valueSet) {
+			if matchesValue(prop.Value, vm) {
 				matched = append(matched, repo)
 				break
 			}
@@ -347,15 +835,14 @@ func findMatchingRepos(
 	return matched
 }
 
-// matchesValue checks if a property value (string or []any) matches any value
-// in the allowed set.
-func matchesValue(val any, allowed map[string]bool) bool {
+// matchesValue checks if a property value (string or []any) is accepted by vm.
+func matchesValue(val any, vm *config.ValueMatcher) bool {
 	switch v := val.(type) {
 	case string:
-		return allowed[v]
+		return vm.Match(v)
 	case []any:
 		for _, item := range v {
-			if s, ok := item.(string); ok && allowed[s] {
+			if s, ok := item.(string); ok && vm.Match(s) {
 				return true
 			}
 		}
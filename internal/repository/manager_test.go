@@ -1,11 +1,15 @@
 package repository
 
 import (
+	"bytes"
 	"encoding/json"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path"
+	"slices"
 	"strings"
 	"testing"
 
@@ -27,6 +31,16 @@ func newTestManager(mappings []config.ExplicitMapping) *Manager {
 	}
 }
 
+// mustMatcher compiles values or fails the test.
+func mustMatcher(t *testing.T, caseInsensitive bool, values ...string) *config.ValueMatcher {
+	t.Helper()
+	vm, err := config.NewValueMatcher(values, caseInsensitive)
+	if err != nil {
+		t.Fatalf("NewValueMatcher(%q): %v", values, err)
+	}
+	return vm
+}
+
 // --- NewManager tests ---
 
 func TestNewManager_NilConfig(t *testing.T) {
@@ -153,7 +167,7 @@ func TestFindMatchingRepos_StringValue(t *testing.T) {
 		},
 	}
 
-	matched := findMatchingRepos(repos, "team", []string{"engineering"})
+	matched := findMatchingRepos(repos, "team", mustMatcher(t, false, "engineering"))
 	if len(matched) != 2 {
 		t.Errorf("expected 2 matches, got %d", len(matched))
 	}
@@ -177,7 +191,7 @@ func TestFindMatchingRepos_ArrayValue(t *testing.T) {
 		},
 	}
 
-	matched := findMatchingRepos(repos, "tags", []string{"go"})
+	matched := findMatchingRepos(repos, "tags", mustMatcher(t, false, "go"))
 	if len(matched) != 1 {
 		t.Errorf("expected 1 match, got %d", len(matched))
 	}
@@ -211,7 +225,7 @@ func TestFindMatchingRepos_MultipleValues(t *testing.T) {
 		},
 	}
 
-	matched := findMatchingRepos(repos, "team", []string{"engineering", "devops"})
+	matched := findMatchingRepos(repos, "team", mustMatcher(t, false, "engineering", "devops"))
 	if len(matched) != 2 {
 		t.Errorf("expected 2 matches, got %d", len(matched))
 	}
@@ -228,7 +242,7 @@ func TestFindMatchingRepos_NoMatch(t *testing.T) {
 		},
 	}
 
-	matched := findMatchingRepos(repos, "team", []string{"engineering"})
+	matched := findMatchingRepos(repos, "team", mustMatcher(t, false, "engineering"))
 	if len(matched) != 0 {
 		t.Errorf("expected 0 matches, got %d", len(matched))
 	}
@@ -245,7 +259,7 @@ func TestFindMatchingRepos_DifferentPropertyName(t *testing.T) {
 		},
 	}
 
-	matched := findMatchingRepos(repos, "team", []string{"engineering"})
+	matched := findMatchingRepos(repos, "team", mustMatcher(t, false, "engineering"))
 	if len(matched) != 0 {
 		t.Errorf("should not match different property name, got %d", len(matched))
 	}
@@ -260,7 +274,7 @@ func TestFindMatchingRepos_NoProperties(t *testing.T) {
 		},
 	}
 
-	matched := findMatchingRepos(repos, "team", []string{"engineering"})
+	matched := findMatchingRepos(repos, "team", mustMatcher(t, false, "engineering"))
 	if len(matched) != 0 {
 		t.Errorf("expected 0 matches for repo with no properties, got %d", len(matched))
 	}
@@ -269,21 +283,21 @@ func TestFindMatchingRepos_NoProperties(t *testing.T) {
 // --- matchesValue tests ---
 
 func TestMatchesValue_StringMatch(t *testing.T) {
-	allowed := map[string]bool{"eng": true, "devops": true}
+	allowed := mustMatcher(t, false, "eng", "devops")
 	if !matchesValue("eng", allowed) {
 		t.Error("expected true for matching string")
 	}
 }
 
 func TestMatchesValue_StringNoMatch(t *testing.T) {
-	allowed := map[string]bool{"eng": true}
+	allowed := mustMatcher(t, false, "eng")
 	if matchesValue("sales", allowed) {
 		t.Error("expected false for non-matching string")
 	}
 }
 
 func TestMatchesValue_ArrayMatch(t *testing.T) {
-	allowed := map[string]bool{"go": true}
+	allowed := mustMatcher(t, false, "go")
 	val := []any{"python", "go", "javascript"}
 	if !matchesValue(val, allowed) {
 		t.Error("expected true for array containing matching value")
@@ -291,7 +305,7 @@ func TestMatchesValue_ArrayMatch(t *testing.T) {
 }
 
 func TestMatchesValue_ArrayNoMatch(t *testing.T) {
-	allowed := map[string]bool{"rust": true}
+	allowed := mustMatcher(t, false, "rust")
 	val := []any{"python", "go"}
 	if matchesValue(val, allowed) {
 		t.Error("expected false for array not containing matching value")
@@ -299,27 +313,92 @@ func TestMatchesValue_ArrayNoMatch(t *testing.T) {
 }
 
 func TestMatchesValue_NilValue(t *testing.T) {
-	allowed := map[string]bool{"eng": true}
+	allowed := mustMatcher(t, false, "eng")
 	if matchesValue(nil, allowed) {
 		t.Error("expected false for nil value")
 	}
 }
 
 func TestMatchesValue_IntValue(t *testing.T) {
-	allowed := map[string]bool{"eng": true}
+	allowed := mustMatcher(t, false, "eng")
 	if matchesValue(42, allowed) {
 		t.Error("expected false for int value")
 	}
 }
 
 func TestMatchesValue_EmptyArray(t *testing.T) {
-	allowed := map[string]bool{"eng": true}
+	allowed := mustMatcher(t, false, "eng")
 	val := []any{}
 	if matchesValue(val, allowed) {
 		t.Error("expected false for empty array")
 	}
 }
 
+func TestMatchesValue_Patterns(t *testing.T) {
+	tests := []struct {
+		values []string
+		fold   bool
+		val    any
+		want   bool
+	}{
+		{[]string{"payments-*"}, false, "payments-api", true},
+		{[]string{"payments-*"}, false, "billing", false},
+		{[]string{"payments-*"}, false, "Payments-api", false},
+		{[]string{"payments-*"}, true, "Payments-api", true},
+		{[]string{"team-?"}, false, []any{"ops", "team-a"}, true},
+		{[]string{"/^payments-(api|web)$/"}, false, "payments-web", true},
+		{[]string{"/^payments-(api|web)$/"}, false, "payments-db", false},
+		{[]string{"/^payments-/"}, true, "PAYMENTS-db", true},
+		{[]string{"Engineering"}, true, "engineering", true},
+		{[]string{"Engineering"}, false, "engineering", false},
+		{[]string{"payments-*"}, false, "payments-*", true},
+	}
+	for _, tt := range tests {
+		if got := matchesValue(tt.val, mustMatcher(t, tt.fold, tt.values...)); got != tt.want {
+			t.Errorf("matchesValue(%v, %q, fold=%v) = %v, want %v", tt.val, tt.values, tt.fold, got, tt.want)
+		}
+	}
+}
+
+func TestMatchRepos_FirstMappingWins(t *testing.T) {
+	repos := []github.RepoProperties{
+		{RepositoryFullName: "org/payments-api", Properties: []github.Property{{PropertyName: "team", Value: "payments-api"}}},
+		{RepositoryFullName: "org/payments-web", Properties: []github.Property{{PropertyName: "team", Value: "payments-web"}}},
+	}
+	mappings := []config.ExplicitMapping{
+		{CostCenter: "Payments API", PropertyName: "team", PropertyValues: []string{"payments-api"}},
+		{CostCenter: "Payments", PropertyName: "team", PropertyValues: []string{"payments-*"}},
+	}
+	mgr := newTestManager(mappings)
+	var logs bytes.Buffer
+	mgr.log = slog.New(slog.NewTextHandler(&logs, nil))
+
+	matches, err := mgr.matchRepos("org", repos)
+	if err != nil {
+		t.Fatalf("matchRepos: %v", err)
+	}
+	if len(matches[0]) != 1 || matches[0][0].RepositoryFullName != "org/payments-api" {
+		t.Errorf("mapping 0 = %v, want only org/payments-api", matches[0])
+	}
+	if len(matches[1]) != 1 || matches[1][0].RepositoryFullName != "org/payments-web" {
+		t.Errorf("mapping 1 = %v, want only org/payments-web", matches[1])
+	}
+	if !strings.Contains(logs.String(), "repo=org/payments-api") || !strings.Contains(logs.String(), `ignored_cost_center=Payments`) {
+		t.Errorf("expected a warning about org/payments-api matching two mappings, got:\n%s", logs.String())
+	}
+}
+
+func TestMatchRepos_InvalidPattern(t *testing.T) {
+	mgr := newTestManager([]config.ExplicitMapping{
+		{CostCenter: "cc", PropertyName: "team", PropertyValues: []string{"eng"}},
+		{CostCenter: "cc", PropertyName: "team", PropertyValues: []string{"/([/"}},
+	})
+	_, err := mgr.matchRepos("org", nil)
+	if err == nil || !strings.Contains(err.Error(), "repos.mappings[1]") {
+		t.Errorf("expected an error naming repos.mappings[1], got %v", err)
+	}
+}
+
 // --- Summary.Print test ---
 
 func TestSummaryPrint(t *testing.T) {
@@ -482,3 +561,519 @@ func TestCreateBudgets_DisabledProducts(t *testing.T) {
 		t.Errorf("expected nil error when all products disabled, got %v", err)
 	}
 }
+
+// --- groupRepos / Run tests ---
+
+func TestGroupRepos(t *testing.T) {
+	repos := []github.RepoProperties{
+		{RepositoryFullName: "org/api", Properties: []github.Property{{PropertyName: "team", Value: "platform"}}},
+		{RepositoryFullName: "org/web", Properties: []github.Property{{PropertyName: "team", Value: []any{"frontend", "design"}}}},
+		{RepositoryFullName: "org/infra", Properties: []github.Property{
+			{PropertyName: "team", Value: "platform"},
+			{PropertyName: "env", Value: "prod"},
+		}},
+		{RepositoryFullName: "org/sales", Properties: []github.Property{{PropertyName: "team", Value: "sales"}}},
+		{RepositoryFullName: "org/bare"}, // no properties at all
+		{RepositoryFullName: "org/misc", Properties: []github.Property{{PropertyName: "env", Value: nil}}},
+	}
+	mappings := []config.ExplicitMapping{
+		{CostCenter: "Platform", PropertyName: "team", PropertyValues: []string{"platform"}},
+		{CostCenter: "Platform", PropertyName: "env", PropertyValues: []string{"prod"}}, // org/infra again
+		{CostCenter: "Frontend", PropertyName: "team", PropertyValues: []string{"frontend"}},
+		{CostCenter: "Missing", PropertyName: "costcenter", PropertyValues: []string{"x"}},
+	}
+
+	matches, err := newTestManager(mappings).matchRepos("org", repos)
+	if err != nil {
+		t.Fatalf("matchRepos: %v", err)
+	}
+	groups, unmatched := groupRepos(repos, mappings, matches)
+
+	if got := strings.Join(groups["Platform"], ","); got != "org/api,org/infra" {
+		t.Errorf("Platform = %q, want org/api,org/infra (string values, de-duplicated)", got)
+	}
+	if got := strings.Join(groups["Frontend"], ","); got != "org/web" {
+		t.Errorf("Frontend = %q, want org/web ([]string value)", got)
+	}
+	if _, ok := groups["Missing"]; ok || len(groups) != 2 {
+		t.Errorf("groups = %v, want only Platform and Frontend", groups)
+	}
+	if got := strings.Join(unmatched, ","); got != "org/bare,org/misc,org/sales" {
+		t.Errorf("unmatched = %q, want repos without a matching property", got)
+	}
+}
+
+// repoTestServer serves the repos of each org (those named in archived as
+// archived), one existing cost center ("Platform") with the attached repos
+// of its ID, cost center creation, and records every write as
+// "METHOD path names...".
+func repoTestServer(t *testing.T, repos map[string][]github.RepoProperties, attached map[string][]string, archived ...string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var writes []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		p := r.URL.Path
+		switch {
+		case strings.HasSuffix(p, "/properties/values"):
+			_ = json.NewEncoder(w).Encode(repos[strings.Split(p, "/")[2]])
+		case strings.HasSuffix(p, "/repos") && r.Method == http.MethodGet:
+			statuses := []github.RepoStatus{}
+			for _, repo := range repos[strings.Split(p, "/")[2]] {
+				name := repo.RepositoryFullName
+				statuses = append(statuses, github.RepoStatus{FullName: name, Archived: slices.Contains(archived, name)})
+			}
+			_ = json.NewEncoder(w).Encode(statuses)
+		case strings.HasSuffix(p, "/settings/billing/cost-centers") && r.Method == http.MethodPost:
+			writes = append(writes, "POST create")
+			_ = json.NewEncoder(w).Encode(map[string]string{"id": "22222222-2222-2222-2222-222222222222"})
+		case strings.Contains(p, "/settings/billing/cost-centers/") && r.Method == http.MethodGet:
+			id := path.Base(p)
+			detail := github.CostCenterDetail{ID: id, State: "active"}
+			for _, name := range attached[id] {
+				detail.Resources = append(detail.Resources, github.Resource{Type: "Repository", Name: name})
+			}
+			_ = json.NewEncoder(w).Encode(detail)
+		case strings.HasSuffix(p, "/settings/billing/cost-centers"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"costCenters": []map[string]string{
+				{"id": "11111111-1111-1111-1111-111111111111", "name": "Platform", "state": "active"},
+			}})
+		default:
+			var body map[string][]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			writes = append(writes, r.Method+" "+path.Base(path.Dir(p))+" "+strings.Join(body["repositories"], ","))
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &writes
+}
+
+func TestRun_PlanAcrossOrgs(t *testing.T) {
+	repos := map[string][]github.RepoProperties{
+		"org1": {
+			{RepositoryFullName: "org1/api", Properties: []github.Property{{PropertyName: "team", Value: "platform"}}},
+			{RepositoryFullName: "org1/docs"},
+		},
+		"org2": {
+			{RepositoryFullName: "org2/infra", Properties: []github.Property{{PropertyName: "team", Value: "platform"}}},
+		},
+	}
+	srv, writes := repoTestServer(t, repos, nil)
+
+	mgr := newTestManager([]config.ExplicitMapping{
+		{CostCenter: "Platform", PropertyName: "team", PropertyValues: []string{"platform"}},
+	})
+	mgr.client = newTestClientFromURL(t, srv.URL)
+
+	summary, err := mgr.Run([]string{"org1", "org2"}, "plan", false)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if summary.TotalRepos != 3 {
+		t.Errorf("TotalRepos = %d, want 3", summary.TotalRepos)
+	}
+	if summary.CostCenterRepos["Platform"] != 2 {
+		t.Errorf("CostCenterRepos = %v, want Platform: 2", summary.CostCenterRepos)
+	}
+	if strings.Join(summary.UnmatchedRepos, ",") != "org1/docs" {
+		t.Errorf("UnmatchedRepos = %v", summary.UnmatchedRepos)
+	}
+	if len(*writes) != 0 {
+		t.Errorf("plan mode made writes: %v", *writes)
+	}
+}
+
+// captureStdout returns what fn writes to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = orig }()
+
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	fn()
+	_ = w.Close()
+	return <-done
+}
+
+func TestRun_OrgScopedMappings(t *testing.T) {
+	repos := map[string][]github.RepoProperties{
+		"org1": {
+			{RepositoryFullName: "org1/api", Properties: []github.Property{{PropertyName: "team", Value: "platform"}}},
+			{RepositoryFullName: "org1/etl", Properties: []github.Property{{PropertyName: "team", Value: "data"}}},
+		},
+		"org2": {
+			{RepositoryFullName: "org2/infra", Properties: []github.Property{{PropertyName: "team", Value: "platform"}}},
+			{RepositoryFullName: "org2/lake", Properties: []github.Property{{PropertyName: "team", Value: "data"}}},
+		},
+	}
+	srv, writes := repoTestServer(t, repos, nil)
+
+	mgr := newTestManager([]config.ExplicitMapping{
+		{CostCenter: "Platform", PropertyName: "team", PropertyValues: []string{"platform"}},
+		{CostCenter: "Data", Organization: "org2", PropertyName: "team", PropertyValues: []string{"data"}},
+	})
+	mgr.client = newTestClientFromURL(t, srv.URL)
+
+	summary, err := mgr.Run([]string{"org1", "org2"}, "apply", false)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := "POST 11111111-1111-1111-1111-111111111111 org1/api;" +
+		"POST 11111111-1111-1111-1111-111111111111 org2/infra;" +
+		"POST create;POST 22222222-2222-2222-2222-222222222222 org2/lake"
+	if got := strings.Join(*writes, ";"); got != want {
+		t.Errorf("writes = %s\nwant %s", got, want)
+	}
+	if summary.MappingsTotal != 3 || len(summary.MappingResults) != 3 {
+		t.Errorf("MappingsTotal = %d, results = %d; want 3 (org-scoped mapping once)", summary.MappingsTotal, len(summary.MappingResults))
+	}
+	if got := summary.OrgCostCenterRepos["org1"]; len(got) != 1 || got["Platform"] != 1 {
+		t.Errorf("org1 = %v, want Platform: 1", got)
+	}
+	if got := summary.OrgCostCenterRepos["org2"]; len(got) != 2 || got["Platform"] != 1 || got["Data"] != 1 {
+		t.Errorf("org2 = %v, want Platform: 1, Data: 1", got)
+	}
+	if strings.Join(summary.UnmatchedRepos, ",") != "org1/etl" {
+		t.Errorf("UnmatchedRepos = %v, want the org1 repo the org2 mapping ignores", summary.UnmatchedRepos)
+	}
+
+	out := captureStdout(t, summary.Print)
+	grouped := "Repositories per organization and cost center:\n  org1\n    Platform: 1\n  org2\n    Data: 1\n    Platform: 1\n"
+	if !strings.Contains(out, grouped) {
+		t.Errorf("summary output missing grouped counts:\n%s", out)
+	}
+}
+
+func TestRun_UnmatchedPolicy(t *testing.T) {
+	repos := map[string][]github.RepoProperties{"org1": {
+		{RepositoryFullName: "org1/api", Properties: []github.Property{{PropertyName: "team", Value: "platform"}}},
+		{RepositoryFullName: "org1/docs"},
+		{RepositoryFullName: "org1/misc", Properties: []github.Property{{PropertyName: "team", Value: "sales"}}},
+	}}
+	mappings := []config.ExplicitMapping{{CostCenter: "Platform", PropertyName: "team", PropertyValues: []string{"platform"}}}
+
+	t.Run("skip", func(t *testing.T) {
+		srv, writes := repoTestServer(t, repos, nil)
+		mgr := newTestManager(mappings)
+		mgr.client = newTestClientFromURL(t, srv.URL)
+		mgr.unmatchedPolicy = "skip"
+
+		summary, err := mgr.Run([]string{"org1"}, "apply", false)
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if strings.Join(*writes, ";") != "POST 11111111-1111-1111-1111-111111111111 org1/api" {
+			t.Errorf("writes = %v, want only the mapped repo", *writes)
+		}
+		if len(summary.MappingResults) != 1 || strings.Join(summary.UnmatchedRepos, ",") != "org1/docs,org1/misc" {
+			t.Errorf("summary = %+v", summary)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		srv, writes := repoTestServer(t, repos, nil)
+		mgr := newTestManager(mappings)
+		mgr.client = newTestClientFromURL(t, srv.URL)
+		mgr.unmatchedPolicy = "error"
+
+		summary, err := mgr.Run([]string{"org1"}, "apply", false)
+		if err == nil || !strings.Contains(err.Error(), "2 repositories match no mapping") {
+			t.Errorf("err = %v, want unmatched error", err)
+		}
+		if summary == nil || len(summary.UnmatchedRepos) != 2 {
+			t.Errorf("summary = %+v, want the unmatched repos listed", summary)
+		}
+		if len(*writes) != 0 {
+			t.Errorf("writes = %v, want none before failing", *writes)
+		}
+	})
+
+	t.Run("assign_default", func(t *testing.T) {
+		srv, writes := repoTestServer(t, repos, nil)
+		mgr := newTestManager(mappings)
+		mgr.client = newTestClientFromURL(t, srv.URL)
+		mgr.unmatchedPolicy, mgr.defaultCC = "assign_default", "Unallocated"
+
+		summary, err := mgr.Run([]string{"org1"}, "apply", false)
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		want := "POST 11111111-1111-1111-1111-111111111111 org1/api;POST create;POST 22222222-2222-2222-2222-222222222222 org1/docs,org1/misc"
+		if got := strings.Join(*writes, ";"); got != want {
+			t.Errorf("writes = %s\nwant %s", got, want)
+		}
+		last := summary.MappingResults[len(summary.MappingResults)-1]
+		if !last.Default || last.CostCenter != "Unallocated" || last.ReposAssigned != 2 || !last.Success {
+			t.Errorf("default result = %+v", last)
+		}
+		if summary.CostCenterRepos["Unallocated"] != 2 {
+			t.Errorf("CostCenterRepos = %v", summary.CostCenterRepos)
+		}
+	})
+}
+
+func TestRun_SkipsArchivedRepos(t *testing.T) {
+	repos := map[string][]github.RepoProperties{"org1": {
+		{RepositoryFullName: "org1/api", Properties: []github.Property{{PropertyName: "team", Value: "platform"}}},
+		{RepositoryFullName: "org1/old-api", Properties: []github.Property{{PropertyName: "team", Value: "platform"}}},
+		{RepositoryFullName: "org1/old-docs"},
+	}}
+	mappings := []config.ExplicitMapping{{CostCenter: "Platform", PropertyName: "team", PropertyValues: []string{"platform"}}}
+
+	t.Run("skipped by default", func(t *testing.T) {
+		srv, writes := repoTestServer(t, repos, nil, "org1/old-api", "org1/old-docs")
+		mgr := newTestManager(mappings)
+		mgr.client = newTestClientFromURL(t, srv.URL)
+
+		summary, err := mgr.Run([]string{"org1"}, "apply", false)
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if got := strings.Join(*writes, ";"); got != "POST 11111111-1111-1111-1111-111111111111 org1/api" {
+			t.Errorf("writes = %s, want only the active repo", got)
+		}
+		if summary.ArchivedSkipped != 2 || summary.TotalRepos != 1 || len(summary.UnmatchedRepos) != 0 {
+			t.Errorf("ArchivedSkipped = %d, TotalRepos = %d, UnmatchedRepos = %v; want 2, 1, none",
+				summary.ArchivedSkipped, summary.TotalRepos, summary.UnmatchedRepos)
+		}
+		if out := captureStdout(t, summary.Print); !strings.Contains(out, "Archived or disabled repositories skipped: 2") {
+			t.Errorf("summary output does not report the skipped repos:\n%s", out)
+		}
+	})
+
+	t.Run("include_archived", func(t *testing.T) {
+		srv, writes := repoTestServer(t, repos, nil, "org1/old-api", "org1/old-docs")
+		mgr := newTestManager(mappings)
+		mgr.client = newTestClientFromURL(t, srv.URL)
+		mgr.includeArchived = true
+
+		summary, err := mgr.Run([]string{"org1"}, "apply", false)
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if got := strings.Join(*writes, ";"); got != "POST 11111111-1111-1111-1111-111111111111 org1/api,org1/old-api" {
+			t.Errorf("writes = %s, want the archived repo assigned too", got)
+		}
+		if summary.ArchivedSkipped != 0 || summary.TotalRepos != 3 {
+			t.Errorf("ArchivedSkipped = %d, TotalRepos = %d; want 0, 3", summary.ArchivedSkipped, summary.TotalRepos)
+		}
+	})
+}
+
+func TestDiffRepos(t *testing.T) {
+	desired := map[string][]string{
+		"Platform": {"org/api", "org/infra", "org/moved"},
+		"Data":     {"org/etl"},
+	}
+	current := map[string][]string{
+		"Platform": {"org/api", "org/stale", "org/other-org-repo"},
+		"Data":     {"org/etl", "org/moved"},
+	}
+	evaluated := map[string]bool{"org/api": true, "org/infra": true, "org/moved": true, "org/etl": true, "org/stale": true}
+
+	changes := diffRepos(desired, current, evaluated, false)
+	platform, data := changes["Platform"], changes["Data"]
+	if strings.Join(platform.Add, ",") != "org/infra,org/moved" || len(platform.Remove) != 0 || platform.Unchanged != 1 {
+		t.Errorf("Platform = %+v, want +infra +moved, 1 unchanged, nothing removed", platform)
+	}
+	if len(data.Add) != 0 || strings.Join(data.Remove, ",") != "org/moved" || data.Unchanged != 1 {
+		t.Errorf("Data = %+v, want -moved (it moved to Platform), 1 unchanged", data)
+	}
+
+	changes = diffRepos(desired, current, evaluated, true)
+	if got := strings.Join(changes["Platform"].Remove, ","); got != "org/stale" {
+		t.Errorf("Platform removes with remove_unmatched = %q, want only the evaluated stale repo", got)
+	}
+	if got := strings.Join(changes["Data"].Remove, ","); got != "org/moved" {
+		t.Errorf("Data removes with remove_unmatched = %q, want org/moved", got)
+	}
+}
+
+func TestRun_AppliesOnlyDelta(t *testing.T) {
+	const platformID = "11111111-1111-1111-1111-111111111111"
+	repos := map[string][]github.RepoProperties{"org1": {
+		{RepositoryFullName: "org1/api", Properties: []github.Property{{PropertyName: "team", Value: "platform"}}},
+		{RepositoryFullName: "org1/infra", Properties: []github.Property{{PropertyName: "team", Value: "platform"}}},
+		{RepositoryFullName: "org1/old"},
+	}}
+	attached := map[string][]string{platformID: {"org1/api", "org1/old", "org9/elsewhere"}}
+	mappings := []config.ExplicitMapping{{CostCenter: "Platform", PropertyName: "team", PropertyValues: []string{"platform"}}}
+
+	t.Run("plan", func(t *testing.T) {
+		srv, writes := repoTestServer(t, repos, attached)
+		mgr := newTestManager(mappings)
+		mgr.client = newTestClientFromURL(t, srv.URL)
+		mgr.removeUnmatched = true
+
+		summary, err := mgr.Run([]string{"org1"}, "plan", false)
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if len(*writes) != 0 {
+			t.Errorf("plan mode made writes: %v", *writes)
+		}
+		out := captureStdout(t, summary.Print)
+		for _, want := range []string{
+			"Changes against current assignments: 1 to add, 1 to remove, 1 unchanged",
+			"  Platform: +1 -1 (1 unchanged)\n    + org1/infra\n    - org1/old\n",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("summary output missing %q:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		srv, writes := repoTestServer(t, repos, attached)
+		mgr := newTestManager(mappings)
+		mgr.client = newTestClientFromURL(t, srv.URL)
+		mgr.removeUnmatched = true
+
+		summary, err := mgr.Run([]string{"org1"}, "apply", false)
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		want := "DELETE " + platformID + " org1/old;POST " + platformID + " org1/infra"
+		if got := strings.Join(*writes, ";"); got != want {
+			t.Errorf("writes = %s\nwant %s", got, want)
+		}
+		r := summary.MappingResults[0]
+		if !r.Success || r.ReposAssigned != 1 || r.ReposUnchanged != 1 {
+			t.Errorf("result = %+v, want 1 assigned, 1 unchanged", r)
+		}
+	})
+
+	t.Run("nothing to do", func(t *testing.T) {
+		srv, writes := repoTestServer(t, repos, map[string][]string{platformID: {"org1/api", "org1/infra", "org1/old"}})
+		mgr := newTestManager(mappings)
+		mgr.client = newTestClientFromURL(t, srv.URL)
+
+		summary, err := mgr.Run([]string{"org1"}, "apply", false)
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if len(*writes) != 0 {
+			t.Errorf("writes = %v, want none when everything is attached and remove_unmatched is off", *writes)
+		}
+		if r := summary.MappingResults[0]; !r.Success || r.ReposUnchanged != 2 {
+			t.Errorf("result = %+v, want success with 2 unchanged", r)
+		}
+		if out := captureStdout(t, summary.Print); !strings.Contains(out, "Nothing to do") {
+			t.Errorf("summary output should say there is nothing to do:\n%s", out)
+		}
+	})
+}
+
+// --- CheckPropertySchema tests ---
+
+// schemaTestManager returns a Manager for mappings whose client serves
+// schemas (org -> definitions) and whose warnings are written to logs.
+func schemaTestManager(t *testing.T, mappings []config.ExplicitMapping, schemas map[string][]github.PropertyDefinition) (*Manager, *bytes.Buffer) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(r.URL.Path, "/") // /orgs/{org}/properties/schema
+		defs, ok := schemas[parts[2]]
+		if !ok || !strings.HasSuffix(r.URL.Path, "/properties/schema") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(defs)
+	}))
+	t.Cleanup(srv.Close)
+
+	mgr := newTestManager(mappings)
+	mgr.client = newTestClientFromURL(t, srv.URL)
+	var logs bytes.Buffer
+	mgr.log = slog.New(slog.NewTextHandler(&logs, nil))
+	return mgr, &logs
+}
+
+func TestCheckPropertySchema_UndefinedProperty(t *testing.T) {
+	mgr, _ := schemaTestManager(t, []config.ExplicitMapping{
+		{CostCenter: "Platform", PropertyName: "team", PropertyValues: []string{"platform"}},
+		{CostCenter: "Finance", PropertyName: "costcenter", PropertyValues: []string{"fin"}},
+	}, map[string][]github.PropertyDefinition{
+		"org1": {{PropertyName: "team", ValueType: "string"}, {PropertyName: "cost-center", ValueType: "string"}},
+		"org2": {{PropertyName: "team", ValueType: "string"}, {PropertyName: "costcenter", ValueType: "string"}},
+	})
+
+	problems, err := mgr.CheckPropertySchema([]string{"org1", "org2"})
+	if err != nil {
+		t.Fatalf("CheckPropertySchema: %v", err)
+	}
+	if len(problems) != 1 {
+		t.Fatalf("problems = %q, want one for org1", problems)
+	}
+	for _, want := range []string{"mapping 2", `"costcenter"`, "org1", `did you mean "cost-center"?`} {
+		if !strings.Contains(problems[0], want) {
+			t.Errorf("problem %q does not mention %s", problems[0], want)
+		}
+	}
+}
+
+func TestCheckPropertySchema_NoSuggestion(t *testing.T) {
+	mgr, _ := schemaTestManager(t, []config.ExplicitMapping{
+		{CostCenter: "Platform", PropertyName: "environment", PropertyValues: []string{"prod"}},
+	}, map[string][]github.PropertyDefinition{
+		"org1": {{PropertyName: "team", ValueType: "string"}},
+	})
+
+	problems, err := mgr.CheckPropertySchema([]string{"org1"})
+	if err != nil {
+		t.Fatalf("CheckPropertySchema: %v", err)
+	}
+	if len(problems) != 1 || strings.Contains(problems[0], "did you mean") {
+		t.Errorf("problems = %q, want one without a suggestion", problems)
+	}
+}
+
+func TestCheckPropertySchema_AllowedValuesWarning(t *testing.T) {
+	mgr, logs := schemaTestManager(t, []config.ExplicitMapping{
+		{CostCenter: "Platform", PropertyName: "team", PropertyValues: []string{"platform", "platfrom", "infra-*", "/^ops/"}},
+		{CostCenter: "Web", PropertyName: "tags", PropertyValues: []string{"anything"}},
+	}, map[string][]github.PropertyDefinition{
+		"org1": {
+			{PropertyName: "team", ValueType: "single_select", AllowedValues: []string{"platform", "infra-core", "web"}},
+			{PropertyName: "tags", ValueType: "multi_select", AllowedValues: []string{"go"}},
+		},
+	})
+
+	problems, err := mgr.CheckPropertySchema([]string{"org1"})
+	if err != nil {
+		t.Fatalf("CheckPropertySchema: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("problems = %q, want none", problems)
+	}
+	out := logs.String()
+	for _, want := range []string{"value=platfrom", "value=/^ops/"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected a warning for %s, got:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"value=platform ", "value=infra-*", "value=anything"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("unexpected warning for %s:\n%s", unwanted, out)
+		}
+	}
+}
+
+func TestCheckPropertySchema_FetchError(t *testing.T) {
+	mgr, _ := schemaTestManager(t, []config.ExplicitMapping{
+		{CostCenter: "Platform", PropertyName: "team", PropertyValues: []string{"platform"}},
+	}, nil)
+
+	if _, err := mgr.CheckPropertySchema([]string{"org1"}); err == nil {
+		t.Error("expected an error when the schema cannot be fetched")
+	}
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+// CheckPropertySchema fetches the custom property schema of each org and
+// returns a problem for every mapping applying to an org whose property_name
+// it does not define, suggesting the closest defined name.  For single_select
+// properties, property_values entries matching none of the allowed values
+// are logged as warnings.
+func (m *Manager) CheckPropertySchema(orgs []string) ([]string, error) {
+	var problems []string
+	for _, org := range orgs {
+		defs, err := m.client.GetOrgPropertySchema(org)
+		if err != nil {
+			return nil, err
+		}
+		byName := make(map[string]github.PropertyDefinition, len(defs))
+		names := make([]string, 0, len(defs))
+		for _, d := range defs {
+			byName[d.PropertyName] = d
+			names = append(names, d.PropertyName)
+		}
+
+		for i, mp := range m.mappings {
+			if !appliesTo(mp, org) {
+				continue
+			}
+			def, ok := byName[mp.PropertyName]
+			if !ok {
+				problem := fmt.Sprintf("mapping %d: custom property %q is not defined in organization %s", i+1, mp.PropertyName, org)
+				if s := config.SuggestName(mp.PropertyName, names); s != "" {
+					problem += fmt.Sprintf(" (did you mean %q?)", s)
+				}
+				problems = append(problems, problem)
+				continue
+			}
+			if def.ValueType != "single_select" {
+				continue
+			}
+			for _, v := range mp.PropertyValues {
+				if !matchesAllowed(v, mp.CaseInsensitive, def.AllowedValues) {
+					m.log.Warn("Property value is not an allowed value of the custom property",
+						"mapping", i+1, "org", org, "property", mp.PropertyName, "value", v,
+						"allowed_values", strings.Join(def.AllowedValues, ","))
+				}
+			}
+		}
+	}
+	return problems, nil
+}
+
+// matchesAllowed reports whether the property_values entry v (an exact
+// value or a pattern) matches at least one of allowed.
+func matchesAllowed(v string, caseInsensitive bool, allowed []string) bool {
+	vm, err := config.NewValueMatcher([]string{v}, caseInsensitive)
+	if err != nil {
+		return false
+	}
+	for _, a := range allowed {
+		if vm.Match(a) {
+			return true
+		}
+	}
+	return false
+}
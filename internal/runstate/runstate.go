@@ -0,0 +1,194 @@
+// Package runstate records per-user assignment results of apply runs so that
+// a run that fails halfway can be resumed.  Each run is an append-only JSON
+// Lines file under <export_dir>/runs, one result per line, written after every
+// batch so that a crash loses at most the batch in flight.
+package runstate
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// runsDir is the directory inside the export dir holding run files.
+	runsDir = "runs"
+	// fileExt is the extension of run files.
+	fileExt = ".jsonl"
+	// runIDLayout formats run IDs so that they sort chronologically.
+	runIDLayout = "20060102-150405.000"
+	// Latest is the --resume reference to the most recent run.
+	Latest = "latest"
+)
+
+// Result is one recorded assignment outcome.
+type Result struct {
+	RunID      string    `json:"run_id"`
+	CostCenter string    `json:"cost_center"`
+	Login      string    `json:"login"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// Recorder appends results to one run file.  It is safe for concurrent use.
+type Recorder struct {
+	mu    sync.Mutex
+	runID string
+	path  string
+}
+
+// New starts a new run under exportDir and returns its recorder.
+func New(exportDir string) (*Recorder, error) {
+	dir := filepath.Join(exportDir, runsDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating run state directory: %w", err)
+	}
+	id := time.Now().UTC().Format(runIDLayout)
+	return &Recorder{runID: id, path: filepath.Join(dir, id+fileExt)}, nil
+}
+
+// Resume returns a recorder that appends to the existing run state.
+func Resume(state *State) *Recorder {
+	return &Recorder{runID: state.RunID, path: state.path}
+}
+
+// RunID returns the ID of the run being recorded.
+func (r *Recorder) RunID() string { return r.runID }
+
+// Record appends the results of one batch for a cost center.  batchErr, when
+// set, is stored as the error of every failed login.
+func (r *Recorder) Record(costCenterID string, results map[string]bool, batchErr error) error {
+	if len(results) == 0 {
+		return nil
+	}
+	logins := make([]string, 0, len(results))
+	for login := range results {
+		logins = append(logins, login)
+	}
+	sort.Strings(logins)
+
+	now := time.Now().UTC()
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	for _, login := range logins {
+		res := Result{RunID: r.runID, CostCenter: costCenterID, Login: login, Success: results[login], Time: now}
+		if !res.Success {
+			res.Error = "not assigned"
+			if batchErr != nil {
+				res.Error = batchErr.Error()
+			}
+		}
+		if err := enc.Encode(res); err != nil {
+			return fmt.Errorf("encoding run state: %w", err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening run state file: %w", err)
+	}
+	if _, err := f.WriteString(buf.String()); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing run state file: %w", err)
+	}
+	return f.Close()
+}
+
+// State is a loaded run.  A login's latest result for a cost center wins, so
+// a resumed run that succeeds overrides an earlier failure.
+type State struct {
+	RunID   string
+	Results []Result
+	path    string
+
+	succeeded map[string]bool // cost center + "/" + lower-cased login
+}
+
+// Load reads the run state for ref, which is a run ID or Latest.
+func Load(exportDir, ref string) (*State, error) {
+	dir := filepath.Join(exportDir, runsDir)
+	id := ref
+	if ref == Latest {
+		var err error
+		if id, err = latestRunID(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	path := filepath.Join(dir, id+fileExt)
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("run %q not found in %s", id, dir)
+		}
+		return nil, fmt.Errorf("opening run state: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	s := &State{RunID: id, path: path, succeeded: make(map[string]bool)}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var res Result
+		if err := json.Unmarshal(scanner.Bytes(), &res); err != nil {
+			// A crash can leave a truncated line; its users are simply
+			// sent again.
+			continue
+		}
+		s.Results = append(s.Results, res)
+		s.succeeded[key(res.CostCenter, res.Login)] = res.Success
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading run state: %w", err)
+	}
+	return s, nil
+}
+
+// Succeeded reports whether login was successfully assigned to the cost
+// center in this run.
+func (s *State) Succeeded(costCenterID, login string) bool {
+	return s.succeeded[key(costCenterID, login)]
+}
+
+// SucceededCount returns the number of (cost center, login) pairs whose
+// latest result is a success.
+func (s *State) SucceededCount() int {
+	n := 0
+	for _, ok := range s.succeeded {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// latestRunID returns the most recent run ID in dir.
+func latestRunID(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("listing runs: %w", err)
+	}
+	var ids []string
+	for _, e := range entries {
+		if name := e.Name(); !e.IsDir() && strings.HasSuffix(name, fileExt) {
+			ids = append(ids, strings.TrimSuffix(name, fileExt))
+		}
+	}
+	if len(ids) == 0 {
+		return "", fmt.Errorf("no previous runs found in %s", dir)
+	}
+	sort.Strings(ids)
+	return ids[len(ids)-1], nil
+}
+
+func key(costCenterID, login string) string {
+	return costCenterID + "/" + strings.ToLower(login)
+}
@@ -0,0 +1,70 @@
+package runstate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	rec, err := New(dir)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := rec.Record("cc1", map[string]bool{"Alice": true, "bob": false}, errors.New("boom")); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	state, err := Load(dir, Latest)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if state.RunID != rec.RunID() || len(state.Results) != 2 {
+		t.Fatalf("state = %+v", state)
+	}
+	if !state.Succeeded("cc1", "alice") || state.Succeeded("cc1", "bob") || state.Succeeded("cc2", "alice") {
+		t.Error("Succeeded must match per cost center and case-insensitively")
+	}
+	if state.Results[1].Error != "boom" {
+		t.Errorf("failed result error = %q, want boom", state.Results[1].Error)
+	}
+
+	// A resumed run appends; the latest result per login wins.
+	if err := Resume(state).Record("cc1", map[string]bool{"bob": true}, nil); err != nil {
+		t.Fatalf("Record on resume: %v", err)
+	}
+	if state, err = Load(dir, rec.RunID()); err != nil {
+		t.Fatalf("Load by ID: %v", err)
+	}
+	if !state.Succeeded("cc1", "bob") || state.SucceededCount() != 2 {
+		t.Errorf("after resume: SucceededCount = %d, want 2", state.SucceededCount())
+	}
+}
+
+func TestLoad_TruncatedLineAndMissingRuns(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Load(dir, Latest); err == nil {
+		t.Error("expected an error when there are no runs")
+	}
+	if _, err := Load(dir, "nope"); err == nil {
+		t.Error("expected an error for an unknown run ID")
+	}
+
+	runs := filepath.Join(dir, runsDir)
+	if err := os.MkdirAll(runs, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data := `{"run_id":"r1","cost_center":"cc1","login":"alice","success":true}` + "\n" + `{"run_id":"r1","cost_ce`
+	if err := os.WriteFile(filepath.Join(runs, "r1"+fileExt), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	state, err := Load(dir, "r1")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(state.Results) != 1 || !state.Succeeded("cc1", "alice") {
+		t.Errorf("truncated last line should be ignored, got %+v", state.Results)
+	}
+}
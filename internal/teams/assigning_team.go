@@ -0,0 +1,113 @@
+package teams
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/renan-alm/gh-cost-center/internal/config"
+	"github.com/renan-alm/gh-cost-center/internal/github"
+)
+
+// NewAssigningTeamManager creates a Manager for the assigning-team mode, in
+// which users are grouped by the team that granted their Copilot seat rather
+// than by team membership.  It reuses the teams cost center resolution,
+// auto-creation, and budget machinery.
+func NewAssigningTeamManager(cfg *config.Manager, client github.CostCenterAPI, logger *slog.Logger) *Manager {
+	m := NewManager(cfg, client, logger)
+	m.autoCreate = cfg.AssigningTeamAutoCreate
+	m.removeUsers = false
+	return m
+}
+
+// AssigningTeamCostCenterName returns the cost center name used for seats
+// granted through the given team: "[assigning team] {org}/{slug}".  Team
+// display names are not unique across organizations, so the owning org and
+// slug are used.  seatOrg is the seat's organization, used when the org
+// cannot be derived from the team itself.
+func AssigningTeamCostCenterName(team *github.AssigningTeam, seatOrg string) string {
+	slug := team.Slug
+	if slug == "" {
+		slug = team.Name
+	}
+	org := team.Org()
+	if org == "" {
+		org = seatOrg
+	}
+	if org == "" {
+		return fmt.Sprintf("[assigning team] %s", slug)
+	}
+	return fmt.Sprintf("[assigning team] %s/%s", org, slug)
+}
+
+// BuildAssigningTeamAssignments groups users by the team that granted their
+// Copilot seat.  Users whose seat has no assigning team are placed in
+// defaultCC.
+//
+// Returns a map of costCenterName -> []UserAssignment.
+func BuildAssigningTeamAssignments(users []github.CopilotUser, defaultCC string) map[string][]UserAssignment {
+	assignments := make(map[string][]UserAssignment)
+	for _, u := range users {
+		if u.Login == "" {
+			continue
+		}
+		ua := UserAssignment{Username: u.Login, CostCenter: defaultCC}
+		if u.AssigningTeam != nil && (u.AssigningTeam.Name != "" || u.AssigningTeam.Slug != "") {
+			ua.CostCenter = AssigningTeamCostCenterName(u.AssigningTeam, u.Organization)
+			ua.TeamSlug = u.AssigningTeam.Slug
+		}
+		assignments[ua.CostCenter] = append(assignments[ua.CostCenter], ua)
+	}
+	return assignments
+}
+
+// SyncAssigningTeamAssignments assigns the given Copilot users to cost centers
+// named after the team that granted their seat.  In plan mode it previews
+// changes; in apply mode it pushes the assignments.
+func (m *Manager) SyncAssigningTeamAssignments(users []github.CopilotUser, mode string, ignoreCurrentCC bool) (map[string]map[string]bool, error) {
+	if !m.cfg.IncludeNonUserAccounts {
+		kept := make([]github.CopilotUser, 0, len(users))
+		for _, u := range users {
+			if !github.IsUserAccount(u.Type) {
+				m.log.Debug("Skipping non-user account", "user", u.Login, "type", u.Type)
+				continue
+			}
+			kept = append(kept, u)
+		}
+		users = kept
+	}
+
+	defaultCC := m.cfg.AssigningTeamDefaultCostCenter
+	assignments := BuildAssigningTeamAssignments(users, defaultCC)
+	if len(assignments) == 0 {
+		m.log.Warn("No Copilot users to assign")
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(assignments))
+	for name := range assignments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		m.log.Info("Assigning-team group", "cost_center", name, "users", len(assignments[name]))
+	}
+	if n := len(assignments[defaultCC]); n > 0 {
+		m.log.Info("Users without an assigning team go to the default cost center",
+			"cost_center", defaultCC, "users", n)
+	}
+
+	return m.syncAssignments(assignments, mode, ignoreCurrentCC)
+}
+
+// PrintAssigningTeamConfigSummary displays the assigning-team mode configuration.
+func (m *Manager) PrintAssigningTeamConfigSummary(checkCurrent, createBudgets bool) {
+	fmt.Println("\n===== Assigning-Team Mode Configuration =====")
+	fmt.Printf("Enterprise: %s\n", m.cfg.Enterprise)
+	fmt.Printf("Auto-create cost centers: %v\n", m.autoCreate)
+	fmt.Printf("Default cost center (no assigning team): %s\n", m.cfg.AssigningTeamDefaultCostCenter)
+	fmt.Printf("Check current cost center: %v\n", checkCurrent)
+	fmt.Printf("Create budgets: %v\n", createBudgets)
+	fmt.Println("Cost center naming: [assigning team] {org-name}/{team-slug}")
+	fmt.Println("===== End of Configuration =====")
+}
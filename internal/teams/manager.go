@@ -4,17 +4,31 @@
 package teams
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"path"
+	"slices"
 	"sort"
 	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
 
 	"github.com/renan-alm/gh-cost-center/internal/config"
 	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/progress"
+	"github.com/renan-alm/gh-cost-center/internal/render"
 )
 
+// DefaultParallel is the default number of teams whose members are fetched
+// at once.
+const DefaultParallel = 5
+
 // UserAssignment records the cost center assignment for a user found via a
-// team.  Only the final (last-team-wins) assignment is kept per user.
+// team.  Only one assignment is kept per user; see Manager.resolveConflict.
 type UserAssignment struct {
 	Username   string
 	CostCenter string
@@ -25,42 +39,88 @@ type UserAssignment struct {
 // Manager handles teams-based cost center assignment logic.
 type Manager struct {
 	cfg    *config.Manager
-	client *github.Client
+	client github.CostCenterAPI
 	log    *slog.Logger
 
 	// Configuration copied from config for convenience.
-	scope       string // "organization" or "enterprise"
-	mode        string // "auto" or "manual"
-	orgs        []string
-	autoCreate  bool
-	mappings    map[string]string // team key -> CC name (manual mode)
-	removeUsers bool
-
-	// Budget creation support.
+	scope        string // "organization" or "enterprise"
+	mode         string // "auto" or "manual"
+	orgs         []string
+	autoCreate   bool
+	mappings     map[string]string // team key -> CC name (manual mode)
+	removeUsers  bool
+	nameTemplate string // auto-mode CC name template
+
+	// Multi-team users: policy, team priority, and the last run's conflicts.
+	conflictPolicy string   // "first_alphabetical", "priority_list", or "error"
+	priority       []string // team keys, highest priority first
+	conflicts      []Conflict
+
+	// Team filters and the number of teams they dropped in the last fetch.
+	include, exclude []string
+	filteredTeams    int
+
+	// Copilot seat holders filter and the members it skipped per team key.
+	copilotOnly       bool
+	nonCopilotSkipped map[string]int
+	seatHolders       map[string]string // lower-cased login -> login, once fetched
+
+	// Teams without a cost center mapping and the users of mapped teams,
+	// from the last BuildTeamAssignments call, for the unmapped report.
+	unmappedTeams []memberJob
+	mappedUsers   map[string]bool // lower-cased logins
+
+	// Member fetching: concurrency, fail-fast, and the last run's failures.
+	parallel          int
+	failFast          bool
+	failedTeams       map[string]error // team key -> error
+	failedCostCenters map[string]bool  // CC names with a failed team
+
+	// Budget creation support.  budgetByTeam sets the premium request budget by
+	// team slug or key; ccTeams maps each CC name to its teams' keys.
 	createBudgets  bool
 	budgetProducts map[string]config.ProductBudget
+	budgetByTeam   map[string]int
+	ccTeams        map[string][]string
 
 	// Caches populated during a run.
 	teamsCache   map[string][]github.Team // org/enterprise -> teams
 	membersCache map[string][]string      // team-key -> usernames
 	ccNameCache  map[string]string        // team-key -> CC name
+
+	// lastSync is the outcome of the last syncAssignments call.
+	lastSync *SyncResult
+
+	// confirm, when set, is asked before an apply-mode sync changes anything.
+	confirm func(adds map[string][]string) (bool, error)
 }
 
+// ErrAborted is returned by an apply-mode sync whose confirmation was
+// declined.
+var ErrAborted = errors.New("aborted by user")
+
 // NewManager creates a new teams manager from the resolved configuration.
-func NewManager(cfg *config.Manager, client *github.Client, logger *slog.Logger) *Manager {
+func NewManager(cfg *config.Manager, client github.CostCenterAPI, logger *slog.Logger) *Manager {
 	return &Manager{
-		cfg:          cfg,
-		client:       client,
-		log:          logger,
-		scope:        cfg.TeamsScope,
-		mode:         cfg.TeamsStrategy,
-		orgs:         cfg.Organizations,
-		autoCreate:   cfg.TeamsAutoCreate,
-		mappings:     cfg.TeamsMappings,
-		removeUsers:  cfg.TeamsRemoveUnmatchedUsers,
-		teamsCache:   make(map[string][]github.Team),
-		membersCache: make(map[string][]string),
-		ccNameCache:  make(map[string]string),
+		cfg:            cfg,
+		client:         client,
+		log:            logger,
+		scope:          cfg.TeamsScope,
+		mode:           cfg.TeamsStrategy,
+		orgs:           cfg.Organizations,
+		autoCreate:     cfg.TeamsAutoCreate,
+		mappings:       cfg.TeamsMappings,
+		removeUsers:    cfg.TeamsRemoveUnmatchedUsers,
+		nameTemplate:   cfg.TeamsNameTemplate,
+		conflictPolicy: cfg.TeamsConflictResolution,
+		priority:       cfg.TeamsPriority,
+		include:        cfg.TeamsInclude,
+		exclude:        cfg.TeamsExclude,
+		copilotOnly:    cfg.TeamsCopilotHoldersOnly,
+		budgetByTeam:   cfg.TeamsBudgetOverrides,
+		teamsCache:     make(map[string][]github.Team),
+		membersCache:   make(map[string][]string),
+		ccNameCache:    make(map[string]string),
 	}
 }
 
@@ -70,6 +130,13 @@ func (m *Manager) SetBudgetConfig(enabled bool, products map[string]config.Produ
 	m.budgetProducts = products
 }
 
+// SetConfirm makes apply-mode syncs call confirm, with the users to add per
+// cost center name, before creating or assigning anything.  A false answer
+// stops the sync with ErrAborted.
+func (m *Manager) SetConfirm(confirm func(adds map[string][]string) (bool, error)) {
+	m.confirm = confirm
+}
+
 // PrintConfigSummary displays the teams mode configuration.
 func (m *Manager) PrintConfigSummary(checkCurrent, createBudgets bool) {
 	fmt.Println("\n===== Teams Mode Configuration =====")
@@ -84,16 +151,13 @@ func (m *Manager) PrintConfigSummary(checkCurrent, createBudgets bool) {
 
 	fmt.Printf("Auto-create cost centers: %v\n", m.autoCreate)
 	fmt.Printf("Full sync (remove users who left teams): %v\n", m.removeUsers)
+	fmt.Printf("Copilot seat holders only: %v\n", m.copilotOnly)
 	fmt.Printf("Check current cost center: %v\n", checkCurrent)
 	fmt.Printf("Create budgets: %v\n", createBudgets)
 
 	switch m.mode {
 	case "auto":
-		if m.scope == "enterprise" {
-			fmt.Println("Cost center naming: [enterprise team] {team-name}")
-		} else {
-			fmt.Println("Cost center naming: [org team] {org-name}/{team-name}")
-		}
+		fmt.Printf("Cost center naming: %s\n", m.nameTemplateOrDefault())
 	case "manual":
 		fmt.Printf("Manual mappings configured: %d\n", len(m.mappings))
 		for teamKey, cc := range m.mappings {
@@ -106,6 +170,7 @@ func (m *Manager) PrintConfigSummary(checkCurrent, createBudgets bool) {
 // fetchAllTeams fetches teams from all configured sources (orgs or enterprise).
 func (m *Manager) fetchAllTeams() (map[string][]github.Team, error) {
 	allTeams := make(map[string][]github.Team)
+	m.filteredTeams = 0
 
 	if m.scope == "enterprise" {
 		m.log.Info("Fetching enterprise teams", "enterprise", m.cfg.Enterprise)
@@ -113,9 +178,10 @@ func (m *Manager) fetchAllTeams() (map[string][]github.Team, error) {
 		if err != nil {
 			return nil, fmt.Errorf("fetching enterprise teams: %w", err)
 		}
+		m.log.Info("Found enterprise teams", "count", len(teams))
+		teams = m.filterTeams(m.cfg.Enterprise, teams)
 		allTeams[m.cfg.Enterprise] = teams
 		m.teamsCache[m.cfg.Enterprise] = teams
-		m.log.Info("Found enterprise teams", "count", len(teams))
 	} else {
 		if len(m.orgs) == 0 {
 			m.log.Warn("No organizations configured for organization scope")
@@ -127,9 +193,10 @@ func (m *Manager) fetchAllTeams() (map[string][]github.Team, error) {
 			if err != nil {
 				return nil, fmt.Errorf("fetching teams for org %s: %w", org, err)
 			}
+			m.log.Info("Found teams in organization", "org", org, "count", len(teams))
+			teams = m.filterTeams(org, teams)
 			allTeams[org] = teams
 			m.teamsCache[org] = teams
-			m.log.Info("Found teams in organization", "org", org, "count", len(teams))
 		}
 	}
 
@@ -141,6 +208,92 @@ func (m *Manager) fetchAllTeams() (map[string][]github.Team, error) {
 	return allTeams, nil
 }
 
+// filterTeams applies teams.include, then teams.exclude, to the teams of one
+// source, before any members are fetched.  A team matches a pattern by slug
+// or by team key ("org/slug" in organization scope).
+func (m *Manager) filterTeams(orgOrEnterprise string, teams []github.Team) []github.Team {
+	if len(m.include) == 0 && len(m.exclude) == 0 {
+		return teams
+	}
+	kept := make([]github.Team, 0, len(teams))
+	for _, team := range teams {
+		key := m.teamKey(orgOrEnterprise, team.Slug)
+		if len(m.include) > 0 && !matchesTeamPattern(m.include, team.Slug, key) {
+			continue
+		}
+		if matchesTeamPattern(m.exclude, team.Slug, key) {
+			continue
+		}
+		kept = append(kept, team)
+	}
+	if dropped := len(teams) - len(kept); dropped > 0 {
+		m.filteredTeams += dropped
+		m.log.Info("Filtered teams by teams.include/exclude",
+			"source", orgOrEnterprise, "kept", len(kept), "filtered_out", dropped)
+	}
+	return kept
+}
+
+// matchesTeamPattern reports whether slug or key matches one of the exact or
+// glob patterns (case-insensitively).
+func matchesTeamPattern(patterns []string, slug, key string) bool {
+	slug, key = strings.ToLower(slug), strings.ToLower(key)
+	for _, p := range patterns {
+		p = strings.ToLower(p)
+		for _, name := range []string{slug, key} {
+			if ok, _ := path.Match(p, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fetchSeatHolders returns all Copilot seat holders keyed by lower-cased
+// login.  The result is cached for the rest of the run.
+func (m *Manager) fetchSeatHolders() (map[string]string, error) {
+	if m.seatHolders != nil {
+		return m.seatHolders, nil
+	}
+	users, err := m.client.GetCopilotUsers()
+	if err != nil {
+		return nil, fmt.Errorf("fetching copilot users: %w", err)
+	}
+	holders := make(map[string]string, len(users))
+	for _, u := range users {
+		holders[strings.ToLower(u.Login)] = u.Login
+	}
+	m.seatHolders = holders
+	return holders, nil
+}
+
+// PrintNonCopilotSkipped lists, per team, how many members were skipped for
+// holding no Copilot seat.
+func (m *Manager) PrintNonCopilotSkipped() {
+	if len(m.nonCopilotSkipped) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(m.nonCopilotSkipped))
+	total := 0
+	for key, n := range m.nonCopilotSkipped {
+		keys = append(keys, key)
+		total += n
+	}
+	sort.Strings(keys)
+	fmt.Printf("\nTeam members without a Copilot seat, not assigned (%d):\n", total)
+	for _, key := range keys {
+		fmt.Printf("  - %s: %d\n", key, m.nonCopilotSkipped[key])
+	}
+}
+
+// PrintTeamFilter reports how many teams teams.include/exclude filtered out.
+func (m *Manager) PrintTeamFilter() {
+	if len(m.include) == 0 && len(m.exclude) == 0 {
+		return
+	}
+	fmt.Printf("\nTeams filtered out by teams.include/exclude: %d\n", m.filteredTeams)
+}
+
 // fetchTeamMembers fetches the members of a team, using an in-memory cache.
 func (m *Manager) fetchTeamMembers(orgOrEnterprise, teamSlug string) ([]string, error) {
 	var cacheKey string
@@ -154,6 +307,20 @@ func (m *Manager) fetchTeamMembers(orgOrEnterprise, teamSlug string) ([]string,
 		return cached, nil
 	}
 
+	usernames, err := m.fetchTeamMemberLogins(orgOrEnterprise, teamSlug)
+	if err != nil {
+		return nil, err
+	}
+	m.membersCache[cacheKey] = usernames
+	return usernames, nil
+}
+
+// fetchTeamMemberLogins fetches the logins of a team's members from the API,
+// without non-user accounts (unless included) and excluded users.  It does
+// not touch the cache and is safe to call concurrently.
+func (m *Manager) fetchTeamMemberLogins(orgOrEnterprise, teamSlug string) ([]string, error) {
+	cacheKey := m.teamKey(orgOrEnterprise, teamSlug)
+
 	var members []github.TeamMember
 	var err error
 	if m.scope == "enterprise" {
@@ -167,23 +334,127 @@ func (m *Manager) fetchTeamMembers(orgOrEnterprise, teamSlug string) ([]string,
 
 	usernames := make([]string, 0, len(members))
 	for _, member := range members {
-		if member.Login != "" {
-			usernames = append(usernames, member.Login)
+		if member.Login == "" {
+			continue
 		}
+		if !m.cfg.IncludeNonUserAccounts && !github.IsUserAccount(member.Type) {
+			m.log.Debug("Skipping non-user team member", "team", cacheKey, "user", member.Login, "type", member.Type)
+			continue
+		}
+		if m.cfg.IsExcludedUser(member.Login) {
+			m.log.Debug("Skipping excluded team member", "team", cacheKey, "user", member.Login)
+			continue
+		}
+		usernames = append(usernames, member.Login)
 	}
-
-	m.membersCache[cacheKey] = usernames
 	return usernames, nil
 }
 
-// costCenterForTeam determines the cost center name for a given team.
-func (m *Manager) costCenterForTeam(orgOrEnterprise string, team github.Team) (string, bool) {
-	var teamKey string
+// memberJob is one team whose members are fetched.
+type memberJob struct {
+	source string // org or enterprise
+	slug   string
+	key    string // team key
+}
+
+// prefetchMembers fetches the members of every job not yet cached with at
+// most m.parallel requests in flight and stores them in membersCache.  Teams
+// that fail are returned by key and skipped, so one failing team does not
+// abort the run; with failFast the first error is returned instead.
+func (m *Manager) prefetchMembers(jobs []memberJob) (map[string]error, error) {
+	workers := m.parallel
+	if workers < 1 {
+		workers = DefaultParallel
+	}
+
+	members := make([][]string, len(jobs))
+	errs := make([]error, len(jobs))
+	fetched := make([]bool, len(jobs))
+
+	pending := 0
+	for _, job := range jobs {
+		if _, ok := m.membersCache[job.key]; !ok {
+			pending++
+		}
+	}
+	task := progress.Start("Fetching team members", pending)
+	defer task.Done()
+
+	g, ctx := errgroup.WithContext(context.Background())
+	g.SetLimit(workers)
+	for i, job := range jobs {
+		if _, ok := m.membersCache[job.key]; ok {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		g.Go(func() error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			start := time.Now()
+			logins, err := m.fetchTeamMemberLogins(job.source, job.slug)
+			m.log.Debug("Fetched team members", "team", job.key, "members", len(logins),
+				"duration", time.Since(start).Round(time.Millisecond))
+			task.Add(1)
+			if err != nil {
+				if m.failFast {
+					return err
+				}
+				errs[i] = err
+				return nil
+			}
+			members[i], fetched[i] = logins, true
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	failed := make(map[string]error)
+	for i, job := range jobs {
+		switch {
+		case errs[i] != nil:
+			failed[job.key] = errs[i]
+		case fetched[i]:
+			m.membersCache[job.key] = members[i]
+		}
+	}
+	return failed, nil
+}
+
+// SetMemberFetchOptions sets how many teams' members are fetched at once and
+// whether the first failing team aborts the run.
+func (m *Manager) SetMemberFetchOptions(parallel int, failFast bool) {
+	m.parallel = parallel
+	m.failFast = failFast
+}
+
+// FailedTeams returns the team keys whose members could not be fetched in
+// the last BuildTeamAssignments call, sorted.
+func (m *Manager) FailedTeams() []string {
+	keys := make([]string, 0, len(m.failedTeams))
+	for key := range m.failedTeams {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// teamKey returns the key identifying a team in mappings and caches:
+// "org/team-slug" in organization scope, the slug in enterprise scope.
+func (m *Manager) teamKey(orgOrEnterprise, teamSlug string) string {
 	if m.scope == "enterprise" {
-		teamKey = team.Slug
-	} else {
-		teamKey = orgOrEnterprise + "/" + team.Slug
+		return teamSlug
 	}
+	return orgOrEnterprise + "/" + teamSlug
+}
+
+// costCenterForTeam determines the cost center name for a given team.
+func (m *Manager) costCenterForTeam(orgOrEnterprise string, team github.Team) (string, bool) {
+	teamKey := m.teamKey(orgOrEnterprise, team.Slug)
 
 	// Check cache.
 	if cc, ok := m.ccNameCache[teamKey]; ok {
@@ -204,11 +475,7 @@ func (m *Manager) costCenterForTeam(orgOrEnterprise string, team github.Team) (s
 		ccName = cc
 
 	case "auto":
-		if m.scope == "enterprise" {
-			ccName = fmt.Sprintf("[enterprise team] %s", team.Name)
-		} else {
-			ccName = fmt.Sprintf("[org team] %s/%s", orgOrEnterprise, team.Name)
-		}
+		ccName = renderNameTemplate(m.nameTemplateOrDefault(), orgOrEnterprise, team)
 
 	default:
 		m.log.Error("Invalid teams mode", "mode", m.mode)
@@ -219,13 +486,36 @@ func (m *Manager) costCenterForTeam(orgOrEnterprise string, team github.Team) (s
 	return ccName, true
 }
 
+// nameTemplateOrDefault returns the auto-mode name template, the team name
+// when none is configured.
+func (m *Manager) nameTemplateOrDefault() string {
+	if m.nameTemplate == "" {
+		return config.DefaultTeamsNameTemplate
+	}
+	return m.nameTemplate
+}
+
+// renderNameTemplate fills in the {org}, {team_name}, and {team_slug}
+// placeholders of a cost_center_name_template.  In enterprise scope {org} is
+// the enterprise.
+func renderNameTemplate(tmpl, orgOrEnterprise string, team github.Team) string {
+	return strings.NewReplacer(
+		"{org}", orgOrEnterprise,
+		"{team_name}", team.Name,
+		"{team_slug}", team.Slug,
+	).Replace(tmpl)
+}
+
 // BuildTeamAssignments builds the complete team->members mapping with cost
-// centers.  Users can only belong to ONE cost center; if a user appears in
-// multiple teams the last-team-wins.
+// centers.  Users can only belong to ONE cost center; a user found in
+// several teams is a conflict, resolved per conflict_resolution (see
+// resolveConflict) and recorded for Conflicts.
 //
-// Returns a map of costCenterName -> []UserAssignment.
+// Returns a map of costCenterName -> []UserAssignment, sorted by username.
 func (m *Manager) BuildTeamAssignments() (map[string][]UserAssignment, error) {
 	m.log.Info("Building team-based cost center assignments...")
+	m.unmappedTeams, m.mappedUsers = nil, nil
+	m.ccTeams = make(map[string][]string)
 
 	allTeams, err := m.fetchAllTeams()
 	if err != nil {
@@ -237,13 +527,40 @@ func (m *Manager) BuildTeamAssignments() (map[string][]UserAssignment, error) {
 		return nil, nil
 	}
 
-	// Track final assignment per user (last-team-wins).
-	userFinal := make(map[string]UserAssignment) // username -> assignment
+	// Only seat holders are assigned when copilot_holders_only is set.
+	var seatHolders map[string]string
+	m.nonCopilotSkipped = nil
+	if m.copilotOnly {
+		if seatHolders, err = m.fetchSeatHolders(); err != nil {
+			return nil, err
+		}
+		m.log.Info("Restricting team members to Copilot seat holders", "seat_holders", len(seatHolders))
+		m.nonCopilotSkipped = make(map[string]int)
+	}
+
+	// Every team each user is found in.
+	candidates := make(map[string][]UserAssignment) // username -> one per team
+
+	// In auto mode every team must get its own cost center; a name template
+	// that renders two teams to the same name would merge them.
+	ccOwner := make(map[string]string) // CC name -> team key (auto mode)
 
-	// Track multi-team users for conflict reporting.
-	userTeamMap := make(map[string][]string) // username -> list of team keys
+	sources := make([]string, 0, len(allTeams))
+	for source := range allTeams {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	type mappedTeam struct {
+		source string
+		team   github.Team
+		ccName string
+	}
+	var mapped []mappedTeam
+	var jobs []memberJob
 
-	for orgOrEnterprise, teams := range allTeams {
+	for _, orgOrEnterprise := range sources {
+		teams := allTeams[orgOrEnterprise]
 		sourceLabel := "organization"
 		if m.scope == "enterprise" {
 			sourceLabel = "enterprise"
@@ -254,88 +571,193 @@ func (m *Manager) BuildTeamAssignments() (map[string][]UserAssignment, error) {
 			"count", len(teams))
 
 		for _, team := range teams {
+			teamKey := m.teamKey(orgOrEnterprise, team.Slug)
 			ccName, ok := m.costCenterForTeam(orgOrEnterprise, team)
 			if !ok {
 				m.log.Debug("Skipping team (no cost center mapping)", "team", team.Slug)
+				m.unmappedTeams = append(m.unmappedTeams, memberJob{source: orgOrEnterprise, slug: team.Slug, key: teamKey})
 				continue
 			}
-
-			members, err := m.fetchTeamMembers(orgOrEnterprise, team.Slug)
-			if err != nil {
-				return nil, err
+			if m.mode == "auto" {
+				if other, taken := ccOwner[ccName]; taken && other != teamKey {
+					pair := []string{other, teamKey}
+					sort.Strings(pair)
+					return nil, fmt.Errorf("teams %s and %s both map to cost center %q: make cost_center_name_template unique per team (e.g. add {org} or {team_slug})",
+						pair[0], pair[1], ccName)
+				}
+				ccOwner[ccName] = teamKey
 			}
+			mapped = append(mapped, mappedTeam{source: orgOrEnterprise, team: team, ccName: ccName})
+			m.ccTeams[ccName] = append(m.ccTeams[ccName], teamKey)
+			jobs = append(jobs, memberJob{source: orgOrEnterprise, slug: team.Slug, key: teamKey})
+		}
+	}
 
-			if len(members) == 0 {
-				m.log.Info("Team has no members, skipping", "team", team.Slug)
-				continue
-			}
+	// Fetch the members of all mapped teams concurrently.
+	m.failedCostCenters = make(map[string]bool)
+	if m.failedTeams, err = m.prefetchMembers(jobs); err != nil {
+		return nil, err
+	}
+	for _, key := range m.FailedTeams() {
+		m.log.Error("Could not fetch team members, skipping team", "team", key, "error", m.failedTeams[key])
+	}
 
-			var teamKey string
-			if m.scope == "enterprise" {
-				teamKey = team.Slug
-			} else {
-				teamKey = orgOrEnterprise + "/" + team.Slug
-			}
+	for _, mt := range mapped {
+		orgOrEnterprise, team, ccName := mt.source, mt.team, mt.ccName
+		teamKey := m.teamKey(orgOrEnterprise, team.Slug)
+		if _, failed := m.failedTeams[teamKey]; failed {
+			// Its cost center is missing members we could not see, so
+			// nobody may be removed from it this run.
+			m.failedCostCenters[ccName] = true
+			continue
+		}
+		members, err := m.fetchTeamMembers(orgOrEnterprise, team.Slug)
+		if err != nil {
+			return nil, err
+		}
 
+		if seatHolders != nil {
+			holders := make([]string, 0, len(members))
 			for _, username := range members {
-				userTeamMap[username] = append(userTeamMap[username], teamKey)
-				// Last-team-wins: overwrite any previous assignment.
-				userFinal[username] = UserAssignment{
-					Username:   username,
-					CostCenter: ccName,
-					Org:        orgOrEnterprise,
-					TeamSlug:   team.Slug,
+				if _, ok := seatHolders[strings.ToLower(username)]; ok {
+					holders = append(holders, username)
 				}
 			}
+			if skipped := len(members) - len(holders); skipped > 0 {
+				m.nonCopilotSkipped[teamKey] = skipped
+				m.log.Debug("Skipping team members without a Copilot seat", "team", teamKey, "count", skipped)
+			}
+			members = holders
+		}
 
-			m.log.Info("Team assignment",
-				"team", team.Name,
-				"key", teamKey,
-				"cost_center", ccName,
-				"members", len(members))
+		if len(members) == 0 {
+			m.log.Info("Team has no members, skipping", "team", team.Slug)
+			continue
 		}
-	}
 
-	// Report multi-team users.
-	var multiTeamUsers []string
-	for user, teams := range userTeamMap {
-		if len(teams) > 1 {
-			multiTeamUsers = append(multiTeamUsers, user)
+		for _, username := range members {
+			candidates[username] = append(candidates[username], UserAssignment{
+				Username:   username,
+				CostCenter: ccName,
+				Org:        orgOrEnterprise,
+				TeamSlug:   team.Slug,
+			})
 		}
+
+		m.log.Info("Team assignment",
+			"team", team.Name,
+			"key", teamKey,
+			"cost_center", ccName,
+			"members", len(members))
 	}
-	if len(multiTeamUsers) > 0 {
-		sort.Strings(multiTeamUsers)
-		m.log.Warn("Users in multiple teams (last-team-wins)",
-			"count", len(multiTeamUsers))
-		limit := 10
-		if len(multiTeamUsers) < limit {
-			limit = len(multiTeamUsers)
-		}
-		for _, user := range multiTeamUsers[:limit] {
-			m.log.Warn("Multi-team user",
-				"user", user,
-				"teams", strings.Join(userTeamMap[user], ", "),
-				"assigned_to", userFinal[user].CostCenter)
-		}
-		if len(multiTeamUsers) > 10 {
-			m.log.Warn("More multi-team users not shown",
-				"remaining", len(multiTeamUsers)-10)
-		}
+
+	// Resolve users found in several teams.
+	usernames := make([]string, 0, len(candidates))
+	for username := range candidates {
+		usernames = append(usernames, username)
 	}
+	sort.Strings(usernames)
 
-	// Convert to costCenter -> []UserAssignment.
+	m.conflicts = nil
+	m.mappedUsers = make(map[string]bool, len(usernames))
 	assignments := make(map[string][]UserAssignment)
-	for _, ua := range userFinal {
-		assignments[ua.CostCenter] = append(assignments[ua.CostCenter], ua)
+	for _, username := range usernames {
+		m.mappedUsers[strings.ToLower(username)] = true
+		found := candidates[username]
+		chosen := found[0]
+		if len(found) > 1 {
+			chosen = m.resolveConflict(found)
+			teamKeys := make([]string, len(found))
+			for i, ua := range found {
+				teamKeys[i] = m.teamKey(ua.Org, ua.TeamSlug)
+			}
+			sort.Strings(teamKeys)
+			m.conflicts = append(m.conflicts, Conflict{
+				Username:   username,
+				Teams:      teamKeys,
+				ChosenTeam: m.teamKey(chosen.Org, chosen.TeamSlug),
+				CostCenter: chosen.CostCenter,
+			})
+		}
+		assignments[chosen.CostCenter] = append(assignments[chosen.CostCenter], chosen)
+	}
+
+	if len(m.conflicts) > 0 {
+		if m.conflictPolicy == "error" {
+			lines := make([]string, len(m.conflicts))
+			for i, c := range m.conflicts {
+				lines[i] = fmt.Sprintf("%s (%s)", c.Username, strings.Join(c.Teams, ", "))
+			}
+			return nil, fmt.Errorf("%d users are in multiple teams and conflict_resolution is \"error\": %s",
+				len(m.conflicts), strings.Join(lines, "; "))
+		}
+		m.log.Warn("Users in multiple teams",
+			"count", len(m.conflicts),
+			"conflict_resolution", m.conflictPolicy)
+		for _, c := range m.conflicts {
+			m.log.Debug("Multi-team user",
+				"user", c.Username,
+				"teams", strings.Join(c.Teams, ", "),
+				"assigned_to", c.CostCenter)
+		}
 	}
 
 	m.log.Info("Team assignment summary",
 		"cost_centers", len(assignments),
-		"unique_users", len(userFinal))
+		"unique_users", len(usernames))
 
 	return assignments, nil
 }
 
+// Conflict records a user found in several teams and where they were put.
+type Conflict struct {
+	Username   string
+	Teams      []string // team keys, sorted
+	ChosenTeam string
+	CostCenter string
+}
+
+// Conflicts returns the multi-team users of the last BuildTeamAssignments
+// call, sorted by username.
+func (m *Manager) Conflicts() []Conflict { return m.conflicts }
+
+// resolveConflict picks the team assignment for a user found in several
+// teams.  priority_list takes the team listed first in teams.priority, with
+// unlisted teams after all listed ones; otherwise, and among unlisted teams,
+// the alphabetically first team key wins.
+func (m *Manager) resolveConflict(found []UserAssignment) UserAssignment {
+	rank := func(ua UserAssignment) (int, string) {
+		key := m.teamKey(ua.Org, ua.TeamSlug)
+		if m.conflictPolicy == "priority_list" {
+			if i := slices.Index(m.priority, key); i >= 0 {
+				return i, key
+			}
+		}
+		return len(m.priority), key
+	}
+	best := found[0]
+	bestRank, bestKey := rank(best)
+	for _, ua := range found[1:] {
+		r, key := rank(ua)
+		if r < bestRank || (r == bestRank && key < bestKey) {
+			best, bestRank, bestKey = ua, r, key
+		}
+	}
+	return best
+}
+
+// PrintConflicts lists every user found in several teams with the cost
+// center chosen for them.
+func (m *Manager) PrintConflicts() {
+	if len(m.conflicts) == 0 {
+		return
+	}
+	fmt.Printf("\nUsers in multiple teams (%d, conflict_resolution: %s):\n", len(m.conflicts), m.conflictPolicy)
+	for _, c := range m.conflicts {
+		fmt.Printf("  - %s: %s -> %s (%s)\n", c.Username, strings.Join(c.Teams, ", "), c.CostCenter, c.ChosenTeam)
+	}
+}
+
 // EnsureCostCentersExist ensures all required cost centers exist, creating
 // them if auto-create is enabled.  When auto-create is disabled, cost center
 // names are resolved to UUIDs by looking up existing cost centers — the sync
@@ -469,6 +891,14 @@ func (m *Manager) SyncTeamAssignments(mode string, ignoreCurrentCC bool) (map[st
 		m.log.Warn("No team assignments to sync")
 		return nil, nil
 	}
+	return m.syncAssignments(assignments, mode, ignoreCurrentCC)
+}
+
+// syncAssignments resolves (or creates) the cost centers named in
+// assignments and, in apply mode, pushes the memberships and handles removal
+// of stale members.  It is shared by the teams and assigning-team flows.
+func (m *Manager) syncAssignments(assignments map[string][]UserAssignment, mode string, ignoreCurrentCC bool) (map[string]map[string]bool, error) {
+	var err error
 
 	// Collect unique cost center names.
 	ccNames := make([]string, 0, len(assignments))
@@ -477,6 +907,20 @@ func (m *Manager) SyncTeamAssignments(mode string, ignoreCurrentCC bool) (map[st
 	}
 	sort.Strings(ccNames)
 
+	if mode == "apply" && m.confirm != nil {
+		byName := make(map[string]string, len(ccNames))
+		for _, name := range ccNames {
+			byName[name] = name
+		}
+		proceed, err := m.confirm(AssignmentGroups(assignments, byName))
+		if err != nil {
+			return nil, fmt.Errorf("confirming assignments: %w", err)
+		}
+		if !proceed {
+			return nil, ErrAborted
+		}
+	}
+
 	// Ensure cost centers exist.
 	var ccMap map[string]string
 	var newlyCreated map[string]bool
@@ -506,26 +950,20 @@ func (m *Manager) SyncTeamAssignments(mode string, ignoreCurrentCC bool) (map[st
 			return nil, fmt.Errorf("ensuring cost centers exist: %w", err)
 		}
 
-		// Create budgets for newly-created cost centers.
-		if m.createBudgets && len(newlyCreated) > 0 {
-			if err := m.createBudgetsForNewCCs(ccMap, newlyCreated); err != nil {
+		// Create the budgets every cost center of the sync still lacks.
+		if m.createBudgets {
+			ccIDs := make(map[string]bool, len(ccNames))
+			for _, name := range ccNames {
+				ccIDs[ccMap[name]] = true
+			}
+			if err := m.ensureBudgets(ccMap, ccIDs); err != nil {
 				return nil, fmt.Errorf("creating budgets: %w", err)
 			}
 		}
 	}
 
 	// Convert assignments to use actual cost center IDs and deduplicate.
-	idBased := make(map[string][]string) // ccID -> []usernames
-	for ccName, userAssigns := range assignments {
-		ccID := ccMap[ccName]
-		seen := make(map[string]bool)
-		for _, ua := range userAssigns {
-			if !seen[ua.Username] {
-				seen[ua.Username] = true
-				idBased[ccID] = append(idBased[ccID], ua.Username)
-			}
-		}
-	}
+	idBased := AssignmentGroups(assignments, ccMap)
 
 	// Summary.
 	totalUsers := 0
@@ -536,6 +974,8 @@ func (m *Manager) SyncTeamAssignments(mode string, ignoreCurrentCC bool) (map[st
 		"cost_centers", len(idBased),
 		"total_users", totalUsers)
 
+	m.lastSync = &SyncResult{Names: reverseMap(ccMap), Adds: idBased}
+
 	if mode == "plan" {
 		m.log.Info("mode=plan: would sync the following assignments:")
 		for ccID, users := range idBased {
@@ -543,6 +983,10 @@ func (m *Manager) SyncTeamAssignments(mode string, ignoreCurrentCC bool) (map[st
 		}
 		if m.removeUsers {
 			m.log.Info("Full sync mode is ENABLED -- in apply mode, users no longer in teams would be removed")
+			m.printPlannedRemovals(idBased, ccMap)
+		}
+		if m.createBudgets {
+			m.printPlannedBudgets(ccNames, ccMap)
 		}
 		return nil, nil
 	}
@@ -554,12 +998,18 @@ func (m *Manager) SyncTeamAssignments(mode string, ignoreCurrentCC bool) (map[st
 		return nil, fmt.Errorf("applying team assignments: %w", err)
 	}
 
+	m.lastSync.Assigned = make(map[string]map[string]bool, len(results))
+	for ccID, userResults := range results {
+		m.lastSync.Assigned[ccID] = maps.Clone(userResults)
+	}
+
 	// Handle user removal.
 	m.log.Info("Checking for users no longer in teams...")
 	removedResults := m.handleUserRemoval(idBased, ccMap, newlyCreated)
 
 	// Merge removal results.
 	if m.removeUsers {
+		m.lastSync.Removed = removedResults
 		for ccID, userResults := range removedResults {
 			if _, ok := results[ccID]; !ok {
 				results[ccID] = make(map[string]bool)
@@ -573,45 +1023,59 @@ func (m *Manager) SyncTeamAssignments(mode string, ignoreCurrentCC bool) (map[st
 	return results, nil
 }
 
-// handleUserRemoval detects (and optionally removes) users who are in a cost
-// center but no longer in the corresponding team.  Newly-created cost centers
-// are skipped as an optimisation -- they cannot have stale members.
-func (m *Manager) handleUserRemoval(
-	expectedAssignments map[string][]string,
-	ccNameToID map[string]string,
-	newlyCreated map[string]bool,
-) map[string]map[string]bool {
-	results := make(map[string]map[string]bool)
+// SyncResult is what the last SyncTeamAssignments or
+// SyncAssigningTeamAssignments call assigned, or would assign in plan mode.
+// All maps are keyed by cost center ID.
+type SyncResult struct {
+	Names map[string]string   // cost center ID -> name
+	Adds  map[string][]string // users assigned (or to assign)
+
+	// Removes lists, in plan mode with full sync, the stale members apply
+	// would remove.
+	Removes map[string][]string
+
+	// Apply mode: per-user results of the assignments and, with full sync,
+	// of the removals.
+	Assigned map[string]map[string]bool
+	Removed  map[string]map[string]bool
+}
 
-	// Build reverse map: ccID -> ccName (for logging).
-	idToName := make(map[string]string, len(ccNameToID))
-	for name, id := range ccNameToID {
-		idToName[id] = name
-	}
+// LastSync returns the outcome of the last sync, or nil when no sync got as
+// far as resolving its cost centers.
+func (m *Manager) LastSync() *SyncResult { return m.lastSync }
 
-	// Filter out newly-created cost centers.
-	toCheck := make(map[string][]string)
-	skipped := 0
-	for ccID, users := range expectedAssignments {
-		if newlyCreated[ccID] {
-			skipped++
-			continue
+// AssignmentGroups converts name-keyed assignments into the cost center ID ->
+// logins map that pru.Manager.AssignmentGroups produces, so that both modes
+// feed the same bulk assignment call.  ccMap maps cost center names to IDs;
+// logins are deduplicated and sorted.
+func AssignmentGroups(assignments map[string][]UserAssignment, ccMap map[string]string) map[string][]string {
+	groups := make(map[string][]string, len(assignments))
+	for ccName, userAssigns := range assignments {
+		ccID := ccMap[ccName]
+		seen := make(map[string]bool, len(userAssigns))
+		for _, ua := range userAssigns {
+			if !seen[ua.Username] {
+				seen[ua.Username] = true
+				groups[ccID] = append(groups[ccID], ua.Username)
+			}
 		}
-		toCheck[ccID] = users
-	}
-	if skipped > 0 {
-		m.log.Info("Skipping newly created cost centers (no stale members possible)",
-			"skipped", skipped)
+		sort.Strings(groups[ccID])
 	}
+	return groups
+}
 
-	m.log.Info("Checking cost centers for users no longer in teams",
-		"count", len(toCheck))
-
-	totalFound := 0
-	totalRemoved := 0
-
-	for ccID, expectedUsers := range toCheck {
-		currentMembers, err := m.client.GetCostCenterMembers(ccID)
+// staleMembers returns, per cost center ID in expectedAssignments, the
+// current members who are not among the expected users, sorted.  Cost
+// centers in skip (newly created ones cannot have stale members) and those
+// whose members cannot be fetched are left out.  Excluded users and PRU
+// exception users are never returned, so they are never auto-removed.
+func (m *Manager) staleMembers(expectedAssignments map[string][]string, idToName map[string]string, skip map[string]bool) map[string][]string {
+	stale := make(map[string][]string)
+	for ccID, expectedUsers := range expectedAssignments {
+		if skip[ccID] {
+			continue
+		}
+		currentMembers, err := m.client.GetCostCenterUsers(ccID)
 		if err != nil {
 			displayName := idToName[ccID]
 			if displayName == "" {
@@ -628,40 +1092,95 @@ func (m *Manager) handleUserRemoval(
 
 		expectedSet := make(map[string]bool, len(expectedUsers))
 		for _, u := range expectedUsers {
-			expectedSet[u] = true
+			expectedSet[strings.ToLower(u)] = true
 		}
 
-		// Find users in CC but not in expected team members.
-		var stale []string
+		var users []string
 		for _, member := range currentMembers {
-			if !expectedSet[member] {
-				stale = append(stale, member)
+			if expectedSet[strings.ToLower(member)] {
+				continue
 			}
+			if m.cfg.IsExcludedUser(member) || m.cfg.IsPRUExceptionUser(member) {
+				m.log.Debug("Keeping protected user not in team", "user", member, "cc", ccID)
+				continue
+			}
+			users = append(users, member)
+		}
+		if len(users) > 0 {
+			sort.Strings(users)
+			stale[ccID] = users
 		}
+	}
+	return stale
+}
 
-		if len(stale) == 0 {
-			continue
+// handleUserRemoval detects (and optionally removes) users who are in a cost
+// center but no longer in the corresponding team.  Newly-created cost centers
+// are skipped as an optimisation -- they cannot have stale members.
+func (m *Manager) handleUserRemoval(
+	expectedAssignments map[string][]string,
+	ccNameToID map[string]string,
+	newlyCreated map[string]bool,
+) map[string]map[string]bool {
+	results := make(map[string]map[string]bool)
+
+	// Build reverse map: ccID -> ccName (for logging).
+	idToName := reverseMap(ccNameToID)
+
+	skipped := 0
+	for ccID := range expectedAssignments {
+		if newlyCreated[ccID] {
+			skipped++
 		}
+	}
+	if skipped > 0 {
+		m.log.Info("Skipping newly created cost centers (no stale members possible)",
+			"skipped", skipped)
+	}
 
+	m.log.Info("Checking cost centers for users no longer in teams",
+		"count", len(expectedAssignments)-skipped)
+
+	totalFound := 0
+	totalRemoved := 0
+
+	skip := maps.Clone(newlyCreated)
+	if skip == nil {
+		skip = make(map[string]bool)
+	}
+	for name := range m.failedCostCenters {
+		if id, ok := ccNameToID[name]; ok {
+			skip[id] = true
+			m.log.Warn("Not removing users from cost center with a team whose members could not be fetched", "cost_center", name)
+		}
+	}
+	stale := m.staleMembers(expectedAssignments, idToName, skip)
+	ccIDs := make([]string, 0, len(stale))
+	for ccID := range stale {
+		ccIDs = append(ccIDs, ccID)
+	}
+	sort.Strings(ccIDs)
+
+	for _, ccID := range ccIDs {
+		users := stale[ccID]
 		displayName := idToName[ccID]
 		if displayName == "" {
 			displayName = ccID
 		}
-		totalFound += len(stale)
+		totalFound += len(users)
 
-		sort.Strings(stale)
 		m.log.Warn("Users no longer in team for cost center",
 			"cost_center", displayName,
-			"count", len(stale))
-		for _, user := range stale {
+			"count", len(users))
+		for _, user := range users {
 			m.log.Warn("User no longer in team", "user", user, "cost_center", displayName)
 		}
 
 		if m.removeUsers {
 			m.log.Info("Removing users no longer in team",
 				"cost_center", displayName,
-				"count", len(stale))
-			removalStatus, err := m.client.RemoveUsersFromCostCenter(ccID, stale)
+				"count", len(users))
+			removalStatus, err := m.client.RemoveUsersFromCostCenter(ccID, users)
 			if err != nil {
 				m.log.Error("Failed to remove users", "cost_center", displayName, "error", err)
 			}
@@ -695,6 +1214,133 @@ func (m *Manager) handleUserRemoval(
 	return results
 }
 
+// printPlannedRemovals lists, in plan mode, the users apply would remove
+// because they are no longer in any team mapped to their cost center.  Only
+// cost centers that already exist are checked.
+func (m *Manager) printPlannedRemovals(expectedAssignments map[string][]string, ccNameToID map[string]string) {
+	existing := make(map[string][]string, len(expectedAssignments))
+	for ccID, users := range expectedAssignments {
+		if github.IsValidCostCenterUUID(ccID) {
+			existing[ccID] = users
+		}
+	}
+	idToName := reverseMap(ccNameToID)
+	skip := make(map[string]bool)
+	for name := range m.failedCostCenters {
+		skip[ccNameToID[name]] = true
+	}
+	stale := m.staleMembers(existing, idToName, skip)
+	if m.lastSync != nil {
+		m.lastSync.Removes = stale
+	}
+
+	ccIDs := make([]string, 0, len(stale))
+	total := 0
+	for ccID, users := range stale {
+		ccIDs = append(ccIDs, ccID)
+		total += len(users)
+	}
+	sort.Strings(ccIDs)
+
+	fmt.Printf("\nTo remove (no longer in a mapped team): %d users\n", total)
+	for _, ccID := range ccIDs {
+		name := idToName[ccID]
+		if name == "" {
+			name = ccID
+		}
+		fmt.Printf("  %s: %s\n", name, strings.Join(stale[ccID], ", "))
+	}
+}
+
+// reverseMap maps each cost center ID back to its name.
+func reverseMap(ccNameToID map[string]string) map[string]string {
+	idToName := make(map[string]string, len(ccNameToID))
+	for name, id := range ccNameToID {
+		idToName[id] = name
+	}
+	return idToName
+}
+
+// UnmappedTeam is a team with Copilot seat holders but no cost center.
+type UnmappedTeam struct {
+	Team           string // team key
+	CopilotHolders int
+}
+
+// Unmapped lists what the last BuildTeamAssignments call left without a cost
+// center: teams with Copilot seat holders that have no mapping, and seat
+// holders who belong to no mapped team.
+type Unmapped struct {
+	Teams []UnmappedTeam
+	Users []string
+}
+
+// Count returns the number of unmapped teams plus unmapped users.
+func (u *Unmapped) Count() int {
+	return len(u.Teams) + len(u.Users)
+}
+
+// FindUnmapped reports the teams and Copilot users the last
+// BuildTeamAssignments call did not map.  It fetches the members of unmapped
+// teams; teams whose members cannot be fetched are logged and left out.
+// Users of mapped teams whose members could not be fetched may show up as
+// unmapped.
+func (m *Manager) FindUnmapped() (*Unmapped, error) {
+	holders, err := m.fetchSeatHolders()
+	if err != nil {
+		return nil, err
+	}
+
+	failed, err := m.prefetchMembers(m.unmappedTeams)
+	if err != nil {
+		return nil, err
+	}
+	out := &Unmapped{}
+	for _, job := range m.unmappedTeams {
+		if err, ok := failed[job.key]; ok {
+			m.log.Warn("Could not fetch members of unmapped team", "team", job.key, "error", err)
+			continue
+		}
+		n := 0
+		for _, username := range m.membersCache[job.key] {
+			if _, ok := holders[strings.ToLower(username)]; ok {
+				n++
+			}
+		}
+		if n > 0 {
+			out.Teams = append(out.Teams, UnmappedTeam{Team: job.key, CopilotHolders: n})
+		}
+	}
+	sort.Slice(out.Teams, func(i, j int) bool { return out.Teams[i].Team < out.Teams[j].Team })
+
+	for lower, login := range holders {
+		if !m.mappedUsers[lower] && !m.cfg.IsExcludedUser(login) {
+			out.Users = append(out.Users, login)
+		}
+	}
+	sort.Strings(out.Users)
+	return out, nil
+}
+
+// Print displays the unmapped teams and users.
+func (u *Unmapped) Print() {
+	p := render.Stdout()
+	heading := func(format string, n int) string {
+		if n > 0 {
+			return p.Warn(fmt.Sprintf(format, n))
+		}
+		return fmt.Sprintf(format, n)
+	}
+	p.Println("\n" + heading("Unmapped teams with Copilot seat holders (%d):", len(u.Teams)))
+	for _, t := range u.Teams {
+		p.Printf("  - %s: %d seat holders\n", t.Team, t.CopilotHolders)
+	}
+	p.Println(heading("Copilot users in no mapped team (%d):", len(u.Users)))
+	for _, login := range u.Users {
+		p.Printf("  - %s\n", login)
+	}
+}
+
 // GenerateSummary builds and returns a teams-aware summary report.
 func (m *Manager) GenerateSummary() (*Summary, error) {
 	assignments, err := m.BuildTeamAssignments()
@@ -717,6 +1363,11 @@ func (m *Manager) GenerateSummary() (*Summary, error) {
 		ccBreakdown[ccName] = len(userAssigns)
 	}
 
+	unmapped, err := m.FindUnmapped()
+	if err != nil {
+		return nil, err
+	}
+
 	return &Summary{
 		Mode:          m.mode,
 		Scope:         m.scope,
@@ -725,6 +1376,7 @@ func (m *Manager) GenerateSummary() (*Summary, error) {
 		TotalCCs:      len(assignments),
 		UniqueUsers:   len(allUsers),
 		CostCenters:   ccBreakdown,
+		Unmapped:      unmapped,
 	}, nil
 }
 
@@ -737,6 +1389,7 @@ type Summary struct {
 	TotalCCs      int
 	UniqueUsers   int
 	CostCenters   map[string]int // CC name -> user count
+	Unmapped      *Unmapped
 }
 
 // Print displays the summary to stdout.
@@ -768,28 +1421,75 @@ func (s *Summary) Print(enterprise string) {
 			fmt.Printf("  %s: %d users\n", name, s.CostCenters[name])
 		}
 	}
+
+	if s.Unmapped != nil {
+		s.Unmapped.Print()
+	}
 }
 
-// createBudgetsForNewCCs creates configured budgets for each newly-created
-// cost center.  Stops attempting if the budgets API is unavailable (404).
-func (m *Manager) createBudgetsForNewCCs(ccMap map[string]string, newlyCreated map[string]bool) error {
-	if len(m.budgetProducts) == 0 {
+// overrideProduct is the budget product whose amount teams.budget_overrides
+// sets.
+const overrideProduct = "copilot_premium_request"
+
+// plannedBudget is one budget a cost center should have.
+type plannedBudget struct {
+	product  string
+	amount   int
+	alerting github.BudgetAlerting
+}
+
+// budgetsFor returns the budgets ccName should have, sorted by product: the
+// enabled budget products with the cost center's budgets.cost_center_overrides
+// entry applied, plus a Copilot premium request budget with the amount from
+// teams.budget_overrides when one of its teams has an override.  When
+// several of its teams do, the highest amount wins.
+func (m *Manager) budgetsFor(ccName string) []plannedBudget {
+	override := 0
+	for _, teamKey := range m.ccTeams[ccName] {
+		slug := teamKey[strings.LastIndex(teamKey, "/")+1:]
+		for _, key := range []string{teamKey, slug} {
+			if amount := m.budgetByTeam[key]; amount > override {
+				override = amount
+			}
+		}
+	}
+
+	products := m.budgetProducts
+	if m.cfg != nil {
+		products = config.WithBudgetOverride(products, m.cfg.BudgetOverrideFor(ccName))
+	}
+	var budgets []plannedBudget
+	for _, product := range slices.Sorted(maps.Keys(products)) {
+		pc := products[product]
+		if product == overrideProduct && override > 0 {
+			continue
+		}
+		if pc.Enabled {
+			budgets = append(budgets, plannedBudget{product, pc.Amount, github.AlertingFromConfig(pc)})
+		}
+	}
+	if override > 0 {
+		budgets = append(budgets, plannedBudget{overrideProduct, override, github.AlertingFromConfig(products[overrideProduct])})
+		sort.Slice(budgets, func(i, j int) bool { return budgets[i].product < budgets[j].product })
+	}
+	return budgets
+}
+
+// ensureBudgets creates the configured budgets for each cost center in ccIDs
+// that lacks them.  Stops attempting if the budgets API is unavailable (404).
+func (m *Manager) ensureBudgets(ccMap map[string]string, ccIDs map[string]bool) error {
+	if len(m.budgetProducts) == 0 && len(m.budgetByTeam) == 0 && (m.cfg == nil || len(m.cfg.BudgetCostCenterOverrides) == 0) {
 		m.log.Debug("No budget products configured, skipping budget creation")
 		return nil
 	}
 
-	m.log.Info("Creating budgets for newly-created cost centers",
-		"count", len(newlyCreated))
+	m.log.Info("Ensuring budgets for cost centers", "count", len(ccIDs))
 
-	// Build reverse map: ccID -> ccName.
-	idToName := make(map[string]string, len(ccMap))
-	for name, id := range ccMap {
-		idToName[id] = name
-	}
+	idToName := reverseMap(ccMap)
 
 	budgetsDisabled := false
 	var failures []string
-	for ccID := range newlyCreated {
+	for _, ccID := range slices.Sorted(maps.Keys(ccIDs)) {
 		if budgetsDisabled {
 			break
 		}
@@ -799,11 +1499,8 @@ func (m *Manager) createBudgetsForNewCCs(ccMap map[string]string, newlyCreated m
 		}
 
 		m.log.Info("Creating budgets for cost center", "name", ccName)
-		for product, pc := range m.budgetProducts {
-			if !pc.Enabled {
-				continue
-			}
-			ok, err := m.client.CreateProductBudget(ccID, ccName, product, pc.Amount)
+		for _, b := range m.budgetsFor(ccName) {
+			ok, err := m.client.CreateProductBudget(ccID, ccName, b.product, b.amount, b.alerting)
 			if err != nil {
 				if _, is404 := err.(*github.BudgetsAPIUnavailableError); is404 {
 					m.log.Warn("Budgets API unavailable, disabling further attempts",
@@ -812,13 +1509,13 @@ func (m *Manager) createBudgetsForNewCCs(ccMap map[string]string, newlyCreated m
 					break
 				}
 				m.log.Error("Failed to create budget",
-					"product", product, "cost_center", ccName, "error", err)
-				failures = append(failures, fmt.Sprintf("%s/%s: %v", ccName, product, err))
+					"product", b.product, "cost_center", ccName, "error", err)
+				failures = append(failures, fmt.Sprintf("%s/%s: %v", ccName, b.product, err))
 				continue
 			}
 			if ok {
 				m.log.Info("Budget created",
-					"product", product, "cost_center", ccName, "amount", pc.Amount)
+					"product", b.product, "cost_center", ccName, "amount", b.amount)
 			}
 		}
 	}
@@ -828,3 +1525,105 @@ func (m *Manager) createBudgetsForNewCCs(ccMap map[string]string, newlyCreated m
 	}
 	return nil
 }
+
+// printPlannedBudgets lists the budgets apply would create: those of each
+// cost center in ccNames that do not exist yet.
+func (m *Manager) printPlannedBudgets(ccNames []string, ccMap map[string]string) {
+	var lines []string
+	existing := 0
+	for _, name := range ccNames {
+		for _, b := range m.budgetsFor(name) {
+			exists, err := m.client.CheckCostCenterHasProductBudget(ccMap[name], name, b.product)
+			if err != nil {
+				if _, is404 := err.(*github.BudgetsAPIUnavailableError); is404 {
+					fmt.Println("\nBudgets API unavailable: no budgets would be created")
+					return
+				}
+				m.log.Warn("Could not check for an existing budget", "product", b.product, "cost_center", name, "error", err)
+			}
+			if exists {
+				existing++
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("  - %s: %s $%d", name, b.product, b.amount))
+		}
+	}
+	fmt.Printf("\nBudgets to create (%d):\n", len(lines))
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	if existing > 0 {
+		fmt.Printf("Budgets that already exist (%d), skipped\n", existing)
+	}
+}
+
+// TeamListing is one team as listed by ListTeams.  Members is only set when
+// members were requested (and could be fetched); CostCenter is empty for a
+// team without a mapping.
+type TeamListing struct {
+	Source     string `json:"source"` // org or enterprise
+	Key        string `json:"key"`
+	Slug       string `json:"slug"`
+	Name       string `json:"name"`
+	Members    *int   `json:"members,omitempty"`
+	CostCenter string `json:"cost_center,omitempty"`
+}
+
+// SetOrganizations replaces the configured organizations searched in
+// organization scope.
+func (m *Manager) SetOrganizations(orgs []string) {
+	m.orgs = orgs
+}
+
+// ResolvesCostCenters reports whether teams map to cost centers in this
+// configuration: always in auto mode, and in manual mode once mappings exist.
+func (m *Manager) ResolvesCostCenters() bool {
+	return m.mode == "auto" || len(m.mappings) > 0
+}
+
+// ListTeams returns the teams in scope, after teams.include/exclude, sorted
+// by key, with the cost center each would be assigned to.  withMembers also
+// counts each team's members (as assignment would see them: non-user and
+// excluded accounts left out), which costs one request per team; teams whose
+// members cannot be fetched are logged and left without a count.
+func (m *Manager) ListTeams(withMembers bool) ([]TeamListing, error) {
+	allTeams, err := m.fetchAllTeams()
+	if err != nil {
+		return nil, err
+	}
+
+	listings := make([]TeamListing, 0)
+	var jobs []memberJob
+	for source, teams := range allTeams {
+		for _, team := range teams {
+			key := m.teamKey(source, team.Slug)
+			l := TeamListing{Source: source, Key: key, Slug: team.Slug, Name: team.Name}
+			switch {
+			case m.mode == "manual":
+				l.CostCenter = m.mappings[key]
+			case m.ResolvesCostCenters():
+				l.CostCenter, _ = m.costCenterForTeam(source, team)
+			}
+			listings = append(listings, l)
+			jobs = append(jobs, memberJob{source: source, slug: team.Slug, key: key})
+		}
+	}
+	sort.Slice(listings, func(i, j int) bool { return listings[i].Key < listings[j].Key })
+
+	if withMembers {
+		failed, err := m.prefetchMembers(jobs)
+		if err != nil {
+			return nil, err
+		}
+		for i := range listings {
+			key := listings[i].Key
+			if err, ok := failed[key]; ok {
+				m.log.Warn("Could not fetch team members", "team", key, "error", err)
+				continue
+			}
+			n := len(m.membersCache[key])
+			listings[i].Members = &n
+		}
+	}
+	return listings, nil
+}
@@ -2,15 +2,26 @@ package teams
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path"
+	"reflect"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/renan-alm/gh-cost-center/internal/config"
 	"github.com/renan-alm/gh-cost-center/internal/github"
+	"github.com/renan-alm/gh-cost-center/internal/githubtest"
 )
 
 // newTestManager builds a Manager with the given overrides and a discarding logger.
@@ -43,12 +54,13 @@ func newTestManager(scope, mode string, orgs []string, mappings map[string]strin
 func TestCostCenterForTeam_AutoOrg(t *testing.T) {
 	mgr := newTestManager("organization", "auto", []string{"my-org"}, nil, false, false)
 
-	team := github.Team{Name: "backend-team", Slug: "backend-team"}
+	// The default template is just the team name: no org, no prefix.
+	team := github.Team{Name: "Backend Team", Slug: "backend-team"}
 	cc, ok := mgr.costCenterForTeam("my-org", team)
 	if !ok {
 		t.Fatal("expected ok=true for auto org team")
 	}
-	want := "[org team] my-org/backend-team"
+	want := "Backend Team"
 	if cc != want {
 		t.Errorf("got %q, want %q", cc, want)
 	}
@@ -62,7 +74,7 @@ func TestCostCenterForTeam_AutoEnterprise(t *testing.T) {
 	if !ok {
 		t.Fatal("expected ok=true for auto enterprise team")
 	}
-	want := "[enterprise team] Platform Engineers"
+	want := "Platform Engineers"
 	if cc != want {
 		t.Errorf("got %q, want %q", cc, want)
 	}
@@ -171,14 +183,14 @@ func TestBuildTeamAssignments_LastTeamWins(t *testing.T) {
 
 	// alice should be in team-a.
 	aliceAssign := userFinal["alice"]
-	if aliceAssign.CostCenter != "[org team] org1/team-a" {
-		t.Errorf("alice: got %q, want %q", aliceAssign.CostCenter, "[org team] org1/team-a")
+	if aliceAssign.CostCenter != "team-a" {
+		t.Errorf("alice: got %q, want %q", aliceAssign.CostCenter, "team-a")
 	}
 
 	// carol should be in team-b.
 	carolAssign := userFinal["carol"]
-	if carolAssign.CostCenter != "[org team] org1/team-b" {
-		t.Errorf("carol: got %q, want %q", carolAssign.CostCenter, "[org team] org1/team-b")
+	if carolAssign.CostCenter != "team-b" {
+		t.Errorf("carol: got %q, want %q", carolAssign.CostCenter, "team-b")
 	}
 
 	// bob is a multi-team user.
@@ -312,6 +324,59 @@ func TestFetchTeamMembers_EnterpriseCacheKey(t *testing.T) {
 	}
 }
 
+func TestFetchTeamMembers_SkipsNonUserAccounts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"login":"alice","type":"User"},{"login":"ci-bot","type":"Bot"},{"login":"bob"}]`))
+	}))
+	defer srv.Close()
+
+	mgr := newTestManager("organization", "auto", []string{"org1"}, nil, false, false)
+	mgr.client = newTestClientFromURL(t, srv.URL)
+	members, err := mgr.fetchTeamMembers("org1", "devs")
+	if err != nil {
+		t.Fatalf("fetchTeamMembers: %v", err)
+	}
+	if strings.Join(members, ",") != "alice,bob" {
+		t.Errorf("members = %v, want [alice bob]", members)
+	}
+
+	mgr = newTestManager("organization", "auto", []string{"org1"}, nil, false, false)
+	mgr.cfg.IncludeNonUserAccounts = true
+	mgr.client = newTestClientFromURL(t, srv.URL)
+	if members, _ = mgr.fetchTeamMembers("org1", "devs"); len(members) != 3 {
+		t.Errorf("with include_non_user_accounts: members = %v, want 3", members)
+	}
+}
+
+func TestHandleUserRemoval_KeepsExcludedUsers(t *testing.T) {
+	const ccID = "d1e2f3a4-b5c6-7890-abcd-ef1234567890"
+	var removed []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodDelete {
+			var body struct {
+				Users []string `json:"users"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			removed = append(removed, body.Users...)
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"` + ccID + `","resources":[{"type":"User","name":"alice"},{"type":"User","name":"CEO"},{"type":"User","name":"gone"}]}`))
+	}))
+	defer srv.Close()
+
+	mgr := newTestManager("organization", "auto", []string{"org1"}, nil, false, true)
+	mgr.client = newTestClientFromURL(t, srv.URL)
+	mgr.cfg.MergeExcludedUsers([]string{"ceo"})
+
+	mgr.handleUserRemoval(map[string][]string{ccID: {"alice"}}, map[string]string{"Team": ccID}, nil)
+	if strings.Join(removed, ",") != "gone" {
+		t.Errorf("removed = %v, want only gone (CEO is excluded)", removed)
+	}
+}
+
 // testLogger returns a quiet logger for test usage.
 func testLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
@@ -333,7 +398,7 @@ func newTestClientFromURL(t *testing.T, url string) *github.Client {
 }
 
 // newTestManagerWithClient builds a Manager with a real github.Client and budget config.
-func newTestManagerWithClient(client *github.Client, products map[string]config.ProductBudget) *Manager {
+func newTestManagerWithClient(client github.CostCenterAPI, products map[string]config.ProductBudget) *Manager {
 	logger := testLogger()
 	cfg := &config.Manager{
 		TeamsScope:    "organization",
@@ -358,7 +423,7 @@ func newTestManagerWithClient(client *github.Client, products map[string]config.
 func TestCreateBudgetsForNewCCs_NoProducts(t *testing.T) {
 	mgr := newTestManagerWithClient(nil, nil)
 	// Empty products should return nil immediately.
-	err := mgr.createBudgetsForNewCCs(
+	err := mgr.ensureBudgets(
 		map[string]string{"CC A": "cc-id-a"},
 		map[string]bool{"cc-id-a": true},
 	)
@@ -384,7 +449,7 @@ func TestCreateBudgetsForNewCCs_AllSucceed(t *testing.T) {
 	}
 	mgr := newTestManagerWithClient(client, products)
 
-	err := mgr.createBudgetsForNewCCs(
+	err := mgr.ensureBudgets(
 		map[string]string{"CC A": "cc-id-a"},
 		map[string]bool{"cc-id-a": true},
 	)
@@ -393,6 +458,27 @@ func TestCreateBudgetsForNewCCs_AllSucceed(t *testing.T) {
 	}
 }
 
+func TestCreateBudgetsForNewCCs_FakeAPI(t *testing.T) {
+	fake := githubtest.New()
+	id := fake.AddCostCenter("CC A")
+	mgr := newTestManagerWithClient(fake, map[string]config.ProductBudget{
+		"actions": {Amount: 100, Enabled: true},
+	})
+
+	if err := mgr.ensureBudgets(map[string]string{"CC A": id}, map[string]bool{id: true}); err != nil {
+		t.Fatalf("ensureBudgets: %v", err)
+	}
+	if len(fake.Budgets) != 1 || fake.Budgets[0].BudgetAmount != 100 || fake.Budgets[0].BudgetEntityName != "CC A" {
+		t.Errorf("budgets = %+v, want one actions budget of 100 for CC A", fake.Budgets)
+	}
+
+	fake.Fail("CreateProductBudget", errors.New("boom"))
+	other := fake.AddCostCenter("CC B")
+	if err := mgr.ensureBudgets(map[string]string{"CC B": other}, map[string]bool{other: true}); err == nil {
+		t.Error("ensureBudgets succeeded with a failing CreateProductBudget")
+	}
+}
+
 func TestCreateBudgetsForNewCCs_PartialFailure(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
@@ -411,7 +497,7 @@ func TestCreateBudgetsForNewCCs_PartialFailure(t *testing.T) {
 	}
 	mgr := newTestManagerWithClient(client, products)
 
-	err := mgr.createBudgetsForNewCCs(
+	err := mgr.ensureBudgets(
 		map[string]string{"CC A": "cc-id-a"},
 		map[string]bool{"cc-id-a": true},
 	)
@@ -437,7 +523,7 @@ func TestCreateBudgetsForNewCCs_APIUnavailable(t *testing.T) {
 	mgr := newTestManagerWithClient(client, products)
 
 	// 404 triggers BudgetsAPIUnavailableError — should return nil (graceful degradation).
-	err := mgr.createBudgetsForNewCCs(
+	err := mgr.ensureBudgets(
 		map[string]string{"CC A": "cc-id-a"},
 		map[string]bool{"cc-id-a": true},
 	)
@@ -446,6 +532,122 @@ func TestCreateBudgetsForNewCCs_APIUnavailable(t *testing.T) {
 	}
 }
 
+func TestBudgetsFor_Overrides(t *testing.T) {
+	mgr := newTestManagerWithClient(nil, map[string]config.ProductBudget{
+		"actions":                 {Amount: 125, Enabled: true},
+		"copilot_premium_request": {Amount: 100, Enabled: true},
+		"packages":                {Amount: 10, Enabled: false},
+	})
+	mgr.budgetByTeam = map[string]int{"platform": 500, "my-org/sre": 800}
+	mgr.ccTeams = map[string][]string{
+		"Platform": {"my-org/platform"},
+		"Infra":    {"my-org/platform", "my-org/sre"},
+		"Sales":    {"my-org/sales"},
+	}
+
+	tests := []struct {
+		cc   string
+		want string
+	}{
+		{"Platform", "actions=125,copilot_premium_request=500"},
+		{"Infra", "actions=125,copilot_premium_request=800"}, // highest override wins
+		{"Sales", "actions=125,copilot_premium_request=100"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, b := range mgr.budgetsFor(tt.cc) {
+			got = append(got, fmt.Sprintf("%s=%d", b.product, b.amount))
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("budgetsFor(%q) = %v, want %s", tt.cc, got, tt.want)
+		}
+	}
+
+	// An override alone still yields a premium request budget.
+	mgr.budgetProducts = nil
+	if got := mgr.budgetsFor("Platform"); len(got) != 1 || got[0].product != "copilot_premium_request" || got[0].amount != 500 {
+		t.Errorf("budgetsFor without products = %+v", got)
+	}
+}
+
+// budgetTestServer lists the given existing budgets and records the SKUs of
+// created budgets.
+func budgetTestServer(t *testing.T, existing ...github.Budget) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var created []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{"budgets": existing})
+			return
+		}
+		var body struct {
+			SKU    string `json:"budget_product_sku"`
+			Entity string `json:"budget_entity_name"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		created = append(created, body.Entity+":"+body.SKU)
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &created
+}
+
+func TestEnsureBudgets_SkipsExisting(t *testing.T) {
+	srv, created := budgetTestServer(t, github.Budget{
+		BudgetScope: "cost_center", BudgetEntityName: "CC A", BudgetProductSKU: "copilot_premium_request", BudgetAmount: 100,
+	})
+	mgr := newTestManagerWithClient(newTestClientFromURL(t, srv.URL), map[string]config.ProductBudget{
+		"actions":                 {Amount: 125, Enabled: true},
+		"copilot_premium_request": {Amount: 100, Enabled: true},
+	})
+
+	err := mgr.ensureBudgets(
+		map[string]string{"CC A": "cc-id-a", "CC B": "cc-id-b"},
+		map[string]bool{"cc-id-a": true, "cc-id-b": true},
+	)
+	if err != nil {
+		t.Fatalf("ensureBudgets: %v", err)
+	}
+	// CC A already has its premium request budget; only the missing ones are created.
+	if got := strings.Join(*created, ","); got != "cc-id-a:actions,cc-id-b:actions,cc-id-b:copilot_premium_request" {
+		t.Errorf("created = %s", got)
+	}
+}
+
+func TestPrintPlannedBudgets(t *testing.T) {
+	srv, created := budgetTestServer(t, github.Budget{
+		BudgetScope: "cost_center", BudgetEntityName: "cc-id-a", BudgetProductSKU: "actions",
+	})
+	mgr := newTestManagerWithClient(newTestClientFromURL(t, srv.URL), map[string]config.ProductBudget{
+		"actions":                 {Amount: 125, Enabled: true},
+		"copilot_premium_request": {Amount: 100, Enabled: true},
+	})
+	mgr.budgetByTeam = map[string]int{"platform": 500}
+	mgr.ccTeams = map[string][]string{"CC A": {"my-org/platform"}}
+
+	out := captureStdout(t, func() {
+		mgr.printPlannedBudgets([]string{"CC A", "CC New"}, map[string]string{"CC A": "cc-id-a", "CC New": "CC New"})
+	})
+	for _, want := range []string{
+		"Budgets to create (3):",
+		"  - CC A: copilot_premium_request $500",
+		"  - CC New: actions $125",
+		"  - CC New: copilot_premium_request $100",
+		"Budgets that already exist (1), skipped",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("plan output missing %q:\n%s", want, out)
+		}
+	}
+	if len(*created) != 0 {
+		t.Errorf("plan created budgets: %v", *created)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // EnsureCostCentersExist — resolve-without-create
 // ---------------------------------------------------------------------------
@@ -674,3 +876,811 @@ func TestEnsureCostCentersExist_UUIDPassthrough(t *testing.T) {
 		t.Errorf("ccMap[uuid]: got %q, want %q (the UUID itself)", ccMap[knownUUID], knownUUID)
 	}
 }
+
+func TestBuildAssigningTeamAssignments(t *testing.T) {
+	users := []github.CopilotUser{
+		{Login: "alice", AssigningTeam: &github.AssigningTeam{ID: 1, Name: "Platform", Slug: "platform", HTMLURL: "https://github.com/orgs/octo/teams/platform"}},
+		{Login: "bob", AssigningTeam: &github.AssigningTeam{ID: 1, Name: "Platform", Slug: "platform", HTMLURL: "https://github.com/orgs/octo/teams/platform"}},
+		{Login: "carol", AssigningTeam: &github.AssigningTeam{ID: 2, Slug: "data-eng"}, Organization: "acme"},
+		{Login: "erin", AssigningTeam: &github.AssigningTeam{ID: 3, Name: "Ops"}},
+		{Login: "dave"},
+		{Login: ""},
+	}
+
+	got := BuildAssigningTeamAssignments(users, "Default CC")
+
+	if n := len(got["[assigning team] octo/platform"]); n != 2 {
+		t.Errorf("octo/platform group has %d users, want 2", n)
+	}
+	if n := len(got["[assigning team] acme/data-eng"]); n != 1 {
+		t.Errorf("seat org fallback group has %d users, want 1", n)
+	}
+	if n := len(got["[assigning team] Ops"]); n != 1 {
+		t.Errorf("name fallback group has %d users, want 1", n)
+	}
+	def := got["Default CC"]
+	if len(def) != 1 || def[0].Username != "dave" {
+		t.Errorf("default group = %+v, want [dave]", def)
+	}
+	if len(got) != 4 {
+		t.Errorf("got %d groups, want 4", len(got))
+	}
+}
+
+func TestBuildAssigningTeamAssignments_SameNameDifferentOrgs(t *testing.T) {
+	users := []github.CopilotUser{
+		{Login: "alice", AssigningTeam: &github.AssigningTeam{ID: 1, Name: "Engineering", Slug: "engineering", HTMLURL: "https://github.com/orgs/octo/teams/engineering"}},
+		{Login: "bob", AssigningTeam: &github.AssigningTeam{ID: 2, Name: "Engineering", Slug: "engineering", HTMLURL: "https://github.com/orgs/acme/teams/engineering"}},
+	}
+
+	got := BuildAssigningTeamAssignments(users, "Default CC")
+
+	if len(got) != 2 {
+		t.Fatalf("teams with the same name in different orgs were merged: %v", got)
+	}
+	if len(got["[assigning team] octo/engineering"]) != 1 || len(got["[assigning team] acme/engineering"]) != 1 {
+		t.Errorf("groups = %v", got)
+	}
+}
+
+func TestSyncAssigningTeamAssignments_Plan(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("plan mode made a %s request to %s", r.Method, r.URL.Path)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"costCenters": []map[string]string{
+			{"id": "d1e2f3a4-b5c6-7890-abcd-ef1234567890", "name": "[assigning team] octo/platform", "state": "active"},
+		}})
+	}))
+	defer srv.Close()
+
+	cfg := &config.Manager{Enterprise: "test-enterprise", AssigningTeamDefaultCostCenter: "Default CC"}
+	mgr := NewAssigningTeamManager(cfg, newTestClientFromURL(t, srv.URL), testLogger())
+	users := []github.CopilotUser{
+		{Login: "alice", AssigningTeam: &github.AssigningTeam{Name: "Platform", Slug: "platform", HTMLURL: "https://github.com/orgs/octo/teams/platform"}},
+		{Login: "dave"},
+	}
+	results, err := mgr.SyncAssigningTeamAssignments(users, "plan", true)
+	if err != nil {
+		t.Fatalf("SyncAssigningTeamAssignments: %v", err)
+	}
+	if results != nil {
+		t.Errorf("plan mode returned results: %v", results)
+	}
+}
+
+// fakeTeamsAPI serves org teams and members and the cost center endpoints,
+// recording every write.
+type fakeTeamsAPI struct {
+	mu      sync.Mutex
+	teams   map[string][]string // org -> team slugs
+	members map[string][]string // "org/slug" -> logins
+	ccs     map[string]string   // cost center name -> ID
+	current map[string][]string // cost center ID -> member logins
+	created []string            // names of created cost centers
+	added   map[string][]string // cost center ID -> POSTed logins
+	removed map[string][]string // cost center ID -> DELETEd logins
+	writes  []string            // "METHOD path" of every write
+	reads   []string            // path of every GET
+	seats   []string            // Copilot seat holder logins
+}
+
+func (f *fakeTeamsAPI) serve(t *testing.T) *httptest.Server {
+	t.Helper()
+	f.added, f.removed = map[string][]string{}, map[string][]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		p := r.URL.Path
+		if r.Method != http.MethodGet {
+			f.writes = append(f.writes, r.Method+" "+p)
+		} else {
+			f.reads = append(f.reads, p)
+		}
+		switch {
+		case strings.HasSuffix(p, "/copilot/billing/seats"):
+			seats := make([]map[string]any, len(f.seats))
+			for i, login := range f.seats {
+				seats[i] = map[string]any{"assignee": map[string]string{"login": login, "type": "User"}}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"total_seats": len(seats), "seats": seats})
+		case strings.HasPrefix(p, "/orgs/") && strings.HasSuffix(p, "/members"):
+			// /orgs/{org}/teams/{slug}/members
+			parts := strings.Split(p, "/")
+			var out []map[string]string
+			for _, login := range f.members[parts[2]+"/"+parts[4]] {
+				out = append(out, map[string]string{"login": login, "type": "User"})
+			}
+			_ = json.NewEncoder(w).Encode(out)
+		case strings.HasPrefix(p, "/orgs/") && strings.HasSuffix(p, "/teams"):
+			org := strings.Split(p, "/")[2]
+			var out []map[string]string
+			for _, slug := range f.teams[org] {
+				out = append(out, map[string]string{"name": slug, "slug": slug})
+			}
+			_ = json.NewEncoder(w).Encode(out)
+		case strings.HasSuffix(p, "/settings/billing/cost-centers") && r.Method == http.MethodPost:
+			var body struct {
+				Name string `json:"name"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			id := fmt.Sprintf("00000000-0000-0000-0000-%012d", len(f.ccs)+1)
+			f.ccs[body.Name] = id
+			f.created = append(f.created, body.Name)
+			_ = json.NewEncoder(w).Encode(map[string]string{"id": id, "name": body.Name})
+		case strings.HasSuffix(p, "/settings/billing/cost-centers"):
+			var out []map[string]string
+			for name, id := range f.ccs {
+				out = append(out, map[string]string{"id": id, "name": name, "state": "active"})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"costCenters": out})
+		case strings.HasSuffix(p, "/resource"):
+			var body struct {
+				Users []string `json:"users"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			id := path.Base(path.Dir(p))
+			if r.Method == http.MethodDelete {
+				f.removed[id] = append(f.removed[id], body.Users...)
+			} else {
+				f.added[id] = append(f.added[id], body.Users...)
+			}
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			id := path.Base(p)
+			var resources []map[string]string
+			for _, login := range f.current[id] {
+				resources = append(resources, map[string]string{"type": "User", "name": login})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": id, "name": id, "state": "active", "resources": resources})
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newSyncTestManager builds a Manager through NewManager against srv.
+func newSyncTestManager(t *testing.T, srv *httptest.Server, cfg *config.Manager) *Manager {
+	t.Helper()
+	cfg.Enterprise = "test-enterprise"
+	if cfg.TeamsScope == "" {
+		cfg.TeamsScope = "organization"
+	}
+	return NewManager(cfg, newTestClientFromURL(t, srv.URL), testLogger())
+}
+
+func sorted(s []string) string {
+	s = append([]string{}, s...)
+	sort.Strings(s)
+	return strings.Join(s, ",")
+}
+
+func TestSyncTeamAssignments_ApplyAutoAcrossOrgs(t *testing.T) {
+	api := &fakeTeamsAPI{
+		teams: map[string][]string{"org1": {"platform"}, "org2": {"mobile"}},
+		members: map[string][]string{
+			"org1/platform": {"alice", "bob"},
+			"org2/mobile":   {"carol"},
+		},
+		ccs: map[string]string{"platform": "00000000-0000-0000-0000-0000000000aa"},
+	}
+	srv := api.serve(t)
+	mgr := newSyncTestManager(t, srv, &config.Manager{
+		TeamsStrategy:   "auto",
+		Organizations:   []string{"org1", "org2"},
+		TeamsAutoCreate: true,
+	})
+
+	results, err := mgr.SyncTeamAssignments("apply", true)
+	if err != nil {
+		t.Fatalf("SyncTeamAssignments: %v", err)
+	}
+	if sorted(api.created) != "mobile" {
+		t.Errorf("created = %v, want only the missing org2/mobile cost center", api.created)
+	}
+	mobileID := api.ccs["mobile"]
+	if got := sorted(api.added["00000000-0000-0000-0000-0000000000aa"]); got != "alice,bob" {
+		t.Errorf("platform cost center got %q, want alice,bob", got)
+	}
+	if got := sorted(api.added[mobileID]); got != "carol" {
+		t.Errorf("mobile cost center got %q, want carol", got)
+	}
+	if len(results) != 2 {
+		t.Errorf("results = %v, want one entry per cost center", results)
+	}
+}
+
+func TestSyncTeamAssignments_PlanMakesNoWrites(t *testing.T) {
+	api := &fakeTeamsAPI{
+		teams:   map[string][]string{"org1": {"platform"}},
+		members: map[string][]string{"org1/platform": {"alice"}},
+		ccs:     map[string]string{},
+	}
+	srv := api.serve(t)
+	mgr := newSyncTestManager(t, srv, &config.Manager{
+		TeamsStrategy:             "auto",
+		Organizations:             []string{"org1"},
+		TeamsAutoCreate:           true,
+		TeamsRemoveUnmatchedUsers: true,
+	})
+
+	results, err := mgr.SyncTeamAssignments("plan", true)
+	if err != nil {
+		t.Fatalf("SyncTeamAssignments: %v", err)
+	}
+	if results != nil || len(api.writes) != 0 {
+		t.Errorf("plan mode: results = %v, writes = %v; want none", results, api.writes)
+	}
+}
+
+func TestSyncTeamAssignments_ManualWithoutAutoCreate(t *testing.T) {
+	api := &fakeTeamsAPI{
+		teams: map[string][]string{"org1": {"platform", "unmapped"}},
+		members: map[string][]string{
+			"org1/platform": {"alice"},
+			"org1/unmapped": {"dave"},
+		},
+		ccs:     map[string]string{"Engineering": "00000000-0000-0000-0000-0000000000ee"},
+		current: map[string][]string{"00000000-0000-0000-0000-0000000000ee": {"zed"}},
+	}
+	srv := api.serve(t)
+	mgr := newSyncTestManager(t, srv, &config.Manager{
+		TeamsStrategy:             "manual",
+		Organizations:             []string{"org1"},
+		TeamsMappings:             map[string]string{"org1/platform": "Engineering"},
+		TeamsRemoveUnmatchedUsers: true,
+	})
+
+	if _, err := mgr.SyncTeamAssignments("apply", true); err != nil {
+		t.Fatalf("SyncTeamAssignments: %v", err)
+	}
+	if len(api.created) != 0 {
+		t.Errorf("created %v without auto-create", api.created)
+	}
+	if got := sorted(api.added["00000000-0000-0000-0000-0000000000ee"]); got != "alice" {
+		t.Errorf("Engineering got %q, want alice (unmapped team skipped)", got)
+	}
+	if got := sorted(api.removed["00000000-0000-0000-0000-0000000000ee"]); got != "zed" {
+		t.Errorf("removed %q, want zed who left the team", got)
+	}
+}
+
+func TestSyncTeamAssignments_MissingCostCenterWithoutAutoCreate(t *testing.T) {
+	api := &fakeTeamsAPI{
+		teams:   map[string][]string{"org1": {"platform"}},
+		members: map[string][]string{"org1/platform": {"alice"}},
+		ccs:     map[string]string{},
+	}
+	srv := api.serve(t)
+	mgr := newSyncTestManager(t, srv, &config.Manager{TeamsStrategy: "auto", Organizations: []string{"org1"}})
+
+	if _, err := mgr.SyncTeamAssignments("apply", true); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("err = %v, want unresolved cost center error", err)
+	}
+	if len(api.writes) != 0 {
+		t.Errorf("writes = %v, want none", api.writes)
+	}
+}
+
+func TestAssignmentGroups_DedupesAndSorts(t *testing.T) {
+	assignments := map[string][]UserAssignment{
+		"cc-a": {{Username: "bob"}, {Username: "alice"}, {Username: "bob"}},
+	}
+	groups := AssignmentGroups(assignments, map[string]string{"cc-a": "id-a"})
+	if got := strings.Join(groups["id-a"], ","); got != "alice,bob" {
+		t.Errorf("groups[id-a] = %q, want alice,bob", got)
+	}
+}
+
+func TestRenderNameTemplate(t *testing.T) {
+	team := github.Team{Name: "Platform Engineers", Slug: "platform-engineers"}
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{"Eng - {team_name}", "Eng - Platform Engineers"},
+		{"{org}/{team_slug}", "my-org/platform-engineers"},
+		{"{team_name} ({org}, {team_slug})", "Platform Engineers (my-org, platform-engineers)"},
+		{"Static", "Static"},
+	}
+	for _, tt := range tests {
+		if got := renderNameTemplate(tt.tmpl, "my-org", team); got != tt.want {
+			t.Errorf("renderNameTemplate(%q) = %q, want %q", tt.tmpl, got, tt.want)
+		}
+	}
+}
+
+func TestCostCenterForTeam_Template(t *testing.T) {
+	mgr := newTestManager("organization", "auto", []string{"my-org"}, nil, false, false)
+	mgr.nameTemplate = "Eng - {team_name}"
+
+	cc, ok := mgr.costCenterForTeam("my-org", github.Team{Name: "Backend", Slug: "backend"})
+	if !ok || cc != "Eng - Backend" {
+		t.Errorf("got %q (ok=%v), want %q", cc, ok, "Eng - Backend")
+	}
+}
+
+func TestSyncTeamAssignments_TemplateResolvesRenderedName(t *testing.T) {
+	api := &fakeTeamsAPI{
+		teams:   map[string][]string{"org1": {"platform"}},
+		members: map[string][]string{"org1/platform": {"alice"}},
+		ccs:     map[string]string{"Eng - platform": "00000000-0000-0000-0000-0000000000aa"},
+	}
+	srv := api.serve(t)
+	mgr := newSyncTestManager(t, srv, &config.Manager{
+		TeamsStrategy:     "auto",
+		Organizations:     []string{"org1"},
+		TeamsNameTemplate: "Eng - {team_name}",
+	})
+
+	if _, err := mgr.SyncTeamAssignments("apply", true); err != nil {
+		t.Fatalf("SyncTeamAssignments: %v", err)
+	}
+	if got := sorted(api.added["00000000-0000-0000-0000-0000000000aa"]); got != "alice" {
+		t.Errorf("rendered cost center got %q, want alice", got)
+	}
+}
+
+func TestBuildTeamAssignments_TemplateCollision(t *testing.T) {
+	api := &fakeTeamsAPI{
+		teams: map[string][]string{"org1": {"platform"}, "org2": {"platform"}},
+		members: map[string][]string{
+			"org1/platform": {"alice"},
+			"org2/platform": {"bob"},
+		},
+		ccs: map[string]string{},
+	}
+	srv := api.serve(t)
+	mgr := newSyncTestManager(t, srv, &config.Manager{
+		TeamsStrategy:     "auto",
+		Organizations:     []string{"org1", "org2"},
+		TeamsNameTemplate: "{team_name}",
+	})
+
+	_, err := mgr.BuildTeamAssignments()
+	if err == nil || !strings.Contains(err.Error(), "org1/platform and org2/platform both map to cost center \"platform\"") {
+		t.Errorf("err = %v, want a collision error naming both teams", err)
+	}
+}
+
+func TestBuildTeamAssignments_ConflictResolution(t *testing.T) {
+	tests := []struct {
+		policy   string
+		priority []string
+		wantCC   string // bob's cost center; "" means an error
+	}{
+		{"first_alphabetical", nil, "alpha"},
+		{"priority_list", []string{"org1/zeta"}, "zeta"},
+		{"priority_list", []string{"org1/other"}, "alpha"}, // unlisted: alphabetical
+		{"error", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy+"/"+strings.Join(tt.priority, ","), func(t *testing.T) {
+			api := &fakeTeamsAPI{
+				teams: map[string][]string{"org1": {"zeta", "alpha"}},
+				members: map[string][]string{
+					"org1/zeta":  {"bob", "carol"},
+					"org1/alpha": {"alice", "bob"},
+				},
+				ccs: map[string]string{},
+			}
+			mgr := newSyncTestManager(t, api.serve(t), &config.Manager{
+				TeamsStrategy:           "auto",
+				Organizations:           []string{"org1"},
+				TeamsConflictResolution: tt.policy,
+				TeamsPriority:           tt.priority,
+			})
+
+			assignments, err := mgr.BuildTeamAssignments()
+			if tt.wantCC == "" {
+				if err == nil || !strings.Contains(err.Error(), "bob (org1/alpha, org1/zeta)") {
+					t.Errorf("err = %v, want a conflict error listing bob", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BuildTeamAssignments: %v", err)
+			}
+			var bobCC string
+			for cc, uas := range assignments {
+				for _, ua := range uas {
+					if ua.Username == "bob" {
+						bobCC = cc
+					}
+				}
+			}
+			if bobCC != tt.wantCC {
+				t.Errorf("bob assigned to %q, want %q", bobCC, tt.wantCC)
+			}
+			conflicts := mgr.Conflicts()
+			if len(conflicts) != 1 || conflicts[0].Username != "bob" || conflicts[0].CostCenter != tt.wantCC ||
+				strings.Join(conflicts[0].Teams, ",") != "org1/alpha,org1/zeta" {
+				t.Errorf("Conflicts = %+v", conflicts)
+			}
+		})
+	}
+}
+
+func TestBuildTeamAssignments_Deterministic(t *testing.T) {
+	build := func(teamOrder []string) map[string][]UserAssignment {
+		api := &fakeTeamsAPI{
+			teams: map[string][]string{"org1": teamOrder, "org2": {"security"}},
+			members: map[string][]string{
+				"org1/platform": {"bob", "alice"},
+				"org1/mobile":   {"bob", "dave"},
+				"org2/security": {"alice", "bob", "erin"},
+			},
+			ccs: map[string]string{},
+		}
+		mgr := newSyncTestManager(t, api.serve(t), &config.Manager{
+			TeamsStrategy:           "auto",
+			Organizations:           []string{"org2", "org1"},
+			TeamsConflictResolution: "first_alphabetical",
+		})
+		assignments, err := mgr.BuildTeamAssignments()
+		if err != nil {
+			t.Fatalf("BuildTeamAssignments: %v", err)
+		}
+		return assignments
+	}
+
+	first := build([]string{"platform", "mobile"})
+	for i := 0; i < 5; i++ {
+		if again := build([]string{"mobile", "platform"}); !reflect.DeepEqual(first, again) {
+			t.Fatalf("run %d differs:\n first %v\n again %v", i, first, again)
+		}
+	}
+	if got := first["mobile"]; len(got) != 2 || got[0].Username != "bob" || got[1].Username != "dave" {
+		t.Errorf("org1/mobile = %+v, want bob and dave (bob's alphabetically first team)", got)
+	}
+}
+
+func TestStaleMembers_GuardsProtectedUsers(t *testing.T) {
+	const ccA, ccB = "00000000-0000-0000-0000-0000000000aa", "00000000-0000-0000-0000-0000000000bb"
+	api := &fakeTeamsAPI{
+		ccs: map[string]string{},
+		current: map[string][]string{
+			ccA: {"Alice", "gone", "ceo", "svc-bot", "vip"},
+			ccB: {"bob", "left"},
+		},
+	}
+	mgr := newSyncTestManager(t, api.serve(t), &config.Manager{TeamsStrategy: "auto"})
+	mgr.cfg.MergeExcludedUsers([]string{"ceo"})
+	mgr.cfg.PRUsExceptionUsers = []string{"VIP", "svc-*"}
+
+	stale := mgr.staleMembers(map[string][]string{ccA: {"alice"}, ccB: {"bob"}}, nil, map[string]bool{ccB: true})
+	if len(stale) != 1 || strings.Join(stale[ccA], ",") != "gone" {
+		t.Errorf("stale = %v, want only gone in ccA (protected users kept, ccB skipped)", stale)
+	}
+}
+
+func TestSyncTeamAssignments_PlanListsRemovals(t *testing.T) {
+	const ccID = "00000000-0000-0000-0000-0000000000aa"
+	api := &fakeTeamsAPI{
+		teams:   map[string][]string{"org1": {"platform"}},
+		members: map[string][]string{"org1/platform": {"alice"}},
+		ccs:     map[string]string{"platform": ccID},
+		current: map[string][]string{ccID: {"alice", "gone"}},
+	}
+	mgr := newSyncTestManager(t, api.serve(t), &config.Manager{
+		TeamsStrategy:             "auto",
+		Organizations:             []string{"org1"},
+		TeamsRemoveUnmatchedUsers: true,
+	})
+
+	out := captureStdout(t, func() {
+		if _, err := mgr.SyncTeamAssignments("plan", true); err != nil {
+			t.Fatalf("SyncTeamAssignments: %v", err)
+		}
+	})
+	if !strings.Contains(out, "To remove (no longer in a mapped team): 1 users") ||
+		!strings.Contains(out, "platform: gone") {
+		t.Errorf("plan output missing removals:\n%s", out)
+	}
+	if len(api.writes) != 0 {
+		t.Errorf("plan mode wrote: %v", api.writes)
+	}
+}
+
+// captureStdout returns what fn prints to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = orig }()
+
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	fn()
+	_ = w.Close()
+	return <-done
+}
+
+func TestMatchesTeamPattern(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		slug     string
+		want     bool
+	}{
+		{[]string{"platform"}, "platform", true},
+		{[]string{"Platform"}, "platform", true},
+		{[]string{"plat*"}, "platform", true},
+		{[]string{"eng-?"}, "eng-1", true},
+		{[]string{"org1/*"}, "anything", true},
+		{[]string{"org2/*"}, "anything", false},
+		{[]string{"*-bots"}, "platform", false},
+		{nil, "platform", false},
+	}
+	for _, tt := range tests {
+		if got := matchesTeamPattern(tt.patterns, tt.slug, "org1/"+tt.slug); got != tt.want {
+			t.Errorf("matchesTeamPattern(%v, %q) = %v, want %v", tt.patterns, tt.slug, got, tt.want)
+		}
+	}
+}
+
+func TestBuildTeamAssignments_IncludeThenExclude(t *testing.T) {
+	api := &fakeTeamsAPI{
+		teams: map[string][]string{"org1": {"eng-web", "eng-bots", "sales", "eng-api"}},
+		members: map[string][]string{
+			"org1/eng-web":  {"alice"},
+			"org1/eng-bots": {"bot"},
+			"org1/sales":    {"sam"},
+			"org1/eng-api":  {"bob"},
+		},
+		ccs: map[string]string{},
+	}
+	mgr := newSyncTestManager(t, api.serve(t), &config.Manager{
+		TeamsStrategy: "auto",
+		Organizations: []string{"org1"},
+		TeamsInclude:  []string{"eng-*"},
+		TeamsExclude:  []string{"org1/*-bots"},
+	})
+
+	assignments, err := mgr.BuildTeamAssignments()
+	if err != nil {
+		t.Fatalf("BuildTeamAssignments: %v", err)
+	}
+	var ccs []string
+	for cc := range assignments {
+		ccs = append(ccs, cc)
+	}
+	if sorted(ccs) != "eng-api,eng-web" {
+		t.Errorf("cost centers = %v, want only eng-web and eng-api", ccs)
+	}
+	if mgr.filteredTeams != 2 {
+		t.Errorf("filteredTeams = %d, want 2", mgr.filteredTeams)
+	}
+	for _, p := range api.reads {
+		if strings.Contains(p, "/sales/") || strings.Contains(p, "/eng-bots/") {
+			t.Errorf("fetched members of a filtered team: %s", p)
+		}
+	}
+	if out := captureStdout(t, mgr.PrintTeamFilter); !strings.Contains(out, "filtered out by teams.include/exclude: 2") {
+		t.Errorf("PrintTeamFilter = %q", out)
+	}
+}
+
+func TestBuildTeamAssignments_CopilotHoldersOnly(t *testing.T) {
+	for _, copilotOnly := range []bool{true, false} {
+		t.Run(fmt.Sprintf("copilot_holders_only=%v", copilotOnly), func(t *testing.T) {
+			api := &fakeTeamsAPI{
+				teams: map[string][]string{"org1": {"platform", "sales"}},
+				members: map[string][]string{
+					"org1/platform": {"alice", "Bob", "nocopilot1"},
+					"org1/sales":    {"nocopilot2", "nocopilot3"},
+				},
+				ccs:   map[string]string{},
+				seats: []string{"alice", "bob"},
+			}
+			mgr := newSyncTestManager(t, api.serve(t), &config.Manager{
+				TeamsStrategy:           "auto",
+				Organizations:           []string{"org1"},
+				TeamsCopilotHoldersOnly: copilotOnly,
+			})
+
+			assignments, err := mgr.BuildTeamAssignments()
+			if err != nil {
+				t.Fatalf("BuildTeamAssignments: %v", err)
+			}
+			var users []string
+			for _, uas := range assignments {
+				for _, ua := range uas {
+					users = append(users, ua.Username)
+				}
+			}
+			out := captureStdout(t, mgr.PrintNonCopilotSkipped)
+
+			if !copilotOnly {
+				if sorted(users) != "Bob,alice,nocopilot1,nocopilot2,nocopilot3" {
+					t.Errorf("users = %v, want every team member", users)
+				}
+				if out != "" {
+					t.Errorf("unexpected skip report: %q", out)
+				}
+				return
+			}
+			if sorted(users) != "Bob,alice" {
+				t.Errorf("users = %v, want only the seat holders", users)
+			}
+			if _, ok := assignments["sales"]; ok {
+				t.Error("sales has no seat holders and must get no assignments")
+			}
+			if mgr.nonCopilotSkipped["org1/platform"] != 1 || mgr.nonCopilotSkipped["org1/sales"] != 2 {
+				t.Errorf("nonCopilotSkipped = %v", mgr.nonCopilotSkipped)
+			}
+			if !strings.Contains(out, "not assigned (3)") || !strings.Contains(out, "org1/sales: 2") {
+				t.Errorf("skip report = %q", out)
+			}
+		})
+	}
+}
+
+// slowTeamsServer serves org1 with n teams of one member each; member
+// requests take delay, and requests for the failing slugs get a 404.  It
+// returns the server and a func reporting the peak number of member requests
+// in flight.
+func slowTeamsServer(t *testing.T, n int, delay time.Duration, failing ...string) (*httptest.Server, func() int32) {
+	t.Helper()
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		p := r.URL.Path
+		switch {
+		case strings.HasSuffix(p, "/teams"):
+			teams := make([]map[string]string, n)
+			for i := range teams {
+				slug := fmt.Sprintf("team-%02d", i)
+				teams[i] = map[string]string{"name": slug, "slug": slug}
+			}
+			_ = json.NewEncoder(w).Encode(teams)
+		case strings.HasSuffix(p, "/members"):
+			cur := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				old := peak.Load()
+				if cur <= old || peak.CompareAndSwap(old, cur) {
+					break
+				}
+			}
+			time.Sleep(delay)
+			slug := strings.Split(p, "/")[4]
+			if slices.Contains(failing, slug) {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode([]map[string]string{{"login": "user-" + slug, "type": "User"}})
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]any{"costCenters": []any{}})
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, peak.Load
+}
+
+func TestBuildTeamAssignments_ParallelMemberFetch(t *testing.T) {
+	const teams, workers, delay = 10, 5, 50 * time.Millisecond
+	srv, peak := slowTeamsServer(t, teams, delay)
+	mgr := newSyncTestManager(t, srv, &config.Manager{TeamsStrategy: "auto", Organizations: []string{"org1"}})
+	mgr.SetMemberFetchOptions(workers, false)
+
+	start := time.Now()
+	assignments, err := mgr.BuildTeamAssignments()
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("BuildTeamAssignments: %v", err)
+	}
+	if len(assignments) != teams {
+		t.Errorf("got %d cost centers, want %d", len(assignments), teams)
+	}
+	if got := peak(); got > workers {
+		t.Errorf("peak member requests in flight = %d, want at most %d", got, workers)
+	}
+	// Serially this takes teams*delay; with the pool at least teams/workers
+	// rounds, and clearly less than serial.
+	if min, serial := time.Duration(teams/workers)*delay, time.Duration(teams)*delay; elapsed < min || elapsed >= serial*3/4 {
+		t.Errorf("elapsed = %v, want between %v and %v", elapsed, min, serial*3/4)
+	}
+}
+
+func TestBuildTeamAssignments_MemberFetchErrors(t *testing.T) {
+	srv, _ := slowTeamsServer(t, 4, 0, "team-01", "team-03")
+
+	mgr := newSyncTestManager(t, srv, &config.Manager{TeamsStrategy: "auto", Organizations: []string{"org1"}})
+	assignments, err := mgr.BuildTeamAssignments()
+	if err != nil {
+		t.Fatalf("BuildTeamAssignments: %v", err)
+	}
+	if len(assignments) != 2 {
+		t.Errorf("got %d cost centers, want the 2 teams that could be fetched", len(assignments))
+	}
+	if got := strings.Join(mgr.FailedTeams(), ","); got != "org1/team-01,org1/team-03" {
+		t.Errorf("FailedTeams = %q", got)
+	}
+	if !mgr.failedCostCenters["team-01"] {
+		t.Error("cost center of a failed team must be protected from removal")
+	}
+
+	mgr = newSyncTestManager(t, srv, &config.Manager{TeamsStrategy: "auto", Organizations: []string{"org1"}})
+	mgr.SetMemberFetchOptions(2, true)
+	if _, err := mgr.BuildTeamAssignments(); err == nil || !strings.Contains(err.Error(), "org1/team-0") {
+		t.Errorf("fail-fast err = %v, want the failing team's error", err)
+	}
+}
+
+func TestFindUnmapped(t *testing.T) {
+	api := &fakeTeamsAPI{
+		teams: map[string][]string{"org1": {"platform", "sales", "bots"}},
+		members: map[string][]string{
+			"org1/platform": {"alice", "bob"},
+			"org1/sales":    {"Carol", "nocopilot"},
+			"org1/bots":     {"nocopilot"},
+		},
+		ccs:   map[string]string{"Platform": "cc-platform"},
+		seats: []string{"alice", "carol", "dave", "eve"},
+	}
+	mgr := newSyncTestManager(t, api.serve(t), &config.Manager{
+		TeamsStrategy:           "manual",
+		Organizations:           []string{"org1"},
+		TeamsMappings:           map[string]string{"org1/platform": "Platform"},
+		TeamsCopilotHoldersOnly: true,
+		ExcludedUsers:           []string{"eve"},
+	})
+
+	if _, err := mgr.BuildTeamAssignments(); err != nil {
+		t.Fatalf("BuildTeamAssignments: %v", err)
+	}
+	unmapped, err := mgr.FindUnmapped()
+	if err != nil {
+		t.Fatalf("FindUnmapped: %v", err)
+	}
+
+	// bots has no seat holders, so only sales is an unmapped team.
+	if want := []UnmappedTeam{{Team: "org1/sales", CopilotHolders: 1}}; !reflect.DeepEqual(unmapped.Teams, want) {
+		t.Errorf("Teams = %+v, want %+v", unmapped.Teams, want)
+	}
+	// carol is only in an unmapped team, dave in none; eve is excluded.
+	if got := strings.Join(unmapped.Users, ","); got != "carol,dave" {
+		t.Errorf("Users = %q, want carol,dave", got)
+	}
+	if unmapped.Count() != 3 {
+		t.Errorf("Count = %d, want 3", unmapped.Count())
+	}
+
+	out := captureStdout(t, unmapped.Print)
+	for _, want := range []string{"Unmapped teams with Copilot seat holders (1)", "org1/sales: 1 seat holders", "in no mapped team (2)", "  - dave"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Print output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestGenerateSummary_IncludesUnmapped(t *testing.T) {
+	api := &fakeTeamsAPI{
+		teams:   map[string][]string{"org1": {"platform"}},
+		members: map[string][]string{"org1/platform": {"alice"}},
+		ccs:     map[string]string{},
+		seats:   []string{"alice", "dave"},
+	}
+	mgr := newSyncTestManager(t, api.serve(t), &config.Manager{TeamsStrategy: "auto", Organizations: []string{"org1"}})
+
+	summary, err := mgr.GenerateSummary()
+	if err != nil {
+		t.Fatalf("GenerateSummary: %v", err)
+	}
+	// In auto mode every team is mapped; only users outside all teams remain.
+	if summary.Unmapped == nil || len(summary.Unmapped.Teams) != 0 || strings.Join(summary.Unmapped.Users, ",") != "dave" {
+		t.Errorf("Unmapped = %+v, want only user dave", summary.Unmapped)
+	}
+	if out := captureStdout(t, func() { summary.Print("test-enterprise") }); !strings.Contains(out, "Copilot users in no mapped team (1)") {
+		t.Errorf("summary output lacks the unmapped section:\n%s", out)
+	}
+}
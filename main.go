@@ -29,14 +29,8 @@ func main() {
 		os.Exit(0)
 	}()
 
-	// SIGINT (Ctrl-C) — let the Go runtime handle cleanup, but ensure
-	// we exit with a non-zero code so callers can detect interruption.
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt)
-	go func() {
-		<-interrupt
-		os.Exit(130) // 128 + SIGINT(2) — standard Unix convention
-	}()
+	// SIGINT (Ctrl-C) is left alone: apply runs catch it to stop between
+	// batches and exit with 130, and other commands die of it as usual.
 
 	cmd.Execute()
 }
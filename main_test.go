@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestMain runs the CLI instead of the tests when GHCC_TEST_MAIN is set, with
+// the arguments after "--", so tests can signal a real process.
+func TestMain(m *testing.M) {
+	if os.Getenv("GHCC_TEST_MAIN") == "1" {
+		for i, arg := range os.Args {
+			if arg == "--" {
+				os.Args = append([]string{"gh-cost-center"}, os.Args[i+1:]...)
+				break
+			}
+		}
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// TestMain_InterruptFinishesBatch sends SIGINT to an apply run while its first
+// batch is in flight: the batch must complete, the summary must be printed,
+// and the process must exit with 130 without sending the next batch.
+func TestMain_InterruptFinishesBatch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("os.Interrupt cannot be sent to a process on Windows")
+	}
+	release := make(chan struct{})
+	inFlight := make(chan struct{}, 1)
+	var posts atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/copilot/billing/seats"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"total_seats": 2, "seats": []map[string]any{
+				{"assignee": map[string]string{"login": "alice", "type": "User"}, "created_at": "2024-01-01T00:00:00Z"},
+				{"assignee": map[string]string{"login": "bob", "type": "User"}, "created_at": "2025-06-01T00:00:00Z"},
+			}})
+		case strings.HasSuffix(r.URL.Path, "/cost-centers"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"costCenters": []map[string]string{
+				{"id": "11111111-1111-4111-8111-111111111111", "name": "No PRUs", "state": "active"},
+				{"id": "22222222-2222-4222-8222-222222222222", "name": "PRUs Allowed", "state": "active"},
+			}})
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/resource"):
+			if posts.Add(1) == 1 {
+				inFlight <- struct{}{}
+				<-release
+			}
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			id := filepath.Base(r.URL.Path)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": id, "name": id, "state": "active", "resources": []any{}})
+		}
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+	defer close(release)
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfgFile := filepath.Join(dir, "config.yaml")
+	cfg := `github:
+  enterprise: "test-ent"
+  api_base_url: "` + srv.URL + `"
+  ca_cert_path: "ca.pem"
+export_dir: "` + filepath.Join(dir, "exports") + `"
+cost_center:
+  users:
+    no_prus_cost_center_name: "No PRUs"
+    prus_allowed_cost_center_name: "PRUs Allowed"
+    exception_users: ["alice"]
+`
+	if err := os.WriteFile(cfgFile, []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(os.Args[0], "--", "assign", "--mode", "apply", "--yes", "--config", cfgFile)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GHCC_TEST_MAIN=1", "GITHUB_TOKEN=test-token")
+	var stdout strings.Builder
+	cmd.Stdout = &stdout
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Collect stderr, noting when the interrupt has been handled.
+	var logs strings.Builder
+	handled := make(chan struct{}, 1)
+	scanned := make(chan struct{})
+	go func() {
+		defer close(scanned)
+		sc := bufio.NewScanner(stderr)
+		for sc.Scan() {
+			logs.WriteString(sc.Text() + "\n")
+			if strings.Contains(sc.Text(), "Interrupted") {
+				select {
+				case handled <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	timeout := time.After(30 * time.Second)
+	select {
+	case <-inFlight:
+	case <-timeout:
+		_ = cmd.Process.Kill()
+		t.Fatal("the first batch was never sent")
+	}
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-handled:
+	case <-scanned:
+		// stderr closed: the process exited without handling the interrupt.
+	case <-timeout:
+		_ = cmd.Process.Kill()
+		t.Fatal("the interrupt was never handled")
+	}
+	release <- struct{}{}
+	<-scanned
+	err = cmd.Wait()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 130 {
+		t.Fatalf("exit = %v, want exit code 130\nstderr:\n%s", err, logs.String())
+	}
+	if !strings.Contains(stdout.String(), "SUCCESS SUMMARY") {
+		t.Errorf("stdout has no summary:\n%s\nstderr:\n%s", stdout.String(), logs.String())
+	}
+	if n := posts.Load(); n != 1 {
+		t.Errorf("sent %d batches, want only the one in flight", n)
+	}
+}